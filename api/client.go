@@ -1,6 +1,12 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/spf13/viper"
@@ -13,7 +19,28 @@ import (
 
 const clientTimeout = 15 * time.Second
 
-var jiraClient *jira.Client
+// Versions of the Jira REST api.
+const (
+	APIVersion2 = "2"
+	APIVersion3 = "3"
+)
+
+// APIVersions are the api versions an invocation can be routed to.
+var APIVersions = []string{APIVersion2, APIVersion3}
+
+// InstallationAPIVersion returns the api version of the configured installation type,
+// v2 for a local installation and v3 otherwise.
+func InstallationAPIVersion() string {
+	if viper.GetString("installation") == jira.InstallationTypeLocal {
+		return APIVersion2
+	}
+	return APIVersion3
+}
+
+var (
+	jiraClient *jira.Client
+	logger     jira.Logger
+)
 
 // Client initializes and returns jira client.
 func Client(config jira.Config) *jira.Client {
@@ -65,11 +92,60 @@ func Client(config jira.Config) *jira.Client {
 		config,
 		jira.WithTimeout(clientTimeout),
 		jira.WithInsecureTLS(*config.Insecure),
+		jira.WithProxy(jira.ResolveProxy(viper.GetString("proxy"), viper.GetString("network.proxy"), os.Getenv)),
+		jira.WithDryRun(DryRun()),
+		jira.WithRetries(viper.GetInt("network.retries")),
+		jira.WithLogger(logger),
+		jira.WithAttachmentTimeouts(jira.AttachmentTimeouts{
+			Dial:           durationConfig("network.dial_timeout"),
+			TLSHandshake:   durationConfig("network.tls_handshake_timeout"),
+			ResponseHeader: durationConfig("network.response_header_timeout"),
+		}),
 	)
 
 	return jiraClient
 }
 
+// durationConfig reads a duration from the config, eg: 10s. It is 0 if the key is not set,
+// and also if the value is invalid, after a warning.
+func durationConfig(key string) time.Duration {
+	v := viper.GetString(key)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		fmt.Fprintf(os.Stderr, "Ignoring %s: invalid duration %q, eg: 10s\n", key, v)
+		return 0
+	}
+	return d
+}
+
+// SetClient makes Client and DefaultClient return c instead of building a client from
+// the config, eg: to run commands against a fake server in tests. The returned func
+// restores the previous client.
+func SetClient(c *jira.Client) (restore func()) {
+	prev := jiraClient
+	jiraClient = c
+	return func() { jiraClient = prev }
+}
+
+// SetLogger makes the client built by Client and DefaultClient log to l, see jira.WithLogger.
+// It must be set before the client is built.
+func SetLogger(l jira.Logger) {
+	logger = l
+}
+
+// DryRun reports if requests that change data on the server are blocked for this
+// invocation, either by the --dry-run flag or the JIRA_CLI_DRY_RUN env var.
+func DryRun() bool {
+	if viper.GetBool("dry_run") {
+		return true
+	}
+	on, _ := strconv.ParseBool(os.Getenv("JIRA_CLI_DRY_RUN"))
+	return on
+}
+
 // DefaultClient returns default jira client.
 func DefaultClient(debug bool) *jira.Client {
 	return Client(jira.Config{Debug: debug})
@@ -124,6 +200,61 @@ func ProxyGetIssue(c *jira.Client, key string, opts ...filter.Filter) (*jira.Iss
 	return iss, err
 }
 
+// ProxyGetIssueFields uses either a v2 or v3 version of the Jira GET /issue/{key}
+// endpoint to fetch only the given fields of an issue based on configured installation type.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyGetIssueFields(c *jira.Client, key string, fields []string) (*jira.Issue, error) {
+	return ProxyGetIssueFieldsVersion(c, InstallationAPIVersion(), key, fields)
+}
+
+// ProxyGetIssueFieldsVersion is ProxyGetIssueFields using the given api version.
+func ProxyGetIssueFieldsVersion(c *jira.Client, version, key string, fields []string) (*jira.Issue, error) {
+	if version == APIVersion2 {
+		return c.GetIssueFieldsV2(key, fields)
+	}
+	return c.GetIssueFields(key, fields)
+}
+
+// ProxyGetIssueFieldsContext is ProxyGetIssueFields with a context, eg: to cap how long the request may take.
+func ProxyGetIssueFieldsContext(ctx context.Context, c *jira.Client, key string, fields []string) (*jira.Issue, error) {
+	return ProxyGetIssueFieldsContextVersion(ctx, c, InstallationAPIVersion(), key, fields)
+}
+
+// ProxyGetIssueFieldsContextVersion is ProxyGetIssueFieldsContext using the given api version.
+func ProxyGetIssueFieldsContextVersion(ctx context.Context, c *jira.Client, version, key string, fields []string) (*jira.Issue, error) {
+	if version == APIVersion2 {
+		return c.GetIssueFieldsV2Context(ctx, key, fields)
+	}
+	return c.GetIssueFieldsContext(ctx, key, fields)
+}
+
+// ProxyGetIssueFieldsLimitedVersion is ProxyGetIssueFieldsContextVersion enforcing the limits,
+// see jira.IssueLimits.
+func ProxyGetIssueFieldsLimitedVersion(
+	ctx context.Context, c *jira.Client, version, key string, fields []string, limits jira.IssueLimits,
+) (*jira.Issue, error) {
+	if version == APIVersion2 {
+		return c.GetIssueFieldsV2Limited(ctx, key, fields, limits)
+	}
+	return c.GetIssueFieldsLimited(ctx, key, fields, limits)
+}
+
+// ProxyGetIssueChangelog fetches the full history of an issue using either the paginated
+// v3 GET /issue/{key}/changelog endpoint, or the v2 GET /issue/{key} endpoint with the
+// changelog expanded, based on configured installation type.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyGetIssueChangelog(c *jira.Client, key string) ([]jira.Changelog, error) {
+	return ProxyGetIssueChangelogVersion(c, InstallationAPIVersion(), key)
+}
+
+// ProxyGetIssueChangelogVersion is ProxyGetIssueChangelog using the given api version.
+func ProxyGetIssueChangelogVersion(c *jira.Client, version, key string) ([]jira.Changelog, error) {
+	if version == APIVersion2 {
+		return c.GetIssueChangelogV2(key)
+	}
+	return c.GetIssueChangelog(key)
+}
+
 // ProxySearch uses either a v2 or v3 version of the Jira GET /search endpoint
 // to search for the relevant issues based on configured installation type.
 // Defaults to v3 if installation type is not defined in the config.
@@ -144,6 +275,81 @@ func ProxySearch(c *jira.Client, jql string, from, limit uint) (*jira.SearchResu
 	return issues, err
 }
 
+// ProxySearchIssueAttachments pages through the issues matching the jql with only their
+// attachments fetched, using either a v2 or v3 version of the Jira search endpoint based on
+// configured installation type, and calls fn with each page. Pages are not retained, so the
+// whole result set is never held in memory.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxySearchIssueAttachments(c *jira.Client, jql string, pageSize uint, fn func([]*jira.Issue) error) error {
+	return ProxySearchIssueAttachmentsVersion(c, InstallationAPIVersion(), jql, pageSize, fn)
+}
+
+// ProxySearchIssueAttachmentsVersion is ProxySearchIssueAttachments using the given api version.
+func ProxySearchIssueAttachmentsVersion(
+	c *jira.Client, version, jql string, pageSize uint, fn func([]*jira.Issue) error,
+) error {
+	return searchPages(version, pageSize, fn, func(from uint, token string) (*jira.SearchResult, error) {
+		if version == APIVersion2 {
+			return c.SearchIssueAttachmentsV2(jql, from, pageSize)
+		}
+		return c.SearchIssueAttachments(jql, token, pageSize)
+	})
+}
+
+// ProxySearchIssueKeys pages through the issues matching the jql with only their key fetched,
+// using either a v2 or v3 version of the Jira search endpoint based on configured installation
+// type, and calls fn with each page.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxySearchIssueKeys(c *jira.Client, jql string, pageSize uint, fn func([]*jira.Issue) error) error {
+	return ProxySearchIssueKeysVersion(c, InstallationAPIVersion(), jql, pageSize, fn)
+}
+
+// ProxySearchIssueKeysVersion is ProxySearchIssueKeys using the given api version.
+func ProxySearchIssueKeysVersion(
+	c *jira.Client, version, jql string, pageSize uint, fn func([]*jira.Issue) error,
+) error {
+	return searchPages(version, pageSize, fn, func(from uint, token string) (*jira.SearchResult, error) {
+		if version == APIVersion2 {
+			return c.SearchIssueKeysV2(jql, from, pageSize)
+		}
+		return c.SearchIssueKeys(jql, token, pageSize)
+	})
+}
+
+// searchPages calls fn with each page returned by fetch until the last one. Pages are
+// requested by offset with v2 and by the token of the previous page with v3.
+func searchPages(
+	version string, pageSize uint, fn func([]*jira.Issue) error, fetch func(from uint, token string) (*jira.SearchResult, error),
+) error {
+	local := version == APIVersion2
+
+	var (
+		from  uint
+		token string
+	)
+	for {
+		page, err := fetch(from, token)
+		if err != nil {
+			return err
+		}
+		if err := fn(page.Issues); err != nil {
+			return err
+		}
+
+		if local {
+			from += uint(len(page.Issues))
+			if len(page.Issues) < int(pageSize) {
+				return nil
+			}
+			continue
+		}
+		if page.IsLast || page.NextPageToken == "" || len(page.Issues) == 0 {
+			return nil
+		}
+		token = page.NextPageToken
+	}
+}
+
 // ProxyAssignIssue uses either a v2 or v3 version of the PUT /issue/{key}/assignee
 // endpoint to assign an issue to the user.
 // Defaults to v3 if installation type is not defined in the config.
@@ -186,6 +392,16 @@ func ProxyUserSearch(c *jira.Client, opts *jira.UserSearchOptions) ([]*jira.User
 	return users, err
 }
 
+// ProxySearchUsers uses either a v2 or v3 version of the Jira GET /user/search
+// endpoint to search for users based on configured installation type.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxySearchUsers(c *jira.Client, query string, maxResults int) ([]*jira.User, error) {
+	if viper.GetString("installation") == jira.InstallationTypeLocal {
+		return c.SearchUsersV2(query, maxResults)
+	}
+	return c.SearchUsers(query, maxResults)
+}
+
 // ProxyTransitions uses either v2 or v3 version of the GET /issue/{key}/transitions
 // endpoint to fetch valid transitions for an issue.
 // Defaults to v3 if installation type is not defined in the config.
@@ -229,26 +445,231 @@ func ProxyWatchIssue(c *jira.Client, key string, user *jira.User) error {
 	return c.WatchIssue(key, assignee)
 }
 
+// ProxyMyself uses either a v2 or v3 version of the GET /myself
+// endpoint to fetch the current user. The user is cached per process.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyMyself(c *jira.Client) (*jira.User, error) {
+	if viper.GetString("installation") == jira.InstallationTypeLocal {
+		return c.MyselfV2()
+	}
+	return c.Myself()
+}
+
+// ProxyAddIssueComment adds a comment to an issue for the configured installation type.
+func ProxyAddIssueComment(c *jira.Client, key, comment string, internal bool) error {
+	return ProxyAddIssueCommentVersion(c, InstallationAPIVersion(), key, comment, internal)
+}
+
+// ProxyAddIssueCommentVersion is ProxyAddIssueComment using the given api version. The
+// comment is posted to the v2 POST /issue/{key}/comment endpoint with either version, as
+// `jira issue comment add` does: it is served by every installation type and takes the
+// markdown of the comment converted to wiki markup, while v3 only takes a document.
+func ProxyAddIssueCommentVersion(c *jira.Client, _, key, comment string, internal bool) error {
+	return c.AddIssueComment(key, comment, internal)
+}
+
 // ProxyUploadAttachment uses either a v2 or v3 version of the POST /issue/{key}/attachments
 // endpoint to upload an attachment to an issue.
 // Defaults to v3 if installation type is not defined in the config.
-func ProxyUploadAttachment(c *jira.Client, key, filePath string) ([]jira.Attachment, error) {
-	it := viper.GetString("installation")
+func ProxyUploadAttachment(c *jira.Client, key, filePath string, opts ...jira.UploadOption) ([]jira.Attachment, error) {
+	return ProxyUploadAttachmentVersion(c, InstallationAPIVersion(), key, filePath, opts...)
+}
 
-	if it == jira.InstallationTypeLocal {
-		return c.UploadAttachmentV2(key, filePath)
+// ProxyUploadAttachmentVersion is ProxyUploadAttachment using the given api version.
+func ProxyUploadAttachmentVersion(c *jira.Client, version, key, filePath string, opts ...jira.UploadOption) ([]jira.Attachment, error) {
+	if version == APIVersion2 {
+		return c.UploadAttachmentV2(key, filePath, opts...)
+	}
+	return c.UploadAttachment(key, filePath, opts...)
+}
+
+// ProxyUploadAttachmentAs uses either a v2 or v3 version of the POST /issue/{key}/attachments
+// endpoint to upload an attachment with the given name to an issue.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyUploadAttachmentAs(c *jira.Client, key, filePath, name string, opts ...jira.UploadOption) ([]jira.Attachment, error) {
+	return ProxyUploadAttachmentAsVersion(c, InstallationAPIVersion(), key, filePath, name, opts...)
+}
+
+// ProxyUploadAttachmentAsVersion is ProxyUploadAttachmentAs using the given api version.
+func ProxyUploadAttachmentAsVersion(
+	c *jira.Client, version, key, filePath, name string, opts ...jira.UploadOption,
+) ([]jira.Attachment, error) {
+	if version == APIVersion2 {
+		return c.UploadAttachmentAsV2(key, filePath, name, opts...)
+	}
+	return c.UploadAttachmentAs(key, filePath, name, opts...)
+}
+
+// ProxyUploadAttachmentFromReaderVersion uses either a v2 or v3 version of the POST
+// /issue/{key}/attachments endpoint to upload the content read from r with the given name.
+func ProxyUploadAttachmentFromReaderVersion(
+	c *jira.Client, version, key, name string, r io.Reader, opts ...jira.UploadOption,
+) ([]jira.Attachment, error) {
+	if version == APIVersion2 {
+		return c.UploadAttachmentFromReaderV2(key, name, r, opts...)
+	}
+	return c.UploadAttachmentFromReader(key, name, r, opts...)
+}
+
+// ProxyUploadAttachmentChunked uploads an attachment in chunks using the media API of Jira cloud.
+// It falls back to the regular multipart upload if the media endpoints are unavailable.
+func ProxyUploadAttachmentChunked(c *jira.Client, key, filePath string, opts jira.ChunkedUploadOptions) ([]jira.Attachment, error) {
+	return ProxyUploadAttachmentChunkedVersion(c, InstallationAPIVersion(), key, filePath, opts)
+}
+
+// ProxyUploadAttachmentChunkedVersion is ProxyUploadAttachmentChunked using the given api version.
+// Chunked uploads are only supported with v3.
+func ProxyUploadAttachmentChunkedVersion(
+	c *jira.Client, version, key, filePath string, opts jira.ChunkedUploadOptions,
+) ([]jira.Attachment, error) {
+	if version == APIVersion2 {
+		return nil, jira.ErrChunkedUploadUnsupported
+	}
+
+	attachments, err := c.UploadAttachmentChunked(key, filePath, opts)
+	if errors.Is(err, jira.ErrMediaAPIUnavailable) {
+		return c.UploadAttachment(key, filePath)
+	}
+	return attachments, err
+}
+
+// ProxyDownloadAttachment downloads an attachment to destPath from the content URL of either
+// a v2 or v3 installation based on configured installation type, see
+// jira.Client.AttachmentContentURLV2 for the differences of Jira server and data center.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyDownloadAttachment(c *jira.Client, a jira.Attachment, destPath string, opts ...jira.DownloadOption) (*jira.DownloadResult, error) {
+	return ProxyDownloadAttachmentVersion(c, InstallationAPIVersion(), a, destPath, opts...)
+}
+
+// ProxyDownloadAttachmentVersion is ProxyDownloadAttachment using the given api version.
+func ProxyDownloadAttachmentVersion(
+	c *jira.Client, version string, a jira.Attachment, destPath string, opts ...jira.DownloadOption,
+) (*jira.DownloadResult, error) {
+	return ProxyDownloadAttachmentContextVersion(context.Background(), c, version, a, destPath, opts...)
+}
+
+// ProxyDownloadAttachmentContextVersion is ProxyDownloadAttachmentVersion with a context, the
+// partially written file is removed if it is cancelled.
+func ProxyDownloadAttachmentContextVersion(
+	ctx context.Context, c *jira.Client, version string, a jira.Attachment, destPath string, opts ...jira.DownloadOption,
+) (*jira.DownloadResult, error) {
+	return c.DownloadAttachmentWithResultContext(ctx, attachmentContentURL(c, version, a), destPath, opts...)
+}
+
+// ProxyDownloadAttachmentTo is ProxyDownloadAttachment streaming the attachment to w.
+func ProxyDownloadAttachmentTo(c *jira.Client, a jira.Attachment, w io.Writer, opts ...jira.DownloadOption) (*jira.DownloadResult, error) {
+	return ProxyDownloadAttachmentToVersion(c, InstallationAPIVersion(), a, w, opts...)
+}
+
+// ProxyDownloadAttachmentToVersion is ProxyDownloadAttachmentTo using the given api version.
+func ProxyDownloadAttachmentToVersion(
+	c *jira.Client, version string, a jira.Attachment, w io.Writer, opts ...jira.DownloadOption,
+) (*jira.DownloadResult, error) {
+	return ProxyDownloadAttachmentToContextVersion(context.Background(), c, version, a, w, opts...)
+}
+
+// ProxyDownloadAttachmentToContextVersion is ProxyDownloadAttachmentToVersion with a context.
+func ProxyDownloadAttachmentToContextVersion(
+	ctx context.Context, c *jira.Client, version string, a jira.Attachment, w io.Writer, opts ...jira.DownloadOption,
+) (*jira.DownloadResult, error) {
+	return c.DownloadAttachmentToContext(ctx, attachmentContentURL(c, version, a), w, opts...)
+}
+
+// ProxyProbeAttachmentVersion checks that the content of the attachment can be downloaded using
+// the content URL of the given api version, see jira.Client.ProbeAttachment.
+func ProxyProbeAttachmentVersion(ctx context.Context, c *jira.Client, version string, a jira.Attachment, timeout time.Duration) jira.LinkProbe {
+	return c.ProbeAttachment(ctx, attachmentContentURL(c, version, a), timeout)
+}
+
+func attachmentContentURL(c *jira.Client, version string, a jira.Attachment) string {
+	if version == APIVersion2 {
+		return c.AttachmentContentURLV2(a)
 	}
-	return c.UploadAttachment(key, filePath)
+	return c.AttachmentContentURL(a)
 }
 
 // ProxyDeleteAttachment uses either a v2 or v3 version of the DELETE /attachment/{id}
 // endpoint to delete an attachment.
 // Defaults to v3 if installation type is not defined in the config.
 func ProxyDeleteAttachment(c *jira.Client, attachmentID string) error {
-	it := viper.GetString("installation")
+	return ProxyDeleteAttachmentVersion(c, InstallationAPIVersion(), attachmentID)
+}
 
-	if it == jira.InstallationTypeLocal {
+// ProxyDeleteAttachmentVersion is ProxyDeleteAttachment using the given api version.
+func ProxyDeleteAttachmentVersion(c *jira.Client, version, attachmentID string) error {
+	if version == APIVersion2 {
 		return c.DeleteAttachmentV2(attachmentID)
 	}
 	return c.DeleteAttachment(attachmentID)
 }
+
+// ProxyGetAttachment uses either a v2 or v3 version of the GET /attachment/{id}
+// endpoint to fetch the metadata of an attachment based on configured installation type.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyGetAttachment(c *jira.Client, attachmentID string) (*jira.Attachment, error) {
+	return ProxyGetAttachmentVersion(c, InstallationAPIVersion(), attachmentID)
+}
+
+// ProxyGetAttachmentVersion is ProxyGetAttachment using the given api version.
+func ProxyGetAttachmentVersion(c *jira.Client, version, attachmentID string) (*jira.Attachment, error) {
+	if version == APIVersion2 {
+		return c.GetAttachmentV2(attachmentID)
+	}
+	return c.GetAttachment(attachmentID)
+}
+
+// ProxyGetAttachmentMeta uses either a v2 or v3 version of the GET /attachment/meta
+// endpoint to fetch the attachment settings based on configured installation type.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyGetAttachmentMeta(c *jira.Client) (*jira.AttachmentMeta, error) {
+	return ProxyGetAttachmentMetaVersion(c, InstallationAPIVersion())
+}
+
+// ProxyGetAttachmentMetaVersion is ProxyGetAttachmentMeta using the given api version.
+func ProxyGetAttachmentMetaVersion(c *jira.Client, version string) (*jira.AttachmentMeta, error) {
+	if version == APIVersion2 {
+		return c.GetAttachmentMetaV2()
+	}
+	return c.GetAttachmentMeta()
+}
+
+// ProxyMyPermissions uses either a v2 or v3 version of the GET /mypermissions endpoint
+// to fetch the permissions of the current user based on configured installation type.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyMyPermissions(c *jira.Client, opts jira.MyPermissionsOptions) (map[string]jira.Permission, error) {
+	return ProxyMyPermissionsVersion(c, InstallationAPIVersion(), opts)
+}
+
+// ProxyMyPermissionsVersion is ProxyMyPermissions using the given api version.
+func ProxyMyPermissionsVersion(c *jira.Client, version string, opts jira.MyPermissionsOptions) (map[string]jira.Permission, error) {
+	if version == APIVersion2 {
+		return c.MyPermissionsV2(opts)
+	}
+	return c.MyPermissions(opts)
+}
+
+// ProxyDeleteAttachmentWithOptions is ProxyDeleteAttachment with preconditions checked
+// against the attachment metadata before it is deleted.
+func ProxyDeleteAttachmentWithOptions(c *jira.Client, attachmentID string, opts jira.DeleteAttachmentOptions) error {
+	return ProxyDeleteAttachmentWithOptionsVersion(c, InstallationAPIVersion(), attachmentID, opts)
+}
+
+// ProxyDeleteAttachmentWithOptionsVersion is ProxyDeleteAttachmentWithOptions using the given api version.
+func ProxyDeleteAttachmentWithOptionsVersion(
+	c *jira.Client, version, attachmentID string, opts jira.DeleteAttachmentOptions,
+) error {
+	if version == APIVersion2 {
+		return c.DeleteAttachmentWithOptionsV2(attachmentID, opts)
+	}
+	return c.DeleteAttachmentWithOptions(attachmentID, opts)
+}
+
+// ProxyCheckToken uses either a v2 or v3 version of the GET /myself
+// endpoint to check the configured token.
+// Defaults to v3 if installation type is not defined in the config.
+func ProxyCheckToken(c *jira.Client) (*jira.TokenStatus, error) {
+	if viper.GetString("installation") == jira.InstallationTypeLocal {
+		return c.CheckTokenV2()
+	}
+	return c.CheckToken()
+}
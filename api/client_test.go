@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -135,3 +136,264 @@ func TestProxyDeleteAttachment(t *testing.T) {
 		})
 	}
 }
+
+func TestProxyGetAttachmentVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		version          string
+		expectedEndpoint string
+	}{
+		{version: APIVersion3, expectedEndpoint: "/rest/api/3/attachment/10001"},
+		{version: APIVersion2, expectedEndpoint: "/rest/api/2/attachment/10001"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.version, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.expectedEndpoint, r.URL.Path)
+				assert.Equal(t, "GET", r.Method)
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id":"10001","filename":"report.pdf","size":10,"mimeType":"application/pdf"}`))
+			}))
+			defer server.Close()
+
+			client := jira.NewClient(jira.Config{
+				Server:   server.URL,
+				Login:    "test",
+				APIToken: "token",
+			}, jira.WithTimeout(3*time.Second))
+
+			a, err := ProxyGetAttachmentVersion(client, tc.version, "10001")
+			assert.NoError(t, err)
+			assert.Equal(t, "report.pdf", a.Filename)
+			assert.Equal(t, int64(10), a.Size)
+		})
+	}
+}
+
+// assertFieldsParam checks the fields selection received by the mock server.
+func assertFieldsParam(t *testing.T, r *http.Request, want ...string) {
+	t.Helper()
+
+	got := r.URL.Query().Get("fields")
+	assert.Equal(t, strings.Join(want, ","), got, "unexpected fields selection")
+}
+
+func TestProxyDownloadAttachment(t *testing.T) {
+	// Not parallel, the installation is read from the global config.
+	tests := []struct {
+		name         string
+		installation string
+		// content returns the content link sent by the server.
+		content          func(server string) string
+		expectedEndpoint string
+	}{
+		{
+			name:             "cloud installation",
+			installation:     jira.InstallationTypeCloud,
+			content:          func(server string) string { return server + "/rest/api/3/attachment/content/10001" },
+			expectedEndpoint: "/rest/api/3/attachment/content/10001",
+		},
+		{
+			name:         "local installation behind a reverse proxy",
+			installation: jira.InstallationTypeLocal,
+			content: func(string) string {
+				return "http://jira.internal:8080/secure/attachment/10001/test%20file.txt"
+			},
+			expectedEndpoint: "/secure/attachment/10001/test file.txt",
+		},
+		{
+			name:             "local installation",
+			installation:     jira.InstallationTypeLocal,
+			content:          func(server string) string { return server + "/secure/attachment/10001/test.txt" },
+			expectedEndpoint: "/secure/attachment/10001/test.txt",
+		},
+		{
+			name:             "default to cloud",
+			installation:     "",
+			content:          func(server string) string { return server + "/rest/api/3/attachment/content/10001" },
+			expectedEndpoint: "/rest/api/3/attachment/content/10001",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.expectedEndpoint, r.URL.Path)
+				assert.Equal(t, "GET", r.Method)
+				user, pass, ok := r.BasicAuth()
+				assert.True(t, ok, "credentials are sent")
+				assert.Equal(t, "test", user)
+				assert.Equal(t, "token", pass)
+
+				w.Header().Set("Content-Type", "text/plain")
+				_, _ = w.Write([]byte("test content"))
+			}))
+			defer server.Close()
+
+			client := jira.NewClient(jira.Config{
+				Server:   server.URL,
+				Login:    "test",
+				APIToken: "token",
+			}, jira.WithTimeout(3*time.Second))
+
+			prev := viper.GetString("installation")
+			viper.Set("installation", tc.installation)
+			defer viper.Set("installation", prev)
+
+			a := jira.Attachment{ID: "10001", Filename: "test file.txt", Content: tc.content(server.URL)}
+			dest := filepath.Join(t.TempDir(), "test.txt")
+
+			res, err := ProxyDownloadAttachment(client, a, dest)
+			assert.NoError(t, err)
+			assert.EqualValues(t, 12, res.Bytes)
+
+			got, err := os.ReadFile(dest)
+			assert.NoError(t, err)
+			assert.Equal(t, "test content", string(got))
+
+			var buf strings.Builder
+			_, err = ProxyDownloadAttachmentTo(client, a, &buf)
+			assert.NoError(t, err)
+			assert.Equal(t, "test content", buf.String())
+		})
+	}
+}
+
+func TestProxyDownloadAttachmentUnavailable(t *testing.T) {
+	t.Parallel()
+
+	client := jira.NewClient(jira.Config{Server: "http://jira.invalid"})
+	for _, version := range APIVersions {
+		_, err := ProxyDownloadAttachmentVersion(client, version, jira.Attachment{ID: "10001"}, filepath.Join(t.TempDir(), "a.txt"))
+		assert.ErrorIs(t, err, jira.ErrAttachmentUnavailable, version)
+	}
+}
+
+func TestProxyGetIssueFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		installation     string
+		expectedEndpoint string
+	}{
+		{
+			name:             "cloud installation",
+			installation:     jira.InstallationTypeCloud,
+			expectedEndpoint: "/rest/api/3/issue/TEST-1",
+		},
+		{
+			name:             "local installation",
+			installation:     jira.InstallationTypeLocal,
+			expectedEndpoint: "/rest/api/2/issue/TEST-1",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.expectedEndpoint, r.URL.Path)
+				assertFieldsParam(t, r, "attachment", "summary")
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(200)
+				_, _ = w.Write([]byte(`{"key": "TEST-1", "fields": {"summary": "Summary", "attachment": [{"id": "10001"}]}}`))
+			}))
+			defer server.Close()
+
+			client := jira.NewClient(jira.Config{
+				Server:   server.URL,
+				Login:    "test",
+				APIToken: "token",
+			}, jira.WithTimeout(3*time.Second))
+
+			viper.Set("installation", tc.installation)
+
+			iss, err := ProxyGetIssueFields(client, "TEST-1", []string{"attachment", "summary"})
+			assert.NoError(t, err)
+			assert.Equal(t, "Summary", iss.Fields.Summary)
+			assert.Len(t, iss.Fields.Attachments, 1)
+		})
+	}
+}
+
+func TestProxyAttachmentVersionIgnoresInstallation(t *testing.T) {
+	tests := []struct {
+		name         string
+		installation string
+		version      string
+		prefix       string
+	}{
+		{name: "v2 on cloud", installation: jira.InstallationTypeCloud, version: APIVersion2, prefix: "/rest/api/2"},
+		{name: "v3 on local", installation: jira.InstallationTypeLocal, version: APIVersion3, prefix: "/rest/api/3"},
+	}
+
+	prev := viper.GetString("installation")
+	defer viper.Set("installation", prev)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.Method+" "+r.URL.Path)
+
+				w.Header().Set("Content-Type", "application/json")
+				switch r.Method {
+				case http.MethodDelete:
+					w.WriteHeader(204)
+				case http.MethodPost:
+					_, _ = w.Write([]byte(`[{"id": "10001", "filename": "test.txt"}]`))
+				default:
+					_, _ = w.Write([]byte(`{"key": "TEST-1", "fields": {"attachment": [{"id": "10001"}]}}`))
+				}
+			}))
+			defer server.Close()
+
+			client := jira.NewClient(jira.Config{
+				Server:   server.URL,
+				Login:    "test",
+				APIToken: "token",
+			}, jira.WithTimeout(3*time.Second))
+
+			viper.Set("installation", tc.installation)
+
+			testFile := filepath.Join(t.TempDir(), "test.txt")
+			assert.NoError(t, os.WriteFile(testFile, []byte("test content"), 0o644))
+
+			_, err := ProxyGetIssueFieldsVersion(client, tc.version, "TEST-1", []string{"attachment"})
+			assert.NoError(t, err)
+			_, err = ProxyUploadAttachmentVersion(client, tc.version, "TEST-1", testFile)
+			assert.NoError(t, err)
+			assert.NoError(t, ProxyDeleteAttachmentVersion(client, tc.version, "10001"))
+
+			assert.Equal(t, []string{
+				"GET " + tc.prefix + "/issue/TEST-1",
+				"POST " + tc.prefix + "/issue/TEST-1/attachments",
+				"DELETE " + tc.prefix + "/attachment/10001",
+			}, paths)
+		})
+	}
+}
+
+func TestInstallationAPIVersion(t *testing.T) {
+	prev := viper.GetString("installation")
+	defer viper.Set("installation", prev)
+
+	for installation, want := range map[string]string{
+		jira.InstallationTypeLocal: APIVersion2,
+		jira.InstallationTypeCloud: APIVersion3,
+		"":                         APIVersion3,
+	} {
+		viper.Set("installation", installation)
+		assert.Equal(t, want, InstallationAPIVersion(), installation)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// profilesKey is the config key holding the named profiles, eg:
+//
+//	profiles:
+//	  customer-jira:
+//	    server: https://jira.customer.com
+//	    login: me@example.com
+//	    installation: Local
+var profilesKey = "profiles"
+
+var (
+	activeProfile string
+	// profileRestore holds the config values overridden by the active profile.
+	profileRestore map[string]any
+)
+
+// ErrUnknownProfile is returned when a profile isn't defined in the config.
+type ErrUnknownProfile struct {
+	Name      string
+	Available []string
+}
+
+func (e *ErrUnknownProfile) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("unknown profile %q, no profiles are configured", e.Name)
+	}
+	return fmt.Sprintf("unknown profile %q, available profiles: %s", e.Name, strings.Join(e.Available, ", "))
+}
+
+// Profiles returns the names of the profiles defined in the config, sorted.
+func Profiles() []string {
+	names := make([]string, 0)
+	for name := range viper.GetStringMap(profilesKey) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseProfile selects a named profile of the config. Its keys, eg: server, login, api_token
+// and installation, override the top-level keys of the same name for this invocation. The
+// client is built again on the next call to Client so that it uses the profile.
+func UseProfile(name string) error {
+	ClearProfile()
+
+	sub := viper.Sub(profilesKey + "." + strings.ToLower(name))
+	if name == "" || strings.Contains(name, ".") || sub == nil {
+		return &ErrUnknownProfile{Name: name, Available: Profiles()}
+	}
+
+	profileRestore = make(map[string]any)
+	for _, key := range sub.AllKeys() {
+		if key == profilesKey || strings.HasPrefix(key, profilesKey+".") {
+			continue
+		}
+		profileRestore[key] = viper.Get(key)
+		viper.Set(key, sub.Get(key))
+	}
+	activeProfile = name
+	jiraClient = nil
+
+	return nil
+}
+
+// ClearProfile restores the config overridden by the active profile, if any.
+func ClearProfile() {
+	if activeProfile == "" {
+		return
+	}
+	for key, val := range profileRestore {
+		viper.Set(key, val)
+	}
+	activeProfile, profileRestore = "", nil
+	jiraClient = nil
+}
+
+// ActiveProfile returns the name of the profile selected with UseProfile, "" if none.
+func ActiveProfile() string {
+	return activeProfile
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func TestUseProfile(t *testing.T) {
+	prev := map[string]any{
+		"profiles":     viper.Get("profiles"),
+		"server":       viper.Get("server"),
+		"login":        viper.Get("login"),
+		"installation": viper.Get("installation"),
+	}
+	t.Cleanup(func() {
+		ClearProfile()
+		for k, v := range prev {
+			viper.Set(k, v)
+		}
+	})
+
+	viper.Set("server", "https://company.atlassian.net")
+	viper.Set("login", "me@company.com")
+	viper.Set("installation", jira.InstallationTypeCloud)
+	viper.Set("profiles", map[string]any{
+		"customer-jira": map[string]any{
+			"server":       "https://jira.customer.com",
+			"installation": jira.InstallationTypeLocal,
+			"mtls":         map[string]any{"ca_cert": "/etc/customer.pem"},
+		},
+		"staging": map[string]any{"server": "https://staging.atlassian.net"},
+	})
+
+	assert.Equal(t, []string{"customer-jira", "staging"}, Profiles())
+
+	assert.NoError(t, UseProfile("customer-jira"))
+	assert.Equal(t, "customer-jira", ActiveProfile())
+	assert.Equal(t, "https://jira.customer.com", viper.GetString("server"))
+	assert.Equal(t, "/etc/customer.pem", viper.GetString("mtls.ca_cert"))
+	assert.Equal(t, APIVersion2, InstallationAPIVersion())
+	// Keys the profile doesn't set are the top-level ones.
+	assert.Equal(t, "me@company.com", viper.GetString("login"))
+
+	// Selecting another profile drops the keys of the previous one.
+	assert.NoError(t, UseProfile("staging"))
+	assert.Equal(t, "https://staging.atlassian.net", viper.GetString("server"))
+	assert.Equal(t, APIVersion3, InstallationAPIVersion())
+
+	ClearProfile()
+	assert.Empty(t, ActiveProfile())
+	assert.Equal(t, "https://company.atlassian.net", viper.GetString("server"))
+}
+
+func TestUseProfileUnknown(t *testing.T) {
+	prev := viper.Get("profiles")
+	t.Cleanup(func() { viper.Set("profiles", prev) })
+
+	viper.Set("profiles", map[string]any{"b": map[string]any{}, "a": map[string]any{"server": "x"}})
+	err := UseProfile("c")
+	assert.EqualError(t, err, `unknown profile "c", available profiles: a, b`)
+	assert.Empty(t, ActiveProfile())
+
+	viper.Set("profiles", nil)
+	assert.EqualError(t, UseProfile("a"), `unknown profile "a", no profiles are configured`)
+}
@@ -0,0 +1,58 @@
+// Package audit appends security relevant actions of the CLI to a log file, one JSON
+// object per line, eg: for a log shipper to collect.
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// fileMode keeps the log private to the user running the CLI.
+const fileMode os.FileMode = 0o600
+
+// Event is an entry of the log.
+type Event struct {
+	Time   time.Time         `json:"time"`
+	Action string            `json:"action"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Logger appends events to a file. A nil Logger, the one of a disabled log, discards them.
+type Logger struct {
+	path string
+	now  func() time.Time
+}
+
+// New returns a logger appending to the file at path, which is created if needed.
+func New(path string) *Logger {
+	return &Logger{path: path, now: time.Now}
+}
+
+// Enabled reports if the events are logged.
+func (l *Logger) Enabled() bool {
+	return l != nil
+}
+
+// Record appends an event with the given action and fields to the log. The line is
+// written with a single write so that the entries of concurrent runs don't interleave.
+func (l *Logger) Record(action string, fields map[string]string) error {
+	if l == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(Event{Time: l.now().UTC(), Action: action, Fields: fields})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
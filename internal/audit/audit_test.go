@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := New(path)
+	l.now = func() time.Time { return time.Date(2024, 2, 29, 11, 30, 0, 0, time.FixedZone("NPT", 20700)) }
+
+	require.NoError(t, l.Record("first", map[string]string{"issue": "TEST-1"}))
+	require.NoError(t, l.Record("second", nil))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	require.Len(t, lines, 2, "events are appended")
+	assert.Equal(t, `{"time":"2024-02-29T05:45:00Z","action":"first","fields":{"issue":"TEST-1"}}`, lines[0])
+
+	var e Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, "second", e.Action)
+	assert.Empty(t, e.Fields)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, fileMode, info.Mode().Perm())
+	}
+}
+
+func TestRecordDisabled(t *testing.T) {
+	t.Parallel()
+
+	var l *Logger
+	assert.False(t, l.Enabled())
+	assert.NoError(t, l.Record("ignored", nil))
+	assert.True(t, New("audit.log").Enabled())
+}
+
+func TestRecordUnwritable(t *testing.T) {
+	t.Parallel()
+
+	l := New(filepath.Join(t.TempDir(), "missing", "audit.log"))
+	assert.ErrorIs(t, l.Record("lost", nil), os.ErrNotExist)
+}
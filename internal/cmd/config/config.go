@@ -0,0 +1,36 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/config/template"
+)
+
+const helpText = `Config manages settings stored in the config file. See available commands below.`
+
+// NewCmdConfig is a config command.
+func NewCmdConfig() *cobra.Command {
+	cmd := cobra.Command{
+		Use:         "config",
+		Short:       "Config manages settings stored in the config file",
+		Long:        helpText,
+		Annotations: map[string]string{"cmd:main": "true"},
+		RunE:        help,
+	}
+
+	attachment := cobra.Command{
+		Use:     "attachment",
+		Short:   "Manage attachment settings",
+		Long:    "Attachment manages the attachment settings stored in the config file, eg: upload templates.",
+		Aliases: []string{"attachments"},
+		RunE:    help,
+	}
+	attachment.AddCommand(template.NewCmdTemplate())
+	cmd.AddCommand(&attachment)
+
+	return &cmd
+}
+
+func help(cmd *cobra.Command, _ []string) error {
+	return cmd.Help()
+}
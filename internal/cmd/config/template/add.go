@@ -0,0 +1,79 @@
+package template
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	jiraConfig "github.com/ankitpokhrel/jira-cli/internal/config"
+)
+
+const addExamples = `$ jira config attachment template add qa-report \
+    --file 'reports/test-report.html' --file 'reports/coverage.xml' --file 'artifacts/screenshots.zip' \
+    --comment 'QA artifacts for {{.IssueKey}}'
+
+# Use env vars and the issue key in paths
+$ jira config attachment template add logs --file '$CI_PROJECT_DIR/logs/{{.IssueKey}}.log'
+
+# Replace an existing template
+$ jira config attachment template add qa-report --file report.pdf --force`
+
+func newCmdAdd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "add NAME",
+		Short:   "Add an upload template",
+		Long:    "Add saves a named set of files, and an optional comment, to upload with attachment add --template.",
+		Example: addExamples,
+		Annotations: map[string]string{
+			"help:args": "NAME\tName of the template, eg: qa-report",
+		},
+		Args:          cobra.ExactArgs(1),
+		RunE:          add,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringArray("file", nil, "Path of a file to upload, repeat the flag for several files")
+	cmd.Flags().String("comment", "", "Comment to add to the issue once the files are uploaded")
+	cmd.Flags().Bool("force", false, "Replace the template if it already exists")
+
+	return &cmd
+}
+
+func add(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := jiraConfig.ValidTemplateName(name); err != nil {
+		return cmdutil.Errorf("%s", err)
+	}
+
+	files, err := cmd.Flags().GetStringArray("file")
+	if err != nil {
+		return err
+	}
+	comment, err := cmd.Flags().GetString("comment")
+	if err != nil {
+		return err
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+
+	tmpl := jiraConfig.AttachmentTemplate{Files: files, Comment: comment}
+	if err := tmpl.Validate(); err != nil {
+		return cmdutil.Errorf("Invalid template %q: %s", name, err)
+	}
+
+	templates, path, err := load()
+	if err != nil {
+		return err
+	}
+	if _, ok := templates[name]; ok && !force {
+		return cmdutil.Errorf("Template %q already exists, use --force to replace it", name)
+	}
+
+	if err := jiraConfig.SaveAttachmentTemplate(path, name, tmpl); err != nil {
+		return cmdutil.Errorf("Unable to save template %q: %s", name, err)
+	}
+	cmdutil.Success("Saved template %q with %d file(s) to %s", name, len(files), path)
+	return nil
+}
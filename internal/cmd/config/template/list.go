@@ -0,0 +1,44 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	jiraConfig "github.com/ankitpokhrel/jira-cli/internal/config"
+)
+
+func newCmdList() *cobra.Command {
+	return &cobra.Command{
+		Use:           "list",
+		Short:         "List upload templates",
+		Long:          "List lists the upload templates of the config file.",
+		Aliases:       []string{"ls"},
+		Args:          cobra.NoArgs,
+		RunE:          list,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+}
+
+func list(cmd *cobra.Command, _ []string) error {
+	templates, _, err := load()
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		cmdutil.Warn("No templates found, add one with: jira config attachment template add NAME --file FILE")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tFILES\tCOMMENT")
+	for _, name := range jiraConfig.TemplateNames(templates) {
+		t := templates[name]
+		fmt.Fprintf(w, "%s\t%d\t%s\n", name, len(t.Files), strings.ReplaceAll(t.Comment, "\n", " "))
+	}
+	return w.Flush()
+}
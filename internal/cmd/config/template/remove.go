@@ -0,0 +1,42 @@
+package template
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	jiraConfig "github.com/ankitpokhrel/jira-cli/internal/config"
+)
+
+func newCmdRemove() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove NAME",
+		Short:   "Remove an upload template",
+		Long:    "Remove deletes an upload template from the config file.",
+		Aliases: []string{"rm", "delete"},
+		Annotations: map[string]string{
+			"help:args": "NAME\tName of the template, eg: qa-report",
+		},
+		Args:          cobra.ExactArgs(1),
+		RunE:          remove,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+}
+
+func remove(_ *cobra.Command, args []string) error {
+	path, err := configFile()
+	if err != nil {
+		return err
+	}
+	err = jiraConfig.RemoveAttachmentTemplate(path, args[0])
+	if errors.Is(err, jiraConfig.ErrTemplateNotFound) {
+		return cmdutil.Errorf("Template %q not found", args[0])
+	}
+	if err != nil {
+		return cmdutil.Errorf("Unable to remove template %q: %s", args[0], err)
+	}
+	cmdutil.Success("Removed template %q from %s", args[0], path)
+	return nil
+}
@@ -0,0 +1,49 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+func newCmdShow() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show NAME",
+		Short: "Show an upload template",
+		Long:  "Show prints the files and the comment of an upload template, before the variables are expanded.",
+		Annotations: map[string]string{
+			"help:args": "NAME\tName of the template, eg: qa-report",
+		},
+		Args:          cobra.ExactArgs(1),
+		RunE:          show,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+}
+
+func show(cmd *cobra.Command, args []string) error {
+	templates, _, err := load()
+	if err != nil {
+		return err
+	}
+	t, ok := templates[args[0]]
+	if !ok {
+		return cmdutil.Errorf("Template %q not found", args[0])
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Name:    %s\n", args[0])
+	for i, f := range t.Files {
+		label := "Files:  "
+		if i > 0 {
+			label = "        "
+		}
+		fmt.Fprintf(out, "%s %s\n", label, f)
+	}
+	if t.Comment != "" {
+		fmt.Fprintf(out, "Comment: %s\n", t.Comment)
+	}
+	return nil
+}
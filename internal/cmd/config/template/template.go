@@ -0,0 +1,66 @@
+package template
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	jiraConfig "github.com/ankitpokhrel/jira-cli/internal/config"
+)
+
+const helpText = `Template manages the upload templates of attachment add --template, ie: named sets of
+files uploaded together along with a comment.
+
+The paths and the comment may use the {{.IssueKey}} variable, the paths env vars as well, eg:
+$CI_PROJECT_DIR. Templates are stored under attachment.templates in the config file.`
+
+// NewCmdTemplate is a template command.
+func NewCmdTemplate() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "template",
+		Short:   "Manage attachment upload templates",
+		Long:    helpText,
+		Aliases: []string{"templates"},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(
+		newCmdAdd(),
+		newCmdList(),
+		newCmdShow(),
+		newCmdRemove(),
+	)
+
+	return &cmd
+}
+
+// configFile returns the path of the config file the templates are stored in, the one given
+// with --config if set, else the one that was loaded.
+func configFile() (string, error) {
+	path := viper.GetString("config")
+	if path == "" {
+		path = viper.ConfigFileUsed()
+	}
+	if path == "" || !jiraConfig.Exists(path) {
+		return "", cmdutil.Errorf("Missing configuration file.\nRun 'jira init' to configure the tool.")
+	}
+	return path, nil
+}
+
+// load returns the templates of the config file and its path.
+func load() (map[string]jiraConfig.AttachmentTemplate, string, error) {
+	path, err := configFile()
+	if err != nil {
+		return nil, "", err
+	}
+	templates, err := jiraConfig.AttachmentTemplates(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, "", cmdutil.Errorf("Unable to read the templates of %s: %s", path, err)
+	}
+	return templates, path, nil
+}
@@ -0,0 +1,94 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	jiraConfig "github.com/ankitpokhrel/jira-cli/internal/config"
+)
+
+func configEnv(t *testing.T) (cmdtest.Env, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("server: https://jira.example.com\n"), 0o600))
+	return cmdtest.Env{Config: map[string]any{"config": path}}, path
+}
+
+func TestTemplateCRUD(t *testing.T) {
+	env, path := configEnv(t)
+
+	res := cmdtest.Run(t, env, NewCmdTemplate(), "add", "qa-report",
+		"--file", "reports/test-report.html", "--file", "reports/coverage.xml", "--file", "artifacts/screenshots.zip",
+		"--comment", "QA artifacts for {{.IssueKey}}")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, `Saved template "qa-report" with 3 file(s)`)
+
+	templates, err := jiraConfig.AttachmentTemplates(path)
+	require.NoError(t, err)
+	assert.Equal(t, jiraConfig.AttachmentTemplate{
+		Files:   []string{"reports/test-report.html", "reports/coverage.xml", "artifacts/screenshots.zip"},
+		Comment: "QA artifacts for {{.IssueKey}}",
+	}, templates["qa-report"])
+
+	res = cmdtest.Run(t, env, NewCmdTemplate(), "add", "qa-report", "--file", "other.txt")
+	assert.EqualError(t, res.Err, `Template "qa-report" already exists, use --force to replace it`)
+
+	res = cmdtest.Run(t, env, NewCmdTemplate(), "add", "logs", "--file", "$CI_PROJECT_DIR/app.log")
+	require.NoError(t, res.Err)
+
+	res = cmdtest.Run(t, env, NewCmdTemplate(), "list")
+	require.NoError(t, res.Err)
+	assert.Equal(t, "NAME       FILES  COMMENT\nlogs       1      \nqa-report  3      QA artifacts for {{.IssueKey}}\n", res.Stdout)
+
+	res = cmdtest.Run(t, env, NewCmdTemplate(), "show", "qa-report")
+	require.NoError(t, res.Err)
+	assert.Equal(t, "Name:    qa-report\n"+
+		"Files:   reports/test-report.html\n"+
+		"         reports/coverage.xml\n"+
+		"         artifacts/screenshots.zip\n"+
+		"Comment: QA artifacts for {{.IssueKey}}\n", res.Stdout)
+
+	res = cmdtest.Run(t, env, NewCmdTemplate(), "remove", "qa-report")
+	require.NoError(t, res.Err)
+	res = cmdtest.Run(t, env, NewCmdTemplate(), "show", "qa-report")
+	assert.EqualError(t, res.Err, `Template "qa-report" not found`)
+	res = cmdtest.Run(t, env, NewCmdTemplate(), "remove", "qa-report")
+	assert.EqualError(t, res.Err, `Template "qa-report" not found`)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "server: https://jira.example.com")
+}
+
+func TestTemplateAddInvalid(t *testing.T) {
+	env, _ := configEnv(t)
+
+	cases := []struct {
+		args []string
+		err  string
+	}{
+		{args: []string{"add", "QA Report", "--file", "a.txt"}, err: `invalid template name "QA Report"`},
+		{args: []string{"add", "qa"}, err: `Invalid template "qa": at least one file is required`},
+		{args: []string{"add", "qa", "--file", "{{.IssueKey"}, err: `Invalid template "qa"`},
+	}
+	for _, tc := range cases {
+		res := cmdtest.Run(t, env, NewCmdTemplate(), tc.args...)
+		require.Error(t, res.Err)
+		assert.Contains(t, res.Err.Error(), tc.err)
+	}
+}
+
+func TestTemplateListEmpty(t *testing.T) {
+	env, _ := configEnv(t)
+
+	res := cmdtest.Run(t, env, NewCmdTemplate(), "list")
+	require.NoError(t, res.Err)
+	assert.Empty(t, res.Stdout)
+	assert.Contains(t, res.Stderr, "No templates found")
+}
@@ -0,0 +1,145 @@
+package attachment
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// accessPermissions are the permissions checked by --check-access.
+var accessPermissions = []string{
+	jira.PermissionBrowseProjects,
+	jira.PermissionCreateAttachments,
+	jira.PermissionDeleteOwnAttachments,
+	jira.PermissionDeleteAllAttachments,
+}
+
+// accessReport is what the current credentials can do with attachments.
+type accessReport struct {
+	// issueKey is the issue the permissions were checked on, empty for any project.
+	issueKey    string
+	permissions map[string]jira.Permission
+	// meta is nil if the attachment settings couldn't be fetched.
+	meta *jira.AttachmentMeta
+	// readOnly is the reason the token was found to be read-only in this process, if it was.
+	readOnly   string
+	isReadOnly bool
+}
+
+// accessRow is a line of the capability matrix.
+type accessRow struct {
+	capability string
+	allowed    bool
+	detail     string
+}
+
+// rows builds the capability matrix. Changes need the project permission, attachments
+// to be enabled on the instance and a token that isn't read-only.
+func (r *accessReport) rows() []accessRow {
+	has := func(key string) bool { return r.permissions[key].HavePermission }
+	enabled := r.meta == nil || r.meta.Enabled
+
+	change := func(capability, key, name string) accessRow {
+		row := accessRow{capability: capability, allowed: has(key), detail: name + " permission"}
+		switch {
+		case !row.allowed:
+			row.detail = "missing " + row.detail
+		case r.isReadOnly:
+			row.allowed, row.detail = false, "the API token is read-only"
+		case !enabled && key == jira.PermissionCreateAttachments:
+			row.allowed, row.detail = false, "attachments are disabled on the instance"
+		}
+		return row
+	}
+
+	browse := accessRow{capability: "List and download", allowed: has(jira.PermissionBrowseProjects), detail: "Browse projects permission"}
+	if !browse.allowed {
+		browse.detail = "missing " + browse.detail
+	}
+	return []accessRow{
+		browse,
+		change("Add", jira.PermissionCreateAttachments, "Create attachments"),
+		change("Remove own", jira.PermissionDeleteOwnAttachments, "Delete own attachments"),
+		change("Remove any", jira.PermissionDeleteAllAttachments, "Delete all attachments"),
+	}
+}
+
+// checkAccess probes what the current credentials can do with attachments, on the issue
+// given as argument or on any project.
+func checkAccess(cmd *cobra.Command, args []string, o cmdcommon.Options) error {
+	debug, err := cmd.Flags().GetBool("debug")
+	if err != nil {
+		return err
+	}
+	version, err := cmdcommon.GetAPIVersion(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := o.Client(debug)
+	if err != nil {
+		return err
+	}
+
+	report := accessReport{}
+	if len(args) > 0 {
+		report.issueKey = cmdutil.GetJiraIssueKey(viper.GetString("project.key"), args[0])
+	}
+
+	s := cmdutil.Info("Checking access...")
+	report.permissions, err = api.ProxyMyPermissionsVersion(client, version, jira.MyPermissionsOptions{
+		Permissions: accessPermissions,
+		IssueKey:    report.issueKey,
+	})
+	if err == nil {
+		// The attachment settings only refine the matrix, the probe works without them.
+		report.meta, _ = api.ProxyGetAttachmentMetaVersion(client, version)
+	}
+	s.Stop()
+	if err != nil {
+		return cmdutil.Errorf("Unable to check access: %s", cmdutil.Diagnose(err))
+	}
+	report.readOnly, report.isReadOnly = client.ReadOnly()
+
+	renderAccess(cmd.OutOrStdout(), &report)
+	return nil
+}
+
+func renderAccess(w io.Writer, r *accessReport) {
+	scope := "any project"
+	if r.issueKey != "" {
+		scope = r.issueKey
+	}
+	fmt.Fprintf(w, "Attachment access on %s%s\n\n", scope, cmdcommon.OnInstance())
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CAPABILITY\tALLOWED\tDETAIL\n")
+	for _, row := range r.rows() {
+		allowed := "no"
+		if row.allowed {
+			allowed = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", row.capability, allowed, row.detail)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+	switch {
+	case r.meta == nil:
+		fmt.Fprintln(w, "Upload limit: unknown")
+	case r.meta.UploadLimit > 0:
+		fmt.Fprintf(w, "Upload limit: %s\n", cmdutil.FormatSize(r.meta.UploadLimit))
+	}
+	if r.isReadOnly {
+		fmt.Fprintf(w, "The server rejected a change for lack of token scope: %s\n", r.readOnly)
+	} else {
+		fmt.Fprintln(w, "Permissions don't reflect the scope of the API token, a read-only token is detected on the first change it is denied.")
+	}
+}
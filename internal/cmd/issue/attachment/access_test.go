@@ -0,0 +1,247 @@
+package attachment
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func granted(keys ...string) map[string]jira.Permission {
+	perms := make(map[string]jira.Permission)
+	for _, k := range accessPermissions {
+		perms[k] = jira.Permission{Key: k}
+	}
+	for _, k := range keys {
+		perms[k] = jira.Permission{Key: k, HavePermission: true}
+	}
+	return perms
+}
+
+func allowed(rows []accessRow) []bool {
+	out := make([]bool, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, r.allowed)
+	}
+	return out
+}
+
+func TestAccessRows(t *testing.T) {
+	t.Parallel()
+
+	all := granted(accessPermissions...)
+	enabled := &jira.AttachmentMeta{Enabled: true, UploadLimit: 10 << 20}
+
+	cases := []struct {
+		name   string
+		report accessReport
+		want   []bool
+		detail string
+	}{
+		{
+			name:   "everything",
+			report: accessReport{permissions: all, meta: enabled},
+			want:   []bool{true, true, true, true},
+		},
+		{
+			name:   "no delete all",
+			report: accessReport{permissions: granted(jira.PermissionBrowseProjects, jira.PermissionCreateAttachments, jira.PermissionDeleteOwnAttachments), meta: enabled},
+			want:   []bool{true, true, true, false},
+			detail: "missing Delete all attachments permission",
+		},
+		{
+			name:   "read-only token",
+			report: accessReport{permissions: all, meta: enabled, isReadOnly: true, readOnly: "Unauthorized; scope does not match"},
+			want:   []bool{true, false, false, false},
+			detail: "the API token is read-only",
+		},
+		{
+			name:   "attachments disabled",
+			report: accessReport{permissions: all, meta: &jira.AttachmentMeta{}},
+			want:   []bool{true, false, true, true},
+			detail: "attachments are disabled on the instance",
+		},
+		{
+			name:   "unknown settings",
+			report: accessReport{permissions: all},
+			want:   []bool{true, true, true, true},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rows := tc.report.rows()
+			assert.Equal(t, tc.want, allowed(rows))
+			if tc.detail != "" {
+				var details []string
+				for _, r := range rows {
+					details = append(details, r.detail)
+				}
+				assert.Contains(t, details, tc.detail)
+			}
+		})
+	}
+}
+
+func TestRenderAccess(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	renderAccess(&b, &accessReport{
+		issueKey:    "TEST-1",
+		permissions: granted(jira.PermissionBrowseProjects, jira.PermissionCreateAttachments, jira.PermissionDeleteOwnAttachments),
+		meta:        &jira.AttachmentMeta{Enabled: true, UploadLimit: 10 << 20},
+	})
+
+	assert.Equal(t, "Attachment access on TEST-1\n\n"+
+		"CAPABILITY         ALLOWED  DETAIL\n"+
+		"List and download  yes      Browse projects permission\n"+
+		"Add                yes      Create attachments permission\n"+
+		"Remove own         yes      Delete own attachments permission\n"+
+		"Remove any         no       missing Delete all attachments permission\n"+
+		"\nUpload limit: 10.00 MB\n"+
+		"Permissions don't reflect the scope of the API token, a read-only token is detected on the first change it is denied.\n",
+		b.String())
+}
+
+func TestCheckAccess(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithDeniedPermissions(jira.PermissionDeleteAllAttachments))
+	defer server.Close()
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": jira.InstallationTypeCloud}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "--check-access", "TEST-1")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "Attachment access on TEST-1")
+	assert.Regexp(t, `Add\s+yes`, res.Stdout)
+	assert.Regexp(t, `Remove any\s+no\s+missing Delete all attachments permission`, res.Stdout)
+	assert.Contains(t, res.Stdout, "Upload limit: 10.00 MB")
+
+	var probed bool
+	for _, r := range server.Requests() {
+		assert.Equal(t, "GET", r.Method, "the probe doesn't change anything")
+		if r.Path == "/rest/api/3/mypermissions" {
+			probed = true
+			assert.Equal(t, "TEST-1", r.Query.Get("issueKey"))
+		}
+	}
+	assert.True(t, probed)
+}
+
+func TestReadOnlyToken(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+	server.SetFaults(jiratest.Faults{ReadOnlyToken: true})
+
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	for _, f := range files {
+		require.NoError(t, os.WriteFile(f, []byte("content"), 0o600))
+	}
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"installation": jira.InstallationTypeCloud, "auth.check_token_expiry": false},
+	}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), append([]string{"add", "TEST-1", "--no-input", "--keep-order"}, files...)...)
+	require.Error(t, res.Err)
+	assert.Contains(t, res.Err.Error(), "the API token is read-only")
+	assert.Contains(t, res.Stderr, "the API token is read-only (Unauthorized; scope does not match)")
+	assert.Contains(t, res.Stderr, `Not attempted: "`+files[1]+`"`)
+	assert.Len(t, paths(server, "POST"), 1)
+
+	// Listing and downloading keep working.
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "list", "TEST-1", "-o", "plain")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "notes.txt")
+
+	// Removing fails without a request, the token is known to be read-only.
+	a := server.Attachments("TEST-1")[0]
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "remove", "TEST-1", a.ID, "--no-input")
+	require.Error(t, res.Err)
+	assert.Empty(t, paths(server, "DELETE"))
+
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "--check-access")
+	require.NoError(t, res.Err)
+	assert.Regexp(t, `Add\s+no\s+the API token is read-only`, res.Stdout)
+	assert.Contains(t, res.Stdout, "The server rejected a change for lack of token scope: Unauthorized; scope does not match")
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	notes := server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+	server.SetFaults(jiratest.Faults{Maintenance: true})
+
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	for _, f := range files {
+		require.NoError(t, os.WriteFile(f, []byte("content"), 0o600))
+	}
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"installation": jira.InstallationTypeLocal},
+	}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), append([]string{"add", "TEST-1", "--no-input", "--keep-order"}, files...)...)
+	require.Error(t, res.Err)
+	assert.Contains(t, res.Err.Error(), "Jira is in maintenance/read-only mode, try again later")
+	assert.Contains(t, res.Stderr, "Jira is in maintenance/read-only mode, try again later (Jira is in read-only mode while it is being upgraded.)")
+	assert.Contains(t, res.Stderr, `Not attempted: "`+files[1]+`"`)
+	assert.Len(t, paths(server, "POST"), 1, "the upload is not retried")
+
+	// Listing and downloading keep working.
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "list", "TEST-1", "-o", "plain")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "notes.txt")
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "download", "TEST-1", "--id", notes.ID, "--output", filepath.Join(dir, "out"))
+	require.NoError(t, res.Err)
+
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "remove", "TEST-1", notes.ID, "--no-input", "--wait-for-writable", "100ms")
+	require.Error(t, res.Err)
+	assert.Contains(t, res.Err.Error(), "Jira is still in maintenance/read-only mode after waiting 100ms")
+	assert.Empty(t, paths(server, "DELETE"), "nothing is attempted")
+
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "remove", "TEST-1", notes.ID, "--no-input", "--wait-for-writable", "soon")
+	assert.EqualError(t, res.Err, `Invalid --wait-for-writable duration "soon", eg: 30m`)
+
+	// The upgrade completes while waiting.
+	time.AfterFunc(200*time.Millisecond, func() { server.SetFaults(jiratest.Faults{}) })
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "remove", "TEST-1", notes.ID, "--no-input", "--wait-for-writable", "10s")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stderr, "Jira is in maintenance/read-only mode, waiting up to 10s for it to accept changes")
+	assert.Contains(t, res.Stderr, "Jira accepts changes again, resuming")
+	assert.Len(t, paths(server, "DELETE"), 1)
+	assert.Empty(t, server.Attachments("TEST-1"))
+}
+
+func TestProjectPermissionDenialIsNotReadOnly(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	first := server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+	second := server.AddAttachment("TEST-1", "b.txt", []byte("b"))
+	server.SetFaults(jiratest.Faults{FailDeletes: true})
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": jira.InstallationTypeCloud}}
+
+	for _, a := range []jira.Attachment{first, second} {
+		res := cmdtest.Run(t, env, NewCmdAttachment(), "remove", "TEST-1", a.ID, "--no-input")
+		require.Error(t, res.Err)
+		assert.NotContains(t, res.Err.Error()+res.Stderr, "read-only")
+	}
+	// Both deletes reached the server.
+	assert.Len(t, paths(server, "DELETE"), 2)
+}
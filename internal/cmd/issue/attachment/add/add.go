@@ -1,8 +1,15 @@
 package add
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
@@ -11,7 +18,13 @@ import (
 	"github.com/ankitpokhrel/jira-cli/api"
 	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
 	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/hooks"
+	"github.com/ankitpokhrel/jira-cli/internal/notify"
 	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/pkg/browser"
+	"github.com/ankitpokhrel/jira-cli/pkg/eol"
+	"github.com/ankitpokhrel/jira-cli/pkg/imgscale"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
 )
 
 const (
@@ -22,11 +35,57 @@ const (
 $ jira issue attachment add ISSUE-1 file1.pdf file2.png file3.txt
 
 # Skip confirmation prompt
-$ jira issue attachment add ISSUE-1 file.pdf --no-input`
+$ jira issue attachment add ISSUE-1 file.pdf --no-input
+
+# Upload a large file in chunks to get around proxy body size limits (cloud only)
+$ jira issue attachment add ISSUE-1 dump.tar.gz --chunked --chunk-size 16
+
+# Forget interrupted chunked uploads instead of resuming them
+$ jira issue attachment add --abort-resume
+
+# Convert line endings of text files to LF before uploading
+$ jira issue attachment add ISSUE-1 notes.txt --eol lf
+
+# Upload files listed in a CSV or JSON manifest (columns: issue,file,name,comment)
+$ jira issue attachment add --from-manifest plan.csv
+
+# Retry rows that failed in a previous manifest run
+$ jira issue attachment add --from-manifest plan.csv --resume plan.results.json
+
+# Upload the files all or nothing, the files uploaded before a failure are deleted
+$ jira issue attachment add ISSUE-1 report.pdf data.csv --atomic
+
+# Upload the files in the given order, by default the ones likely to be rejected go first
+$ jira issue attachment add ISSUE-1 dump.tar.gz setup.exe --keep-order
+
+# Downscale screenshots and photos larger than 1600 pixels before uploading
+$ jira issue attachment add ISSUE-1 screenshot.png photo.jpg --max-image-dimension 1600
+
+# Open the issue in the browser after the upload
+$ jira issue attachment add ISSUE-1 screenshot.png --web
+
+# Scan files before they are uploaded, a non-zero exit skips the file
+$ jira issue attachment add ISSUE-1 report.pdf --pre-hook 'clamscan --no-summary "$JIRA_ATTACHMENT_FILE"'
+
+# Upload base64 content, eg: from the response of another API, without writing a file
+$ jira issue attachment add ISSUE-1 --data-base64 "data:text/plain;base64,aGVsbG8=" --filename hello.txt
+
+# Wait up to an hour for Jira to leave maintenance/read-only mode, eg: in a scheduled job
+$ jira issue attachment add ISSUE-1 report.pdf --no-input --wait-for-writable 1h
+
+# Read long base64 content from a file
+$ jira issue attachment add ISSUE-1 --data-base64 @payload.b64 --filename report.pdf
+
+# Upload the files of a template, see: jira config attachment template add
+$ jira issue attachment add ISSUE-1 --template qa-report
+
+# Add a file to the ones of the template and comment once all files are uploaded
+$ jira issue attachment add ISSUE-1 notes.txt --template qa-report --comment "QA done"`
 )
 
 // NewCmdAttachmentAdd is an attachment add command.
-func NewCmdAttachmentAdd() *cobra.Command {
+func NewCmdAttachmentAdd(opts ...cmdcommon.Options) *cobra.Command {
+	o := cmdcommon.ResolveOptions(opts)
 	cmd := cobra.Command{
 		Use:     "add ISSUE-KEY FILE [FILE...]",
 		Short:   "Add attachments to an issue",
@@ -37,46 +96,398 @@ func NewCmdAttachmentAdd() *cobra.Command {
 			"help:args": "ISSUE-KEY\tIssue key, eg: ISSUE-1\n" +
 				"FILE\tPath to file(s) to upload",
 		},
-		Run: add,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return add(cmd, args, o)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
 	cmd.Flags().Bool("no-input", false, "Skip confirmation prompt")
+	cmd.Flags().Bool("allow-cross-project", false, "Allow uploads with --no-input to an issue outside of the default project")
+	cmd.Flags().Bool("short-url", false, "Print the issue key or the configured short URL instead of the full browse URL")
+	cmd.Flags().Bool("web", false, "Open issue in web browser after successful upload")
+	cmd.Flags().Bool("open", false, "Alias for --web")
+	cmd.Flags().Bool("chunked", false, "Upload files in chunks using the media API (Jira cloud only)")
+	cmd.Flags().Uint("chunk-size", 8, "Chunk size in MB for --chunked uploads")
+	cmd.Flags().Bool("abort-resume", false, "Clear saved sessions of interrupted --chunked uploads, of the given files or all")
+	cmd.Flags().String("from-manifest", "", "Upload files listed in a CSV or JSON manifest")
+	cmd.Flags().String("resume", "", "Skip manifest rows uploaded successfully as per the given results file")
+	cmd.Flags().Uint("concurrency", 1, fmt.Sprintf("Number of issues of a manifest uploaded to at the same time, at most %d", maxConcurrency))
+	cmd.Flags().String("eol", "", "Convert line endings of text files before uploading: native, lf or crlf")
+	cmd.Flags().String("pre-hook", "", "Command to run before each file is uploaded, a non-zero exit skips the file")
+	cmd.Flags().String("post-hook", "", "Command to run after each file is uploaded")
+	cmd.Flags().Bool("atomic", false, "Upload all files or none: if a file fails, delete the ones uploaded before it")
+	cmd.Flags().Bool("keep-order", false, "Upload the files in the given order instead of the ones likely to be rejected first")
+	cmd.Flags().Uint("max-image-dimension", 0, "Downscale PNG and JPEG images larger than the given pixels in width or height before uploading")
+	cmd.Flags().Uint("image-quality", imgscale.DefaultJPEGQuality, "Quality of downscaled JPEG images, from 1 to 100")
+	cmd.Flags().String("data-base64", "", "Upload base64 content, optionally as a data URI, instead of files; use @file to read it from a file")
+	cmd.Flags().String("filename", "", "Name of the attachment uploaded with --data-base64")
+	cmd.Flags().String("template", "", "Upload the files of the named template, see: jira config attachment template")
+	cmd.Flags().String("comment", "", "Comment to add to the issue once all files are uploaded, replaces the one of --template")
+	cmd.Flags().Bool("plain", false, "Show a spinner instead of a progress bar while uploading, eg: when the output is logged")
+	cmdcommon.SetNotifyFlag(&cmd)
+	cmdcommon.SetWaitForWritableFlag(&cmd)
+
+	o.Apply(&cmd)
 
 	return &cmd
 }
 
-func add(cmd *cobra.Command, args []string) {
-	params := parseArgsAndFlags(args, cmd.Flags())
-	client := api.DefaultClient(params.debug)
+func add(cmd *cobra.Command, args []string, o cmdcommon.Options) error {
+	params, err := parseArgsAndFlags(args, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	if params.abortResume {
+		return abortResume(params)
+	}
+	if err := validateData(params); err != nil {
+		return err
+	}
+
+	if params.apiVersion, err = cmdcommon.GetAPIVersion(cmd); err != nil {
+		return err
+	}
+	if params.notifier, err = cmdcommon.NewNotifier(cmd); err != nil {
+		return err
+	}
+	client, err := o.Client(params.debug)
+	if err != nil {
+		return err
+	}
+	if err := cmdcommon.WaitForWritable(cmd, client); err != nil {
+		return err
+	}
+
+	if params.template != "" && (params.manifest != "" || params.dataBase64 != "") {
+		return cmdutil.Errorf("--template can't be used with --from-manifest or --data-base64")
+	}
+	if params.manifest != "" {
+		if len(args) > 0 {
+			return cmdutil.Errorf("ISSUE-KEY and FILE can't be used with --from-manifest")
+		}
+		if params.atomic {
+			return cmdutil.Errorf("--atomic can't be used with --from-manifest")
+		}
+		return addFromManifest(cmd, client, params)
+	}
+	if params.resume != "" {
+		return cmdutil.Errorf("--resume can only be used with --from-manifest")
+	}
+	if params.concurrency > 1 {
+		return cmdutil.Errorf("--concurrency can only be used with --from-manifest")
+	}
+	if params.data != nil {
+		return addData(cmd, client, params)
+	}
 
 	if params.issueKey == "" {
-		cmdutil.Failed("ISSUE-KEY is required")
+		return cmdutil.Errorf("ISSUE-KEY is required")
+	}
+	if params.template != "" {
+		if err := applyTemplate(params); err != nil {
+			return err
+		}
 	}
 
 	if len(params.files) == 0 {
-		cmdutil.Failed("At least one file path is required")
+		return cmdutil.Errorf("At least one file path is required")
+	}
+
+	if params.chunked && viper.GetString("installation") == jira.InstallationTypeLocal {
+		return cmdutil.Errorf("Chunked uploads are only supported on Jira cloud, remove --chunked to upload to Jira server")
+	}
+	if params.chunked && params.apiVersion == api.APIVersion2 {
+		return cmdutil.Errorf("Chunked uploads are only supported by the v3 api, remove --chunked or --api-version 2")
+	}
+	if params.chunked && params.chunkSize == 0 {
+		return cmdutil.Errorf("Chunk size must be greater than 0")
 	}
 
 	// Validate that all files exist
 	for _, file := range params.files {
 		if _, err := os.Stat(file); os.IsNotExist(err) {
-			cmdutil.Failed("File %q does not exist", file)
+			return cmdutil.Errorf("File %q does not exist", file)
 		}
 	}
 
+	if err := cmdcommon.CheckTokenExpiry(client); err != nil {
+		return err
+	}
+
+	if err := checkProject(client, params); err != nil {
+		return err
+	}
+
+	planned := planUploads(inspectFiles(params.files, uploadLimit(client, params)), params.keepOrder)
+	params.files = plannedPaths(planned)
+
 	// Show confirmation unless --no-input is set
 	if !params.noInput {
-		fileList := ""
-		for _, file := range params.files {
-			fileList += fmt.Sprintf("  - %s\n", file)
+		ok, err := cmdcommon.Confirm(ask, viewList, fmt.Sprintf("Upload %d file(s) to %s%s?", len(params.files), params.target(), cmdcommon.OnInstance()), planItems(planned))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return cmdutil.Errorf("Action aborted")
+		}
+	}
+
+	if params.atomic {
+		res, rb := uploadAtomic(client, params)
+		summary := res.summary(params.issueKey, len(params.files))
+		if rb != nil && !rb.skipped {
+			// The uploaded files were deleted again.
+			summary.Done, summary.Failed, summary.Bytes = 0, summary.Total, 0
+		}
+		params.notify(res, summary)
+		if rb != nil {
+			return atomicError(res, rb, params.issueKey)
+		}
+		return finishUpload(cmd, client, params, res)
+	}
+
+	res := uploadFiles(client, params)
+	params.notify(res, res.summary(params.issueKey, len(params.files)))
+
+	if res.guard.Stopped() {
+		for _, file := range res.notAttempted {
+			cmdutil.Fail("Not attempted: %q", file)
+		}
+		return cmdutil.Errorf("Stopped after %d of %d file(s): %s", len(params.files)-len(res.notAttempted), len(params.files), res.guard.Reason())
+	}
+	if res.failed > 0 {
+		summary := fmt.Sprintf("Uploaded %d of %d file(s) to issue %q", len(params.files)-res.failed, len(params.files), params.issueKey)
+		if res.rejected > 0 {
+			summary += fmt.Sprintf(", %d rejected by the pre-upload hook", res.rejected)
+		}
+		return cmdutil.Errorf("%s", summary)
+	}
+	return finishUpload(cmd, client, params, res)
+}
+
+// finishUpload adds the comment of --comment or --template once all the files are uploaded,
+// prints the issue URL, and opens it with --web.
+func finishUpload(cmd *cobra.Command, client *jira.Client, params *addParams, res *uploadResult) error {
+	if res.dryRun > 0 {
+		cmdutil.DryRun("Nothing was uploaded to issue %q", params.issueKey)
+		return nil
+	}
+	if params.comment != "" {
+		if err := api.ProxyAddIssueCommentVersion(client, params.apiVersion, params.issueKey, params.comment, false); err != nil {
+			return cmdutil.Errorf("Uploaded the files, but failed to add the comment to issue %q: %s", params.issueKey, err)
+		}
+		cmdutil.Success("Added a comment to issue %q", params.issueKey)
+	}
+
+	server := viper.GetString("server")
+	fmt.Fprintf(cmd.OutOrStdout(), "%s\n", params.issueURL(params.issueKey))
+
+	if params.web {
+		u := attachmentBrowseURL(server, params.issueKey, res.uploaded, viper.GetString("installation"))
+		return navigate(defaultOpener, u, hasDisplay(os.Getenv, runtime.GOOS))
+	}
+	return nil
+}
+
+// ask and viewList are replaced in tests to answer the confirmation prompt without a terminal.
+var (
+	ask      cmdcommon.Asker = survey.Ask
+	viewList                 = cmdcommon.ViewList
+)
+
+// uploadResult is the outcome of uploading the files given on the command line.
+type uploadResult struct {
+	uploaded     []jira.Attachment
+	failed       int
+	rejected     int
+	dryRun       int
+	notAttempted []string
+	guard        cmdcommon.AuthGuard
+	// failures are the failed files by reason, not counting those not attempted.
+	failures map[string]int
+}
+
+// fail counts a failed file.
+func (r *uploadResult) fail(err error) {
+	if r.failures == nil {
+		r.failures = make(map[string]int)
+	}
+	r.failed++
+	r.failures[failureReason(err)]++
+}
+
+// summary is the outcome of uploading total files to the issue for its completion
+// notification.
+func (r *uploadResult) summary(issueKey string, total int) notify.Summary {
+	var bytes int64
+	for _, a := range r.uploaded {
+		bytes += a.Size
+	}
+	failed := r.failed + len(r.notAttempted)
+	failures := make(map[string]int, len(r.failures)+1)
+	for reason, n := range r.failures {
+		failures[reason] = n
+	}
+	if len(r.notAttempted) > 0 {
+		failures[cmdcommon.FailureNotAttempted] = len(r.notAttempted)
+	}
+	return notify.Summary{
+		Op:       notify.OpUpload,
+		Target:   issueKey,
+		Total:    total,
+		Done:     total - failed,
+		Failed:   failed,
+		Bytes:    bytes,
+		Failures: failures,
+	}
+}
+
+// failureReason returns the reason of a failed upload reported in the metrics.
+func failureReason(err error) string {
+	var rejected *errHookRejected
+	if errors.As(err, &rejected) {
+		return cmdcommon.FailureRejected
+	}
+	return cmdcommon.FailureReason(err)
+}
+
+// uploadFiles uploads the files one by one. A failed file doesn't stop the batch unless the
+// server rejected the credentials, or with --atomic, in which case the remaining files are
+// not attempted.
+func uploadFiles(client *jira.Client, params *addParams) *uploadResult {
+	var res uploadResult
+
+	for i, file := range params.files {
+		if err := params.hooks.before(params.issueKey, file, filepath.Base(file)); err != nil {
+			res.fail(err)
+			res.rejected++
+			cmdutil.Fail("Skipped %q: %s", file, err)
+			if params.atomic {
+				res.notAttempted = params.files[i+1:]
+				break
+			}
+			continue
 		}
 
+		var (
+			converted bool
+			scaled    *imgscale.Result
+		)
+		attachments, err := func() ([]jira.Attachment, error) {
+			// Chunks are sent in requests of their own, the spinner is shown for them.
+			p := cmdutil.NewProgress(fmt.Sprintf("Uploading %s", file), params.progressBar && !params.chunked)
+			defer p.Stop()
+
+			path, cleanup, err := convertLineEndings(file, params.eol)
+			if err != nil {
+				return nil, err
+			}
+			defer cleanup()
+			converted = path != file
+
+			var cleanupImage func()
+			path, cleanupImage, scaled, err = downscaleImage(path, params.image)
+			if err != nil {
+				return nil, err
+			}
+			defer cleanupImage()
+
+			if params.chunked {
+				opts := jira.ChunkedUploadOptions{
+					ChunkSize: int64(params.chunkSize) << 20,
+					Notify:    func(msg string) { cmdutil.Warn("%s", msg) },
+				}
+				// Converted files are temporary, there is nothing to resume them from.
+				if !converted && scaled == nil {
+					opts.Sessions = uploadSessions()
+				}
+				return api.ProxyUploadAttachmentChunkedVersion(client, params.apiVersion, params.issueKey, path, opts)
+			}
+			return api.ProxyUploadAttachmentVersion(client, params.apiVersion, params.issueKey, path, jira.WithUploadProgress(p.Update))
+		}()
+		if errors.Is(err, jira.ErrDryRun) {
+			res.dryRun++
+			cmdutil.DryRun("Would upload %q to issue %q", file, params.issueKey)
+			continue
+		}
+		if err != nil {
+			res.fail(err)
+			withUploadLimit(client, params.apiVersion, err)
+			cmdutil.Fail("Failed to upload %q: %s%s", file, uploadErrorMessage(err), params.chunkedHint(err))
+			if res.guard.Observe(err) || params.atomic {
+				res.notAttempted = params.files[i+1:]
+				break
+			}
+			continue
+		}
+		res.guard.Observe(nil)
+
+		res.uploaded = append(res.uploaded, attachments...)
+		_ = params.hooks.after(params.issueKey, file, attachments)
+		uploaded := describeUpload(file, filepath.Base(file), attachmentNames(attachments))
+		var notes []string
+		if converted {
+			notes = append(notes, fmt.Sprintf("converted line endings to %s", params.eol))
+		}
+		if scaled != nil {
+			notes = append(notes, describeDownscale(scaled))
+		}
+		if len(notes) > 0 {
+			cmdutil.Success("Uploaded %s to issue %q (%s)", uploaded, params.issueKey, strings.Join(notes, "; "))
+		} else {
+			cmdutil.Success("Uploaded %s to issue %q", uploaded, params.issueKey)
+		}
+	}
+	return &res
+}
+
+func addFromManifest(cmd *cobra.Command, client *jira.Client, params *addParams) error {
+	rows, err := readManifest(params.manifest)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return cmdutil.Errorf("Manifest %q doesn't list any files", params.manifest)
+	}
+
+	if errs := validateManifest(rows, os.Stat); len(errs) > 0 {
+		for _, e := range errs {
+			cmdutil.Fail("%s", e)
+		}
+		return cmdutil.Errorf("Manifest %q is invalid, nothing was uploaded", params.manifest)
+	}
+
+	var prev *manifestResults
+	if params.resume != "" {
+		prev, err = readResults(params.resume, func(format string, a ...any) {
+			cmdutil.Warn(format, a...)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	pending, done := pendingRows(rows, prev)
+	if len(done) > 0 {
+		cmdutil.Warn("Skipping %d row(s) uploaded in a previous run", len(done))
+	}
+
+	if len(pending) > 0 {
+		if err := cmdcommon.CheckTokenExpiry(client); err != nil {
+			return err
+		}
+	}
+
+	if !params.noInput && len(pending) > 0 {
 		answer := struct{ Action string }{}
 		err := survey.Ask([]*survey.Question{
 			{
 				Name: "action",
 				Prompt: &survey.Select{
-					Message: fmt.Sprintf("Upload %d file(s) to %s?\n%s", len(params.files), params.issueKey, fileList),
+					Message: fmt.Sprintf("Upload %d file(s) to %d issue(s)%s?", len(pending), len(groupByIssue(pending)), cmdcommon.OnInstance()),
 					Options: []string{
 						cmdcommon.ActionSubmit,
 						cmdcommon.ActionCancel,
@@ -84,39 +495,248 @@ func add(cmd *cobra.Command, args []string) {
 				},
 			},
 		}, &answer)
-		cmdutil.ExitIfError(err)
+		if err != nil {
+			return err
+		}
 
 		if answer.Action == cmdcommon.ActionCancel {
-			cmdutil.Failed("Action aborted")
+			return cmdutil.Errorf("Action aborted")
 		}
 	}
 
-	// Upload each file
-	for _, file := range params.files {
-		err := func() error {
-			s := cmdutil.Info(fmt.Sprintf("Uploading %s", file))
-			defer s.Stop()
+	out := resultsPath(params.manifest)
+	results := &manifestResults{Manifest: params.manifest, Rows: done}
 
-			_, err := api.ProxyUploadAttachment(client, params.issueKey, file)
+	var failed, notAttempted, dryRun int
+	failures := make(map[string]int)
+	uploader := clientUploader{client: client, version: params.apiVersion, hooks: params.hooks}
+	executeManifest(pending, uploader, params.concurrency, func(res rowResult) {
+		results.Rows = append(results.Rows, res)
+		switch res.Status {
+		case rowStatusFailed:
+			failed++
+			failures[res.reason]++
+			cmdutil.Fail("Row %d: failed to upload %q to issue %q: %s", res.Line, res.File, res.Issue, res.Error)
+		case rowStatusNotAttempted:
+			failed++
+			notAttempted++
+			failures[cmdcommon.FailureNotAttempted]++
+			cmdutil.Fail("Row %d: not attempted %q to issue %q", res.Line, res.File, res.Issue)
+		case rowStatusDryRun:
+			dryRun++
+			cmdutil.DryRun("Row %d: would upload %q to issue %q", res.Line, res.File, res.Issue)
+		default:
+			cmdutil.Success("Row %d: uploaded %s to issue %q", res.Line, describeUpload(res.File, res.uploadName(), res.Filenames), res.Issue)
+		}
+		if err := writeResults(out, results); err != nil {
+			cmdutil.Warn("Unable to write results to %s: %s", out, err)
+		}
+	})
+
+	if len(pending) == 0 {
+		if err := writeResults(out, results); err != nil {
 			return err
-		}()
-		cmdutil.ExitIfError(err)
+		}
+	} else if dryRun == 0 {
+		params.notifier.Done(notify.Summary{
+			Op:       notify.OpUpload,
+			Target:   fmt.Sprintf("%d issue(s)", len(groupByIssue(pending))),
+			Total:    len(pending),
+			Done:     len(pending) - failed,
+			Failed:   failed,
+			Failures: failures,
+		})
+	}
 
-		cmdutil.Success("Uploaded %q to issue %q", file, params.issueKey)
+	fmt.Fprintf(cmd.OutOrStdout(), "Results written to %s\n", out)
+	if notAttempted > 0 {
+		return cmdutil.Errorf("Stopped with %d row(s) not attempted, %s. Rerun with --resume %s once the credentials are fixed",
+			notAttempted, results.Rows[len(results.Rows)-1].Error, out)
 	}
+	if failed > 0 {
+		return cmdutil.Errorf("Uploaded %d of %d row(s), rerun with --resume %s to retry the failed ones", len(rows)-failed, len(rows), out)
+	}
+	return nil
+}
 
-	server := viper.GetString("server")
-	fmt.Printf("%s\n", cmdutil.GenerateServerBrowseURL(server, params.issueKey))
+// convertLineEndings writes a copy of the file with converted line endings to a temporary
+// directory, keeping the original filename. The original path is returned as is if no
+// conversion is requested or the file is not text.
+func convertLineEndings(file string, mode eol.Mode) (string, func(), error) {
+	noop := func() {}
+	if mode == eol.ModeNone {
+		return file, noop, nil
+	}
+
+	dir, err := os.MkdirTemp("", "jira-attachment-")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	dst := filepath.Join(dir, filepath.Base(file))
+	converted, err := eol.ConvertFile(dst, file, mode, "")
+	if err != nil || !converted {
+		cleanup()
+		return file, noop, err
+	}
+	return dst, cleanup, nil
+}
+
+// navigate opens the url using the given opener. It is a no-op
+// with a warning if there is no display to open the browser in.
+func navigate(o opener, url string, display bool) error {
+	if !display {
+		cmdutil.Warn("No display available, skipping opening %s in the browser", url)
+		return nil
+	}
+	return o.Open(url)
+}
+
+// withUploadLimit completes an upload rejected as too large with the attachment limit of
+// the instance if it isn't known yet, to tell a proxy limit from the one of Jira.
+func withUploadLimit(client *jira.Client, version string, err error) {
+	var bigErr *jira.UploadTooLargeError
+	if !errors.As(err, &bigErr) || bigErr.Limit > 0 {
+		return
+	}
+	if meta, err := api.ProxyGetAttachmentMetaVersion(client, version); err == nil {
+		bigErr.Limit = meta.UploadLimit
+	}
+}
+
+func uploadErrorMessage(err error) string {
+	var attErr *jira.AttachmentError
+	if errors.As(err, &attErr) && attErr.Retryable {
+		return fmt.Sprintf("the issue appears to be temporarily locked (%s), please retry later", attErr.Status)
+	}
+	if e, ok := err.(*jira.ErrUnexpectedResponse); ok {
+		if msg := cmdutil.NormalizeJiraError(e.Error()); msg != "" {
+			return msg
+		}
+		return e.Status
+	}
+	if d := cmdutil.Diagnose(err); d.Kind != cmdutil.ErrorKindUnknown {
+		return d.String()
+	}
+	return err.Error()
+}
+
+// attachmentNames returns the filenames of the attachments as stored by the server.
+func attachmentNames(attachments []jira.Attachment) []string {
+	names := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		names = append(names, a.Filename)
+	}
+	return names
+}
+
+// describeUpload names an uploaded file in messages. Jira may store the file under
+// another name than requested, eg: "local (1).log" if the issue already has a
+// "local.log", in which case the name on the issue is shown along with the local path.
+func describeUpload(local, requested string, names []string) string {
+	if len(names) != 1 || names[0] == requested {
+		return strconv.Quote(local)
+	}
+	return fmt.Sprintf("%q as %q", local, names[0])
+}
+
+// opener opens the given url, usually in a web browser.
+type opener interface {
+	Open(url string) error
+}
+
+type browserOpener struct{}
+
+func (browserOpener) Open(url string) error {
+	return browser.Browse(url)
+}
+
+var defaultOpener opener = browserOpener{}
+
+// attachmentBrowseURL returns the browse URL of the issue. If exactly one attachment
+// was uploaded to a cloud instance, the URL focuses that attachment in the web UI.
+func attachmentBrowseURL(server, key string, uploaded []jira.Attachment, installation string) string {
+	u := cmdutil.GenerateServerBrowseURL(strings.TrimSuffix(server, "/"), key)
+	if len(uploaded) != 1 || uploaded[0].ID == "" || installation == jira.InstallationTypeLocal {
+		return u
+	}
+	q := url.Values{}
+	q.Set("focusedAttachmentId", uploaded[0].ID)
+	return fmt.Sprintf("%s?%s", u, q.Encode())
+}
+
+// hasDisplay reports if a browser can be launched from the current session.
+// Remote sessions without a forwarded display can't open a browser unless
+// the user configured a custom one via env.
+func hasDisplay(getenv func(string) string, goos string) bool {
+	if getenv("JIRA_BROWSER") != "" || getenv("BROWSER") != "" {
+		return true
+	}
+	if goos == "windows" || goos == "darwin" {
+		return getenv("SSH_CONNECTION") == "" && getenv("SSH_TTY") == ""
+	}
+	return getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != ""
 }
 
 type addParams struct {
-	issueKey string
-	files    []string
-	noInput  bool
-	debug    bool
+	issueKey    string
+	files       []string
+	noInput     bool
+	issueURL    cmdutil.IssueURLFunc
+	web         bool
+	chunked     bool
+	chunkSize   uint
+	abortResume bool
+	eol         eol.Mode
+	manifest    string
+	resume      string
+	concurrency int
+	hooks       uploadHooks
+	atomic      bool
+	keepOrder   bool
+	image       imgscale.Options
+	dataBase64  string
+	filename    string
+	data        []byte
+	template    string
+	comment     string
+	notifier    *notify.Notifier
+	progressBar bool
+	apiVersion  string
+	debug       bool
+
+	// allowCrossProject allows uploads with --no-input to an issue outside of the default project.
+	allowCrossProject bool
+	// crossProject is the issue uploaded to if it is outside of the default project.
+	crossProject *crossProjectIssue
+}
+
+// chunkedHint suggests --chunked for an upload that a proxy in front of Jira rejected or
+// dropped as too large, if chunked uploads are available, as each chunk is sent in a
+// request of its own.
+func (p *addParams) chunkedHint(err error) string {
+	if p.chunked || p.apiVersion == api.APIVersion2 || viper.GetString("installation") == jira.InstallationTypeLocal {
+		return ""
+	}
+	var (
+		bigErr  *jira.UploadTooLargeError
+		dropErr *jira.UploadInterruptedError
+	)
+	if (errors.As(err, &bigErr) && bigErr.Proxy) || errors.As(err, &dropErr) {
+		return " Retry with --chunked to upload the file in smaller requests, eg: --chunked --chunk-size 4."
+	}
+	return ""
+}
+
+// notify sends the completion notification of an upload, dry runs aren't notified.
+func (p *addParams) notify(res *uploadResult, summary notify.Summary) {
+	if res.dryRun == 0 {
+		p.notifier.Done(summary)
+	}
 }
 
-func parseArgsAndFlags(args []string, flags query.FlagParser) *addParams {
+func parseArgsAndFlags(args []string, flags query.FlagParser) (*addParams, error) {
 	var issueKey string
 	var files []string
 
@@ -128,15 +748,173 @@ func parseArgsAndFlags(args []string, flags query.FlagParser) *addParams {
 	}
 
 	debug, err := flags.GetBool("debug")
-	cmdutil.ExitIfError(err)
+	if err != nil {
+		return nil, err
+	}
 
 	noInput, err := flags.GetBool("no-input")
-	cmdutil.ExitIfError(err)
+	if err != nil {
+		return nil, err
+	}
 
-	return &addParams{
-		issueKey: issueKey,
-		files:    files,
-		noInput:  noInput,
-		debug:    debug,
+	allowCrossProject, err := flags.GetBool("allow-cross-project")
+	if err != nil {
+		return nil, err
+	}
+
+	shortURL, err := flags.GetBool("short-url")
+	if err != nil {
+		return nil, err
+	}
+
+	issueURL, err := cmdutil.IssueURLResolver(viper.GetString("server"), shortURL, viper.GetString("output.short_urls"))
+	if err != nil {
+		return nil, err
+	}
+
+	web, err := flags.GetBool("web")
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := flags.GetBool("open")
+	if err != nil {
+		return nil, err
+	}
+
+	chunked, err := flags.GetBool("chunked")
+	if err != nil {
+		return nil, err
+	}
+
+	chunkSize, err := flags.GetUint("chunk-size")
+	if err != nil {
+		return nil, err
 	}
+
+	abortResume, err := flags.GetBool("abort-resume")
+	if err != nil {
+		return nil, err
+	}
+
+	eolFlag, err := flags.GetString("eol")
+	if err != nil {
+		return nil, err
+	}
+
+	eolMode, err := eol.ParseMode(eolFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := flags.GetString("from-manifest")
+	if err != nil {
+		return nil, err
+	}
+
+	resume, err := flags.GetString("resume")
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency, err := flags.GetUint("concurrency")
+	if err != nil {
+		return nil, err
+	}
+	if concurrency < 1 || concurrency > maxConcurrency {
+		return nil, cmdutil.Errorf("--concurrency must be between 1 and %d", maxConcurrency)
+	}
+
+	preHook, err := flags.GetString("pre-hook")
+	if err != nil {
+		return nil, err
+	}
+
+	postHook, err := flags.GetString("post-hook")
+	if err != nil {
+		return nil, err
+	}
+
+	atomic, err := flags.GetBool("atomic")
+	if err != nil {
+		return nil, err
+	}
+
+	keepOrder, err := flags.GetBool("keep-order")
+	if err != nil {
+		return nil, err
+	}
+
+	maxImageDimension, err := flags.GetUint("max-image-dimension")
+	if err != nil {
+		return nil, err
+	}
+
+	imageQuality, err := flags.GetUint("image-quality")
+	if err != nil {
+		return nil, err
+	}
+	if imageQuality < 1 || imageQuality > 100 {
+		return nil, cmdutil.Errorf("Image quality must be between 1 and 100")
+	}
+
+	dataBase64, err := flags.GetString("data-base64")
+	if err != nil {
+		return nil, err
+	}
+
+	filename, err := flags.GetString("filename")
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := flags.GetString("template")
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := flags.GetString("comment")
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := flags.GetBool("plain")
+	if err != nil {
+		return nil, err
+	}
+
+	hookTimeout := hooks.DefaultTimeout
+	if t := viper.GetString("attachment.hook_timeout"); t != "" {
+		hookTimeout, err = time.ParseDuration(t)
+		if err != nil {
+			return nil, cmdutil.Errorf("Invalid attachment.hook_timeout duration %q", t)
+		}
+	}
+
+	return &addParams{
+		issueKey:    issueKey,
+		files:       files,
+		noInput:     noInput,
+		issueURL:    issueURL,
+		web:         web || open,
+		chunked:     chunked,
+		chunkSize:   chunkSize,
+		abortResume: abortResume,
+		eol:         eolMode,
+		manifest:    manifest,
+		resume:      resume,
+		concurrency: int(concurrency),
+		hooks:       newUploadHooks(preHook, postHook, hookTimeout),
+		atomic:      atomic,
+		keepOrder:   keepOrder,
+		image:       imgscale.Options{MaxDimension: int(maxImageDimension), JPEGQuality: int(imageQuality)},
+		dataBase64:  dataBase64,
+		filename:    filename,
+		template:    strings.ToLower(strings.TrimSpace(template)),
+		comment:     comment,
+		progressBar: cmdutil.ProgressBarEnabled(plain),
+		debug:       debug,
+
+		allowCrossProject: allowCrossProject,
+	}, nil
 }
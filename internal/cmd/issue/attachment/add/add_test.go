@@ -0,0 +1,368 @@
+package add
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/eol"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+type fakeOpener struct {
+	urls []string
+	err  error
+}
+
+func (f *fakeOpener) Open(url string) error {
+	f.urls = append(f.urls, url)
+	return f.err
+}
+
+func TestAttachmentBrowseURL(t *testing.T) {
+	t.Parallel()
+
+	one := []jira.Attachment{{ID: "10001"}}
+	two := []jira.Attachment{{ID: "10001"}, {ID: "10002"}}
+
+	cases := []struct {
+		name         string
+		server       string
+		uploaded     []jira.Attachment
+		installation string
+		expected     string
+	}{
+		{
+			name:         "single attachment on cloud is focused",
+			server:       "https://example.atlassian.net",
+			uploaded:     one,
+			installation: jira.InstallationTypeCloud,
+			expected:     "https://example.atlassian.net/browse/TEST-1?focusedAttachmentId=10001",
+		},
+		{
+			name:     "default installation is treated as cloud",
+			server:   "https://example.atlassian.net",
+			uploaded: one,
+			expected: "https://example.atlassian.net/browse/TEST-1?focusedAttachmentId=10001",
+		},
+		{
+			name:         "multiple attachments link to the issue",
+			server:       "https://example.atlassian.net",
+			uploaded:     two,
+			installation: jira.InstallationTypeCloud,
+			expected:     "https://example.atlassian.net/browse/TEST-1",
+		},
+		{
+			name:         "local installation is never focused",
+			server:       "https://jira.example.com",
+			uploaded:     one,
+			installation: jira.InstallationTypeLocal,
+			expected:     "https://jira.example.com/browse/TEST-1",
+		},
+		{
+			name:         "context path is preserved",
+			server:       "https://example.com/jira/",
+			uploaded:     one,
+			installation: jira.InstallationTypeCloud,
+			expected:     "https://example.com/jira/browse/TEST-1?focusedAttachmentId=10001",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, attachmentBrowseURL(tc.server, "TEST-1", tc.uploaded, tc.installation))
+		})
+	}
+}
+
+func TestNavigate(t *testing.T) {
+	t.Parallel()
+
+	o := &fakeOpener{}
+	assert.NoError(t, navigate(o, "https://example.com/browse/TEST-1", true))
+	assert.Equal(t, []string{"https://example.com/browse/TEST-1"}, o.urls)
+
+	o = &fakeOpener{}
+	assert.NoError(t, navigate(o, "https://example.com/browse/TEST-1", false))
+	assert.Empty(t, o.urls)
+
+	o = &fakeOpener{err: errors.New("no browser")}
+	assert.Error(t, navigate(o, "https://example.com/browse/TEST-1", true))
+}
+
+func TestHasDisplay(t *testing.T) {
+	t.Parallel()
+
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+
+	assert.True(t, hasDisplay(env(map[string]string{"DISPLAY": ":0"}), "linux"))
+	assert.False(t, hasDisplay(env(map[string]string{"SSH_TTY": "/dev/pts/0"}), "linux"))
+	assert.True(t, hasDisplay(env(map[string]string{"BROWSER": "lynx"}), "linux"))
+	assert.True(t, hasDisplay(env(nil), "darwin"))
+	assert.False(t, hasDisplay(env(map[string]string{"SSH_CONNECTION": "1.2.3.4"}), "darwin"))
+}
+
+func TestUploadErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, jira.ErrNoAttachmentCreated.Error(), uploadErrorMessage(jira.ErrNoAttachmentCreated))
+
+	locked := &jira.AttachmentError{Op: jira.AttachmentOpUpload, Status: "423 Locked", StatusCode: 423, Retryable: true}
+	assert.Equal(t, "the issue appears to be temporarily locked (423 Locked), please retry later", uploadErrorMessage(locked))
+
+	unexpected := &jira.ErrUnexpectedResponse{
+		Body:       jira.Errors{ErrorMessages: []string{"File too large"}},
+		Status:     "413 Payload Too Large",
+		StatusCode: 413,
+	}
+	assert.Equal(t, "File too large", uploadErrorMessage(unexpected))
+
+	unexpected = &jira.ErrUnexpectedResponse{Status: "500 Internal Server Error", StatusCode: 500}
+	assert.Equal(t, "500 Internal Server Error", uploadErrorMessage(unexpected))
+
+	tooLarge := &jira.UploadTooLargeError{Size: 1 << 20, Limit: 10 << 20, Proxy: true, Err: &jira.ErrUnexpectedResponse{
+		Status: "413 Request Entity Too Large", StatusCode: 413,
+	}}
+	assert.True(t, strings.HasPrefix(uploadErrorMessage(tooLarge),
+		"the server or a proxy in front of it rejected the upload as too large (1048576 bytes); the Jira attachment limit reported by the instance is 10MB."))
+}
+
+func TestChunkedHint(t *testing.T) {
+	proxy := &jira.UploadTooLargeError{Size: 1 << 20, Proxy: true}
+	dropped := &jira.UploadInterruptedError{Size: 1 << 20, Err: errors.New("write: broken pipe")}
+	byJira := &jira.UploadTooLargeError{Size: 1 << 20}
+
+	params := &addParams{apiVersion: "3"}
+	assert.Contains(t, params.chunkedHint(proxy), "--chunked")
+	assert.Contains(t, params.chunkedHint(fmt.Errorf("upload: %w", dropped)), "--chunked")
+	assert.Empty(t, params.chunkedHint(byJira), "chunks don't get around the limit of Jira")
+	assert.Empty(t, params.chunkedHint(errors.New("other")))
+
+	assert.Empty(t, (&addParams{apiVersion: "3", chunked: true}).chunkedHint(proxy), "already chunked")
+	assert.Empty(t, (&addParams{apiVersion: "2"}).chunkedHint(proxy), "v3 only")
+}
+
+func TestConvertLineEndings(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("one\r\ntwo\r\n"), 0o644))
+
+	path, cleanup, err := convertLineEndings(file, eol.ModeNone)
+	assert.NoError(t, err)
+	assert.Equal(t, file, path)
+	cleanup()
+
+	path, cleanup, err = convertLineEndings(file, eol.ModeLF)
+	assert.NoError(t, err)
+	assert.NotEqual(t, file, path)
+	assert.Equal(t, "notes.txt", filepath.Base(path))
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", string(b))
+
+	cleanup()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// The original file is never modified.
+	b, err = os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "one\r\ntwo\r\n", string(b))
+}
+
+func writeFiles(t *testing.T, names ...string) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(name), 0o600))
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestUploadFilesStopsOnAuthFailure(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	// The token expires after the second upload.
+	server.SetFaults(jiratest.Faults{UnauthorizedAfter: 2})
+
+	files := writeFiles(t, "1.txt", "2.txt", "3.txt", "4.txt", "5.txt")
+	res := uploadFiles(server.Client(), &addParams{issueKey: "TEST-1", files: files})
+
+	assert.Len(t, res.uploaded, 2)
+	assert.Equal(t, 1, res.failed)
+	assert.Equal(t, files[3:], res.notAttempted)
+	assert.True(t, res.guard.Stopped())
+	assert.Contains(t, res.guard.Reason(), "midway")
+
+	// No request is sent for the files after the rejected one.
+	assert.Len(t, server.Requests(), 3)
+	assert.Len(t, server.Attachments("TEST-1"), 2)
+}
+
+func TestUploadFilesContinuesOnOtherFailures(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithUploadLimit(5))
+	defer server.Close()
+
+	files := writeFiles(t, "1.txt", "too-large.txt", "3.txt")
+	res := uploadFiles(server.Client(), &addParams{issueKey: "TEST-1", files: files})
+
+	assert.Len(t, res.uploaded, 2)
+	assert.Equal(t, 1, res.failed)
+	assert.Empty(t, res.notAttempted)
+	assert.False(t, res.guard.Stopped())
+}
+
+func TestAddReportsUploadLimit(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithUploadLimit(5))
+	defer server.Close()
+
+	files := writeFiles(t, "too-large.txt")
+	res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentAdd(), "TEST-1", files[0], "--no-input")
+	assert.Error(t, res.Err)
+	assert.Contains(t, res.Stderr, "Jira rejected the upload as too large")
+	assert.Contains(t, res.Stderr, "the Jira attachment limit reported by the instance is 5 bytes")
+	assert.NotContains(t, res.Stderr, "--chunked", "chunks don't get around the limit of Jira")
+}
+
+func TestDescribeUpload(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `"logs/local.log"`, describeUpload("logs/local.log", "local.log", []string{"local.log"}))
+	assert.Equal(t, `"logs/local.log" as "local (1).log"`, describeUpload("logs/local.log", "local.log", []string{"local (1).log"}))
+	assert.Equal(t, `"a.zip"`, describeUpload("a.zip", "a.zip", nil))
+}
+
+func TestAddReportsServerFilename(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithRenameOnCollision())
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "local.log", []byte("old"))
+	file := writeFiles(t, "local.log")[0]
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "auth.check_token_expiry": false},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEST-1", file, "--no-input")
+	assert.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, fmt.Sprintf(`Uploaded %q as "local (1).log" to issue "TEST-1"`, file))
+
+	attachments := server.Attachments("TEST-1")
+	assert.Equal(t, "local (1).log", attachments[1].Filename)
+}
+
+func TestAddKeepsEscapedFilenames(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	names := []string{`report "final".pdf`, `back\slash.txt`, "a;b.txt", " padded.txt "}
+	files := writeFiles(t, names...)
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "auth.check_token_expiry": false},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), append([]string{"TEST-1", "--no-input"}, files...)...)
+	assert.NoError(t, res.Err)
+
+	attachments := server.Attachments("TEST-1")
+	stored := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		stored = append(stored, a.Filename)
+	}
+	assert.ElementsMatch(t, names, stored)
+}
+
+func TestAbortResume(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dir, err := uploadSessionDir()
+	assert.NoError(t, err)
+	store := jira.FileSessionStore{Dir: dir}
+
+	files := writeFiles(t, "a.bin", "b.bin")
+	for _, f := range files {
+		assert.NoError(t, store.Save(&jira.SavedUpload{Issue: "TEST-1", Path: f, SessionID: "session-1"}))
+	}
+
+	env := cmdtest.Env{Config: map[string]any{"auth.check_token_expiry": false}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEST-1", files[0], "--abort-resume")
+	assert.NoError(t, res.Err)
+
+	u, err := store.Load("TEST-1", files[0])
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+	u, err = store.Load("TEST-1", files[1])
+	assert.NoError(t, err)
+	assert.NotNil(t, u)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentAdd(), "--abort-resume")
+	assert.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "Cleared 1 saved upload session(s)")
+
+	u, err = store.Load("TEST-1", files[1])
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+}
+
+func TestAddConfirmTruncatesList(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	names := make([]string, 0, 12)
+	for i := range 12 {
+		names = append(names, fmt.Sprintf("file-%02d.txt", i+1))
+	}
+	files := writeFiles(t, names...)
+
+	var messages []string
+	prevAsk := ask
+	ask = func(qs []*survey.Question, response any, _ ...survey.AskOpt) error {
+		messages = append(messages, qs[0].Prompt.(*survey.Select).Message)
+		response.(*struct{ Action string }).Action = cmdcommon.ActionCancel
+		return nil
+	}
+	t.Cleanup(func() { ask = prevAsk })
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "auth.check_token_expiry": false},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), append([]string{"TEST-1"}, files...)...)
+	assert.ErrorContains(t, res.Err, "Action aborted")
+	assert.Empty(t, server.Attachments("TEST-1"))
+
+	if assert.Len(t, messages, 1) {
+		assert.True(t, strings.HasPrefix(messages[0], "Upload 12 file(s) to TEST-1? (12 items, "))
+		assert.Contains(t, messages[0], "file-10.txt")
+		assert.NotContains(t, messages[0], "file-11.txt")
+		assert.Contains(t, messages[0], "… and 2 more")
+	}
+}
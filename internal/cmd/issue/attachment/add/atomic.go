@@ -0,0 +1,87 @@
+package add
+
+import (
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// rollback is the outcome of deleting the attachments of a failed --atomic upload.
+type rollback struct {
+	deleted []jira.Attachment
+	failed  []rollbackFailure
+	// skipped is set if the credentials were rejected, the deletes would be rejected as well.
+	skipped bool
+}
+
+type rollbackFailure struct {
+	attachment jira.Attachment
+	err        error
+}
+
+// uploadAtomic uploads the files all or nothing. It stops at the first file that fails and
+// deletes the attachments uploaded before it, so that the issue is left as it was found.
+// The rollback is nil if every file was uploaded.
+func uploadAtomic(client *jira.Client, params *addParams) (*uploadResult, *rollback) {
+	res := uploadFiles(client, params)
+	if res.failed == 0 && !res.guard.Stopped() {
+		return res, nil
+	}
+
+	return res, rollbackUploads(res.uploaded, res.guard.Stopped(), func(id string) error {
+		return api.ProxyDeleteAttachmentVersion(client, params.apiVersion, id)
+	})
+}
+
+// rollbackUploads deletes the attachments uploaded by this run. Only the ids returned by the
+// uploads are deleted, never an attachment that was on the issue before. It carries on past a
+// failed delete so that as much as possible is cleaned up.
+func rollbackUploads(uploaded []jira.Attachment, authFailed bool, del func(id string) error) *rollback {
+	var rb rollback
+	if authFailed {
+		rb.skipped = true
+		return &rb
+	}
+
+	for _, a := range uploaded {
+		if err := del(a.ID); err != nil {
+			rb.failed = append(rb.failed, rollbackFailure{attachment: a, err: err})
+			continue
+		}
+		rb.deleted = append(rb.deleted, a)
+	}
+	return &rb
+}
+
+// atomicError reports what was rolled back and what is left on the issue.
+func atomicError(res *uploadResult, rb *rollback, key string) error {
+	for _, file := range res.notAttempted {
+		cmdutil.Fail("Not attempted: %q", file)
+	}
+
+	if rb.skipped {
+		if len(res.uploaded) == 0 {
+			return cmdutil.Errorf("Stopped before anything was uploaded to issue %q: %s", key, res.guard.Reason())
+		}
+		cmdutil.Warn("WARNING: not rolling back, %s. The following attachment(s) are left on issue %q and must be deleted by hand:",
+			res.guard.Reason(), key)
+		for _, a := range res.uploaded {
+			cmdutil.Warn("  %q (ID: %s)", a.Filename, a.ID)
+		}
+		return cmdutil.Errorf("Atomic upload to issue %q failed and was not rolled back, %d attachment(s) are left on the issue",
+			key, len(res.uploaded))
+	}
+
+	for _, a := range rb.deleted {
+		cmdutil.Warn("Rolled back %q (ID: %s)", a.Filename, a.ID)
+	}
+	for _, f := range rb.failed {
+		cmdutil.Fail("Unable to roll back %q (ID: %s): %s", f.attachment.Filename, f.attachment.ID, uploadErrorMessage(f.err))
+	}
+	if len(rb.failed) > 0 {
+		return cmdutil.Errorf("Atomic upload to issue %q failed and %d of %d attachment(s) could not be rolled back, delete them by hand",
+			key, len(rb.failed), len(res.uploaded))
+	}
+	return cmdutil.Errorf("Atomic upload to issue %q failed, the %d attachment(s) uploaded before the failure were deleted",
+		key, len(rb.deleted))
+}
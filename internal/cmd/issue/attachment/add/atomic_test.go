@@ -0,0 +1,167 @@
+package add
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func attachmentFilenames(attachments []jira.Attachment) []string {
+	names := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		names = append(names, a.Filename)
+	}
+	return names
+}
+
+func TestUploadAtomicRollsBack(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	existing := server.AddAttachment("TEST-1", "report.pdf", []byte("previous report"))
+	server.SetFaults(jiratest.Faults{FailUpload: 3})
+
+	files := writeFiles(t, "report.pdf", "data.csv", "chart.png", "notes.txt")
+	res, rb := uploadAtomic(server.Client(), &addParams{issueKey: "TEST-1", files: files, atomic: true})
+	require.NotNil(t, rb)
+
+	assert.Equal(t, []string{"report.pdf", "data.csv"}, attachmentFilenames(res.uploaded))
+	assert.Equal(t, 1, res.failed)
+	assert.Equal(t, files[3:], res.notAttempted)
+
+	// Only the attachments of this run are deleted, not the report that was there before
+	// even though it has the same name.
+	assert.False(t, rb.skipped)
+	assert.Empty(t, rb.failed)
+	assert.Equal(t, res.uploaded, rb.deleted)
+	assert.NotEqual(t, existing.ID, rb.deleted[0].ID)
+
+	assert.Equal(t, []jira.Attachment{existing}, server.Attachments("TEST-1"))
+
+	var deletes []string
+	for _, r := range server.Requests() {
+		if r.Method == "DELETE" {
+			deletes = append(deletes, r.Path)
+		}
+	}
+	assert.Equal(t, []string{
+		"/rest/api/3/attachment/" + res.uploaded[0].ID,
+		"/rest/api/3/attachment/" + res.uploaded[1].ID,
+	}, deletes)
+}
+
+func TestUploadAtomicSucceeds(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	files := writeFiles(t, "1.txt", "2.txt")
+	res, rb := uploadAtomic(server.Client(), &addParams{issueKey: "TEST-1", files: files, atomic: true})
+	assert.Nil(t, rb)
+	assert.Len(t, res.uploaded, 2)
+	assert.Len(t, server.Attachments("TEST-1"), 2)
+}
+
+func TestUploadAtomicSkipsRollbackOnAuthFailure(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.SetFaults(jiratest.Faults{UnauthorizedAfter: 2})
+
+	files := writeFiles(t, "1.txt", "2.txt", "3.txt", "4.txt")
+	res, rb := uploadAtomic(server.Client(), &addParams{issueKey: "TEST-1", files: files, atomic: true})
+	require.NotNil(t, rb)
+
+	assert.True(t, rb.skipped)
+	assert.Empty(t, rb.deleted)
+	assert.Len(t, res.uploaded, 2)
+	// No delete is attempted with rejected credentials.
+	assert.Len(t, server.Requests(), 3)
+}
+
+func TestRollbackUploadsCarriesOn(t *testing.T) {
+	t.Parallel()
+
+	uploaded := []jira.Attachment{{ID: "1", Filename: "a"}, {ID: "2", Filename: "b"}, {ID: "3", Filename: "c"}}
+
+	var calls []string
+	rb := rollbackUploads(uploaded, false, func(id string) error {
+		calls = append(calls, id)
+		if id == "2" {
+			return errors.New("forbidden")
+		}
+		return nil
+	})
+
+	assert.Equal(t, []string{"1", "2", "3"}, calls)
+	assert.Equal(t, []string{"a", "c"}, attachmentFilenames(rb.deleted))
+	require.Len(t, rb.failed, 1)
+	assert.Equal(t, "b", rb.failed[0].attachment.Filename)
+}
+
+func TestAddAtomic(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.SetFaults(jiratest.Faults{FailUpload: 3})
+
+	files := writeFiles(t, "report.pdf", "data.csv", "chart.png", "notes.txt")
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "auth.check_token_expiry": false},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), append([]string{"TEST-1", "--no-input", "--atomic", "--keep-order"}, files...)...)
+	assert.EqualError(t, res.Err, `Atomic upload to issue "TEST-1" failed, the 2 attachment(s) uploaded before the failure were deleted`)
+
+	assert.Contains(t, res.Stderr, `Failed to upload "`+files[2]+`"`)
+	assert.Contains(t, res.Stderr, `Not attempted: "`+files[3]+`"`)
+	assert.Contains(t, res.Stderr, `Rolled back "report.pdf"`)
+	assert.Contains(t, res.Stderr, `Rolled back "data.csv"`)
+	assert.Empty(t, server.Attachments("TEST-1"))
+}
+
+func TestAddAtomicRollbackFailure(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.SetFaults(jiratest.Faults{FailUpload: 2, FailDeletes: true})
+
+	files := writeFiles(t, "report.pdf", "data.csv")
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "auth.check_token_expiry": false},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), append([]string{"TEST-1", "--no-input", "--atomic", "--keep-order"}, files...)...)
+	assert.EqualError(t, res.Err, `Atomic upload to issue "TEST-1" failed and 1 of 1 attachment(s) could not be rolled back, delete them by hand`)
+	assert.Contains(t, res.Stderr, `Unable to roll back "report.pdf"`)
+	assert.Len(t, server.Attachments("TEST-1"), 1)
+}
+
+func TestAddAtomicAuthFailure(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.SetFaults(jiratest.Faults{UnauthorizedAfter: 1})
+
+	files := writeFiles(t, "report.pdf", "data.csv")
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "auth.check_token_expiry": false},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), append([]string{"TEST-1", "--no-input", "--atomic", "--keep-order"}, files...)...)
+	assert.EqualError(t, res.Err, `Atomic upload to issue "TEST-1" failed and was not rolled back, 1 attachment(s) are left on the issue`)
+	assert.Contains(t, res.Stderr, "WARNING: not rolling back")
+	assert.Contains(t, res.Stderr, `"report.pdf" (ID: `)
+	assert.Len(t, server.Attachments("TEST-1"), 1)
+}
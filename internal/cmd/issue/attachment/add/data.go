@@ -0,0 +1,185 @@
+package add
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/where"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// defaultDataMaxSize is the default cap of the content given with --data-base64, which is
+// held in memory as a whole.
+const defaultDataMaxSize = 10 << 20
+
+// dataMaxSize returns attachment.data_max_size, 10MB by default.
+func dataMaxSize() (int64, error) {
+	s := viper.GetString("attachment.data_max_size")
+	if s == "" {
+		return defaultDataMaxSize, nil
+	}
+	n, err := where.ParseSize(s)
+	if err != nil || n <= 0 {
+		return 0, cmdutil.Errorf("Invalid attachment.data_max_size %q, expected a size like 10MB", s)
+	}
+	return n, nil
+}
+
+// readDataArg returns the base64 given with --data-base64, read from the file with @file.
+func readDataArg(value string) (string, error) {
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", cmdutil.Errorf("Unable to read --data-base64 from %q: %s", path, err)
+	}
+	return string(b), nil
+}
+
+// decodeData decodes standard base64, with or without padding, optionally prefixed as a data
+// URI, eg: data:image/png;base64,iVBOR... Whitespace is ignored to accept wrapped lines. The
+// content must not be larger than max bytes.
+func decodeData(s string, max int64) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	if rest, ok := strings.CutPrefix(s, "data:"); ok {
+		meta, data, found := strings.Cut(rest, ",")
+		if !found || !strings.HasSuffix(meta, ";base64") {
+			return nil, cmdutil.Errorf("Invalid --data-base64: data URIs must be base64 encoded, eg: data:text/plain;base64,aGk=")
+		}
+		s = data
+	}
+	if s == "" {
+		return nil, cmdutil.Errorf("Invalid --data-base64: no content")
+	}
+
+	// Check the size before decoding to not allocate an oversized payload.
+	enc := base64.StdEncoding
+	if len(s)%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	if int64(enc.DecodedLen(len(s))) > max+2 {
+		return nil, dataTooLargeError(max)
+	}
+
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return nil, cmdutil.Errorf("Invalid --data-base64: %s", err)
+	}
+	if int64(len(b)) > max {
+		return nil, dataTooLargeError(max)
+	}
+	return b, nil
+}
+
+func dataTooLargeError(max int64) error {
+	return cmdutil.Errorf("--data-base64 content is larger than %s, raise attachment.data_max_size to upload it", cmdutil.FormatSize(max))
+}
+
+// validateData checks the flags of an upload of --data-base64 and decodes the content.
+func validateData(params *addParams) error {
+	if params.dataBase64 == "" {
+		if params.filename != "" {
+			return cmdutil.Errorf("--filename can only be used with --data-base64")
+		}
+		return nil
+	}
+
+	if params.manifest != "" {
+		return cmdutil.Errorf("--data-base64 can't be used with --from-manifest")
+	}
+	if len(params.files) > 0 {
+		return cmdutil.Errorf("FILE can't be used with --data-base64")
+	}
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"--chunked", params.chunked},
+		{"--eol", params.eol != ""},
+		{"--max-image-dimension", params.image.MaxDimension > 0},
+	} {
+		if f.set {
+			return cmdutil.Errorf("%s can't be used with --data-base64", f.name)
+		}
+	}
+	if params.filename == "" {
+		return cmdutil.Errorf("--filename is required with --data-base64")
+	}
+	if filepath.Base(params.filename) != params.filename || strings.ContainsAny(params.filename, `/\`) {
+		return cmdutil.Errorf("Invalid --filename %q, expected a file name without directories", params.filename)
+	}
+	if params.issueKey == "" {
+		return cmdutil.Errorf("ISSUE-KEY is required")
+	}
+
+	max, err := dataMaxSize()
+	if err != nil {
+		return err
+	}
+	s, err := readDataArg(params.dataBase64)
+	if err != nil {
+		return err
+	}
+	params.data, err = decodeData(s, max)
+	return err
+}
+
+// addData uploads the content given with --data-base64 as params.filename. There is no local
+// file to run the upload hooks on, so they are skipped.
+func addData(cmd *cobra.Command, client *jira.Client, params *addParams) error {
+	if err := cmdcommon.CheckTokenExpiry(client); err != nil {
+		return err
+	}
+	if err := checkProject(client, params); err != nil {
+		return err
+	}
+
+	if !params.noInput {
+		items := []cmdcommon.ConfirmItem{{Label: params.filename, Size: int64(len(params.data))}}
+		ok, err := cmdcommon.Confirm(ask, viewList, fmt.Sprintf("Upload 1 file(s) to %s%s?", params.target(), cmdcommon.OnInstance()), items)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return cmdutil.Errorf("Action aborted")
+		}
+	}
+
+	var res uploadResult
+	attachments, err := func() ([]jira.Attachment, error) {
+		s := cmdutil.Info(fmt.Sprintf("Uploading %s", params.filename))
+		defer s.Stop()
+
+		return api.ProxyUploadAttachmentFromReaderVersion(client, params.apiVersion, params.issueKey, params.filename, bytes.NewReader(params.data))
+	}()
+	switch {
+	case errors.Is(err, jira.ErrDryRun):
+		res.dryRun++
+		cmdutil.DryRun("Would upload %q (%s) to issue %q", params.filename, cmdutil.FormatSize(int64(len(params.data))), params.issueKey)
+	case err != nil:
+		res.fail(err)
+		withUploadLimit(client, params.apiVersion, err)
+	default:
+		res.uploaded = attachments
+		cmdutil.Success("Uploaded %s to issue %q", describeUpload(params.filename, params.filename, attachmentNames(attachments)), params.issueKey)
+	}
+	params.notify(&res, res.summary(params.issueKey, 1))
+
+	if err != nil && res.dryRun == 0 {
+		return cmdutil.Errorf("Failed to upload %q: %s", params.filename, uploadErrorMessage(err))
+	}
+	return finishUpload(cmd, client, params, &res)
+}
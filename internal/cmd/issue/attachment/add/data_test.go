@@ -0,0 +1,189 @@
+package add
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestDecodeData(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		in      string
+		max     int64
+		want    string
+		wantErr string
+	}{
+		{name: "plain", in: "aGVsbG8gd29ybGQ=", max: 100, want: "hello world"},
+		{name: "unpadded", in: "aGVsbG8gd29ybGQ", max: 100, want: "hello world"},
+		{name: "data uri", in: "data:text/plain;base64,aGVsbG8gd29ybGQ=", max: 100, want: "hello world"},
+		{name: "data uri with parameters", in: "data:text/plain;charset=utf-8;base64,aGk=", max: 100, want: "hi"},
+		{name: "wrapped lines", in: "aGVsbG8g\nd29ybGQ=\n", max: 100, want: "hello world"},
+		{name: "exactly the cap", in: "aGVsbG8gd29ybGQ=", max: 11, want: "hello world"},
+		{
+			name:    "over the cap",
+			in:      "aGVsbG8gd29ybGQ=",
+			max:     10,
+			wantErr: "--data-base64 content is larger than 10 B, raise attachment.data_max_size to upload it",
+		},
+		{
+			name:    "far over the cap is rejected before decoding",
+			in:      strings.Repeat("A", 4<<10),
+			max:     1 << 10,
+			wantErr: "--data-base64 content is larger than 1.00 KB, raise attachment.data_max_size to upload it",
+		},
+		{name: "invalid", in: "not base64!", max: 100, wantErr: "Invalid --data-base64: illegal base64 data at input byte 9"},
+		{
+			name:    "data uri not base64",
+			in:      "data:text/plain,hello",
+			max:     100,
+			wantErr: "Invalid --data-base64: data URIs must be base64 encoded, eg: data:text/plain;base64,aGk=",
+		},
+		{name: "empty", in: "data:text/plain;base64,", max: 100, wantErr: "Invalid --data-base64: no content"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := decodeData(tc.in, tc.max)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestAddDataBase64(t *testing.T) {
+	// Every byte value, to check the content reaches the server unaltered.
+	content := make([]byte, 0, 512)
+	for i := range 512 {
+		content = append(content, byte(i))
+	}
+	encoded := base64.StdEncoding.EncodeToString(content)
+
+	payload := filepath.Join(t.TempDir(), "payload.b64")
+	require.NoError(t, os.WriteFile(payload, []byte("data:application/octet-stream;base64,"+encoded+"\n"), 0o600))
+
+	cases := []struct {
+		name         string
+		value        string
+		installation string
+	}{
+		{name: "plain", value: encoded},
+		{name: "data uri with v2", value: "data:application/octet-stream;base64," + encoded, installation: "Local"},
+		{name: "file", value: "@" + payload},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+			defer server.Close()
+
+			env := cmdtest.Env{
+				Client: server.Client(),
+				Config: map[string]any{"server": server.URL, "installation": tc.installation, "auth.check_token_expiry": false},
+			}
+			res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEST-1", "--data-base64", tc.value, "--filename", "blob.bin", "--no-input")
+			require.NoError(t, res.Err)
+			assert.Contains(t, res.Stdout, `Uploaded "blob.bin" to issue "TEST-1"`)
+
+			attachments := server.Attachments("TEST-1")
+			require.Len(t, attachments, 1)
+			assert.Equal(t, "blob.bin", attachments[0].Filename)
+			got, ok := server.Content(attachments[0].ID)
+			require.True(t, ok)
+			assert.Equal(t, content, got)
+		})
+	}
+}
+
+func TestAddDataBase64FailsBeforeRequests(t *testing.T) {
+	file := writeFiles(t, "notes.txt")[0]
+
+	cases := []struct {
+		name    string
+		args    []string
+		config  map[string]any
+		wantErr string
+	}{
+		{
+			name:    "missing filename",
+			args:    []string{"TEST-1", "--data-base64", "aGk="},
+			wantErr: "--filename is required with --data-base64",
+		},
+		{
+			name:    "filename without data",
+			args:    []string{"TEST-1", file, "--filename", "a.txt"},
+			wantErr: "--filename can only be used with --data-base64",
+		},
+		{
+			name:    "with files",
+			args:    []string{"TEST-1", file, "--data-base64", "aGk=", "--filename", "a.txt"},
+			wantErr: "FILE can't be used with --data-base64",
+		},
+		{
+			name:    "with chunked",
+			args:    []string{"TEST-1", "--data-base64", "aGk=", "--filename", "a.txt", "--chunked"},
+			wantErr: "--chunked can't be used with --data-base64",
+		},
+		{
+			name:    "filename with directories",
+			args:    []string{"TEST-1", "--data-base64", "aGk=", "--filename", "../a.txt"},
+			wantErr: `Invalid --filename "../a.txt", expected a file name without directories`,
+		},
+		{
+			name:    "invalid base64",
+			args:    []string{"TEST-1", "--data-base64", "aGk=!", "--filename", "a.txt"},
+			wantErr: "Invalid --data-base64: illegal base64 data at input byte 3",
+		},
+		{
+			name:    "over the configured cap",
+			args:    []string{"TEST-1", "--data-base64", "aGVsbG8gd29ybGQ=", "--filename", "a.txt"},
+			config:  map[string]any{"attachment.data_max_size": "8"},
+			wantErr: "--data-base64 content is larger than 8 B, raise attachment.data_max_size to upload it",
+		},
+		{
+			name:    "invalid cap",
+			args:    []string{"TEST-1", "--data-base64", "aGk=", "--filename", "a.txt"},
+			config:  map[string]any{"attachment.data_max_size": "big"},
+			wantErr: `Invalid attachment.data_max_size "big", expected a size like 10MB`,
+		},
+		{
+			name:    "missing file",
+			args:    []string{"TEST-1", "--data-base64", "@" + filepath.Join(t.TempDir(), "missing.b64"), "--filename", "a.txt"},
+			wantErr: "Unable to read --data-base64 from",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+			defer server.Close()
+
+			config := map[string]any{"server": server.URL, "auth.check_token_expiry": false}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+			env := cmdtest.Env{Client: server.Client(), Config: config}
+			res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), append(tc.args, "--no-input")...)
+			require.Error(t, res.Err)
+			assert.Contains(t, res.Err.Error(), tc.wantErr)
+			assert.Empty(t, server.Requests())
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package add
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/hooks"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// errHookRejected is returned when the pre-upload hook blocks a file.
+type errHookRejected struct {
+	err error
+}
+
+func (e *errHookRejected) Error() string {
+	return fmt.Sprintf("upload rejected: %s", e.err)
+}
+
+func (e *errHookRejected) Unwrap() error {
+	return e.err
+}
+
+// uploadHooks run before and after each file is uploaded.
+type uploadHooks struct {
+	pre  hooks.Hook
+	post hooks.Hook
+}
+
+// newUploadHooks configures hooks from flags, falling back to the attachment.pre_upload_hook
+// and attachment.post_upload_hook config. The post-upload hook is best-effort as the file is
+// already uploaded by the time it runs.
+func newUploadHooks(preFlag, postFlag string, timeout time.Duration) uploadHooks {
+	pick := func(flag, key string) string {
+		if flag != "" {
+			return flag
+		}
+		return viper.GetString(key)
+	}
+
+	return uploadHooks{
+		pre: hooks.Hook{
+			Name:    "pre-upload",
+			Command: pick(preFlag, "attachment.pre_upload_hook"),
+			Timeout: timeout,
+			Stdout:  os.Stderr,
+			Stderr:  os.Stderr,
+		},
+		post: hooks.Hook{
+			Name:       "post-upload",
+			Command:    pick(postFlag, "attachment.post_upload_hook"),
+			Timeout:    timeout,
+			BestEffort: true,
+			Stdout:     os.Stderr,
+			Stderr:     os.Stderr,
+		},
+	}
+}
+
+// before runs the pre-upload hook. A failing hook blocks the upload of the file.
+func (h uploadHooks) before(key, file, name string) error {
+	err := h.pre.Run(context.Background(), hooks.Env{
+		hooks.EnvPhase:          h.pre.Name,
+		hooks.EnvIssueKey:       key,
+		hooks.EnvAttachmentFile: absPath(file),
+		hooks.EnvAttachmentName: name,
+	})
+	if err != nil {
+		return &errHookRejected{err: err}
+	}
+	return nil
+}
+
+// after runs the post-upload hook once for every attachment created.
+func (h uploadHooks) after(key, file string, attachments []jira.Attachment) error {
+	for _, a := range attachments {
+		err := h.post.Run(context.Background(), hooks.Env{
+			hooks.EnvPhase:          h.post.Name,
+			hooks.EnvIssueKey:       key,
+			hooks.EnvAttachmentFile: absPath(file),
+			hooks.EnvAttachmentName: a.Filename,
+			hooks.EnvAttachmentID:   a.ID,
+			hooks.EnvAttachmentURL:  a.Content,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func absPath(file string) string {
+	if p, err := filepath.Abs(file); err == nil {
+		return p
+	}
+	return file
+}
@@ -0,0 +1,91 @@
+package add
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/internal/hooks"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func newTestHooks(pre, post string, out *bytes.Buffer) uploadHooks {
+	return uploadHooks{
+		pre:  hooks.Hook{Name: "pre-upload", Command: pre, Timeout: 5 * time.Second, Stdout: out, Stderr: out},
+		post: hooks.Hook{Name: "post-upload", Command: post, Timeout: 5 * time.Second, BestEffort: true, Stdout: out, Stderr: out},
+	}
+}
+
+func TestUploadHooksBefore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are tested with sh")
+	}
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	h := newTestHooks(`echo "$JIRA_HOOK_PHASE $JIRA_ISSUE_KEY $JIRA_ATTACHMENT_NAME $JIRA_ATTACHMENT_FILE"`, "", &out)
+	assert.NoError(t, h.before("TEST-1", "report.pdf", "report.pdf"))
+
+	abs, _ := filepath.Abs("report.pdf")
+	assert.Equal(t, "[pre-upload] pre-upload TEST-1 report.pdf "+abs+"\n", out.String())
+
+	h = newTestHooks("exit 3", "", &out)
+	err := h.before("TEST-1", "report.pdf", "report.pdf")
+
+	var rejected *errHookRejected
+	assert.True(t, errors.As(err, &rejected))
+	assert.Equal(t, "upload rejected: pre-upload hook exited with status 3", err.Error())
+
+	// No hook configured.
+	assert.NoError(t, uploadHooks{}.before("TEST-1", "report.pdf", "report.pdf"))
+}
+
+func TestUploadHooksAfter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are tested with sh")
+	}
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	h := newTestHooks("", `echo "$JIRA_HOOK_PHASE $JIRA_ATTACHMENT_ID $JIRA_ATTACHMENT_NAME $JIRA_ATTACHMENT_URL"`, &out)
+	err := h.after("TEST-1", "report.pdf", []jira.Attachment{
+		{ID: "10001", Filename: "report.pdf", Content: "https://jira.example.com/attachment/10001"},
+		{ID: "10002", Filename: "report-1.pdf", Content: "https://jira.example.com/attachment/10002"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"[post-upload] post-upload 10001 report.pdf https://jira.example.com/attachment/10001",
+		"[post-upload] post-upload 10002 report-1.pdf https://jira.example.com/attachment/10002",
+	}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+
+	// The file is already uploaded, so a failing post-upload hook only warns.
+	out.Reset()
+	h = newTestHooks("", "exit 1", &out)
+	assert.NoError(t, h.after("TEST-1", "report.pdf", []jira.Attachment{{ID: "10001"}}))
+	assert.Equal(t, "Warning: post-upload hook exited with status 1\n", out.String())
+}
+
+func TestUploadHooksTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are tested with sh")
+	}
+	t.Parallel()
+
+	h := uploadHooks{pre: hooks.Hook{Name: "pre-upload", Command: "sleep 10", Timeout: 100 * time.Millisecond}}
+
+	start := time.Now()
+	err := h.before("TEST-1", "report.pdf", "report.pdf")
+
+	var herr *hooks.Error
+	assert.True(t, errors.As(err, &herr))
+	assert.True(t, herr.TimedOut)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
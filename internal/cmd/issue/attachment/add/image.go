@@ -0,0 +1,46 @@
+package add
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/imgscale"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+// downscaleImage writes a downscaled copy of a PNG or JPEG image larger than the maximum
+// dimension to a temporary directory, keeping the original filename. The original path is
+// returned as is, with a nil result, if no maximum is set, the file is not an image or the
+// image already fits.
+func downscaleImage(file string, opts imgscale.Options) (string, func(), *imgscale.Result, error) {
+	noop := func() {}
+	if opts.MaxDimension <= 0 {
+		return file, noop, nil, nil
+	}
+
+	dir, err := os.MkdirTemp("", "jira-attachment-")
+	if err != nil {
+		return "", noop, nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	dst := filepath.Join(dir, filepath.Base(file))
+	res, err := imgscale.ResizeFile(dst, file, opts)
+	if err != nil {
+		cleanup()
+		return file, noop, nil, fmt.Errorf("unable to downscale image: %w", err)
+	}
+	if !res.Resized {
+		cleanup()
+		return file, noop, nil, nil
+	}
+	return dst, cleanup, res, nil
+}
+
+// describeDownscale describes the dimensions and sizes of a downscaled image before and after.
+func describeDownscale(res *imgscale.Result) string {
+	return fmt.Sprintf("downscaled %dx%d -> %dx%d, %s -> %s",
+		res.Width, res.Height, res.NewWidth, res.NewHeight, cmdutil.FormatSize(res.Size), cmdutil.FormatSize(res.NewSize))
+}
@@ -0,0 +1,100 @@
+package add
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/imgscale"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	assert.NoError(t, png.Encode(f, image.NewGray(image.Rect(0, 0, w, h))))
+}
+
+func TestDownscaleImage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	large := filepath.Join(dir, "large.png")
+	writePNG(t, large, 300, 200)
+	small := filepath.Join(dir, "small.png")
+	writePNG(t, small, 30, 20)
+
+	path, cleanup, res, err := downscaleImage(large, imgscale.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, large, path)
+	assert.Nil(t, res)
+	cleanup()
+
+	path, cleanup, res, err = downscaleImage(small, imgscale.Options{MaxDimension: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, small, path)
+	assert.Nil(t, res)
+	cleanup()
+
+	path, cleanup, res, err = downscaleImage(large, imgscale.Options{MaxDimension: 100})
+	assert.NoError(t, err)
+	assert.NotEqual(t, large, path)
+	assert.Equal(t, "large.png", filepath.Base(path))
+	assert.Equal(t, 100, res.NewWidth)
+	assert.Equal(t, 67, res.NewHeight)
+
+	cleanup()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDescribeDownscale(t *testing.T) {
+	t.Parallel()
+
+	res := imgscale.Result{Width: 3024, Height: 1964, Size: 8 << 20, NewWidth: 1600, NewHeight: 1039, NewSize: 1258291}
+	assert.Equal(t, "downscaled 3024x1964 -> 1600x1039, 8.00 MB -> 1.20 MB", describeDownscale(&res))
+}
+
+func TestAddDownscalesImages(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "shot.png")
+	writePNG(t, shot, 400, 100)
+	info, err := os.Stat(shot)
+	assert.NoError(t, err)
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "auth.check_token_expiry": false},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEST-1", shot, "--no-input", "--max-image-dimension", "200")
+	assert.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "(downscaled 400x100 -> 200x50, ")
+
+	attachments := server.Attachments("TEST-1")
+	assert.Len(t, attachments, 1)
+	assert.Equal(t, "shot.png", attachments[0].Filename)
+
+	// The file on disk is left alone.
+	after, err := os.Stat(shot)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), after.Size())
+	assert.Equal(t, info.ModTime(), after.ModTime())
+}
+
+func TestAddRejectsInvalidImageQuality(t *testing.T) {
+	env := cmdtest.Env{Config: map[string]any{"auth.check_token_expiry": false}}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEST-1", "shot.jpg", "--image-quality", "0")
+	assert.EqualError(t, res.Err, "Image quality must be between 1 and 100")
+}
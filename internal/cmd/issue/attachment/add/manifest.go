@@ -0,0 +1,470 @@
+package add
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/pkg/atomicfile"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	rowStatusUploaded     = "uploaded"
+	rowStatusFailed       = "failed"
+	rowStatusNotAttempted = "not_attempted"
+	// rowStatusDryRun denotes a row skipped in dry-run mode, it is retried on resume.
+	rowStatusDryRun = "dry_run"
+)
+
+// maxConcurrency caps --concurrency, the uploads of a manifest share the rate limit of the
+// server with the other requests.
+const maxConcurrency = 8
+
+var issueKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-[1-9][0-9]*$`)
+
+// manifestRow is an upload listed in a manifest.
+type manifestRow struct {
+	Line    int    `json:"-"`
+	Issue   string `json:"issue"`
+	File    string `json:"file"`
+	Name    string `json:"name,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// key identifies a row across runs.
+func (r manifestRow) key() string {
+	return strings.Join([]string{r.Issue, r.File, r.Name}, "\x00")
+}
+
+// uploadName is the name of the attachment created for the row.
+func (r manifestRow) uploadName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return filepath.Base(r.File)
+}
+
+// rowResult is the outcome of uploading a manifest row.
+type rowResult struct {
+	Line          int      `json:"line"`
+	Issue         string   `json:"issue"`
+	File          string   `json:"file"`
+	Name          string   `json:"name,omitempty"`
+	Status        string   `json:"status"`
+	Error         string   `json:"error,omitempty"`
+	AttachmentIDs []string `json:"attachmentIds,omitempty"`
+	// Filenames are the names of the created attachments as stored by the
+	// server, which may differ from the requested name, eg: on a collision.
+	Filenames []string `json:"filenames,omitempty"`
+	// reason is the reason of the failure reported in the metrics.
+	reason string
+}
+
+func (r rowResult) key() string {
+	return strings.Join([]string{r.Issue, r.File, r.Name}, "\x00")
+}
+
+// uploadName is the name requested for the attachment created for the row.
+func (r rowResult) uploadName() string {
+	return manifestRow{File: r.File, Name: r.Name}.uploadName()
+}
+
+// manifestResults is written alongside the manifest after the upload.
+type manifestResults struct {
+	Manifest string      `json:"manifest"`
+	Rows     []rowResult `json:"rows"`
+}
+
+// readManifest reads a manifest file. Files with a .json extension are
+// parsed as JSON, everything else as CSV.
+func readManifest(path string) ([]manifestRow, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return parseJSONManifest(b)
+	}
+	return parseCSVManifest(b)
+}
+
+func trimBOM(b []byte) []byte {
+	return bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+}
+
+// parseCSVManifest parses a CSV manifest with the columns issue, file and the optional
+// name and comment. The header row is optional; if present, columns may be in any order.
+func parseCSVManifest(b []byte) ([]manifestRow, error) {
+	r := csv.NewReader(bytes.NewReader(trimBOM(b)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	cols := map[string]int{"issue": 0, "file": 1, "name": 2, "comment": 3}
+
+	var (
+		rows  []manifestRow
+		first = true
+	)
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+
+		if first {
+			first = false
+			if header, ok := csvHeader(rec); ok {
+				cols = header
+				continue
+			}
+		}
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+
+		field := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+
+		rows = append(rows, manifestRow{
+			Line:    line,
+			Issue:   strings.ToUpper(field("issue")),
+			File:    field("file"),
+			Name:    field("name"),
+			Comment: field("comment"),
+		})
+	}
+	return rows, nil
+}
+
+// csvHeader detects if the record is a header and maps column names to their index.
+func csvHeader(rec []string) (map[string]int, bool) {
+	cols := make(map[string]int, len(rec))
+	for i, c := range rec {
+		cols[strings.ToLower(strings.TrimSpace(c))] = i
+	}
+	_, hasIssue := cols["issue"]
+	_, hasFile := cols["file"]
+	if !hasIssue || !hasFile {
+		return nil, false
+	}
+	return cols, true
+}
+
+// parseJSONManifest parses a JSON array of rows.
+func parseJSONManifest(b []byte) ([]manifestRow, error) {
+	var rows []manifestRow
+	if err := json.Unmarshal(trimBOM(b), &rows); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	for i := range rows {
+		rows[i].Line = i + 1
+		rows[i].Issue = strings.ToUpper(strings.TrimSpace(rows[i].Issue))
+		rows[i].File = strings.TrimSpace(rows[i].File)
+		rows[i].Name = strings.TrimSpace(rows[i].Name)
+	}
+	return rows, nil
+}
+
+// validateManifest checks all rows up front and reports every problem found.
+func validateManifest(rows []manifestRow, stat func(string) (os.FileInfo, error)) []error {
+	var (
+		errs []error
+		seen = make(map[string]int, len(rows))
+	)
+
+	for _, r := range rows {
+		if !issueKeyPattern.MatchString(r.Issue) {
+			errs = append(errs, fmt.Errorf("row %d: malformed issue key %q", r.Line, r.Issue))
+		}
+		if r.File == "" {
+			errs = append(errs, fmt.Errorf("row %d: file is required", r.Line))
+		} else if info, err := stat(r.File); err != nil {
+			errs = append(errs, fmt.Errorf("row %d: file %q does not exist", r.Line, r.File))
+		} else if info.IsDir() {
+			errs = append(errs, fmt.Errorf("row %d: %q is a directory", r.Line, r.File))
+		}
+		if prev, ok := seen[r.key()]; ok {
+			errs = append(errs, fmt.Errorf("row %d: duplicate of row %d", r.Line, prev))
+		} else {
+			seen[r.key()] = r.Line
+		}
+	}
+	return errs
+}
+
+// groupByIssue groups rows by issue keeping the order in which issues first appear.
+func groupByIssue(rows []manifestRow) [][]manifestRow {
+	var (
+		groups [][]manifestRow
+		index  = make(map[string]int)
+	)
+	for _, r := range rows {
+		i, ok := index[r.Issue]
+		if !ok {
+			i = len(groups)
+			index[r.Issue] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], r)
+	}
+	return groups
+}
+
+// pendingRows drops rows that were uploaded successfully in a previous run.
+func pendingRows(rows []manifestRow, prev *manifestResults) (pending []manifestRow, done []rowResult) {
+	uploaded := make(map[string]rowResult)
+	if prev != nil {
+		for _, r := range prev.Rows {
+			if r.Status == rowStatusUploaded {
+				uploaded[r.key()] = r
+			}
+		}
+	}
+
+	for _, r := range rows {
+		if res, ok := uploaded[r.key()]; ok {
+			res.Line = r.Line
+			done = append(done, res)
+			continue
+		}
+		pending = append(pending, r)
+	}
+	return pending, done
+}
+
+// resultsPath returns the path of the results file for the manifest, eg: plan.results.json.
+func resultsPath(manifest string) string {
+	return strings.TrimSuffix(manifest, filepath.Ext(manifest)) + ".results.json"
+}
+
+// readResults reads the results file of a previous run. If the file is missing or corrupt,
+// eg: torn by a crash during its write, its backup is used instead and warn is called.
+func readResults(path string, warn func(format string, a ...any)) (*manifestResults, error) {
+	var res manifestResults
+	_, err := atomicfile.ReadFile(path, func(b []byte) error {
+		res = manifestResults{}
+		return json.Unmarshal(b, &res)
+	}, func(reason error) {
+		warn("Resuming from the backup %s, the results file %s can't be used: %s", atomicfile.BackupPath(path), path, reason)
+	})
+	var cErr *atomicfile.CorruptError
+	if errors.As(err, &cErr) {
+		return nil, fmt.Errorf("invalid results file %q: %w", path, cErr.Err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// writeResults writes the results file, replacing it atomically so that a crash never
+// leaves it truncated. It is written after every row.
+func writeResults(path string, res *manifestResults) error {
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// manifestUploader performs the requests for a manifest row.
+type manifestUploader interface {
+	Upload(row manifestRow) ([]jira.Attachment, error)
+	Comment(issue, body string) error
+}
+
+type clientUploader struct {
+	client  *jira.Client
+	version string
+	hooks   uploadHooks
+}
+
+func (u clientUploader) Upload(row manifestRow) ([]jira.Attachment, error) {
+	if err := u.hooks.before(row.Issue, row.File, row.uploadName()); err != nil {
+		return nil, err
+	}
+	attachments, err := api.ProxyUploadAttachmentAsVersion(u.client, u.version, row.Issue, row.File, row.uploadName())
+	if err != nil {
+		return nil, err
+	}
+	_ = u.hooks.after(row.Issue, row.File, attachments)
+	return attachments, nil
+}
+
+func (u clientUploader) Comment(issue, body string) error {
+	return api.ProxyAddIssueCommentVersion(u.client, u.version, issue, body, false)
+}
+
+// executeManifest uploads the rows grouped by issue with a pool of workers, each working through
+// the rows of one issue at a time in order, so that at most workers requests are in flight. A
+// failed row doesn't stop the batch unless the server rejected the credentials, the remaining
+// rows are then reported as not attempted. The progress func is called after every row, one
+// call at a time, so that the results can be saved. The results are in the order of the groups
+// regardless of the order in which they complete.
+func executeManifest(rows []manifestRow, up manifestUploader, workers int, progress func(rowResult)) []rowResult {
+	var (
+		groups  = groupByIssue(rows)
+		offsets = make([]int, len(groups))
+		results = make([]rowResult, len(rows))
+		jobs    = make(chan int)
+		mu      sync.Mutex
+		guard   cmdcommon.AuthGuard
+		wg      sync.WaitGroup
+	)
+	for i := 1; i < len(groups); i++ {
+		offsets[i] = offsets[i-1] + len(groups[i-1])
+	}
+
+	// stopped and observe share the guard between the workers.
+	stopped := func() (bool, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return guard.Stopped(), guard.Reason()
+	}
+	observe := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		guard.Observe(err)
+	}
+	done := func(i int, res rowResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = res
+		if progress != nil {
+			progress(res)
+		}
+	}
+
+	for w := 0; w < min(max(workers, 1), len(groups)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for g := range jobs {
+				for j, row := range groups[g] {
+					done(offsets[g]+j, uploadRow(row, up, stopped, observe))
+				}
+			}
+		}()
+	}
+
+	for g := range groups {
+		jobs <- g
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// uploadRow uploads a manifest row and adds its comment, unless the batch was stopped.
+func uploadRow(row manifestRow, up manifestUploader, stopped func() (bool, string), observe func(error)) rowResult {
+	res := rowResult{Line: row.Line, Issue: row.Issue, File: row.File, Name: row.Name}
+
+	if ok, reason := stopped(); ok {
+		res.Status, res.Error = rowStatusNotAttempted, reason
+		return res
+	}
+
+	attachments, err := up.Upload(row)
+	if errors.Is(err, jira.ErrDryRun) {
+		res.Status = rowStatusDryRun
+		return res
+	}
+	observe(err)
+	names := attachmentNames(attachments)
+	if err == nil && row.Comment != "" {
+		body := renameAttachmentRefs(row.Comment, row.uploadName(), names)
+		if cErr := up.Comment(row.Issue, body); cErr != nil {
+			observe(cErr)
+			err = fmt.Errorf("uploaded, but failed to add comment: %w", cErr)
+		}
+	}
+	for _, a := range attachments {
+		res.AttachmentIDs = append(res.AttachmentIDs, a.ID)
+	}
+	if len(names) > 0 {
+		res.Filenames = names
+	}
+
+	// A row is only done if both the upload and the comment succeeded. Since
+	// retrying a row with a failed comment uploads the file again, the ids of
+	// the created attachments are kept in the result for reference.
+	if err != nil {
+		res.Status, res.Error = rowStatusFailed, uploadErrorMessage(err)
+		res.reason = failureReason(err)
+	} else {
+		res.Status = rowStatusUploaded
+	}
+	return res
+}
+
+// renameAttachmentRefs replaces the mentions of the requested filename in a comment with
+// the name the server stored the attachment under if the two differ, so that the comment
+// refers to what is actually on the issue. Mentions that are part of a longer filename,
+// eg: "a.txt" in "data.txt" or "a.txt.bak", are kept.
+func renameAttachmentRefs(body, requested string, names []string) string {
+	if len(names) != 1 || names[0] == requested || requested == "" {
+		return body
+	}
+
+	var out strings.Builder
+	for {
+		i := strings.Index(body, requested)
+		if i < 0 {
+			break
+		}
+		end := i + len(requested)
+		if isFilenameBoundary(body[:i], false) && isFilenameBoundary(body[end:], true) {
+			out.WriteString(body[:i] + names[0])
+		} else {
+			out.WriteString(body[:end])
+		}
+		body = body[end:]
+	}
+	out.WriteString(body)
+	return out.String()
+}
+
+// isFilenameBoundary reports if a filename mention may end before, or start after, the text.
+// A trailing dot ends a sentence unless it is followed by an extension.
+func isFilenameBoundary(text string, after bool) bool {
+	isNameChar := func(r rune) bool {
+		return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	if text == "" {
+		return true
+	}
+	if !after {
+		r, _ := utf8.DecodeLastRuneInString(text)
+		return r != '.' && !isNameChar(r)
+	}
+	r, size := utf8.DecodeRuneInString(text)
+	if r == '.' {
+		next, _ := utf8.DecodeRuneInString(text[size:])
+		return len(text) == size || !isNameChar(next)
+	}
+	return !isNameChar(r)
+}
@@ -0,0 +1,473 @@
+package add
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestParseCSVManifest(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		input    string
+		expected []manifestRow
+	}{
+		{
+			name:  "without header",
+			input: "TEST-1,a.txt\ntest-2,b.txt,renamed.txt,\"hello, world\"\n",
+			expected: []manifestRow{
+				{Line: 1, Issue: "TEST-1", File: "a.txt"},
+				{Line: 2, Issue: "TEST-2", File: "b.txt", Name: "renamed.txt", Comment: "hello, world"},
+			},
+		},
+		{
+			name:  "header with columns in a different order",
+			input: "file,Issue,comment\na.txt,TEST-1,first\n\nb.txt,TEST-2,\n",
+			expected: []manifestRow{
+				{Line: 2, Issue: "TEST-1", File: "a.txt", Comment: "first"},
+				{Line: 4, Issue: "TEST-2", File: "b.txt"},
+			},
+		},
+		{
+			name:  "header with byte order mark",
+			input: "\xef\xbb\xbfissue,file\nTEST-1,a.txt\n",
+			expected: []manifestRow{
+				{Line: 2, Issue: "TEST-1", File: "a.txt"},
+			},
+		},
+		{
+			name:     "empty",
+			input:    "",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rows, err := parseCSVManifest([]byte(tc.input))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, rows)
+		})
+	}
+}
+
+func TestParseJSONManifest(t *testing.T) {
+	t.Parallel()
+
+	rows, err := parseJSONManifest([]byte("\xef\xbb\xbf" + `[
+		{"issue": "test-1", "file": "a.txt"},
+		{"issue": "TEST-2", "file": " b.txt ", "name": "c.txt", "comment": "note"}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []manifestRow{
+		{Line: 1, Issue: "TEST-1", File: "a.txt"},
+		{Line: 2, Issue: "TEST-2", File: "b.txt", Name: "c.txt", Comment: "note"},
+	}, rows)
+
+	_, err = parseJSONManifest([]byte(`{"issue": "TEST-1"}`))
+	assert.Error(t, err)
+}
+
+type fakeFileInfo struct {
+	os.FileInfo
+	dir bool
+}
+
+func (f fakeFileInfo) IsDir() bool { return f.dir }
+
+func TestValidateManifest(t *testing.T) {
+	t.Parallel()
+
+	stat := func(name string) (os.FileInfo, error) {
+		switch name {
+		case "a.txt", "b.txt":
+			return fakeFileInfo{}, nil
+		case "dir":
+			return fakeFileInfo{dir: true}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	errs := validateManifest([]manifestRow{
+		{Line: 1, Issue: "TEST-1", File: "a.txt"},
+		{Line: 2, Issue: "TEST-1", File: "b.txt"},
+		{Line: 3, Issue: "TEST-1", File: "b.txt", Name: "other.txt"},
+	}, stat)
+	assert.Empty(t, errs)
+
+	errs = validateManifest([]manifestRow{
+		{Line: 1, Issue: "TEST", File: "a.txt"},
+		{Line: 2, Issue: "TEST-1", File: "missing.txt"},
+		{Line: 3, Issue: "TEST-1", File: ""},
+		{Line: 4, Issue: "TEST-1", File: "dir"},
+		{Line: 5, Issue: "TEST-2", File: "a.txt"},
+		{Line: 6, Issue: "TEST-2", File: "a.txt"},
+	}, stat)
+
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`row 1: malformed issue key "TEST"`,
+		`row 2: file "missing.txt" does not exist`,
+		`row 3: file is required`,
+		`row 4: "dir" is a directory`,
+		`row 6: duplicate of row 5`,
+	}, msgs)
+}
+
+func TestGroupByIssue(t *testing.T) {
+	t.Parallel()
+
+	groups := groupByIssue([]manifestRow{
+		{Line: 1, Issue: "B-1"},
+		{Line: 2, Issue: "A-1"},
+		{Line: 3, Issue: "B-1"},
+	})
+	assert.Equal(t, [][]manifestRow{
+		{{Line: 1, Issue: "B-1"}, {Line: 3, Issue: "B-1"}},
+		{{Line: 2, Issue: "A-1"}},
+	}, groups)
+}
+
+func TestPendingRows(t *testing.T) {
+	t.Parallel()
+
+	rows := []manifestRow{
+		{Line: 1, Issue: "TEST-1", File: "a.txt"},
+		{Line: 2, Issue: "TEST-1", File: "b.txt"},
+		{Line: 3, Issue: "TEST-2", File: "a.txt", Name: "x.txt"},
+	}
+
+	pending, done := pendingRows(rows, nil)
+	assert.Equal(t, rows, pending)
+	assert.Empty(t, done)
+
+	pending, done = pendingRows(rows, &manifestResults{Rows: []rowResult{
+		{Line: 7, Issue: "TEST-1", File: "a.txt", Status: rowStatusUploaded, AttachmentIDs: []string{"10"}},
+		{Line: 8, Issue: "TEST-1", File: "b.txt", Status: rowStatusFailed},
+		{Line: 9, Issue: "TEST-2", File: "a.txt", Status: rowStatusUploaded},
+	}})
+	assert.Equal(t, []manifestRow{rows[1], rows[2]}, pending)
+	assert.Equal(t, []rowResult{
+		{Line: 1, Issue: "TEST-1", File: "a.txt", Status: rowStatusUploaded, AttachmentIDs: []string{"10"}},
+	}, done)
+}
+
+func TestResultsPath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "plan.results.json", resultsPath("plan.csv"))
+	assert.Equal(t, filepath.Join("dir", "plan.results.json"), resultsPath(filepath.Join("dir", "plan.json")))
+}
+
+func TestExecuteManifest(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for _, f := range []string{"a.txt", "b.txt"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, f), []byte(f), 0o644))
+	}
+
+	rows := []manifestRow{
+		{Line: 1, Issue: "TEST-1", File: filepath.Join(dir, "a.txt"), Comment: "hello"},
+		{Line: 2, Issue: "TEST-2", File: filepath.Join(dir, "a.txt")},
+		{Line: 3, Issue: "TEST-1", File: filepath.Join(dir, "b.txt"), Name: "renamed.txt"},
+	}
+
+	var progress int
+	results := executeManifest(rows, clientUploader{client: server.Client()}, 1, func(rowResult) { progress++ })
+
+	assert.Equal(t, 3, progress)
+
+	attachments := server.Attachments("TEST-1")
+	assert.Len(t, attachments, 2)
+	assert.Equal(t, "a.txt", attachments[0].Filename)
+	assert.Equal(t, "renamed.txt", attachments[1].Filename)
+
+	content, _ := server.Content(attachments[1].ID)
+	assert.Equal(t, "b.txt", string(content))
+
+	comments := server.Comments("TEST-1")
+	assert.Len(t, comments, 1)
+	assert.Contains(t, comments[0], "hello")
+
+	// Rows are grouped by issue, so the TEST-2 row comes last.
+	assert.Equal(t, []int{1, 3, 2}, []int{results[0].Line, results[1].Line, results[2].Line})
+	assert.Equal(t, rowStatusUploaded, results[0].Status)
+	assert.Equal(t, []string{attachments[0].ID}, results[0].AttachmentIDs)
+	assert.Equal(t, rowStatusUploaded, results[1].Status)
+	assert.Equal(t, rowStatusFailed, results[2].Status)
+	assert.NotEmpty(t, results[2].Error)
+}
+
+type failingCommenter struct {
+	clientUploader
+}
+
+func (failingCommenter) Upload(manifestRow) ([]jira.Attachment, error) {
+	return []jira.Attachment{{ID: "1"}}, nil
+}
+
+func (failingCommenter) Comment(string, string) error {
+	return errors.New("boom")
+}
+
+func TestExecuteManifestCommentFailure(t *testing.T) {
+	t.Parallel()
+
+	results := executeManifest([]manifestRow{{Line: 1, Issue: "TEST-1", File: "a.txt", Comment: "hi"}}, failingCommenter{}, 1, nil)
+	assert.Equal(t, rowStatusFailed, results[0].Status)
+	assert.Equal(t, []string{"1"}, results[0].AttachmentIDs)
+	assert.Contains(t, results[0].Error, "failed to add comment")
+}
+
+// slowUploader uploads after a delay that depends on the issue, so that the groups complete
+// out of order, and records the most uploads in flight at the same time.
+type slowUploader struct {
+	clientUploader
+	delays   map[string]time.Duration
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	issues   map[string]int
+}
+
+func (u *slowUploader) Upload(row manifestRow) ([]jira.Attachment, error) {
+	u.mu.Lock()
+	u.inFlight++
+	u.peak = max(u.peak, u.inFlight)
+	u.issues[row.Issue]++
+	concurrent := u.issues[row.Issue]
+	u.mu.Unlock()
+
+	time.Sleep(u.delays[row.Issue])
+
+	u.mu.Lock()
+	u.inFlight--
+	u.issues[row.Issue]--
+	u.mu.Unlock()
+
+	if concurrent > 1 {
+		return nil, errors.New("two uploads to the same issue at the same time")
+	}
+	return []jira.Attachment{{ID: fmt.Sprint(row.Line), Filename: row.uploadName()}}, nil
+}
+
+func TestExecuteManifestConcurrently(t *testing.T) {
+	t.Parallel()
+
+	up := &slowUploader{
+		delays: map[string]time.Duration{"TEST-1": 30 * time.Millisecond, "TEST-2": 10 * time.Millisecond},
+		issues: make(map[string]int),
+	}
+	rows := []manifestRow{
+		{Line: 1, Issue: "TEST-1", File: "a.txt"},
+		{Line: 2, Issue: "TEST-2", File: "b.txt"},
+		{Line: 3, Issue: "TEST-3", File: "c.txt"},
+		{Line: 4, Issue: "TEST-1", File: "d.txt"},
+		{Line: 5, Issue: "TEST-2", File: "e.txt"},
+	}
+
+	var progress []int
+	results := executeManifest(rows, up, 2, func(res rowResult) { progress = append(progress, res.Line) })
+
+	// Groups run in parallel up to the number of workers, the rows of a group one at a time.
+	assert.Equal(t, 2, up.peak)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5}, progress)
+
+	// The results are in the order of the groups whatever the order they completed in.
+	lines := make([]int, 0, len(results))
+	for _, r := range results {
+		assert.Equal(t, rowStatusUploaded, r.Status, r.Line)
+		assert.Equal(t, []string{fmt.Sprint(r.Line)}, r.AttachmentIDs)
+		lines = append(lines, r.Line)
+	}
+	assert.Equal(t, []int{1, 4, 2, 5, 3}, lines)
+}
+
+func TestExecuteManifestCommentMarkdown(t *testing.T) {
+	t.Parallel()
+
+	for _, version := range []string{api.APIVersion2, api.APIVersion3} {
+		server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+		defer server.Close()
+
+		file := filepath.Join(t.TempDir(), "a (1).txt")
+		assert.NoError(t, os.WriteFile(file, []byte("a"), 0o600))
+
+		rows := []manifestRow{{Line: 1, Issue: "TEST-1", File: file, Comment: "See a (1).txt"}}
+		results := executeManifest(rows, clientUploader{client: server.Client(), version: version}, 1, nil)
+		assert.Equal(t, rowStatusUploaded, results[0].Status, version)
+
+		// The markdown is converted to wiki markup with either version, like comment add does.
+		comments := server.Comments("TEST-1")
+		assert.Len(t, comments, 1, version)
+		assert.Contains(t, comments[0], `"body":"See a \\(1\\).txt"`, version)
+
+		for _, r := range server.Requests() {
+			if strings.HasSuffix(r.Path, "/comment") {
+				assert.Equal(t, "/rest/api/2/issue/TEST-1/comment", r.Path, version)
+			} else {
+				assert.Contains(t, r.Path, "/rest/api/"+version+"/", version)
+			}
+		}
+	}
+}
+
+func TestExecuteManifestStopsOnAuthFailure(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1", "TEST-2"))
+	defer server.Close()
+
+	server.SetFaults(jiratest.Faults{UnauthorizedAfter: 2})
+
+	dir := t.TempDir()
+	rows := make([]manifestRow, 0, 5)
+	for i, issue := range []string{"TEST-1", "TEST-1", "TEST-1", "TEST-2", "TEST-2"} {
+		file := filepath.Join(dir, string(rune('a'+i))+".txt")
+		assert.NoError(t, os.WriteFile(file, []byte("x"), 0o600))
+		rows = append(rows, manifestRow{Line: i + 1, Issue: issue, File: file})
+	}
+
+	var progress int
+	results := executeManifest(rows, clientUploader{client: server.Client()}, 1, func(rowResult) { progress++ })
+
+	assert.Equal(t, 5, progress)
+
+	statuses := make([]string, 0, len(results))
+	for _, r := range results {
+		statuses = append(statuses, r.Status)
+	}
+	assert.Equal(t, []string{
+		rowStatusUploaded, rowStatusUploaded, rowStatusFailed, rowStatusNotAttempted, rowStatusNotAttempted,
+	}, statuses)
+	assert.Len(t, server.Requests(), 3)
+
+	// Rows not attempted are retried on resume.
+	pending, done := pendingRows(rows, &manifestResults{Rows: results})
+	assert.Len(t, done, 2)
+	assert.Len(t, pending, 3)
+}
+
+func TestExecuteManifestServerFilenames(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithRenameOnCollision())
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "local.log", []byte("old"))
+
+	file := filepath.Join(t.TempDir(), "local.log")
+	assert.NoError(t, os.WriteFile(file, []byte("new"), 0o600))
+
+	rows := []manifestRow{
+		{Line: 1, Issue: "TEST-1", File: file, Comment: "See local.log for the new errors."},
+	}
+	results := executeManifest(rows, clientUploader{client: server.Client()}, 1, nil)
+
+	assert.Equal(t, rowStatusUploaded, results[0].Status)
+	assert.Equal(t, []string{"local (1).log"}, results[0].Filenames)
+
+	comments := server.Comments("TEST-1")
+	assert.Len(t, comments, 1)
+	assert.Contains(t, comments[0], `"body":"See local \\(1\\).log for the new errors."`, "converted to wiki markup")
+
+	// The results file keeps the server names.
+	out := filepath.Join(t.TempDir(), "plan.results.json")
+	assert.NoError(t, writeResults(out, &manifestResults{Rows: results}))
+	saved, err := readResults(out, t.Logf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"local (1).log"}, saved.Rows[0].Filenames)
+}
+
+func TestRenameAttachmentRefs(t *testing.T) {
+	t.Parallel()
+
+	body := "a.txt replaces data.txt and a.txt.bak, see a.txt."
+	assert.Equal(t, body, renameAttachmentRefs(body, "a.txt", []string{"a.txt"}))
+	assert.Equal(t, "a (1).txt replaces data.txt and a.txt.bak, see a (1).txt.", renameAttachmentRefs(body, "a.txt", []string{"a (1).txt"}))
+	assert.Equal(t, body, renameAttachmentRefs(body, "a.txt", nil))
+}
+
+func TestExecuteManifestDryRun(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "a.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("a"), 0o600))
+
+	rows := []manifestRow{{Line: 1, Issue: "TEST-1", File: file, Comment: "hello"}}
+	results := executeManifest(rows, clientUploader{client: server.Client(jira.WithDryRun(true))}, 1, nil)
+
+	assert.Equal(t, rowStatusDryRun, results[0].Status)
+	assert.Empty(t, results[0].Error)
+	assert.Empty(t, server.Attachments("TEST-1"))
+	assert.Empty(t, server.Comments("TEST-1"))
+
+	// Rows skipped in dry-run mode are uploaded on resume.
+	pending, _ := pendingRows(rows, &manifestResults{Rows: results})
+	assert.Len(t, pending, 1)
+}
+
+func TestAddRejectsInvalidConcurrency(t *testing.T) {
+	env := cmdtest.Env{Config: map[string]any{"auth.check_token_expiry": false}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "--from-manifest", "plan.csv", "--concurrency", "9")
+	assert.EqualError(t, res.Err, "--concurrency must be between 1 and 8")
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEST-1", "a.txt", "--concurrency", "2")
+	assert.EqualError(t, res.Err, "--concurrency can only be used with --from-manifest")
+}
+
+func TestReadResultsFallsBackToBackup(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "plan.results.json")
+	first := &manifestResults{Rows: []rowResult{{Line: 1, Issue: "TEST-1", Status: rowStatusUploaded}}}
+	second := &manifestResults{Rows: append(first.Rows, rowResult{Line: 2, Issue: "TEST-2", Status: rowStatusUploaded})}
+	require.NoError(t, writeResults(path, first))
+	require.NoError(t, writeResults(path, second))
+
+	// A crash while writing the results leaves a truncated file.
+	require.NoError(t, os.WriteFile(path, []byte(`{"rows": [`), 0o644))
+
+	var warnings []string
+	res, err := readResults(path, func(format string, a ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, a...))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first.Rows, res.Rows)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "Resuming from the backup")
+
+	require.NoError(t, os.Remove(path+".bak"))
+	_, err = readResults(path, t.Logf)
+	assert.ErrorContains(t, err, "invalid results file")
+}
@@ -0,0 +1,141 @@
+package add
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+// nearLimitRatio is the fraction of the upload limit from which a file is flagged as near
+// the limit. Multipart overhead and proxies in front of Jira can tip such files over.
+const nearLimitRatio = 0.9
+
+// riskyExtensions are extensions of executables and scripts that instances commonly block.
+var riskyExtensions = []string{
+	".bat", ".cmd", ".com", ".cpl", ".dll", ".exe", ".hta", ".js", ".jse", ".lnk",
+	".msi", ".ps1", ".reg", ".scr", ".sh", ".vbe", ".vbs", ".wsf",
+}
+
+// uploadRisk ranks how likely a file is to be rejected, riskier files are uploaded first.
+type uploadRisk int
+
+const (
+	riskOverLimit uploadRisk = iota
+	riskFlagged
+	riskNone
+)
+
+// plannedFile is a file of an upload batch with the heuristics that flagged it.
+type plannedFile struct {
+	path string
+	// size in bytes, negative if unknown.
+	size int64
+	// flags are the reasons the file is likely to be rejected.
+	flags []string
+	risk  uploadRisk
+}
+
+// inspectFiles gathers the metadata of the files and flags the ones that are likely to be
+// rejected. limit is the upload limit of the instance, 0 if unknown.
+func inspectFiles(files []string, limit int64) []plannedFile {
+	planned := make([]plannedFile, 0, len(files))
+	for _, f := range files {
+		size := int64(-1)
+		if info, err := os.Stat(f); err == nil {
+			size = info.Size()
+		}
+		planned = append(planned, flagFile(f, size, limit))
+	}
+	return planned
+}
+
+// flagFile applies the local validation heuristics to a file.
+func flagFile(path string, size, limit int64) plannedFile {
+	p := plannedFile{path: path, size: size, risk: riskNone}
+
+	switch {
+	case limit > 0 && size > limit:
+		p.flags = append(p.flags, fmt.Sprintf("over the %s upload limit", cmdutil.FormatSize(limit)))
+		p.risk = riskOverLimit
+	case limit > 0 && float64(size) >= float64(limit)*nearLimitRatio:
+		p.flags = append(p.flags, fmt.Sprintf("near the %s upload limit", cmdutil.FormatSize(limit)))
+		p.risk = riskFlagged
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); slices.Contains(riskyExtensions, ext) {
+		p.flags = append(p.flags, fmt.Sprintf("%s files are often blocked", ext))
+		p.risk = min(p.risk, riskFlagged)
+	}
+	return p
+}
+
+// planUploads orders a batch so that likely rejections surface before time is spent on
+// the large files that are expected to go through: files over the upload limit first,
+// then the other flagged files, then the rest from the smallest to the largest. The sort
+// is stable, files of the same rank keep their argument order. With keepOrder the batch
+// is returned as given.
+func planUploads(files []plannedFile, keepOrder bool) []plannedFile {
+	planned := slices.Clone(files)
+	if keepOrder {
+		return planned
+	}
+	slices.SortStableFunc(planned, func(a, b plannedFile) int {
+		if a.risk != b.risk {
+			return int(a.risk) - int(b.risk)
+		}
+		if a.risk == riskNone {
+			switch {
+			case a.size < b.size:
+				return -1
+			case a.size > b.size:
+				return 1
+			}
+		}
+		return 0
+	})
+	return planned
+}
+
+// plannedPaths returns the paths of the files in upload order.
+func plannedPaths(files []plannedFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	return paths
+}
+
+// planItems lists the files of the plan in the confirmation prompt, with the reasons
+// flagged files are uploaded first.
+func planItems(files []plannedFile) []cmdcommon.ConfirmItem {
+	items := make([]cmdcommon.ConfirmItem, 0, len(files))
+	for _, f := range files {
+		label := f.path
+		if len(f.flags) > 0 {
+			label += " [" + strings.Join(f.flags, ", ") + "]"
+		}
+		items = append(items, cmdcommon.ConfirmItem{Label: label, Size: f.size})
+	}
+	return items
+}
+
+// uploadLimit fetches the upload limit of the instance to flag the files near or over it.
+// It is 0 if there is nothing to plan, or if the limit can't be fetched, in which case
+// the files are only ordered by the other heuristics.
+func uploadLimit(client *jira.Client, params *addParams) int64 {
+	if params.keepOrder || len(params.files) < 2 {
+		return 0
+	}
+	meta, err := api.ProxyGetAttachmentMetaVersion(client, params.apiVersion)
+	if err != nil {
+		return 0
+	}
+	return meta.UploadLimit
+}
@@ -0,0 +1,109 @@
+package add
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestFlagFile(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, riskNone, flagFile("a.txt", 10, 100).risk)
+	assert.Equal(t, riskNone, flagFile("a.txt", 10, 0).risk)
+	assert.Equal(t, riskNone, flagFile("a.txt", -1, 100).risk)
+
+	near := flagFile("a.txt", 95, 100)
+	assert.Equal(t, riskFlagged, near.risk)
+	assert.Equal(t, []string{"near the 100 B upload limit"}, near.flags)
+
+	over := flagFile("setup.EXE", 101, 100)
+	assert.Equal(t, riskOverLimit, over.risk)
+	assert.Equal(t, []string{"over the 100 B upload limit", ".exe files are often blocked"}, over.flags)
+
+	assert.Equal(t, riskFlagged, flagFile("run.sh", 1, 0).risk)
+}
+
+func TestPlanUploads(t *testing.T) {
+	t.Parallel()
+
+	files := []plannedFile{
+		{path: "large.bin", size: 800, risk: riskNone},
+		{path: "script.ps1", size: 5, risk: riskFlagged},
+		{path: "small.txt", size: 10, risk: riskNone},
+		{path: "huge.bin", size: 2000, risk: riskOverLimit},
+		{path: "same-a.txt", size: 10, risk: riskNone},
+		{path: "near.bin", size: 950, risk: riskFlagged},
+		{path: "same-b.txt", size: 10, risk: riskNone},
+	}
+
+	assert.Equal(t,
+		[]string{"huge.bin", "script.ps1", "near.bin", "small.txt", "same-a.txt", "same-b.txt", "large.bin"},
+		plannedPaths(planUploads(files, false)),
+	)
+	// The plan doesn't depend on the previous order of files of the same rank.
+	assert.Equal(t, plannedPaths(planUploads(files, false)), plannedPaths(planUploads(planUploads(files, false), false)))
+
+	assert.Equal(t,
+		[]string{"large.bin", "script.ps1", "small.txt", "huge.bin", "same-a.txt", "near.bin", "same-b.txt"},
+		plannedPaths(planUploads(files, true)),
+	)
+	assert.Equal(t, "large.bin", files[0].path, "the input is not modified")
+}
+
+func TestPlanItems(t *testing.T) {
+	t.Parallel()
+
+	items := planItems([]plannedFile{
+		{path: "huge.bin", size: 2000, flags: []string{"over the 1000 B upload limit"}},
+		{path: "small.txt", size: 10},
+	})
+	assert.Equal(t, "huge.bin [over the 1000 B upload limit]", items[0].Label)
+	assert.Equal(t, int64(2000), items[0].Size)
+	assert.Equal(t, "small.txt", items[1].Label)
+}
+
+func TestAtomicUploadFailsFastOnOverLimitFile(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithUploadLimit(1000))
+	defer server.Close()
+
+	dir := t.TempDir()
+	write := func(name string, size int) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o600))
+		return path
+	}
+	// The large file comes first on the command line.
+	large, small, huge := write("large.bin", 800), write("small.txt", 10), write("huge.bin", 2000)
+	files := []string{large, small, huge}
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "auth.check_token_expiry": false},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), append([]string{"TEST-1", "--atomic", "--no-input"}, files...)...)
+	require.Error(t, res.Err)
+	assert.Contains(t, res.Stderr, `Not attempted: "`+small+`"`)
+	assert.Contains(t, res.Stderr, `Not attempted: "`+large+`"`)
+
+	var uploads int
+	for _, r := range server.Requests() {
+		if r.Method == "POST" && strings.HasSuffix(r.Path, "/attachments") {
+			uploads++
+		}
+	}
+	assert.Equal(t, 1, uploads, "only the over-limit file is sent")
+	assert.Empty(t, server.Attachments("TEST-1"))
+
+	// With --keep-order the large file is uploaded and rolled back.
+	res = cmdtest.Run(t, env, NewCmdAttachmentAdd(), append([]string{"TEST-1", "--atomic", "--no-input", "--keep-order"}, files...)...)
+	require.Error(t, res.Err)
+	assert.Contains(t, res.Stderr, `Rolled back "large.bin"`)
+}
@@ -0,0 +1,93 @@
+package add
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// crossProjectFields are the issue fields fetched to describe an issue of another project.
+var crossProjectFields = []string{"summary", "project"}
+
+// crossProject returns the project of the issue key if it isn't the default project, ie: the
+// key was given in full for an issue of another project. Keys without a default project are
+// never cross-project.
+func crossProject(defaultProject, issueKey string) (string, bool) {
+	i := strings.LastIndex(issueKey, "-")
+	if defaultProject == "" || i <= 0 {
+		return "", false
+	}
+	project := issueKey[:i]
+	if strings.EqualFold(project, defaultProject) {
+		return "", false
+	}
+	return project, true
+}
+
+// crossProjectIssue is an issue outside of the default project that files are uploaded to.
+type crossProjectIssue struct {
+	key            string
+	summary        string
+	project        jira.Project
+	defaultProject string
+}
+
+// String describes the issue prominently, with its summary and the name of its project.
+func (c *crossProjectIssue) String() string {
+	name := c.project.Key
+	if c.project.Name != "" && c.project.Name != c.project.Key {
+		name = fmt.Sprintf("%s (%s)", c.project.Name, c.project.Key)
+	}
+	s := c.key
+	if c.summary != "" {
+		s += fmt.Sprintf(" %q", c.summary)
+	}
+	return fmt.Sprintf("%s in project %s", cmdutil.SanitizeTerminalText(s), cmdutil.SanitizeTerminalText(name))
+}
+
+// checkProject fetches the summary and project of the issue if it is outside of the default
+// project, project.key, so that the upload names them. Without a prompt, ie: with --no-input,
+// such uploads fail unless --allow-cross-project is set.
+func checkProject(client *jira.Client, params *addParams) error {
+	defaultProject := viper.GetString("project.key")
+	project, ok := crossProject(defaultProject, params.issueKey)
+	if !ok {
+		return nil
+	}
+
+	issue, err := cmdcommon.GetAttachmentIssue(client, params.apiVersion, params.issueKey, crossProjectFields)
+	if err != nil {
+		return cmdutil.RequestError(err, params.debug)
+	}
+	c := &crossProjectIssue{
+		key:            params.issueKey,
+		summary:        issue.Fields.Summary,
+		project:        issue.Fields.Project,
+		defaultProject: defaultProject,
+	}
+	if c.project.Key == "" {
+		c.project.Key = project
+	}
+
+	if params.noInput && !params.allowCrossProject {
+		return cmdutil.Errorf("Issue %s is not in the default project %s, nothing was uploaded. "+
+			"Add --allow-cross-project to upload to it with --no-input", c, defaultProject)
+	}
+	cmdutil.Warn("Issue %s is not in the default project %s", c, defaultProject)
+	params.crossProject = c
+	return nil
+}
+
+// target names the issue files are uploaded to in the confirmation prompt, with its summary
+// and project if it is outside of the default project.
+func (p *addParams) target() string {
+	if p.crossProject != nil {
+		return p.crossProject.String() + ", not " + p.crossProject.defaultProject
+	}
+	return p.issueKey
+}
@@ -0,0 +1,113 @@
+package add
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestCrossProject(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		defaultProject string
+		key            string
+		project        string
+		cross          bool
+	}{
+		{defaultProject: "", key: "TEAMB-7"},
+		{defaultProject: "TEAMA", key: "TEAMA-7"},
+		{defaultProject: "teama", key: "TEAMA-7"},
+		{defaultProject: "TEAMA", key: "TEAMB-7", project: "TEAMB", cross: true},
+		{defaultProject: "TEAM", key: "TEAM_B-7", project: "TEAM_B", cross: true},
+		{defaultProject: "TEAMA", key: "7"},
+	}
+
+	for _, tc := range cases {
+		project, cross := crossProject(tc.defaultProject, tc.key)
+		assert.Equal(t, tc.cross, cross, "%s in %s", tc.key, tc.defaultProject)
+		assert.Equal(t, tc.project, project, "%s in %s", tc.key, tc.defaultProject)
+	}
+}
+
+// answerPrompts answers the confirmation prompts with the action and returns their messages.
+func answerPrompts(t *testing.T, action string) *[]string {
+	t.Helper()
+
+	var messages []string
+	prevAsk := ask
+	ask = func(qs []*survey.Question, response any, _ ...survey.AskOpt) error {
+		messages = append(messages, qs[0].Prompt.(*survey.Select).Message)
+		response.(*struct{ Action string }).Action = action
+		return nil
+	}
+	t.Cleanup(func() { ask = prevAsk })
+	return &messages
+}
+
+func TestAddCrossProject(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEAMA-1"), jiratest.WithProject("TEAMB", "Team B"))
+	defer server.Close()
+	server.AddIssue("TEAMB-7", "Payroll export")
+
+	files := writeFiles(t, "salaries.csv")
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "project.key": "TEAMA", "auth.check_token_expiry": false},
+	}
+
+	t.Run("same project", func(t *testing.T) {
+		messages := answerPrompts(t, cmdcommon.ActionCancel)
+
+		res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "1", files[0])
+		assert.ErrorContains(t, res.Err, "Action aborted")
+		if assert.Len(t, *messages, 1) {
+			assert.True(t, strings.HasPrefix((*messages)[0], "Upload 1 file(s) to TEAMA-1?"), (*messages)[0])
+		}
+		assert.NotContains(t, res.Stderr, "default project")
+	})
+
+	t.Run("cross project interactive", func(t *testing.T) {
+		messages := answerPrompts(t, cmdcommon.ActionCancel)
+
+		res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "teamb-7", files[0])
+		assert.ErrorContains(t, res.Err, "Action aborted")
+		if assert.Len(t, *messages, 1) {
+			assert.True(t, strings.HasPrefix((*messages)[0],
+				`Upload 1 file(s) to TEAMB-7 "Payroll export" in project Team B (TEAMB), not TEAMA?`), (*messages)[0])
+		}
+		assert.Contains(t, res.Stderr, "is not in the default project TEAMA")
+		assert.Empty(t, server.Attachments("TEAMB-7"))
+	})
+
+	t.Run("cross project without input", func(t *testing.T) {
+		answerPrompts(t, cmdcommon.ActionSubmit)
+
+		res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEAMB-7", files[0], "--no-input")
+		require.Error(t, res.Err)
+		assert.Equal(t, `Issue TEAMB-7 "Payroll export" in project Team B (TEAMB) is not in the default project TEAMA, nothing was uploaded. `+
+			"Add --allow-cross-project to upload to it with --no-input", res.Err.Error())
+		assert.Empty(t, server.Attachments("TEAMB-7"))
+	})
+
+	t.Run("cross project allowed without input", func(t *testing.T) {
+		answerPrompts(t, cmdcommon.ActionSubmit)
+
+		res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEAMB-7", files[0], "--no-input", "--allow-cross-project")
+		require.NoError(t, res.Err)
+		assert.Contains(t, res.Stderr, "is not in the default project TEAMA")
+		assert.Len(t, server.Attachments("TEAMB-7"), 1)
+	})
+
+	t.Run("data upload", func(t *testing.T) {
+		res := cmdtest.Run(t, env, NewCmdAttachmentAdd(), "TEAMB-7", "--data-base64", "aGVsbG8=", "--filename", "hello.txt", "--no-input")
+		assert.ErrorContains(t, res.Err, "Add --allow-cross-project to upload to it with --no-input")
+	})
+}
@@ -0,0 +1,62 @@
+package add
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// uploadSessionDir returns the directory sessions of interrupted chunked uploads are kept in.
+func uploadSessionDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jira-cli", "upload-sessions"), nil
+}
+
+// uploadSessions returns the store of chunked upload sessions, or nil if there is
+// no cache directory in which case uploads are not resumable.
+func uploadSessions() jira.UploadSessionStore {
+	dir, err := uploadSessionDir()
+	if err != nil {
+		return nil
+	}
+	return jira.FileSessionStore{Dir: dir}
+}
+
+// abortResume clears the saved sessions of the given files, or of all interrupted
+// uploads if no arguments are given, so that the next upload starts over.
+func abortResume(params *addParams) error {
+	dir, err := uploadSessionDir()
+	if err != nil {
+		return err
+	}
+	store := jira.FileSessionStore{Dir: dir}
+
+	if params.issueKey == "" && len(params.files) == 0 {
+		n, err := store.Clear()
+		if err != nil {
+			return err
+		}
+		cmdutil.Success("Cleared %d saved upload session(s)", n)
+		return nil
+	}
+
+	if len(params.files) == 0 {
+		return cmdutil.Errorf("At least one file path is required, or none to clear all saved sessions")
+	}
+	for _, file := range params.files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		if err := store.Delete(params.issueKey, path); err != nil {
+			return err
+		}
+	}
+	cmdutil.Success("Cleared the saved upload sessions of %d file(s) on issue %q", len(params.files), params.issueKey)
+	return nil
+}
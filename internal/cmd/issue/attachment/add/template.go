@@ -0,0 +1,57 @@
+package add
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	jiraConfig "github.com/ankitpokhrel/jira-cli/internal/config"
+)
+
+// applyTemplate expands the files and the comment of the --template for the issue. The files
+// come before the ones given as arguments, and the comment is used unless --comment is given.
+// All files of the template must exist, else the upload fails before anything is sent.
+func applyTemplate(params *addParams) error {
+	var templates map[string]jiraConfig.AttachmentTemplate
+	if err := viper.UnmarshalKey(jiraConfig.TemplatesKey, &templates); err != nil {
+		return cmdutil.Errorf("Invalid %s config: %s", jiraConfig.TemplatesKey, err)
+	}
+	t, ok := templates[params.template]
+	if !ok {
+		return cmdutil.Errorf("Template %q not found, see: jira config attachment template list", params.template)
+	}
+
+	files, comment, err := t.Expand(jiraConfig.TemplateVars{IssueKey: params.issueKey}, os.Getenv)
+	if err != nil {
+		return cmdutil.Errorf("Unable to expand template %q: %s", params.template, err)
+	}
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil {
+			return cmdutil.Errorf("Template %q: file %q does not exist", params.template, f)
+		}
+	}
+
+	params.files = mergeFiles(files, params.files)
+	if params.comment == "" {
+		params.comment = comment
+	}
+	return nil
+}
+
+// mergeFiles appends the extra files to the ones of the template, skipping the ones the
+// template already uploads.
+func mergeFiles(template, extra []string) []string {
+	seen := make(map[string]bool, len(template))
+	out := make([]string, 0, len(template)+len(extra))
+	for _, files := range [][]string{template, extra} {
+		for _, f := range files {
+			if key := filepath.Clean(f); !seen[key] {
+				seen[key] = true
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package add
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func templateEnv(server *jiratest.Server, templates map[string]any) cmdtest.Env {
+	return cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{
+			"server":                  server.URL,
+			"auth.check_token_expiry": false,
+			"attachment.templates":    templates,
+		},
+	}
+}
+
+func qaTemplate() map[string]any {
+	return map[string]any{
+		"qa-report": map[string]any{
+			"files":   []any{"$QA_DIR/test-report.html", "${QA_DIR}/{{.IssueKey}}.log"},
+			"comment": "QA artifacts for {{.IssueKey}}",
+		},
+	}
+}
+
+func TestAddTemplate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("QA_DIR", dir)
+	for _, name := range []string{"test-report.html", "TEST-1.log"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600))
+	}
+	extra := writeFiles(t, "notes.txt")[0]
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	res := cmdtest.Run(t, templateEnv(server, qaTemplate()), NewCmdAttachmentAdd(),
+		"TEST-1", extra, filepath.Join(dir, "test-report.html"), "--template", "qa-report", "--no-input", "--keep-order")
+	require.NoError(t, res.Err)
+
+	var names []string
+	for _, a := range server.Attachments("TEST-1") {
+		names = append(names, a.Filename)
+	}
+	assert.Equal(t, []string{"test-report.html", "TEST-1.log", "notes.txt"}, names, "the template files come first, once")
+	comments := server.Comments("TEST-1")
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0], `QA artifacts for TEST\\-1`)
+}
+
+func TestAddTemplateCommentOverride(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("QA_DIR", dir)
+	for _, name := range []string{"test-report.html", "TEST-1.log"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600))
+	}
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	res := cmdtest.Run(t, templateEnv(server, qaTemplate()), NewCmdAttachmentAdd(),
+		"TEST-1", "--template", "qa-report", "--comment", "Sprint 12 sign-off", "--no-input")
+	require.NoError(t, res.Err)
+	comments := server.Comments("TEST-1")
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0], `Sprint 12 sign\\-off`)
+}
+
+func TestAddTemplateMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("QA_DIR", dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test-report.html"), []byte("report"), 0o600))
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	res := cmdtest.Run(t, templateEnv(server, qaTemplate()), NewCmdAttachmentAdd(), "TEST-1", "--template", "qa-report", "--no-input")
+	require.Error(t, res.Err)
+	assert.Equal(t, `Template "qa-report": file "`+filepath.Join(dir, "TEST-1.log")+`" does not exist`, res.Err.Error())
+	assert.Empty(t, server.Requests(), "nothing is sent before all files are found")
+}
+
+func TestAddTemplateErrors(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	cases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "unknown", args: []string{"TEST-1", "--template", "nope", "--no-input"}, err: `Template "nope" not found`},
+		{name: "with manifest", args: []string{"--template", "qa-report", "--from-manifest", "plan.csv"}, err: "--template can't be used with --from-manifest"},
+		{name: "without issue", args: []string{"--template", "qa-report"}, err: "ISSUE-KEY is required"},
+	}
+	for _, tc := range cases {
+		res := cmdtest.Run(t, templateEnv(server, qaTemplate()), NewCmdAttachmentAdd(), tc.args...)
+		require.Error(t, res.Err, tc.name)
+		assert.Contains(t, res.Err.Error(), tc.err, tc.name)
+	}
+	assert.Empty(t, server.Requests())
+}
+
+func TestMergeFiles(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t,
+		[]string{"reports/a.html", "b.xml", "c.txt"},
+		mergeFiles([]string{"reports/a.html", "b.xml"}, []string{"./reports/a.html", "c.txt", "b.xml"}),
+	)
+	assert.Equal(t, []string{"a"}, mergeFiles([]string{"a"}, nil))
+}
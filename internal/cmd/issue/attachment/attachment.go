@@ -2,35 +2,83 @@ package attachment
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/add"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/clean"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/count"
 	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/download"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/duplicates"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/find"
 	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/list"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/open"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/passthrough"
 	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/remove"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/stats"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/watch"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
 )
 
-const helpText = `Attachment command helps you manage issue attachments. See available commands below.`
+const (
+	helpText = `Attachment command helps you manage issue attachments. See available commands below.`
+	examples = `# Check what the configured credentials can do with attachments
+$ jira issue attachment --check-access
 
-// NewCmdAttachment is an attachment command.
-func NewCmdAttachment() *cobra.Command {
+# Check it on a specific issue
+$ jira issue attachment --check-access ISSUE-1`
+)
+
+// NewCmdAttachment is an attachment command. The options are passed to the list, download,
+// add and remove subcommands to embed the command tree in another application.
+func NewCmdAttachment(opts ...cmdcommon.Options) *cobra.Command {
+	o := cmdcommon.ResolveOptions(opts)
 	cmd := cobra.Command{
 		Use:     "attachment",
 		Short:   "Manage issue attachments",
 		Long:    helpText,
+		Example: examples,
 		Aliases: []string{"attachments"},
-		RunE:    attachment,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return attachment(cmd, args, o)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
+	cmd.PersistentFlags().String("proxy", "", "Proxy URL for this invocation, overrides network.proxy config and proxy env vars")
+	_ = viper.BindPFlag("proxy", cmd.PersistentFlags().Lookup("proxy"))
+	cmdcommon.SetAPIVersionFlag(&cmd)
+	cmdcommon.SetProfileFlag(&cmd)
+	cmdcommon.SetVerbosityFlag(&cmd)
+	cmdcommon.SetMetricsFlag(&cmd)
+	cmd.Flags().Bool("check-access", false, "Check what the current credentials can do with attachments, on the given ISSUE-KEY or any project")
+
 	cmd.AddCommand(
-		list.NewCmdAttachmentList(),
-		download.NewCmdAttachmentDownload(),
-		add.NewCmdAttachmentAdd(),
-		remove.NewCmdAttachmentRemove(),
+		list.NewCmdAttachmentList(o),
+		download.NewCmdAttachmentDownload(o),
+		open.NewCmdAttachmentOpen(),
+		add.NewCmdAttachmentAdd(o),
+		remove.NewCmdAttachmentRemove(o),
+		stats.NewCmdAttachmentStats(),
+		count.NewCmdAttachmentCount(),
+		duplicates.NewCmdAttachmentDuplicates(),
+		find.NewCmdAttachmentFind(),
+		passthrough.NewCmdAttachmentAPI(),
+		watch.NewCmdAttachmentWatch(),
+		clean.NewCmdAttachmentClean(),
 	)
+	o.Apply(&cmd)
 
 	return &cmd
 }
 
-func attachment(cmd *cobra.Command, _ []string) error {
+func attachment(cmd *cobra.Command, args []string, o cmdcommon.Options) error {
+	check, err := cmd.Flags().GetBool("check-access")
+	if err != nil {
+		return err
+	}
+	if check {
+		return checkAccess(cmd, args, o)
+	}
 	return cmd.Help()
 }
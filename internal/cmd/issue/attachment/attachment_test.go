@@ -0,0 +1,208 @@
+package attachment
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func paths(server *jiratest.Server, method string) []string {
+	var out []string
+	for _, r := range server.Requests() {
+		if r.Method == method {
+			out = append(out, r.Path)
+		}
+	}
+	return out
+}
+
+func TestAPIVersionOverride(t *testing.T) {
+	cases := []struct {
+		name         string
+		installation string
+		args         []string
+		want         string
+	}{
+		{name: "cloud default", installation: jira.InstallationTypeCloud, want: "/rest/api/3/issue/TEST-1"},
+		{name: "local default", installation: jira.InstallationTypeLocal, want: "/rest/api/2/issue/TEST-1"},
+		{name: "cloud forced to v2", installation: jira.InstallationTypeCloud, args: []string{"--api-version", "2"}, want: "/rest/api/2/issue/TEST-1"},
+		{name: "local forced to v3", installation: jira.InstallationTypeLocal, args: []string{"--api-version", "3"}, want: "/rest/api/3/issue/TEST-1"},
+		{name: "same as installation", installation: jira.InstallationTypeCloud, args: []string{"--api-version", "3"}, want: "/rest/api/3/issue/TEST-1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+			defer server.Close()
+			server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+
+			env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": tc.installation}}
+			args := append([]string{"list", "TEST-1", "-o", "plain"}, tc.args...)
+
+			res := cmdtest.Run(t, env, NewCmdAttachment(), args...)
+			require.NoError(t, res.Err)
+			assert.Contains(t, res.Stdout, "notes.txt")
+			assert.Equal(t, []string{tc.want}, paths(server, "GET"))
+		})
+	}
+}
+
+func TestAPIVersionOverrideRemove(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	a := server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": jira.InstallationTypeCloud}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "remove", "TEST-1", a.ID, "--no-input", "--api-version", "2", "--debug")
+	require.NoError(t, res.Err)
+	assert.Equal(t, []string{"/rest/api/2/attachment/" + a.ID}, paths(server, "DELETE"))
+	assert.Contains(t, res.Stderr, "Using Jira REST api v2 (--api-version)")
+}
+
+func TestAPIVersionOverrideInvalid(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	env := cmdtest.Env{Client: server.Client()}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "list", "TEST-1", "--api-version", "4")
+	assert.EqualError(t, res.Err, `Invalid --api-version "4", use one of: 2, 3`)
+	assert.Empty(t, server.Requests())
+}
+
+func TestProfileRouting(t *testing.T) {
+	company := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithCredentials("me@company.com", "company-token"))
+	defer company.Close()
+	company.AddAttachment("TEST-1", "company.txt", []byte("company"))
+
+	customer := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithCredentials("", "customer-pat"))
+	defer customer.Close()
+	customer.AddAttachment("TEST-1", "customer.txt", []byte("customer"))
+
+	env := cmdtest.Env{Config: map[string]any{
+		"server":       "http://127.0.0.1:1",
+		"installation": jira.InstallationTypeCloud,
+		"profiles": map[string]any{
+			"company": map[string]any{
+				"server":    company.URL,
+				"login":     "me@company.com",
+				"api_token": "company-token",
+			},
+			"customer-jira": map[string]any{
+				"server":       customer.URL,
+				"auth_type":    string(jira.AuthTypeBearer),
+				"api_token":    "customer-pat",
+				"installation": jira.InstallationTypeLocal,
+			},
+		},
+	}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "list", "TEST-1", "-o", "plain", "--profile", "company")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "company.txt")
+
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "list", "TEST-1", "-o", "plain", "--profile", "customer-jira")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "customer.txt")
+
+	assert.Equal(t, []string{"/rest/api/3/issue/TEST-1"}, paths(company, "GET"))
+	assert.Equal(t, []string{"/rest/api/2/issue/TEST-1"}, paths(customer, "GET"))
+}
+
+func TestProfileUnknown(t *testing.T) {
+	env := cmdtest.Env{Config: map[string]any{
+		"profiles": map[string]any{"company": map[string]any{}, "customer-jira": map[string]any{}},
+	}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "list", "TEST-1", "--profile", "custmer")
+	assert.EqualError(t, res.Err, `Unknown profile "custmer", available profiles: company, customer-jira`)
+}
+
+// indexOf returns the # of the attachment in the plain output of list.
+func indexOf(t *testing.T, stdout, filename string) string {
+	t.Helper()
+
+	for _, line := range strings.Split(stdout, "\n") {
+		if fields := strings.Fields(line); len(fields) > 2 && fields[2] == filename {
+			return fields[0]
+		}
+	}
+	t.Fatalf("%s is not listed in:\n%s", filename, stdout)
+	return ""
+}
+
+func TestListAndDownloadIndexAgree(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithClock(func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}))
+	defer server.Close()
+
+	// Uploaded oldest first, in an order that differs from the names.
+	var ids []string
+	for _, name := range []string{"shot.png", "d.txt", "a.txt", "c.txt", "b.txt"} {
+		ids = append(ids, server.AddAttachment("TEST-1", name, []byte(name)).ID)
+	}
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": jira.InstallationTypeCloud}}
+
+	cases := []struct {
+		name   string
+		filter []string
+		want   string
+	}{
+		{name: "all", want: "a.txt"},
+		// The index counts the attachments left by the other filters.
+		{name: "filtered", filter: []string{"--name", "*.txt"}, want: "c.txt"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The # column doesn't follow the order of the rows.
+			args := append([]string{"list", "TEST-1", "-o", "plain", "--order-by", "filename"}, tc.filter...)
+			res := cmdtest.Run(t, env, NewCmdAttachment(), args...)
+			require.NoError(t, res.Err)
+			assert.Equal(t, "3", indexOf(t, res.Stdout, tc.want))
+
+			out := t.TempDir()
+			args = append([]string{"download", "TEST-1", "--index", "3", "--output", out}, tc.filter...)
+			res = cmdtest.Run(t, env, NewCmdAttachment(), args...)
+			require.NoError(t, res.Err)
+
+			entries, err := os.ReadDir(out)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.want, entries[0].Name())
+		})
+	}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "download", "TEST-1", "--index", "2,6", "--output", t.TempDir())
+	assert.ErrorContains(t, res.Err, "--index 6 is out of range, 5 attachments match the other filters, valid indexes are 1-5")
+
+	res = cmdtest.Run(t, env, NewCmdAttachment(), "remove", "TEST-1", "--index", "1", "--no-input")
+	require.NoError(t, res.Err)
+	assert.Equal(t, []string{"/rest/api/3/attachment/" + ids[0]}, paths(server, "DELETE"))
+}
+
+func TestCommandsSilenceErrors(t *testing.T) {
+	// Commands returning errors leave printing them to the root, once and without the usage.
+	cmd := NewCmdAttachment()
+	for _, c := range append([]*cobra.Command{cmd}, cmd.Commands()...) {
+		if c.RunE == nil {
+			continue
+		}
+		assert.True(t, c.SilenceErrors, c.Name())
+		assert.True(t, c.SilenceUsage, c.Name())
+	}
+}
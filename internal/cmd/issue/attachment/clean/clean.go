@@ -0,0 +1,106 @@
+package clean
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
+)
+
+const (
+	helpText = `Clean removes the temporary files that interrupted downloads left in a directory.
+
+Temporary files are named ` + tmpfile.Prefix + `<pid>-<name>.<random>` + tmpfile.Suffix + `. A file is removed once it
+hasn't been modified for --older-than and the process that wrote it isn't running anymore.
+Downloads do the same in their output directory before they start. Subdirectories are not
+cleaned. Use the global --dry-run flag to list the files without removing them.`
+	examples = `$ jira issue attachment clean ./downloads
+
+# List what would be removed
+$ jira issue attachment clean ./downloads --dry-run
+
+# Also remove files from the last hour
+$ jira issue attachment clean ./downloads --older-than 1h`
+)
+
+// NewCmdAttachmentClean is an attachment clean command.
+func NewCmdAttachmentClean() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "clean [DIR]",
+		Short:   "Remove temporary files left by interrupted downloads",
+		Long:    helpText,
+		Example: examples,
+		Annotations: map[string]string{
+			"help:args": "DIR\tDirectory to clean, defaults to the current directory",
+		},
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          clean,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().String("older-than", "", "Remove files not modified for this long, defaults to attachment.temp_max_age or 24h")
+
+	return &cmd
+}
+
+func clean(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	maxAge, err := olderThan(cmd)
+	if err != nil {
+		return err
+	}
+
+	dryRun := api.DryRun()
+	out := cmd.OutOrStdout()
+	removed, err := tmpfile.Clean(dir, tmpfile.Options{
+		MaxAge: maxAge,
+		DryRun: dryRun,
+		Notify: func(msg string) { _, _ = fmt.Fprintln(out, msg) },
+	})
+	if err != nil {
+		return cmdutil.Errorf("Unable to clean up temporary files in %s: %s", dir, err)
+	}
+
+	switch {
+	case len(removed) == 0:
+		cmdutil.SuccessStderr("No temporary files to clean up in %s", dir)
+	case dryRun:
+		cmdutil.DryRun("Would remove %d temporary file(s), %s", len(removed), cmdutil.FormatSize(totalSize(removed)))
+	default:
+		cmdutil.SuccessStderr("Removed %d temporary file(s), %s", len(removed), cmdutil.FormatSize(totalSize(removed)))
+	}
+	return nil
+}
+
+func olderThan(cmd *cobra.Command) (time.Duration, error) {
+	flag, err := cmd.Flags().GetString("older-than")
+	if err != nil {
+		return 0, err
+	}
+	if flag == "" {
+		return cmdcommon.TempMaxAge()
+	}
+	age, err := time.ParseDuration(flag)
+	if err != nil || age <= 0 {
+		return 0, cmdutil.Errorf("Invalid --older-than %q, use eg: 12h or 30m", flag)
+	}
+	return age, nil
+}
+
+func totalSize(leftovers []tmpfile.Leftover) int64 {
+	var n int64
+	for _, l := range leftovers {
+		n += l.Size
+	}
+	return n
+}
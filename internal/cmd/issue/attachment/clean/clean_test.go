@@ -0,0 +1,64 @@
+package clean
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
+)
+
+// deadPID is above the pid limit of the supported systems, so no process runs with it.
+const deadPID = 99999999
+
+func seed(t *testing.T, dir, name string, age time.Duration) string {
+	t.Helper()
+
+	path := filepath.Join(dir, fmt.Sprintf("%s%d-%s.1%s", tmpfile.Prefix, deadPID, name, tmpfile.Suffix))
+	require.NoError(t, os.WriteFile(path, []byte("partial"), 0o600))
+	mtime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	return path
+}
+
+func TestClean(t *testing.T) {
+	dir := t.TempDir()
+	stale := seed(t, dir, "a.txt", 48*time.Hour)
+	fresh := seed(t, dir, "b.txt", 2*time.Hour)
+
+	res := cmdtest.Run(t, cmdtest.Env{}, NewCmdAttachmentClean(), dir, "--dry-run")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "Would remove temporary file "+stale+" left by pid 99999999")
+	assert.NotContains(t, res.Stdout, fresh)
+	assert.Contains(t, res.Stderr, "Would remove 1 temporary file(s), 7 B")
+	assert.FileExists(t, stale)
+
+	res = cmdtest.Run(t, cmdtest.Env{}, NewCmdAttachmentClean(), dir)
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "Removed temporary file "+stale)
+	assert.Contains(t, res.Stderr, "Removed 1 temporary file(s), 7 B")
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, fresh)
+
+	res = cmdtest.Run(t, cmdtest.Env{}, NewCmdAttachmentClean(), dir, "--older-than", "1h")
+	require.NoError(t, res.Err)
+	assert.NoFileExists(t, fresh)
+
+	res = cmdtest.Run(t, cmdtest.Env{}, NewCmdAttachmentClean(), dir)
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stderr, "No temporary files to clean up in "+dir)
+}
+
+func TestCleanInvalidAge(t *testing.T) {
+	res := cmdtest.Run(t, cmdtest.Env{}, NewCmdAttachmentClean(), t.TempDir(), "--older-than", "0")
+	assert.EqualError(t, res.Err, `Invalid --older-than "0", use eg: 12h or 30m`)
+
+	res = cmdtest.Run(t, cmdtest.Env{Config: map[string]any{"attachment.temp_max_age": "-1h"}}, NewCmdAttachmentClean(), t.TempDir())
+	assert.EqualError(t, res.Err, `Invalid attachment.temp_max_age duration "-1h"`)
+}
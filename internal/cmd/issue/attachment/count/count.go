@@ -0,0 +1,174 @@
+package count
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/query"
+)
+
+const (
+	helpText = `Count prints the number of attachments on an issue.
+
+It is meant for shell prompts and status lines: it fetches only the attachment field,
+gives up after --max-time and prints nothing but the count. If ISSUE-KEY is omitted,
+the key is read from the closest .jira-issue file in the current directory or its parents.`
+	examples = `$ jira issue attachment count ISSUE-1
+
+# Use the key from .jira-issue and never fail, eg: in a prompt
+$ jira issue attachment count --soft
+
+# Wait at most 300ms for the server
+$ jira issue attachment count ISSUE-1 --max-time 300ms`
+
+	defaultMaxTime = "800ms"
+
+	// unavailable is printed instead of the count if it couldn't be fetched.
+	unavailable = "n/a"
+)
+
+// NewCmdAttachmentCount is an attachment count command.
+func NewCmdAttachmentCount() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "count [ISSUE-KEY]",
+		Short:   "Print the number of attachments on an issue",
+		Long:    helpText,
+		Example: examples,
+		Annotations: map[string]string{
+			"help:args": "ISSUE-KEY\tIssue key, eg: ISSUE-1, defaults to the key in .jira-issue",
+		},
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          count,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().Bool("soft", false, `Print "n/a" and exit with status 0 on any error`)
+	cmd.Flags().String("max-time", defaultMaxTime, `Maximum time to wait for the server, eg: 300ms, prints "n/a" when exceeded`)
+
+	return &cmd
+}
+
+func count(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+
+	params, err := parseArgsAndFlags(args, cmd.Flags())
+	if err != nil {
+		return softError(out, params, err)
+	}
+	if params.apiVersion, err = cmdcommon.GetAPIVersion(cmd); err != nil {
+		return softError(out, params, err)
+	}
+
+	n, err := fetchCount(cmd.Context(), params)
+	if err != nil {
+		return softError(out, params, err)
+	}
+
+	_, _ = fmt.Fprintln(out, n)
+	return nil
+}
+
+// softError prints "n/a" in place of the count on timeouts, and with --soft on any
+// error, so that a prompt shows a placeholder rather than nothing. Errors are only
+// swallowed with --soft.
+func softError(out io.Writer, params *countParams, err error) error {
+	soft := params != nil && params.soft
+	timedOut := errors.Is(err, context.DeadlineExceeded)
+
+	if soft || timedOut {
+		_, _ = fmt.Fprintln(out, unavailable)
+	}
+	if soft {
+		return nil
+	}
+	if timedOut {
+		return cmdutil.Errorf("Timed out after %s fetching the attachments of issue %q", params.maxTime, params.issueKey)
+	}
+	return err
+}
+
+func fetchCount(ctx context.Context, params *countParams) (int, error) {
+	if params.issueKey == "" {
+		return 0, cmdutil.Errorf("ISSUE-KEY is required, pass it or add it to a %s file", cmdutil.IssueKeyFile)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if params.maxTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, params.maxTime)
+		defer cancel()
+	}
+
+	client := api.DefaultClient(params.debug)
+
+	issue, err := cmdcommon.GetAttachmentIssueContext(ctx, client, params.apiVersion, params.issueKey, cmdcommon.AttachmentIssueFields)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, cmdutil.RequestError(err, params.debug)
+	}
+	return len(issue.Fields.Attachments), nil
+}
+
+type countParams struct {
+	issueKey string
+	soft     bool
+	maxTime  time.Duration
+	debug    bool
+
+	apiVersion string
+}
+
+func parseArgsAndFlags(args []string, flags query.FlagParser) (*countParams, error) {
+	var params countParams
+
+	soft, err := flags.GetBool("soft")
+	if err != nil {
+		return nil, err
+	}
+	params.soft = soft
+
+	maxTime, err := flags.GetString("max-time")
+	if err != nil {
+		return &params, err
+	}
+	if params.maxTime, err = time.ParseDuration(maxTime); err != nil || params.maxTime < 0 {
+		return &params, cmdutil.Errorf("Invalid --max-time %q, use a duration like 800ms or 2s", maxTime)
+	}
+
+	params.debug, err = flags.GetBool("debug")
+	if err != nil {
+		return &params, err
+	}
+
+	key := ""
+	if len(args) >= 1 {
+		key = args[0]
+	} else {
+		wd, err := os.Getwd()
+		if err != nil {
+			return &params, err
+		}
+		if key, err = cmdutil.FindIssueKey(wd); err != nil {
+			return &params, err
+		}
+	}
+	if key != "" {
+		params.issueKey = cmdutil.GetJiraIssueKey(viper.GetString("project.key"), key)
+	}
+
+	return &params, nil
+}
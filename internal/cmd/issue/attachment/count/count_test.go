@@ -0,0 +1,108 @@
+package count
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func newEnv(server *jiratest.Server) cmdtest.Env {
+	return cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{
+			"server":                  server.URL,
+			"installation":            "Cloud",
+			"auth.check_token_expiry": false,
+		},
+	}
+}
+
+func TestCount(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+	server.AddAttachment("TEST-1", "b.txt", []byte("b"))
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentCount(), "TEST-1")
+	require.NoError(t, res.Err)
+	assert.Equal(t, "2\n", res.Stdout)
+
+	// Only the attachment field is fetched.
+	reqs := server.Requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "attachment", reqs[0].Query.Get("fields"))
+}
+
+func TestCountIssueKeyFile(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, cmdutil.IssueKeyFile), []byte("TEST-1\n"), 0o600))
+	nested := filepath.Join(dir, "src")
+	require.NoError(t, os.Mkdir(nested, 0o700))
+	t.Chdir(nested)
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentCount())
+	require.NoError(t, res.Err)
+	assert.Equal(t, "1\n", res.Stdout)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, cmdutil.IssueKeyFile)))
+
+	res = cmdtest.Run(t, newEnv(server), NewCmdAttachmentCount())
+	assert.ErrorContains(t, res.Err, "ISSUE-KEY is required")
+
+	res = cmdtest.Run(t, newEnv(server), NewCmdAttachmentCount(), "--soft")
+	require.NoError(t, res.Err)
+	assert.Equal(t, "n/a\n", res.Stdout)
+}
+
+func TestCountMaxTime(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.SetFaults(jiratest.Faults{Latency: 5 * time.Second})
+
+	start := time.Now()
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentCount(), "TEST-1", "--max-time", "50ms")
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, "n/a\n", res.Stdout)
+	assert.ErrorContains(t, res.Err, "Timed out after 50ms")
+
+	res = cmdtest.Run(t, newEnv(server), NewCmdAttachmentCount(), "TEST-1", "--max-time", "50ms", "--soft")
+	require.NoError(t, res.Err)
+	assert.Equal(t, "n/a\n", res.Stdout)
+}
+
+func TestCountNetworkError(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	env := newEnv(server)
+	server.Close()
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentCount(), "TEST-1")
+	require.Error(t, res.Err)
+	assert.Empty(t, res.Stdout)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentCount(), "TEST-1", "--soft")
+	require.NoError(t, res.Err)
+	assert.Equal(t, "n/a\n", res.Stdout)
+}
+
+func TestCountInvalidMaxTime(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentCount(), "TEST-1", "--max-time", "soon")
+	assert.ErrorContains(t, res.Err, `Invalid --max-time "soon"`)
+}
@@ -0,0 +1,449 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/notify"
+	"github.com/ankitpokhrel/jira-cli/pkg/atomicfile"
+	"github.com/ankitpokhrel/jira-cli/pkg/dirlock"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	// bulkProgressFile is the file in the output directory recording the progress of a --jql
+	// download, so that --resume continues an interrupted run.
+	bulkProgressFile = ".jira-cli-bulk-progress.json"
+
+	// bulkProgressVersion is the schema version of the progress file. Files without a
+	// version were written before the checksums were added and are read as is.
+	bulkProgressVersion = 2
+
+	// bulkPageSize is the number of keys fetched per search request of the snapshot.
+	bulkPageSize = 100
+)
+
+// errNoIssues denotes a progress file without any issue, eg: of an empty snapshot.
+var errNoIssues = errors.New("it has no issues")
+
+// bulkProgress is the frozen list of issue keys of a --jql download and the issues
+// processed so far. Skipped issues are the ones gone between the snapshot and their turn,
+// with the reason.
+type bulkProgress struct {
+	JQL       string            `json:"jql"`
+	Keys      []string          `json:"keys"`
+	Completed []string          `json:"completed"`
+	Skipped   map[string]string `json:"skipped,omitempty"`
+
+	path string
+	// flushEvery is the number of issues recorded between two writes of the file, so that
+	// a crash loses at most as many. Issues recorded since the last write are unsaved.
+	flushEvery int
+	unsaved    int
+}
+
+// bulkProgressData is the content of the progress file. The snapshot and each issue done
+// have a checksum, so that a file corrupted on disk is detected instead of resuming from
+// wrong progress.
+type bulkProgressData struct {
+	Version int                 `json:"version"`
+	JQL     string              `json:"jql"`
+	Keys    []string            `json:"keys"`
+	Sum     string              `json:"sum"`
+	Entries []bulkProgressEntry `json:"entries"`
+}
+
+// bulkProgressEntry is an issue done, completed or skipped for the reason.
+type bulkProgressEntry struct {
+	Key     string `json:"key"`
+	Skipped string `json:"skipped,omitempty"`
+	Sum     string `json:"sum"`
+}
+
+// progressSum returns the checksum of the fields of a progress file. It detects corruption,
+// not tampering, so a short sum is enough.
+func progressSum(fields ...string) string {
+	h := sha256.New()
+	for _, f := range fields {
+		_, _ = h.Write([]byte(f))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func snapshotSum(jql string, keys []string) string {
+	return progressSum(append([]string{strconv.Itoa(bulkProgressVersion), jql}, keys...)...)
+}
+
+var jqlOrderBy = regexp.MustCompile(`(?i)\border\s+by\b`)
+
+// bulkJQL returns the jql of the snapshot, ordered by key so that pages stay in the same
+// order while issues are created or updated. An ORDER BY clause of the jql is replaced.
+func bulkJQL(jql string) string {
+	if loc := jqlOrderBy.FindStringIndex(jql); loc != nil {
+		jql = jql[:loc[0]]
+	}
+	if jql = strings.TrimSpace(jql); jql == "" {
+		return "ORDER BY key ASC"
+	}
+	return jql + " ORDER BY key ASC"
+}
+
+// snapshotKeys pages through the issues matching the jql with only their key fetched and
+// returns the keys in order. A key returned twice, eg: by an offset shifted by a new issue,
+// is only kept once.
+func snapshotKeys(client *jira.Client, version, jql string) ([]string, error) {
+	var keys []string
+	seen := make(map[string]bool)
+	err := api.ProxySearchIssueKeysVersion(client, version, bulkJQL(jql), bulkPageSize, func(issues []*jira.Issue) error {
+		for _, iss := range issues {
+			if iss.Key == "" || seen[iss.Key] {
+				continue
+			}
+			seen[iss.Key] = true
+			keys = append(keys, iss.Key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// loadBulkProgress reads the progress file of the output directory. If the file is
+// missing or corrupt, eg: torn by a crash during its write, its backup is used instead and
+// warn is called. A nil progress is returned if neither exist, or can't be read as a
+// progress file, in which case warn is called and the run starts over.
+func loadBulkProgress(dir string, warn func(format string, a ...any)) *bulkProgress {
+	path := filepath.Join(dir, bulkProgressFile)
+
+	var p *bulkProgress
+	_, err := atomicfile.ReadFile(path, func(b []byte) error {
+		var err error
+		p, err = decodeBulkProgress(b)
+		return err
+	}, func(reason error) {
+		warn("Resuming from the backup %s, the progress file %s", atomicfile.BackupPath(path), progressProblem(reason))
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		warn("Ignoring the progress file %s, %s", path, progressProblem(err))
+		return nil
+	}
+	p.path = path
+	return p
+}
+
+// progressProblem describes why a progress file can't be used.
+func progressProblem(err error) string {
+	var cErr *atomicfile.CorruptError
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return "is missing"
+	case errors.Is(err, errNoIssues):
+		return errNoIssues.Error()
+	case errors.As(err, &cErr):
+		return "it is corrupt: " + cErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// decodeBulkProgress decodes a progress file and verifies its checksums.
+func decodeBulkProgress(b []byte) (*bulkProgress, error) {
+	var f bulkProgressData
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	var p bulkProgress
+	switch {
+	case f.Version == 0:
+		if err := json.Unmarshal(b, &p); err != nil {
+			return nil, err
+		}
+	case f.Version > bulkProgressVersion:
+		return nil, fmt.Errorf("version %d is written by a newer jira-cli", f.Version)
+	default:
+		if f.Sum != snapshotSum(f.JQL, f.Keys) {
+			return nil, errors.New("checksum mismatch of the issue keys")
+		}
+		p = bulkProgress{JQL: f.JQL, Keys: f.Keys}
+		for _, e := range f.Entries {
+			if e.Sum != progressSum(e.Key, e.Skipped) {
+				return nil, fmt.Errorf("checksum mismatch of issue %s", e.Key)
+			}
+			if e.Skipped == "" {
+				p.Completed = append(p.Completed, e.Key)
+				continue
+			}
+			if p.Skipped == nil {
+				p.Skipped = make(map[string]string)
+			}
+			p.Skipped[e.Key] = e.Skipped
+		}
+	}
+	if len(p.Keys) == 0 && len(p.Completed) == 0 {
+		return nil, errNoIssues
+	}
+	return &p, nil
+}
+
+// encode returns the content of the progress file, the completed issues in order then the
+// skipped ones by key.
+func (p *bulkProgress) encode() ([]byte, error) {
+	f := bulkProgressData{
+		Version: bulkProgressVersion,
+		JQL:     p.JQL,
+		Keys:    p.Keys,
+		Sum:     snapshotSum(p.JQL, p.Keys),
+		Entries: make([]bulkProgressEntry, 0, len(p.Completed)+len(p.Skipped)),
+	}
+	for _, k := range p.Completed {
+		f.Entries = append(f.Entries, bulkProgressEntry{Key: k, Sum: progressSum(k, "")})
+	}
+	skipped := make([]string, 0, len(p.Skipped))
+	for k := range p.Skipped {
+		skipped = append(skipped, k)
+	}
+	sort.Strings(skipped)
+	for _, k := range skipped {
+		f.Entries = append(f.Entries, bulkProgressEntry{Key: k, Skipped: p.Skipped[k], Sum: progressSum(k, p.Skipped[k])})
+	}
+	return json.MarshalIndent(f, "", "  ")
+}
+
+func newBulkProgress(dir, jql string, keys []string) *bulkProgress {
+	return &bulkProgress{JQL: jql, Keys: keys, path: filepath.Join(dir, bulkProgressFile)}
+}
+
+// pending returns the keys of the snapshot not completed or skipped yet, in order.
+func (p *bulkProgress) pending() []string {
+	done := make(map[string]bool, len(p.Completed)+len(p.Skipped))
+	for _, k := range p.Completed {
+		done[k] = true
+	}
+	for k := range p.Skipped {
+		done[k] = true
+	}
+
+	keys := make([]string, 0, len(p.Keys))
+	for _, k := range p.Keys {
+		if !done[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (p *bulkProgress) complete(key string) error {
+	if !slices.Contains(p.Completed, key) {
+		p.Completed = append(p.Completed, key)
+	}
+	return p.record()
+}
+
+func (p *bulkProgress) skip(key, reason string) error {
+	if p.Skipped == nil {
+		p.Skipped = make(map[string]string)
+	}
+	p.Skipped[key] = reason
+	return p.record()
+}
+
+// record saves the progress once flushEvery issues are unsaved.
+func (p *bulkProgress) record() error {
+	p.unsaved++
+	if p.unsaved < p.flushEvery {
+		return nil
+	}
+	return p.save()
+}
+
+// flush saves the issues recorded since the last save, if any.
+func (p *bulkProgress) flush() error {
+	if p.unsaved == 0 {
+		return nil
+	}
+	return p.save()
+}
+
+// save writes the progress file atomically, keeping the previous one as its backup.
+func (p *bulkProgress) save() error {
+	b, err := p.encode()
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(p.path, b, 0o600); err != nil {
+		return err
+	}
+	p.unsaved = 0
+	return nil
+}
+
+// vanishedReason returns why an issue of the snapshot can't be fetched anymore, false if the
+// error isn't about the issue being gone.
+func vanishedReason(err error) (string, bool) {
+	var uErr *jira.ErrUnexpectedResponse
+	if !errors.As(err, &uErr) {
+		return "", false
+	}
+	switch uErr.StatusCode {
+	case http.StatusNotFound:
+		return "not found, it was deleted or moved", true
+	case http.StatusForbidden:
+		return "permission denied", true
+	}
+	return "", false
+}
+
+// startBulk returns the progress of a --jql download: the one recorded in the output
+// directory with --resume, else a new snapshot of the issue keys.
+func startBulk(client *jira.Client, params *downloadParams) (*bulkProgress, error) {
+	if params.resume {
+		p := loadBulkProgress(params.outputDir, func(format string, a ...any) { cmdutil.Warn(format, a...) })
+		switch {
+		case p == nil:
+			cmdutil.Warn("No progress to resume in %s, starting over", params.outputDir)
+		case p.JQL != params.jql:
+			return nil, cmdutil.Errorf("The download in %s was started with another --jql: %s", params.outputDir, p.JQL)
+		default:
+			p.flushEvery = params.flushEvery
+			return p, nil
+		}
+	}
+
+	keys, err := snapshotKeys(client, params.apiVersion, params.jql)
+	if err != nil {
+		return nil, cmdutil.RequestError(err, params.debug)
+	}
+	p := newBulkProgress(params.outputDir, params.jql, keys)
+	p.flushEvery = params.flushEvery
+	if err := p.save(); err != nil {
+		return nil, cmdutil.Errorf("Unable to write the progress file: %s", err)
+	}
+	return p, nil
+}
+
+// downloadBulk downloads the attachments selected by the flags of each pending issue of the
+// progress into a directory named after the issue key, and records each issue once done.
+// It stops at the first failure, the issues left are downloaded by a run with --resume. The
+// issues not saved yet are saved when it returns. It returns the number of attachments
+// attempted.
+func downloadBulk(client *jira.Client, progress *bulkProgress, params *downloadParams, resolver *conflictResolver) (total int, err error) {
+	defer func() {
+		if fErr := progress.flush(); fErr != nil && err == nil {
+			err = fmt.Errorf("unable to write the progress file: %w", fErr)
+		}
+	}()
+
+	ctx := params.requestContext()
+	for _, key := range progress.pending() {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		issue, err := cmdcommon.GetAttachmentIssueContext(ctx, client, params.apiVersion, key, cmdcommon.AttachmentIssueFields)
+		if err != nil {
+			reason, ok := vanishedReason(err)
+			if !ok {
+				return total, err
+			}
+			cmdutil.Warn("Skipping %s, %s", key, reason)
+			if err := progress.skip(key, reason); err != nil {
+				return total, fmt.Errorf("unable to write the progress file: %w", err)
+			}
+			continue
+		}
+
+		picked, _ := params.selector.Apply(issue.Fields.Attachments)
+		batches, _ := excludeUnavailable([]cmdcommon.IssueAttachments{{Issue: key, Attachments: picked}}, func(format string, a ...any) {
+			cmdutil.Warn(format, a...)
+		})
+		batches, _ = params.policy.exclude(batches)
+
+		if n := len(batches[0].Attachments); n > 0 {
+			total += n
+			issueParams := *params
+			issueParams.issueKey = key
+			issueParams.outputDir = filepath.Join(params.outputDir, key)
+			if err := makeDir(issueParams.outputDir, params.dirMode); err != nil {
+				return total, err
+			}
+			cmdcommon.CleanTempFiles(issueParams.outputDir, params.tempMaxAge)
+			if err := downloadBatches(client, batches, &issueParams, resolver); err != nil {
+				return total, err
+			}
+		}
+
+		if err := progress.complete(key); err != nil {
+			return total, fmt.Errorf("unable to write the progress file: %w", err)
+		}
+	}
+	return total, nil
+}
+
+// downloadJQL runs a --jql download into the output directory, locked for the whole run.
+func downloadJQL(client *jira.Client, params *downloadParams, notifier *notify.Notifier) error {
+	if params.selector.ID != "" || (!params.all && !params.selector.Filtered()) {
+		return cmdutil.Errorf("--jql can only be used with --all or the attachment filters")
+	}
+	if err := params.selector.Resolve(client); err != nil {
+		return err
+	}
+	if err := makeDir(params.outputDir, params.dirMode); err != nil {
+		return err
+	}
+	if err := cmdcommon.CheckTokenExpiry(client); err != nil {
+		return err
+	}
+
+	lock, err := dirlock.Acquire(params.outputDir, dirlock.Options{
+		Wait:   params.waitLock,
+		Notify: func(msg string) { cmdutil.Warn(msg) },
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
+
+	progress, err := startBulk(client, params)
+	if err != nil {
+		return err
+	}
+	pending := len(progress.pending())
+	if pending < len(progress.Keys) {
+		cmdutil.Warn("Resuming, %d of %d issue(s) left", pending, len(progress.Keys))
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	params.ctx = ctx
+	params.tally = &downloadTally{}
+
+	total, err := downloadBulk(client, progress, params, newConflictResolver(params.conflict))
+	notifier.Done(params.tally.summary(params.jql, total))
+	if err != nil {
+		cmdutil.Warn("Stopped with %d of %d issue(s) left, run again with --resume to continue", len(progress.pending()), len(progress.Keys))
+		return downloadError(params.tally.fail(err), params.debug)
+	}
+
+	cmdutil.Success("Processed %d issue(s) matching the JQL, %d skipped", len(progress.Completed), len(progress.Skipped))
+	if err := params.policy.deniedError(); err != nil {
+		return err
+	}
+	return params.verifier.finish(params.strict)
+}
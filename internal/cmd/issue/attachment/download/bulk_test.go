@@ -0,0 +1,440 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/selector"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestBulkJQL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		jql  string
+		want string
+	}{
+		{jql: "project = TEST", want: "project = TEST ORDER BY key ASC"},
+		{jql: " project = TEST AND updated >= -7d ", want: "project = TEST AND updated >= -7d ORDER BY key ASC"},
+		{jql: "project = TEST order by updated DESC", want: "project = TEST ORDER BY key ASC"},
+		{jql: "ORDER BY created", want: "ORDER BY key ASC"},
+		{jql: "", want: "ORDER BY key ASC"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, bulkJQL(tc.jql), tc.jql)
+	}
+}
+
+func TestSnapshotKeys(t *testing.T) {
+	t.Parallel()
+
+	// The second page starts with the last key of the first one, like when an issue
+	// is created during the snapshot.
+	pages := map[string]string{
+		"":  `{"issues": [{"key": "TEST-1"}, {"key": "TEST-2"}], "nextPageToken": "2"}`,
+		"2": `{"issues": [{"key": "TEST-2"}, {"key": "TEST-3"}], "isLast": true}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/3/search/jql", r.URL.Path)
+		assert.Equal(t, "project = TEST ORDER BY key ASC", r.URL.Query().Get("jql"))
+		assert.Equal(t, "key", r.URL.Query().Get("fields"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(pages[r.URL.Query().Get("nextPageToken")]))
+	}))
+	defer server.Close()
+
+	client := jira.NewClient(jira.Config{Server: server.URL}, jira.WithTimeout(3*time.Second))
+	keys, err := snapshotKeys(client, api.APIVersion3, "project = TEST ORDER BY updated")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TEST-1", "TEST-2", "TEST-3"}, keys)
+}
+
+func TestLoadBulkProgress(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		content string
+		warning string
+	}{
+		{name: "missing"},
+		{name: "truncated", content: `{"jql": "project = TEST", "keys": ["TEST-1", "TE`, warning: "it is corrupt"},
+		{name: "not a progress file", content: `[1, 2, 3]`, warning: "it is corrupt"},
+		{name: "empty", content: `{}`, warning: "it has no issues"},
+		{name: "newer version", content: `{"version": 99, "jql": "project = TEST", "keys": ["TEST-1"]}`, warning: "written by a newer jira-cli"},
+		{
+			name:    "wrong snapshot checksum",
+			content: `{"version": 2, "jql": "project = TEST", "keys": ["TEST-1", "TEST-2"], "sum": "0000000000000000", "entries": []}`,
+			warning: "checksum mismatch of the issue keys",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if tc.content != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, bulkProgressFile), []byte(tc.content), 0o600))
+			}
+
+			var warnings []string
+			p := loadBulkProgress(dir, func(format string, a ...any) { warnings = append(warnings, fmt.Sprintf(format, a...)) })
+			assert.Nil(t, p)
+			if tc.warning == "" {
+				assert.Empty(t, warnings)
+			} else {
+				require.Len(t, warnings, 1)
+				assert.Contains(t, warnings[0], tc.warning)
+			}
+		})
+	}
+}
+
+func TestBulkProgressSaveAndLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := newBulkProgress(dir, "project = TEST", []string{"TEST-1", "TEST-2", "TEST-3"})
+	require.NoError(t, p.complete("TEST-1"))
+	require.NoError(t, p.skip("TEST-2", "permission denied"))
+
+	loaded := loadBulkProgress(dir, func(format string, a ...any) { t.Errorf(format, a...) })
+	require.NotNil(t, loaded)
+	assert.Equal(t, "project = TEST", loaded.JQL)
+	assert.Equal(t, []string{"TEST-1", "TEST-2", "TEST-3"}, loaded.Keys)
+	assert.Equal(t, []string{"TEST-1"}, loaded.Completed)
+	assert.Equal(t, map[string]string{"TEST-2": "permission denied"}, loaded.Skipped)
+
+	var names []string
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{bulkProgressFile, bulkProgressFile + ".bak"}, names, "no temporary file is left")
+}
+
+func TestLoadBulkProgressLegacy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	content := `{"jql": "project = TEST", "keys": ["TEST-1", "TEST-2"], "completed": ["TEST-1"]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, bulkProgressFile), []byte(content), 0o600))
+
+	p := loadBulkProgress(dir, func(format string, a ...any) { t.Errorf(format, a...) })
+	require.NotNil(t, p)
+	assert.Equal(t, []string{"TEST-2"}, p.pending())
+}
+
+func TestLoadBulkProgressDetectsPartialCorruption(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := newBulkProgress(dir, "project = TEST", []string{"TEST-1", "TEST-2", "TEST-3"})
+	require.NoError(t, p.save())
+	require.NoError(t, p.complete("TEST-1"))
+
+	// A flipped byte leaves valid json with another issue completed.
+	path := filepath.Join(dir, bulkProgressFile)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	b = bytes.Replace(b, []byte(`"key": "TEST-1"`), []byte(`"key": "TEST-3"`), 1)
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	var warnings []string
+	loaded := loadBulkProgress(dir, func(format string, a ...any) { warnings = append(warnings, fmt.Sprintf(format, a...)) })
+	require.NotNil(t, loaded)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "checksum mismatch of issue TEST-3")
+
+	// The backup is the progress before TEST-1 was completed.
+	assert.Empty(t, loaded.Completed)
+	assert.Equal(t, []string{"TEST-1", "TEST-2", "TEST-3"}, loaded.pending())
+}
+
+func TestLoadBulkProgressTornWrite(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		tear func(t *testing.T, path string)
+	}{
+		{
+			name: "truncated",
+			tear: func(t *testing.T, path string) {
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				require.NoError(t, os.Truncate(path, info.Size()/2))
+			},
+		},
+		{
+			name: "missing",
+			tear: func(t *testing.T, path string) {
+				require.NoError(t, os.Remove(path))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			p := newBulkProgress(dir, "project = TEST", []string{"TEST-1", "TEST-2", "TEST-3"})
+			require.NoError(t, p.save())
+			require.NoError(t, p.complete("TEST-1"))
+			require.NoError(t, p.complete("TEST-2"))
+			tc.tear(t, filepath.Join(dir, bulkProgressFile))
+
+			var warnings []string
+			loaded := loadBulkProgress(dir, func(format string, a ...any) { warnings = append(warnings, fmt.Sprintf(format, a...)) })
+			require.NotNil(t, loaded)
+			require.Len(t, warnings, 1)
+			assert.Contains(t, warnings[0], "Resuming from the backup")
+			assert.Equal(t, []string{"TEST-1"}, loaded.Completed)
+			assert.Equal(t, []string{"TEST-2", "TEST-3"}, loaded.pending())
+		})
+	}
+}
+
+func TestBulkProgressFlushEvery(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := newBulkProgress(dir, "project = TEST", []string{"TEST-1", "TEST-2", "TEST-3", "TEST-4", "TEST-5"})
+	p.flushEvery = 2
+	require.NoError(t, p.save())
+
+	saved := func() []string {
+		loaded := loadBulkProgress(dir, func(format string, a ...any) { t.Errorf(format, a...) })
+		require.NotNil(t, loaded)
+		return loaded.Completed
+	}
+
+	require.NoError(t, p.complete("TEST-1"))
+	assert.Empty(t, saved(), "a crash now loses at most 2 issues")
+	require.NoError(t, p.skip("TEST-2", "not found"))
+	assert.Equal(t, []string{"TEST-1"}, saved())
+	require.NoError(t, p.complete("TEST-3"))
+	assert.Equal(t, []string{"TEST-1"}, saved())
+
+	require.NoError(t, p.flush())
+	assert.Equal(t, []string{"TEST-1", "TEST-3"}, saved())
+	assert.Zero(t, p.unsaved)
+}
+
+func TestBulkProgressPending(t *testing.T) {
+	t.Parallel()
+
+	p := &bulkProgress{
+		Keys:      []string{"TEST-1", "TEST-2", "TEST-3", "TEST-4"},
+		Completed: []string{"TEST-3", "TEST-1", "OTHER-1"},
+		Skipped:   map[string]string{"TEST-2": "not found"},
+	}
+	assert.Equal(t, []string{"TEST-4"}, p.pending())
+
+	p = &bulkProgress{Keys: []string{"TEST-2", "TEST-1"}}
+	assert.Equal(t, []string{"TEST-2", "TEST-1"}, p.pending(), "the snapshot order is kept")
+}
+
+func TestDownloadBulkSkipsVanishedIssues(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-2"))
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+	server.AddAttachment("TEST-2", "b.txt", []byte("b"))
+	server.AddAttachment("TEST-3", "c.txt", []byte("c"))
+	server.Forbid("TEST-2")
+
+	dir := t.TempDir()
+	progress := newBulkProgress(dir, "project = TEST", []string{"TEST-1", "TEST-2", "TEST-9", "TEST-3"})
+	params := &downloadParams{outputDir: dir, all: true, selector: &selector.Selector{}, apiVersion: api.APIVersion3}
+
+	total, err := downloadBulk(server.Client(), progress, params, newConflictResolver(conflictFail))
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	assert.Equal(t, []string{"TEST-1", "TEST-3"}, progress.Completed)
+	assert.Equal(t, map[string]string{
+		"TEST-2": "permission denied",
+		"TEST-9": "not found, it was deleted or moved",
+	}, progress.Skipped)
+
+	for key, name := range map[string]string{"TEST-1": "a.txt", "TEST-3": "c.txt"} {
+		_, err := os.Stat(filepath.Join(dir, key, name))
+		assert.NoError(t, err)
+	}
+	_, err = os.Stat(filepath.Join(dir, "TEST-2"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// failingIssue serves a fake Jira, failing the requests for an issue while set.
+type failingIssue struct {
+	jira *jiratest.Server
+
+	mu    sync.Mutex
+	issue string
+	paths []string
+}
+
+func (f *failingIssue) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.paths = append(f.paths, r.URL.Path)
+	fail := f.issue != "" && strings.HasSuffix(r.URL.Path, "/issue/"+f.issue)
+	f.mu.Unlock()
+
+	if fail {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	f.jira.ServeHTTP(w, r)
+}
+
+func (f *failingIssue) set(issue string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.issue = issue
+	f.paths = nil
+}
+
+func (f *failingIssue) requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.paths...)
+}
+
+func TestDownloadJQLResume(t *testing.T) {
+	fake := jiratest.New()
+	attachments := map[string]jira.Attachment{}
+	for _, key := range []string{"TEST-1", "TEST-2", "TEST-3"} {
+		attachments[key] = fake.AddAttachment(key, strings.ToLower(key)+".txt", []byte(key))
+	}
+
+	f := &failingIssue{jira: fake}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+
+	client := jira.NewClient(jira.Config{Server: ts.URL}, jira.WithTimeout(5*time.Second))
+	env := cmdtest.Env{Client: client, Config: map[string]any{"installation": jira.InstallationTypeCloud, "auth.check_token_expiry": false}}
+	out := t.TempDir()
+
+	// The run stops after the first issue.
+	f.set("TEST-2")
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "--jql", "project = TEST", "--all", "--output", out)
+	require.Error(t, res.Err)
+	assert.Contains(t, res.Stderr, "run again with --resume")
+
+	_, err := os.Stat(filepath.Join(out, "TEST-1", "test-1.txt"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(out, "TEST-2"))
+	require.True(t, os.IsNotExist(err))
+
+	// New issues matching the jql don't change the frozen list.
+	fake.AddAttachment("TEST-4", "test-4.txt", []byte("TEST-4"))
+
+	f.set("")
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "--jql", "project = TEST", "--all", "--output", out, "--resume")
+	require.NoError(t, res.Err)
+
+	for _, path := range f.requests() {
+		assert.NotContains(t, path, "/search", "the snapshot isn't taken again")
+		assert.NotContains(t, path, "TEST-1", "the completed issue isn't touched again")
+		assert.NotContains(t, path, attachments["TEST-1"].ID)
+		assert.NotContains(t, path, "TEST-4")
+	}
+	for _, key := range []string{"TEST-2", "TEST-3"} {
+		got, err := os.ReadFile(filepath.Join(out, key, strings.ToLower(key)+".txt"))
+		require.NoError(t, err)
+		assert.Equal(t, key, string(got))
+	}
+
+	progress := loadBulkProgress(out, func(format string, a ...any) { t.Errorf(format, a...) })
+	require.NotNil(t, progress)
+	assert.Equal(t, []string{"TEST-1", "TEST-2", "TEST-3"}, progress.Completed)
+	assert.Empty(t, progress.pending())
+}
+
+func TestDownloadJQLResumeFromBackup(t *testing.T) {
+	fake := jiratest.New()
+	for _, key := range []string{"TEST-1", "TEST-2", "TEST-3"} {
+		fake.AddAttachment(key, strings.ToLower(key)+".txt", []byte(key))
+	}
+
+	f := &failingIssue{jira: fake}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+
+	client := jira.NewClient(jira.Config{Server: ts.URL}, jira.WithTimeout(5*time.Second))
+	env := cmdtest.Env{Client: client, Config: map[string]any{"installation": jira.InstallationTypeCloud, "auth.check_token_expiry": false}}
+	out := t.TempDir()
+
+	// The run stops after two issues, then the progress file is torn as if by a crash
+	// during its last write.
+	f.set("TEST-3")
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "--jql", "project = TEST", "--all", "--output", out)
+	require.Error(t, res.Err)
+
+	path := filepath.Join(out, bulkProgressFile)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-10))
+
+	f.set("")
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "--jql", "project = TEST", "--all", "--output", out, "--resume", "--on-conflict", "overwrite")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stderr, "Resuming from the backup")
+	assert.Contains(t, res.Stderr, "Resuming, 2 of 3 issue(s) left")
+
+	for _, path := range f.requests() {
+		assert.NotContains(t, path, "/search", "the snapshot isn't taken again")
+		assert.NotContains(t, path, "TEST-1", "the issue completed as per the backup isn't touched again")
+	}
+	progress := loadBulkProgress(out, func(format string, a ...any) { t.Errorf(format, a...) })
+	require.NotNil(t, progress)
+	assert.Equal(t, []string{"TEST-1", "TEST-2", "TEST-3"}, progress.Completed)
+}
+
+func TestDownloadJQLFlags(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "resume without jql", args: []string{"TEST-1", "--all", "--resume"}, err: "--resume requires --jql"},
+		{name: "with issue key", args: []string{"TEST-1", "--jql", "project = TEST", "--all"}, err: "--jql can't be combined with ISSUE-KEY"},
+		{name: "with tar", args: []string{"--jql", "project = TEST", "--all", "--tar", "-"}, err: "--jql can't be combined with --tar"},
+		{name: "without selection", args: []string{"--jql", "project = TEST"}, err: "--jql can only be used with --all or the attachment filters"},
+		{name: "flush every without jql", args: []string{"TEST-1", "--all", "--flush-every", "10"}, err: "--flush-every requires --jql"},
+		{name: "flush every zero", args: []string{"--jql", "project = TEST", "--all", "--flush-every", "0"}, err: "--flush-every must be at least 1"},
+	}
+
+	for _, tc := range cases {
+		res := cmdtest.Run(t, cmdtest.Env{Client: jira.NewClient(jira.Config{Server: "http://127.0.0.1:0"})}, NewCmdAttachmentDownload(), tc.args...)
+		require.Error(t, res.Err, tc.name)
+		assert.Contains(t, res.Err.Error(), tc.err, tc.name)
+	}
+}
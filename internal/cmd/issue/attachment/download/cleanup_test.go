@@ -0,0 +1,63 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
+)
+
+// deadPID is above the pid limit of the supported systems, so no process runs with it.
+const deadPID = 99999999
+
+// seedTempFile creates a temporary file of the pid in dir last modified age ago.
+func seedTempFile(t *testing.T, dir string, pid int, name string, age time.Duration) string {
+	t.Helper()
+
+	path := filepath.Join(dir, fmt.Sprintf("%s%d-%s.1%s", tmpfile.Prefix, pid, name, tmpfile.Suffix))
+	require.NoError(t, os.WriteFile(path, []byte("partial"), 0o600))
+	mtime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	return path
+}
+
+func TestDownloadCleansStaleTempFiles(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+	server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+
+	out := t.TempDir()
+	stale := seedTempFile(t, out, deadPID, "notes.txt", 25*time.Hour)
+	fresh := seedTempFile(t, out, deadPID, "spec.txt", time.Hour)
+	live := seedTempFile(t, out, os.Getpid(), "report.pdf", 72*time.Hour)
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "notes.txt", "--output", out)
+	require.NoError(t, res.Err)
+
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, fresh)
+	assert.FileExists(t, live)
+	assert.FileExists(t, filepath.Join(out, "notes.txt"))
+	assert.Contains(t, res.Stderr, "Removed temporary file "+stale)
+	assert.NotContains(t, res.Stderr, fresh)
+
+	// A shorter configured age also removes the recent file.
+	env.Config["attachment.temp_max_age"] = "30m"
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "notes.txt", "--output", out, "--on-conflict", "overwrite")
+	require.NoError(t, res.Err)
+	assert.NoFileExists(t, fresh)
+	assert.FileExists(t, live)
+
+	env.Config["attachment.temp_max_age"] = "soon"
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "notes.txt", "--output", out)
+	assert.EqualError(t, res.Err, `Invalid attachment.temp_max_age duration "soon"`)
+}
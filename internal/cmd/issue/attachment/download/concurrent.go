@@ -0,0 +1,209 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/s3"
+)
+
+// maxConcurrency caps --concurrency, more downloads at the same time rarely make a run
+// faster and get the client rate limited.
+const maxConcurrency = 8
+
+// downloadJob is an attachment to download along with its destination and the params of
+// its issue. With --s3-url, the destination is the URL of the object at key and the object
+// is set once it is written.
+type downloadJob struct {
+	attachment jira.Attachment
+	dest       string
+	key        string
+	object     *s3.Object
+	params     *downloadParams
+}
+
+// batchError is returned by a concurrent run if attachments failed to download. The
+// failures are reported and counted as they happen. The cause is the failure that stopped
+// the run, if any, eg: the credentials were rejected.
+type batchError struct {
+	failed []string
+	total  int
+	cause  error
+}
+
+func (e *batchError) Error() string {
+	msg := fmt.Sprintf("failed to download %d of %d attachment(s)", len(e.failed), e.total)
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+func (e *batchError) Unwrap() error {
+	return e.cause
+}
+
+// planDownloads resolves the destination of every attachment before any is downloaded, as
+// resolving a conflict may ask the user. A destination taken by an earlier attachment of
+// the run counts as an existing file, so that two downloads never write the same file.
+func planDownloads(batches []cmdcommon.IssueAttachments, params *downloadParams, resolver *conflictResolver) ([]downloadJob, error) {
+	claimed := make(map[string]int)
+	r := *resolver
+	r.exists = func(path string) bool {
+		_, ok := claimed[path]
+		return ok || resolver.exists(path)
+	}
+
+	var jobs []downloadJob
+	for _, b := range batches {
+		if len(b.Attachments) == 0 {
+			continue
+		}
+
+		issueParams := *params
+		issueParams.issueKey = b.Issue
+		if params.includeSubtasks {
+			issueParams.outputDir = filepath.Join(params.outputDir, b.Issue)
+			if err := makeDir(issueParams.outputDir, params.dirMode); err != nil {
+				return nil, err
+			}
+			cmdcommon.CleanTempFiles(issueParams.outputDir, params.tempMaxAge)
+		}
+
+		names := fitLocalNames(issueParams.outputDir, localNames(b.Attachments, params.idPrefix), b.Attachments)
+		for i, a := range b.Attachments {
+			dest, err := r.resolve(filepath.Join(issueParams.outputDir, names[i]), names[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			if dest == "" {
+				cmdutil.Warn("Skipped %q, file already exists", a.Filename)
+				params.tally.skip()
+				continue
+			}
+
+			job := downloadJob{attachment: a, dest: dest, params: &issueParams}
+			if k, ok := claimed[dest]; ok {
+				// Downloaded one after the other, the later attachment would overwrite the
+				// earlier one.
+				cmdutil.Warn("Skipped %q, %s is overwritten by a later attachment", jobs[k].attachment.Filename, dest)
+				params.tally.skip()
+				jobs[k] = job
+				continue
+			}
+			claimed[dest] = len(jobs)
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// downloadConcurrently downloads the jobs with --concurrency workers. A failed download
+// doesn't stop the others, the failures are reported as they complete and returned
+// together at the end. The run stops if the credentials are rejected, the size cap is
+// reached or the command is interrupted, the attachments not downloaded by then are
+// reported as such.
+func downloadConcurrently(client *jira.Client, jobs []downloadJob, params *downloadParams) error {
+	ctx, cancel := context.WithCancel(params.requestContext())
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		failed  = make([]error, len(jobs))
+		stopped = make([]bool, len(jobs))
+		cause   error
+		queue   = make(chan int)
+		wg      sync.WaitGroup
+	)
+
+	for w := 0; w < min(params.concurrency, len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Each worker writes to its own slots of failed and stopped, so only the cause
+			// requires locking.
+			for i := range queue {
+				job := jobs[i]
+				a := job.attachment
+
+				var (
+					outcome fetchOutcome
+					err     error
+				)
+				if params.s3 != nil {
+					jobs[i].object, err = uploadAttachment(ctx, client, a, job.key, job.params, params.budget)
+				} else {
+					outcome, err = fetchAttachment(ctx, client, a, job.dest, job.params, params.budget)
+				}
+				var (
+					capErr    *jira.ErrSizeCapExceeded
+					deniedErr *typeDeniedError
+				)
+				switch {
+				case err == nil:
+					reportDownloaded(a, job.dest, outcome, job.params)
+				case params.verifier != nil && isNotFound(err):
+					params.verifier.deleted(a)
+					cmdutil.Warn("%q was deleted from the server before it could be downloaded", a.Filename)
+				case ctx.Err() != nil && errors.Is(err, context.Canceled):
+					stopped[i] = true
+				case errors.As(err, &deniedErr):
+					// Reported with the others once the run completes.
+					cmdutil.Fail("Aborted %s", params.tally.fail(deniedErr))
+				default:
+					failed[i] = params.tally.fail(err)
+					cmdutil.Fail("Failed to download %q: %s", a.Filename, cmdutil.Diagnose(err).Message)
+					if jira.IsAuthFailure(err) || errors.As(err, &capErr) || s3Fatal(err) {
+						mu.Lock()
+						if cause == nil {
+							cause = err
+						}
+						mu.Unlock()
+						cancel()
+					}
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range jobs {
+		select {
+		case <-ctx.Done():
+			for j := i; j < len(jobs); j++ {
+				stopped[j] = true
+			}
+			break dispatch
+		case queue <- i:
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	for i, job := range jobs {
+		if stopped[i] {
+			cmdutil.Fail("Not downloaded: %q", job.attachment.Filename)
+		}
+	}
+	if err := params.requestContext().Err(); err != nil {
+		return err
+	}
+
+	batchErr := &batchError{total: len(jobs), cause: cause}
+	for i, err := range failed {
+		if err != nil {
+			batchErr.failed = append(batchErr.failed, fmt.Sprintf("%q", jobs[i].attachment.Filename))
+		}
+	}
+	if len(batchErr.failed) == 0 && cause == nil {
+		return nil
+	}
+	return batchErr
+}
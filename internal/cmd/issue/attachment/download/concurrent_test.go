@@ -0,0 +1,206 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+// inFlight serves the fake Jira and records the largest number of attachment contents
+// served at the same time. Contents of the failing attachment ids are rejected with status.
+type inFlight struct {
+	jira    *jiratest.Server
+	status  int
+	failing map[string]bool
+
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (f *inFlight) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isContentPath(r.URL.Path) {
+		f.jira.ServeHTTP(w, r)
+		return
+	}
+
+	f.mu.Lock()
+	f.current++
+	f.max = max(f.max, f.current)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.current--
+		f.mu.Unlock()
+	}()
+
+	if f.failing[contentID(r.URL.Path)] {
+		w.WriteHeader(f.status)
+		return
+	}
+	f.jira.ServeHTTP(w, r)
+}
+
+func (f *inFlight) peak() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.max
+}
+
+func isContentPath(path string) bool {
+	return strings.Contains(path, "/attachment/content/") || strings.HasPrefix(path, "/secure/attachment/")
+}
+
+// contentID returns the attachment id of a content path, eg: /secure/attachment/10001/a.txt.
+func contentID(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/secure/attachment/"); ok {
+		id, _, _ := strings.Cut(rest, "/")
+		return id
+	}
+	return path[strings.LastIndexByte(path, '/')+1:]
+}
+
+// slowJira starts a fake Jira whose responses take latency, with n attachments on TEST-1.
+func slowJira(t *testing.T, n int, latency time.Duration) (*inFlight, *jira.Client, []jira.Attachment) {
+	t.Helper()
+
+	fake := jiratest.New(jiratest.WithIssues("TEST-1"))
+	fake.SetFaults(jiratest.Faults{Latency: latency})
+
+	attachments := make([]jira.Attachment, 0, n)
+	for i := range n {
+		attachments = append(attachments, fake.AddAttachment("TEST-1", fmt.Sprintf("build-%02d.log", i), []byte(fmt.Sprintf("log %d", i))))
+	}
+
+	f := &inFlight{jira: fake, failing: map[string]bool{}}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+
+	return f, jira.NewClient(jira.Config{Server: ts.URL}, jira.WithTimeout(5*time.Second)), attachments
+}
+
+func TestDownloadConcurrency(t *testing.T) {
+	f, client, attachments := slowJira(t, 8, 100*time.Millisecond)
+
+	out := t.TempDir()
+	res := cmdtest.Run(t, cmdtest.Env{Client: client}, NewCmdAttachmentDownload(), "TEST-1", "--all", "--output", out, "--concurrency", "4")
+	require.NoError(t, res.Err)
+
+	for i, a := range attachments {
+		b, err := os.ReadFile(filepath.Join(out, a.Filename))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("log %d", i), string(b))
+		assert.Contains(t, res.Stdout, fmt.Sprintf("Downloaded %q to %s", a.Filename, filepath.Join(out, a.Filename)))
+	}
+	assert.Equal(t, len(attachments), strings.Count(res.Stdout, "Downloaded "), "one line per file")
+	assert.Greater(t, f.peak(), 1, "attachments are downloaded at the same time")
+	assert.LessOrEqual(t, f.peak(), 4)
+}
+
+func TestDownloadConcurrencyDefaultIsSerial(t *testing.T) {
+	f, client, _ := slowJira(t, 3, 50*time.Millisecond)
+
+	res := cmdtest.Run(t, cmdtest.Env{Client: client}, NewCmdAttachmentDownload(), "TEST-1", "--all", "--output", t.TempDir())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 1, f.peak())
+}
+
+func TestDownloadConcurrencyCollectsFailures(t *testing.T) {
+	f, client, attachments := slowJira(t, 6, 50*time.Millisecond)
+	f.status = http.StatusInternalServerError
+	f.failing[attachments[1].ID] = true
+	f.failing[attachments[4].ID] = true
+
+	out := t.TempDir()
+	res := cmdtest.Run(t, cmdtest.Env{Client: client}, NewCmdAttachmentDownload(), "TEST-1", "--all", "--output", out, "--concurrency", "3")
+	require.Error(t, res.Err)
+	assert.EqualError(t, res.Err, `Failed to download 2 of 6 attachment(s): "build-01.log", "build-04.log"`)
+
+	for i, a := range attachments {
+		if i == 1 || i == 4 {
+			assert.NoFileExists(t, filepath.Join(out, a.Filename))
+			assert.Contains(t, res.Stderr, fmt.Sprintf("Failed to download %q", a.Filename))
+			continue
+		}
+		assert.FileExists(t, filepath.Join(out, a.Filename), "a failure doesn't stop the others")
+	}
+	assert.NotContains(t, res.Stderr, "Not downloaded")
+}
+
+func TestDownloadConcurrencyStopsOnAuthFailure(t *testing.T) {
+	f, client, attachments := slowJira(t, 6, 50*time.Millisecond)
+	f.status = http.StatusUnauthorized
+	f.failing[attachments[0].ID] = true
+
+	out := t.TempDir()
+	res := cmdtest.Run(t, cmdtest.Env{Client: client}, NewCmdAttachmentDownload(), "TEST-1", "--all", "--output", out, "--concurrency", "2")
+	require.Error(t, res.Err)
+	assert.NotContains(t, res.Err.Error(), "Failed to download", "the rejected credentials are reported")
+	assert.Contains(t, res.Stderr, fmt.Sprintf("Not downloaded: %q", attachments[len(attachments)-1].Filename))
+}
+
+func TestDownloadConcurrencyRenamesSameNames(t *testing.T) {
+	fake := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(fake.Close)
+	fake.AddAttachment("TEST-1", "report.pdf", []byte("first"))
+	fake.AddAttachment("TEST-1", "report.pdf", []byte("second"))
+
+	out := t.TempDir()
+	res := cmdtest.Run(t, cmdtest.Env{Client: fake.Client()}, NewCmdAttachmentDownload(),
+		"TEST-1", "--all", "--output", out, "--concurrency", "2", "--on-conflict", "rename")
+	require.NoError(t, res.Err)
+
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{"report.pdf", "report (1).pdf"}, names, "both downloads get a file of their own")
+}
+
+func TestDownloadTallyConcurrentFailures(t *testing.T) {
+	t.Parallel()
+
+	tally := &downloadTally{}
+	tally.downloaded(10)
+	tally.skip()
+	_ = tally.fail(context.DeadlineExceeded)
+	_ = tally.fail(context.DeadlineExceeded)
+	// The error of the run isn't counted again.
+	_ = tally.fail(&batchError{failed: []string{`"a"`, `"b"`}, total: 6})
+
+	s := tally.summary("TEST-1", 6)
+	assert.Equal(t, 1, s.Done)
+	assert.Equal(t, 4, s.Failed)
+	assert.Equal(t, map[string]int{"timeout": 2, "not_attempted": 2}, s.Failures)
+}
+
+func TestDownloadConcurrencyFlag(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	env := cmdtest.Env{Client: server.Client()}
+	for _, n := range []string{"0", "9"} {
+		res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--concurrency", n)
+		assert.EqualError(t, res.Err, "--concurrency must be between 1 and 8")
+	}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--tar", "-", "--concurrency", "2")
+	assert.EqualError(t, res.Err, "--tar writes the attachments one after the other, it can't be combined with --concurrency")
+	assert.Empty(t, server.Requests())
+}
@@ -0,0 +1,195 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+)
+
+// conflictPolicy decides what happens if a file to download already exists.
+type conflictPolicy string
+
+const (
+	conflictFail      conflictPolicy = "fail"
+	conflictSkip      conflictPolicy = "skip"
+	conflictOverwrite conflictPolicy = "overwrite"
+	conflictRename    conflictPolicy = "rename"
+	conflictAsk       conflictPolicy = "ask"
+	// conflictUpdate overwrites a file only if the attachment changed on the server since it
+	// was downloaded, as per the ETag and Last-Modified recorded with its provenance.
+	conflictUpdate conflictPolicy = "update"
+)
+
+// conflictAction is the choice made when asked about a conflict.
+type conflictAction string
+
+const (
+	actionOverwrite conflictAction = "Overwrite"
+	actionSkip      conflictAction = "Skip"
+	actionRename    conflictAction = "Rename"
+	actionAbort     conflictAction = "Abort"
+)
+
+// errDownloadAborted is returned if the user aborts the download on a conflict.
+var errDownloadAborted = errors.New("download aborted")
+
+// parseConflictPolicy parses the --on-conflict flag. Asking is the default if the session is
+// interactive. Non-interactive sessions never ask and fail on conflicts instead.
+func parseConflictPolicy(s string, interactive bool) (conflictPolicy, error) {
+	switch p := conflictPolicy(strings.ToLower(s)); p {
+	case "":
+		if interactive {
+			return conflictAsk, nil
+		}
+		return conflictFail, nil
+	case conflictAsk:
+		if interactive {
+			return conflictAsk, nil
+		}
+		return conflictFail, nil
+	case conflictFail, conflictSkip, conflictOverwrite, conflictRename, conflictUpdate:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid --on-conflict value %q, must be one of: ask, fail, skip, overwrite, rename, update", s)
+}
+
+// conflictAsker asks the user how to resolve a conflict.
+type conflictAsker interface {
+	// Action asks what to do with the existing file at path.
+	Action(path string) (conflictAction, error)
+	// Rename asks for a new filename, suggestion is used as the default.
+	Rename(suggestion string) (string, error)
+}
+
+type surveyAsker struct{}
+
+func (surveyAsker) Action(path string) (conflictAction, error) {
+	var action string
+	err := survey.AskOne(&survey.Select{
+		Message: fmt.Sprintf("File %q already exists", path),
+		Options: []string{
+			string(actionOverwrite),
+			string(actionSkip),
+			string(actionRename),
+			string(actionAbort),
+		},
+	}, &action)
+	return conflictAction(action), err
+}
+
+func (surveyAsker) Rename(suggestion string) (string, error) {
+	var name string
+	err := survey.AskOne(&survey.Input{
+		Message: "New filename",
+		Default: suggestion,
+	}, &name)
+	return strings.TrimSpace(name), err
+}
+
+// conflictResolver resolves conflicts with existing files as per the policy.
+type conflictResolver struct {
+	policy conflictPolicy
+	asker  conflictAsker
+	exists func(path string) bool
+	// warn is called if a name entered on rename is rejected.
+	warn func(format string, a ...any)
+}
+
+func newConflictResolver(policy conflictPolicy) *conflictResolver {
+	return &conflictResolver{
+		policy: policy,
+		asker:  surveyAsker{},
+		exists: fileExists,
+		warn:   func(string, ...any) {},
+	}
+}
+
+// resolve returns the path to download to, or an empty path if the download should be skipped.
+// The pending names are the filenames still to be downloaded in the batch, a new name must not
+// collide with them either.
+func (r *conflictResolver) resolve(dest string, pending []string) (string, error) {
+	if !r.exists(dest) {
+		return dest, nil
+	}
+
+	switch r.policy {
+	case conflictSkip:
+		return "", nil
+	case conflictOverwrite, conflictUpdate:
+		return dest, nil
+	case conflictRename:
+		return r.suggest(dest, pending), nil
+	case conflictAsk:
+		return r.ask(dest, pending)
+	}
+	return "", fmt.Errorf("file %q already exists, please remove it, use a different output directory or set --on-conflict", dest)
+}
+
+func (r *conflictResolver) ask(dest string, pending []string) (string, error) {
+	action, err := r.asker.Action(dest)
+	if err != nil {
+		return "", err
+	}
+
+	switch action {
+	case actionOverwrite:
+		return dest, nil
+	case actionSkip:
+		return "", nil
+	case actionRename:
+		dir := filepath.Dir(dest)
+		suggestion := filepath.Base(r.suggest(dest, pending))
+		for {
+			name, err := r.asker.Rename(suggestion)
+			if err != nil {
+				return "", err
+			}
+			if err := r.validate(dir, name, pending); err != nil {
+				r.warn("%s", err)
+				continue
+			}
+			return filepath.Join(dir, name), nil
+		}
+	}
+	return "", errDownloadAborted
+}
+
+// validate checks that a new name is a plain filename that collides neither
+// with an existing file nor with a file still to be downloaded.
+func (r *conflictResolver) validate(dir, name string, pending []string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0) {
+		return fmt.Errorf("%q is not a valid filename", name)
+	}
+	for _, p := range pending {
+		if p == name {
+			return fmt.Errorf("%q is also being downloaded, choose another name", name)
+		}
+	}
+	if r.exists(filepath.Join(dir, name)) {
+		return fmt.Errorf("file %q already exists, choose another name", name)
+	}
+	return nil
+}
+
+// suggest returns the first free "name (N).ext" variant of dest.
+func (r *conflictResolver) suggest(dest string, pending []string) string {
+	dir, base := filepath.Split(dest)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s (%d)%s", stem, i, ext)
+		if r.validate(dir, name, pending) == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,198 @@
+package download
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+// scriptedAsker answers prompts from a script and records the paths it was asked about.
+type scriptedAsker struct {
+	actions []conflictAction
+	names   []string
+	asked   []string
+	renames []string
+}
+
+func (s *scriptedAsker) Action(path string) (conflictAction, error) {
+	s.asked = append(s.asked, filepath.Base(path))
+	if len(s.actions) == 0 {
+		return "", errors.New("unexpected prompt")
+	}
+	a := s.actions[0]
+	s.actions = s.actions[1:]
+	return a, nil
+}
+
+func (s *scriptedAsker) Rename(suggestion string) (string, error) {
+	s.renames = append(s.renames, suggestion)
+	if len(s.names) == 0 {
+		return "", errors.New("unexpected prompt")
+	}
+	n := s.names[0]
+	s.names = s.names[1:]
+	return n, nil
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in          string
+		interactive bool
+		want        conflictPolicy
+		wantErr     bool
+	}{
+		{in: "", interactive: true, want: conflictAsk},
+		{in: "", interactive: false, want: conflictFail},
+		{in: "ask", interactive: true, want: conflictAsk},
+		{in: "ask", interactive: false, want: conflictFail},
+		{in: "Skip", want: conflictSkip},
+		{in: "overwrite", want: conflictOverwrite},
+		{in: "rename", want: conflictRename},
+		{in: "Update", want: conflictUpdate},
+		{in: "fail", interactive: true, want: conflictFail},
+		{in: "merge", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := parseConflictPolicy(tc.in, tc.interactive)
+		if tc.wantErr {
+			assert.Error(t, err, tc.in)
+			continue
+		}
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+	}
+}
+
+func TestConflictResolverPolicies(t *testing.T) {
+	t.Parallel()
+
+	existing := map[string]bool{"a.txt": true, "a (1).txt": true}
+	exists := func(p string) bool { return existing[filepath.Base(p)] }
+
+	resolve := func(policy conflictPolicy, dest string, pending ...string) (string, error) {
+		r := &conflictResolver{policy: policy, exists: exists, warn: func(string, ...any) {}}
+		return r.resolve(dest, pending)
+	}
+
+	got, err := resolve(conflictFail, "new.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "new.txt", got)
+
+	_, err = resolve(conflictFail, "a.txt")
+	assert.Error(t, err)
+
+	got, err = resolve(conflictSkip, "a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	got, err = resolve(conflictOverwrite, "a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "a.txt", got)
+
+	// The suggestion skips existing files and files still to be downloaded.
+	got, err = resolve(conflictRename, filepath.Join("out", "a.txt"), "a (2).txt")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("out", "a (3).txt"), got)
+}
+
+func TestConflictResolverAsk(t *testing.T) {
+	t.Parallel()
+
+	existing := map[string]bool{"a.txt": true, "taken.txt": true}
+	exists := func(p string) bool { return existing[filepath.Base(p)] }
+
+	t.Run("overwrite and skip", func(t *testing.T) {
+		t.Parallel()
+
+		asker := &scriptedAsker{actions: []conflictAction{actionOverwrite, actionSkip}}
+		r := &conflictResolver{policy: conflictAsk, asker: asker, exists: exists, warn: func(string, ...any) {}}
+
+		got, err := r.resolve("a.txt", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "a.txt", got)
+
+		got, err = r.resolve("a.txt", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "", got)
+	})
+
+	t.Run("rename reprompts on collisions", func(t *testing.T) {
+		t.Parallel()
+
+		var warnings []string
+		asker := &scriptedAsker{
+			actions: []conflictAction{actionRename},
+			names:   []string{"taken.txt", "b.txt", "../c.txt", "c.txt"},
+		}
+		r := &conflictResolver{policy: conflictAsk, asker: asker, exists: exists, warn: func(format string, a ...any) {
+			warnings = append(warnings, format)
+		}}
+
+		got, err := r.resolve(filepath.Join("out", "a.txt"), []string{"b.txt"})
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join("out", "c.txt"), got)
+		assert.Equal(t, []string{"a (1).txt", "a (1).txt", "a (1).txt", "a (1).txt"}, asker.renames)
+		assert.Len(t, warnings, 3)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		t.Parallel()
+
+		asker := &scriptedAsker{actions: []conflictAction{actionAbort}}
+		r := &conflictResolver{policy: conflictAsk, asker: asker, exists: exists}
+
+		_, err := r.resolve("a.txt", nil)
+		assert.ErrorIs(t, err, errDownloadAborted)
+	})
+}
+
+func TestDownloadAttachmentsAbortMidBatch(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("old b"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c.txt"), []byte("old c"), 0o600))
+
+	attachments := make([]jira.Attachment, 0, 4)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		attachments = append(attachments, server.AddAttachment("TEST-1", name, []byte("new "+name[:1])))
+	}
+
+	asker := &scriptedAsker{
+		actions: []conflictAction{actionRename, actionAbort},
+		// c.txt and d.txt are taken, on disk and in the rest of the batch respectively.
+		names: []string{"c.txt", "d.txt", "b-new.txt"},
+	}
+	resolver := &conflictResolver{policy: conflictAsk, asker: asker, exists: fileExists, warn: func(string, ...any) {}}
+
+	err := downloadAttachments(server.Client(), attachments, &downloadParams{outputDir: dir}, resolver)
+	assert.ErrorIs(t, err, errDownloadAborted)
+	assert.Equal(t, []string{"b.txt", "c.txt"}, asker.asked)
+
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+
+	// Files completed before the abort are kept, nothing after it is touched.
+	assert.Equal(t, "new a", read("a.txt"))
+	assert.Equal(t, "old b", read("b.txt"))
+	assert.Equal(t, "new b", read("b-new.txt"))
+	assert.Equal(t, "old c", read("c.txt"))
+	assert.Equal(t, "", read("d.txt"))
+}
@@ -1,17 +1,32 @@
 package download
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/selector"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
 	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/notify"
 	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/internal/where"
+	"github.com/ankitpokhrel/jira-cli/pkg/dirlock"
+	"github.com/ankitpokhrel/jira-cli/pkg/eol"
+	"github.com/ankitpokhrel/jira-cli/pkg/filehash"
 	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
 )
 
 const (
@@ -21,17 +36,82 @@ const (
 # Download specific file
 $ jira issue attachment download ISSUE-1 document.pdf
 
+# Download all attachments you uploaded
+$ jira issue attachment download ISSUE-1 --mine
+
 # Download by attachment ID
 $ jira issue attachment download ISSUE-1 --id 12345
 
+# Download the latest image
+$ jira issue attachment download ISSUE-1 --mime image --latest 1
+
 # Download to specific directory
-$ jira issue attachment download ISSUE-1 --all --output /path/to/dir`
+$ jira issue attachment download ISSUE-1 --all --output /path/to/dir
+
+# Wait up to a minute if another download into the same directory is running
+$ jira issue attachment download ISSUE-1 --all --output /path/to/dir --wait-lock 1m
+
+# Convert line endings of text attachments to the ones of the current platform
+$ jira issue attachment download ISSUE-1 app.log --eol native
+
+# Keep existing files and download the new ones as "name (1).ext"
+$ jira issue attachment download ISSUE-1 --all --on-conflict rename
+
+# Download attachments of the issue and its subtasks into a directory per issue
+$ jira issue attachment download ISSUE-1 --all --include-subtasks --output /path/to/dir
+
+# Download 4 attachments at a time
+$ jira issue attachment download ISSUE-1 --all --concurrency 4
+
+# Download a large attachment as 8 concurrent byte ranges
+$ jira issue attachment download ISSUE-1 backup.tar.gz --parallel-ranges 8
+
+# Refuse to download more than 2 GB in total
+$ jira issue attachment download ISSUE-1 --all --max-total-size 2GB
+
+# Make the downloaded files readable by the group
+$ jira issue attachment download ISSUE-1 --all --output shared --mode 0640 --dir-mode 0750
+
+# Check that no attachment was replaced or deleted on the server during the download
+$ jira issue attachment download ISSUE-1 --all --verify-after --strict
+
+# Record the issue each file came from in a sidecar file, eg: report.pdf.jira.json
+$ jira issue attachment download ISSUE-1 --all --output shared --provenance sidecar
+
+# Save each file as <attachment id>-<filename>, eg: 12345-screenshot.png
+$ jira issue attachment download ISSUE-1 --all --use-id-prefix
+
+# Write a single attachment to stdout to pipe it into another tool
+$ jira issue attachment download ISSUE-1 app.log --stdout | grep ERROR
+
+# Stream all attachments as a gzip'd tar archive to stdout
+$ jira issue attachment download ISSUE-1 --all --tar - --tar-gz | tar -tzv
+
+# Stream all attachments into s3://archive/jira/ISSUE-1/ without writing them to disk
+$ jira issue attachment download ISSUE-1 --all --s3-url s3://archive/jira/
+
+# Download the attachments of every issue matching a JQL into a directory per issue
+$ jira issue attachment download --jql 'project = TEST AND updated >= -7d' --all --output archive
+
+# Continue an interrupted JQL download without downloading the completed issues again
+$ jira issue attachment download --jql 'project = TEST AND updated >= -7d' --all --output archive --resume
+
+# Retry a download over a flaky link if no bytes are received for 10 seconds
+$ jira issue attachment download ISSUE-1 backup.tar.gz --stall-timeout 10s`
 )
 
+// nameReserve is the room left in the name of a downloaded file for the suffixes added
+// to it, eg: by its temporary file, its sidecar file or --on-conflict rename.
+const nameReserve = 48
+
+// maxParallelRanges caps --parallel-ranges so that a typo doesn't open thousands of connections.
+const maxParallelRanges = 32
+
 // NewCmdAttachmentDownload is an attachment download command.
-func NewCmdAttachmentDownload() *cobra.Command {
+func NewCmdAttachmentDownload(opts ...cmdcommon.Options) *cobra.Command {
+	o := cmdcommon.ResolveOptions(opts)
 	cmd := cobra.Command{
-		Use:     "download ISSUE-KEY [FILENAME]",
+		Use:     "download {ISSUE-KEY [FILENAME] | --jql JQL}",
 		Short:   "Download attachments from an issue",
 		Long:    helpText,
 		Example: examples,
@@ -40,81 +120,778 @@ func NewCmdAttachmentDownload() *cobra.Command {
 			"help:args": "ISSUE-KEY\tIssue key, eg: ISSUE-1\n" +
 				"FILENAME\tOptional filename to download",
 		},
-		Run: download,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return download(cmd, args, o)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
 	cmd.Flags().Bool("all", false, "Download all attachments")
-	cmd.Flags().String("id", "", "Download attachment by ID")
 	cmd.Flags().StringP("output", "o", ".", "Output directory")
+	selector.SetFlags(&cmd)
+	cmd.Flags().String("wait-lock", "0s", "Wait for a concurrent download into the output directory to finish, eg: 30s")
+	cmd.Flags().String("eol", "", "Convert line endings of text attachments: native, lf or crlf")
+	cmd.Flags().String("max-total-size", "", "Don't download more than the given total size, eg: 500MB, 2GB")
+	cmd.Flags().Uint("concurrency", 1, fmt.Sprintf("Number of attachments downloaded at the same time, at most %d", maxConcurrency))
+	cmd.Flags().Uint("parallel-ranges", 0, "Download each attachment as the given number of concurrent byte ranges if the server supports it")
+	cmd.Flags().Bool("strict", false, "Exit with a non-zero status if an attachment is unavailable, eg: of an archived issue")
+	cmd.Flags().Bool("include-subtasks", false, "Include attachments of the subtasks, each issue is downloaded into its own directory")
+	cmd.Flags().String("on-conflict", "", "What to do if a file already exists: ask, fail, skip, overwrite, rename or update (default ask if interactive, else fail)")
+	cmd.Flags().String("mode", fmt.Sprintf("%#o", jira.DefaultAttachmentFileMode), "Octal permission of the downloaded files, set when they are created")
+	cmd.Flags().String("dir-mode", fmt.Sprintf("%#o", jira.DefaultAttachmentDirMode), "Octal permission of the directories created for the downloads")
+	cmd.Flags().Bool("verify-after", false, "Fetch the metadata of each attachment again once downloaded to detect attachments changed or deleted on the server meanwhile")
+	cmd.Flags().String("provenance", "", "Record the issue, attachment id and server of each file: xattr (extended attributes, falls back to sidecar) or sidecar (a <filename>.jira.json file)")
+	cmd.Flags().Uint("hash-workers", 0, fmt.Sprintf("Number of files hashed at the same time for the checksums of the sidecar files, 0 for one per CPU up to %d", filehash.MaxDefaultWorkers))
+	cmd.Flags().Bool("stdout", false, "Write the content of a single attachment to stdout, eg: to pipe it into another tool")
+	cmd.Flags().String("tar", "", "Write the attachments into a tar archive at the path, or to stdout with -, as they download")
+	cmd.Flags().Bool("tar-gz", false, "Compress the archive written with --tar with gzip")
+	cmd.Flags().String("stall-timeout", jira.DefaultStallTimeout.String(), "Retry a download if no bytes are received for the given duration, resuming it if the server supports it, 0 to disable")
+	cmd.Flags().StringArray("unsafe-allow", nil, "Allow attachments denied by the download type policy if they match the pattern, eg: .sh or application/x-*, logged to the audit log")
+	cmd.Flags().String("s3-url", "", "Stream the attachments into objects of an S3 bucket instead of files, eg: s3://bucket/prefix/")
+	cmd.Flags().String("s3-endpoint", "", "URL of an S3-compatible storage for --s3-url, eg: http://localhost:9000 for MinIO")
+	cmd.Flags().Bool("plain", false, "Show a spinner instead of a progress bar while downloading, eg: when the output is logged")
+	cmd.Flags().Bool("use-id-prefix", false, "Prefix the name of every downloaded file with the attachment ID, eg: 12345-report.pdf")
+	cmd.Flags().String("jql", "", "Download the attachments of every issue matching the JQL instead of one issue, into a directory per issue")
+	cmd.Flags().Bool("resume", false, "Continue an interrupted --jql download into the output directory, skipping the issues already done")
+	cmd.Flags().Uint("flush-every", 1, "Write the progress of a --jql download after every given number of issues, a crash loses at most as many")
+	cmdcommon.SetNotifyFlag(&cmd)
+
+	o.Apply(&cmd)
 
 	return &cmd
 }
 
-func download(cmd *cobra.Command, args []string) {
-	params := parseArgsAndFlags(args, cmd.Flags())
-	client := api.DefaultClient(params.debug)
+func download(cmd *cobra.Command, args []string, o cmdcommon.Options) error {
+	params, err := parseArgsAndFlags(args, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	if params.apiVersion, err = cmdcommon.GetAPIVersion(cmd); err != nil {
+		return err
+	}
+	notifier, err := cmdcommon.NewNotifier(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := o.Client(params.debug)
+	if err != nil {
+		return err
+	}
+	if params.verifyAfter {
+		params.verifier = newVerifier(client, params.apiVersion)
+	}
+	params.provenance = newProvenanceWriter(params.provenanceMode, viper.GetString("server"), params.hashWorkers)
+	defer params.provenance.finish()
+	if params.policy, err = newTypePolicy(params.unsafeAllow); err != nil {
+		return err
+	}
+	if params.s3URL != "" {
+		if params.s3, err = newS3Target(params.s3URL, params.s3Endpoint); err != nil {
+			return err
+		}
+	}
 
+	if params.jql != "" {
+		return downloadJQL(client, params, notifier)
+	}
 	if params.issueKey == "" {
-		cmdutil.Failed("ISSUE-KEY is required")
+		return cmdutil.Errorf("ISSUE-KEY is required")
 	}
 
-	issue, err := api.ProxyGetIssue(client, params.issueKey)
-	cmdutil.ExitIfError(err)
+	var batches []cmdcommon.IssueAttachments
+	if params.includeSubtasks {
+		if params.selector.ID != "" || params.selector.Filename != "" || (!params.all && !params.selector.Filtered()) {
+			return cmdutil.Errorf("--include-subtasks can only be used with --all or the attachment filters")
+		}
+		issues, err := cmdcommon.FetchAttachmentsWithSubtasks(client, params.apiVersion, params.issueKey, params.debug)
+		if err != nil {
+			return err
+		}
+		if batches, params.report, err = params.selector.SelectIssues(client, issues); err != nil {
+			return err
+		}
+	} else {
+		attachments, err := selectAttachments(client, params)
+		if err != nil {
+			return err
+		}
+		batches = []cmdcommon.IssueAttachments{{Issue: params.issueKey, Attachments: attachments}}
+	}
 
-	if len(issue.Fields.Attachments) == 0 {
-		cmdutil.Failed("No attachments found for issue %q", params.issueKey)
+	batches, unavailable := excludeUnavailable(batches, func(format string, a ...any) {
+		cmdutil.Warn(format, a...)
+	})
+	batches, denied := params.policy.exclude(batches)
+
+	var attachmentsToDownload []jira.Attachment
+	for _, b := range batches {
+		attachmentsToDownload = append(attachmentsToDownload, b.Attachments...)
+	}
+	if len(attachmentsToDownload) == 0 {
+		if denied > 0 {
+			return params.policy.deniedError()
+		}
+		if unavailable > 0 {
+			return cmdutil.Errorf("No downloadable attachments found for issue %q, %d attachment(s) unavailable", params.issueKey, unavailable)
+		}
+		if why := params.report.Explain(); why != "" {
+			return cmdutil.Errorf("No attachments matching the filters found for issue %q, %s", params.issueKey, why)
+		}
+		return cmdutil.Errorf("No attachments matching the filters found for issue %q", params.issueKey)
+	}
+
+	if params.maxTotal > 0 {
+		if err := checkTotalSize(attachmentsToDownload, params.maxTotal); err != nil {
+			return err
+		}
+	}
+
+	// The tally is shared by the per-issue copies of the params.
+	params.tally = &downloadTally{}
+	defer func() {
+		notifier.Done(params.tally.summary(params.issueKey, len(attachmentsToDownload)))
+	}()
+
+	ctx, stop := interruptContext()
+	defer stop()
+	params.ctx = ctx
+
+	if params.stdout {
+		if len(attachmentsToDownload) > 1 {
+			return cmdutil.Errorf("--stdout writes a single attachment but %d match, narrow the selection with --id, --name or --index",
+				len(attachmentsToDownload))
+		}
+		if err := downloadStdout(client, attachmentsToDownload[0], params, cmd.OutOrStdout()); err != nil {
+			return downloadError(params.tally.fail(err), params.debug)
+		}
+		return unavailableError(unavailable, params.strict)
+	}
+
+	if params.tar != "" {
+		if err := downloadTar(client, batches, params, cmd.OutOrStdout()); err != nil {
+			return downloadError(params.tally.fail(err), params.debug)
+		}
+		if err := params.policy.deniedError(); err != nil {
+			return err
+		}
+		return unavailableError(unavailable, params.strict)
+	}
+
+	if params.s3 != nil {
+		if params.maxTotal > 0 {
+			params.budget = jira.NewByteBudget(params.maxTotal)
+		}
+		jobs := planUploads(batches, params)
+		err := downloadConcurrently(client, jobs, params)
+		if mErr := writeS3Manifest(jobs, params, viper.GetString("server")); err == nil {
+			err = mErr
+		}
+		if err != nil {
+			return downloadError(params.tally.fail(err), params.debug)
+		}
+		if err := params.policy.deniedError(); err != nil {
+			return err
+		}
+		return unavailableError(unavailable, params.strict)
 	}
 
 	// Create output directory if it doesn't exist
 	if params.outputDir != "." {
-		err := os.MkdirAll(params.outputDir, 0o755)
-		cmdutil.ExitIfError(err)
+		if err := makeDir(params.outputDir, params.dirMode); err != nil {
+			return err
+		}
 	}
+	cmdcommon.CleanTempFiles(params.outputDir, params.tempMaxAge)
 
-	// Determine which attachments to download
-	var attachmentsToDownload []jira.Attachment
+	resolver := newConflictResolver(params.conflict)
+	resolver.warn = func(format string, a ...any) { cmdutil.Warn(format, a...) }
+
+	// Lock the output directory so that concurrent bulk downloads don't race on the same files.
+	if len(attachmentsToDownload) > 1 {
+		if err := cmdcommon.CheckTokenExpiry(client); err != nil {
+			return err
+		}
+
+		lock, err := dirlock.Acquire(params.outputDir, dirlock.Options{
+			Wait:   params.waitLock,
+			Notify: func(msg string) { cmdutil.Warn(msg) },
+		})
+		if err != nil {
+			return err
+		}
+
+		err = downloadBatches(client, batches, params, resolver)
+		_ = lock.Release()
+		if err != nil {
+			return downloadError(params.tally.fail(err), params.debug)
+		}
+		return finishDownload(params, unavailable)
+	}
+
+	if err := downloadBatches(client, batches, params, resolver); err != nil {
+		return downloadError(params.tally.fail(err), params.debug)
+	}
+	return finishDownload(params, unavailable)
+}
+
+// downloadTally counts the attachments of a run for its completion notification.
+// It is safe for concurrent use.
+type downloadTally struct {
+	mu      sync.Mutex
+	done    int
+	skipped int
+	bytes   int64
+	// failures are the failed attachments by reason.
+	failures map[string]int
+}
+
+// fail counts the attachment that failed with err and returns err. The failures of a
+// concurrent run are counted as they happen, its error isn't counted again.
+func (t *downloadTally) fail(err error) error {
+	var batchErr *batchError
+	if t == nil || errors.As(err, &batchErr) {
+		return err
+	}
+	var (
+		capErr *jira.ErrSizeCapExceeded
+		reason string
+	)
 	switch {
-	case params.all:
-		attachmentsToDownload = issue.Fields.Attachments
-	case params.id != "":
-		attachmentsToDownload = findAttachmentByID(issue.Fields.Attachments, params.id)
-	case params.filename != "":
-		attachmentsToDownload = findAttachmentByFilename(issue.Fields.Attachments, params.filename)
+	case errors.Is(err, errDownloadAborted):
+		reason = "aborted"
+	case errors.Is(err, context.Canceled):
+		reason = "interrupted"
+	case errors.As(err, &capErr):
+		reason = "size_cap"
+	case errors.As(err, new(*typeDeniedError)):
+		reason = "denied"
 	default:
-		cmdutil.Failed("Please specify --all, --id, or provide a filename")
+		reason = cmdcommon.FailureReason(err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failures == nil {
+		t.failures = make(map[string]int)
 	}
+	t.failures[reason]++
+	return err
+}
 
-	// Download attachments
-	for _, a := range attachmentsToDownload {
-		destPath := filepath.Join(params.outputDir, a.Filename)
+func (t *downloadTally) downloaded(bytes int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done++
+	t.bytes += bytes
+}
 
-		// Check if file already exists
-		if _, err := os.Stat(destPath); err == nil {
-			cmdutil.Failed("File %q already exists. Please remove it or use a different output directory", destPath)
+func (t *downloadTally) skip() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.skipped++
+}
+
+// summary is the outcome of a run of total attachments. Attachments skipped because they
+// already exist are not failures. The failed attachments that aren't counted with a reason
+// were not attempted once the run stopped.
+func (t *downloadTally) summary(issueKey string, total int) notify.Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := notify.Summary{
+		Op:     notify.OpDownload,
+		Target: issueKey,
+		Total:  total,
+		Done:   t.done,
+		Failed: total - t.done - t.skipped,
+		Bytes:  t.bytes,
+	}
+	if s.Failed > 0 && len(t.failures) > 0 {
+		s.Failures = make(map[string]int, len(t.failures)+1)
+		counted := 0
+		for reason, n := range t.failures {
+			s.Failures[reason] = n
+			counted += n
 		}
+		if s.Failed > counted {
+			s.Failures[cmdcommon.FailureNotAttempted] = s.Failed - counted
+		}
+	}
+	return s
+}
+
+// finishDownload reports the verifications and fails if attachments were denied by the
+// type policy, or with --strict if attachments were unavailable, or changed or deleted on
+// the server during the download.
+func finishDownload(params *downloadParams, unavailable int) error {
+	if err := params.policy.deniedError(); err != nil {
+		_ = params.verifier.finish(false)
+		return err
+	}
+	verifyErr := params.verifier.finish(params.strict)
+	if err := unavailableError(unavailable, params.strict); err != nil {
+		return err
+	}
+	return verifyErr
+}
+
+// selectAttachments fetches the issue and returns the attachments selected by the flags.
+func selectAttachments(client *jira.Client, params *downloadParams) ([]jira.Attachment, error) {
+	issue, err := cmdcommon.GetAttachmentIssue(client, params.apiVersion, params.issueKey, cmdcommon.AttachmentIssueFields)
+	if err != nil {
+		return nil, cmdutil.RequestError(err, params.debug)
+	}
+
+	if len(issue.Fields.Attachments) == 0 {
+		return nil, cmdutil.Errorf("No attachments found for issue %q", params.issueKey)
+	}
 
-		err := func() error {
-			s := cmdutil.Info(fmt.Sprintf("Downloading %s", a.Filename))
-			defer s.Stop()
+	if err := params.selector.Resolve(client); err != nil {
+		return nil, err
+	}
+	attachments, err := pickAttachments(issue.Fields.Attachments, params)
+	if err != nil {
+		return nil, cmdutil.Errorf("Unable to download: %s", err)
+	}
+	return attachments, nil
+}
 
-			return client.DownloadAttachment(a.Content, destPath)
+// pickAttachments returns the attachments selected by the flags. An attachment selected
+// by ID or filename is rejected if it is unavailable.
+func pickAttachments(attachments []jira.Attachment, params *downloadParams) ([]jira.Attachment, error) {
+	sel := params.selector
+	if !params.all && !sel.Active() {
+		return nil, errors.New("please specify --all, --id, or provide a filename")
+	}
+
+	picked, report := sel.Apply(attachments)
+	params.report = report
+	if st, ok := report.Eliminator(); ok {
+		switch st.Criterion {
+		case selector.CriterionID:
+			return nil, fmt.Errorf("attachment with ID %q not found", sel.ID)
+		case selector.CriterionFilename:
+			return nil, fmt.Errorf("attachment with filename %q not found", sel.Filename)
+		}
+	}
+	if err := sel.CheckIndex(report); err != nil {
+		return nil, err
+	}
+	if sel.ID != "" || sel.Filename != "" {
+		for _, a := range picked {
+			if err := requireAvailable(a); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return picked, nil
+}
+
+// downloadBatches downloads the attachments of each issue. With subtasks included,
+// the attachments of each issue go to a directory named after the issue key.
+// The size cap applies to all issues together.
+func downloadBatches(client *jira.Client, batches []cmdcommon.IssueAttachments, params *downloadParams, resolver *conflictResolver) error {
+	if params.maxTotal > 0 && params.budget == nil {
+		params.budget = jira.NewByteBudget(params.maxTotal)
+	}
+	if params.concurrency > 1 {
+		jobs, err := planDownloads(batches, params, resolver)
+		if err != nil {
+			return err
+		}
+		return downloadConcurrently(client, jobs, params)
+	}
+
+	for _, b := range batches {
+		if len(b.Attachments) == 0 {
+			continue
+		}
+
+		issueParams := *params
+		issueParams.issueKey = b.Issue
+		if params.includeSubtasks {
+			issueParams.outputDir = filepath.Join(params.outputDir, b.Issue)
+			if err := makeDir(issueParams.outputDir, params.dirMode); err != nil {
+				return err
+			}
+			cmdcommon.CleanTempFiles(issueParams.outputDir, params.tempMaxAge)
+		}
+		if err := downloadAttachments(client, b.Attachments, &issueParams, resolver); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unavailableError fails with --strict if attachments were left out as unavailable.
+func unavailableError(unavailable int, strict bool) error {
+	if strict && unavailable > 0 {
+		return cmdutil.Errorf("%d attachment(s) unavailable", unavailable)
+	}
+	return nil
+}
+
+func downloadError(err error, debug bool) error {
+	if errors.Is(err, errDownloadAborted) {
+		return cmdutil.Errorf("Download aborted")
+	}
+	if errors.Is(err, context.Canceled) {
+		return cmdutil.Errorf("Download interrupted")
+	}
+	var capErr *jira.ErrSizeCapExceeded
+	if errors.As(err, &capErr) {
+		return cmdutil.Errorf("Download stopped, %s", capErr)
+	}
+	var deniedErr *typeDeniedError
+	if errors.As(err, &deniedErr) {
+		return cmdutil.ErrorfWithCode(cmdutil.ExitDenied, "Download stopped, %s", deniedErr)
+	}
+	var batchErr *batchError
+	if errors.As(err, &batchErr) {
+		if batchErr.cause != nil {
+			return cmdutil.RequestError(batchErr.cause, debug)
+		}
+		return cmdutil.Errorf("Failed to download %d of %d attachment(s): %s", len(batchErr.failed), batchErr.total, strings.Join(batchErr.failed, ", "))
+	}
+	return cmdutil.RequestError(err, debug)
+}
+
+// downloadAttachments downloads the attachments one by one and stops at the first failure.
+// If a size cap is set, the bytes received count against it and the download that goes
+// over the cap is aborted.
+func downloadAttachments(client *jira.Client, attachments []jira.Attachment, params *downloadParams, resolver *conflictResolver) error {
+	budget := params.budget
+	if budget == nil && params.maxTotal > 0 {
+		budget = jira.NewByteBudget(params.maxTotal)
+	}
+
+	names := fitLocalNames(params.outputDir, localNames(attachments, params.idPrefix), attachments)
+	for i, a := range attachments {
+		destPath, err := resolver.resolve(filepath.Join(params.outputDir, names[i]), names[i+1:])
+		if err != nil {
+			return err
+		}
+		if destPath == "" {
+			cmdutil.Warn("Skipped %q, file already exists", a.Filename)
+			params.tally.skip()
+			continue
+		}
+
+		outcome, err := func() (fetchOutcome, error) {
+			p := cmdutil.NewProgress(fmt.Sprintf("Downloading %s", a.Filename), params.progress)
+			defer p.Stop()
+
+			return fetchAttachment(params.requestContext(), client, a, destPath, params, budget, jira.WithProgress(p.Update))
 		}()
-		cmdutil.ExitIfError(err)
+		if err != nil && params.verifier != nil && isNotFound(err) {
+			// Deleted since it was listed, it is reported with the verifications.
+			params.verifier.deleted(a)
+			cmdutil.Warn("%q was deleted from the server before it could be downloaded", a.Filename)
+			continue
+		}
+		var deniedErr *typeDeniedError
+		if errors.As(err, &deniedErr) {
+			// Reported with the others once the run completes.
+			cmdutil.Fail("Aborted %s", params.tally.fail(deniedErr))
+			continue
+		}
+		if err != nil {
+			var capErr *jira.ErrSizeCapExceeded
+			switch {
+			case errors.As(err, &capErr):
+				cmdutil.Warn("Stopped after %d of %d attachment(s), %s received", i, len(attachments), cmdutil.FormatSize(capErr.Used))
+				for _, p := range attachments[i:] {
+					cmdutil.Fail("Not downloaded: %q", p.Filename)
+				}
+			case jira.IsAuthFailure(err):
+				// Every remaining download would be rejected as well.
+				for _, p := range attachments[i+1:] {
+					cmdutil.Fail("Not attempted: %q", p.Filename)
+				}
+			}
+			return err
+		}
+
+		reportDownloaded(a, destPath, outcome, params)
+	}
+	return nil
+}
+
+// localName returns the name of the file an attachment is saved to, its filename made safe
+// to create in the output directory, with a notice if it had to be changed.
+func localName(a jira.Attachment) string {
+	name := cmdutil.SanitizeFilename(a.Filename, "attachment-"+a.ID)
+	if name != a.Filename {
+		cmdutil.Warn("Saving %q as %q, the filename isn't safe to write as is", a.Filename, name)
+	}
+	return name
+}
+
+// localNames returns the names of the files the attachments are saved to, in the same
+// order. Jira allows several attachments with the same filename, the first one keeps the
+// name and the next ones get a " (N)" suffix like --on-conflict rename, skipping the names
+// of the other attachments. Names are compared case-insensitively, as on macOS and Windows.
+// With idPrefix, every name is prefixed with the attachment ID and is unique as is.
+func localNames(attachments []jira.Attachment, idPrefix bool) []string {
+	names := make([]string, len(attachments))
+	taken := make(map[string]bool, len(attachments))
+	for i, a := range attachments {
+		names[i] = localName(a)
+		if idPrefix {
+			names[i] = a.ID + "-" + names[i]
+		}
+		taken[strings.ToLower(names[i])] = true
+	}
+	if idPrefix {
+		return names
+	}
+
+	seen := make(map[string]bool, len(attachments))
+	for i, name := range names {
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			continue
+		}
+
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for n := 1; taken[strings.ToLower(names[i])]; n++ {
+			names[i] = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		}
+		taken[strings.ToLower(names[i])] = true
+		seen[strings.ToLower(names[i])] = true
+		cmdutil.Warn("Saving %q (ID: %s) as %q, another attachment has the same name", attachments[i].Filename, attachments[i].ID, names[i])
+	}
+	return names
+}
+
+// fitLocalNames shortens the names too long to be created in dir, keeping room for the
+// suffixes of the temporary and sidecar files, with a notice for each. See
+// cmdutil.FitFilename.
+func fitLocalNames(dir string, names []string, attachments []jira.Attachment) []string {
+	for i, name := range names {
+		fit := cmdutil.FitFilename(dir, name, nameReserve)
+		if fit != name {
+			cmdutil.Warn("Saving %q (ID: %s) as %q, the name is too long for the filesystem", attachments[i].Filename, attachments[i].ID, fit)
+			names[i] = fit
+		}
+	}
+	return names
+}
+
+// fetchOutcome is what became of an attachment fetched to a file.
+type fetchOutcome int
+
+const (
+	outcomeDownloaded fetchOutcome = iota
+	// outcomeConverted is a download whose line endings were converted with --eol.
+	outcomeConverted
+	// outcomeUnchanged is an existing file left as is with --on-conflict update, the
+	// attachment didn't change on the server.
+	outcomeUnchanged
+)
+
+// fetchAttachment downloads an attachment to destPath, converting its line endings with --eol,
+// and records its provenance. With --on-conflict update, the download of an existing file is
+// conditional on the validators recorded with its provenance. The extra options are passed to
+// the download, eg: to report its progress.
+func fetchAttachment(
+	ctx context.Context, client *jira.Client, a jira.Attachment, destPath string, params *downloadParams, budget *jira.ByteBudget,
+	extra ...jira.DownloadOption,
+) (fetchOutcome, error) {
+	destPath = cmdutil.LongPath(destPath)
+	opts := append(downloadOptions(a, params, budget), extra...)
+	if params.conflict == conflictUpdate {
+		opts = append(opts, recordedValidators(destPath).options()...)
+	}
+
+	var (
+		res       *jira.DownloadResult
+		converted bool
+		err       error
+	)
+	if params.eol != eol.ModeNone {
+		res, converted, err = fetchConverted(ctx, client, a, destPath, params, opts)
+	} else {
+		opts = append(opts, jira.WithFileMode(params.mode))
+		if params.ranges > 1 {
+			opts = append(opts, jira.WithParallelRanges(params.ranges))
+		}
+		res, err = api.ProxyDownloadAttachmentContextVersion(ctx, client, params.apiVersion, a, destPath, opts...)
+	}
+	if err != nil {
+		return outcomeDownloaded, err
+	}
+	if res.NotModified {
+		params.tally.skip()
+		return outcomeUnchanged, nil
+	}
+	params.tally.downloaded(res.Bytes)
+
+	var lineEndings string
+	if converted {
+		lineEndings = params.eol.String()
+	}
+	if err := params.provenance.record(destPath, params.issueKey, a, validatorsOf(res), "", lineEndings, params.mode); err != nil {
+		cmdutil.Warn("Unable to record the provenance of %q: %s", a.Filename, err)
+	}
+	if converted {
+		return outcomeConverted, nil
+	}
+	return outcomeDownloaded, nil
+}
+
+// fetchConverted streams an attachment through the line ending conversion of --eol into a
+// temporary file next to destPath, renamed to destPath once complete. Binary attachments
+// pass through untouched. It reports whether the line endings were converted. If the server
+// reports the attachment as not modified, destPath is left as is.
+func fetchConverted(
+	ctx context.Context, client *jira.Client, a jira.Attachment, destPath string, params *downloadParams, opts []jira.DownloadOption,
+) (res *jira.DownloadResult, converted bool, err error) {
+	tmp, err := tmpfile.Create(destPath)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	w := eol.NewWriter(tmp, params.eol, a.MimeType)
+	if res, err = api.ProxyDownloadAttachmentToContextVersion(ctx, client, params.apiVersion, a, w, opts...); err != nil {
+		return nil, false, err
+	}
+	if res.NotModified {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return res, false, nil
+	}
+	if err = w.Close(); err != nil {
+		return nil, false, err
+	}
+	if err = tmp.Chmod(params.mode); err != nil {
+		return nil, false, err
+	}
+	if err = tmp.Close(); err != nil {
+		return nil, false, err
+	}
+	if err = os.Rename(tmp.Name(), destPath); err != nil {
+		return nil, false, err
+	}
+	return res, w.Converted(), nil
+}
 
+// reportDownloaded prints the line of a downloaded attachment, followed by its verification
+// with --verify-after.
+func reportDownloaded(a jira.Attachment, destPath string, outcome fetchOutcome, params *downloadParams) {
+	switch outcome {
+	case outcomeUnchanged:
+		cmdutil.Success("Skipped %q, %s is up to date", a.Filename, destPath)
+		return
+	case outcomeConverted:
+		cmdutil.Success("Downloaded %q to %s (converted line endings to %s)", a.Filename, destPath, params.eol)
+	default:
 		cmdutil.Success("Downloaded %q to %s", a.Filename, destPath)
 	}
+	if params.verifier != nil {
+		params.verifier.check(a).report()
+	}
+}
+
+// downloadOptions returns the options of the download of an attachment shared by
+// files and archives.
+func downloadOptions(a jira.Attachment, params *downloadParams, budget *jira.ByteBudget) []jira.DownloadOption {
+	opts := []jira.DownloadOption{
+		jira.ExpectContent(a.MimeType, a.Size),
+		jira.WithStallTimeout(params.stall, jira.DefaultStallRetries),
+	}
+	if budget != nil {
+		opts = append(opts, jira.WithByteBudget(budget))
+	}
+	if params.policy != nil {
+		policy, issue := params.policy, params.issueKey
+		opts = append(opts, jira.WithContentCheck(func(head []byte) error {
+			return policy.checkContent(issue, a, head)
+		}))
+	}
+	return opts
+}
+
+// interruptContext returns a context cancelled on SIGINT or SIGTERM, so that the download in
+// progress is aborted and its partial file removed before the lock is released. A second
+// interrupt terminates the process right away.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
 }
 
 type downloadParams struct {
-	issueKey  string
-	filename  string
-	all       bool
-	id        string
-	outputDir string
-	debug     bool
+	ctx        context.Context
+	issueKey   string
+	all        bool
+	outputDir  string
+	eol        eol.Mode
+	waitLock   time.Duration
+	tempMaxAge time.Duration
+	stall      time.Duration
+	selector   *selector.Selector
+	report     selector.SelectionReport
+	conflict   conflictPolicy
+	maxTotal   int64
+	budget     *jira.ByteBudget
+	ranges     int
+	strict     bool
+	verifier   *verifier
+	policy     *typePolicy
+	provenance *provenanceWriter
+	tally      *downloadTally
+	// stdout writes the single attachment selected to stdout instead of a file.
+	stdout     bool
+	tar        string
+	tarGz      bool
+	s3         *s3Target
+	idPrefix   bool
+	jql        string
+	resume     bool
+	flushEvery int
+	progress   bool
+	mode       os.FileMode
+	dirMode    os.FileMode
+	debug      bool
+
+	concurrency     int
+	includeSubtasks bool
+	verifyAfter     bool
+	provenanceMode  provenanceMode
+	hashWorkers     int
+	unsafeAllow     []string
+	s3URL           string
+	s3Endpoint      string
+	apiVersion      string
 }
 
-func parseArgsAndFlags(args []string, flags query.FlagParser) *downloadParams {
+// requestContext returns the context of the downloads, cancelled when the command is interrupted.
+func (p *downloadParams) requestContext() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+func parseArgsAndFlags(args []string, flags query.FlagParser) (*downloadParams, error) {
 	var issueKey, filename string
 
 	if len(args) >= 1 {
@@ -125,43 +902,311 @@ func parseArgsAndFlags(args []string, flags query.FlagParser) *downloadParams {
 	}
 
 	debug, err := flags.GetBool("debug")
-	cmdutil.ExitIfError(err)
+	if err != nil {
+		return nil, err
+	}
 
 	all, err := flags.GetBool("all")
-	cmdutil.ExitIfError(err)
-
-	id, err := flags.GetString("id")
-	cmdutil.ExitIfError(err)
+	if err != nil {
+		return nil, err
+	}
 
 	outputDir, err := flags.GetString("output")
-	cmdutil.ExitIfError(err)
+	if err != nil {
+		return nil, err
+	}
 
-	return &downloadParams{
-		issueKey:  issueKey,
-		filename:  filename,
-		all:       all,
-		id:        id,
-		outputDir: outputDir,
-		debug:     debug,
+	eolFlag, err := flags.GetString("eol")
+	if err != nil {
+		return nil, err
+	}
+
+	eolMode, err := eol.ParseMode(eolFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	waitLockFlag, err := flags.GetString("wait-lock")
+	if err != nil {
+		return nil, err
+	}
+
+	waitLock, err := time.ParseDuration(waitLockFlag)
+	if err != nil {
+		return nil, cmdutil.Errorf("Invalid --wait-lock duration %q", waitLockFlag)
+	}
+
+	stallFlag, err := flags.GetString("stall-timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	stall, err := time.ParseDuration(stallFlag)
+	if err != nil || stall < 0 {
+		return nil, cmdutil.Errorf("Invalid --stall-timeout duration %q, eg: 30s", stallFlag)
+	}
+
+	sel, err := selector.New(flags)
+	if err != nil {
+		return nil, err
+	}
+	sel.Filename = filename
+
+	onConflict, err := flags.GetString("on-conflict")
+	if err != nil {
+		return nil, err
 	}
-}
 
-func findAttachmentByID(attachments []jira.Attachment, id string) []jira.Attachment {
-	for _, a := range attachments {
-		if a.ID == id {
-			return []jira.Attachment{a}
+	conflict, err := parseConflictPolicy(onConflict, !cmdutil.StdinHasData())
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency, err := flags.GetUint("concurrency")
+	if err != nil {
+		return nil, err
+	}
+	if concurrency < 1 || concurrency > maxConcurrency {
+		return nil, cmdutil.Errorf("--concurrency must be between 1 and %d", maxConcurrency)
+	}
+
+	ranges, err := flags.GetUint("parallel-ranges")
+	if err != nil {
+		return nil, err
+	}
+	if ranges > maxParallelRanges {
+		return nil, cmdutil.Errorf("--parallel-ranges can't be more than %d", maxParallelRanges)
+	}
+	if ranges > 1 && eolMode != eol.ModeNone {
+		return nil, cmdutil.Errorf("--parallel-ranges can't be combined with --eol, the attachments are converted as they stream")
+	}
+
+	strict, err := flags.GetBool("strict")
+	if err != nil {
+		return nil, err
+	}
+
+	includeSubtasks, err := flags.GetBool("include-subtasks")
+	if err != nil {
+		return nil, err
+	}
+
+	verifyAfter, err := flags.GetBool("verify-after")
+	if err != nil {
+		return nil, err
+	}
+
+	provenanceFlag, err := flags.GetString("provenance")
+	if err != nil {
+		return nil, err
+	}
+
+	provenanceMode, err := parseProvenanceMode(provenanceFlag)
+	if err != nil {
+		return nil, err
+	}
+	if conflict == conflictUpdate && provenanceMode == provenanceOff {
+		return nil, cmdutil.Errorf("--on-conflict update requires --provenance, the files are compared with the ETag and Last-Modified recorded with it")
+	}
+
+	hashWorkers, err := flags.GetUint("hash-workers")
+	if err != nil {
+		return nil, err
+	}
+
+	tar, err := flags.GetString("tar")
+	if err != nil {
+		return nil, err
+	}
+
+	tarGz, err := flags.GetBool("tar-gz")
+	if err != nil {
+		return nil, err
+	}
+	if tarGz && tar == "" {
+		return nil, cmdutil.Errorf("--tar-gz requires --tar")
+	}
+	if tar != "" && (outputDir != "." || eolMode != eol.ModeNone || onConflict != "" || ranges > 1 || provenanceMode != provenanceOff || verifyAfter) {
+		return nil, cmdutil.Errorf("--tar can't be combined with --output, --eol, --on-conflict, --parallel-ranges, --provenance or --verify-after")
+	}
+	if tar != "" && concurrency > 1 {
+		return nil, cmdutil.Errorf("--tar writes the attachments one after the other, it can't be combined with --concurrency")
+	}
+
+	maxTotalFlag, err := flags.GetString("max-total-size")
+	if err != nil {
+		return nil, err
+	}
+
+	modeFlag, err := flags.GetString("mode")
+	if err != nil {
+		return nil, err
+	}
+	mode, err := parseMode("mode", modeFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	dirModeFlag, err := flags.GetString("dir-mode")
+	if err != nil {
+		return nil, err
+	}
+	dirMode, err := parseMode("dir-mode", dirModeFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxTotal int64
+	if maxTotalFlag != "" {
+		if maxTotal, err = where.ParseSize(maxTotalFlag); err != nil || maxTotal <= 0 {
+			return nil, cmdutil.Errorf("Invalid --max-total-size %q, expected a size like 500MB", maxTotalFlag)
 		}
 	}
-	cmdutil.Failed("Attachment with ID %q not found", id)
+
+	tempMaxAge, err := cmdcommon.TempMaxAge()
+	if err != nil {
+		return nil, err
+	}
+
+	unsafeAllow, err := flags.GetStringArray("unsafe-allow")
+	if err != nil {
+		return nil, err
+	}
+
+	idPrefix, err := flags.GetBool("use-id-prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	s3URL, err := flags.GetString("s3-url")
+	if err != nil {
+		return nil, err
+	}
+
+	s3Endpoint, err := flags.GetString("s3-endpoint")
+	if err != nil {
+		return nil, err
+	}
+	if s3Endpoint != "" && s3URL == "" {
+		return nil, cmdutil.Errorf("--s3-endpoint requires --s3-url")
+	}
+	if s3URL != "" && (tar != "" || outputDir != "." || eolMode != eol.ModeNone || onConflict != "" || ranges > 1 || provenanceMode != provenanceOff || verifyAfter) {
+		return nil, cmdutil.Errorf("--s3-url can't be combined with --tar, --output, --eol, --on-conflict, --parallel-ranges, --provenance or --verify-after")
+	}
+
+	stdout, err := flags.GetBool("stdout")
+	if err != nil {
+		return nil, err
+	}
+	if stdout && all {
+		return nil, cmdutil.Errorf("--stdout writes a single attachment, it can't be combined with --all")
+	}
+	if stdout && (tar != "" || s3URL != "" || outputDir != "." || eolMode != eol.ModeNone || onConflict != "" || ranges > 1 ||
+		provenanceMode != provenanceOff || verifyAfter || includeSubtasks || concurrency > 1) {
+		return nil, cmdutil.Errorf("--stdout can't be combined with --tar, --s3-url, --output, --eol, --on-conflict, --parallel-ranges, " +
+			"--provenance, --verify-after, --include-subtasks or --concurrency")
+	}
+
+	plain, err := flags.GetBool("plain")
+	if err != nil {
+		return nil, err
+	}
+
+	jql, err := flags.GetString("jql")
+	if err != nil {
+		return nil, err
+	}
+
+	resume, err := flags.GetBool("resume")
+	if err != nil {
+		return nil, err
+	}
+	if resume && jql == "" {
+		return nil, cmdutil.Errorf("--resume requires --jql")
+	}
+
+	flushEvery, err := flags.GetUint("flush-every")
+	if err != nil {
+		return nil, err
+	}
+	if flushEvery < 1 {
+		return nil, cmdutil.Errorf("--flush-every must be at least 1")
+	}
+	if flushEvery > 1 && jql == "" {
+		return nil, cmdutil.Errorf("--flush-every requires --jql")
+	}
+	if jql != "" {
+		if len(args) > 0 {
+			return nil, cmdutil.Errorf("--jql can't be combined with ISSUE-KEY or FILENAME")
+		}
+		if tar != "" || s3URL != "" || includeSubtasks || stdout {
+			return nil, cmdutil.Errorf("--jql can't be combined with --tar, --s3-url, --include-subtasks or --stdout")
+		}
+	}
+
+	return &downloadParams{
+		issueKey:    issueKey,
+		all:         all,
+		outputDir:   outputDir,
+		eol:         eolMode,
+		waitLock:    waitLock,
+		tempMaxAge:  tempMaxAge,
+		stall:       stall,
+		selector:    sel,
+		conflict:    conflict,
+		maxTotal:    maxTotal,
+		ranges:      int(ranges),
+		concurrency: int(concurrency),
+		strict:      strict,
+		mode:        mode,
+		dirMode:     dirMode,
+		debug:       debug,
+
+		includeSubtasks: includeSubtasks,
+		verifyAfter:     verifyAfter,
+		provenanceMode:  provenanceMode,
+		hashWorkers:     int(hashWorkers),
+		unsafeAllow:     unsafeAllow,
+		s3URL:           s3URL,
+		s3Endpoint:      s3Endpoint,
+		stdout:          stdout,
+		tar:             tar,
+		tarGz:           tarGz,
+		idPrefix:        idPrefix,
+		jql:             jql,
+		resume:          resume,
+		flushEvery:      int(flushEvery),
+		progress:        cmdutil.ProgressBarEnabled(plain),
+	}, nil
+}
+
+// requireAvailable rejects an attachment selected by ID or filename that is unavailable.
+func requireAvailable(a jira.Attachment) error {
+	if !a.Available() {
+		return fmt.Errorf(
+			"attachment %q (ID: %s) is unavailable, the issue may be archived or the attachment restricted", a.Filename, a.ID,
+		)
+	}
 	return nil
 }
 
-func findAttachmentByFilename(attachments []jira.Attachment, filename string) []jira.Attachment {
-	for _, a := range attachments {
-		if a.Filename == filename {
-			return []jira.Attachment{a}
+// excludeUnavailable leaves out the attachments that can't be downloaded and reports
+// each one to warn. It returns the number of attachments left out.
+func excludeUnavailable(batches []cmdcommon.IssueAttachments, warn func(format string, a ...any)) ([]cmdcommon.IssueAttachments, int) {
+	var skipped int
+
+	out := make([]cmdcommon.IssueAttachments, 0, len(batches))
+	for _, b := range batches {
+		available := make([]jira.Attachment, 0, len(b.Attachments))
+		for _, a := range b.Attachments {
+			if !a.Available() {
+				warn("Skipping %q (ID: %s) of %s, the attachment is unavailable", a.Filename, a.ID, b.Issue)
+				skipped++
+				continue
+			}
+			available = append(available, a)
 		}
+		out = append(out, cmdcommon.IssueAttachments{Issue: b.Issue, Attachments: available})
 	}
-	cmdutil.Failed("Attachment with filename %q not found", filename)
-	return nil
+	return out, skipped
 }
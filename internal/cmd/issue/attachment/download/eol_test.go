@@ -0,0 +1,57 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestDownloadConvertsLineEndingsWhileStreaming(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	binary := []byte{0x00, 0x01, '\n', 0xff, '\r', '\n', 0x00}
+	server.AddAttachment("TEST-1", "app.log", []byte("one\ntwo\r\nthree\n"))
+	server.AddAttachment("TEST-1", "blob.bin", binary)
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+	out := t.TempDir()
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(),
+		"TEST-1", "--all", "--output", out, "--eol", "crlf", "--provenance", "sidecar", "--mode", "0640")
+	require.NoError(t, res.Err)
+
+	got, err := os.ReadFile(filepath.Join(out, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "one\r\ntwo\r\nthree\r\n", string(got))
+
+	got, err = os.ReadFile(filepath.Join(out, "blob.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, binary, got, "binary attachments are left alone")
+
+	info, err := os.Stat(filepath.Join(out, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	// The conversion is recorded with the provenance of the file.
+	assert.Equal(t, "CRLF", readSidecar(t, filepath.Join(out, "app.log")).LineEndings)
+	assert.Empty(t, readSidecar(t, filepath.Join(out, "blob.bin")).LineEndings)
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4)
+}
+
+func TestDownloadRejectsEOLWithParallelRanges(t *testing.T) {
+	env := cmdtest.Env{Config: map[string]any{"auth.check_token_expiry": false}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--eol", "lf", "--parallel-ranges", "4")
+	assert.EqualError(t, res.Err, "--parallel-ranges can't be combined with --eol, the attachments are converted as they stream")
+}
@@ -0,0 +1,137 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestDownloadSanitizesFilenames(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	server.AddAttachment("TEST-1", "../../.bashrc", []byte("alias ls=rm"))
+	server.AddAttachment("TEST-1", "/etc/cron.d/job", []byte("* * * * * root id"))
+	server.AddAttachment("TEST-1", `..\..\startup.bat`, []byte("echo"))
+	server.AddAttachment("TEST-1", "report.pdf", []byte("%PDF-1.7"))
+
+	root := t.TempDir()
+	out := filepath.Join(root, "a", "b")
+	for _, concurrency := range []string{"1", "2"} {
+		require.NoError(t, os.RemoveAll(root))
+		require.NoError(t, os.MkdirAll(out, 0o700))
+
+		res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentDownload(),
+			"TEST-1", "--all", "--output", out, "--concurrency", concurrency)
+		require.NoError(t, res.Err)
+
+		var names []string
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				rel, _ := filepath.Rel(root, path)
+				names = append(names, rel)
+			}
+			return err
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			filepath.Join("a", "b", ".bashrc"),
+			filepath.Join("a", "b", "job"),
+			filepath.Join("a", "b", "startup.bat"),
+			filepath.Join("a", "b", "report.pdf"),
+		}, names, "nothing is written outside of the output directory")
+
+		assert.Contains(t, res.Stderr, `Saving "../../.bashrc" as ".bashrc"`)
+		assert.Contains(t, res.Stderr, `Saving "/etc/cron.d/job" as "job"`)
+		assert.NotContains(t, res.Stderr, `Saving "report.pdf"`, "safe names are kept as is")
+	}
+}
+
+func TestDownloadDuplicateFilenames(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	first := server.AddAttachment("TEST-1", "screenshot.png", []byte("first"))
+	second := server.AddAttachment("TEST-1", "screenshot.png", []byte("second"))
+	third := server.AddAttachment("TEST-1", "screenshot.png", []byte("third"))
+
+	for _, concurrency := range []string{"1", "2"} {
+		out := t.TempDir()
+		res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentDownload(),
+			"TEST-1", "--all", "--output", out, "--concurrency", concurrency)
+		require.NoError(t, res.Err)
+
+		for name, want := range map[string]string{
+			"screenshot.png":     "first",
+			"screenshot (1).png": "second",
+			"screenshot (2).png": "third",
+		} {
+			got, err := os.ReadFile(filepath.Join(out, name))
+			require.NoError(t, err, name)
+			assert.Equal(t, want, string(got), name)
+		}
+		assert.Contains(t, res.Stderr, `Saving "screenshot.png" (ID: `+third.ID+`) as "screenshot (2).png"`)
+	}
+
+	out := t.TempDir()
+	res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentDownload(),
+		"TEST-1", "--all", "--output", out, "--use-id-prefix")
+	require.NoError(t, res.Err)
+	for _, a := range []struct{ id, content string }{{first.ID, "first"}, {second.ID, "second"}, {third.ID, "third"}} {
+		got, err := os.ReadFile(filepath.Join(out, a.id+"-screenshot.png"))
+		require.NoError(t, err)
+		assert.Equal(t, a.content, string(got))
+	}
+	assert.NotContains(t, res.Stderr, "another attachment has the same name")
+}
+
+func TestLocalNames(t *testing.T) {
+	attachments := []jira.Attachment{
+		{ID: "1", Filename: "a.txt"},
+		{ID: "2", Filename: "A.txt"},
+		{ID: "3", Filename: "a (1).txt"},
+		{ID: "4", Filename: "a.txt"},
+		{ID: "5", Filename: "notes"},
+		{ID: "6", Filename: "notes"},
+	}
+	assert.Equal(t, []string{"a.txt", "A (2).txt", "a (1).txt", "a (3).txt", "notes", "notes (1)"}, localNames(attachments, false))
+	assert.Equal(t, []string{"1-a.txt", "2-A.txt", "3-a (1).txt", "4-a.txt", "5-notes", "6-notes"}, localNames(attachments, true))
+}
+
+func TestDownloadShortensLongFilenames(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	long := strings.Repeat("quarterly report ", 20) + ".pdf"
+	a := server.AddAttachment("TEST-1", long, []byte("%PDF-1.7"))
+
+	for _, concurrency := range []string{"1", "2"} {
+		out := t.TempDir()
+		res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentDownload(),
+			"TEST-1", "--all", "--output", out, "--concurrency", concurrency, "--provenance", "sidecar")
+		require.NoError(t, res.Err)
+
+		entries, err := os.ReadDir(out)
+		require.NoError(t, err)
+		require.Len(t, entries, 2, "the file and its sidecar")
+
+		name := cmdutil.FitFilename(out, long, nameReserve)
+		assert.NotEqual(t, long, name)
+		assert.True(t, strings.HasSuffix(name, ".pdf"))
+		assert.FileExists(t, filepath.Join(out, name))
+		assert.Contains(t, res.Stderr, "the name is too long for the filesystem")
+
+		p := readSidecar(t, filepath.Join(out, name))
+		assert.Equal(t, a.ID, p.AttachmentID)
+		assert.Equal(t, long, p.Filename, "the sidecar maps the file to the attachment filename")
+	}
+}
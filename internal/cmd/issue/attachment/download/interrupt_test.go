@@ -0,0 +1,57 @@
+package download
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestDownloadAttachmentsInterrupted(t *testing.T) {
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	attachments := []jira.Attachment{
+		server.AddAttachment("TEST-1", "a.txt", []byte("a")),
+		server.AddAttachment("TEST-1", "b.txt", []byte("b")),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dir := t.TempDir()
+	params := &downloadParams{ctx: ctx, outputDir: dir}
+	err := downloadAttachments(server.Client(), attachments, params, newConflictResolver(conflictOverwrite))
+	require.ErrorIs(t, err, context.Canceled)
+	assert.EqualError(t, downloadError(err, false), "Download interrupted")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "no partial file is left")
+}
+
+func TestDownloadTarInterrupted(t *testing.T) {
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	a := server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	path := filepath.Join(t.TempDir(), "out.tar")
+	params := &downloadParams{ctx: ctx, tar: path, mode: 0o600}
+	err := downloadTar(server.Client(), []cmdcommon.IssueAttachments{{Issue: "TEST-1", Attachments: []jira.Attachment{a}}}, params, io.Discard)
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "the truncated archive is removed")
+}
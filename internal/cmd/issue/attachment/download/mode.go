@@ -0,0 +1,55 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// parseMode parses an octal permission such as 0600 or 640.
+func parseMode(flag, s string) (os.FileMode, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(s), "0o")
+	mode, err := strconv.ParseUint(v, 8, 32)
+	if err != nil || v == "" || mode > 0o777 {
+		return 0, cmdutil.Errorf("Invalid --%s %q, expected an octal permission like 0600", flag, s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// makeDir creates the directory and its missing parents with the given mode. MkdirAll is
+// subject to the umask, so the mode is set again on the directories it created while they
+// are still empty. Directories that already existed are left as they are. Paths too long
+// for Windows' MAX_PATH are prefixed, see cmdutil.LongPath.
+func makeDir(path string, mode os.FileMode) error {
+	if mode == 0 {
+		mode = jira.DefaultAttachmentDirMode
+	}
+	path = cmdutil.LongPath(path)
+
+	var created []string
+	for p := filepath.Clean(path); ; {
+		if _, err := os.Stat(p); err == nil {
+			break
+		}
+		created = append(created, p)
+		parent := filepath.Dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+
+	if err := os.MkdirAll(path, mode); err != nil {
+		return err
+	}
+	for _, p := range created {
+		if err := os.Chmod(p, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are best effort on windows")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in      string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{in: "0600", want: 0o600},
+		{in: "640", want: 0o640},
+		{in: "0o750", want: 0o750},
+		{in: "0777", want: 0o777},
+		{in: "", wantErr: true},
+		{in: "rw-------", wantErr: true},
+		{in: "0800", wantErr: true},
+		{in: "1777", wantErr: true},
+		{in: "-600", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		mode, err := parseMode("mode", tc.in)
+		if tc.wantErr {
+			assert.EqualError(t, err, `Invalid --mode "`+tc.in+`", expected an octal permission like 0600`)
+			continue
+		}
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, mode, tc.in)
+	}
+}
+
+func TestMakeDir(t *testing.T) {
+	t.Parallel()
+	skipOnWindows(t)
+
+	root := t.TempDir()
+	existing := filepath.Join(root, "existing")
+	require.NoError(t, os.Mkdir(existing, 0o755))
+	require.NoError(t, os.Chmod(existing, 0o755))
+
+	require.NoError(t, makeDir(filepath.Join(existing, "a", "b"), 0o750))
+
+	for path, want := range map[string]os.FileMode{
+		existing:                          0o755,
+		filepath.Join(existing, "a"):      0o750,
+		filepath.Join(existing, "a", "b"): 0o750,
+	} {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, info.Mode().Perm(), path)
+	}
+}
+
+func newModeServer(t *testing.T) *jiratest.Server {
+	t.Helper()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+	server.AddAttachment("TEST-1", "secret.txt", []byte("secret"))
+	return server
+}
+
+func TestDownloadModes(t *testing.T) {
+	skipOnWindows(t)
+
+	cases := []struct {
+		name              string
+		args              []string
+		fileMode, dirMode os.FileMode
+	}{
+		{name: "defaults", fileMode: 0o600, dirMode: 0o700},
+		{name: "custom", args: []string{"--mode", "0640", "--dir-mode", "0750"}, fileMode: 0o640, dirMode: 0o750},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newModeServer(t)
+			env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": jira.InstallationTypeCloud}}
+			out := filepath.Join(t.TempDir(), "downloads")
+
+			args := append([]string{"TEST-1", "--all", "--output", out, "--on-conflict", "fail"}, tc.args...)
+			res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), args...)
+			require.NoError(t, res.Err)
+
+			dir, err := os.Stat(out)
+			require.NoError(t, err)
+			assert.Equal(t, tc.dirMode, dir.Mode().Perm())
+
+			file, err := os.Stat(filepath.Join(out, "secret.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.fileMode, file.Mode().Perm())
+		})
+	}
+}
+
+func TestDownloadInvalidModeBeforeRequests(t *testing.T) {
+	for _, args := range [][]string{{"--mode", "0999"}, {"--dir-mode", "drwx"}} {
+		server := newModeServer(t)
+		env := cmdtest.Env{Client: server.Client()}
+
+		res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), append([]string{"TEST-1", "--all"}, args...)...)
+		assert.ErrorContains(t, res.Err, "expected an octal permission like 0600")
+		assert.Empty(t, server.Requests())
+	}
+}
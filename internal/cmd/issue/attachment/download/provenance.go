@@ -0,0 +1,271 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/filehash"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// provenanceMode is how --provenance records where a downloaded file came from.
+type provenanceMode string
+
+const (
+	provenanceOff     provenanceMode = ""
+	provenanceXattr   provenanceMode = "xattr"
+	provenanceSidecar provenanceMode = "sidecar"
+)
+
+// sidecarSuffix is appended to the name of a downloaded file for its sidecar file.
+const sidecarSuffix = ".jira.json"
+
+// provenanceVersion is the version of the sidecar schema. It is bumped on changes
+// that readers of older sidecar files must know about.
+const provenanceVersion = 1
+
+// Extended attributes written in xattr mode.
+const (
+	xattrIssue        = "user.jira.issue"
+	xattrAttachmentID = "user.jira.attachment_id"
+	xattrServer       = "user.jira.server"
+	xattrFilename     = "user.jira.filename"
+	xattrLineEndings  = "user.jira.line_endings"
+	xattrETag         = "user.jira.etag"
+	xattrLastModified = "user.jira.last_modified"
+)
+
+func parseProvenanceMode(s string) (provenanceMode, error) {
+	switch m := provenanceMode(strings.ToLower(s)); m {
+	case provenanceOff, provenanceXattr, provenanceSidecar:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid --provenance value %q, must be one of: xattr, sidecar", s)
+}
+
+// provenance is the content of a sidecar file.
+type provenance struct {
+	Version      int    `json:"version"`
+	Issue        string `json:"issue"`
+	AttachmentID string `json:"attachment_id"`
+	Server       string `json:"server"`
+	DownloadedAt string `json:"downloaded_at"`
+	// SHA256 is the checksum of the file, filled in once the downloads are done.
+	SHA256 string `json:"sha256,omitempty"`
+	// Filename is the filename of the attachment if the file was saved under another
+	// name, eg: shortened to fit the filesystem.
+	Filename string `json:"filename,omitempty"`
+	// LineEndings is the line ending the file was converted to with --eol, eg: CRLF.
+	LineEndings string `json:"line_endings,omitempty"`
+	// ETag and LastModified are the validators the server sent with the file, sent
+	// back by --on-conflict update to skip the download if it is unchanged.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validators are the ETag and Last-Modified of a downloaded attachment.
+type validators struct {
+	etag         string
+	lastModified string
+}
+
+func validatorsOf(res *jira.DownloadResult) validators {
+	if res == nil {
+		return validators{}
+	}
+	return validators{etag: res.ETag, lastModified: res.LastModified}
+}
+
+// options returns the options that make a download conditional on the validators.
+func (v validators) options() []jira.DownloadOption {
+	var opts []jira.DownloadOption
+	if v.etag != "" {
+		opts = append(opts, jira.IfNoneMatch(v.etag))
+	}
+	if v.lastModified != "" {
+		opts = append(opts, jira.IfModifiedSince(v.lastModified))
+	}
+	return opts
+}
+
+// provenanceWriter records the issue, attachment and server a downloaded file came from.
+type provenanceWriter struct {
+	mode   provenanceMode
+	server string
+	now    func() time.Time
+	// supported probes if a directory supports extended attributes, the result is cached.
+	supported func(dir string) bool
+	mu        sync.Mutex
+	probed    map[string]bool
+	// hashWorkers is the number of sidecar files hashed at the same time by finish.
+	hashWorkers int
+	// unsummed are the sidecar files written without a checksum, filled in by finish.
+	unsummed []sidecar
+}
+
+// sidecar is a sidecar file written for the downloaded file at path.
+type sidecar struct {
+	path string
+	prov provenance
+	mode os.FileMode
+}
+
+func newProvenanceWriter(mode provenanceMode, server string, hashWorkers int) *provenanceWriter {
+	if mode == provenanceOff {
+		return nil
+	}
+	return &provenanceWriter{mode: mode, server: server, now: time.Now, supported: xattrSupported, hashWorkers: hashWorkers}
+}
+
+// record records the provenance of the file at path downloaded from the attachment. In
+// xattr mode, a sidecar file is written instead if the filesystem doesn't support
+// extended attributes. A sidecar file recorded without a checksum gets one from finish.
+func (w *provenanceWriter) record(
+	path, issue string, a jira.Attachment, v validators, sum, lineEndings string, mode os.FileMode,
+) error {
+	if w == nil {
+		return nil
+	}
+	if w.mode == provenanceXattr && w.xattrSupported(filepath.Dir(path)) {
+		return w.writeXattrs(path, issue, a, v, lineEndings)
+	}
+	return w.writeSidecar(path, issue, a, v, sum, lineEndings, mode)
+}
+
+// recordedValidators returns the validators recorded with the provenance of the file at path, from
+// its sidecar file or else its extended attributes. They are empty if none were recorded.
+func recordedValidators(path string) validators {
+	if data, err := os.ReadFile(path + sidecarSuffix); err == nil {
+		var prov provenance
+		if json.Unmarshal(data, &prov) == nil {
+			return validators{etag: prov.ETag, lastModified: prov.LastModified}
+		}
+	}
+	etag, _ := getXattr(path, xattrETag)
+	lastModified, _ := getXattr(path, xattrLastModified)
+	return validators{etag: etag, lastModified: lastModified}
+}
+
+func (w *provenanceWriter) xattrSupported(dir string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.probed == nil {
+		w.probed = make(map[string]bool)
+	}
+	ok, seen := w.probed[dir]
+	if !seen {
+		ok = w.supported(dir)
+		w.probed[dir] = ok
+	}
+	return ok
+}
+
+func (w *provenanceWriter) writeXattrs(path, issue string, a jira.Attachment, v validators, lineEndings string) error {
+	attrs := [][2]string{
+		{xattrIssue, issue},
+		{xattrAttachmentID, a.ID},
+		{xattrServer, w.server},
+	}
+	if name := renamedFrom(path, a); name != "" {
+		attrs = append(attrs, [2]string{xattrFilename, name})
+	}
+	if lineEndings != "" {
+		attrs = append(attrs, [2]string{xattrLineEndings, lineEndings})
+	}
+	if v.etag != "" {
+		attrs = append(attrs, [2]string{xattrETag, v.etag})
+	}
+	if v.lastModified != "" {
+		attrs = append(attrs, [2]string{xattrLastModified, v.lastModified})
+	}
+	for _, attr := range attrs {
+		if err := setXattr(path, attr[0], attr[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *provenanceWriter) writeSidecar(
+	path, issue string, a jira.Attachment, v validators, sum, lineEndings string, mode os.FileMode,
+) error {
+	sc := sidecar{
+		path: path,
+		prov: provenance{
+			Version:      provenanceVersion,
+			Issue:        issue,
+			AttachmentID: a.ID,
+			Server:       w.server,
+			DownloadedAt: w.now().UTC().Format(time.RFC3339),
+			SHA256:       sum,
+			Filename:     renamedFrom(path, a),
+			LineEndings:  lineEndings,
+			ETag:         v.etag,
+			LastModified: v.lastModified,
+		},
+		mode: mode,
+	}
+	if err := sc.write(); err != nil {
+		return err
+	}
+	if sum == "" {
+		w.mu.Lock()
+		w.unsummed = append(w.unsummed, sc)
+		w.mu.Unlock()
+	}
+	return nil
+}
+
+// finish hashes the files of the sidecar files recorded without a checksum with a pool of
+// workers, and writes the checksums into their sidecar files. The sidecar files are written
+// as the files download so that an interrupted download still has them; hashing is left to
+// the end so that it doesn't slow down the downloads.
+func (w *provenanceWriter) finish() {
+	if w == nil || len(w.unsummed) == 0 {
+		return
+	}
+
+	files := make([]filehash.File, 0, len(w.unsummed))
+	for _, sc := range w.unsummed {
+		files = append(files, filehash.File{Path: sc.path})
+	}
+	for i, r := range filehash.Files(files, filehash.Options{Workers: w.hashWorkers}) {
+		sc := w.unsummed[i]
+		if r.Err != nil {
+			cmdutil.Warn("Unable to compute the checksum of %q: %s", sc.path, r.Err)
+			continue
+		}
+		sc.prov.SHA256 = r.Sum
+		if err := sc.write(); err != nil {
+			cmdutil.Warn("Unable to record the checksum of %q: %s", sc.path, err)
+		}
+	}
+	w.unsummed = nil
+}
+
+func (sc sidecar) write() error {
+	data, err := json.MarshalIndent(sc.prov, "", "  ")
+	if err != nil {
+		return err
+	}
+	mode := sc.mode
+	if mode == 0 {
+		mode = jira.DefaultAttachmentFileMode
+	}
+	return os.WriteFile(sc.path+sidecarSuffix, append(data, '\n'), mode)
+}
+
+// renamedFrom returns the filename of the attachment if the file at path has another name,
+// else an empty string.
+func renamedFrom(path string, a jira.Attachment) string {
+	if a.Filename == "" || filepath.Base(path) == a.Filename {
+		return ""
+	}
+	return a.Filename
+}
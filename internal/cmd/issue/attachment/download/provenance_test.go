@@ -0,0 +1,224 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestParseProvenanceMode(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]provenanceMode{"": provenanceOff, "xattr": provenanceXattr, "Sidecar": provenanceSidecar} {
+		got, err := parseProvenanceMode(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := parseProvenanceMode("db")
+	assert.EqualError(t, err, `invalid --provenance value "db", must be one of: xattr, sidecar`)
+}
+
+func readSidecar(t *testing.T, path string) provenance {
+	t.Helper()
+
+	data, err := os.ReadFile(path + sidecarSuffix)
+	require.NoError(t, err)
+
+	var p provenance
+	require.NoError(t, json.Unmarshal(data, &p))
+	return p
+}
+
+func TestProvenanceSidecar(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("pdf"), 0o600))
+
+	w := newProvenanceWriter(provenanceSidecar, "https://jira.example.com", 0)
+	w.now = func() time.Time { return time.Date(2024, 3, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600)) }
+
+	require.NoError(t, w.record(path, "TEST-1", jira.Attachment{ID: "10001"}, validators{}, "abc123", "", 0o640))
+	assert.Equal(t, provenance{
+		Version:      1,
+		Issue:        "TEST-1",
+		AttachmentID: "10001",
+		Server:       "https://jira.example.com",
+		DownloadedAt: "2024-03-01T09:00:00Z",
+		SHA256:       "abc123",
+	}, readSidecar(t, path))
+
+	info, err := os.Stat(path + sidecarSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	// Without a checksum, the field is left out.
+	require.NoError(t, w.record(path, "TEST-1", jira.Attachment{ID: "10001"}, validators{}, "", "", 0))
+	data, err := os.ReadFile(path + sidecarSuffix)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sha256")
+}
+
+func TestProvenanceFinishFillsChecksums(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w := newProvenanceWriter(provenanceSidecar, "https://jira.example.com", 2)
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o600))
+		require.NoError(t, w.record(path, "TEST-1", jira.Attachment{ID: name}, validators{}, "", "", 0))
+		assert.Empty(t, readSidecar(t, path).SHA256, "hashed once the downloads are done")
+	}
+	// A file gone before it is hashed keeps its sidecar file without a checksum.
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.txt")))
+
+	w.finish()
+
+	for name, want := range map[string]string{
+		"a.txt": sha256Hex("a.txt"),
+		"b.txt": "",
+		"c.txt": sha256Hex("c.txt"),
+	} {
+		p := readSidecar(t, filepath.Join(dir, name))
+		assert.Equal(t, want, p.SHA256, name)
+		assert.Equal(t, name, p.AttachmentID, name)
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestProvenanceXattr(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if !xattrSupported(dir) {
+		t.Skip("the filesystem of the temporary directory doesn't support extended attributes")
+	}
+
+	path := filepath.Join(dir, "report.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("pdf"), 0o600))
+
+	w := newProvenanceWriter(provenanceXattr, "https://jira.example.com", 0)
+	require.NoError(t, w.record(path, "TEST-1", jira.Attachment{ID: "10001"}, validators{}, "", "", 0))
+
+	for name, want := range map[string]string{
+		xattrIssue:        "TEST-1",
+		xattrAttachmentID: "10001",
+		xattrServer:       "https://jira.example.com",
+	} {
+		got, err := getXattr(path, name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+	_, err := os.Stat(path + sidecarSuffix)
+	assert.True(t, os.IsNotExist(err), "no sidecar file with extended attributes")
+
+	// The probe leaves nothing behind.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestProvenanceXattrFallsBackToSidecar(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var probes int
+
+	w := newProvenanceWriter(provenanceXattr, "https://jira.example.com", 0)
+	w.supported = func(string) bool {
+		probes++
+		return false
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o600))
+		require.NoError(t, w.record(path, "TEST-1", jira.Attachment{ID: name}, validators{}, "", "", 0))
+		assert.Equal(t, name, readSidecar(t, path).AttachmentID)
+	}
+	assert.Equal(t, 1, probes, "the directory is probed once")
+}
+
+func TestDownloadProvenance(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	a := server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+	out := t.TempDir()
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "notes.txt", "--output", out, "--provenance", "sidecar")
+	require.NoError(t, res.Err)
+
+	p := readSidecar(t, filepath.Join(out, "notes.txt"))
+	assert.Equal(t, "TEST-1", p.Issue)
+	assert.Equal(t, a.ID, p.AttachmentID)
+	assert.Equal(t, server.URL, p.Server)
+	assert.NotEmpty(t, p.DownloadedAt)
+	assert.Equal(t, sha256Hex("notes"), p.SHA256)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "notes.txt", "--output", out, "--provenance", "db")
+	assert.EqualError(t, res.Err, `invalid --provenance value "db", must be one of: xattr, sidecar`)
+}
+
+func TestDownloadOnConflictUpdate(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+	out := t.TempDir()
+	path := filepath.Join(out, "notes.txt")
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "notes.txt", "--output", out, "--provenance", "sidecar")
+	require.NoError(t, res.Err)
+	etag := readSidecar(t, path).ETag
+	assert.NotEmpty(t, etag)
+
+	// The server answers 304 to the recorded ETag, the local file is left as is.
+	require.NoError(t, os.WriteFile(path, []byte("local"), 0o600))
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(),
+		"TEST-1", "notes.txt", "--output", out, "--provenance", "sidecar", "--on-conflict", "update")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout+res.Stderr, "is up to date")
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "local", string(got))
+
+	// A stale ETag downloads the attachment again.
+	p := readSidecar(t, path)
+	p.ETag = `"stale"`
+	data, err := json.Marshal(p)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path+sidecarSuffix, data, 0o600))
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(),
+		"TEST-1", "notes.txt", "--output", out, "--provenance", "sidecar", "--on-conflict", "update")
+	require.NoError(t, res.Err)
+	got, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "notes", string(got))
+	assert.Equal(t, etag, readSidecar(t, path).ETag)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "notes.txt", "--output", out, "--on-conflict", "update")
+	assert.EqualError(t, res.Err,
+		"--on-conflict update requires --provenance, the files are compared with the ETag and Last-Modified recorded with it")
+}
@@ -0,0 +1,136 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/s3"
+)
+
+// s3ManifestName is the name of the object, under the --s3-url prefix, that lists the
+// objects written by a run.
+const s3ManifestName = "manifest.json"
+
+// s3Target is the bucket and prefix given with --s3-url that attachments are written to.
+type s3Target struct {
+	client *s3.Client
+	loc    s3.Location
+}
+
+// newS3Target parses the --s3-url and loads the AWS configuration, the endpoint overrides
+// the configured one if set.
+func newS3Target(rawURL, endpoint string) (*s3Target, error) {
+	loc, err := s3.ParseURL(rawURL)
+	if err != nil {
+		return nil, cmdutil.Errorf("%s", err)
+	}
+	cfg, err := s3.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if endpoint != "" {
+		cfg.Endpoint = endpoint
+	}
+	client, err := s3.New(cfg)
+	if err != nil {
+		return nil, cmdutil.Errorf("%s", err)
+	}
+	return &s3Target{client: client, loc: loc}, nil
+}
+
+// planUploads returns a job per attachment, the object of each one named
+// <prefix>/<issue-key>/<filename>.
+func planUploads(batches []cmdcommon.IssueAttachments, params *downloadParams) []downloadJob {
+	var jobs []downloadJob
+	for _, b := range batches {
+		issueParams := *params
+		issueParams.issueKey = b.Issue
+
+		names := localNames(b.Attachments, params.idPrefix)
+		for i, a := range b.Attachments {
+			key := params.s3.loc.Key(b.Issue, names[i])
+			jobs = append(jobs, downloadJob{attachment: a, dest: params.s3.loc.URL(key), key: key, params: &issueParams})
+		}
+	}
+	return jobs
+}
+
+// uploadAttachment streams an attachment into the object at key. The upload is aborted if
+// the download fails, so that no partial object is left behind.
+func uploadAttachment(
+	ctx context.Context, client *jira.Client, a jira.Attachment, key string, params *downloadParams, budget *jira.ByteBudget,
+) (*s3.Object, error) {
+	w := params.s3.client.NewWriter(ctx, params.s3.loc.Bucket, key, a.MimeType)
+	_, err := api.ProxyDownloadAttachmentToContextVersion(ctx, client, params.apiVersion, a, w, downloadOptions(a, params, budget)...)
+	if err != nil {
+		_ = w.Abort()
+		return nil, err
+	}
+	return w.Commit()
+}
+
+// s3Fatal reports if an S3 error fails every upload of the run, eg: a missing bucket.
+func s3Fatal(err error) bool {
+	var s3Err *s3.Error
+	if !errors.As(err, &s3Err) {
+		return false
+	}
+	switch s3Err.Code {
+	case "NoSuchBucket", "AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch":
+		return true
+	}
+	return false
+}
+
+// s3Manifest is written as s3ManifestName under the prefix.
+type s3Manifest struct {
+	Server  string            `json:"server"`
+	Objects []s3ManifestEntry `json:"objects"`
+}
+
+type s3ManifestEntry struct {
+	Issue        string `json:"issue"`
+	AttachmentID string `json:"attachmentId"`
+	Filename     string `json:"filename"`
+	Key          string `json:"key"`
+	ETag         string `json:"etag"`
+	Size         int64  `json:"size"`
+}
+
+// writeS3Manifest lists the objects written by the jobs, if any, in the manifest. It is
+// written even if the run was interrupted, for the objects written by then.
+func writeS3Manifest(jobs []downloadJob, params *downloadParams, server string) error {
+	manifest := s3Manifest{Server: server, Objects: []s3ManifestEntry{}}
+	for _, job := range jobs {
+		if job.object == nil {
+			continue
+		}
+		manifest.Objects = append(manifest.Objects, s3ManifestEntry{
+			Issue:        job.params.issueKey,
+			AttachmentID: job.attachment.ID,
+			Filename:     job.attachment.Filename,
+			Key:          job.object.Key,
+			ETag:         job.object.ETag,
+			Size:         job.object.Size,
+		})
+	}
+	if len(manifest.Objects) == 0 {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	key := params.s3.loc.Key(s3ManifestName)
+	if _, err := params.s3.client.PutObject(context.WithoutCancel(params.requestContext()), params.s3.loc.Bucket, key, "application/json", b); err != nil {
+		return err
+	}
+	cmdutil.Success("Wrote the manifest of %d object(s) to %s", len(manifest.Objects), params.s3.loc.URL(key))
+	return nil
+}
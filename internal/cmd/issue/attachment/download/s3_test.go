@@ -0,0 +1,161 @@
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+	"github.com/ankitpokhrel/jira-cli/pkg/s3/s3test"
+)
+
+// setS3Env points the AWS configuration at the fake server, ignoring the one of the user.
+func setS3Env(t *testing.T) {
+	t.Helper()
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	for name, value := range map[string]string{
+		"AWS_ACCESS_KEY_ID":           s3test.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY":       s3test.SecretAccessKey,
+		"AWS_SESSION_TOKEN":           "",
+		"AWS_PROFILE":                 "",
+		"AWS_REGION":                  "",
+		"AWS_ENDPOINT_URL":            "",
+		"AWS_ENDPOINT_URL_S3":         "",
+		"AWS_CONFIG_FILE":             missing,
+		"AWS_SHARED_CREDENTIALS_FILE": missing,
+	} {
+		t.Setenv(name, value)
+	}
+}
+
+func TestDownloadToS3(t *testing.T) {
+	setS3Env(t)
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+	report := server.AddAttachment("TEST-1", "report.pdf", []byte("%PDF-1.7"))
+	first := server.AddAttachment("TEST-1", "screenshot.png", []byte("first"))
+	second := server.AddAttachment("TEST-1", "screenshot.png", []byte("second"))
+
+	storage := s3test.NewServer(s3test.WithBuckets("archive"))
+	t.Cleanup(storage.Close)
+
+	// Nothing is written to the local disk.
+	t.Chdir(t.TempDir())
+
+	res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentDownload(),
+		"TEST-1", "--all", "--s3-url", "s3://archive/jira/", "--s3-endpoint", storage.URL, "--concurrency", "2")
+	require.NoError(t, res.Err)
+
+	assert.Equal(t, []string{
+		"jira/TEST-1/report.pdf",
+		"jira/TEST-1/screenshot (1).png",
+		"jira/TEST-1/screenshot.png",
+		"jira/manifest.json",
+	}, storage.Keys("archive"))
+	assert.Contains(t, res.Stdout, `Downloaded "report.pdf" to s3://archive/jira/TEST-1/report.pdf`)
+	assert.Contains(t, res.Stdout, "Wrote the manifest of 3 object(s) to s3://archive/jira/manifest.json")
+
+	entries, err := os.ReadDir(".")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	b, _, ok := storage.Object("archive", "jira/manifest.json")
+	require.True(t, ok)
+	var manifest s3Manifest
+	require.NoError(t, json.Unmarshal(b, &manifest))
+	require.Len(t, manifest.Objects, 3)
+
+	want := map[string]string{
+		"jira/TEST-1/report.pdf":         report.ID,
+		"jira/TEST-1/screenshot.png":     first.ID,
+		"jira/TEST-1/screenshot (1).png": second.ID,
+	}
+	for _, o := range manifest.Objects {
+		assert.Equal(t, want[o.Key], o.AttachmentID, o.Key)
+		assert.Equal(t, "TEST-1", o.Issue)
+
+		content, etag, ok := storage.Object("archive", o.Key)
+		require.True(t, ok)
+		assert.Equal(t, etag, o.ETag)
+		assert.Equal(t, int64(len(content)), o.Size)
+
+		local, _ := server.Content(o.AttachmentID)
+		assert.Equal(t, local, content)
+	}
+	assert.Equal(t, "application/pdf", storage.ContentType("archive", "jira/TEST-1/report.pdf"))
+}
+
+func TestDownloadToS3Errors(t *testing.T) {
+	setS3Env(t)
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1", "TEST-2"))
+	t.Cleanup(server.Close)
+	server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+	server.AddAttachment("TEST-1", "b.txt", []byte("b"))
+	// Half of it is more than a part, the multipart upload starts before the download is cut off.
+	server.AddAttachment("TEST-2", "backup.bin", bytes.Repeat([]byte("x"), 18<<20))
+
+	storage := s3test.NewServer(s3test.WithBuckets("archive", "locked"))
+	t.Cleanup(storage.Close)
+	storage.Deny("locked")
+
+	run := func(args ...string) cmdtest.Result {
+		return cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentDownload(),
+			append(args, "--s3-endpoint", storage.URL)...)
+	}
+
+	res := run("TEST-1", "--all", "--s3-url", "s3://missing/jira/")
+	assert.EqualError(t, res.Err, `s3: bucket "missing" doesn't exist`)
+
+	res = run("TEST-1", "--all", "--s3-url", "s3://locked/jira/")
+	assert.EqualError(t, res.Err, "s3: access denied to s3://locked/jira/TEST-1/a.txt, check the permissions of the credentials on the bucket")
+	assert.Empty(t, storage.Keys("locked"))
+
+	server.SetFaults(jiratest.Faults{TruncateDownloads: true})
+	res = run("TEST-2", "--all", "--s3-url", "s3://archive/", "--stall-timeout", "0")
+	assert.Error(t, res.Err)
+	assert.Empty(t, storage.Keys("archive"), "no object nor manifest is written")
+	assert.Equal(t, 1, storage.AbortedUploads(), "the multipart upload is aborted")
+	assert.Zero(t, storage.PendingUploads())
+}
+
+func TestDownloadToS3Flags(t *testing.T) {
+	cases := []struct {
+		args   []string
+		errMsg string
+	}{
+		{
+			args:   []string{"--s3-url", "s3://archive/", "--output", "out"},
+			errMsg: "--s3-url can't be combined with --tar, --output, --eol, --on-conflict, --parallel-ranges, --provenance or --verify-after",
+		},
+		{
+			args:   []string{"--s3-url", "s3://archive/", "--tar", "-"},
+			errMsg: "--s3-url can't be combined with --tar, --output, --eol, --on-conflict, --parallel-ranges, --provenance or --verify-after",
+		},
+		{args: []string{"--s3-endpoint", "http://localhost:9000"}, errMsg: "--s3-endpoint requires --s3-url"},
+	}
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	for _, tc := range cases {
+		res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentDownload(), append([]string{"TEST-1", "--all"}, tc.args...)...)
+		assert.EqualError(t, res.Err, tc.errMsg)
+	}
+
+	setS3Env(t)
+	_, err := newS3Target("https://archive/jira", "")
+	assert.EqualError(t, err, `invalid S3 URL "https://archive/jira", expected s3://bucket/prefix/`)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	_, err = newS3Target("s3://archive/jira", "")
+	assert.EqualError(t, err, "s3: no credentials, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or configure a profile in ~/.aws/credentials")
+}
@@ -0,0 +1,124 @@
+package download
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestDownloadSelectionFlags(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		clock = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	)
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		clock = clock.Add(time.Minute)
+		return clock
+	}))
+	t.Cleanup(server.Close)
+
+	a := server.AddAttachment("TEST-1", "a.png", []byte("\x89PNG\r\n\x1a\na"))
+	server.AddAttachment("TEST-1", "b.png", []byte("\x89PNG\r\n\x1a\nb"))
+	server.AddAttachment("TEST-1", "c.txt", []byte("c"))
+
+	for _, name := range []string{"id", "name", "mime", "mine", "author", "older-than", "where", "latest"} {
+		assert.NotNil(t, NewCmdAttachmentDownload().Flags().Lookup(name), name)
+	}
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": "Cloud"}}
+
+	cases := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{name: "latest image", args: []string{"--mime", "image", "--latest", "1"}, want: []string{"b.png"}},
+		{name: "name glob", args: []string{"--name", "*.png"}, want: []string{"a.png", "b.png"}},
+		{name: "id", args: []string{"--id", a.ID}, want: []string{"a.png"}},
+		{name: "unknown id", args: []string{"--id", "999"}, wantErr: `Unable to download: attachment with ID "999" not found`},
+		{
+			name:    "filename removed by a filter",
+			args:    []string{"c.txt", "--mime", "image"},
+			wantErr: `No attachments matching the filters found for issue "TEST-1", --mime image removed the last 1 of 3`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := t.TempDir()
+			args := append([]string{"TEST-1"}, tc.args...)
+			res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), append(args, "--output", out)...)
+			if tc.wantErr != "" {
+				assert.EqualError(t, res.Err, tc.wantErr)
+				return
+			}
+			require.NoError(t, res.Err)
+
+			entries, err := os.ReadDir(out)
+			require.NoError(t, err)
+			got := make([]string, 0, len(entries))
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDownloadFilterAndSizeFlags(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	server.AddAttachment("TEST-1", "small.har", []byte("{}"))
+	server.AddAttachment("TEST-1", "large.har", []byte(`{"log": {"entries": []}}`))
+	server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": "Cloud"}}
+
+	cases := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{name: "filter with all", args: []string{"--all", "--filter", "*.har"}, want: []string{"large.har", "small.har"}},
+		{name: "filter and max size", args: []string{"--all", "--filter", "*.har", "--max-size", "10B"}, want: []string{"small.har"}},
+		{name: "min size without all", args: []string{"--min-size", "5"}, want: []string{"large.har", "notes.txt"}},
+		{
+			name:    "nothing matches",
+			args:    []string{"--all", "--filter", "*.har", "--min-size", "1KB"},
+			wantErr: `No attachments matching the filters found for issue "TEST-1", --min-size 1KB removed the last 2 of 3`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := t.TempDir()
+			args := append([]string{"TEST-1"}, tc.args...)
+			res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), append(args, "--output", out)...)
+			if tc.wantErr != "" {
+				assert.EqualError(t, res.Err, tc.wantErr)
+				return
+			}
+			require.NoError(t, res.Err)
+
+			entries, err := os.ReadDir(out)
+			require.NoError(t, err)
+			got := make([]string, 0, len(entries))
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
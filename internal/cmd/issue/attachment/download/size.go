@@ -0,0 +1,52 @@
+package download
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+// maxOffenders is the number of largest attachments listed when the size cap is exceeded.
+const maxOffenders = 5
+
+// errTotalSizeExceeded is returned by checkTotalSize if the metadata
+// of the selected attachments already adds up to more than the cap.
+type errTotalSizeExceeded struct {
+	limit     int64
+	total     int64
+	offenders []jira.Attachment
+}
+
+func (e *errTotalSizeExceeded) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "the selected attachments add up to %s, more than the --max-total-size of %s. Largest attachments:",
+		cmdutil.FormatSize(e.total), cmdutil.FormatSize(e.limit))
+	for _, a := range e.offenders {
+		fmt.Fprintf(&b, "\n  - %q (%s)", a.Filename, cmdutil.FormatSize(a.Size))
+	}
+	return b.String()
+}
+
+// checkTotalSize sums the sizes from the attachment metadata and fails if the total exceeds
+// the limit. Attachments without a size are not counted, the cap is enforced for them while
+// they are downloaded.
+func checkTotalSize(attachments []jira.Attachment, limit int64) error {
+	var total int64
+	for _, a := range attachments {
+		total += a.Size
+	}
+	if total <= limit {
+		return nil
+	}
+
+	sorted := append([]jira.Attachment(nil), attachments...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Size > sorted[j].Size })
+	if len(sorted) > maxOffenders {
+		sorted = sorted[:maxOffenders]
+	}
+	return &errTotalSizeExceeded{limit: limit, total: total, offenders: sorted}
+}
@@ -0,0 +1,85 @@
+package download
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestCheckTotalSize(t *testing.T) {
+	t.Parallel()
+
+	attachments := []jira.Attachment{
+		{Filename: "a.bin", Size: 100},
+		{Filename: "b.bin", Size: 400},
+		{Filename: "c.bin"},
+		{Filename: "d.bin", Size: 300},
+		{Filename: "e.bin", Size: 10},
+		{Filename: "f.bin", Size: 20},
+		{Filename: "g.bin", Size: 30},
+	}
+
+	assert.NoError(t, checkTotalSize(attachments, 860))
+
+	err := checkTotalSize(attachments, 859)
+
+	var sizeErr *errTotalSizeExceeded
+	assert.True(t, errors.As(err, &sizeErr))
+	assert.Equal(t, int64(860), sizeErr.total)
+
+	names := make([]string, 0, len(sizeErr.offenders))
+	for _, a := range sizeErr.offenders {
+		names = append(names, a.Filename)
+	}
+	assert.Equal(t, []string{"b.bin", "d.bin", "a.bin", "g.bin", "f.bin"}, names)
+	assert.Contains(t, err.Error(), "860 B, more than the --max-total-size of 859 B")
+	assert.Contains(t, err.Error(), `"b.bin" (400 B)`)
+}
+
+func TestDownloadAttachmentsSizeCap(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	// The server doesn't advertise sizes, so the cap is only hit while downloading.
+	server.SetFaults(jiratest.Faults{OmitContentLength: true})
+
+	attachments := make([]jira.Attachment, 0, 3)
+	for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+		a := server.AddAttachment("TEST-1", name, []byte(strings.Repeat(name[:1], 100)))
+		a.Size = 0
+		attachments = append(attachments, a)
+	}
+
+	dir := t.TempDir()
+	resolver := &conflictResolver{policy: conflictFail, exists: fileExists, warn: func(string, ...any) {}}
+	err := downloadAttachments(server.Client(), attachments, &downloadParams{outputDir: dir, maxTotal: 150}, resolver)
+
+	var capErr *jira.ErrSizeCapExceeded
+	assert.True(t, errors.As(err, &capErr), "got %v", err)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "a.bin", entries[0].Name())
+
+	_, err = os.Stat(filepath.Join(dir, "b.bin"))
+	assert.True(t, os.IsNotExist(err), "partial file must be removed")
+
+	// c.bin is never requested.
+	var downloads int
+	for _, r := range server.Requests() {
+		if strings.HasPrefix(r.Path, "/secure/attachment/") {
+			downloads++
+		}
+	}
+	assert.Equal(t, 2, downloads)
+}
@@ -0,0 +1,20 @@
+package download
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+)
+
+func TestDownloadInvalidStallTimeoutBeforeRequests(t *testing.T) {
+	for _, v := range []string{"soon", "-5s"} {
+		server := newModeServer(t)
+		env := cmdtest.Env{Client: server.Client()}
+
+		res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--stall-timeout", v)
+		assert.EqualError(t, res.Err, `Invalid --stall-timeout duration "`+v+`", eg: 30s`)
+		assert.Empty(t, server.Requests())
+	}
+}
@@ -0,0 +1,31 @@
+package download
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// downloadStdout streams the content of the attachment to stdout as is, eg: to pipe it into
+// another tool. The spinner goes to stderr and nothing else is printed, so that stdout only
+// carries the content.
+func downloadStdout(client *jira.Client, a jira.Attachment, params *downloadParams, stdout io.Writer) error {
+	var budget *jira.ByteBudget
+	if params.maxTotal > 0 {
+		budget = jira.NewByteBudget(params.maxTotal)
+	}
+
+	s := cmdutil.Info(fmt.Sprintf("Downloading %s", a.Filename))
+	res, err := api.ProxyDownloadAttachmentToContextVersion(params.requestContext(), client, params.apiVersion, a, stdout, downloadOptions(a, params, budget)...)
+	s.Stop()
+	if err != nil {
+		return err
+	}
+
+	params.tally.downloaded(res.Bytes)
+	cmdutil.Log().Infof("Wrote %d byte(s) of %q to stdout", res.Bytes, a.Filename)
+	return nil
+}
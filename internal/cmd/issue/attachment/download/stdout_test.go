@@ -0,0 +1,64 @@
+package download
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestDownloadStdout(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	binary := []byte("\x00\x01\r\n\xff\xfe line\r\n")
+	server.AddAttachment("TEST-1", "stdout-app.log", []byte("INFO start\nERROR failed\n"))
+	server.AddAttachment("TEST-1", "stdout-core.bin", binary)
+	env := cmdtest.Env{Client: server.Client()}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "stdout-app.log", "--stdout")
+	require.NoError(t, res.Err)
+	assert.Equal(t, "INFO start\nERROR failed\n", res.Stdout)
+	assert.NotContains(t, res.Stderr, "Downloaded")
+	_, err := os.Stat("stdout-app.log")
+	assert.True(t, os.IsNotExist(err), "no file is written")
+
+	// Binary content is passed through as is.
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--name", "*.bin", "--stdout")
+	require.NoError(t, res.Err)
+	assert.Equal(t, string(binary), res.Stdout)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--name", "stdout-*", "--stdout")
+	assert.EqualError(t, res.Err, "--stdout writes a single attachment but 2 match, narrow the selection with --id, --name or --index")
+	assert.Empty(t, res.Stdout)
+}
+
+func TestDownloadStdoutFlags(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "all", args: []string{"TEST-1", "--all", "--stdout"}, err: "--stdout writes a single attachment, it can't be combined with --all"},
+		{name: "tar", args: []string{"TEST-1", "a.txt", "--stdout", "--tar", "-"}, err: "--stdout can't be combined with --tar"},
+		{name: "output", args: []string{"TEST-1", "a.txt", "--stdout", "--output", "out"}, err: "--stdout can't be combined with"},
+		{name: "eol", args: []string{"TEST-1", "a.txt", "--stdout", "--eol", "lf"}, err: "--stdout can't be combined with"},
+		{name: "jql", args: []string{"--jql", "project = TEST", "--id", "1", "--stdout"}, err: "--jql can't be combined with --tar, --s3-url, --include-subtasks or --stdout"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+			t.Cleanup(server.Close)
+
+			res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentDownload(), tc.args...)
+			require.Error(t, res.Err)
+			assert.Contains(t, res.Err.Error(), tc.err)
+			assert.Empty(t, server.Requests())
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package download
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestDownloadBatchesPerIssueDirectories(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	batches := []cmdcommon.IssueAttachments{
+		{Issue: "TEST-1", Attachments: []jira.Attachment{server.AddAttachment("TEST-1", "spec.txt", []byte("parent"))}},
+		{Issue: "TEST-2"},
+		{Issue: "TEST-3", Attachments: []jira.Attachment{server.AddAttachment("TEST-3", "spec.txt", []byte("subtask"))}},
+	}
+
+	dir := t.TempDir()
+	resolver := &conflictResolver{policy: conflictFail, exists: fileExists, warn: func(string, ...any) {}}
+	err := downloadBatches(server.Client(), batches, &downloadParams{outputDir: dir, includeSubtasks: true}, resolver)
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(filepath.Join(dir, "TEST-1", "spec.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "parent", string(got))
+
+	got, err = os.ReadFile(filepath.Join(dir, "TEST-3", "spec.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "subtask", string(got))
+
+	_, err = os.Stat(filepath.Join(dir, "TEST-2"))
+	assert.True(t, os.IsNotExist(err), "no directory for an issue without attachments")
+}
+
+func TestDownloadBatchesShareSizeCap(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	defer server.Close()
+	server.SetFaults(jiratest.Faults{OmitContentLength: true})
+
+	batches := make([]cmdcommon.IssueAttachments, 0, 2)
+	for _, key := range []string{"TEST-1", "TEST-2"} {
+		a := server.AddAttachment(key, "a.bin", []byte(strings.Repeat("a", 100)))
+		a.Size = 0
+		batches = append(batches, cmdcommon.IssueAttachments{Issue: key, Attachments: []jira.Attachment{a}})
+	}
+
+	dir := t.TempDir()
+	resolver := &conflictResolver{policy: conflictFail, exists: fileExists, warn: func(string, ...any) {}}
+	err := downloadBatches(server.Client(), batches, &downloadParams{outputDir: dir, maxTotal: 150, includeSubtasks: true}, resolver)
+
+	var capErr *jira.ErrSizeCapExceeded
+	assert.True(t, errors.As(err, &capErr), "got %v", err)
+
+	_, err = os.Stat(filepath.Join(dir, "TEST-1", "a.bin"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "TEST-2", "a.bin"))
+	assert.True(t, os.IsNotExist(err))
+}
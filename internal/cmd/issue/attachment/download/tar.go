@@ -0,0 +1,205 @@
+package download
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// tarStdout is the --tar value that writes the archive to stdout.
+const tarStdout = "-"
+
+// tarArchive writes downloaded attachments into a tar stream as they download, without
+// keeping them on disk. Entries whose size isn't known from the metadata are buffered in
+// a temporary file first, as the size of a tar entry is written before its content.
+type tarArchive struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+	// mode is the permission of the entries.
+	mode os.FileMode
+	// taken holds the entry names already written.
+	taken map[string]bool
+	// idPrefix prefixes the entry names with the attachment ID, as --use-id-prefix.
+	idPrefix bool
+	// now is the mtime of entries without a created date.
+	now func() time.Time
+}
+
+func newTarArchive(w io.Writer, gz bool, mode os.FileMode) *tarArchive {
+	a := &tarArchive{taken: make(map[string]bool), mode: mode, now: time.Now}
+	if a.mode == 0 {
+		a.mode = jira.DefaultAttachmentFileMode
+	}
+	if gz {
+		a.gz = gzip.NewWriter(w)
+		w = a.gz
+	}
+	a.tw = tar.NewWriter(w)
+	return a
+}
+
+// fetchFunc streams the content of an attachment to w.
+type fetchFunc func(w io.Writer) (*jira.DownloadResult, error)
+
+// add writes the attachment as an entry in dir, "" for the root of the archive, and
+// returns the name of the entry.
+func (t *tarArchive) add(dir string, a jira.Attachment, fetch fetchFunc) (string, error) {
+	name := t.entryName(dir, a)
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(t.mode.Perm()),
+		ModTime:  t.now(),
+	}
+	if created, ok := a.CreatedTime(); ok {
+		hdr.ModTime = created
+	}
+
+	if a.Size <= 0 {
+		return name, t.addBuffered(hdr, fetch)
+	}
+
+	hdr.Size = a.Size
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return name, err
+	}
+	res, err := fetch(t.tw)
+	if err != nil {
+		return name, err
+	}
+	if res.Bytes != a.Size {
+		return name, fmt.Errorf("received %d bytes for %q, expected %d from its metadata, the archive is incomplete", res.Bytes, a.Filename, a.Size)
+	}
+	return name, nil
+}
+
+// addBuffered downloads the attachment to a temporary file to know its size before
+// writing the entry.
+func (t *tarArchive) addBuffered(hdr *tar.Header, fetch fetchFunc) error {
+	tmp, err := os.CreateTemp("", "jira-tar-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	res, err := fetch(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	hdr.Size = res.Bytes
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.CopyN(t.tw, tmp, res.Bytes)
+	return err
+}
+
+// entryName returns the name of the entry of an attachment. The filename is sanitized
+// so that an entry can't be extracted outside of the target directory, and a name already
+// in the archive gets a " (N)" suffix like --on-conflict rename.
+func (t *tarArchive) entryName(dir string, a jira.Attachment) string {
+	base := cmdutil.SanitizeFilename(a.Filename, "attachment-"+a.ID)
+	if t.idPrefix {
+		base = a.ID + "-" + base
+	}
+
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	name := path.Join(dir, base)
+	for i := 1; t.taken[name]; i++ {
+		name = path.Join(dir, fmt.Sprintf("%s (%d)%s", stem, i, ext))
+	}
+	t.taken[name] = true
+	return name
+}
+
+// Close writes the end of the archive.
+func (t *tarArchive) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	if t.gz != nil {
+		return t.gz.Close()
+	}
+	return nil
+}
+
+// downloadTar downloads the attachments into the archive given with --tar, stdout for "-".
+// With subtasks included, the attachments of each issue go to a directory named after
+// the issue key. Progress is printed to stderr so that stdout only carries the archive.
+func downloadTar(client *jira.Client, batches []cmdcommon.IssueAttachments, params *downloadParams, stdout io.Writer) (err error) {
+	out, label := stdout, "stdout"
+	if params.tar != tarStdout {
+		f, oErr := os.OpenFile(params.tar, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, params.mode)
+		if oErr != nil {
+			return oErr
+		}
+		defer func() {
+			if cErr := f.Close(); err == nil {
+				err = cErr
+			}
+			// An interrupted archive is truncated, it isn't left behind.
+			if errors.Is(err, context.Canceled) {
+				_ = os.Remove(params.tar)
+			}
+		}()
+		out, label = f, params.tar
+	}
+
+	var budget *jira.ByteBudget
+	if params.maxTotal > 0 {
+		budget = jira.NewByteBudget(params.maxTotal)
+	}
+
+	archive := newTarArchive(out, params.tarGz, params.mode)
+	archive.idPrefix = params.idPrefix
+	for _, b := range batches {
+		var dir string
+		if params.includeSubtasks {
+			dir = b.Issue
+		}
+		for _, a := range b.Attachments {
+			name, err := func() (string, error) {
+				s := cmdutil.Info(fmt.Sprintf("Downloading %s", a.Filename))
+				defer s.Stop()
+
+				var bytes int64
+				name, err := archive.add(dir, a, func(w io.Writer) (*jira.DownloadResult, error) {
+					res, err := api.ProxyDownloadAttachmentToContextVersion(params.requestContext(), client, params.apiVersion, a, w, downloadOptions(a, params, budget)...)
+					if res != nil {
+						bytes = res.Bytes
+					}
+					return res, err
+				})
+				if err == nil {
+					params.tally.downloaded(bytes)
+				}
+				return name, err
+			}()
+			if err != nil {
+				return err
+			}
+			cmdutil.SuccessStderr("Added %q to %s as %s", a.Filename, label, name)
+		}
+	}
+	return archive.Close()
+}
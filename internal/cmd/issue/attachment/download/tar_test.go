@@ -0,0 +1,171 @@
+package download
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+type tarEntry struct {
+	content string
+	modTime time.Time
+	mode    int64
+}
+
+// extractTar reads all entries of a tar stream, gunzipping it first if gz is set.
+func extractTar(t *testing.T, r io.Reader, gz bool) (map[string]tarEntry, []string) {
+	t.Helper()
+
+	if gz {
+		zr, err := gzip.NewReader(r)
+		require.NoError(t, err)
+		r = zr
+	}
+
+	entries := make(map[string]tarEntry)
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries[hdr.Name] = tarEntry{content: string(data), modTime: hdr.ModTime, mode: hdr.Mode}
+		names = append(names, hdr.Name)
+	}
+	return entries, names
+}
+
+func serve(content string) fetchFunc {
+	return func(w io.Writer) (*jira.DownloadResult, error) {
+		n, err := io.WriteString(w, content)
+		return &jira.DownloadResult{Bytes: int64(n)}, err
+	}
+}
+
+func TestTarArchive(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	archive := newTarArchive(&buf, false, 0o640)
+	archive.now = func() time.Time { return now }
+
+	cases := []struct {
+		dir      string
+		a        jira.Attachment
+		content  string
+		wantName string
+	}{
+		{a: jira.Attachment{ID: "1", Filename: "a.txt", Size: 5, Created: "2024-01-31T10:00:00.000+0000"}, content: "known", wantName: "a.txt"},
+		// The size is unknown from the metadata, the entry is buffered.
+		{a: jira.Attachment{ID: "2", Filename: "b.log", Created: "2024-02-01T10:00:00.000+0100"}, content: "unknown size", wantName: "b.log"},
+		{a: jira.Attachment{ID: "3", Filename: "a.txt", Size: 3}, content: "dup", wantName: "a (1).txt"},
+		{a: jira.Attachment{ID: "4", Filename: `..\..\etc/passwd`, Size: 1}, content: "x", wantName: "passwd"},
+		{a: jira.Attachment{ID: "5", Filename: "..", Size: 1}, content: "y", wantName: "attachment-5"},
+		{dir: "TEST-2", a: jira.Attachment{ID: "6", Filename: "a.txt", Size: 2}, content: "st", wantName: "TEST-2/a.txt"},
+	}
+	for _, tc := range cases {
+		name, err := archive.add(tc.dir, tc.a, serve(tc.content))
+		require.NoError(t, err)
+		assert.Equal(t, tc.wantName, name)
+	}
+	require.NoError(t, archive.Close())
+
+	entries, names := extractTar(t, &buf, false)
+	require.Len(t, names, len(cases))
+	for i, tc := range cases {
+		assert.Equal(t, tc.wantName, names[i])
+		e := entries[tc.wantName]
+		assert.Equal(t, tc.content, e.content, tc.wantName)
+		assert.Equal(t, int64(0o640), e.mode, tc.wantName)
+
+		want := now
+		if created, ok := tc.a.CreatedTime(); ok {
+			want = created
+		}
+		assert.True(t, want.Equal(e.modTime), "%s: mtime %s, want %s", tc.wantName, e.modTime, want)
+	}
+}
+
+func TestTarArchiveSizeMismatch(t *testing.T) {
+	t.Parallel()
+
+	archive := newTarArchive(io.Discard, false, 0)
+	_, err := archive.add("", jira.Attachment{Filename: "a.txt", Size: 10}, serve("short"))
+	assert.EqualError(t, err, `received 5 bytes for "a.txt", expected 10 from its metadata, the archive is incomplete`)
+}
+
+func TestDownloadTarBatches(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	t.Cleanup(server.Close)
+	server.SetFaults(jiratest.Faults{OmitContentLength: true})
+
+	parent := server.AddAttachment("TEST-1", "spec.txt", []byte("parent"))
+	subtask := server.AddAttachment("TEST-2", "spec.txt", []byte("subtask"))
+	subtask.Size = 0
+
+	path := filepath.Join(t.TempDir(), "out.tar.gz")
+	params := &downloadParams{tar: path, tarGz: true, includeSubtasks: true, mode: 0o600}
+	err := downloadTar(server.Client(), []cmdcommon.IssueAttachments{
+		{Issue: "TEST-1", Attachments: []jira.Attachment{parent}},
+		{Issue: "TEST-2", Attachments: []jira.Attachment{subtask}},
+	}, params, io.Discard)
+	require.NoError(t, err)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	entries, names := extractTar(t, f, true)
+	assert.Equal(t, []string{"TEST-1/spec.txt", "TEST-2/spec.txt"}, names)
+	assert.Equal(t, "parent", entries["TEST-1/spec.txt"].content)
+	assert.Equal(t, "subtask", entries["TEST-2/spec.txt"].content)
+}
+
+func TestDownloadTarStdout(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithClock(func() time.Time {
+		return time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	}))
+	t.Cleanup(server.Close)
+
+	server.AddAttachment("TEST-1", "a.txt", []byte("alpha"))
+	server.AddAttachment("TEST-1", "b.txt", []byte("bravo"))
+	env := cmdtest.Env{Client: server.Client()}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--tar", "-")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stderr, `Added "a.txt" to stdout as a.txt`)
+
+	entries, names := extractTar(t, bytes.NewReader([]byte(res.Stdout)), false)
+	assert.Equal(t, []string{"a.txt", "b.txt"}, names)
+	assert.Equal(t, "alpha", entries["a.txt"].content)
+	assert.Equal(t, "bravo", entries["b.txt"].content)
+	assert.True(t, time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC).Equal(entries["a.txt"].modTime))
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--tar-gz")
+	assert.EqualError(t, res.Err, "--tar-gz requires --tar")
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--tar", "-", "--output", "dir")
+	assert.EqualError(t, res.Err, "--tar can't be combined with --output, --eol, --on-conflict, --parallel-ranges, --provenance or --verify-after")
+}
@@ -0,0 +1,320 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/audit"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// Config keys of the download type policy.
+const (
+	allowTypesKey = "attachment.download_allow_types"
+	denyTypesKey  = "attachment.download_deny_types"
+)
+
+// auditUnsafeAllow is the audit log action of a download allowed by --unsafe-allow.
+const auditUnsafeAllow = "attachment.download.unsafe_allow"
+
+// Phases of the type check.
+const (
+	// phaseMetadata checks the filename and the mime type of the metadata before the transfer.
+	phaseMetadata = "metadata"
+	// phaseContent checks the type sniffed from the head of the content before it is written.
+	phaseContent = "content"
+)
+
+// typePattern is a pattern of the type policy, either an extension, eg: .exe, or a mime
+// type pattern, eg: application/x-*.
+type typePattern struct {
+	raw  string
+	ext  string
+	mime string
+}
+
+// parseTypePatterns parses the patterns of a list, values may hold comma separated patterns.
+func parseTypePatterns(source string, values []string) ([]typePattern, error) {
+	var patterns []typePattern
+	for _, v := range values {
+		for _, raw := range strings.Split(v, ",") {
+			p := strings.ToLower(strings.TrimSpace(raw))
+			switch {
+			case p == "":
+				continue
+			case strings.HasPrefix(p, "*.") || strings.HasPrefix(p, "."):
+				patterns = append(patterns, typePattern{raw: raw, ext: strings.TrimPrefix(p, "*")})
+				continue
+			case strings.Contains(p, "/"):
+				if _, err := path.Match(p, ""); err == nil {
+					patterns = append(patterns, typePattern{raw: raw, mime: p})
+					continue
+				}
+			}
+			return nil, cmdutil.Errorf("Invalid pattern %q in %s, expected a mime type like image/* or an extension like .exe", strings.TrimSpace(raw), source)
+		}
+	}
+	return patterns, nil
+}
+
+// matches reports if the pattern matches the filename or the mime type. Extensions are
+// only matched if the filename is given.
+func (p typePattern) matches(filename, mimeType string) bool {
+	if p.ext != "" {
+		return filename != "" && strings.HasSuffix(strings.ToLower(filename), p.ext)
+	}
+	return mimeType != "" && matchMime(p.mime, mimeType)
+}
+
+func matchMime(pattern, mimeType string) bool {
+	mt, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mt = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+	ok, _ := path.Match(pattern, mt)
+	return ok
+}
+
+func firstMatch(patterns []typePattern, filename, mimeType string) (typePattern, bool) {
+	for _, p := range patterns {
+		if p.matches(filename, mimeType) {
+			return p, true
+		}
+	}
+	return typePattern{}, false
+}
+
+// typeDeniedError is returned for an attachment the type policy doesn't allow to write.
+type typeDeniedError struct {
+	Filename string
+	Type     string
+	Phase    string
+	Reason   string
+}
+
+func (e *typeDeniedError) Error() string {
+	what := "its type"
+	if e.Type != "" {
+		what = e.Type
+	}
+	if e.Phase == phaseContent {
+		what = "its content, sniffed as " + e.Type
+	}
+	return fmt.Sprintf("%q is denied, %s %s", e.Filename, what, e.Reason)
+}
+
+// typePolicy decides which attachments may be written to disk with the allow and deny
+// lists of the config, eg: to never write executables or scripts in locked-down
+// environments. Each attachment is checked twice: with its filename and the mime type
+// of its metadata before the transfer, and with the type sniffed from the head of its
+// content before any of it is written, to catch mislabeled files. A nil policy allows
+// everything. It is safe for concurrent use.
+type typePolicy struct {
+	allow, deny []typePattern
+	// unsafeAllow are the patterns of --unsafe-allow that lift a denial.
+	unsafeAllow []typePattern
+	audit       *audit.Logger
+
+	mu     sync.Mutex
+	denied []string
+}
+
+// newTypePolicy returns the policy of the config and --unsafe-allow patterns, nil if no
+// list is configured.
+func newTypePolicy(unsafeAllow []string) (*typePolicy, error) {
+	allow, err := parseTypePatterns(allowTypesKey, viper.GetStringSlice(allowTypesKey))
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseTypePatterns(denyTypesKey, viper.GetStringSlice(denyTypesKey))
+	if err != nil {
+		return nil, err
+	}
+	unsafe, err := parseTypePatterns("--unsafe-allow", unsafeAllow)
+	if err != nil {
+		return nil, err
+	}
+	if len(allow) == 0 && len(deny) == 0 {
+		if len(unsafe) > 0 {
+			cmdutil.Warn("--unsafe-allow has no effect, neither %s nor %s is configured", allowTypesKey, denyTypesKey)
+		}
+		return nil, nil
+	}
+	return &typePolicy{allow: allow, deny: deny, unsafeAllow: unsafe, audit: cmdcommon.AuditLogger()}, nil
+}
+
+// rule returns why an attachment of the given type is denied, "" if it is allowed. With
+// withExt unset the filename isn't considered, eg: as it was already checked.
+func (p *typePolicy) rule(filename, mimeType string, withExt bool) string {
+	name := filename
+	if !withExt {
+		name = ""
+	}
+	if m, ok := firstMatch(p.deny, name, mimeType); ok {
+		return fmt.Sprintf("matches %q of %s", strings.TrimSpace(m.raw), denyTypesKey)
+	}
+	if len(p.allow) == 0 {
+		return ""
+	}
+	if !withExt && !hasMimePatterns(p.allow) {
+		return ""
+	}
+	if _, ok := firstMatch(p.allow, name, mimeType); !ok {
+		return "isn't in " + allowTypesKey
+	}
+	return ""
+}
+
+func hasMimePatterns(patterns []typePattern) bool {
+	for _, p := range patterns {
+		if p.mime != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMetadata checks the filename and the mime type of the metadata of the attachment
+// of issue, before it is downloaded.
+func (p *typePolicy) checkMetadata(issue string, a jira.Attachment) error {
+	if p == nil {
+		return nil
+	}
+	return p.decide(issue, a, a.MimeType, phaseMetadata, p.rule(a.Filename, a.MimeType, true))
+}
+
+// checkContent checks the type sniffed from the head of the content of the attachment.
+// The generic types the sniffer falls back to when it can't tell are only checked
+// against the deny list.
+func (p *typePolicy) checkContent(issue string, a jira.Attachment, head []byte) error {
+	if p == nil {
+		return nil
+	}
+	sniffed := sniffType(head)
+	var rule string
+	if isGenericType(sniffed) {
+		if m, ok := firstMatch(p.deny, "", sniffed); ok {
+			rule = fmt.Sprintf("matches %q of %s", strings.TrimSpace(m.raw), denyTypesKey)
+		}
+	} else {
+		rule = p.rule(a.Filename, sniffed, false)
+	}
+	return p.decide(issue, a, sniffed, phaseContent, rule)
+}
+
+// decide denies the attachment if a rule applies, unless an --unsafe-allow pattern lifts
+// the denial, which is warned about and recorded in the audit log.
+func (p *typePolicy) decide(issue string, a jira.Attachment, mimeType, phase, rule string) error {
+	if rule == "" {
+		return nil
+	}
+
+	denied := &typeDeniedError{Filename: a.Filename, Type: mimeType, Phase: phase, Reason: rule}
+	if m, ok := firstMatch(p.unsafeAllow, a.Filename, mimeType); ok {
+		cmdutil.Warn("Allowing %s with --unsafe-allow %q", denied, strings.TrimSpace(m.raw))
+		err := p.audit.Record(auditUnsafeAllow, map[string]string{
+			"issue":      issue,
+			"attachment": a.ID,
+			"filename":   a.Filename,
+			"type":       mimeType,
+			"phase":      phase,
+			"rule":       rule,
+			"pattern":    strings.TrimSpace(m.raw),
+			"server":     viper.GetString("server"),
+			"login":      viper.GetString("login"),
+		})
+		if err != nil {
+			cmdutil.Warn("Unable to write to the audit log: %s", err)
+		}
+		return nil
+	}
+
+	p.mu.Lock()
+	p.denied = append(p.denied, a.Filename)
+	p.mu.Unlock()
+	return denied
+}
+
+// exclude leaves out the attachments denied by their metadata and reports each one. It
+// returns the number of attachments left out.
+func (p *typePolicy) exclude(batches []cmdcommon.IssueAttachments) ([]cmdcommon.IssueAttachments, int) {
+	if p == nil {
+		return batches, 0
+	}
+
+	var excluded int
+	out := make([]cmdcommon.IssueAttachments, 0, len(batches))
+	for _, b := range batches {
+		allowed := make([]jira.Attachment, 0, len(b.Attachments))
+		for _, a := range b.Attachments {
+			if err := p.checkMetadata(b.Issue, a); err != nil {
+				cmdutil.Fail("Skipped %s", err)
+				excluded++
+				continue
+			}
+			allowed = append(allowed, a)
+		}
+		out = append(out, cmdcommon.IssueAttachments{Issue: b.Issue, Attachments: allowed})
+	}
+	return out, excluded
+}
+
+// deniedError fails the run with ExitDenied if attachments were denied.
+func (p *typePolicy) deniedError() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.denied) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(p.denied))
+	for _, n := range p.denied {
+		names = append(names, fmt.Sprintf("%q", n))
+	}
+	return cmdutil.ErrorfWithCode(cmdutil.ExitDenied, "%d attachment(s) denied by the download type policy: %s", len(names), strings.Join(names, ", "))
+}
+
+// executableSignatures are the magic numbers of executables and scripts, which
+// http.DetectContentType reports as generic binary or text.
+var executableSignatures = []struct {
+	magic    []byte
+	mimeType string
+}{
+	{[]byte("\x7fELF"), "application/x-executable"},
+	{[]byte("MZ"), "application/x-msdownload"},
+	{[]byte{0xcf, 0xfa, 0xed, 0xfe}, "application/x-mach-binary"},
+	{[]byte{0xce, 0xfa, 0xed, 0xfe}, "application/x-mach-binary"},
+	{[]byte{0xfe, 0xed, 0xfa, 0xcf}, "application/x-mach-binary"},
+	{[]byte{0xfe, 0xed, 0xfa, 0xce}, "application/x-mach-binary"},
+	{[]byte("#!"), "text/x-shellscript"},
+}
+
+// sniffType returns the type of the content from its head.
+func sniffType(head []byte) string {
+	for _, s := range executableSignatures {
+		if bytes.HasPrefix(head, s.magic) {
+			return s.mimeType
+		}
+	}
+	mt, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mt
+}
+
+// isGenericType reports if the sniffer couldn't tell the type of the content.
+func isGenericType(mimeType string) bool {
+	return mimeType == "application/octet-stream" || mimeType == "text/plain"
+}
@@ -0,0 +1,153 @@
+package download
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/audit"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+// elf is the head of a Linux executable.
+var elf = append([]byte("\x7fELF\x02\x01\x01"), make([]byte, 64)...)
+
+func TestParseTypePatterns(t *testing.T) {
+	t.Parallel()
+
+	patterns, err := parseTypePatterns(denyTypesKey, []string{"*.EXE, .sh", "application/x-*"})
+	require.NoError(t, err)
+	require.Len(t, patterns, 3)
+	assert.Equal(t, ".exe", patterns[0].ext)
+	assert.Equal(t, ".sh", patterns[1].ext)
+	assert.Equal(t, "application/x-*", patterns[2].mime)
+
+	assert.True(t, patterns[0].matches("setup.exe", ""))
+	assert.False(t, patterns[0].matches("", "application/x-msdownload"), "extensions only match filenames")
+	assert.True(t, patterns[2].matches("", "application/x-sh; charset=utf-8"))
+	assert.False(t, patterns[2].matches("run.sh", "text/plain"))
+
+	for _, invalid := range []string{"exe", "application/[x"} {
+		_, err := parseTypePatterns(denyTypesKey, []string{invalid})
+		assert.EqualError(t, err, `Invalid pattern "`+invalid+`" in attachment.download_deny_types, expected a mime type like image/* or an extension like .exe`)
+	}
+}
+
+func TestTypePolicyRule(t *testing.T) {
+	t.Parallel()
+
+	allow, err := parseTypePatterns(allowTypesKey, []string{"image/*", ".pdf"})
+	require.NoError(t, err)
+	deny, err := parseTypePatterns(denyTypesKey, []string{"image/svg+xml"})
+	require.NoError(t, err)
+	p := &typePolicy{allow: allow, deny: deny}
+
+	assert.Empty(t, p.rule("cat.png", "image/png", true))
+	assert.Empty(t, p.rule("spec.pdf", "application/pdf", true))
+	assert.Equal(t, `matches "image/svg+xml" of attachment.download_deny_types`, p.rule("logo.svg", "image/svg+xml", true))
+	assert.Equal(t, "isn't in attachment.download_allow_types", p.rule("notes.txt", "text/plain", true))
+}
+
+func TestSniffType(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "application/x-executable", sniffType(elf))
+	assert.Equal(t, "text/x-shellscript", sniffType([]byte("#!/bin/sh\necho hi\n")))
+	assert.Equal(t, "application/pdf", sniffType([]byte("%PDF-1.7\n")))
+	assert.Equal(t, "text/plain", sniffType([]byte("hello")))
+}
+
+func TestDownloadDeniedByMetadata(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	server.AddAttachment("TEST-1", "install.sh", []byte("#!/bin/sh\n"))
+	server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{denyTypesKey: []string{".sh"}}}
+	out := t.TempDir()
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--output", out)
+	require.Error(t, res.Err)
+	assert.EqualError(t, res.Err, `1 attachment(s) denied by the download type policy: "install.sh"`)
+	assert.Equal(t, cmdutil.ExitDenied, cmdutil.ExitCode(res.Err))
+	assert.Contains(t, res.Stderr, `Skipped "install.sh" is denied`)
+
+	assert.FileExists(t, filepath.Join(out, "notes.txt"), "the allowed attachments are downloaded")
+	assert.NoFileExists(t, filepath.Join(out, "install.sh"))
+	for _, r := range server.Requests() {
+		assert.NotContains(t, r.Path, "install.sh", "denied attachments aren't requested")
+	}
+}
+
+func TestDownloadDeniedByContent(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	// Labelled as a PDF, the content is an executable.
+	a := server.AddAttachment("TEST-1", "report.pdf", elf)
+	require.Equal(t, "application/pdf", a.MimeType)
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{denyTypesKey: []string{"application/x-executable"}}}
+	out := t.TempDir()
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "report.pdf", "--output", out)
+	require.Error(t, res.Err)
+	assert.Equal(t, cmdutil.ExitDenied, cmdutil.ExitCode(res.Err))
+	assert.Contains(t, res.Stderr, `Aborted "report.pdf" is denied, its content, sniffed as application/x-executable matches "application/x-executable"`)
+
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing is left of the denied download")
+}
+
+func TestDownloadUnsafeAllow(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	a := server.AddAttachment("TEST-1", "report.pdf", elf)
+
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{
+		denyTypesKey: []string{"application/x-executable"},
+		"audit.log":  auditLog,
+		"login":      "jane@example.com",
+	}}
+	out := t.TempDir()
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "report.pdf", "--output", out, "--unsafe-allow", "application/x-executable")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stderr, `Allowing "report.pdf" is denied`)
+
+	b, err := os.ReadFile(filepath.Join(out, "report.pdf"))
+	require.NoError(t, err)
+	assert.Equal(t, elf, b)
+
+	logged, err := os.ReadFile(auditLog)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(logged), "\n"), "\n")
+	require.Len(t, lines, 1)
+
+	var e audit.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &e))
+	assert.Equal(t, auditUnsafeAllow, e.Action)
+	assert.Equal(t, "TEST-1", e.Fields["issue"])
+	assert.Equal(t, a.ID, e.Fields["attachment"])
+	assert.Equal(t, phaseContent, e.Fields["phase"])
+	assert.Equal(t, "application/x-executable", e.Fields["pattern"])
+	assert.Equal(t, "jane@example.com", e.Fields["login"])
+}
+
+func TestDownloadInvalidTypePatternBeforeRequests(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{allowTypesKey: []string{"pdf"}}}
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--output", t.TempDir())
+	assert.EqualError(t, res.Err, `Invalid pattern "pdf" in attachment.download_allow_types, expected a mime type like image/* or an extension like .exe`)
+	assert.Empty(t, server.Requests())
+}
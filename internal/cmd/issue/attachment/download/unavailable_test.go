@@ -0,0 +1,97 @@
+package download
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/selector"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+var partialAttachments = []jira.Attachment{
+	{ID: "1", Filename: "ok.txt", Content: "https://example.com/1"},
+	{ID: "2", Filename: "archived.txt"},
+}
+
+func TestPickAttachmentsUnavailable(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		params  downloadParams
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "all keeps unavailable for the notice",
+			params: downloadParams{all: true, selector: &selector.Selector{}},
+			want:   []string{"ok.txt", "archived.txt"},
+		},
+		{
+			name:    "by id",
+			params:  downloadParams{selector: &selector.Selector{ID: "2"}},
+			wantErr: `attachment "archived.txt" (ID: 2) is unavailable, the issue may be archived or the attachment restricted`,
+		},
+		{
+			name:    "by filename",
+			params:  downloadParams{selector: &selector.Selector{Filename: "archived.txt"}},
+			wantErr: `attachment "archived.txt" (ID: 2) is unavailable`,
+		},
+		{
+			name:   "available by filename",
+			params: downloadParams{selector: &selector.Selector{Filename: "ok.txt"}},
+			want:   []string{"ok.txt"},
+		},
+		{
+			name:    "missing id",
+			params:  downloadParams{selector: &selector.Selector{ID: "3"}},
+			wantErr: `attachment with ID "3" not found`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := pickAttachments(partialAttachments, &tc.params)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			names := make([]string, 0, len(got))
+			for _, a := range got {
+				names = append(names, a.Filename)
+			}
+			assert.Equal(t, tc.want, names)
+		})
+	}
+}
+
+func TestExcludeUnavailable(t *testing.T) {
+	t.Parallel()
+
+	var notices []string
+	batches, skipped := excludeUnavailable([]cmdcommon.IssueAttachments{
+		{Issue: "TEST-1", Attachments: partialAttachments},
+		{Issue: "TEST-2", Attachments: []jira.Attachment{{ID: "3", Filename: "restricted.pdf"}}},
+	}, func(format string, a ...any) {
+		notices = append(notices, fmt.Sprintf(format, a...))
+	})
+
+	assert.Equal(t, 2, skipped)
+	assert.Equal(t, []cmdcommon.IssueAttachments{
+		{Issue: "TEST-1", Attachments: partialAttachments[:1]},
+		{Issue: "TEST-2", Attachments: []jira.Attachment{}},
+	}, batches)
+	assert.Equal(t, []string{
+		`Skipping "archived.txt" (ID: 2) of TEST-1, the attachment is unavailable`,
+		`Skipping "restricted.pdf" (ID: 3) of TEST-2, the attachment is unavailable`,
+	}, notices)
+}
@@ -0,0 +1,187 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// verifyOutcome is what the server says about an attachment once it is downloaded.
+type verifyOutcome int
+
+const (
+	// verifyUnchanged is an attachment with the size and created date it was listed with.
+	verifyUnchanged verifyOutcome = iota
+	// verifyChanged is an attachment replaced on the server since it was listed.
+	verifyChanged
+	// verifyDeleted is an attachment deleted from the server since it was listed.
+	verifyDeleted
+	// verifyFailed is an attachment whose metadata couldn't be fetched again.
+	verifyFailed
+)
+
+// verification is the outcome of checking a downloaded attachment against the server.
+type verification struct {
+	attachment jira.Attachment
+	outcome    verifyOutcome
+	// diffs describes what changed, eg: "size 10 B -> 12 B".
+	diffs []string
+	err   error
+}
+
+// verifier fetches the metadata of the downloaded attachments again with --verify-after,
+// to detect attachments replaced or deleted on the server during the download. It is safe
+// for concurrent use.
+type verifier struct {
+	get     func(id string) (*jira.Attachment, error)
+	mu      sync.Mutex
+	results []verification
+}
+
+func newVerifier(client *jira.Client, version string) *verifier {
+	return &verifier{get: func(id string) (*jira.Attachment, error) {
+		return api.ProxyGetAttachmentVersion(client, version, id)
+	}}
+}
+
+// check fetches the metadata of the downloaded attachment and compares it to the listed one.
+func (v *verifier) check(before jira.Attachment) verification {
+	after, err := v.get(before.ID)
+	res := classifyVerification(before, after, err)
+	v.record(res)
+	return res
+}
+
+// deleted records an attachment whose download failed as it no longer exists.
+func (v *verifier) deleted(a jira.Attachment) {
+	v.record(verification{attachment: a, outcome: verifyDeleted})
+}
+
+func (v *verifier) record(res verification) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.results = append(v.results, res)
+}
+
+// classifyVerification decides the outcome of fetching the metadata of an attachment after
+// its download. A 404 means it was deleted in the meantime.
+func classifyVerification(before jira.Attachment, after *jira.Attachment, err error) verification {
+	res := verification{attachment: before}
+	switch {
+	case isNotFound(err):
+		res.outcome = verifyDeleted
+	case err != nil:
+		res.outcome, res.err = verifyFailed, err
+	case after == nil:
+		res.outcome, res.err = verifyFailed, jira.ErrEmptyResponse
+	default:
+		if res.diffs = compareAttachment(before, *after); len(res.diffs) > 0 {
+			res.outcome = verifyChanged
+		}
+	}
+	return res
+}
+
+// compareAttachment returns the differences in size and created date of the metadata of an
+// attachment fetched after its download to the one it was listed with. Dates are compared
+// as instants if both can be parsed, so that a different offset isn't reported.
+func compareAttachment(before, after jira.Attachment) []string {
+	var diffs []string
+	if before.Size != after.Size {
+		diffs = append(diffs, fmt.Sprintf("size %s -> %s", cmdutil.FormatSize(before.Size), cmdutil.FormatSize(after.Size)))
+	}
+
+	bt, bOK := before.CreatedTime()
+	at, aOK := after.CreatedTime()
+	sameCreated := before.Created == after.Created
+	if bOK && aOK {
+		sameCreated = bt.Equal(at)
+	}
+	if !sameCreated {
+		diffs = append(diffs, fmt.Sprintf("created %s -> %s", before.CreatedUTC(), after.CreatedUTC()))
+	}
+	return diffs
+}
+
+// isNotFound checks if a request failed because the resource doesn't exist.
+func isNotFound(err error) bool {
+	var uErr *jira.ErrUnexpectedResponse
+	return errors.As(err, &uErr) && uErr.StatusCode == http.StatusNotFound
+}
+
+// report prints the outcome of a verification.
+func (res verification) report() {
+	name := res.attachment.Filename
+	switch res.outcome {
+	case verifyChanged:
+		cmdutil.Warn("%q changed on the server during the download: %s", name, strings.Join(res.diffs, ", "))
+	case verifyDeleted:
+		cmdutil.Warn("%q was deleted from the server during the download", name)
+	case verifyFailed:
+		cmdutil.Warn("Unable to verify %q: %s", name, res.err)
+	}
+}
+
+// verifySummary counts the outcomes of the verifications.
+type verifySummary struct {
+	checked, changed, deleted, failed int
+}
+
+func summarizeVerifications(results []verification) verifySummary {
+	s := verifySummary{checked: len(results)}
+	for _, r := range results {
+		switch r.outcome {
+		case verifyChanged:
+			s.changed++
+		case verifyDeleted:
+			s.deleted++
+		case verifyFailed:
+			s.failed++
+		}
+	}
+	return s
+}
+
+// String describes the summary, eg: "Verified 3 attachment(s): 1 changed, 1 deleted".
+func (s verifySummary) String() string {
+	var parts []string
+	if s.changed > 0 {
+		parts = append(parts, fmt.Sprintf("%d changed", s.changed))
+	}
+	if s.deleted > 0 {
+		parts = append(parts, fmt.Sprintf("%d deleted", s.deleted))
+	}
+	if s.failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d not verified", s.failed))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("Verified %d attachment(s), none changed on the server", s.checked)
+	}
+	return fmt.Sprintf("Verified %d attachment(s): %s", s.checked, strings.Join(parts, ", "))
+}
+
+// finish prints the summary of the verifications. With --strict, attachments that changed
+// or were deleted during the download are an error.
+func (v *verifier) finish(strict bool) error {
+	if v == nil || len(v.results) == 0 {
+		return nil
+	}
+
+	s := summarizeVerifications(v.results)
+	if s.changed+s.deleted+s.failed == 0 {
+		cmdutil.Success("%s", s.String())
+		return nil
+	}
+	cmdutil.Warn("%s", s.String())
+
+	if strict && s.changed+s.deleted > 0 {
+		return cmdutil.Errorf("%d attachment(s) changed or deleted on the server during the download", s.changed+s.deleted)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package download
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestCompareAttachment(t *testing.T) {
+	t.Parallel()
+
+	before := jira.Attachment{ID: "1", Size: 10, Created: "2026-03-01T10:00:00.000+0000"}
+
+	cases := []struct {
+		name  string
+		after jira.Attachment
+		want  []string
+	}{
+		{name: "unchanged", after: before},
+		{
+			name:  "same instant in another offset",
+			after: jira.Attachment{ID: "1", Size: 10, Created: "2026-03-01T11:00:00.000+0100"},
+		},
+		{
+			name:  "size",
+			after: jira.Attachment{ID: "1", Size: 2048, Created: before.Created},
+			want:  []string{"size 10 B -> 2.00 KB"},
+		},
+		{
+			name:  "replaced",
+			after: jira.Attachment{ID: "1", Size: 12, Created: "2026-03-02T09:00:00.000+0000"},
+			want:  []string{"size 10 B -> 12 B", "created 2026-03-01T10:00:00Z -> 2026-03-02T09:00:00Z"},
+		},
+		{
+			name:  "unparsable dates compared as is",
+			after: jira.Attachment{ID: "1", Size: 10, Created: "yesterday"},
+			want:  []string{"created 2026-03-01T10:00:00Z -> yesterday [UNPARSED]"},
+		},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, compareAttachment(before, tc.after), tc.name)
+	}
+}
+
+func TestClassifyVerification(t *testing.T) {
+	t.Parallel()
+
+	before := jira.Attachment{ID: "1", Filename: "a.txt", Size: 10, Created: "2026-03-01T10:00:00.000+0000"}
+	changed := before
+	changed.Size = 11
+	notFound := &jira.ErrUnexpectedResponse{Status: "404 Not Found", StatusCode: http.StatusNotFound}
+	forbidden := &jira.ErrUnexpectedResponse{Status: "403 Forbidden", StatusCode: http.StatusForbidden}
+
+	cases := []struct {
+		name    string
+		after   *jira.Attachment
+		err     error
+		want    verifyOutcome
+		wantErr bool
+	}{
+		{name: "unchanged", after: &before, want: verifyUnchanged},
+		{name: "changed", after: &changed, want: verifyChanged},
+		{name: "deleted", err: notFound, want: verifyDeleted},
+		{name: "other error", err: forbidden, want: verifyFailed, wantErr: true},
+		{name: "network error", err: errors.New("connection reset"), want: verifyFailed, wantErr: true},
+		{name: "empty response", want: verifyFailed, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		res := classifyVerification(before, tc.after, tc.err)
+		assert.Equal(t, tc.want, res.outcome, tc.name)
+		assert.Equal(t, tc.wantErr, res.err != nil, tc.name)
+		assert.Equal(t, before, res.attachment, tc.name)
+	}
+}
+
+func TestSummarizeVerifications(t *testing.T) {
+	t.Parallel()
+
+	s := summarizeVerifications([]verification{
+		{outcome: verifyUnchanged},
+		{outcome: verifyChanged},
+		{outcome: verifyDeleted},
+		{outcome: verifyDeleted},
+		{outcome: verifyFailed},
+	})
+	assert.Equal(t, verifySummary{checked: 5, changed: 1, deleted: 2, failed: 1}, s)
+	assert.Equal(t, "Verified 5 attachment(s): 1 changed, 2 deleted, 1 not verified", s.String())
+
+	s = summarizeVerifications([]verification{{outcome: verifyUnchanged}, {outcome: verifyUnchanged}})
+	assert.Equal(t, "Verified 2 attachment(s), none changed on the server", s.String())
+}
+
+// swappingServer serves a fake Jira that calls after once the content of an attachment was served.
+func swappingServer(t *testing.T, after func(s *jiratest.Server, id string)) *jiratest.Server {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		clock = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	)
+	s := jiratest.New(jiratest.WithIssues("TEST-1"), jiratest.WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		clock = clock.Add(time.Minute)
+		return clock
+	}))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.ServeHTTP(w, r)
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/secure/attachment/"); ok {
+			after(s, strings.SplitN(rest, "/", 2)[0])
+		}
+	}))
+	t.Cleanup(ts.Close)
+	s.URL = ts.URL
+	return s
+}
+
+func TestDownloadVerifyAfter(t *testing.T) {
+	var kept, replaced, removed jira.Attachment
+	server := swappingServer(t, func(s *jiratest.Server, id string) {
+		switch id {
+		case replaced.ID:
+			s.ReplaceAttachment(id, []byte("a newer version"))
+		case removed.ID:
+			s.RemoveAttachment(id)
+		}
+	})
+	kept = server.AddAttachment("TEST-1", "kept.txt", []byte("kept"))
+	replaced = server.AddAttachment("TEST-1", "report.txt", []byte("report"))
+	removed = server.AddAttachment("TEST-1", "draft.txt", []byte("draft"))
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"installation": jira.InstallationTypeCloud}}
+	out := t.TempDir()
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--output", out, "--on-conflict", "fail", "--verify-after")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stderr, `"report.txt" changed on the server during the download: size 6 B -> 15 B, created `)
+	assert.Contains(t, res.Stderr, `"draft.txt" was deleted from the server during the download`)
+	assert.NotContains(t, res.Stderr, `"kept.txt" changed`)
+	assert.Contains(t, res.Stderr, "Verified 3 attachment(s): 1 changed, 1 deleted")
+
+	var fetched []string
+	for _, r := range server.Requests() {
+		if strings.HasPrefix(r.Path, "/rest/api/3/attachment/") {
+			fetched = append(fetched, strings.TrimPrefix(r.Path, "/rest/api/3/attachment/"))
+		}
+	}
+	assert.Equal(t, []string{kept.ID, replaced.ID, removed.ID}, fetched)
+}
+
+func TestDownloadVerifyAfterStrict(t *testing.T) {
+	var replaced jira.Attachment
+	server := swappingServer(t, func(s *jiratest.Server, id string) {
+		if id == replaced.ID {
+			s.ReplaceAttachment(id, []byte("a newer version"))
+		}
+	})
+	replaced = server.AddAttachment("TEST-1", "report.txt", []byte("report"))
+
+	env := cmdtest.Env{Client: server.Client()}
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "report.txt", "--output", t.TempDir(), "--verify-after", "--strict")
+	assert.EqualError(t, res.Err, "1 attachment(s) changed or deleted on the server during the download")
+}
+
+func TestDownloadVerifyAfterDeletedBeforeDownload(t *testing.T) {
+	var first, second jira.Attachment
+	server := swappingServer(t, func(s *jiratest.Server, id string) {
+		// Another user deletes the second attachment while the first one is downloaded.
+		if id == first.ID {
+			s.RemoveAttachment(second.ID)
+		}
+	})
+	first = server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+	second = server.AddAttachment("TEST-1", "b.txt", []byte("b"))
+	third := server.AddAttachment("TEST-1", "c.txt", []byte("c"))
+
+	env := cmdtest.Env{Client: server.Client()}
+	out := t.TempDir()
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDownload(), "TEST-1", "--all", "--output", out, "--on-conflict", "fail", "--verify-after")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stderr, `"b.txt" was deleted from the server before it could be downloaded`)
+	assert.Contains(t, res.Stderr, "Verified 3 attachment(s): 1 deleted")
+	assert.FileExists(t, filepath.Join(out, third.Filename))
+	assert.NoFileExists(t, filepath.Join(out, second.Filename))
+}
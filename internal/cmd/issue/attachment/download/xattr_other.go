@@ -0,0 +1,21 @@
+//go:build !linux && !darwin
+
+package download
+
+import "errors"
+
+var errXattrUnsupported = errors.New("extended attributes are not supported on this platform")
+
+// xattrSupported is false on platforms without user extended attributes, the
+// provenance is then written to a sidecar file.
+func xattrSupported(string) bool {
+	return false
+}
+
+func setXattr(string, string, string) error {
+	return errXattrUnsupported
+}
+
+func getXattr(string, string) (string, error) {
+	return "", errXattrUnsupported
+}
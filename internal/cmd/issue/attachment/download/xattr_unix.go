@@ -0,0 +1,42 @@
+//go:build linux || darwin
+
+package download
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
+)
+
+// xattrProbe is the attribute set on a scratch file to probe for extended attribute support.
+const xattrProbe = "user.jira.probe"
+
+// xattrSupported probes if the filesystem of dir supports user extended attributes,
+// eg: tmpfs on older kernels and most network filesystems don't.
+func xattrSupported(dir string) bool {
+	f, err := tmpfile.Create(filepath.Join(dir, "xattr-probe"))
+	if err != nil {
+		return false
+	}
+	name := f.Name()
+	_ = f.Close()
+	defer func() { _ = os.Remove(name) }()
+
+	return unix.Setxattr(name, xattrProbe, []byte("1"), 0) == nil
+}
+
+func setXattr(path, name, value string) error {
+	return unix.Setxattr(path, name, []byte(value), 0)
+}
+
+func getXattr(path, name string) (string, error) {
+	buf := make([]byte, 1024)
+	n, err := unix.Getxattr(path, name, buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
@@ -0,0 +1,248 @@
+package duplicates
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/internal/where"
+	"github.com/ankitpokhrel/jira-cli/pkg/filehash"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	helpText = `Duplicates finds files attached more than once across a project.
+
+Attachments with the same name and size are reported as duplicates, the groups wasting the most
+storage first. Use --verify to download and compare the content of the candidates.`
+	examples = `$ jira issue attachment duplicates --project FOO
+
+# Only look at files of 1MB or more
+$ jira issue attachment duplicates -pFOO --min-size 1MB
+
+# Confirm the duplicates by their content
+$ jira issue attachment duplicates -pFOO --verify
+
+# Print the report as CSV, JSON or YAML
+$ jira issue attachment duplicates -pFOO -o csv
+$ jira issue attachment duplicates -pFOO -o json
+$ jira issue attachment duplicates -pFOO -o yaml`
+
+	// searchPageSize is the number of issues fetched per search request.
+	searchPageSize = 100
+)
+
+// NewCmdAttachmentDuplicates is an attachment duplicates command.
+func NewCmdAttachmentDuplicates() *cobra.Command {
+	cmd := cobra.Command{
+		Use:           "duplicates",
+		Short:         "Find files attached more than once across a project",
+		Long:          helpText,
+		Example:       examples,
+		Aliases:       []string{"dups"},
+		Args:          cobra.NoArgs,
+		RunE:          duplicates,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().String("min-size", "", "Ignore attachments smaller than the size, eg: 1MB")
+	cmd.Flags().Bool("verify", false, "Download the candidates and compare their content")
+	cmd.Flags().Uint("hash-workers", 0, fmt.Sprintf("Number of files hashed at the same time with --verify, 0 for one per CPU up to %d", filehash.MaxDefaultWorkers))
+	cmdutil.RegisterOutputFlag(&cmd,
+		[]string{cmdutil.OutputTable, cmdutil.OutputPlain, cmdutil.OutputCSV, cmdutil.OutputJSON, cmdutil.OutputYAML},
+		cmdutil.OutputAlias{Flag: "plain", Format: cmdutil.OutputPlain, Usage: "Display output in plain mode, without the header"},
+		cmdutil.OutputAlias{Flag: "csv", Format: cmdutil.OutputCSV, Usage: "Print output in CSV format"},
+		cmdutil.OutputAlias{Flag: "json", Format: cmdutil.OutputJSON, Usage: "Print output in JSON format"},
+	)
+
+	return &cmd
+}
+
+func duplicates(cmd *cobra.Command, _ []string) error {
+	params, err := parseArgsAndFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	if params.output, err = cmdutil.ResolveRenderer(cmd); err != nil {
+		return err
+	}
+	version, err := cmdcommon.GetAPIVersion(cmd)
+	if err != nil {
+		return err
+	}
+
+	client := api.DefaultClient(params.debug)
+
+	c := newCollector(params.minSize, params.verify)
+	jql := fmt.Sprintf("project = %q", params.project)
+	err = api.ProxySearchIssueAttachmentsVersion(client, version, jql, searchPageSize, func(issues []*jira.Issue) error {
+		c.add(issues)
+		return nil
+	})
+	if err != nil {
+		return cmdutil.RequestError(err, params.debug)
+	}
+
+	groups := c.candidates()
+	if params.verify && len(groups) > 0 {
+		hash, cleanup, err := downloadHasher(client, params.hashWorkers)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		if groups, err = verify(groups, hash); err != nil {
+			return cmdutil.RequestError(err, params.debug)
+		}
+	}
+
+	dups := report(groups)
+	out := cmd.OutOrStdout()
+
+	switch {
+	case params.output.Structured():
+		return params.output.Encode(out, dups)
+	case len(dups) == 0:
+		cmdutil.Success("No duplicate attachments found in project %q", params.project)
+		return nil
+	case params.output.Format == cmdutil.OutputCSV:
+		return renderCSV(out, dups)
+	default:
+		renderTable(out, dups, params.output.Format != cmdutil.OutputPlain)
+		return nil
+	}
+}
+
+type duplicatesParams struct {
+	project string
+	minSize int64
+	verify  bool
+	output  cmdutil.Renderer
+	debug   bool
+
+	hashWorkers int
+}
+
+func parseArgsAndFlags(flags query.FlagParser) (*duplicatesParams, error) {
+	var (
+		params duplicatesParams
+		err    error
+	)
+
+	params.project = viper.GetString("project.key")
+	if params.project == "" {
+		return nil, cmdutil.Errorf("Project is required, pass it with --project or set project.key in the config")
+	}
+
+	minSize, err := flags.GetString("min-size")
+	if err != nil {
+		return nil, err
+	}
+	if minSize != "" {
+		if params.minSize, err = where.ParseSize(minSize); err != nil {
+			return nil, cmdutil.Errorf("Invalid --min-size: %s", err)
+		}
+	}
+
+	if params.verify, err = flags.GetBool("verify"); err != nil {
+		return nil, err
+	}
+	hashWorkers, err := flags.GetUint("hash-workers")
+	if err != nil {
+		return nil, err
+	}
+	if hashWorkers > 0 && !params.verify {
+		return nil, cmdutil.Errorf("--hash-workers requires --verify")
+	}
+	params.hashWorkers = int(hashWorkers)
+	if params.debug, err = flags.GetBool("debug"); err != nil {
+		return nil, err
+	}
+
+	return &params, nil
+}
+
+// downloadHasher returns a hash function that downloads attachments to temporary files and
+// hashes them with package filehash using the number of workers, so that no attachment is ever
+// held in memory. The cleanup removes the directory.
+func downloadHasher(client *jira.Client, workers int) (hashFunc, func(), error) {
+	dir, err := os.MkdirTemp("", "jira-duplicates-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	hash := func(members []member) ([]string, error) {
+		files := make([]filehash.File, 0, len(members))
+		defer func() {
+			for _, f := range files {
+				_ = os.Remove(f.Path)
+			}
+		}()
+
+		for _, m := range members {
+			path := filepath.Join(dir, m.id)
+			if err := client.DownloadAttachment(m.url, path); err != nil {
+				return nil, fmt.Errorf("failed to verify attachment %s of issue %s: %w", m.id, m.issue, err)
+			}
+			files = append(files, filehash.File{Path: path})
+		}
+
+		sums := make([]string, 0, len(files))
+		for _, r := range filehash.Files(files, filehash.Options{Workers: workers}) {
+			if r.Err != nil {
+				return nil, r.Err
+			}
+			sums = append(sums, r.Sum)
+		}
+		return sums, nil
+	}
+	return hash, cleanup, nil
+}
+
+func renderTable(w io.Writer, dups []Duplicate, header bool) {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	if header {
+		fmt.Fprintf(tw, "WASTED\tCOPIES\tSIZE\tFILENAME\tISSUES\n")
+	}
+	for _, d := range dups {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n",
+			cmdutil.FormatSize(d.Wasted),
+			d.Count,
+			cmdutil.FormatSize(d.Size),
+			cmdutil.SanitizeTerminalText(d.Filename),
+			strings.Join(d.Issues, ", "),
+		)
+	}
+	_ = tw.Flush()
+}
+
+func renderCSV(w io.Writer, dups []Duplicate) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"FILENAME", "SIZE", "COPIES", "WASTED", "SHA256", "ISSUES"})
+	for _, d := range dups {
+		_ = cw.Write([]string{
+			d.Filename,
+			strconv.FormatInt(d.Size, 10),
+			strconv.Itoa(d.Count),
+			strconv.FormatInt(d.Wasted, 10),
+			d.SHA256,
+			strings.Join(d.Issues, " "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,137 @@
+package duplicates
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func newEnv(server *jiratest.Server) cmdtest.Env {
+	return cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{
+			"server":                  server.URL,
+			"installation":            "Cloud",
+			"project.key":             "TEST",
+			"auth.check_token_expiry": false,
+		},
+	}
+}
+
+func newProject() *jiratest.Server {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1", "TEST-2", "TEST-3", "OTHER-1"))
+
+	server.AddAttachment("TEST-1", "architecture.pdf", []byte("the same architecture"))
+	server.AddAttachment("TEST-2", "architecture.pdf", []byte("the same architecture"))
+	server.AddAttachment("TEST-3", "architecture.pdf", []byte("the same architecture"))
+	server.AddAttachment("OTHER-1", "architecture.pdf", []byte("the same architecture"))
+
+	// Same name and size, different content.
+	server.AddAttachment("TEST-1", "report.csv", []byte("a,b\n1,2\n"))
+	server.AddAttachment("TEST-2", "report.csv", []byte("a,b\n3,4\n"))
+
+	return server
+}
+
+func TestDuplicates(t *testing.T) {
+	server := newProject()
+	defer server.Close()
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentDuplicates(), "--plain")
+	require.NoError(t, res.Err)
+	assert.Equal(t, ""+
+		"42 B\t3\t21 B\tarchitecture.pdf\tTEST-1, TEST-2, TEST-3\n"+
+		"8 B\t2\t8 B\treport.csv\t\tTEST-1, TEST-2\n",
+		res.Stdout,
+	)
+
+	// Only searches, nothing is downloaded.
+	for _, r := range server.Requests() {
+		assert.Equal(t, "/rest/api/3/search/jql", r.Path)
+		assert.Equal(t, "attachment", r.Query.Get("fields"))
+		assert.Equal(t, `project = "TEST"`, r.Query.Get("jql"))
+	}
+}
+
+func TestDuplicatesVerify(t *testing.T) {
+	server := newProject()
+	defer server.Close()
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentDuplicates(), "--verify", "--json")
+	require.NoError(t, res.Err)
+
+	var dups []Duplicate
+	require.NoError(t, json.Unmarshal([]byte(res.Stdout), &dups))
+
+	// The report files differ, so each is alone in its group and not reported.
+	require.Len(t, dups, 1)
+	assert.Equal(t, "architecture.pdf", dups[0].Filename)
+	assert.Equal(t, 3, dups[0].Count)
+	assert.Equal(t, int64(42), dups[0].Wasted)
+	assert.Len(t, dups[0].SHA256, 64)
+	assert.Equal(t, []string{"TEST-1", "TEST-2", "TEST-3"}, dups[0].Issues)
+
+	// The number of hash workers doesn't change the outcome.
+	res = cmdtest.Run(t, newEnv(server), NewCmdAttachmentDuplicates(), "--verify", "--hash-workers", "1", "--json")
+	require.NoError(t, res.Err)
+	assert.JSONEq(t, string(mustJSON(t, dups)), res.Stdout)
+
+	res = cmdtest.Run(t, newEnv(server), NewCmdAttachmentDuplicates(), "--hash-workers", "2")
+	assert.EqualError(t, res.Err, "--hash-workers requires --verify")
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDuplicatesCSV(t *testing.T) {
+	server := newProject()
+	defer server.Close()
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentDuplicates(), "--csv", "--min-size", "10")
+	require.NoError(t, res.Err)
+	assert.Equal(t, "FILENAME,SIZE,COPIES,WASTED,SHA256,ISSUES\narchitecture.pdf,21,3,42,,TEST-1 TEST-2 TEST-3\n", res.Stdout)
+}
+
+func TestDuplicatesErrors(t *testing.T) {
+	server := newProject()
+	defer server.Close()
+
+	env := newEnv(server)
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentDuplicates(), "--min-size", "huge")
+	assert.ErrorContains(t, res.Err, "Invalid --min-size")
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentDuplicates(), "--csv", "--json")
+	assert.EqualError(t, res.Err, "--json conflicts with --csv")
+
+	env.Config["project.key"] = ""
+	res = cmdtest.Run(t, env, NewCmdAttachmentDuplicates())
+	assert.ErrorContains(t, res.Err, "Project is required")
+}
+
+func TestDuplicatesOutputFormats(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1", "TEST-2"))
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "design.pdf", []byte("the same design"))
+	server.AddAttachment("TEST-2", "design.pdf", []byte("the same design"))
+
+	for _, format := range []string{"table", "plain", "csv", "json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentDuplicates(), "-o", format)
+			require.NoError(t, res.Err)
+			cmdtest.AssertOutput(t, format, res.Stdout)
+			assert.Contains(t, res.Stdout, "design.pdf")
+		})
+	}
+}
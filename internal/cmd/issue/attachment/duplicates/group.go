@@ -0,0 +1,225 @@
+package duplicates
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// groupKey identifies attachments that are likely the same file.
+type groupKey struct {
+	filename string
+	size     int64
+}
+
+// member is an attachment of a group, kept only to be hashed with --verify.
+type member struct {
+	issue string
+	id    string
+	url   string
+}
+
+// group is a set of attachments with the same name and size.
+type group struct {
+	key    groupKey
+	count  int
+	issues map[string]struct{}
+	// members are only collected with --verify.
+	members []member
+	hash    string
+}
+
+// collector groups the attachments of a project page by page. Only the group keys, the counts
+// and the keys of the issues are retained, and the members if they are to be verified, never
+// the attachment bodies nor the issue pages.
+type collector struct {
+	minSize     int64
+	keepMembers bool
+	groups      map[groupKey]*group
+}
+
+func newCollector(minSize int64, keepMembers bool) *collector {
+	return &collector{
+		minSize:     minSize,
+		keepMembers: keepMembers,
+		groups:      make(map[groupKey]*group),
+	}
+}
+
+// add adds the attachments of the issues of a search page.
+func (c *collector) add(issues []*jira.Issue) {
+	for _, iss := range issues {
+		for _, a := range iss.Fields.Attachments {
+			c.addAttachment(iss.Key, a)
+		}
+	}
+}
+
+func (c *collector) addAttachment(issue string, a jira.Attachment) {
+	// Empty files are all the same but waste nothing.
+	if a.Size <= 0 || a.Size < c.minSize {
+		return
+	}
+
+	k := groupKey{filename: a.Filename, size: a.Size}
+	g, ok := c.groups[k]
+	if !ok {
+		g = &group{key: k, issues: make(map[string]struct{})}
+		c.groups[k] = g
+	}
+	g.count++
+	g.issues[issue] = struct{}{}
+	if c.keepMembers {
+		g.members = append(g.members, member{issue: issue, id: a.ID, url: a.Content})
+	}
+}
+
+// candidates returns the groups of more than one attachment.
+func (c *collector) candidates() []*group {
+	var out []*group
+	for _, g := range c.groups {
+		if g.count > 1 {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// hashFunc returns the content hashes of attachments, in their order.
+type hashFunc func(members []member) ([]string, error)
+
+// verify confirms the groups by content, hashing one member plus one other. If both match, the
+// group is confirmed as is. If they don't, the file names and sizes collide by chance: the rest of
+// the members are hashed as well and the group is split by content. Parts left with a single
+// attachment are not duplicates and are dropped.
+func verify(groups []*group, hash hashFunc) ([]*group, error) {
+	var out []*group
+
+	for _, g := range groups {
+		if len(g.members) < 2 {
+			continue
+		}
+
+		hashes, err := hash(g.members[:2])
+		if err != nil {
+			return nil, err
+		}
+		if hashes[0] == hashes[1] {
+			g.hash = hashes[0]
+			out = append(out, g)
+			continue
+		}
+
+		rest, err := hash(g.members[2:])
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, rest...)
+		for _, p := range split(g, hashes) {
+			if p.count > 1 {
+				out = append(out, p)
+			}
+		}
+	}
+	return out, nil
+}
+
+// split splits a group by the content hashes of its members, in the order of first appearance.
+func split(g *group, hashes []string) []*group {
+	var parts []*group
+	byHash := make(map[string]*group)
+
+	for i, m := range g.members {
+		p, ok := byHash[hashes[i]]
+		if !ok {
+			p = &group{key: g.key, issues: make(map[string]struct{}), hash: hashes[i]}
+			byHash[hashes[i]] = p
+			parts = append(parts, p)
+		}
+		p.count++
+		p.issues[m.issue] = struct{}{}
+		p.members = append(p.members, m)
+	}
+	return parts
+}
+
+// Duplicate is a reported group of identical attachments.
+type Duplicate struct {
+	Filename string   `json:"filename"`
+	Size     int64    `json:"size"`
+	Count    int      `json:"count"`
+	Wasted   int64    `json:"wasted"`
+	SHA256   string   `json:"sha256,omitempty"`
+	Issues   []string `json:"issues"`
+}
+
+// wasted is the storage used by the copies of a file, all but one of them.
+func wasted(size int64, count int) int64 {
+	if count < 2 {
+		return 0
+	}
+	return size * int64(count-1)
+}
+
+// report turns the groups into duplicates, the most wasteful first.
+func report(groups []*group) []Duplicate {
+	out := make([]Duplicate, 0, len(groups))
+	for _, g := range groups {
+		issues := make([]string, 0, len(g.issues))
+		for k := range g.issues {
+			issues = append(issues, k)
+		}
+		slices.SortFunc(issues, compareIssueKeys)
+
+		out = append(out, Duplicate{
+			Filename: g.key.filename,
+			Size:     g.key.size,
+			Count:    g.count,
+			Wasted:   wasted(g.key.size, g.count),
+			SHA256:   g.hash,
+			Issues:   issues,
+		})
+	}
+	sortByWaste(out)
+	return out
+}
+
+// sortByWaste sorts the duplicates by wasted bytes, then by name, size and hash so
+// that the order is the same on every run.
+func sortByWaste(dups []Duplicate) {
+	slices.SortFunc(dups, func(a, b Duplicate) int {
+		if c := cmp.Compare(b.Wasted, a.Wasted); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Filename, b.Filename); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Size, b.Size); c != 0 {
+			return c
+		}
+		return strings.Compare(a.SHA256, b.SHA256)
+	})
+}
+
+// compareIssueKeys orders issue keys by project, then by number.
+func compareIssueKeys(a, b string) int {
+	pa, na := splitIssueKey(a)
+	pb, nb := splitIssueKey(b)
+	if c := strings.Compare(pa, pb); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(len(na), len(nb)); c != 0 {
+		return c
+	}
+	return strings.Compare(na, nb)
+}
+
+func splitIssueKey(key string) (string, string) {
+	i := strings.LastIndex(key, "-")
+	if i == -1 {
+		return key, ""
+	}
+	return key[:i], key[i+1:]
+}
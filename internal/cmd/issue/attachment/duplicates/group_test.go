@@ -0,0 +1,171 @@
+package duplicates
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const mb = 1024 * 1024
+
+func page(key string, attachments ...jira.Attachment) *jira.Issue {
+	return &jira.Issue{Key: key, Fields: jira.IssueFields{Attachments: attachments}}
+}
+
+func file(id, name string, size int64) jira.Attachment {
+	return jira.Attachment{ID: id, Filename: name, Size: size, Content: "https://test.local/" + id}
+}
+
+func TestCollectorGroups(t *testing.T) {
+	t.Parallel()
+
+	c := newCollector(0, false)
+	c.add([]*jira.Issue{
+		page("TEST-1", file("1", "architecture.pdf", 40*mb), file("2", "notes.txt", 10)),
+		page("TEST-2", file("3", "architecture.pdf", 40*mb), file("4", "empty.txt", 0)),
+	})
+	c.add([]*jira.Issue{
+		page("TEST-10", file("5", "architecture.pdf", 40*mb), file("6", "empty.txt", 0)),
+		// Same name, different size.
+		page("TEST-3", file("7", "architecture.pdf", 41*mb)),
+		// The same file attached twice to an issue.
+		page("TEST-4", file("8", "notes.txt", 10), file("9", "notes.txt", 10)),
+	})
+
+	dups := report(c.candidates())
+	require.Len(t, dups, 2)
+
+	assert.Equal(t, Duplicate{
+		Filename: "architecture.pdf",
+		Size:     40 * mb,
+		Count:    3,
+		Wasted:   80 * mb,
+		Issues:   []string{"TEST-1", "TEST-2", "TEST-10"},
+	}, dups[0])
+	assert.Equal(t, Duplicate{
+		Filename: "notes.txt",
+		Size:     10,
+		Count:    3,
+		Wasted:   20,
+		Issues:   []string{"TEST-1", "TEST-4"},
+	}, dups[1])
+
+	// Members are only kept to be verified.
+	for _, g := range c.groups {
+		assert.Empty(t, g.members)
+	}
+}
+
+func TestCollectorMinSize(t *testing.T) {
+	t.Parallel()
+
+	c := newCollector(mb, true)
+	c.add([]*jira.Issue{
+		page("TEST-1", file("1", "big.bin", mb), file("2", "small.bin", mb-1)),
+		page("TEST-2", file("3", "big.bin", mb), file("4", "small.bin", mb-1)),
+	})
+
+	groups := c.candidates()
+	require.Len(t, groups, 1)
+	assert.Equal(t, "big.bin", groups[0].key.filename)
+	assert.Equal(t, []member{
+		{issue: "TEST-1", id: "1", url: "https://test.local/1"},
+		{issue: "TEST-2", id: "3", url: "https://test.local/3"},
+	}, groups[0].members)
+}
+
+func TestWasted(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		size     int64
+		count    int
+		expected int64
+	}{
+		{size: 40 * mb, count: 200, expected: 199 * 40 * mb},
+		{size: 10, count: 2, expected: 10},
+		{size: 10, count: 1, expected: 0},
+		{size: 10, count: 0, expected: 0},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(fmt.Sprintf("%dx%d", tc.size, tc.count), func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, wasted(tc.size, tc.count))
+		})
+	}
+}
+
+func TestSortByWaste(t *testing.T) {
+	t.Parallel()
+
+	dups := []Duplicate{
+		{Filename: "b.txt", Size: 10, Wasted: 10},
+		{Filename: "big.iso", Size: 100, Wasted: 100},
+		{Filename: "a.txt", Size: 5, Wasted: 10},
+		{Filename: "a.txt", Size: 10, Wasted: 10, SHA256: "bb"},
+		{Filename: "a.txt", Size: 10, Wasted: 10, SHA256: "aa"},
+	}
+	sortByWaste(dups)
+
+	var order []string
+	for _, d := range dups {
+		order = append(order, fmt.Sprintf("%s/%d/%s", d.Filename, d.Size, d.SHA256))
+	}
+	assert.Equal(t, []string{"big.iso/100/", "a.txt/5/", "a.txt/10/aa", "a.txt/10/bb", "b.txt/10/"}, order)
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	content := map[string]string{"1": "x", "2": "x", "3": "x", "4": "y", "5": "z", "6": "y", "7": "q", "8": "r"}
+
+	c := newCollector(0, true)
+	c.add([]*jira.Issue{
+		page("TEST-1", file("1", "same.txt", 1), file("4", "mixed.txt", 1), file("7", "unrelated.txt", 1)),
+		page("TEST-2", file("2", "same.txt", 1), file("5", "mixed.txt", 1), file("8", "unrelated.txt", 1)),
+		page("TEST-3", file("3", "same.txt", 1), file("6", "mixed.txt", 1)),
+	})
+
+	var hashed []string
+	groups, err := verify(c.candidates(), func(members []member) ([]string, error) {
+		sums := make([]string, 0, len(members))
+		for _, m := range members {
+			hashed = append(hashed, m.id)
+			sums = append(sums, content[m.id])
+		}
+		return sums, nil
+	})
+	require.NoError(t, err)
+
+	dups := report(groups)
+	require.Len(t, dups, 2)
+	assert.Equal(t, Duplicate{Filename: "same.txt", Size: 1, Count: 3, Wasted: 2, SHA256: "x", Issues: []string{"TEST-1", "TEST-2", "TEST-3"}}, dups[0])
+	assert.Equal(t, Duplicate{Filename: "mixed.txt", Size: 1, Count: 2, Wasted: 1, SHA256: "y", Issues: []string{"TEST-1", "TEST-3"}}, dups[1])
+
+	// A confirmed group only has one member plus one other hashed.
+	assert.NotContains(t, hashed, "3")
+	assert.Contains(t, hashed, "6")
+
+	_, err = verify(c.candidates(), func([]member) ([]string, error) {
+		return nil, errors.New("forbidden")
+	})
+	assert.Error(t, err)
+}
+
+func TestCompareIssueKeys(t *testing.T) {
+	t.Parallel()
+
+	assert.Negative(t, compareIssueKeys("TEST-2", "TEST-10"))
+	assert.Positive(t, compareIssueKeys("TEST-10", "TEST-2"))
+	assert.Negative(t, compareIssueKeys("ABC-10", "TEST-1"))
+	assert.Zero(t, compareIssueKeys("TEST-1", "TEST-1"))
+}
@@ -0,0 +1,86 @@
+package attachment_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+// embed adds the attachment command tree to a root of another application. The root
+// defines the persistent flags the commands read.
+func embed(opts cmdcommon.Options, args ...string) *cobra.Command {
+	root := &cobra.Command{Use: "myapp", SilenceErrors: true, SilenceUsage: true}
+	root.PersistentFlags().Bool("debug", false, "Turn on debug output")
+	root.AddCommand(attachment.NewCmdAttachment(opts))
+	root.SetArgs(append([]string{"attachment"}, args...))
+	return root
+}
+
+func ExampleNewCmdAttachment() {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithClock(func() time.Time {
+		return time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	}))
+	defer server.Close()
+	server.AddAttachment("TEST-1", "notes.txt", []byte("hello"))
+
+	var out bytes.Buffer
+	root := embed(cmdcommon.Options{
+		ClientProvider: func() (*jira.Client, error) { return server.Client(), nil },
+		Out:            &out,
+	}, "list", "TEST-1", "--output", "csv")
+	if err := root.Execute(); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Print(out.String())
+
+	// Output:
+	// ID,FILENAME,SIZE,AUTHOR,CREATED
+	// 10000,notes.txt,5,Administrator,2024-03-01T10:00:00Z
+}
+
+func TestEmbeddedListUsesProvidedClient(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+	server.AddAttachment("TEST-1", "notes.txt", []byte("hello"))
+
+	// A client built from the config would fail to reach this server.
+	for key, val := range map[string]any{"server": "http://jira.invalid", "login": "nobody", "api_token": "nope"} {
+		prev := viper.Get(key)
+		viper.Set(key, val)
+		t.Cleanup(func() { viper.Set(key, prev) })
+	}
+
+	var calls int
+	var out bytes.Buffer
+	root := embed(cmdcommon.Options{
+		ClientProvider: func() (*jira.Client, error) {
+			calls++
+			return server.Client(), nil
+		},
+		Out: &out,
+	}, "list", "TEST-1", "--plain")
+
+	require.NoError(t, root.Execute())
+	assert.Equal(t, 1, calls)
+	assert.Contains(t, out.String(), "10000\tnotes.txt\t")
+	assert.NotEmpty(t, server.Requests())
+
+	// Errors of the provider are returned as is.
+	errNoClient := errors.New("no client")
+	root = embed(cmdcommon.Options{
+		ClientProvider: func() (*jira.Client, error) { return nil, errNoClient },
+	}, "list", "TEST-1")
+	assert.ErrorIs(t, root.Execute(), errNoClient)
+}
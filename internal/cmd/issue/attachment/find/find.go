@@ -0,0 +1,317 @@
+package find
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	helpText = `Find lists the issues with an attachment whose filename matches a glob.
+
+JQL can't match attachment filenames, so the candidates are narrowed on the server to the issues
+of the project with attachments, and --jql if given, then their filenames are matched locally.
+Only the attachment field of the issues is fetched. At most --limit issues are scanned.`
+	examples = `$ jira issue attachment find forecast-q3.xlsx
+
+# Match a glob, ignoring case, in another project
+$ jira issue attachment find '*forecast*.xlsx' -i --project FOO
+
+# Only look at recently updated issues
+$ jira issue attachment find '*.xlsx' --jql 'updated >= -30d'
+
+# Stop at the first match and print it as JSON
+$ jira issue attachment find forecast-q3.xlsx --max-matches 1 -o json`
+
+	// searchPageSize is the number of issues fetched per search request.
+	searchPageSize = 100
+
+	defaultLimit = 1000
+)
+
+// errStopScan stops paging through the search results.
+var errStopScan = errors.New("stop scan")
+
+// NewCmdAttachmentFind is an attachment find command.
+func NewCmdAttachmentFind() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "find FILENAME-GLOB",
+		Short:   "Find issues by attachment filename",
+		Long:    helpText,
+		Example: examples,
+		Annotations: map[string]string{
+			"help:args": "FILENAME-GLOB\tFilename or glob to match, eg: forecast-q3.xlsx or '*.xlsx'",
+		},
+		Args:          cobra.ExactArgs(1),
+		RunE:          find,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().BoolP("ignore-case", "i", false, "Match filenames case-insensitively")
+	cmd.Flags().String("jql", "", "Extra JQL the candidate issues must match, eg: 'updated >= -30d'")
+	cmd.Flags().Uint("limit", defaultLimit, "Maximum number of issues to scan")
+	cmd.Flags().Uint("max-matches", 0, "Stop after this many matching attachments, 0 for no limit")
+	cmdcommon.SetTimezoneFlag(&cmd)
+	cmdutil.RegisterOutputFlag(&cmd,
+		[]string{cmdutil.OutputTable, cmdutil.OutputPlain, cmdutil.OutputJSON, cmdutil.OutputYAML},
+		cmdutil.OutputAlias{Flag: "plain", Format: cmdutil.OutputPlain, Usage: "Display output in plain mode, without the header"},
+		cmdutil.OutputAlias{Flag: "json", Format: cmdutil.OutputJSON, Usage: "Print output in JSON format"},
+	)
+
+	return &cmd
+}
+
+func find(cmd *cobra.Command, args []string) error {
+	params, err := parseArgsAndFlags(args, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	if params.output, err = cmdutil.ResolveRenderer(cmd); err != nil {
+		return err
+	}
+	loc, err := cmdcommon.DisplayLocation(cmd)
+	if err != nil {
+		return err
+	}
+	version, err := cmdcommon.GetAPIVersion(cmd)
+	if err != nil {
+		return err
+	}
+
+	client := api.DefaultClient(params.debug)
+
+	s := newScanner(params.matcher, params.limit, params.maxMatches)
+	jql := buildJQL(params.project, params.jql)
+	// One issue past the limit tells if the scan is truncated without requesting another page.
+	pageSize := min(searchPageSize, params.limit+1)
+	err = api.ProxySearchIssueAttachmentsVersion(client, version, jql, pageSize, func(issues []*jira.Issue) error {
+		if s.scan(issues) {
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopScan) {
+		return cmdutil.RequestError(err, params.debug)
+	}
+	if msg := s.truncatedWarning(); msg != "" {
+		cmdutil.Warn(msg)
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case params.output.Structured():
+		return params.output.Encode(out, newOutput(s.matches))
+	case len(s.matches) == 0:
+		return cmdutil.Errorf("No attachments matching %q found in %d issue(s) scanned", params.glob, s.scanned)
+	default:
+		renderTable(out, s.matches, loc, params.output.Format != cmdutil.OutputPlain)
+		return nil
+	}
+}
+
+type findParams struct {
+	glob       string
+	matcher    *matcher
+	project    string
+	jql        string
+	limit      uint
+	maxMatches uint
+	output     cmdutil.Renderer
+	debug      bool
+}
+
+func parseArgsAndFlags(args []string, flags query.FlagParser) (*findParams, error) {
+	params := findParams{glob: args[0], project: viper.GetString("project.key")}
+
+	ignoreCase, err := flags.GetBool("ignore-case")
+	if err != nil {
+		return nil, err
+	}
+	if params.matcher, err = newMatcher(params.glob, ignoreCase); err != nil {
+		return nil, err
+	}
+
+	if params.jql, err = flags.GetString("jql"); err != nil {
+		return nil, err
+	}
+	if params.limit, err = flags.GetUint("limit"); err != nil {
+		return nil, err
+	}
+	if params.limit == 0 {
+		return nil, cmdutil.Errorf("--limit must be greater than 0")
+	}
+	if params.maxMatches, err = flags.GetUint("max-matches"); err != nil {
+		return nil, err
+	}
+	if params.debug, err = flags.GetBool("debug"); err != nil {
+		return nil, err
+	}
+
+	return &params, nil
+}
+
+var orderBy = regexp.MustCompile(`(?i)\border\s+by\b`)
+
+// buildJQL returns the jql of the candidate issues: the issues of the project, if any, that
+// have attachments and match the extra jql. An ORDER BY clause of the extra jql is kept last.
+func buildJQL(project, extra string) string {
+	var clauses []string
+	if project != "" {
+		clauses = append(clauses, fmt.Sprintf("project = %q", project))
+	}
+	clauses = append(clauses, "attachments is not EMPTY")
+
+	var order string
+	if loc := orderBy.FindStringIndex(extra); loc != nil {
+		extra, order = extra[:loc[0]], extra[loc[0]:]
+	}
+	if extra = strings.TrimSpace(extra); extra != "" {
+		clauses = append(clauses, "("+extra+")")
+	}
+
+	jql := strings.Join(clauses, " AND ")
+	if order = strings.TrimSpace(order); order != "" {
+		jql += " " + order
+	}
+	return jql
+}
+
+// matcher matches filenames against a glob.
+type matcher struct {
+	pattern    string
+	ignoreCase bool
+}
+
+func newMatcher(glob string, ignoreCase bool) (*matcher, error) {
+	if ignoreCase {
+		glob = strings.ToLower(glob)
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, cmdutil.Errorf("Invalid filename glob %q: %s", glob, err)
+	}
+	return &matcher{pattern: glob, ignoreCase: ignoreCase}, nil
+}
+
+func (m *matcher) match(filename string) bool {
+	if m.ignoreCase {
+		filename = strings.ToLower(filename)
+	}
+	ok, _ := path.Match(m.pattern, filename)
+	return ok
+}
+
+// match is an attachment matching the glob.
+type match struct {
+	issue      string
+	attachment jira.Attachment
+}
+
+// scanner matches the attachments of the scanned issues, up to limit issues and maxMatches
+// matches if not zero.
+type scanner struct {
+	matcher    *matcher
+	limit      uint
+	maxMatches uint
+
+	scanned uint
+	// truncated is set if the scan stopped at the limit with issues left to scan.
+	truncated bool
+	matches   []match
+}
+
+func newScanner(m *matcher, limit, maxMatches uint) *scanner {
+	return &scanner{matcher: m, limit: limit, maxMatches: maxMatches}
+}
+
+// scan matches a page of issues and reports if the scan is over. The scan is truncated only
+// once an issue past the limit is received, so that a result set of exactly limit issues
+// isn't reported as truncated.
+func (s *scanner) scan(issues []*jira.Issue) bool {
+	for _, iss := range issues {
+		if s.scanned == s.limit {
+			s.truncated = true
+			return true
+		}
+		s.scanned++
+
+		for _, a := range iss.Fields.Attachments {
+			if !s.matcher.match(a.Filename) {
+				continue
+			}
+			s.matches = append(s.matches, match{issue: iss.Key, attachment: a})
+			if s.maxMatches > 0 && uint(len(s.matches)) == s.maxMatches {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// truncatedWarning explains that matches may be missing as the scan stopped at the limit.
+func (s *scanner) truncatedWarning() string {
+	if !s.truncated {
+		return ""
+	}
+	return fmt.Sprintf("Stopped after scanning %d issues, there may be more matches; raise --limit or narrow the search with --jql", s.scanned)
+}
+
+// output is a match in the JSON and YAML output.
+type output struct {
+	Issue    string `json:"issue"`
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Author   string `json:"author"`
+	// Created is RFC 3339 in UTC.
+	Created string `json:"created"`
+}
+
+func newOutput(matches []match) []output {
+	out := make([]output, 0, len(matches))
+	for _, m := range matches {
+		out = append(out, output{
+			Issue:    m.issue,
+			ID:       m.attachment.ID,
+			Filename: m.attachment.Filename,
+			Size:     m.attachment.Size,
+			MimeType: m.attachment.MimeType,
+			Author:   cmdutil.AuthorName(m.attachment.Author),
+			Created:  m.attachment.CreatedUTC(),
+		})
+	}
+	return out
+}
+
+func renderTable(w io.Writer, matches []match, loc *time.Location, header bool) {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	if header {
+		fmt.Fprintf(tw, "ISSUE\tID\tFILENAME\tSIZE\tAUTHOR\tCREATED\n")
+	}
+	for _, m := range matches {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			m.issue,
+			m.attachment.ID,
+			cmdutil.SanitizeTerminalText(m.attachment.Filename),
+			cmdutil.FormatSize(m.attachment.Size),
+			cmdutil.SanitizeTerminalText(cmdutil.AuthorName(m.attachment.Author)),
+			m.attachment.FormatCreated(loc, time.DateOnly),
+		)
+	}
+	_ = tw.Flush()
+}
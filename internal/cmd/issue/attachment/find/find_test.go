@@ -0,0 +1,227 @@
+package find
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func newEnv(server *jiratest.Server) cmdtest.Env {
+	return cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{
+			"server":                  server.URL,
+			"installation":            "Cloud",
+			"project.key":             "TEST",
+			"auth.check_token_expiry": false,
+		},
+	}
+}
+
+// newProject returns a server with n issues in the TEST project.
+func newProject(n int) *jiratest.Server {
+	keys := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		keys = append(keys, fmt.Sprintf("TEST-%d", i))
+	}
+	return jiratest.NewServer(jiratest.WithIssues(keys...))
+}
+
+func TestBuildJQL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		project string
+		extra   string
+		want    string
+	}{
+		{name: "project", project: "TEST", want: `project = "TEST" AND attachments is not EMPTY`},
+		{name: "no project", want: `attachments is not EMPTY`},
+		{
+			name:    "extra jql is grouped",
+			project: "TEST",
+			extra:   "updated >= -30d OR priority = High",
+			want:    `project = "TEST" AND attachments is not EMPTY AND (updated >= -30d OR priority = High)`,
+		},
+		{
+			name:    "order by is kept last",
+			project: "TEST",
+			extra:   "status = Done order by updated DESC",
+			want:    `project = "TEST" AND attachments is not EMPTY AND (status = Done) order by updated DESC`,
+		},
+		{
+			name:  "order by only",
+			extra: "  ORDER BY created  ",
+			want:  `attachments is not EMPTY ORDER BY created`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, buildJQL(tc.project, tc.extra))
+		})
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	t.Parallel()
+
+	m, err := newMatcher("forecast-*.xlsx", false)
+	require.NoError(t, err)
+	assert.True(t, m.match("forecast-q3.xlsx"))
+	assert.False(t, m.match("Forecast-Q3.XLSX"))
+	assert.False(t, m.match("old-forecast-q3.xlsx"))
+
+	m, err = newMatcher("Forecast-*.xlsx", true)
+	require.NoError(t, err)
+	assert.True(t, m.match("forecast-q3.xlsx"))
+	assert.True(t, m.match("FORECAST-Q3.XLSX"))
+
+	_, err = newMatcher("forecast-[q3.xlsx", false)
+	assert.EqualError(t, err, `Invalid filename glob "forecast-[q3.xlsx": syntax error in pattern`)
+}
+
+func issues(from, to int, attachments map[int]string) []*jira.Issue {
+	var out []*jira.Issue
+	for i := from; i <= to; i++ {
+		iss := &jira.Issue{Key: fmt.Sprintf("TEST-%d", i)}
+		if name, ok := attachments[i]; ok {
+			iss.Fields.Attachments = []jira.Attachment{{ID: fmt.Sprint(i), Filename: name}}
+		}
+		out = append(out, iss)
+	}
+	return out
+}
+
+func TestScannerTruncation(t *testing.T) {
+	t.Parallel()
+
+	m, err := newMatcher("*.xlsx", false)
+	require.NoError(t, err)
+
+	t.Run("more issues than the limit", func(t *testing.T) {
+		t.Parallel()
+
+		s := newScanner(m, 3, 0)
+		assert.True(t, s.scan(issues(1, 4, map[int]string{2: "a.xlsx", 4: "b.xlsx"})))
+		assert.EqualValues(t, 3, s.scanned)
+		assert.Len(t, s.matches, 1)
+		assert.Equal(t, "Stopped after scanning 3 issues, there may be more matches; raise --limit or narrow the search with --jql", s.truncatedWarning())
+	})
+
+	t.Run("exactly the limit", func(t *testing.T) {
+		t.Parallel()
+
+		s := newScanner(m, 3, 0)
+		assert.False(t, s.scan(issues(1, 3, nil)))
+		assert.Empty(t, s.truncatedWarning())
+	})
+
+	t.Run("limit reached on the next page", func(t *testing.T) {
+		t.Parallel()
+
+		s := newScanner(m, 3, 0)
+		assert.False(t, s.scan(issues(1, 3, nil)))
+		assert.True(t, s.scan(issues(4, 6, nil)))
+		assert.NotEmpty(t, s.truncatedWarning())
+	})
+
+	t.Run("max matches reached first", func(t *testing.T) {
+		t.Parallel()
+
+		s := newScanner(m, 3, 1)
+		assert.True(t, s.scan(issues(1, 4, map[int]string{1: "a.xlsx", 2: "b.xlsx"})))
+		assert.EqualValues(t, 1, s.scanned)
+		assert.Len(t, s.matches, 1)
+		assert.Empty(t, s.truncatedWarning())
+	})
+}
+
+func TestFind(t *testing.T) {
+	server := newProject(3)
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+	server.AddAttachment("TEST-2", "forecast-q3.xlsx", []byte("forecast"))
+	server.AddAttachment("TEST-3", "Forecast-Q4.XLSX", []byte("forecast"))
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentFind(), "forecast-*.xlsx", "-i", "-o", "plain")
+	require.NoError(t, res.Err)
+	assert.Regexp(t, `^TEST-2\t10001\tforecast-q3.xlsx\t8 B\tAdministrator\t\d{4}-\d\d-\d\d\n`+
+		`TEST-3\t10002\tForecast-Q4.XLSX\t8 B\tAdministrator\t\d{4}-\d\d-\d\d\n$`, res.Stdout)
+
+	// Only the attachment field of the candidates is fetched.
+	reqs := server.Requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "attachment", reqs[0].Query.Get("fields"))
+	assert.Equal(t, `project = "TEST" AND attachments is not EMPTY`, reqs[0].Query.Get("jql"))
+
+	res = cmdtest.Run(t, newEnv(server), NewCmdAttachmentFind(), "forecast-q3.xlsx", "-o", "json")
+	require.NoError(t, res.Err)
+	var out []output
+	require.NoError(t, json.Unmarshal([]byte(res.Stdout), &out))
+	require.Len(t, out, 1)
+	assert.Equal(t, "TEST-2", out[0].Issue)
+	assert.Equal(t, "forecast-q3.xlsx", out[0].Filename)
+	assert.EqualValues(t, 8, out[0].Size)
+
+	res = cmdtest.Run(t, newEnv(server), NewCmdAttachmentFind(), "budget.xlsx")
+	assert.EqualError(t, res.Err, `No attachments matching "budget.xlsx" found in 3 issue(s) scanned`)
+}
+
+func TestFindStopsAtMaxMatches(t *testing.T) {
+	server := newProject(350)
+	defer server.Close()
+
+	for i := 1; i <= 350; i++ {
+		server.AddAttachment(fmt.Sprintf("TEST-%d", i), "notes.txt", []byte("notes"))
+	}
+	server.AddAttachment("TEST-150", "forecast-q3.xlsx", []byte("forecast"))
+	server.AddAttachment("TEST-340", "forecast-q3.xlsx", []byte("forecast"))
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentFind(), "forecast-q3.xlsx", "--max-matches", "1", "-o", "plain")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "TEST-150\t")
+	assert.NotContains(t, res.Stdout, "TEST-340")
+	assert.Empty(t, res.Stderr)
+
+	// The second page has the match, the third one is never requested.
+	reqs := server.Requests()
+	require.Len(t, reqs, 2)
+	assert.Empty(t, reqs[0].Query.Get("nextPageToken"))
+	assert.Equal(t, "100", reqs[1].Query.Get("nextPageToken"))
+}
+
+func TestFindLimit(t *testing.T) {
+	server := newProject(250)
+	defer server.Close()
+
+	for i := 1; i <= 250; i++ {
+		server.AddAttachment(fmt.Sprintf("TEST-%d", i), "notes.txt", []byte("notes"))
+	}
+	server.AddAttachment("TEST-220", "forecast-q3.xlsx", []byte("forecast"))
+
+	res := cmdtest.Run(t, newEnv(server), NewCmdAttachmentFind(), "forecast-q3.xlsx", "--limit", "200")
+	assert.EqualError(t, res.Err, `No attachments matching "forecast-q3.xlsx" found in 200 issue(s) scanned`)
+	assert.Contains(t, res.Stderr, "Stopped after scanning 200 issues, there may be more matches")
+
+	// Issues without attachments aren't candidates.
+	server = newProject(250)
+	defer server.Close()
+	server.AddAttachment("TEST-220", "forecast-q3.xlsx", []byte("forecast"))
+
+	res = cmdtest.Run(t, newEnv(server), NewCmdAttachmentFind(), "forecast-q3.xlsx", "--limit", "1", "-o", "plain")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "TEST-220\t")
+	assert.NotContains(t, res.Stderr, "Stopped after scanning")
+}
@@ -0,0 +1,87 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// archiveEntryLimit is the number of files listed under an archive with --expand-archives.
+const archiveEntryLimit = 10
+
+var (
+	archiveMimeTypes = []string{
+		"application/zip",
+		"application/x-zip-compressed",
+		"application/java-archive",
+		"application/x-java-archive",
+	}
+	archiveExtensions = []string{".zip", ".jar"}
+)
+
+// archives holds the contents of the attached archives, by attachment ID.
+type archives map[string]*jira.AttachmentArchive
+
+// isArchive reports if the attachment is an archive Jira can list the files of. The extension
+// is checked too as archives are often uploaded as application/octet-stream.
+func isArchive(a jira.Attachment) bool {
+	mimeType, _, _ := strings.Cut(strings.ToLower(a.MimeType), ";")
+	if slices.Contains(archiveMimeTypes, strings.TrimSpace(mimeType)) {
+		return true
+	}
+	return slices.Contains(archiveExtensions, strings.ToLower(filepath.Ext(a.Filename)))
+}
+
+// expandArchives fetches the contents of the archives among the rows. The endpoint only exists
+// on Jira server and data center, on cloud nothing is fetched. Archives Jira can't expand are
+// left out without an error.
+func expandArchives(client *jira.Client, rows []cmdcommon.IssueAttachment, installation string, debug bool) (archives, error) {
+	if installation != jira.InstallationTypeLocal {
+		cmdutil.Warn("Archive contents are only available on Jira server and data center, listing without them")
+		return nil, nil
+	}
+
+	out := make(archives)
+	for _, a := range rows {
+		if !isArchive(a.Attachment) || !a.Available() {
+			continue
+		}
+		archive, err := client.GetAttachmentHumanMetadata(a.ID)
+		if errors.Is(err, jira.ErrNoResult) {
+			continue
+		}
+		if err != nil {
+			return nil, cmdutil.RequestError(err, debug)
+		}
+		out[a.ID] = archive
+	}
+	return out, nil
+}
+
+// writeArchiveEntries writes the files of an archive indented under its row, in the FILENAME
+// and SIZE columns. At most archiveEntryLimit files are listed, followed by the number of
+// files left out.
+func writeArchiveEntries(w io.Writer, archive *jira.AttachmentArchive, withIssue, withIndex bool) {
+	lead := "\t"
+	if withIssue {
+		lead += "\t"
+	}
+	if withIndex {
+		lead += "\t"
+	}
+
+	entries := archive.Entries[:min(len(archive.Entries), archiveEntryLimit)]
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s  %s\t%s\t\t\n", lead, cmdutil.SanitizeTerminalText(e.Name()), cmdutil.SanitizeTerminalText(e.Size))
+	}
+	if more := max(archive.TotalEntryCount, len(archive.Entries)) - len(entries); more > 0 {
+		fmt.Fprintf(w, "%s  +%d more\t\t\t\n", lead, more)
+	}
+}
@@ -0,0 +1,154 @@
+package list
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestIsArchive(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		attachment jira.Attachment
+		want       bool
+	}{
+		{name: "zip", attachment: jira.Attachment{Filename: "a", MimeType: "application/zip"}, want: true},
+		{name: "jar", attachment: jira.Attachment{Filename: "a", MimeType: "application/java-archive"}, want: true},
+		{name: "mime type parameters", attachment: jira.Attachment{Filename: "a", MimeType: "Application/ZIP; charset=binary"}, want: true},
+		{name: "octet stream zip", attachment: jira.Attachment{Filename: "logs.ZIP", MimeType: "application/octet-stream"}, want: true},
+		{name: "text", attachment: jira.Attachment{Filename: "notes.txt", MimeType: "text/plain"}, want: false},
+		{name: "tarball", attachment: jira.Attachment{Filename: "dump.tar.gz", MimeType: "application/gzip"}, want: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, isArchive(tc.attachment))
+		})
+	}
+}
+
+func testArchive(files int) *jira.AttachmentArchive {
+	archive := jira.AttachmentArchive{Name: "bundle.zip", TotalEntryCount: files}
+	for i := range min(files, 30) {
+		archive.Entries = append(archive.Entries, jira.ArchiveEntry{
+			Index: i,
+			Label: fmt.Sprintf("file-%02d.txt", i),
+			Size:  "1 kB",
+		})
+	}
+	return &archive
+}
+
+func TestRenderArchiveEntries(t *testing.T) {
+	t.Parallel()
+
+	attachments := []jira.Attachment{
+		{ID: "10001", Filename: "small.zip", Size: 2048, Content: "https://example.com/10001", Created: "2020-12-01T10:00:00.000+0100"},
+		{ID: "10002", Filename: "large.zip", Size: 4096, Content: "https://example.com/10002", Created: "2020-12-01T10:00:00.000+0100"},
+		{ID: "10003", Filename: "notes.txt", Size: 10, Content: "https://example.com/10003", Created: "2020-12-01T10:00:00.000+0100"},
+	}
+	contents := archives{"10001": testArchive(2), "10002": testArchive(45)}
+
+	var buf bytes.Buffer
+	renderPlain(&buf, rowsOf(attachments), defaultColumns, contents, nil, time.UTC)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	// 3 rows, 2 + 10 entries and a footer.
+	require.Len(t, lines, 16)
+
+	assert.Regexp(t, `^10001\s+small.zip\s+2.00 KB`, lines[0])
+	assert.Regexp(t, `^\s+  file-00.txt\s+1 kB`, lines[1])
+	assert.Regexp(t, `^\s+  file-01.txt\s+1 kB`, lines[2])
+	assert.Regexp(t, `^10002\s+large.zip`, lines[3])
+	assert.Regexp(t, `^\s+  file-09.txt\s+1 kB`, lines[13])
+	assert.Regexp(t, `^\s+  \+35 more`, lines[14])
+	assert.Regexp(t, `^10003\s+notes.txt`, lines[15])
+	assert.NotContains(t, buf.String(), "file-10.txt")
+
+	// The sub-listing is in the FILENAME column, the table pads with tabs.
+	tabsBefore := func(line, s string) int { return strings.Count(line[:strings.Index(line, s)], "\t") }
+	assert.Equal(t, tabsBefore(lines[0], "small.zip"), tabsBefore(lines[1], "file-00.txt"))
+}
+
+func newArchiveServer(t *testing.T) *jiratest.Server {
+	t.Helper()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"README.md", "bin/app"} {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, _ = f.Write([]byte(name))
+	}
+	require.NoError(t, zw.Close())
+
+	server.AddAttachment("TEST-1", "release.zip", buf.Bytes())
+	// Not a valid archive, the endpoint is not found.
+	server.AddAttachment("TEST-1", "broken.zip", []byte("not a zip"))
+	server.AddAttachment("TEST-1", "notes.txt", []byte("hello"))
+	return server
+}
+
+func expandRequests(server *jiratest.Server) int {
+	var n int
+	for _, r := range server.Requests() {
+		if strings.HasSuffix(r.Path, "/expand/human") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestListExpandArchives(t *testing.T) {
+	server := newArchiveServer(t)
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "installation": jira.InstallationTypeLocal},
+	}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "-o", "plain", "--expand-archives")
+	require.NoError(t, res.Err)
+	assert.Regexp(t, `release.zip[^\n]*\n\s+  README.md\s+9 B\s*\n\s+  bin/app\s+7 B`, res.Stdout)
+	assert.Regexp(t, `broken.zip[^\n]*\n3\s+10002\s+notes.txt`, res.Stdout)
+	assert.Empty(t, res.Stderr)
+	assert.Equal(t, 2, expandRequests(server))
+}
+
+func TestListExpandArchivesOnCloud(t *testing.T) {
+	server := newArchiveServer(t)
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "installation": jira.InstallationTypeCloud},
+	}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "-o", "plain", "--expand-archives")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "release.zip")
+	assert.NotContains(t, res.Stdout, "README.md")
+	assert.Contains(t, res.Stderr, "only available on Jira server and data center")
+	assert.Zero(t, expandRequests(server))
+}
+
+func TestListExpandArchivesRejectsCSV(t *testing.T) {
+	env := cmdtest.Env{Config: map[string]any{"installation": jira.InstallationTypeLocal}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--csv", "--expand-archives")
+	assert.EqualError(t, res.Err, "--expand-archives only works with the table and plain output")
+}
@@ -0,0 +1,176 @@
+package list
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+// listColumn is a column of the table, plain and csv output that can be picked with --columns.
+type listColumn struct {
+	name string
+	// optional columns are only shown if picked.
+	optional bool
+	// text returns the value shown in the table and plain output, dates in loc.
+	text func(a cmdcommon.IssueAttachment, loc *time.Location) string
+	// csv returns the value of the csv output, dates in UTC.
+	csv func(a cmdcommon.IssueAttachment) string
+}
+
+var listColumns = []listColumn{
+	{
+		name: "ID",
+		text: func(a cmdcommon.IssueAttachment, _ *time.Location) string { return a.ID },
+		csv:  func(a cmdcommon.IssueAttachment) string { return a.ID },
+	},
+	{
+		name: "FILENAME",
+		text: func(a cmdcommon.IssueAttachment, _ *time.Location) string {
+			name := cmdutil.SanitizeTerminalText(a.Filename)
+			if !a.Available() {
+				name += " " + unavailableMarker
+			}
+			return name
+		},
+		csv: func(a cmdcommon.IssueAttachment) string { return a.Filename },
+	},
+	{
+		name: "SIZE",
+		text: func(a cmdcommon.IssueAttachment, _ *time.Location) string { return cmdutil.FormatSize(a.Size) },
+		csv:  func(a cmdcommon.IssueAttachment) string { return strconv.FormatInt(a.Size, 10) },
+	},
+	{
+		name: "AUTHOR",
+		text: func(a cmdcommon.IssueAttachment, _ *time.Location) string {
+			return cmdutil.SanitizeTerminalText(cmdutil.AuthorName(a.Author))
+		},
+		csv: func(a cmdcommon.IssueAttachment) string { return cmdutil.AuthorName(a.Author) },
+	},
+	{
+		name: "CREATED",
+		text: func(a cmdcommon.IssueAttachment, loc *time.Location) string {
+			return a.FormatCreated(loc, time.DateOnly)
+		},
+		csv: func(a cmdcommon.IssueAttachment) string { return a.CreatedUTC() },
+	},
+	{
+		name:     "MIMETYPE",
+		optional: true,
+		text: func(a cmdcommon.IssueAttachment, _ *time.Location) string {
+			return cmdutil.SanitizeTerminalText(a.MimeType)
+		},
+		csv: func(a cmdcommon.IssueAttachment) string { return a.MimeType },
+	},
+}
+
+// defaultColumns are the columns shown without --columns, the ones not optional.
+var defaultColumns = func() []listColumn {
+	var columns []listColumn
+	for _, c := range listColumns {
+		if !c.optional {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}()
+
+func columnNames(columns []listColumn) []string {
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// parseColumns parses the comma separated --columns, in the order given. Names are case
+// insensitive. The default columns are returned if s is empty.
+func parseColumns(s string) ([]listColumn, error) {
+	if strings.TrimSpace(s) == "" {
+		return defaultColumns, nil
+	}
+
+	var columns []listColumn
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		i := slices.IndexFunc(listColumns, func(c listColumn) bool { return c.name == name })
+		if i < 0 {
+			return nil, cmdutil.Errorf("Invalid column %q for --columns, valid columns are: %s",
+				name, strings.Join(columnNames(listColumns), ", "))
+		}
+		if slices.ContainsFunc(columns, func(c listColumn) bool { return c.name == name }) {
+			return nil, cmdutil.Errorf("Column %q is given twice to --columns", name)
+		}
+		columns = append(columns, listColumns[i])
+	}
+	return columns, nil
+}
+
+// listOrder is a --order-by key.
+type listOrder struct {
+	name    string
+	compare func(a, b cmdcommon.IssueAttachment) int
+}
+
+var listOrders = []listOrder{
+	{name: "filename", compare: func(a, b cmdcommon.IssueAttachment) int {
+		return strings.Compare(strings.ToLower(a.Filename), strings.ToLower(b.Filename))
+	}},
+	{name: "size", compare: func(a, b cmdcommon.IssueAttachment) int {
+		return cmp.Compare(a.Size, b.Size)
+	}},
+	{name: "created", compare: compareCreated},
+	{name: "author", compare: func(a, b cmdcommon.IssueAttachment) int {
+		return strings.Compare(strings.ToLower(cmdutil.AuthorName(a.Author)), strings.ToLower(cmdutil.AuthorName(b.Author)))
+	}},
+}
+
+// compareCreated orders attachments by creation date, the ones with a date that can't be
+// parsed last.
+func compareCreated(a, b cmdcommon.IssueAttachment) int {
+	x, okX := a.CreatedTime()
+	y, okY := b.CreatedTime()
+	switch {
+	case okX && okY:
+		return x.Compare(y)
+	case okX:
+		return -1
+	case okY:
+		return 1
+	}
+	return 0
+}
+
+// parseOrder parses --order-by, case insensitive. A nil order is returned if s is empty.
+func parseOrder(s string) (*listOrder, error) {
+	if s == "" {
+		return nil, nil
+	}
+	name := strings.ToLower(strings.TrimSpace(s))
+	for i := range listOrders {
+		if listOrders[i].name == name {
+			return &listOrders[i], nil
+		}
+	}
+
+	names := make([]string, 0, len(listOrders))
+	for _, o := range listOrders {
+		names = append(names, o.name)
+	}
+	return nil, cmdutil.Errorf("Invalid --order-by %q, valid keys are: %s", s, strings.Join(names, ", "))
+}
+
+// sortRows orders the rows by the key, descending if reverse. The sort is stable, rows with
+// equal keys keep the order they are listed in either way.
+func sortRows(rows []cmdcommon.IssueAttachment, order *listOrder, reverse bool) {
+	slices.SortStableFunc(rows, func(a, b cmdcommon.IssueAttachment) int {
+		if reverse {
+			return order.compare(b, a)
+		}
+		return order.compare(a, b)
+	})
+}
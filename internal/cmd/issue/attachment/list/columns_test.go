@@ -0,0 +1,176 @@
+package list
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestParseColumns(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		input string
+		want  []string
+		err   string
+	}{
+		{input: "", want: []string{"ID", "FILENAME", "SIZE", "AUTHOR", "CREATED"}},
+		{input: "SIZE,FILENAME", want: []string{"SIZE", "FILENAME"}},
+		{input: " mimetype , id ", want: []string{"MIMETYPE", "ID"}},
+		{input: "ID,STATUS", err: `Invalid column "STATUS" for --columns, valid columns are: ID, FILENAME, SIZE, AUTHOR, CREATED, MIMETYPE`},
+		{input: "ID,", err: `Invalid column "" for --columns`},
+		{input: "id,ID", err: `Column "ID" is given twice to --columns`},
+	}
+
+	for _, tc := range cases {
+		columns, err := parseColumns(tc.input)
+		if tc.err != "" {
+			require.Error(t, err, tc.input)
+			assert.Contains(t, err.Error(), tc.err, tc.input)
+			continue
+		}
+		require.NoError(t, err, tc.input)
+		assert.Equal(t, tc.want, columnNames(columns), tc.input)
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	t.Parallel()
+
+	order, err := parseOrder("")
+	require.NoError(t, err)
+	assert.Nil(t, order)
+
+	order, err = parseOrder("Size")
+	require.NoError(t, err)
+	assert.Equal(t, "size", order.name)
+
+	_, err = parseOrder("updated")
+	assert.EqualError(t, err, `Invalid --order-by "updated", valid keys are: filename, size, created, author`)
+}
+
+func TestSortRows(t *testing.T) {
+	t.Parallel()
+
+	attachments := []jira.Attachment{
+		{ID: "1", Filename: "b.txt", Size: 20, Author: jira.User{DisplayName: "zoe"}, Created: "2024-01-02T10:00:00.000+0000"},
+		{ID: "2", Filename: "A.txt", Size: 10, Author: jira.User{DisplayName: "Adam"}, Created: "2024-01-03T10:00:00.000+0000"},
+		{ID: "3", Filename: "c.txt", Size: 20, Author: jira.User{DisplayName: "adam"}, Created: "not a date"},
+		{ID: "4", Filename: "a.txt", Size: 10, Author: jira.User{DisplayName: "Zoe"}, Created: "2024-01-01T10:00:00.000+0000"},
+	}
+
+	cases := []struct {
+		order   string
+		reverse bool
+		want    []string
+	}{
+		// Equal keys keep the order they are listed in, whether reversed or not.
+		{order: "size", want: []string{"2", "4", "1", "3"}},
+		{order: "size", reverse: true, want: []string{"1", "3", "2", "4"}},
+		{order: "filename", want: []string{"2", "4", "1", "3"}},
+		{order: "filename", reverse: true, want: []string{"3", "1", "2", "4"}},
+		{order: "author", want: []string{"2", "3", "1", "4"}},
+		{order: "author", reverse: true, want: []string{"1", "4", "2", "3"}},
+		// Dates that can't be parsed are last.
+		{order: "created", want: []string{"4", "1", "2", "3"}},
+		{order: "created", reverse: true, want: []string{"3", "2", "1", "4"}},
+	}
+
+	for _, tc := range cases {
+		order, err := parseOrder(tc.order)
+		require.NoError(t, err)
+
+		rows := rowsOf(attachments)
+		sortRows(rows, order, tc.reverse)
+
+		ids := make([]string, 0, len(rows))
+		for _, r := range rows {
+			ids = append(ids, r.ID)
+		}
+		assert.Equal(t, tc.want, ids, "%s reverse=%t", tc.order, tc.reverse)
+	}
+}
+
+func TestRenderColumns(t *testing.T) {
+	t.Parallel()
+
+	attachments := []jira.Attachment{
+		{ID: "10001", Filename: "shot.png", Size: 2048, MimeType: "image/png", Content: "https://example.com/10001", Created: "2024-01-02T10:00:00.000+0000"},
+	}
+	columns, err := parseColumns("mimetype,size,filename")
+	require.NoError(t, err)
+
+	var table, plain, csvOut bytes.Buffer
+	renderTable(&table, rowsOf(attachments), columns, nil, nil, time.UTC)
+	renderPlain(&plain, rowsOf(attachments), columns, nil, nil, time.UTC)
+	require.NoError(t, renderCSV(&csvOut, rowsOf(attachments), columns, nil))
+
+	assert.Equal(t, []string{"MIMETYPE", "SIZE", "FILENAME"}, strings.Fields(strings.Split(table.String(), "\n")[0]))
+	assert.Equal(t, []string{"image/png", "2.00", "KB", "shot.png"}, strings.Fields(strings.Split(table.String(), "\n")[1]))
+	assert.Equal(t, "image/png\t2.00 KB\tshot.png\n", plain.String())
+	assert.Equal(t, [][]string{
+		{"MIMETYPE", "SIZE", "FILENAME"},
+		{"image/png", "2048", "shot.png"},
+	}, readCSV(t, &csvOut))
+
+	// The issue and status columns frame the columns picked.
+	rows := []cmdcommon.IssueAttachment{{Issue: "TEST-2", Attachment: attachments[0]}}
+	csvOut.Reset()
+	require.NoError(t, renderCSV(&csvOut, rows, columns, []jira.LinkStatus{jira.LinkOK}))
+	assert.Equal(t, [][]string{
+		{"ISSUE", "MIMETYPE", "SIZE", "FILENAME", "STATUS"},
+		{"TEST-2", "image/png", "2048", "shot.png", "ok"},
+	}, readCSV(t, &csvOut))
+}
+
+func TestListOrderAndColumns(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "medium.txt", []byte("12345"))
+	server.AddAttachment("TEST-1", "large.txt", []byte("1234567890"))
+	server.AddAttachment("TEST-1", "small.txt", []byte("1"))
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--order-by", "size", "--reverse", "--columns", "filename,size", "-o", "csv")
+	require.NoError(t, res.Err)
+	assert.Equal(t, [][]string{
+		{"FILENAME", "SIZE"},
+		{"large.txt", "10"},
+		{"medium.txt", "5"},
+		{"small.txt", "1"},
+	}, readCSV(t, strings.NewReader(res.Stdout)))
+
+	// The order applies to the structured output too.
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--order-by", "filename", "-o", "json")
+	require.NoError(t, res.Err)
+	assert.Less(t, strings.Index(res.Stdout, "large.txt"), strings.Index(res.Stdout, "medium.txt"))
+	assert.Less(t, strings.Index(res.Stdout, "medium.txt"), strings.Index(res.Stdout, "small.txt"))
+
+	errCases := []struct {
+		args []string
+		err  string
+	}{
+		{args: []string{"--order-by", "date"}, err: "valid keys are: filename, size, created, author"},
+		{args: []string{"--columns", "id,name"}, err: "valid columns are: ID, FILENAME, SIZE, AUTHOR, CREATED, MIMETYPE"},
+		{args: []string{"--reverse"}, err: "--reverse only works with --order-by"},
+		{args: []string{"--columns", "id", "-o", "json"}, err: "--columns only works with the table, plain and csv output"},
+		{args: []string{"--columns", "id", "--expand-archives"}, err: "--columns can't be combined with --expand-archives"},
+		{args: []string{"--order-by", "size", "--changed-since", "2024-01-01"}, err: "--order-by and --columns can't be combined with --changed-since"},
+	}
+	for _, tc := range errCases {
+		res := cmdtest.Run(t, env, NewCmdAttachmentList(), append([]string{"TEST-1"}, tc.args...)...)
+		require.Error(t, res.Err, tc.args)
+		assert.Contains(t, res.Err.Error(), tc.err, tc.args)
+	}
+}
@@ -0,0 +1,182 @@
+package list
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	eventAdded   = "added"
+	eventRemoved = "removed"
+
+	// attachmentField is the changelog field of attachment changes.
+	attachmentField = "attachment"
+
+	eventTimeLayout = "2006-01-02 15:04:05 -0700"
+)
+
+// attachmentEvent is an attachment added to or removed from an issue, as recorded in its changelog.
+type attachmentEvent struct {
+	Action   string    `json:"action"`
+	ID       string    `json:"id"`
+	Filename string    `json:"filename"`
+	Actor    string    `json:"actor"`
+	Time     time.Time `json:"time"`
+	// Exists reports if an added attachment is still on the issue.
+	Exists bool `json:"exists"`
+}
+
+// changesOutput is the JSON and YAML output of --changed-since.
+type changesOutput struct {
+	Issue  string            `json:"issue"`
+	Since  time.Time         `json:"since"`
+	Events []attachmentEvent `json:"events"`
+}
+
+// attachmentEvents extracts the attachment changes made after since from the changelog, oldest
+// first. An added attachment has its id in To and name in ToString, a removed one in From and
+// FromString. Entries with a date that can't be parsed can't be placed in the window and are skipped.
+func attachmentEvents(changelog []jira.Changelog, since time.Time) []attachmentEvent {
+	var events []attachmentEvent
+
+	for _, entry := range changelog {
+		created, ok := jira.ParseCreated(entry.Created)
+		if !ok || created.Before(since) {
+			continue
+		}
+		created = created.UTC()
+		for _, item := range entry.Items {
+			if !strings.EqualFold(item.Field, attachmentField) && item.FieldID != attachmentField {
+				continue
+			}
+
+			ev := attachmentEvent{Actor: cmdutil.AuthorName(entry.Author), Time: created}
+			switch {
+			case item.To != "":
+				ev.Action, ev.ID, ev.Filename = eventAdded, item.To, item.ToString
+			case item.From != "":
+				ev.Action, ev.ID, ev.Filename = eventRemoved, item.From, item.FromString
+			default:
+				continue
+			}
+			events = append(events, ev)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
+
+// reconcile marks the added attachments that are still on the issue.
+func reconcile(events []attachmentEvent, current []jira.Attachment) {
+	ids := make(map[string]struct{}, len(current))
+	for _, a := range current {
+		ids[a.ID] = struct{}{}
+	}
+	for i, ev := range events {
+		if ev.Action != eventAdded {
+			continue
+		}
+		_, events[i].Exists = ids[ev.ID]
+	}
+}
+
+// status describes if an added attachment is still on the issue.
+func (ev attachmentEvent) status() string {
+	if ev.Action != eventAdded {
+		return "-"
+	}
+	if ev.Exists {
+		return "present"
+	}
+	return "gone"
+}
+
+// renderEventsTable renders the events with their date in loc.
+func renderEventsTable(w io.Writer, events []attachmentEvent, header bool, loc *time.Location) {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	if header {
+		fmt.Fprintf(tw, "DATE\tACTION\tID\tFILENAME\tACTOR\tSTATUS\n")
+	}
+	for _, ev := range events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			ev.Time.In(loc).Format(eventTimeLayout),
+			ev.Action,
+			ev.ID,
+			cmdutil.SanitizeTerminalText(ev.Filename),
+			cmdutil.SanitizeTerminalText(ev.Actor),
+			ev.status(),
+		)
+	}
+	_ = tw.Flush()
+}
+
+func renderEventsCSV(w io.Writer, events []attachmentEvent) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"DATE", "ACTION", "ID", "FILENAME", "ACTOR", "STATUS"})
+	for _, ev := range events {
+		_ = cw.Write([]string{
+			ev.Time.UTC().Format(time.RFC3339),
+			ev.Action,
+			ev.ID,
+			ev.Filename,
+			ev.Actor,
+			ev.status(),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// parseChangedSince parses the --changed-since date in local time, or a full Jira datetime.
+func parseChangedSince(value string) (time.Time, error) {
+	s, err := cmdutil.DateStringToJiraFormatInLocation(value, "Local")
+	if err != nil || s == "" {
+		return time.Time{}, cmdutil.Errorf("Invalid --changed-since date %q, use eg: 2024-01-31 or \"2024-01-31 15:04:05\"", value)
+	}
+	return time.Parse(jira.RFC3339MilliLayout, s)
+}
+
+// listChanges renders the attachments added to or removed from the issue since the cutoff.
+func listChanges(w io.Writer, client *jira.Client, params *listParams) error {
+	changelog, err := api.ProxyGetIssueChangelogVersion(client, params.apiVersion, params.issueKey)
+	if err != nil {
+		return cmdutil.RequestError(err, params.debug)
+	}
+	issue, err := cmdcommon.GetAttachmentIssue(client, params.apiVersion, params.issueKey, cmdcommon.AttachmentIssueFields)
+	if err != nil {
+		return cmdutil.RequestError(err, params.debug)
+	}
+
+	events := attachmentEvents(changelog, params.changedSince)
+	if len(events) == 0 && !params.output.Structured() {
+		cmdutil.Success("No attachments were added to or removed from issue %q since %s", params.issueKey, params.changedSince.In(params.loc).Format(eventTimeLayout))
+		return nil
+	}
+	reconcile(events, issue.Fields.Attachments)
+
+	switch {
+	case params.output.Structured():
+		if events == nil {
+			events = []attachmentEvent{}
+		}
+		return params.output.Encode(w, changesOutput{Issue: params.issueKey, Since: params.changedSince.UTC(), Events: events})
+	case params.excel:
+		return renderEventsCSV(cmdutil.NewExcelWriter(w), events)
+	case params.output.Format == cmdutil.OutputCSV:
+		return renderEventsCSV(w, events)
+	default:
+		renderEventsTable(w, events, params.output.Format != cmdutil.OutputPlain, params.loc)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package list
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func loadChangelog(t *testing.T) []jira.Changelog {
+	t.Helper()
+
+	b, err := os.ReadFile("./testdata/changelog.json")
+	require.NoError(t, err)
+
+	var out []jira.Changelog
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out
+}
+
+var cutoff = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+func TestAttachmentEvents(t *testing.T) {
+	t.Parallel()
+
+	events := attachmentEvents(loadChangelog(t), cutoff)
+
+	type row struct{ action, id, name, actor string }
+	got := make([]row, 0, len(events))
+	for _, ev := range events {
+		got = append(got, row{ev.Action, ev.ID, ev.Filename, ev.Actor})
+	}
+
+	// The add of old-spec.pdf is before the cutoff, only its removal is listed.
+	assert.Equal(t, []row{
+		{eventAdded, "10002", "report.pdf", "Jon Doe"},
+		{eventAdded, "10003", "draft.docx", "Jon Doe"},
+		{eventRemoved, "10003", "draft.docx", "Jane Doe"},
+		{eventRemoved, "10001", "old-spec.pdf", "Jane Doe"},
+		{eventAdded, "10004", "notes, v2.txt", "Jane Doe"},
+	}, got)
+	assert.True(t, events[2].Time.Equal(time.Date(2024, 2, 3, 11, 30, 0, 0, time.UTC)))
+
+	assert.Empty(t, attachmentEvents(loadChangelog(t), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Len(t, attachmentEvents(loadChangelog(t), time.Time{}), 6)
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	events := attachmentEvents(loadChangelog(t), cutoff)
+	reconcile(events, []jira.Attachment{{ID: "10002"}, {ID: "10004"}})
+
+	statuses := make([]string, 0, len(events))
+	for _, ev := range events {
+		statuses = append(statuses, ev.status())
+	}
+	// draft.docx was added and removed within the window, so it's gone.
+	assert.Equal(t, []string{"present", "gone", "-", "-", "present"}, statuses)
+}
+
+func TestRenderEvents(t *testing.T) {
+	t.Parallel()
+
+	events := attachmentEvents(loadChangelog(t), time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC))
+	reconcile(events, []jira.Attachment{{ID: "10004"}})
+
+	var buf bytes.Buffer
+	renderEventsTable(&buf, events, true, time.UTC)
+	assert.Equal(t, "DATE\t\t\t\tACTION\tID\tFILENAME\tACTOR\t\tSTATUS\n"+
+		"2024-02-04 08:15:00 +0000\tadded\t10004\tnotes, v2.txt\tJane Doe\tpresent\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, renderEventsCSV(&buf, events))
+	assert.Equal(t, [][]string{
+		{"DATE", "ACTION", "ID", "FILENAME", "ACTOR", "STATUS"},
+		{"2024-02-04T08:15:00Z", "added", "10004", "notes, v2.txt", "Jane Doe", "present"},
+	}, readCSV(t, &buf))
+}
+
+func TestParseChangedSince(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseChangedSince("2024-02-01T10:00:00.000+0100")
+	require.NoError(t, err)
+	assert.True(t, got.Equal(time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)))
+
+	got, err = parseChangedSince("2024-02-01")
+	require.NoError(t, err)
+	assert.True(t, got.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)))
+
+	_, err = parseChangedSince("last week")
+	assert.Error(t, err)
+}
+
+func TestListChangedSince(t *testing.T) {
+	for _, installation := range []string{"Cloud", "Local"} {
+		t.Run(installation, func(t *testing.T) {
+			server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+			defer server.Close()
+
+			server.AddChangelog("TEST-1", loadChangelog(t)...)
+			server.AddAttachment("TEST-1", "report.pdf", []byte("pdf"))
+
+			env := cmdtest.Env{
+				Client: server.Client(),
+				Config: map[string]any{"server": server.URL, "installation": installation},
+			}
+			res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--changed-since", "2024-02-01T00:00:00.000+0000", "--csv")
+			require.NoError(t, res.Err)
+
+			// The fake server numbers attachments from 10000, so only removals and gone files match the fixture.
+			assert.Contains(t, res.Stdout, "2024-02-02T11:00:00Z,added,10003,draft.docx,Jon Doe,gone\n")
+			assert.Contains(t, res.Stdout, "2024-02-03T11:30:00Z,removed,10001,old-spec.pdf,Jane Doe,-\n")
+		})
+	}
+}
+
+func TestListChangedSinceRejectsFilters(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--changed-since", "2024-02-01", "--include-subtasks")
+	assert.ErrorContains(t, res.Err, "--changed-since can't be combined")
+}
@@ -0,0 +1,72 @@
+package list
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// probeConcurrency is the number of content URLs probed at the same time with
+// --verify-links, the same cap as the concurrent downloads.
+const probeConcurrency = 8
+
+// verifyLinks probes the content URLs of the rows with at most probeConcurrency probes at
+// a time, each bounded by timeout. The statuses are in the order of the rows. Attachments
+// without a content URL are missing, they aren't probed.
+func verifyLinks(client *jira.Client, version string, rows []cmdcommon.IssueAttachment, timeout time.Duration) []jira.LinkStatus {
+	statuses := make([]jira.LinkStatus, len(rows))
+
+	var (
+		jobs = make(chan int)
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < min(probeConcurrency, len(rows)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Each worker writes to its own slots of statuses, so no locking is required.
+			for i := range jobs {
+				statuses[i] = api.ProxyProbeAttachmentVersion(context.Background(), client, version, rows[i].Attachment, timeout).Status
+			}
+		}()
+	}
+
+	for i, r := range rows {
+		if !r.Available() {
+			statuses[i] = jira.LinkMissing
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return statuses
+}
+
+// linksError fails with --strict if content URLs aren't ok, the attachments are listed
+// with their status.
+func linksError(rows []cmdcommon.IssueAttachment, statuses []jira.LinkStatus, strict bool) error {
+	var broken []string
+	for i, s := range statuses {
+		if s != jira.LinkOK {
+			broken = append(broken, fmt.Sprintf("%q (%s)", rows[i].Filename, s))
+		}
+	}
+	if len(broken) == 0 {
+		return nil
+	}
+	if !strict {
+		cmdutil.Warn("The content of %d of %d attachment(s) can't be downloaded", len(broken), len(rows))
+		return nil
+	}
+	return cmdutil.Errorf("The content of %d of %d attachment(s) can't be downloaded: %s", len(broken), len(rows), strings.Join(broken, ", "))
+}
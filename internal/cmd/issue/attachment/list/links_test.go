@@ -0,0 +1,124 @@
+package list
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+// linkServer serves content with the status scripted by the last element of the path, a
+// status code or "hang", and records the largest number of requests served at once.
+type linkServer struct {
+	mu      sync.Mutex
+	current int
+	peak    int
+}
+
+func (s *linkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.current++
+	s.peak = max(s.peak, s.current)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.current--
+		s.mu.Unlock()
+	}()
+
+	script := r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:]
+	if script == "hang" {
+		<-r.Context().Done()
+		return
+	}
+	code, _ := strconv.Atoi(script)
+	// Later rows answer first so that the results complete out of order.
+	time.Sleep(time.Duration(100-code%100) * time.Millisecond / 10)
+	w.WriteHeader(code)
+}
+
+func (s *linkServer) maxInFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peak
+}
+
+func TestVerifyLinks(t *testing.T) {
+	t.Parallel()
+
+	ls := &linkServer{}
+	server := httptest.NewServer(ls)
+	t.Cleanup(server.Close)
+
+	scripts := []string{"200", "404", "403", "hang", "200", "500", "410", "206", "401", "200", "404", "200"}
+	want := []jira.LinkStatus{
+		jira.LinkOK, jira.LinkMissing, jira.LinkForbidden, jira.LinkError, jira.LinkOK, jira.LinkError,
+		jira.LinkMissing, jira.LinkOK, jira.LinkForbidden, jira.LinkOK, jira.LinkMissing, jira.LinkOK,
+	}
+	attachments := make([]jira.Attachment, 0, len(scripts)+1)
+	for i, s := range scripts {
+		attachments = append(attachments, jira.Attachment{ID: strconv.Itoa(i), Content: fmt.Sprintf("%s/content/%d/%s", server.URL, i, s)})
+	}
+	// Attachments without a content URL aren't probed.
+	attachments = append(attachments, jira.Attachment{ID: "archived"})
+	want = append(want, jira.LinkMissing)
+
+	client := jira.NewClient(jira.Config{Server: server.URL}, jira.WithTimeout(10*time.Second))
+	start := time.Now()
+	statuses := verifyLinks(client, "3", rowsOf(attachments), 300*time.Millisecond)
+
+	assert.Equal(t, want, statuses, "the statuses are in the order of the rows")
+	assert.Less(t, time.Since(start), 2*time.Second, "a hanging URL only holds its own probe")
+	assert.Greater(t, ls.maxInFlight(), 1)
+	assert.LessOrEqual(t, ls.maxInFlight(), probeConcurrency)
+}
+
+func TestListVerifyLinks(t *testing.T) {
+	fake := jiratest.New(jiratest.WithIssues("TEST-1"))
+	fake.AddAttachment("TEST-1", "kept.pdf", []byte("kept"))
+	lost := fake.AddAttachment("TEST-1", "lost.pdf", []byte("lost"))
+	// The content of lost.pdf is gone, as after a migration that lost a binary.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/secure/attachment/"+lost.ID+"/") ||
+			strings.HasSuffix(r.URL.Path, "/attachment/content/"+lost.ID) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	env := cmdtest.Env{Client: jira.NewClient(jira.Config{Server: server.URL}, jira.WithTimeout(5*time.Second))}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--verify-links")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "STATUS")
+	lines := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(lines[1]), "ok"), lines[1])
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(lines[2]), "missing"), lines[2])
+	assert.Contains(t, res.Stderr, "The content of 1 of 2 attachment(s) can't be downloaded")
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--verify-links", "--strict", "--csv")
+	assert.EqualError(t, res.Err, `The content of 1 of 2 attachment(s) can't be downloaded: "lost.pdf" (missing)`)
+	assert.Contains(t, res.Stdout, "ID,FILENAME,SIZE,AUTHOR,CREATED,STATUS\n")
+	assert.Contains(t, res.Stdout, ",missing\n")
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--verify-links", "--strict", "--quiet")
+	assert.Error(t, res.Err)
+	assert.Empty(t, res.Stdout)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--strict")
+	assert.EqualError(t, res.Err, "--strict only works with --verify-links")
+}
@@ -1,15 +1,19 @@
 package list
 
 import (
+	"encoding/csv"
 	"fmt"
 	"io"
-	"os"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
-	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/selector"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
 	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
 	"github.com/ankitpokhrel/jira-cli/internal/query"
 	"github.com/ankitpokhrel/jira-cli/pkg/jira"
@@ -20,14 +24,52 @@ const (
 	examples = `$ jira issue attachment list ISSUE-1
 
 # List attachments in CSV format
-$ jira issue attachment list ISSUE-1 --csv
+$ jira issue attachment list ISSUE-1 --output csv
+
+# List attachments as JSON or YAML
+$ jira issue attachment list ISSUE-1 -o json
+$ jira issue attachment list ISSUE-1 -o yaml
+
+# List attachments in CSV format that opens correctly in Excel
+$ jira issue attachment list ISSUE-1 --excel > attachments.csv
 
 # List attachments in plain text
-$ jira issue attachment list ISSUE-1 --plain`
+$ jira issue attachment list ISSUE-1 -o plain
+
+# List attachments you uploaded more than 30 days ago
+$ jira issue attachment list ISSUE-1 --mine --older-than 30d
+
+# List attachments of the issue and its subtasks
+$ jira issue attachment list ISSUE-1 --include-subtasks
+
+# List attachments added or removed since the last audit
+$ jira issue attachment list ISSUE-1 --changed-since 2024-01-31
+
+# List the 3 most recent PDFs
+$ jira issue attachment list ISSUE-1 --name "*.pdf" --latest 3
+
+# List images larger than 1MB
+$ jira issue attachment list ISSUE-1 --where 'mimetype ~ "image/*" and size > 1MB'
+
+# Fail unless the issue has release notes attached, eg: in a CI gate
+$ jira issue attachment list ISSUE-1 --where 'filename ~ "release-notes*.pdf"' --fail-on-empty --quiet
+
+# Show the files inside attached zip and jar archives (Jira server and data center)
+$ jira issue attachment list ISSUE-1 --expand-archives
+
+# Fail unless the issue has at least 3 images attached
+$ jira issue attachment list ISSUE-1 --where 'mimetype ~ "image/*"' --min-count 3
+
+# List the largest attachments first, with their mime type
+$ jira issue attachment list ISSUE-1 --order-by size --reverse --columns filename,size,mimetype
+
+# Check that the content of each attachment can still be downloaded, eg: after a migration
+$ jira issue attachment list ISSUE-1 --verify-links --strict`
 )
 
 // NewCmdAttachmentList is an attachment list command.
-func NewCmdAttachmentList() *cobra.Command {
+func NewCmdAttachmentList(opts ...cmdcommon.Options) *cobra.Command {
+	o := cmdcommon.ResolveOptions(opts)
 	cmd := cobra.Command{
 		Use:     "list ISSUE-KEY",
 		Short:   "List attachments on an issue",
@@ -37,48 +79,268 @@ func NewCmdAttachmentList() *cobra.Command {
 		Annotations: map[string]string{
 			"help:args": "ISSUE-KEY\tIssue key, eg: ISSUE-1",
 		},
-		Run: list,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return list(cmd, args, o)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
-	cmd.Flags().Bool("plain", false, "Plain text output")
-	cmd.Flags().Bool("csv", false, "CSV output")
+	cmdutil.RegisterOutputFlag(&cmd,
+		[]string{cmdutil.OutputTable, cmdutil.OutputPlain, cmdutil.OutputCSV, cmdutil.OutputJSON, cmdutil.OutputYAML},
+		cmdutil.OutputAlias{Flag: "plain", Format: cmdutil.OutputPlain, Usage: "Plain text output"},
+		cmdutil.OutputAlias{Flag: "csv", Format: cmdutil.OutputCSV, Usage: "CSV output"},
+	)
+	cmd.Flags().Bool("excel", false, "CSV output for Excel with a UTF-8 BOM and CRLF line endings, implies --output csv")
+	cmd.Flags().Bool("include-subtasks", false, "Include attachments of the subtasks of the issue")
+	cmd.Flags().String("changed-since", "", "List attachments added or removed since the date, eg: 2024-01-31 or \"2024-01-31 15:04:05\" in local time")
+	cmd.Flags().Bool("fail-on-empty", false, "Exit with an error if no attachment matches the filters")
+	cmd.Flags().Uint("min-count", 0, "Exit with an error if fewer than N attachments match the filters")
+	cmd.Flags().Bool("quiet", false, "Print nothing, only set the exit status")
+	cmd.Flags().Bool("expand-archives", false, "List the files inside attached zip and jar archives (Jira server and data center only)")
+	cmd.Flags().Bool("verify-links", false, "Check that the content of each attachment can be downloaded and show it in a STATUS column: ok, missing, forbidden or error")
+	cmd.Flags().Bool("strict", false, "Exit with a non-zero status if --verify-links finds attachments whose status isn't ok")
+	cmd.Flags().String("order-by", "", "Order the attachments by filename, size, created or author")
+	cmd.Flags().Bool("reverse", false, "Reverse the order of --order-by, eg: the largest first")
+	cmd.Flags().String("columns", "", fmt.Sprintf("Comma separated columns of the table, plain and csv output, in order. Accepts: %s",
+		strings.Join(columnNames(listColumns), ", ")))
+	selector.SetFlags(&cmd)
+	cmdcommon.SetTimezoneFlag(&cmd)
+
+	o.Apply(&cmd)
 
 	return &cmd
 }
 
-func list(cmd *cobra.Command, args []string) {
-	params := parseArgsAndFlags(args, cmd.Flags())
-	client := api.DefaultClient(params.debug)
+func list(cmd *cobra.Command, args []string, o cmdcommon.Options) error {
+	params, err := parseArgsAndFlags(args, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	if params.output, err = resolveOutput(cmd, params.excel); err != nil {
+		return err
+	}
+	if params.loc, err = cmdcommon.DisplayLocation(cmd); err != nil {
+		return err
+	}
+	if params.apiVersion, err = cmdcommon.GetAPIVersion(cmd); err != nil {
+		return err
+	}
+	client, err := o.Client(params.debug)
+	if err != nil {
+		return err
+	}
 
 	if params.issueKey == "" {
-		cmdutil.Failed("ISSUE-KEY is required")
+		return cmdutil.Errorf("ISSUE-KEY is required")
+	}
+	if params.expandArchives && params.output.Format != cmdutil.OutputTable && params.output.Format != cmdutil.OutputPlain {
+		return cmdutil.Errorf("--expand-archives only works with the table and plain output")
+	}
+	if params.strict && !params.verifyLinks {
+		return cmdutil.Errorf("--strict only works with --verify-links")
+	}
+	if err := checkColumns(params); err != nil {
+		return err
 	}
 
-	issue, err := api.ProxyGetIssue(client, params.issueKey)
-	cmdutil.ExitIfError(err)
+	if !params.changedSince.IsZero() {
+		if params.includeSubtasks || params.selector.Active() {
+			return cmdutil.Errorf("--changed-since can't be combined with --include-subtasks or attachment filters")
+		}
+		if params.minCount > 0 {
+			return cmdutil.Errorf("--fail-on-empty and --min-count can't be combined with --changed-since")
+		}
+		if params.expandArchives {
+			return cmdutil.Errorf("--expand-archives can't be combined with --changed-since")
+		}
+		if params.verifyLinks {
+			return cmdutil.Errorf("--verify-links can't be combined with --changed-since")
+		}
+		if params.order != nil || params.columnsSet {
+			return cmdutil.Errorf("--order-by and --columns can't be combined with --changed-since")
+		}
+		return listChanges(cmd.OutOrStdout(), client, params)
+	}
+
+	rows, err := listRows(client, params)
+	if err != nil {
+		return err
+	}
+	if err := checkCount(rows, params); err != nil {
+		return err
+	}
+	if params.order != nil {
+		sortRows(rows, params.order, params.reverse)
+	}
 
-	if len(issue.Fields.Attachments) == 0 {
+	var statuses []jira.LinkStatus
+	if params.verifyLinks {
+		statuses = verifyLinks(client, params.apiVersion, rows, params.probeTimeout)
+	}
+	if params.quiet {
+		if params.strict {
+			return linksError(rows, statuses, true)
+		}
+		return nil
+	}
+	if params.output.Structured() {
+		if err := params.output.Encode(cmd.OutOrStdout(), newListOutput(params.issueKey, rows, statuses)); err != nil {
+			return err
+		}
+		return linksError(rows, statuses, params.strict)
+	}
+	if len(rows) == 0 {
 		cmdutil.Success("No attachments found for issue %q", params.issueKey)
-		return
+		return nil
 	}
 
-	if params.csv {
-		renderCSV(os.Stdout, issue.Fields.Attachments)
-	} else if params.plain {
-		renderPlain(os.Stdout, issue.Fields.Attachments)
-	} else {
-		renderTable(os.Stdout, issue.Fields.Attachments)
+	var contents archives
+	if params.expandArchives {
+		if contents, err = expandArchives(client, rows, viper.GetString("installation"), params.debug); err != nil {
+			return err
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case params.excel:
+		if err := renderCSV(cmdutil.NewExcelWriter(out), rows, params.columns, statuses); err != nil {
+			return err
+		}
+	case params.output.Format == cmdutil.OutputCSV:
+		if err := renderCSV(out, rows, params.columns, statuses); err != nil {
+			return err
+		}
+	case params.output.Format == cmdutil.OutputPlain:
+		renderPlain(out, rows, params.columns, contents, statuses, params.loc)
+	default:
+		renderTable(out, rows, params.columns, contents, statuses, params.loc)
+	}
+	return linksError(rows, statuses, params.strict)
+}
+
+// resolveOutput resolves the output format, --excel implies csv.
+func resolveOutput(cmd *cobra.Command, excel bool) (cmdutil.Renderer, error) {
+	output, err := cmdutil.ResolveRenderer(cmd)
+	if err != nil {
+		return output, err
+	}
+	return output.Imply(excel, "excel", cmdutil.OutputCSV)
+}
+
+// checkColumns rejects --columns and --reverse where they don't apply.
+func checkColumns(params *listParams) error {
+	if params.reverse && params.order == nil {
+		return cmdutil.Errorf("--reverse only works with --order-by")
+	}
+	if !params.columnsSet {
+		return nil
+	}
+	if params.output.Structured() {
+		return cmdutil.Errorf("--columns only works with the table, plain and csv output")
+	}
+	if params.expandArchives {
+		return cmdutil.Errorf("--columns can't be combined with --expand-archives")
+	}
+	return nil
+}
+
+// listRows fetches the attachments of the issue, and of its subtasks with --include-subtasks,
+// and applies the filters. The rows are the ones rendered and counted by the --min-count gate.
+func listRows(client *jira.Client, params *listParams) ([]cmdcommon.IssueAttachment, error) {
+	if params.includeSubtasks {
+		issues, err := cmdcommon.FetchAttachmentsWithSubtasks(client, params.apiVersion, params.issueKey, params.debug)
+		if err != nil {
+			return nil, err
+		}
+		if issues, params.report, err = params.selector.SelectIssues(client, issues); err != nil {
+			return nil, err
+		}
+		return numberRows(cmdcommon.MergeByCreated(issues), params.report.Numbers), nil
+	}
+
+	issue, err := cmdcommon.GetAttachmentIssue(client, params.apiVersion, params.issueKey, cmdcommon.AttachmentIssueFields)
+	if err != nil {
+		return nil, cmdutil.RequestError(err, params.debug)
+	}
+
+	var attachments []jira.Attachment
+	if attachments, params.report, err = params.selector.Select(client, issue.Fields.Attachments); err != nil {
+		return nil, err
+	}
+	rows := make([]cmdcommon.IssueAttachment, 0, len(attachments))
+	for _, a := range attachments {
+		rows = append(rows, cmdcommon.IssueAttachment{Attachment: a})
+	}
+	return numberRows(rows, params.report.Numbers), nil
+}
+
+// numberRows sets the position each row is selected by with --index, so that the numbers
+// of the # column are the ones download and remove take.
+func numberRows(rows []cmdcommon.IssueAttachment, numbers map[string]int) []cmdcommon.IssueAttachment {
+	for i := range rows {
+		rows[i].Index = numbers[rows[i].ID]
 	}
+	return rows
+}
+
+// checkCount fails if fewer rows than --min-count, or none with --fail-on-empty, matched.
+// The error lists the filters that were applied, as they are the usual suspects, and
+// which one removed the last attachments if several were.
+func checkCount(rows []cmdcommon.IssueAttachment, params *listParams) error {
+	if len(rows) >= int(params.minCount) {
+		return nil
+	}
+
+	filters := params.selector.Describe()
+	if params.includeSubtasks {
+		filters = append([]string{"--include-subtasks"}, filters...)
+	}
+	applied := "no filters"
+	if len(filters) > 0 {
+		applied = "filters: " + strings.Join(filters, " ")
+	}
+	if why := params.report.Explain(); why != "" {
+		applied += ", " + why
+	}
+
+	if params.minCount == 1 {
+		return cmdutil.Errorf("No attachments found for issue %q (%s)", params.issueKey, applied)
+	}
+	return cmdutil.Errorf("Found %d attachment(s) for issue %q, expected at least %d (%s)",
+		len(rows), params.issueKey, params.minCount, applied)
 }
 
 type listParams struct {
-	issueKey string
-	plain    bool
-	csv      bool
-	debug    bool
+	issueKey        string
+	output          cmdutil.Renderer
+	excel           bool
+	includeSubtasks bool
+	changedSince    time.Time
+	selector        *selector.Selector
+	report          selector.SelectionReport
+	minCount        uint
+	quiet           bool
+	expandArchives  bool
+	verifyLinks     bool
+	strict          bool
+	// columns are the columns of the table, plain and csv output, columnsSet if picked
+	// with --columns.
+	columns    []listColumn
+	columnsSet bool
+	// order is the --order-by key, nil to keep the order of the issue.
+	order   *listOrder
+	reverse bool
+	// probeTimeout bounds each probe of --verify-links.
+	probeTimeout time.Duration
+	// loc is the timezone of the dates in the table and plain output.
+	loc        *time.Location
+	debug      bool
+	apiVersion string
 }
 
-func parseArgsAndFlags(args []string, flags query.FlagParser) *listParams {
+func parseArgsAndFlags(args []string, flags query.FlagParser) (*listParams, error) {
 	var issueKey string
 
 	if len(args) >= 1 {
@@ -86,109 +348,247 @@ func parseArgsAndFlags(args []string, flags query.FlagParser) *listParams {
 	}
 
 	debug, err := flags.GetBool("debug")
-	cmdutil.ExitIfError(err)
+	if err != nil {
+		return nil, err
+	}
 
-	plain, err := flags.GetBool("plain")
-	cmdutil.ExitIfError(err)
+	excel, err := flags.GetBool("excel")
+	if err != nil {
+		return nil, err
+	}
 
-	csv, err := flags.GetBool("csv")
-	cmdutil.ExitIfError(err)
+	includeSubtasks, err := flags.GetBool("include-subtasks")
+	if err != nil {
+		return nil, err
+	}
 
-	return &listParams{
-		issueKey: issueKey,
-		plain:    plain,
-		csv:      csv,
-		debug:    debug,
+	changedSinceFlag, err := flags.GetString("changed-since")
+	if err != nil {
+		return nil, err
 	}
-}
 
-func renderTable(w io.Writer, attachments []jira.Attachment) {
-	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	var changedSince time.Time
+	if changedSinceFlag != "" {
+		if changedSince, err = parseChangedSince(changedSinceFlag); err != nil {
+			return nil, err
+		}
+	}
 
-	fmt.Fprintf(tw, "ID\tFILENAME\tSIZE\tAUTHOR\tCREATED\n")
+	sel, err := selector.New(flags)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, a := range attachments {
-		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-			a.ID,
-			a.Filename,
-			formatSize(a.Size),
-			a.Author.DisplayName,
-			formatDate(a.Created),
-		)
+	failOnEmpty, err := flags.GetBool("fail-on-empty")
+	if err != nil {
+		return nil, err
 	}
 
-	_ = tw.Flush()
-}
+	minCount, err := flags.GetUint("min-count")
+	if err != nil {
+		return nil, err
+	}
+	if failOnEmpty {
+		minCount = max(minCount, 1)
+	}
 
-func renderPlain(w io.Writer, attachments []jira.Attachment) {
-	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	quiet, err := flags.GetBool("quiet")
+	if err != nil {
+		return nil, err
+	}
 
-	for _, a := range attachments {
-		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-			a.ID,
-			a.Filename,
-			formatSize(a.Size),
-			a.Author.DisplayName,
-			formatDate(a.Created),
-		)
+	expandArchives, err := flags.GetBool("expand-archives")
+	if err != nil {
+		return nil, err
 	}
 
-	_ = tw.Flush()
+	verifyLinks, err := flags.GetBool("verify-links")
+	if err != nil {
+		return nil, err
+	}
+
+	strict, err := flags.GetBool("strict")
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := flags.GetString("order-by")
+	if err != nil {
+		return nil, err
+	}
+	order, err := parseOrder(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	reverse, err := flags.GetBool("reverse")
+	if err != nil {
+		return nil, err
+	}
+
+	columnsFlag, err := flags.GetString("columns")
+	if err != nil {
+		return nil, err
+	}
+	columns, err := parseColumns(columnsFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listParams{
+		issueKey:        issueKey,
+		excel:           excel,
+		includeSubtasks: includeSubtasks,
+		changedSince:    changedSince,
+		selector:        sel,
+		minCount:        minCount,
+		quiet:           quiet,
+		expandArchives:  expandArchives,
+		verifyLinks:     verifyLinks,
+		strict:          strict,
+		columns:         columns,
+		columnsSet:      columnsFlag != "",
+		order:           order,
+		reverse:         reverse,
+		probeTimeout:    jira.DefaultProbeTimeout,
+		debug:           debug,
+	}, nil
 }
 
-func renderCSV(w io.Writer, attachments []jira.Attachment) {
-	fmt.Fprintf(w, "ID,FILENAME,SIZE,AUTHOR,CREATED\n")
+// unavailableMarker flags attachments without a content URL, eg: of archived issues.
+const unavailableMarker = "[UNAVAILABLE]"
 
-	for _, a := range attachments {
-		fmt.Fprintf(w, "%s,%s,%d,%s,%s\n",
-			a.ID,
-			escapeCSV(a.Filename),
-			a.Size,
-			escapeCSV(a.Author.DisplayName),
-			a.Created,
-		)
+// hasIssueColumn reports if the rows are from more than the requested issue
+// and need a column with the issue key.
+func hasIssueColumn(rows []cmdcommon.IssueAttachment) bool {
+	return len(rows) > 0 && rows[0].Issue != ""
+}
+
+// hasIndexColumn reports if the rows are numbered and the table and plain output lead with
+// the # column.
+func hasIndexColumn(rows []cmdcommon.IssueAttachment) bool {
+	return len(rows) > 0 && rows[0].Index > 0
+}
+
+func renderTable(w io.Writer, rows []cmdcommon.IssueAttachment, columns []listColumn, contents archives, statuses []jira.LinkStatus, loc *time.Location) {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	names := header(rows, columns, statuses)
+	if hasIndexColumn(rows) {
+		names = append([]string{"#"}, names...)
 	}
+	fmt.Fprintln(tw, strings.Join(names, "\t"))
+	writeRows(tw, rows, columns, contents, statuses, loc)
+	_ = tw.Flush()
 }
 
-func formatSize(bytes int64) string {
-	const (
-		KB = 1024
-		MB = KB * 1024
-		GB = MB * 1024
-	)
+func renderPlain(w io.Writer, rows []cmdcommon.IssueAttachment, columns []listColumn, contents archives, statuses []jira.LinkStatus, loc *time.Location) {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	writeRows(tw, rows, columns, contents, statuses, loc)
+	_ = tw.Flush()
+}
 
-	switch {
-	case bytes >= GB:
-		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
-	case bytes >= MB:
-		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
-	case bytes >= KB:
-		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
-	default:
-		return fmt.Sprintf("%d B", bytes)
+// header returns the header of the columns, with the ISSUE column if the rows need one and
+// the STATUS column with --verify-links.
+func header(rows []cmdcommon.IssueAttachment, columns []listColumn, statuses []jira.LinkStatus) []string {
+	var names []string
+	if hasIssueColumn(rows) {
+		names = append(names, "ISSUE")
+	}
+	names = append(names, columnNames(columns)...)
+	if statuses != nil {
+		names = append(names, "STATUS")
 	}
+	return names
 }
 
-func formatDate(date string) string {
-	// Just return the raw date for now - could be enhanced with better formatting
-	if len(date) > 10 {
-		return date[:10]
+// writeRows writes the columns of the rows with their creation date in loc, and their link
+// status with --verify-links. Numbered rows lead with their --index position.
+func writeRows(w io.Writer, rows []cmdcommon.IssueAttachment, columns []listColumn, contents archives, statuses []jira.LinkStatus, loc *time.Location) {
+	withIssue, withIndex := hasIssueColumn(rows), hasIndexColumn(rows)
+	for i, a := range rows {
+		fields := make([]string, 0, len(columns)+3)
+		if withIndex {
+			fields = append(fields, strconv.Itoa(a.Index))
+		}
+		if withIssue {
+			fields = append(fields, a.Issue)
+		}
+		for _, c := range columns {
+			fields = append(fields, c.text(a, loc))
+		}
+		if statuses != nil {
+			fields = append(fields, string(statuses[i]))
+		}
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
+		if archive, ok := contents[a.ID]; ok {
+			writeArchiveEntries(w, archive, withIssue, withIndex)
+		}
 	}
-	return date
 }
 
-func escapeCSV(s string) string {
-	if containsSpecialChar(s) {
-		return fmt.Sprintf(`"%s"`, s)
+// listOutput is the JSON and YAML output of the list command.
+type listOutput struct {
+	Issue       string             `json:"issue"`
+	Attachments []attachmentOutput `json:"attachments"`
+}
+
+type attachmentOutput struct {
+	// Issue is set with --include-subtasks.
+	Issue    string `json:"issue,omitempty"`
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Author   string `json:"author"`
+	// Created is RFC 3339 in UTC.
+	Created   string `json:"created"`
+	Available bool   `json:"available"`
+	// Status is the link status, set with --verify-links.
+	Status jira.LinkStatus `json:"status,omitempty"`
+}
+
+func newListOutput(issueKey string, rows []cmdcommon.IssueAttachment, statuses []jira.LinkStatus) listOutput {
+	out := listOutput{Issue: issueKey, Attachments: make([]attachmentOutput, 0, len(rows))}
+	for i, a := range rows {
+		var status jira.LinkStatus
+		if statuses != nil {
+			status = statuses[i]
+		}
+		out.Attachments = append(out.Attachments, attachmentOutput{
+			Issue:     a.Issue,
+			ID:        a.ID,
+			Filename:  a.Filename,
+			Size:      a.Size,
+			MimeType:  a.MimeType,
+			Author:    cmdutil.AuthorName(a.Author),
+			Created:   a.CreatedUTC(),
+			Available: a.Available(),
+			Status:    status,
+		})
 	}
-	return s
+	return out
 }
 
-func containsSpecialChar(s string) bool {
-	for _, ch := range s {
-		if ch == ',' || ch == '"' || ch == '\n' {
-			return true
+// renderCSV writes a row for each attachment. Fields are quoted as needed by encoding/csv,
+// so that any filename reads back as is.
+func renderCSV(w io.Writer, rows []cmdcommon.IssueAttachment, columns []listColumn, statuses []jira.LinkStatus) error {
+	withIssue := hasIssueColumn(rows)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(header(rows, columns, statuses))
+	for i, a := range rows {
+		record := make([]string, 0, len(columns)+2)
+		if withIssue {
+			record = append(record, a.Issue)
+		}
+		for _, c := range columns {
+			record = append(record, c.csv(a))
+		}
+		if statuses != nil {
+			record = append(record, string(statuses[i]))
 		}
+		_ = cw.Write(record)
 	}
-	return false
+	cw.Flush()
+	return cw.Error()
 }
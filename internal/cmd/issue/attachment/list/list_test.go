@@ -2,124 +2,122 @@ package list
 
 import (
 	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
 	"testing"
+	"time"
+	_ "time/tzdata" // For --tz in environments without a timezone database.
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
 	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
 )
 
-func TestFormatSize(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		name     string
-		bytes    int64
-		expected string
-	}{
-		{
-			name:     "bytes",
-			bytes:    500,
-			expected: "500 B",
-		},
-		{
-			name:     "kilobytes",
-			bytes:    2048,
-			expected: "2.00 KB",
-		},
-		{
-			name:     "megabytes",
-			bytes:    5242880,
-			expected: "5.00 MB",
-		},
-		{
-			name:     "gigabytes",
-			bytes:    2147483648,
-			expected: "2.00 GB",
-		},
-	}
-
-	for _, tc := range tests {
-		tc := tc
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-			actual := formatSize(tc.bytes)
-			assert.Equal(t, tc.expected, actual)
-		})
+func rowsOf(attachments []jira.Attachment) []cmdcommon.IssueAttachment {
+	rows := make([]cmdcommon.IssueAttachment, 0, len(attachments))
+	for _, a := range attachments {
+		rows = append(rows, cmdcommon.IssueAttachment{Attachment: a})
 	}
+	return rows
 }
 
-func TestFormatDate(t *testing.T) {
+func TestCreatedDates(t *testing.T) {
 	t.Parallel()
 
+	// Created just after midnight in Auckland, the day before in UTC.
+	attachments := []jira.Attachment{
+		{ID: "10001", Filename: "nz.log", Size: 10, Content: "https://example.com/10001", Created: "2024-03-01T00:30:00.000+1300"},
+		{ID: "10002", Filename: "odd.log", Size: 10, Content: "https://example.com/10002", Created: "01/03/2024"},
+	}
+
 	tests := []struct {
 		name     string
-		date     string
+		loc      *time.Location
 		expected string
 	}{
-		{
-			name:     "full date",
-			date:     "2020-12-01T10:00:00.000+0100",
-			expected: "2020-12-01",
-		},
-		{
-			name:     "short date",
-			date:     "2020-12-01",
-			expected: "2020-12-01",
-		},
-		{
-			name:     "very short",
-			date:     "2020",
-			expected: "2020",
-		},
+		{name: "utc", loc: time.UTC, expected: "2024-02-29"},
+		{name: "auckland", loc: time.FixedZone("NZDT", 13*60*60), expected: "2024-03-01"},
+		{name: "honolulu", loc: time.FixedZone("HST", -10*60*60), expected: "2024-02-29"},
 	}
 
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			actual := formatDate(tc.date)
-			assert.Equal(t, tc.expected, actual)
+
+			var buf bytes.Buffer
+			renderPlain(&buf, rowsOf(attachments), defaultColumns, nil, nil, tc.loc)
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, 2)
+			assert.True(t, strings.HasSuffix(lines[0], "\t"+tc.expected), lines[0])
+			// Dates that can't be parsed are shown as returned, with a marker.
+			assert.True(t, strings.HasSuffix(lines[1], "\t01/03/2024 [UNPARSED]"), lines[1])
 		})
 	}
+
+	// Machine readable outputs are in UTC whatever the timezone.
+	var buf bytes.Buffer
+	require.NoError(t, renderCSV(&buf, rowsOf(attachments), defaultColumns, nil))
+	assert.Equal(t, [][]string{
+		{"ID", "FILENAME", "SIZE", "AUTHOR", "CREATED"},
+		{"10001", "nz.log", "10", "-", "2024-02-29T11:30:00Z"},
+		{"10002", "odd.log", "10", "-", "01/03/2024 [UNPARSED]"},
+	}, readCSV(t, &buf))
+
+	out := newListOutput("TEST-1", rowsOf(attachments), nil)
+	assert.Equal(t, "2024-02-29T11:30:00Z", out.Attachments[0].Created)
+	assert.Equal(t, "01/03/2024 [UNPARSED]", out.Attachments[1].Created)
 }
 
-func TestEscapeCSV(t *testing.T) {
+// readCSV parses the output of a csv renderer back into records.
+func readCSV(t *testing.T, r io.Reader) [][]string {
+	t.Helper()
+
+	records, err := csv.NewReader(r).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestRenderCSVRoundTrip(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
 		name     string
-		input    string
-		expected string
+		filename string
+		author   string
 	}{
-		{
-			name:     "no special chars",
-			input:    "normal text",
-			expected: "normal text",
-		},
-		{
-			name:     "with comma",
-			input:    "text, with comma",
-			expected: `"text, with comma"`,
-		},
-		{
-			name:     "with quote",
-			input:    `text "quoted"`,
-			expected: `"text "quoted""`,
-		},
-		{
-			name:     "with newline",
-			input:    "text\nwith newline",
-			expected: "\"text\nwith newline\"",
-		},
+		{name: "no special chars", filename: "normal.txt", author: "John Doe"},
+		{name: "with comma", filename: "text, with comma.txt", author: "Doe, John"},
+		{name: "with quote", filename: `report "final".pdf`, author: `John "JD" Doe`},
+		{name: "with newline", filename: "text\nwith newline.txt", author: "John\nDoe"},
+		{name: "with surrounding spaces", filename: "  padded.txt ", author: " John Doe  "},
+		{name: "only quotes", filename: `""`, author: `"`},
 	}
 
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			actual := escapeCSV(tc.input)
-			assert.Equal(t, tc.expected, actual)
+
+			attachments := []jira.Attachment{{
+				ID:       "10001",
+				Filename: tc.filename,
+				Author:   jira.User{DisplayName: tc.author},
+				Created:  "2020-12-01T10:00:00.000+0100",
+				Size:     42,
+			}}
+
+			var buf bytes.Buffer
+			require.NoError(t, renderCSV(&buf, rowsOf(attachments), defaultColumns, nil))
+			records := readCSV(t, &buf)
+			require.Len(t, records, 2)
+			assert.Equal(t, []string{"10001", tc.filename, "42", tc.author, "2020-12-01T09:00:00Z"}, records[1])
 		})
 	}
 }
@@ -149,7 +147,7 @@ func TestRenderTable(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	renderTable(&buf, attachments)
+	renderTable(&buf, rowsOf(attachments), defaultColumns, nil, nil, time.UTC)
 
 	output := buf.String()
 	assert.Contains(t, output, "ID")
@@ -185,7 +183,7 @@ func TestRenderPlain(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	renderPlain(&buf, attachments)
+	renderPlain(&buf, rowsOf(attachments), defaultColumns, nil, nil, time.UTC)
 
 	output := buf.String()
 	assert.Contains(t, output, "10001")
@@ -220,10 +218,308 @@ func TestRenderCSV(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	renderCSV(&buf, attachments)
+	require.NoError(t, renderCSV(&buf, rowsOf(attachments), defaultColumns, nil))
+	assert.Equal(t, [][]string{
+		{"ID", "FILENAME", "SIZE", "AUTHOR", "CREATED"},
+		{"10001", "document.pdf", "1048576", "John Doe", "2020-12-01T09:00:00Z"},
+		{"10002", "file, with comma.txt", "524288", "Jane Smith", "2020-12-02T14:30:00Z"},
+	}, readCSV(t, &buf))
+}
 
-	output := buf.String()
-	assert.Contains(t, output, "ID,FILENAME,SIZE,AUTHOR,CREATED")
-	assert.Contains(t, output, "10001,document.pdf,1048576,John Doe,2020-12-01T10:00:00.000+0100")
-	assert.Contains(t, output, `10002,"file, with comma.txt",524288,Jane Smith,2020-12-02T15:30:00.000+0100`)
+func TestRenderCSVExcel(t *testing.T) {
+	t.Parallel()
+
+	attachments := []jira.Attachment{
+		{ID: "10001", Filename: "報告書.pdf", Size: 1234567, Author: jira.User{DisplayName: "山田太郎"}, Created: "2020-12-01T10:00:00.000+0100"},
+	}
+
+	var plain, excel bytes.Buffer
+	require.NoError(t, renderCSV(&plain, rowsOf(attachments), defaultColumns, nil))
+	require.NoError(t, renderCSV(cmdutil.NewExcelWriter(&excel), rowsOf(attachments), defaultColumns, nil))
+
+	// The default output is left as is for unix tooling.
+	assert.Equal(t, "ID,FILENAME,SIZE,AUTHOR,CREATED\n10001,報告書.pdf,1234567,山田太郎,2020-12-01T09:00:00Z\n", plain.String())
+
+	assert.Equal(t, append(append([]byte{}, cmdutil.UTF8BOM...), []byte(
+		"ID,FILENAME,SIZE,AUTHOR,CREATED\r\n10001,報告書.pdf,1234567,山田太郎,2020-12-01T09:00:00Z\r\n",
+	)...), excel.Bytes())
+}
+
+func TestRenderSanitizesTerminalOutput(t *testing.T) {
+	t.Parallel()
+
+	attachments := []jira.Attachment{
+		{
+			ID:       "10001",
+			Filename: "invoice\u202efdp.exe",
+			Author: jira.User{
+				DisplayName: "\x1b[31mroot\x1b[0m",
+			},
+			Created: "2020-12-01T10:00:00.000+0100",
+			Size:    1024,
+		},
+	}
+
+	var table, plain, csvOut bytes.Buffer
+	renderTable(&table, rowsOf(attachments), defaultColumns, nil, nil, time.UTC)
+	renderPlain(&plain, rowsOf(attachments), defaultColumns, nil, nil, time.UTC)
+	require.NoError(t, renderCSV(&csvOut, rowsOf(attachments), defaultColumns, nil))
+
+	for _, out := range []string{table.String(), plain.String()} {
+		assert.Contains(t, out, "invoice<U+202E>fdp.exe")
+		assert.Contains(t, out, `\x1b[31mroot\x1b[0m`)
+		assert.NotContains(t, out, "\x1b")
+		assert.NotContains(t, out, "\u202e")
+	}
+
+	// CSV is machine readable and keeps the original bytes.
+	records := readCSV(t, &csvOut)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"10001", "invoice\u202efdp.exe", "1024", "\x1b[31mroot\x1b[0m"}, records[1][:4])
+}
+
+func TestRenderIssueColumn(t *testing.T) {
+	t.Parallel()
+
+	rows := []cmdcommon.IssueAttachment{
+		{Issue: "TEST-1", Attachment: jira.Attachment{ID: "10001", Filename: "spec.pdf", Size: 10}},
+		{Issue: "TEST-2", Attachment: jira.Attachment{ID: "10002", Filename: "log.txt", Size: 20}},
+	}
+
+	var table, plain, csvOut bytes.Buffer
+	renderTable(&table, rows, defaultColumns, nil, nil, time.UTC)
+	renderPlain(&plain, rows, defaultColumns, nil, nil, time.UTC)
+	require.NoError(t, renderCSV(&csvOut, rows, defaultColumns, nil))
+
+	assert.Regexp(t, `^ISSUE\s+ID\s+FILENAME`, table.String())
+	assert.Regexp(t, `(?m)^TEST-2\s+10002\s+log.txt`, table.String())
+	assert.Regexp(t, `(?m)^TEST-1\s+10001\s+spec.pdf`, plain.String())
+	assert.Equal(t, [][]string{
+		{"ISSUE", "ID", "FILENAME", "SIZE", "AUTHOR", "CREATED"},
+		{"TEST-1", "10001", "spec.pdf", "10", "-", ""},
+		{"TEST-2", "10002", "log.txt", "20", "-", ""},
+	}, readCSV(t, &csvOut))
+
+	var single bytes.Buffer
+	renderTable(&single, rowsOf([]jira.Attachment{rows[0].Attachment}), defaultColumns, nil, nil, time.UTC)
+	assert.NotContains(t, single.String(), "ISSUE")
+}
+
+func TestRenderPartialMetadata(t *testing.T) {
+	t.Parallel()
+
+	attachments := []jira.Attachment{
+		{ID: "10001", Filename: "archived.log", Size: 2048, Created: "2020-12-01T10:00:00.000+0100"},
+		{ID: "10002", Filename: "ok.txt", Size: 10, Content: "https://example.com/10002", Author: jira.User{DisplayName: "Jane"}},
+	}
+
+	var table, plain, csvOut bytes.Buffer
+	renderTable(&table, rowsOf(attachments), defaultColumns, nil, nil, time.UTC)
+	renderPlain(&plain, rowsOf(attachments), defaultColumns, nil, nil, time.UTC)
+	require.NoError(t, renderCSV(&csvOut, rowsOf(attachments), defaultColumns, nil))
+
+	for _, out := range []string{table.String(), plain.String()} {
+		assert.Regexp(t, `10001\s+archived.log \[UNAVAILABLE\]\s+2.00 KB\s+-\s+2020-12-01`, out)
+		assert.Regexp(t, `10002\s+ok.txt\s+10 B\s+Jane`, out)
+		assert.NotContains(t, out, "ok.txt [UNAVAILABLE]")
+	}
+	records := readCSV(t, &csvOut)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"10001", "archived.log", "2048", "-", "2020-12-01T09:00:00Z"}, records[1])
+}
+
+func newGateServer(t *testing.T) *jiratest.Server {
+	t.Helper()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	t.Cleanup(server.Close)
+
+	server.AddAttachment("TEST-1", "release-notes-1.2.pdf", []byte("%PDF-1.4"))
+	server.AddAttachment("TEST-1", "release-notes-1.2-draft.pdf", []byte("%PDF-1.4"))
+	server.AddAttachment("TEST-1", "screenshot.png", []byte("png"))
+	return server
+}
+
+func TestListMinCount(t *testing.T) {
+	server := newGateServer(t)
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	cases := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name: "non-empty passes",
+			args: []string{"--where", `filename ~ "release-notes*.pdf"`, "--fail-on-empty"},
+		},
+		{
+			name:    "empty after filter fails",
+			args:    []string{"--where", `filename ~ "release-notes*.zip"`, "--fail-on-empty"},
+			wantErr: `No attachments found for issue "TEST-1" (filters: --where 'filename ~ "release-notes*.zip"')`,
+		},
+		{
+			name: "min count boundary passes",
+			args: []string{"--where", `filename ~ "release-notes*.pdf"`, "--min-count", "2"},
+		},
+		{
+			name:    "min count above the matches fails",
+			args:    []string{"--where", `filename ~ "release-notes*.pdf"`, "--min-count", "3"},
+			wantErr: `Found 2 attachment(s) for issue "TEST-1", expected at least 3 (filters: --where 'filename ~ "release-notes*.pdf"')`,
+		},
+		{
+			name:    "without filters",
+			args:    []string{"--min-count", "4", "--fail-on-empty"},
+			wantErr: `Found 3 attachment(s) for issue "TEST-1", expected at least 4 (no filters)`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := cmdtest.Run(t, env, NewCmdAttachmentList(), append([]string{"TEST-1", "--plain"}, tc.args...)...)
+			if tc.wantErr != "" {
+				assert.EqualError(t, res.Err, tc.wantErr)
+				assert.Empty(t, res.Stdout)
+				return
+			}
+			require.NoError(t, res.Err)
+			assert.Contains(t, res.Stdout, "release-notes-1.2.pdf")
+			assert.Contains(t, res.Stdout, "release-notes-1.2-draft.pdf")
+			assert.NotContains(t, res.Stdout, "screenshot.png")
+		})
+	}
+}
+
+func TestListSelectionFlags(t *testing.T) {
+	server := newGateServer(t)
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	for _, name := range []string{"id", "name", "mime", "mine", "author", "older-than", "where", "latest"} {
+		assert.NotNil(t, NewCmdAttachmentList().Flags().Lookup(name), name)
+	}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--plain", "--name", "*-draft.pdf")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "release-notes-1.2-draft.pdf")
+	assert.Equal(t, 1, strings.Count(res.Stdout, "\n"))
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--plain", "--mime", "application/pdf", "--latest", "1")
+	require.NoError(t, res.Err)
+	assert.Equal(t, 1, strings.Count(res.Stdout, "\n"))
+
+	// With several filters, the one that removed the last attachments is named.
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--name", "*.pdf", "--mime", "image", "--fail-on-empty")
+	assert.EqualError(t, res.Err,
+		`No attachments found for issue "TEST-1" (filters: --name '*.pdf' --mime image, --mime image removed the last 2 of 3)`)
+}
+
+func TestListQuiet(t *testing.T) {
+	server := newGateServer(t)
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--where", `filename ~ "*.pdf"`, "--fail-on-empty", "--quiet")
+	require.NoError(t, res.Err)
+	assert.Empty(t, res.Stdout)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--where", `filename ~ "*.zip"`, "--fail-on-empty", "--quiet")
+	assert.Error(t, res.Err)
+	assert.Empty(t, res.Stdout)
+
+	// Without a gate, an empty result is not an error.
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--where", `filename ~ "*.zip"`, "--quiet")
+	require.NoError(t, res.Err)
+	assert.Empty(t, res.Stdout)
+}
+
+func TestListMinCountRejectsChangedSince(t *testing.T) {
+	server := newGateServer(t)
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--changed-since", "2024-02-01", "--fail-on-empty")
+	assert.EqualError(t, res.Err, "--fail-on-empty and --min-count can't be combined with --changed-since")
+}
+
+func TestListOutputFormats(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "design.pdf", []byte("%PDF-1.4"))
+	server.AddAttachment("TEST-1", "screenshot.png", []byte("png"))
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	for _, format := range []string{"table", "plain", "csv", "json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--output", format)
+			require.NoError(t, res.Err)
+			cmdtest.AssertOutput(t, format, res.Stdout)
+			assert.Contains(t, res.Stdout, "design.pdf")
+			assert.Contains(t, res.Stdout, "screenshot.png")
+		})
+	}
+
+	// YAML has the same schema as JSON.
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "-o", "yaml")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "issue: TEST-1\nattachments:\n  - id: \"")
+	assert.Contains(t, res.Stdout, "    filename: design.pdf\n")
+}
+
+func TestListOutputAliases(t *testing.T) {
+	server := newGateServer(t)
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--csv")
+	require.NoError(t, res.Err)
+	assert.True(t, strings.HasPrefix(res.Stdout, "ID,FILENAME,SIZE,AUTHOR,CREATED\n"))
+	assert.Contains(t, res.Stderr, "Flag --csv has been deprecated, use --output csv instead")
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--excel")
+	require.NoError(t, res.Err)
+	assert.True(t, strings.HasPrefix(res.Stdout, "\xEF\xBB\xBFID,FILENAME"))
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--plain", "-o", "json")
+	assert.EqualError(t, res.Err, "--plain conflicts with --output json")
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--excel", "-o", "yaml")
+	assert.EqualError(t, res.Err, "--excel can't be used with --output yaml")
+}
+
+func TestListOutputEmpty(t *testing.T) {
+	server := newGateServer(t)
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--where", `filename ~ "*.zip"`, "-o", "json")
+	require.NoError(t, res.Err)
+	assert.JSONEq(t, `{"issue": "TEST-1", "attachments": []}`, res.Stdout)
+}
+
+func TestListTimezone(t *testing.T) {
+	// Uploaded just after midnight in Auckland, it is still February in UTC.
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	require.NoError(t, err)
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithClock(func() time.Time {
+		return time.Date(2024, 3, 1, 0, 30, 0, 0, auckland)
+	}))
+	t.Cleanup(server.Close)
+	server.AddAttachment("TEST-1", "nz.log", []byte("kia ora"))
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL, "display.timezone": "Pacific/Auckland"}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--plain")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "\t2024-03-01\n")
+
+	// --tz overrides the config.
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--plain", "--tz", "UTC")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "\t2024-02-29\n")
+
+	// Machine readable outputs are in UTC whatever the timezone.
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "-o", "json")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, `"created": "2024-02-29T11:30:00Z"`)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentList(), "TEST-1", "--tz", "Mars/Olympus")
+	assert.EqualError(t, res.Err, `Invalid --tz "Mars/Olympus", use eg: UTC or Europe/Berlin`)
 }
@@ -0,0 +1,114 @@
+package attachment
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+// parseTextfile parses a file in the Prometheus text format into the value of every
+// sample, keyed by the sample as written, eg: `name{label="value"}`. It fails the test
+// on a line the textfile collector would reject.
+func parseTextfile(t *testing.T, path string) map[string]float64 {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	samples := make(map[string]float64)
+	types := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			require.Len(t, fields, 4, line)
+			require.Contains(t, []string{"counter", "gauge"}, fields[3], line)
+			types[fields[2]] = fields[3]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		require.Positive(t, i, line)
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		require.NoError(t, err, line)
+
+		name := line[:i]
+		if j := strings.IndexByte(name, '{'); j >= 0 {
+			require.True(t, strings.HasSuffix(name, "}"), line)
+			name = name[:j]
+		}
+		require.Contains(t, types, name, "sample without a TYPE: %s", line)
+		samples[line[:i]] = v
+	}
+	require.NoError(t, sc.Err())
+	return samples
+}
+
+func TestMetricsTextfileDownload(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	server.AddAttachment("TEST-1", "a.txt", []byte("hello"))
+	server.AddAttachment("TEST-1", "b.txt", []byte("world!"))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jira.prom")
+	env := cmdtest.Env{Client: server.Client()}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "download", "TEST-1", "--all", "-o", filepath.Join(dir, "out"), "--metrics-textfile", path)
+	require.NoError(t, res.Err)
+
+	samples := parseTextfile(t, path)
+	assert.Equal(t, 2.0, samples[`jira_cli_attachments_processed_total{op="download"}`])
+	assert.Equal(t, 11.0, samples[`jira_cli_attachment_bytes_transferred_total{direction="in"}`])
+	assert.Contains(t, samples, "jira_cli_attachment_run_duration_seconds")
+	for name := range samples {
+		assert.NotContains(t, name, "jira_cli_attachment_failures_total", "no failures")
+	}
+}
+
+func TestMetricsTextfileRemoveFailure(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+	server.AddAttachment("TEST-1", "b.txt", []byte("b"))
+	server.AddAttachment("TEST-1", "c.txt", []byte("c"))
+	server.SetFaults(jiratest.Faults{FailDeletes: true})
+
+	path := filepath.Join(t.TempDir(), "jira.prom")
+	env := cmdtest.Env{Client: server.Client()}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "remove", "TEST-1", "--name", "*.txt", "--no-input", "--metrics-textfile", path)
+	require.Error(t, res.Err)
+
+	samples := parseTextfile(t, path)
+	assert.Equal(t, 0.0, samples[`jira_cli_attachments_processed_total{op="delete"}`])
+	assert.Equal(t, 1.0, samples[`jira_cli_attachment_failures_total{reason="http"}`])
+	assert.Equal(t, 2.0, samples[`jira_cli_attachment_failures_total{reason="not_attempted"}`])
+}
+
+func TestMetricsTextfileUnwritable(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "missing", "jira.prom")
+	env := cmdtest.Env{Client: server.Client()}
+
+	res := cmdtest.Run(t, env, NewCmdAttachment(), "download", "TEST-1", "--all", "-o", filepath.Join(dir, "out"), "--metrics-textfile", path)
+	require.NoError(t, res.Err, "failing to write the metrics doesn't fail the run")
+	assert.Contains(t, res.Stderr, "Unable to write metrics to "+path)
+}
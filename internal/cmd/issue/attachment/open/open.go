@@ -0,0 +1,199 @@
+package open
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/pkg/browser"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	helpText = `Open downloads an attachment to a temporary directory and opens it with the default
+application of its type, eg: an image viewer for a screenshot. The path of the downloaded file
+is printed so that it can be found later. Use --web to open the attachment in the browser instead.`
+	examples = `$ jira issue attachment open ISSUE-1 screenshot.png
+
+# Open an attachment by ID
+$ jira issue attachment open ISSUE-1 --id 12345
+
+# Open the attachment in the browser without downloading it
+$ jira issue attachment open ISSUE-1 screenshot.png --web`
+)
+
+// opener opens downloaded files and URLs.
+type opener interface {
+	OpenFile(path string) error
+	OpenURL(url string) error
+}
+
+type systemOpener struct{}
+
+func (systemOpener) OpenFile(path string) error {
+	return browser.OpenFile(path)
+}
+
+func (systemOpener) OpenURL(url string) error {
+	return browser.Browse(url)
+}
+
+var defaultOpener opener = systemOpener{}
+
+// NewCmdAttachmentOpen is an attachment open command.
+func NewCmdAttachmentOpen() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "open ISSUE-KEY [FILENAME]",
+		Short:   "Open an attachment with the default application or in the browser",
+		Long:    helpText,
+		Example: examples,
+		Annotations: map[string]string{
+			"help:args": "ISSUE-KEY\tIssue key, eg: ISSUE-1\n" +
+				"FILENAME\tFilename of the attachment to open",
+		},
+		Args:          cobra.RangeArgs(1, 2),
+		RunE:          open,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().String("id", "", "ID of the attachment to open")
+	cmd.Flags().Bool("web", false, "Open the attachment in the browser instead of downloading it")
+
+	return &cmd
+}
+
+func open(cmd *cobra.Command, args []string) error {
+	params, err := parseArgsAndFlags(args, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	version, err := cmdcommon.GetAPIVersion(cmd)
+	if err != nil {
+		return err
+	}
+	client := api.DefaultClient(params.debug)
+
+	issue, err := cmdcommon.GetAttachmentIssue(client, version, params.issueKey, cmdcommon.AttachmentIssueFields)
+	if err != nil {
+		return cmdutil.RequestError(err, params.debug)
+	}
+	a, err := findAttachment(issue.Fields.Attachments, params)
+	if err != nil {
+		return cmdutil.Errorf("Unable to open: %s", err)
+	}
+
+	if params.web {
+		if err := defaultOpener.OpenURL(a.Content); err != nil {
+			return cmdutil.Errorf("Unable to open %s in the browser: %s", a.Content, err)
+		}
+		cmdutil.Success("Opened %q in the browser", a.Filename)
+		return nil
+	}
+
+	path, err := downloadToTemp(client, version, a)
+	if err != nil {
+		return cmdutil.RequestError(err, params.debug)
+	}
+	cmdutil.Success("Downloaded %q to %s", a.Filename, path)
+
+	if err := defaultOpener.OpenFile(path); err != nil {
+		return cmdutil.Errorf("Unable to open %s: %s", path, err)
+	}
+	return nil
+}
+
+// findAttachment returns the attachment selected by ID or filename. Jira allows several
+// attachments with the same filename, the latest one is picked with a notice.
+func findAttachment(attachments []jira.Attachment, params *openParams) (jira.Attachment, error) {
+	var matches []jira.Attachment
+	for _, a := range attachments {
+		if (params.id != "" && a.ID == params.id) || (params.id == "" && a.Filename == params.filename) {
+			matches = append(matches, a)
+		}
+	}
+	if len(matches) == 0 {
+		if params.id != "" {
+			return jira.Attachment{}, fmt.Errorf("attachment with ID %q not found", params.id)
+		}
+		return jira.Attachment{}, fmt.Errorf("attachment with filename %q not found", params.filename)
+	}
+
+	a := matches[0]
+	for _, m := range matches[1:] {
+		if m.CreatedAt.After(a.CreatedAt) {
+			a = m
+		}
+	}
+	if len(matches) > 1 {
+		cmdutil.Warn("%d attachments are named %q, opening the latest one (ID: %s), use --id to pick another", len(matches), a.Filename, a.ID)
+	}
+
+	if !a.Available() {
+		return jira.Attachment{}, fmt.Errorf(
+			"attachment %q (ID: %s) is unavailable, the issue may be archived or the attachment restricted", a.Filename, a.ID,
+		)
+	}
+	return a, nil
+}
+
+// downloadToTemp downloads the attachment into a new temporary directory and returns the
+// path of the file. The directory is left behind for the application that opens the file.
+func downloadToTemp(client *jira.Client, version string, a jira.Attachment) (string, error) {
+	dir, err := os.MkdirTemp("", "jira-cli-attachment-*")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, cmdutil.SanitizeFilename(a.Filename, "attachment-"+a.ID))
+
+	_, err = api.ProxyDownloadAttachmentVersion(client, version, a, path,
+		jira.ExpectContent(a.MimeType, a.Size),
+		jira.WithStallTimeout(jira.DefaultStallTimeout, jira.DefaultStallRetries),
+	)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return path, nil
+}
+
+type openParams struct {
+	issueKey string
+	filename string
+	id       string
+	web      bool
+	debug    bool
+}
+
+func parseArgsAndFlags(args []string, flags query.FlagParser) (*openParams, error) {
+	params := openParams{issueKey: cmdutil.GetJiraIssueKey(viper.GetString("project.key"), args[0])}
+	if len(args) == 2 {
+		params.filename = args[1]
+	}
+
+	var err error
+	if params.id, err = flags.GetString("id"); err != nil {
+		return nil, err
+	}
+	if params.web, err = flags.GetBool("web"); err != nil {
+		return nil, err
+	}
+	if params.debug, err = flags.GetBool("debug"); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case params.id == "" && params.filename == "":
+		return nil, cmdutil.Errorf("Provide a filename or --id")
+	case params.id != "" && params.filename != "":
+		return nil, cmdutil.Errorf("Provide either a filename or --id, not both")
+	}
+	return &params, nil
+}
@@ -0,0 +1,168 @@
+package open
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+// stubOpener records what would be opened.
+type stubOpener struct {
+	files []string
+	urls  []string
+}
+
+func (o *stubOpener) OpenFile(path string) error {
+	o.files = append(o.files, path)
+	return nil
+}
+
+func (o *stubOpener) OpenURL(url string) error {
+	o.urls = append(o.urls, url)
+	return nil
+}
+
+func stubOpen(t *testing.T) *stubOpener {
+	t.Helper()
+
+	stub := &stubOpener{}
+	prev := defaultOpener
+	defaultOpener = stub
+	t.Cleanup(func() { defaultOpener = prev })
+	return stub
+}
+
+func TestParseArgsAndFlags(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		args    []string
+		flags   []string
+		want    openParams
+		wantErr string
+	}{
+		{name: "filename", args: []string{"TEST-1", "shot.png"}, want: openParams{issueKey: "TEST-1", filename: "shot.png"}},
+		{name: "id", args: []string{"TEST-1"}, flags: []string{"--id", "10001"}, want: openParams{issueKey: "TEST-1", id: "10001"}},
+		{name: "web", args: []string{"TEST-1", "shot.png"}, flags: []string{"--web"}, want: openParams{issueKey: "TEST-1", filename: "shot.png", web: true}},
+		{name: "neither", args: []string{"TEST-1"}, wantErr: "Provide a filename or --id"},
+		{name: "both", args: []string{"TEST-1", "shot.png"}, flags: []string{"--id", "10001"}, wantErr: "not both"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			flags := pflag.NewFlagSet("open", pflag.ContinueOnError)
+			flags.String("id", "", "")
+			flags.Bool("web", false, "")
+			flags.Bool("debug", false, "")
+			require.NoError(t, flags.Parse(tc.flags))
+
+			params, err := parseArgsAndFlags(tc.args, flags)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, *params)
+		})
+	}
+}
+
+func TestOpenDownloadsToTemp(t *testing.T) {
+	stub := stubOpen(t)
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	server.AddAttachment("TEST-1", "shot.png", []byte("png"))
+
+	res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentOpen(), "TEST-1", "shot.png")
+	require.NoError(t, res.Err)
+
+	require.Len(t, stub.files, 1)
+	path := stub.files[0]
+	t.Cleanup(func() { _ = os.RemoveAll(filepath.Dir(path)) })
+
+	assert.Equal(t, "shot.png", filepath.Base(path))
+	assert.Equal(t, filepath.Clean(os.TempDir()), filepath.Dir(filepath.Dir(path)))
+	assert.Contains(t, res.Stdout, path, "the path is printed")
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(got))
+	assert.Empty(t, stub.urls)
+}
+
+func TestOpenByIDPicksAttachment(t *testing.T) {
+	stub := stubOpen(t)
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+	b := server.AddAttachment("TEST-1", "b.txt", []byte("b"))
+
+	res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentOpen(), "TEST-1", "--id", b.ID)
+	require.NoError(t, res.Err)
+
+	require.Len(t, stub.files, 1)
+	t.Cleanup(func() { _ = os.RemoveAll(filepath.Dir(stub.files[0])) })
+	assert.Equal(t, "b.txt", filepath.Base(stub.files[0]))
+}
+
+func TestOpenWeb(t *testing.T) {
+	stub := stubOpen(t)
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	a := server.AddAttachment("TEST-1", "shot.png", []byte("png"))
+
+	res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentOpen(), "TEST-1", "shot.png", "--web")
+	require.NoError(t, res.Err)
+
+	assert.Equal(t, []string{a.Content}, stub.urls)
+	assert.Empty(t, stub.files, "nothing is downloaded")
+	for _, r := range server.Requests() {
+		assert.NotContains(t, r.Path, "/attachment/content/")
+	}
+}
+
+func TestOpenNotFound(t *testing.T) {
+	stub := stubOpen(t)
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+	server.AddAttachment("TEST-1", "shot.png", []byte("png"))
+
+	res := cmdtest.Run(t, cmdtest.Env{Client: server.Client()}, NewCmdAttachmentOpen(), "TEST-1", "missing.png")
+	require.Error(t, res.Err)
+	assert.Contains(t, res.Err.Error(), `Unable to open: attachment with filename "missing.png" not found`)
+	assert.Empty(t, stub.files)
+}
+
+func TestFindAttachmentPicksLatestDuplicate(t *testing.T) {
+	attachments := []jira.Attachment{
+		{ID: "1", Filename: "shot.png", Content: "https://example.com/1", CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: "2", Filename: "shot.png", Content: "https://example.com/2", CreatedAt: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{ID: "3", Filename: "other.png", Content: "https://example.com/3"},
+	}
+
+	a, err := findAttachment(attachments, &openParams{filename: "shot.png"})
+	require.NoError(t, err)
+	assert.Equal(t, "2", a.ID)
+
+	_, err = findAttachment([]jira.Attachment{{ID: "4", Filename: "archived.png"}}, &openParams{id: "4"})
+	assert.ErrorContains(t, err, "is unavailable")
+}
@@ -0,0 +1,211 @@
+package passthrough
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	helpText = `Api sends a request to an attachment endpoint of the jira REST api and prints the raw response.
+
+The path is relative to the REST api root, the version is picked based on the configured installation
+unless it is set with --api-version.`
+	examples = `$ jira issue attachment api GET /attachment/10001
+
+# Fetch expanded metadata of an archive attachment
+$ jira issue attachment api GET /attachment/10001/expand/human
+
+# Compact output for scripts
+$ jira issue attachment api GET /attachment/meta --compact
+
+# Delete an attachment
+$ jira issue attachment api DELETE /attachment/10001`
+)
+
+var allowedPaths = regexp.MustCompile(`^/(attachment(/.*)?|issue/[^/]+/attachments)$`)
+
+// NewCmdAttachmentAPI is an attachment api passthrough command.
+func NewCmdAttachmentAPI() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "api METHOD PATH",
+		Short:   "Send a raw request to an attachment endpoint",
+		Long:    helpText,
+		Example: examples,
+		Annotations: map[string]string{
+			"help:args": "METHOD\tHTTP method, one of GET, DELETE, POST or PUT\n" +
+				"PATH\tPath of the endpoint, eg: /attachment/10001",
+		},
+		Args: cobra.ExactArgs(2),
+		Run:  passthrough,
+	}
+
+	cmd.Flags().Bool("compact", false, "Print compact JSON instead of pretty printing it")
+	cmd.Flags().String("data", "", "Request body for POST and PUT requests")
+	cmd.Flags().String("data-file", "", "Read the request body for POST and PUT requests from a file")
+
+	return &cmd
+}
+
+func passthrough(cmd *cobra.Command, args []string) {
+	params := parseArgsAndFlags(args, cmd.Flags())
+	version, err := cmdcommon.GetAPIVersion(cmd)
+	cmdutil.ExitIfError(err)
+	client := api.DefaultClient(params.debug)
+
+	req, err := newRequest(params)
+	cmdutil.ExitIfError(err)
+
+	code, err := do(client, version, req, params.compact, os.Stdout, os.Stderr)
+	if errors.Is(err, jira.ErrDryRun) {
+		cmdutil.DryRun("Would send %s %s", req.method, req.path)
+		return
+	}
+	cmdutil.ExitIfRequestError(err, params.debug)
+
+	if code != 0 {
+		os.Exit(code)
+	}
+}
+
+type request struct {
+	method string
+	path   string
+	body   []byte
+}
+
+func newRequest(params *passthroughParams) (*request, error) {
+	method := strings.ToUpper(params.method)
+	switch method {
+	case http.MethodGet, http.MethodDelete, http.MethodPost, http.MethodPut:
+	default:
+		return nil, fmt.Errorf("unsupported method %q, must be one of GET, DELETE, POST or PUT", params.method)
+	}
+
+	path := "/" + strings.TrimPrefix(params.path, "/")
+	if p := strings.SplitN(path, "?", 2)[0]; !allowedPaths.MatchString(p) {
+		return nil, fmt.Errorf("path %q is not an attachment endpoint", params.path)
+	}
+
+	body := []byte(params.data)
+	if params.dataFile != "" {
+		if params.data != "" {
+			return nil, fmt.Errorf("--data and --data-file can't be used together")
+		}
+		var err error
+		if body, err = os.ReadFile(params.dataFile); err != nil {
+			return nil, err
+		}
+	}
+	if len(body) > 0 && method != http.MethodPost && method != http.MethodPut {
+		return nil, fmt.Errorf("a request body can only be sent with POST and PUT requests")
+	}
+
+	return &request{method: method, path: path, body: body}, nil
+}
+
+// do sends the request and prints the response body as is. Error responses are not wrapped:
+// the status line goes to stderr, the body to stdout, and a non-zero exit code is returned.
+func do(client *jira.Client, version string, req *request, compact bool, stdout, stderr io.Writer) (int, error) {
+	headers := jira.Header{
+		"Accept":            "application/json",
+		"X-Atlassian-Token": "no-check",
+	}
+	if len(req.body) > 0 {
+		headers["Content-Type"] = "application/json"
+	}
+
+	send := client.Request
+	if version == api.APIVersion2 {
+		send = client.RequestV2
+	}
+
+	res, err := send(context.Background(), req.method, req.path, req.body, headers)
+	if err != nil {
+		return 1, err
+	}
+	if res == nil {
+		return 1, jira.ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 1, err
+	}
+
+	code := 0
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		code = 1
+		fmt.Fprintln(stderr, res.Status)
+	}
+
+	if len(body) > 0 {
+		_, _ = stdout.Write(format(body, compact))
+		fmt.Fprintln(stdout)
+	}
+	return code, nil
+}
+
+// format pretty prints or compacts JSON bodies. Other bodies are returned as is.
+func format(body []byte, compact bool) []byte {
+	var out bytes.Buffer
+
+	var err error
+	if compact {
+		err = json.Compact(&out, body)
+	} else {
+		err = json.Indent(&out, body, "", "  ")
+	}
+	if err != nil {
+		return bytes.TrimRight(body, "\n")
+	}
+	return out.Bytes()
+}
+
+type passthroughParams struct {
+	method   string
+	path     string
+	data     string
+	dataFile string
+	compact  bool
+	debug    bool
+}
+
+func parseArgsAndFlags(args []string, flags query.FlagParser) *passthroughParams {
+	debug, err := flags.GetBool("debug")
+	cmdutil.ExitIfError(err)
+
+	compact, err := flags.GetBool("compact")
+	cmdutil.ExitIfError(err)
+
+	data, err := flags.GetString("data")
+	cmdutil.ExitIfError(err)
+
+	dataFile, err := flags.GetString("data-file")
+	cmdutil.ExitIfError(err)
+
+	return &passthroughParams{
+		method:   args[0],
+		path:     args[1],
+		data:     data,
+		dataFile: dataFile,
+		compact:  compact,
+		debug:    debug,
+	}
+}
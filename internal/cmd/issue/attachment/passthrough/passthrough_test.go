@@ -0,0 +1,141 @@
+package passthrough
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func TestNewRequest(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		params passthroughParams
+		want   *request
+		err    string
+	}{
+		{
+			name:   "get attachment",
+			params: passthroughParams{method: "get", path: "attachment/10001/expand/human"},
+			want:   &request{method: http.MethodGet, path: "/attachment/10001/expand/human", body: []byte{}},
+		},
+		{
+			name:   "issue attachments",
+			params: passthroughParams{method: "POST", path: "/issue/TEST-1/attachments", data: `{}`},
+			want:   &request{method: http.MethodPost, path: "/issue/TEST-1/attachments", body: []byte(`{}`)},
+		},
+		{
+			name:   "unsupported method",
+			params: passthroughParams{method: "PATCH", path: "/attachment/10001"},
+			err:    `unsupported method "PATCH", must be one of GET, DELETE, POST or PUT`,
+		},
+		{
+			name:   "not an attachment endpoint",
+			params: passthroughParams{method: "GET", path: "/issue/TEST-1"},
+			err:    `path "/issue/TEST-1" is not an attachment endpoint`,
+		},
+		{
+			name:   "body with get",
+			params: passthroughParams{method: "GET", path: "/attachment/10001", data: `{}`},
+			err:    "a request body can only be sent with POST and PUT requests",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := newRequest(&tc.params)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	var (
+		gotPath, gotAuth, gotBody string
+		status                    = http.StatusOK
+		response                  = `{"id":"10001","filename":"a.zip"}`
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := jira.NewClient(jira.Config{
+		Server:   server.URL,
+		Login:    "user",
+		APIToken: "token",
+	}, jira.WithTimeout(3*time.Second))
+
+	get := &request{method: http.MethodGet, path: "/attachment/10001/expand/human"}
+
+	// Cloud installations use v3, auth is applied.
+	var stdout, stderr bytes.Buffer
+	code, err := do(client, api.APIVersion3, get, false, &stdout, &stderr)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "/rest/api/3/attachment/10001/expand/human", gotPath)
+	assert.Equal(t, "Basic dXNlcjp0b2tlbg==", gotAuth)
+	assert.Equal(t, "{\n  \"id\": \"10001\",\n  \"filename\": \"a.zip\"\n}\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	// Local installations use v2, compact output.
+	stdout.Reset()
+	code, err = do(client, api.APIVersion2, get, true, &stdout, &stderr)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "/rest/api/2/attachment/10001/expand/human", gotPath)
+	assert.Equal(t, "{\"id\":\"10001\",\"filename\":\"a.zip\"}\n", stdout.String())
+
+	// Request body is sent as is.
+	put := &request{method: http.MethodPut, path: "/attachment/10001", body: []byte(`{"a":1}`)}
+	stdout.Reset()
+	_, err = do(client, api.APIVersion3, put, true, &stdout, &stderr)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, gotBody)
+
+	// Error responses are passed through without wrapping.
+	status, response = http.StatusNotFound, `{"errorMessages":["Attachment not found"]}`
+	stdout.Reset()
+	code, err = do(client, api.APIVersion3, get, true, &stdout, &stderr)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "404 Not Found\n", stderr.String())
+	assert.Equal(t, response+"\n", stdout.String())
+
+	// Non JSON bodies are printed as is.
+	status, response = http.StatusBadGateway, "<html>bad gateway</html>\n"
+	stdout.Reset()
+	stderr.Reset()
+	code, err = do(client, api.APIVersion3, get, false, &stdout, &stderr)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "502 Bad Gateway\n", stderr.String())
+	assert.Equal(t, "<html>bad gateway</html>\n", stdout.String())
+}
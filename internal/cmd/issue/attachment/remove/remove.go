@@ -1,16 +1,21 @@
 package remove
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/selector"
 	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
 	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/notify"
 	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
 )
 
 const (
@@ -18,103 +23,217 @@ const (
 	examples = `$ jira issue attachment remove ISSUE-1 12345
 
 # Skip confirmation prompt
-$ jira issue attachment remove ISSUE-1 12345 --no-input`
+$ jira issue attachment remove ISSUE-1 12345 --no-input
+
+# Only remove the attachment if it wasn't replaced since it was listed, with its created
+# value from: jira issue attachment list ISSUE-1 -o json
+$ jira issue attachment remove ISSUE-1 12345 --if-created 2024-01-31T10:00:00.000+0000
+
+# Remove attachments you uploaded more than 30 days ago
+$ jira issue attachment remove ISSUE-1 --mine --older-than 30d
+
+# Remove the PDFs uploaded by jane
+$ jira issue attachment remove ISSUE-1 --name "*.pdf" --author @jane
+
+# Wait up to 30 minutes for Jira to leave maintenance/read-only mode before deleting
+$ jira issue attachment remove ISSUE-1 12345 --no-input --wait-for-writable 30m
+
+# Remove large attachments uploaded before June, except PDFs
+$ jira issue attachment remove ISSUE-1 --where 'size > 50MB and created < 2024-06-01 and not filename ~ "*.pdf"'`
 )
 
 // NewCmdAttachmentRemove is an attachment remove command.
-func NewCmdAttachmentRemove() *cobra.Command {
+func NewCmdAttachmentRemove(opts ...cmdcommon.Options) *cobra.Command {
+	o := cmdcommon.ResolveOptions(opts)
 	cmd := cobra.Command{
-		Use:     "remove ISSUE-KEY ATTACHMENT-ID",
+		Use:     "remove ISSUE-KEY [ATTACHMENT-ID]",
 		Short:   "Remove an attachment from an issue",
 		Long:    helpText,
 		Example: examples,
 		Aliases: []string{"rm", "delete", "del"},
 		Annotations: map[string]string{
 			"help:args": "ISSUE-KEY\tIssue key, eg: ISSUE-1\n" +
-				"ATTACHMENT-ID\tID of the attachment to remove",
+				"ATTACHMENT-ID\tID of the attachment to remove, same as --id, optional if other selection flags are set",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return remove(cmd, args, o)
 		},
-		Run: remove,
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
 	cmd.Flags().Bool("no-input", false, "Skip confirmation prompt")
+	cmd.Flags().Bool("short-url", false, "Print the issue key or the configured short URL instead of the full browse URL")
+	cmd.Flags().String("if-created", "", "Only delete the attachment if its created timestamp is exactly the given one, as listed with --output json")
+	selector.SetFlags(&cmd)
+	cmdcommon.SetWaitForWritableFlag(&cmd)
+
+	o.Apply(&cmd)
 
 	return &cmd
 }
 
-func remove(cmd *cobra.Command, args []string) {
-	params := parseArgsAndFlags(args, cmd.Flags())
-	client := api.DefaultClient(params.debug)
+func remove(cmd *cobra.Command, args []string, o cmdcommon.Options) error {
+	params, err := parseArgsAndFlags(args, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	if params.apiVersion, err = cmdcommon.GetAPIVersion(cmd); err != nil {
+		return err
+	}
+	client, err := o.Client(params.debug)
+	if err != nil {
+		return err
+	}
 
 	if params.issueKey == "" {
-		cmdutil.Failed("ISSUE-KEY is required")
+		return cmdutil.Errorf("ISSUE-KEY is required")
 	}
 
-	if params.attachmentID == "" {
-		cmdutil.Failed("ATTACHMENT-ID is required")
+	if !params.selector.Active() {
+		return cmdutil.Errorf("ATTACHMENT-ID is required")
+	}
+	if params.ifCreated != "" && (params.selector.ID == "" || params.selector.Filtered()) {
+		return cmdutil.Errorf("--if-created can only be used to remove a single ATTACHMENT-ID, without filters")
 	}
 
 	// Get issue to verify attachment exists and show filename
-	issue, err := api.ProxyGetIssue(client, params.issueKey)
-	cmdutil.ExitIfError(err)
-
-	var attachmentFilename string
-	found := false
-	for _, a := range issue.Fields.Attachments {
-		if a.ID == params.attachmentID {
-			attachmentFilename = a.Filename
-			found = true
-			break
-		}
+	issue, err := cmdcommon.GetAttachmentIssue(client, params.apiVersion, params.issueKey, cmdcommon.AttachmentIssueFields)
+	if err != nil {
+		return cmdutil.RequestError(err, params.debug)
 	}
 
-	if !found {
-		cmdutil.Failed("Attachment with ID %q not found on issue %q", params.attachmentID, params.issueKey)
+	attachments, report, err := params.selector.Select(client, issue.Fields.Attachments)
+	if err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		return noMatchError(report, params)
 	}
 
 	// Show confirmation unless --no-input is set
 	if !params.noInput {
-		answer := struct{ Action string }{}
-		err := survey.Ask([]*survey.Question{
-			{
-				Name: "action",
-				Prompt: &survey.Select{
-					Message: fmt.Sprintf("Delete attachment %q (ID: %s) from %s?", attachmentFilename, params.attachmentID, params.issueKey),
-					Options: []string{
-						cmdcommon.ActionSubmit,
-						cmdcommon.ActionCancel,
-					},
-				},
-			},
-		}, &answer)
-		cmdutil.ExitIfError(err)
-
-		if answer.Action == cmdcommon.ActionCancel {
-			cmdutil.Failed("Action aborted")
+		question, items := confirmPrompt(attachments, params.issueKey)
+		ok, err := cmdcommon.Confirm(ask, viewList, question, items)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return cmdutil.Errorf("Action aborted")
 		}
 	}
 
-	err = func() error {
-		s := cmdutil.Info(fmt.Sprintf("Deleting attachment %s", attachmentFilename))
-		defer s.Stop()
+	if err := cmdcommon.WaitForWritable(cmd, client); err != nil {
+		return err
+	}
+
+	notifier, err := cmdcommon.NewNotifier(cmd)
+	if err != nil {
+		return err
+	}
 
-		return api.ProxyDeleteAttachment(client, params.attachmentID)
-	}()
-	cmdutil.ExitIfError(err)
+	var dryRun bool
+	for i, a := range attachments {
+		err = func() error {
+			s := cmdutil.Info(fmt.Sprintf("Deleting attachment %s", a.Filename))
+			defer s.Stop()
 
-	server := viper.GetString("server")
+			return api.ProxyDeleteAttachmentWithOptionsVersion(client, params.apiVersion, a.ID, jira.DeleteAttachmentOptions{IfCreated: params.ifCreated})
+		}()
+		if errors.Is(err, jira.ErrDryRun) {
+			dryRun = true
+			cmdutil.DryRun("Would delete attachment %q (ID: %s) from issue %q", a.Filename, a.ID, params.issueKey)
+			continue
+		}
+		if err != nil {
+			notifier.Done(deleteSummary(params.issueKey, len(attachments), i, err))
+		}
+		var precondition *jira.ErrPreconditionFailed
+		if errors.As(err, &precondition) {
+			return cmdutil.Errorf("Precondition failed — attachment metadata changed since you listed it: attachment %s was created %s, expected %s",
+				precondition.ID, precondition.Actual, precondition.Expected)
+		}
+		if err != nil {
+			return cmdutil.RequestError(err, params.debug)
+		}
 
-	cmdutil.Success("Deleted attachment %q from issue %q", attachmentFilename, params.issueKey)
-	fmt.Printf("%s\n", cmdutil.GenerateServerBrowseURL(server, params.issueKey))
+		cmdutil.Success("Deleted attachment %q from issue %q", a.Filename, params.issueKey)
+	}
+	if dryRun {
+		return nil
+	}
+	notifier.Done(deleteSummary(params.issueKey, len(attachments), len(attachments), nil))
+	fmt.Fprintf(cmd.OutOrStdout(), "%s\n", params.issueURL(params.issueKey))
+	return nil
+}
+
+// deleteSummary is the outcome of deleting total attachments from the issue, the first
+// done of which were deleted. A failure stops the run, the attachments after the failed
+// one are not attempted.
+func deleteSummary(issueKey string, total, done int, err error) notify.Summary {
+	s := notify.Summary{
+		Op:     notify.OpDelete,
+		Target: issueKey,
+		Total:  total,
+		Done:   done,
+		Failed: total - done,
+	}
+	if err != nil {
+		reason := cmdcommon.FailureReason(err)
+		var precondition *jira.ErrPreconditionFailed
+		if errors.As(err, &precondition) {
+			reason = "precondition_failed"
+		}
+		s.Failures = map[string]int{reason: 1}
+		if s.Failed > 1 {
+			s.Failures[cmdcommon.FailureNotAttempted] = s.Failed - 1
+		}
+	}
+	return s
+}
+
+// noMatchError explains why no attachment was selected.
+func noMatchError(report selector.SelectionReport, params *removeParams) error {
+	if st, ok := report.Eliminator(); ok && st.Criterion == selector.CriterionID {
+		return cmdutil.Errorf("Attachment with ID %q not found on issue %q", params.selector.ID, params.issueKey)
+	}
+	if why := report.Explain(); why != "" {
+		return cmdutil.Errorf("No attachments matching the filters found on issue %q, %s", params.issueKey, why)
+	}
+	return cmdutil.Errorf("No attachments matching the filters found on issue %q", params.issueKey)
+}
+
+// ask and viewList are replaced in tests to answer the confirmation prompt without a terminal.
+var (
+	ask      cmdcommon.Asker = survey.Ask
+	viewList                 = cmdcommon.ViewList
+)
+
+// confirmPrompt returns the question and the list of the confirmation prompt.
+func confirmPrompt(attachments []jira.Attachment, key string) (string, []cmdcommon.ConfirmItem) {
+	if len(attachments) == 1 {
+		a := attachments[0]
+		return fmt.Sprintf("Delete attachment %q (ID: %s) from %s%s?", cmdutil.SanitizeTerminalText(a.Filename), a.ID, key, cmdcommon.OnInstance()), nil
+	}
+
+	items := make([]cmdcommon.ConfirmItem, 0, len(attachments))
+	for _, a := range attachments {
+		items = append(items, cmdcommon.ConfirmItem{Label: fmt.Sprintf("%s (ID: %s)", a.Filename, a.ID), Size: a.Size})
+	}
+	return fmt.Sprintf("Delete %d attachments from %s%s?", len(attachments), key, cmdcommon.OnInstance()), items
 }
 
 type removeParams struct {
-	issueKey     string
-	attachmentID string
-	noInput      bool
-	debug        bool
+	issueKey   string
+	noInput    bool
+	issueURL   cmdutil.IssueURLFunc
+	selector   *selector.Selector
+	ifCreated  string
+	apiVersion string
+	debug      bool
 }
 
-func parseArgsAndFlags(args []string, flags query.FlagParser) *removeParams {
+func parseArgsAndFlags(args []string, flags query.FlagParser) (*removeParams, error) {
 	var issueKey, attachmentID string
 
 	if len(args) >= 1 {
@@ -125,15 +244,47 @@ func parseArgsAndFlags(args []string, flags query.FlagParser) *removeParams {
 	}
 
 	debug, err := flags.GetBool("debug")
-	cmdutil.ExitIfError(err)
+	if err != nil {
+		return nil, err
+	}
 
 	noInput, err := flags.GetBool("no-input")
-	cmdutil.ExitIfError(err)
+	if err != nil {
+		return nil, err
+	}
 
-	return &removeParams{
-		issueKey:     issueKey,
-		attachmentID: attachmentID,
-		noInput:      noInput,
-		debug:        debug,
+	shortURL, err := flags.GetBool("short-url")
+	if err != nil {
+		return nil, err
+	}
+
+	issueURL, err := cmdutil.IssueURLResolver(viper.GetString("server"), shortURL, viper.GetString("output.short_urls"))
+	if err != nil {
+		return nil, err
+	}
+
+	sel, err := selector.New(flags)
+	if err != nil {
+		return nil, err
 	}
+	if attachmentID != "" {
+		if sel.ID != "" && sel.ID != attachmentID {
+			return nil, cmdutil.Errorf("ATTACHMENT-ID %q and --id %q don't match", attachmentID, sel.ID)
+		}
+		sel.ID = attachmentID
+	}
+
+	ifCreated, err := flags.GetString("if-created")
+	if err != nil {
+		return nil, err
+	}
+
+	return &removeParams{
+		issueKey:  issueKey,
+		noInput:   noInput,
+		issueURL:  issueURL,
+		selector:  sel,
+		ifCreated: strings.TrimSpace(ifCreated),
+		debug:     debug,
+	}, nil
 }
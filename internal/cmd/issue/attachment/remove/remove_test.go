@@ -0,0 +1,187 @@
+package remove
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmd/issue/attachment/list"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestRemoveConfirmViewFullList(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	for i := range 15 {
+		server.AddAttachment("TEST-1", fmt.Sprintf("log-%02d.txt", i+1), []byte("log"))
+	}
+
+	answers := []string{cmdcommon.ActionViewList, cmdcommon.ActionSubmit}
+	var messages, viewed []string
+
+	prevAsk, prevView := ask, viewList
+	ask = func(qs []*survey.Question, response any, _ ...survey.AskOpt) error {
+		messages = append(messages, qs[0].Prompt.(*survey.Select).Message)
+		response.(*struct{ Action string }).Action = answers[0]
+		answers = answers[1:]
+		return nil
+	}
+	viewList = func(list string) error {
+		viewed = append(viewed, list)
+		return nil
+	}
+	t.Cleanup(func() { ask, viewList = prevAsk, prevView })
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "installation": "Cloud"},
+	}
+	res := cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", "--where", "size > 0")
+	require.NoError(t, res.Err)
+
+	require.Len(t, messages, 2)
+	assert.True(t, strings.HasPrefix(messages[0], "Delete 15 attachments from TEST-1? (15 items, 45 B in total)\n"))
+	assert.Contains(t, messages[0], "… and 5 more")
+
+	require.Len(t, viewed, 1)
+	assert.Contains(t, viewed[0], "log-15.txt")
+
+	assert.Empty(t, server.Attachments("TEST-1"))
+}
+
+func deleteRequests(server *jiratest.Server) int {
+	var n int
+	for _, r := range server.Requests() {
+		if r.Method == http.MethodDelete {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRemoveIfCreated(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	env := cmdtest.Env{
+		Client: server.Client(),
+		Config: map[string]any{"server": server.URL, "installation": "Cloud"},
+	}
+
+	// The created value is copied from the JSON output of list.
+	a := server.AddAttachment("TEST-1", "report.pdf", []byte("v1"))
+	res := cmdtest.Run(t, env, list.NewCmdAttachmentList(), "TEST-1", "-o", "json")
+	require.NoError(t, res.Err)
+
+	var listed struct {
+		Attachments []struct {
+			ID      string `json:"id"`
+			Created string `json:"created"`
+		} `json:"attachments"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(res.Stdout), &listed))
+	require.Len(t, listed.Attachments, 1)
+	created := listed.Attachments[0].Created
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", a.ID, "--no-input", "--if-created", "2000-01-01T00:00:00.000+0000")
+	assert.EqualError(t, res.Err, fmt.Sprintf(
+		"Precondition failed — attachment metadata changed since you listed it: attachment %s was created %s, expected 2000-01-01T00:00:00.000+0000",
+		a.ID, a.Created))
+	assert.Zero(t, deleteRequests(server))
+	assert.Len(t, server.Attachments("TEST-1"), 1)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", a.ID, "--no-input", "--if-created", created)
+	require.NoError(t, res.Err)
+	assert.Equal(t, 1, deleteRequests(server))
+	assert.Empty(t, server.Attachments("TEST-1"))
+
+	// Without the option, nothing is checked.
+	b := server.AddAttachment("TEST-1", "notes.txt", []byte("v1"))
+	before := len(server.Requests())
+	res = cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", b.ID, "--no-input")
+	require.NoError(t, res.Err)
+	for _, r := range server.Requests()[before:] {
+		if r.Path == "/rest/api/3/attachment/"+b.ID {
+			assert.Equal(t, http.MethodDelete, r.Method)
+		}
+	}
+	assert.Empty(t, server.Attachments("TEST-1"))
+}
+
+func TestRemoveIfCreatedRequiresSingleID(t *testing.T) {
+	env := cmdtest.Env{Config: map[string]any{"installation": "Cloud"}}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", "--mine", "--if-created", "2024-01-31T10:00:00.000+0000")
+	assert.EqualError(t, res.Err, "--if-created can only be used to remove a single ATTACHMENT-ID, without filters")
+}
+
+func TestRemoveSelectionFlags(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL, "installation": "Cloud"}}
+
+	for _, name := range []string{"id", "name", "mime", "mine", "author", "older-than", "where", "latest"} {
+		assert.NotNil(t, NewCmdAttachmentRemove().Flags().Lookup(name), name)
+	}
+
+	server.AddAttachment("TEST-1", "a.log", []byte("a"))
+	keep := server.AddAttachment("TEST-1", "b.txt", []byte("b"))
+	server.AddAttachment("TEST-1", "c.log", []byte("c"))
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", "--name", "*.log", "--no-input")
+	require.NoError(t, res.Err)
+	assert.Equal(t, 2, deleteRequests(server))
+	assert.Equal(t, []string{keep.ID}, attachmentIDs(server.Attachments("TEST-1")))
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", "999", "--no-input")
+	assert.EqualError(t, res.Err, `Attachment with ID "999" not found on issue "TEST-1"`)
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", keep.ID, "--id", "999", "--no-input")
+	assert.EqualError(t, res.Err, fmt.Sprintf(`ATTACHMENT-ID %q and --id "999" don't match`, keep.ID))
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentRemove(), "TEST-1", "--id", keep.ID, "--mime", "image", "--no-input")
+	assert.EqualError(t, res.Err, `No attachments matching the filters found on issue "TEST-1", --mime image removed the last 1 of 1`)
+	assert.Equal(t, 2, deleteRequests(server))
+}
+
+func attachmentIDs(attachments []jira.Attachment) []string {
+	out := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		out = append(out, a.ID)
+	}
+	return out
+}
+
+func TestConfirmPromptNamesProfile(t *testing.T) {
+	prev := viper.Get("profiles")
+	viper.Set("profiles", map[string]any{"customer-jira": map[string]any{"installation": "Local"}})
+	t.Cleanup(func() {
+		api.ClearProfile()
+		viper.Set("profiles", prev)
+	})
+
+	attachments := []jira.Attachment{{ID: "1", Filename: "a.txt"}, {ID: "2", Filename: "b.txt"}}
+
+	question, _ := confirmPrompt(attachments[:1], "CUST-9")
+	assert.Equal(t, `Delete attachment "a.txt" (ID: 1) from CUST-9?`, question)
+
+	require.NoError(t, api.UseProfile("customer-jira"))
+
+	question, _ = confirmPrompt(attachments[:1], "CUST-9")
+	assert.Equal(t, `Delete attachment "a.txt" (ID: 1) from CUST-9 on customer-jira?`, question)
+	question, _ = confirmPrompt(attachments, "CUST-9")
+	assert.Equal(t, "Delete 2 attachments from CUST-9 on customer-jira?", question)
+}
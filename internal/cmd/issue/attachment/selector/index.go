@@ -0,0 +1,104 @@
+package selector
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// ParseIndexes parses the 1-based positions of --index, eg: 3 or 1-3,5, and returns them
+// sorted, each once.
+func ParseIndexes(s string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid --index %q, expected positions like 3 or 1-3,5", s)
+		}
+
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := parseIndex(lo)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseIndex(hi); err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid --index range %q, the start is after the end", part)
+			}
+		}
+		for i := first; i <= last; i++ {
+			out = append(out, i)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+func parseIndex(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid --index position %q, positions start at 1", strings.TrimSpace(s))
+	}
+	return n, nil
+}
+
+// Number returns the 1-based position of each attachment by id, as --index counts them:
+// oldest first, attachments created at the same time by id.
+func Number(attachments []jira.Attachment) map[string]int {
+	order := slices.Clone(attachments)
+	slices.SortStableFunc(order, jira.CompareAttachmentsByCreated)
+
+	out := make(map[string]int, len(order))
+	for i, a := range order {
+		out[a.ID] = i + 1
+	}
+	return out
+}
+
+// formatIndexes formats positions the way --index takes them, with ranges for runs.
+func formatIndexes(indexes []int) string {
+	var parts []string
+	for i := 0; i < len(indexes); {
+		j := i
+		for j+1 < len(indexes) && indexes[j+1] == indexes[j]+1 {
+			j++
+		}
+		if j == i {
+			parts = append(parts, strconv.Itoa(indexes[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", indexes[i], indexes[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// CheckIndex fails if a position of --index is beyond the attachments matching the other
+// criteria, as per the report of Apply. Nothing matching at all is left to the caller to
+// report, with the criterion that removed the last attachments.
+func (s *Selector) CheckIndex(report SelectionReport) error {
+	if s == nil || len(s.Index) == 0 {
+		return nil
+	}
+	for _, st := range report.Steps {
+		if st.Criterion != CriterionIndex {
+			continue
+		}
+		candidates := st.Removed + st.Remaining
+		if last := s.Index[len(s.Index)-1]; candidates > 0 && last > candidates {
+			if candidates == 1 {
+				return fmt.Errorf("--index %d is out of range, 1 attachment matches the other filters, the valid index is 1", last)
+			}
+			return fmt.Errorf("--index %d is out of range, %d attachments match the other filters, valid indexes are 1-%d",
+				last, candidates, candidates)
+		}
+	}
+	return nil
+}
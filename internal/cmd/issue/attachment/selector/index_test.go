@@ -0,0 +1,170 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func TestParseIndexes(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr string
+	}{
+		{name: "single", input: "3", want: []int{3}},
+		{name: "range", input: "1-3", want: []int{1, 2, 3}},
+		{name: "mixed", input: "1-3,5", want: []int{1, 2, 3, 5}},
+		{name: "spaces", input: " 5 , 2 - 3 ", want: []int{2, 3, 5}},
+		{name: "duplicates collapsed", input: "2,1-3,3,2-2", want: []int{1, 2, 3}},
+		{name: "sorted", input: "7,2", want: []int{2, 7}},
+		{name: "one position range", input: "4-4", want: []int{4}},
+		{name: "reversed range", input: "5-3", wantErr: `invalid --index range "5-3", the start is after the end`},
+		{name: "zero", input: "0", wantErr: `invalid --index position "0", positions start at 1`},
+		{name: "negative", input: "-2", wantErr: `invalid --index position "", positions start at 1`},
+		{name: "not a number", input: "third", wantErr: `invalid --index position "third"`},
+		{name: "open range", input: "2-", wantErr: `invalid --index position ""`},
+		{name: "empty part", input: "1,,2", wantErr: `invalid --index "1,,2", expected positions like 3 or 1-3,5`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseIndexes(tc.input)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFormatIndexes(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "3", formatIndexes([]int{3}))
+	assert.Equal(t, "1-3,5,7-8", formatIndexes([]int{1, 2, 3, 5, 7, 8}))
+}
+
+func TestNumber(t *testing.T) {
+	t.Parallel()
+
+	// Oldest first, the attachment without a date by id.
+	assert.Equal(t, map[string]int{"1": 1, "3": 2, "2": 3, "4": 4, "5": 5}, Number(fixtures))
+	assert.Empty(t, Number(nil))
+}
+
+func TestApplyIndex(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		selector *Selector
+		want     []string
+		numbers  map[string]int
+	}{
+		{
+			name:     "positions are oldest first, the order is kept",
+			selector: &Selector{Index: []int{1, 3}},
+			want:     []string{"1", "2"},
+			numbers:  map[string]int{"1": 1, "3": 2, "2": 3, "4": 4, "5": 5},
+		},
+		{
+			name:     "after the other filters",
+			selector: &Selector{Name: "*.pdf", Index: []int{2}},
+			want:     []string{"4"},
+			numbers:  map[string]int{"1": 1, "4": 2},
+		},
+		{
+			name:     "after latest",
+			selector: &Selector{MIME: "image", Latest: 1, Index: []int{1}},
+			want:     []string{"2"},
+			numbers:  map[string]int{"2": 1},
+		},
+		{
+			name:     "out of range keeps the ones in range",
+			selector: &Selector{Author: "jane", Index: []int{2, 3}},
+			want:     []string{"4"},
+			numbers:  map[string]int{"3": 1, "4": 2},
+		},
+		{
+			name:     "numbers without index",
+			selector: &Selector{Author: "jane"},
+			want:     []string{"3", "4"},
+			numbers:  map[string]int{"3": 1, "4": 2},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, report := resolved(tc.selector).Apply(fixtures)
+			assert.Equal(t, tc.want, ids(got))
+			assert.Equal(t, tc.numbers, report.Numbers)
+		})
+	}
+}
+
+func TestCheckIndex(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		selector *Selector
+		wantErr  string
+	}{
+		{name: "no index", selector: &Selector{Name: "*.pdf"}},
+		{name: "in range", selector: &Selector{Name: "*.pdf", Index: []int{1, 2}}},
+		{
+			name:     "out of range",
+			selector: &Selector{Name: "*.pdf", Index: []int{2, 3}},
+			wantErr:  "--index 3 is out of range, 2 attachments match the other filters, valid indexes are 1-2",
+		},
+		{
+			name:     "single candidate",
+			selector: &Selector{ID: "1", Index: []int{2}},
+			wantErr:  "--index 2 is out of range, 1 attachment matches the other filters, the valid index is 1",
+		},
+		// Nothing matching is reported with the filter that removed the last attachments.
+		{name: "no candidates", selector: &Selector{Name: "*.zip", Index: []int{1}}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := resolved(tc.selector)
+			_, report := s.Apply(fixtures)
+			err := s.CheckIndex(report)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestSelectChecksIndex(t *testing.T) {
+	t.Parallel()
+
+	s := &Selector{Index: []int{9}}
+	_, _, err := s.Select(nil, []jira.Attachment{{ID: "1"}, {ID: "2"}})
+	assert.EqualError(t, err, "--index 9 is out of range, 2 attachments match the other filters, valid indexes are 1-2")
+}
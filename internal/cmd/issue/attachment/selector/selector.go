@@ -0,0 +1,686 @@
+// Package selector selects the attachments of an issue that list, download and remove
+// work on, so that the selection flags mean the same to every command.
+package selector
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/internal/where"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// Criterion identifies a selection criterion.
+type Criterion string
+
+// Criteria, in the order they are applied.
+const (
+	CriterionID       Criterion = "id"
+	CriterionFilename Criterion = "filename"
+	CriterionName     Criterion = "name"
+	CriterionMIME     Criterion = "mime"
+	CriterionMine     Criterion = "mine"
+	CriterionAuthor   Criterion = "author"
+	CriterionMinSize  Criterion = "min-size"
+	CriterionMaxSize  Criterion = "max-size"
+	CriterionAge      Criterion = "older-than"
+	CriterionWhere    Criterion = "where"
+	CriterionLatest   Criterion = "latest"
+	CriterionIndex    Criterion = "index"
+)
+
+// maxAuthorCandidates is the number of users fetched to resolve an author handle.
+const maxAuthorCandidates = 20
+
+// Selector selects attachments. The criteria are set from the flags registered by SetFlags,
+// and an attachment is selected if it matches all of them.
+type Selector struct {
+	// ID keeps the attachment with the id.
+	ID string
+	// Filename keeps the attachments named exactly so, eg: given as an argument.
+	Filename string
+	// Name keeps attachments whose filename matches the glob, eg: "*.pdf".
+	Name string
+	// MIME keeps attachments whose mime type matches the glob, ignoring case. A type
+	// without a subtype matches all of them, eg: image and image/ are image/*.
+	MIME string
+	// Mine keeps attachments uploaded by the current user.
+	Mine bool
+	// Author keeps attachments uploaded by the user. A value starting with @ is a
+	// handle resolved to a user with Resolve, ie: the user name on server or the
+	// account id on cloud. Other values match the account id, the user name or a
+	// part of the display name.
+	Author string
+	// MinSize and MaxSize keep attachments of at least and at most as many bytes, if set.
+	MinSize int64
+	MaxSize int64
+	// OlderThan keeps attachments created before now - OlderThan.
+	OlderThan time.Duration
+	// Where keeps attachments matching the expression.
+	Where *where.Expr
+	// Latest keeps the given number of most recently created attachments among the
+	// ones matching the other criteria.
+	Latest int
+	// Index keeps the attachments at the 1-based positions, sorted, among the ones matching
+	// the other criteria as numbered by Number.
+	Index []int
+
+	// nameFlag is the flag Name was given with, --name or its alias --filter.
+	nameFlag string
+	// age, minSize and maxSize are the --older-than, --min-size and --max-size values as given.
+	age, minSize, maxSize string
+	// me and author are the users Mine and an @handle Author resolved to.
+	me, author   *jira.User
+	installation string
+	now          func() time.Time
+	// warn is called once if users had to be matched by their display name.
+	warn func(string)
+
+	criteria []criterion
+}
+
+// criterion is a compiled selection criterion.
+type criterion struct {
+	kind Criterion
+	flag string
+	// keep returns the attachments the criterion selects, in their order.
+	keep func([]jira.Attachment) []jira.Attachment
+}
+
+// SetFlags registers the selection flags.
+func SetFlags(cmd *cobra.Command) {
+	cmd.Flags().String("id", "", "Only the attachment with the ID")
+	cmd.Flags().String("name", "", `Only attachments whose filename matches the glob pattern, eg: "*.pdf"`)
+	cmd.Flags().String("filter", "", "Alias of --name")
+	cmd.Flags().String("mime", "", "Only attachments whose mime type matches the glob pattern, eg: image/png, image/* or image/")
+	cmd.Flags().Bool("mine", false, "Only attachments uploaded by you")
+	cmd.Flags().String("author", "", "Only attachments uploaded by the user, eg: @handle, an account id or a part of the display name")
+	cmd.Flags().String("min-size", "", "Only attachments of at least the given size, eg: 10KB")
+	cmd.Flags().String("max-size", "", "Only attachments of at most the given size, eg: 50MB")
+	cmd.Flags().String("older-than", "", "Only attachments older than the given age, eg: 30d, 2w, 12h")
+	cmd.Flags().String("where", "", `Only attachments matching the expression, eg: 'size > 50MB and not filename ~ "*.pdf"'`)
+	cmd.Flags().Uint("latest", 0, "Only the N most recently created attachments matching the other criteria")
+	cmd.Flags().String("index", "", "Only the attachments at the positions shown in the # column of list, eg: 3 or 1-3,5, counted after the other criteria")
+}
+
+// New parses the flags registered by SetFlags.
+func New(flags query.FlagParser) (*Selector, error) {
+	id, err := flags.GetString("id")
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := flags.GetString("name")
+	if err != nil {
+		return nil, err
+	}
+	name, nameFlag := strings.TrimSpace(name), "--name"
+
+	filter, err := flags.GetString("filter")
+	if err != nil {
+		return nil, err
+	}
+	if filter = strings.TrimSpace(filter); filter != "" {
+		if name != "" && name != filter {
+			return nil, fmt.Errorf("--filter is an alias of --name, give only one of them")
+		}
+		name, nameFlag = filter, "--filter"
+	}
+	if _, err := path.Match(name, ""); err != nil {
+		return nil, fmt.Errorf("invalid %s pattern %q", nameFlag, name)
+	}
+
+	mime, err := flags.GetString("mime")
+	if err != nil {
+		return nil, err
+	}
+	mime = strings.TrimSpace(mime)
+	if _, err := path.Match(mime, ""); err != nil {
+		return nil, fmt.Errorf("invalid --mime pattern %q", mime)
+	}
+
+	mine, err := flags.GetBool("mine")
+	if err != nil {
+		return nil, err
+	}
+
+	author, err := flags.GetString("author")
+	if err != nil {
+		return nil, err
+	}
+	author = strings.TrimSpace(author)
+	if author == "@" {
+		return nil, fmt.Errorf("invalid --author %q, the handle is missing", author)
+	}
+
+	minSizeFlag, minSize, err := sizeFlag(flags, "min-size")
+	if err != nil {
+		return nil, err
+	}
+	maxSizeFlag, maxSize, err := sizeFlag(flags, "max-size")
+	if err != nil {
+		return nil, err
+	}
+	if minSizeFlag != "" && maxSizeFlag != "" && minSize > maxSize {
+		return nil, fmt.Errorf("--min-size %s is larger than --max-size %s", minSizeFlag, maxSizeFlag)
+	}
+
+	olderThan, err := flags.GetString("older-than")
+	if err != nil {
+		return nil, err
+	}
+
+	age, err := cmdcommon.ParseAge(olderThan)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := flags.GetString("where")
+	if err != nil {
+		return nil, err
+	}
+
+	var cond *where.Expr
+	if strings.TrimSpace(expr) != "" {
+		if cond, err = where.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid --where expression: %w", err)
+		}
+	}
+
+	latest, err := flags.GetUint("latest")
+	if err != nil {
+		return nil, err
+	}
+
+	indexFlag, err := flags.GetString("index")
+	if err != nil {
+		return nil, err
+	}
+	var index []int
+	if strings.TrimSpace(indexFlag) != "" {
+		if index, err = ParseIndexes(indexFlag); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Selector{
+		ID:        strings.TrimSpace(id),
+		Name:      name,
+		MIME:      mime,
+		Mine:      mine,
+		Author:    author,
+		MinSize:   minSize,
+		MaxSize:   maxSize,
+		OlderThan: age,
+		Where:     cond,
+		Latest:    int(latest),
+		Index:     index,
+		nameFlag:  nameFlag,
+		age:       strings.TrimSpace(olderThan),
+		minSize:   minSizeFlag,
+		maxSize:   maxSizeFlag,
+	}, nil
+}
+
+// sizeFlag parses a size flag, eg: --max-size 50MB, and returns it as given along with the
+// number of bytes. Both are zero if the flag isn't set.
+func sizeFlag(flags query.FlagParser, name string) (string, int64, error) {
+	value, err := flags.GetString(name)
+	if err != nil {
+		return "", 0, err
+	}
+	if value = strings.TrimSpace(value); value == "" {
+		return "", 0, nil
+	}
+	n, err := where.ParseSize(value)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+	if n <= 0 {
+		return "", 0, fmt.Errorf("--%s must be larger than 0", name)
+	}
+	return value, n, nil
+}
+
+// Active reports if any criterion is set.
+func (s *Selector) Active() bool {
+	return s != nil && (s.ID != "" || s.Filename != "" || s.Filtered())
+}
+
+// Filtered reports if a criterion other than ID and Filename, ie: one that may select
+// several attachments, is set.
+func (s *Selector) Filtered() bool {
+	return s != nil && (s.Name != "" || s.MIME != "" || s.Mine || s.Author != "" || s.MinSize > 0 ||
+		s.MaxSize > 0 || s.OlderThan > 0 || s.Where != nil || s.Latest > 0 || len(s.Index) > 0)
+}
+
+// Describe lists the criteria that are set as the flags they were given with, eg: to
+// explain in a message why nothing matched.
+func (s *Selector) Describe() []string {
+	if !s.Active() {
+		return nil
+	}
+
+	var out []string
+	for _, c := range s.compile() {
+		out = append(out, c.flag)
+	}
+	return out
+}
+
+// Resolve looks up the users the criteria refer to, ie: the current user for Mine and
+// the user of an @handle Author. The users are kept in the selector, so the lookups run
+// once per invocation.
+func (s *Selector) Resolve(client *jira.Client) error {
+	if s == nil {
+		return nil
+	}
+	if s.installation == "" {
+		s.installation = viper.GetString("installation")
+	}
+	if s.warn == nil {
+		s.warn = func(msg string) { cmdutil.Warn(msg) }
+	}
+
+	if s.Mine && s.me == nil {
+		me, err := api.ProxyMyself(client)
+		if err != nil {
+			return err
+		}
+		s.me = me
+	}
+	return s.resolveAuthor(client)
+}
+
+// resolveAuthor resolves an @handle Author to a user with the user search. It is a
+// no-op for other values.
+func (s *Selector) resolveAuthor(client *jira.Client) error {
+	if s.author != nil || !strings.HasPrefix(s.Author, "@") {
+		return nil
+	}
+
+	handle := strings.TrimPrefix(s.Author, "@")
+	users, err := api.ProxySearchUsers(client, handle, maxAuthorCandidates)
+	if err != nil {
+		return err
+	}
+
+	u, err := pickUser(handle, users, s.installation)
+	if err != nil {
+		return err
+	}
+	s.author = u
+	return nil
+}
+
+// pickUser picks the user a handle refers to from the search results. The search matches
+// parts of names, so a single exact match of the user name, account id, email or display
+// name wins over other results.
+func pickUser(handle string, users []*jira.User, installation string) (*jira.User, error) {
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no user found for @%s", handle)
+	}
+	if len(users) == 1 {
+		return users[0], nil
+	}
+
+	var exact []*jira.User
+	for _, u := range users {
+		local, _, _ := strings.Cut(u.Email, "@")
+		if u.AccountID == handle || strings.EqualFold(u.Name, handle) ||
+			strings.EqualFold(u.Email, handle) || strings.EqualFold(local, handle) ||
+			strings.EqualFold(u.DisplayName, handle) {
+			exact = append(exact, u)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0], nil
+	}
+
+	var candidates strings.Builder
+	for _, u := range users {
+		fmt.Fprintf(&candidates, "\n  @%s\t%s", userHandle(u, installation), cmdutil.SanitizeTerminalText(u.DisplayName))
+	}
+	return nil, fmt.Errorf("@%s matches %d users, use one of:%s", handle, len(users), candidates.String())
+}
+
+// userHandle returns the handle that identifies the user unambiguously, ie:
+// the user name on server and the account id on cloud.
+func userHandle(u *jira.User, installation string) string {
+	if installation == jira.InstallationTypeLocal && u.Name != "" {
+		return u.Name
+	}
+	if u.AccountID != "" {
+		return u.AccountID
+	}
+	return u.Name
+}
+
+// Select resolves the users the criteria refer to and applies the selector. It fails if
+// --index is out of range.
+func (s *Selector) Select(client *jira.Client, attachments []jira.Attachment) ([]jira.Attachment, SelectionReport, error) {
+	if err := s.Resolve(client); err != nil {
+		return nil, SelectionReport{}, err
+	}
+	out, report := s.Apply(attachments)
+	if err := s.CheckIndex(report); err != nil {
+		return nil, report, err
+	}
+	return out, report, nil
+}
+
+// SelectIssues applies the selector to the attachments of all issues at once, so the
+// users are looked up a single time and Latest counts the attachments of all issues.
+func (s *Selector) SelectIssues(
+	client *jira.Client, issues []cmdcommon.IssueAttachments,
+) ([]cmdcommon.IssueAttachments, SelectionReport, error) {
+	var all []jira.Attachment
+	for _, iss := range issues {
+		all = append(all, iss.Attachments...)
+	}
+	if !s.Active() {
+		return issues, SelectionReport{Total: len(all), Numbers: Number(all)}, nil
+	}
+
+	matched, report, err := s.Select(client, all)
+	if err != nil {
+		return nil, report, err
+	}
+	keep := make(map[string]struct{}, len(matched))
+	for _, a := range matched {
+		keep[a.ID] = struct{}{}
+	}
+
+	out := make([]cmdcommon.IssueAttachments, 0, len(issues))
+	for _, iss := range issues {
+		var kept []jira.Attachment
+		for _, a := range iss.Attachments {
+			if _, ok := keep[a.ID]; ok {
+				kept = append(kept, a)
+			}
+		}
+		out = append(out, cmdcommon.IssueAttachments{Issue: iss.Issue, Attachments: kept})
+	}
+	return out, report, nil
+}
+
+// Apply returns the attachments matching all criteria, in their order, along with how
+// many attachments each criterion removed. Mine and an @handle Author only match once
+// resolved with Resolve. Index is applied last and isn't checked, see CheckIndex. The
+// selection is logged with -v.
+func (s *Selector) Apply(attachments []jira.Attachment) ([]jira.Attachment, SelectionReport) {
+	report := SelectionReport{Total: len(attachments)}
+	if !s.Active() {
+		cmdutil.Log().Infof("Selected all %d attachment(s), no criteria given", len(attachments))
+		report.Numbers = Number(attachments)
+		return attachments, report
+	}
+
+	out := attachments
+	for _, c := range s.compile() {
+		if c.kind == CriterionIndex {
+			report.Numbers = Number(out)
+		}
+		kept := c.keep(out)
+		report.Steps = append(report.Steps, Step{
+			Criterion: c.kind,
+			Flag:      c.flag,
+			Removed:   len(out) - len(kept),
+			Remaining: len(kept),
+		})
+		out = kept
+	}
+	if report.Numbers == nil {
+		report.Numbers = Number(out)
+	}
+	logSelection(report, out)
+	return out, report
+}
+
+// logSelection logs what each criterion removed and the attachments left.
+func logSelection(report SelectionReport, selected []jira.Attachment) {
+	log := cmdutil.Log()
+	if !log.Enabled(jira.LogInfo) {
+		return
+	}
+	for _, st := range report.Steps {
+		log.Infof("Criterion %s removed %d, %d left", st.Flag, st.Removed, st.Remaining)
+	}
+	log.Infof("Selected %d of %d attachment(s)", report.Selected(), report.Total)
+	for _, a := range selected {
+		log.Infof("  %s (ID: %s)", a.Filename, a.ID)
+	}
+}
+
+// compile builds the criteria that are set, once.
+func (s *Selector) compile() []criterion {
+	if s.criteria != nil {
+		return s.criteria
+	}
+
+	var cs []criterion
+	add := func(kind Criterion, flag string, match func(a *jira.Attachment) bool) {
+		cs = append(cs, criterion{kind: kind, flag: flag, keep: func(in []jira.Attachment) []jira.Attachment {
+			var out []jira.Attachment
+			for i := range in {
+				if match(&in[i]) {
+					out = append(out, in[i])
+				}
+			}
+			return out
+		}})
+	}
+
+	if s.ID != "" {
+		add(CriterionID, "--id "+s.ID, func(a *jira.Attachment) bool {
+			return a.ID == s.ID
+		})
+	}
+	if s.Filename != "" {
+		add(CriterionFilename, strconv.Quote(s.Filename), func(a *jira.Attachment) bool {
+			return a.Filename == s.Filename
+		})
+	}
+	if s.Name != "" {
+		flag := s.nameFlag
+		if flag == "" {
+			flag = "--name"
+		}
+		add(CriterionName, flag+" '"+s.Name+"'", func(a *jira.Attachment) bool {
+			ok, _ := path.Match(s.Name, a.Filename)
+			return ok
+		})
+	}
+	if s.MIME != "" {
+		pattern := strings.ToLower(s.MIME)
+		switch {
+		case !strings.Contains(pattern, "/"):
+			pattern += "/*"
+		case strings.HasSuffix(pattern, "/"):
+			pattern += "*"
+		}
+		add(CriterionMIME, "--mime "+s.MIME, func(a *jira.Attachment) bool {
+			mime, _, _ := strings.Cut(strings.ToLower(a.MimeType), ";")
+			ok, _ := path.Match(pattern, strings.TrimSpace(mime))
+			return ok
+		})
+	}
+	if s.Mine {
+		var warned bool
+		add(CriterionMine, "--mine", func(a *jira.Attachment) bool {
+			match, fallback := cmdcommon.IsSameUser(a.Author, s.me, s.installation)
+			if fallback && !warned && s.warn != nil {
+				s.warn("Unable to identify users by their id, matching attachments by display name instead")
+				warned = true
+			}
+			return match
+		})
+	}
+	if s.Author != "" {
+		add(CriterionAuthor, "--author "+s.Author, func(a *jira.Attachment) bool {
+			return s.matchAuthor(a.Author)
+		})
+	}
+	if s.MinSize > 0 {
+		add(CriterionMinSize, "--min-size "+sizeText(s.minSize, s.MinSize), func(a *jira.Attachment) bool {
+			return a.Size >= s.MinSize
+		})
+	}
+	if s.MaxSize > 0 {
+		add(CriterionMaxSize, "--max-size "+sizeText(s.maxSize, s.MaxSize), func(a *jira.Attachment) bool {
+			return a.Size <= s.MaxSize
+		})
+	}
+	if s.OlderThan > 0 {
+		age := s.age
+		if age == "" {
+			age = s.OlderThan.String()
+		}
+		add(CriterionAge, "--older-than "+age, func(a *jira.Attachment) bool {
+			created, ok := a.CreatedTime()
+			return ok && created.Before(s.clock().Add(-s.OlderThan))
+		})
+	}
+	if s.Where != nil {
+		add(CriterionWhere, "--where '"+s.Where.String()+"'", s.Where.Match)
+	}
+	if s.Latest > 0 {
+		cs = append(cs, criterion{kind: CriterionLatest, flag: "--latest " + strconv.Itoa(s.Latest), keep: s.latest})
+	}
+	if len(s.Index) > 0 {
+		cs = append(cs, criterion{kind: CriterionIndex, flag: "--index " + formatIndexes(s.Index), keep: s.index})
+	}
+
+	s.criteria = cs
+	return cs
+}
+
+// sizeText returns a size flag as given, or the number of bytes if it was set directly.
+func sizeText(given string, n int64) string {
+	if given != "" {
+		return given
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// matchAuthor reports if the user matches Author. An @handle only matches once resolved,
+// and then by id like Mine does.
+func (s *Selector) matchAuthor(u jira.User) bool {
+	if strings.HasPrefix(s.Author, "@") {
+		if s.author == nil {
+			return false
+		}
+		match, _ := cmdcommon.IsSameUser(u, s.author, s.installation)
+		return match
+	}
+	if u.AccountID == s.Author || (u.Name != "" && u.Name == s.Author) {
+		return true
+	}
+	return u.DisplayName != "" && strings.Contains(strings.ToLower(u.DisplayName), strings.ToLower(s.Author))
+}
+
+// latest keeps the Latest most recently created attachments, in their order.
+func (s *Selector) latest(in []jira.Attachment) []jira.Attachment {
+	if len(in) <= s.Latest {
+		return in
+	}
+
+	order := make([]int, len(in))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(x, y int) int {
+		return jira.CompareAttachmentsByCreated(in[y], in[x])
+	})
+	keep := order[:s.Latest]
+	slices.Sort(keep)
+
+	out := make([]jira.Attachment, 0, len(keep))
+	for _, i := range keep {
+		out = append(out, in[i])
+	}
+	return out
+}
+
+// index keeps the attachments at the Index positions, in their order.
+func (s *Selector) index(in []jira.Attachment) []jira.Attachment {
+	numbers := Number(in)
+
+	var out []jira.Attachment
+	for _, a := range in {
+		if _, ok := slices.BinarySearch(s.Index, numbers[a.ID]); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func (s *Selector) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// Step is what a criterion did to the attachments left by the previous ones.
+type Step struct {
+	Criterion Criterion
+	// Flag is the criterion as given, eg: --older-than 30d.
+	Flag      string
+	Removed   int
+	Remaining int
+}
+
+// SelectionReport records how many attachments each criterion removed, in the order
+// the criteria were applied.
+type SelectionReport struct {
+	Total int
+	Steps []Step
+	// Numbers are the positions --index counts, by attachment id, of the attachments
+	// matching the criteria other than Index.
+	Numbers map[string]int
+}
+
+// Selected returns the number of attachments matching all criteria.
+func (r SelectionReport) Selected() int {
+	if len(r.Steps) == 0 {
+		return r.Total
+	}
+	return r.Steps[len(r.Steps)-1].Remaining
+}
+
+// Eliminator returns the step that removed the last candidates, if nothing was selected
+// out of some attachments.
+func (r SelectionReport) Eliminator() (Step, bool) {
+	if r.Total == 0 || r.Selected() > 0 {
+		return Step{}, false
+	}
+	for _, st := range r.Steps {
+		if st.Remaining == 0 {
+			return st, true
+		}
+	}
+	return Step{}, false
+}
+
+// Explain tells which criterion removed the last candidates, eg: "--mine removed the
+// last 2 of 5". It is empty if something was selected, or if a single criterion was
+// set as it is then obvious.
+func (r SelectionReport) Explain() string {
+	st, ok := r.Eliminator()
+	if !ok || len(r.Steps) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%s removed the last %d of %d", st.Flag, st.Removed, r.Total)
+}
@@ -0,0 +1,484 @@
+package selector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/where"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+var now = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+var fixtures = []jira.Attachment{
+	{ID: "1", Filename: "report.pdf", MimeType: "application/pdf", Size: 100, Author: jira.User{AccountID: "me", DisplayName: "Me"}, Created: "2024-01-01T10:00:00.000+0000"},
+	{ID: "2", Filename: "screenshot.PNG", MimeType: "image/png", Size: 2048, Author: jira.User{AccountID: "me", DisplayName: "Me"}, Created: "2024-02-25T10:00:00.000+0000"},
+	{ID: "3", Filename: "photo.jpg", MimeType: "IMAGE/JPEG; charset=binary", Size: 4096, Author: jira.User{AccountID: "jane", Name: "jane", DisplayName: "Jane Doe"}, Created: "2024-01-15T10:00:00.000+0000"},
+	{ID: "4", Filename: "report.pdf", MimeType: "application/pdf", Size: 300, Author: jira.User{AccountID: "jane", Name: "jane", DisplayName: "Jane Doe"}, Created: "2024-02-28T10:00:00.000+0000"},
+	{ID: "5", Filename: "notes.txt", MimeType: "text/plain", Size: 10, Author: jira.User{AccountID: "bob", DisplayName: "Bob"}, Created: "not a date"},
+}
+
+func ids(attachments []jira.Attachment) []string {
+	out := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		out = append(out, a.ID)
+	}
+	return out
+}
+
+// resolved returns a selector as if Resolve had run for the current user "me".
+func resolved(s *Selector) *Selector {
+	s.me = &jira.User{AccountID: "me"}
+	s.installation = jira.InstallationTypeCloud
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func mustWhere(t *testing.T, expr string) *where.Expr {
+	t.Helper()
+
+	e, err := where.Compile(expr)
+	require.NoError(t, err)
+	return e
+}
+
+func parse(t *testing.T, args ...string) (*Selector, error) {
+	t.Helper()
+
+	cmd := &cobra.Command{Use: "test"}
+	SetFlags(cmd)
+	require.NoError(t, cmd.Flags().Parse(args))
+	return New(cmd.Flags())
+}
+
+func TestSetFlags(t *testing.T) {
+	t.Parallel()
+
+	cmd := &cobra.Command{Use: "test"}
+	SetFlags(cmd)
+
+	for _, name := range []string{"id", "name", "filter", "mime", "mine", "author", "min-size", "max-size", "older-than", "where", "latest", "index"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	s, err := parse(t)
+	require.NoError(t, err)
+	assert.False(t, s.Active())
+
+	s, err = parse(t,
+		"--id", " 10 ", "--name", "*.pdf", "--mime", "image", "--mine", "--author", "@jane",
+		"--older-than", "30d", "--where", "size > 1KB", "--latest", "2",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "10", s.ID)
+	assert.Equal(t, "*.pdf", s.Name)
+	assert.Equal(t, "image", s.MIME)
+	assert.True(t, s.Mine)
+	assert.Equal(t, "@jane", s.Author)
+	assert.Equal(t, 30*24*time.Hour, s.OlderThan)
+	assert.NotNil(t, s.Where)
+	assert.Equal(t, 2, s.Latest)
+	assert.True(t, s.Active())
+	assert.True(t, s.Filtered())
+
+	cases := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--name", "[a-"}, wantErr: `invalid --name pattern "[a-"`},
+		{args: []string{"--mime", "image/["}, wantErr: `invalid --mime pattern "image/["`},
+		{args: []string{"--author", " @ "}, wantErr: `invalid --author "@", the handle is missing`},
+		{args: []string{"--older-than", "soon"}, wantErr: `invalid age "soon"`},
+		{args: []string{"--where", "size >"}, wantErr: "invalid --where expression"},
+		{args: []string{"--filter", "[a-"}, wantErr: `invalid --filter pattern "[a-"`},
+		{args: []string{"--filter", "*.log", "--name", "*.har"}, wantErr: "--filter is an alias of --name, give only one of them"},
+		{args: []string{"--min-size", "big"}, wantErr: "invalid --min-size: invalid size"},
+		{args: []string{"--max-size", "0"}, wantErr: "--max-size must be larger than 0"},
+		{args: []string{"--min-size", "2MB", "--max-size", "1MB"}, wantErr: "--min-size 2MB is larger than --max-size 1MB"},
+		{args: []string{"--index", "3-1"}, wantErr: `invalid --index range "3-1"`},
+	}
+	for _, tc := range cases {
+		_, err := parse(t, tc.args...)
+		assert.ErrorContains(t, err, tc.wantErr, tc.args)
+	}
+}
+
+func TestNewFilterAndSizes(t *testing.T) {
+	t.Parallel()
+
+	s, err := parse(t, "--filter", " *.har ", "--min-size", "1.5KB", "--max-size", "50mb")
+	require.NoError(t, err)
+	assert.Equal(t, "*.har", s.Name)
+	assert.Equal(t, int64(1536), s.MinSize)
+	assert.Equal(t, int64(50<<20), s.MaxSize)
+	assert.True(t, s.Filtered())
+	assert.Equal(t, []string{"--filter '*.har'", "--min-size 1.5KB", "--max-size 50mb"}, s.Describe())
+
+	// The same pattern given to both is fine, eg: from an alias and a script.
+	s, err = parse(t, "--filter", "*.log", "--name", "*.log")
+	require.NoError(t, err)
+	assert.Equal(t, "*.log", s.Name)
+}
+
+func TestActiveAndFiltered(t *testing.T) {
+	t.Parallel()
+
+	var nilSelector *Selector
+	assert.False(t, nilSelector.Active())
+	assert.False(t, nilSelector.Filtered())
+
+	assert.True(t, (&Selector{ID: "1"}).Active())
+	assert.False(t, (&Selector{ID: "1"}).Filtered())
+	assert.True(t, (&Selector{Filename: "a.txt"}).Active())
+	assert.False(t, (&Selector{Filename: "a.txt"}).Filtered())
+	assert.True(t, (&Selector{Latest: 1}).Filtered())
+	assert.True(t, (&Selector{MinSize: 1}).Filtered())
+	assert.True(t, (&Selector{MaxSize: 1}).Filtered())
+}
+
+func TestApplyCriterion(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		selector *Selector
+		want     []string
+	}{
+		{name: "none", selector: &Selector{}, want: []string{"1", "2", "3", "4", "5"}},
+		{name: "id", selector: &Selector{ID: "3"}, want: []string{"3"}},
+		{name: "unknown id", selector: &Selector{ID: "9"}, want: []string{}},
+		{name: "filename is exact", selector: &Selector{Filename: "report.pdf"}, want: []string{"1", "4"}},
+		{name: "filename is case sensitive", selector: &Selector{Filename: "screenshot.png"}, want: []string{}},
+		{name: "filename is not a glob", selector: &Selector{Filename: "*.pdf"}, want: []string{}},
+		{name: "name glob", selector: &Selector{Name: "*.pdf"}, want: []string{"1", "4"}},
+		{name: "name glob is case sensitive", selector: &Selector{Name: "*.png"}, want: []string{}},
+		{name: "name character class", selector: &Selector{Name: "[ps]*"}, want: []string{"2", "3"}},
+		{name: "mime exact", selector: &Selector{MIME: "application/pdf"}, want: []string{"1", "4"}},
+		{name: "mime glob ignores case and parameters", selector: &Selector{MIME: "image/*"}, want: []string{"2", "3"}},
+		{name: "mime type without subtype", selector: &Selector{MIME: "Image"}, want: []string{"2", "3"}},
+		{name: "mime type prefix", selector: &Selector{MIME: "image/"}, want: []string{"2", "3"}},
+		{name: "mime prefix of another type", selector: &Selector{MIME: "imag/"}, want: []string{}},
+		{name: "mine", selector: &Selector{Mine: true}, want: []string{"1", "2"}},
+		{name: "author by account id", selector: &Selector{Author: "jane"}, want: []string{"3", "4"}},
+		{name: "author by part of the display name", selector: &Selector{Author: "doe"}, want: []string{"3", "4"}},
+		{name: "min size is inclusive", selector: &Selector{MinSize: 300}, want: []string{"2", "3", "4"}},
+		{name: "max size is inclusive", selector: &Selector{MaxSize: 300}, want: []string{"1", "4", "5"}},
+		{name: "size range", selector: &Selector{MinSize: 100, MaxSize: 2048}, want: []string{"1", "2", "4"}},
+		{name: "older than skips unparsable dates", selector: &Selector{OlderThan: 30 * 24 * time.Hour}, want: []string{"1", "3"}},
+		{name: "where", selector: &Selector{Where: mustWhere(t, "size >= 2KB")}, want: []string{"2", "3"}},
+		// Without a created date, attachments are ordered by id like list orders them.
+		{name: "latest", selector: &Selector{Latest: 2}, want: []string{"4", "5"}},
+		{name: "latest keeps the order", selector: &Selector{Latest: 3}, want: []string{"2", "4", "5"}},
+		{name: "latest above the count", selector: &Selector{Latest: 10}, want: []string{"1", "2", "3", "4", "5"}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, report := resolved(tc.selector).Apply(fixtures)
+			assert.Equal(t, tc.want, ids(got))
+			assert.Equal(t, len(fixtures), report.Total)
+			assert.Equal(t, len(tc.want), report.Selected())
+		})
+	}
+}
+
+func TestApplyCombinations(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		selector *Selector
+		want     []string
+	}{
+		{name: "mine and older than", selector: &Selector{Mine: true, OlderThan: 30 * 24 * time.Hour}, want: []string{"1"}},
+		{name: "name and author", selector: &Selector{Name: "*.pdf", Author: "jane"}, want: []string{"4"}},
+		{name: "filename and latest", selector: &Selector{Filename: "report.pdf", Latest: 1}, want: []string{"4"}},
+		{name: "latest counts the matches only", selector: &Selector{MIME: "image", Latest: 1}, want: []string{"2"}},
+		{name: "id and mismatching filter", selector: &Selector{ID: "1", Author: "jane"}, want: []string{}},
+		{name: "filter and max size", selector: &Selector{Name: "*.pdf", MaxSize: 200}, want: []string{"1"}},
+		{name: "mime prefix and author", selector: &Selector{MIME: "image/", Author: "jane"}, want: []string{"3"}},
+		{name: "min size and mine", selector: &Selector{MinSize: 1024, Mine: true}, want: []string{"2"}},
+		{name: "where and older than", selector: &Selector{Where: mustWhere(t, `author = jane or created > 2024-02-01`), OlderThan: 30 * 24 * time.Hour}, want: []string{"3"}},
+		{
+			name:     "all",
+			selector: &Selector{Name: "*", MIME: "*/*", Author: "e", OlderThan: time.Hour, Where: mustWhere(t, "size > 0"), Latest: 2},
+			want:     []string{"2", "4"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, _ := resolved(tc.selector).Apply(fixtures)
+			assert.Equal(t, tc.want, ids(got))
+		})
+	}
+}
+
+func TestApplyReport(t *testing.T) {
+	t.Parallel()
+
+	s := resolved(&Selector{Name: "*.pdf", Mine: true, OlderThan: 30 * 24 * time.Hour, age: "30d", Latest: 1})
+	got, report := s.Apply(fixtures)
+	assert.Equal(t, []string{"1"}, ids(got))
+	assert.Equal(t, SelectionReport{Total: 5, Steps: []Step{
+		{Criterion: CriterionName, Flag: "--name '*.pdf'", Removed: 3, Remaining: 2},
+		{Criterion: CriterionMine, Flag: "--mine", Removed: 1, Remaining: 1},
+		{Criterion: CriterionAge, Flag: "--older-than 30d", Removed: 0, Remaining: 1},
+		{Criterion: CriterionLatest, Flag: "--latest 1", Removed: 0, Remaining: 1},
+	}, Numbers: map[string]int{"1": 1}}, report)
+	assert.Equal(t, 1, report.Selected())
+
+	_, ok := report.Eliminator()
+	assert.False(t, ok)
+	assert.Empty(t, report.Explain())
+
+	// The criteria are compiled once, applying again gives the same result.
+	again, _ := s.Apply(fixtures)
+	assert.Equal(t, got, again)
+}
+
+func TestApplyReportEliminator(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		selector    *Selector
+		attachments []jira.Attachment
+		eliminator  Criterion
+		explain     string
+	}{
+		{
+			name:        "last criterion",
+			selector:    &Selector{Mine: true, Author: "jane"},
+			attachments: fixtures,
+			eliminator:  CriterionAuthor,
+			explain:     "--author jane removed the last 2 of 5",
+		},
+		{
+			name:        "first criterion, later ones have nothing left",
+			selector:    &Selector{ID: "9", Mine: true},
+			attachments: fixtures,
+			eliminator:  CriterionID,
+			explain:     "--id 9 removed the last 5 of 5",
+		},
+		{
+			name:        "single criterion isn't explained",
+			selector:    &Selector{Filename: "missing.txt"},
+			attachments: fixtures,
+			eliminator:  CriterionFilename,
+		},
+		{
+			name:     "no attachments",
+			selector: &Selector{Mine: true, Author: "jane"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, report := resolved(tc.selector).Apply(tc.attachments)
+			assert.Empty(t, got)
+
+			st, ok := report.Eliminator()
+			assert.Equal(t, tc.eliminator != "", ok)
+			assert.Equal(t, tc.eliminator, st.Criterion)
+			assert.Equal(t, tc.explain, report.Explain())
+		})
+	}
+}
+
+func TestApplyWarnsOnFallback(t *testing.T) {
+	t.Parallel()
+
+	attachments := []jira.Attachment{
+		{ID: "1", Author: jira.User{DisplayName: "Me"}},
+		{ID: "2", Author: jira.User{DisplayName: "Other"}},
+		{ID: "3", Author: jira.User{DisplayName: "Me"}},
+	}
+
+	var warnings []string
+	s := &Selector{Mine: true, me: &jira.User{DisplayName: "Me"}, installation: jira.InstallationTypeCloud}
+	s.warn = func(msg string) { warnings = append(warnings, msg) }
+
+	out, _ := s.Apply(attachments)
+	assert.Equal(t, []string{"1", "3"}, ids(out))
+	assert.Len(t, warnings, 1)
+}
+
+func TestApplyUnresolved(t *testing.T) {
+	t.Parallel()
+
+	// Mine and an @handle match nothing rather than the handle string until resolved.
+	attachments := []jira.Attachment{{ID: "1", Author: jira.User{AccountID: "acc-jonas", DisplayName: "@jon"}}}
+
+	out, _ := (&Selector{Mine: true}).Apply(attachments)
+	assert.Empty(t, out)
+
+	out, _ = (&Selector{Author: "@jon"}).Apply(attachments)
+	assert.Empty(t, out)
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	var nilSelector *Selector
+	assert.Nil(t, nilSelector.Describe())
+	assert.Nil(t, (&Selector{}).Describe())
+
+	s := &Selector{
+		ID: "10", Filename: "a b.txt", Name: "*.txt", MIME: "text", Mine: true, Author: "@jane",
+		OlderThan: 30 * 24 * time.Hour, age: "30d", Where: mustWhere(t, `filename ~ "release-notes*.pdf"`), Latest: 3,
+	}
+	assert.Equal(t, []string{
+		"--id 10",
+		`"a b.txt"`,
+		"--name '*.txt'",
+		"--mime text",
+		"--mine",
+		"--author @jane",
+		"--older-than 30d",
+		`--where 'filename ~ "release-notes*.pdf"'`,
+		"--latest 3",
+	}, s.Describe())
+
+	assert.Equal(t, []string{"--older-than 2h0m0s"}, (&Selector{OlderThan: 2 * time.Hour}).Describe())
+}
+
+func TestResolveAuthor(t *testing.T) {
+	t.Parallel()
+
+	jane := jira.User{AccountID: "acc-jane", Name: "jane", DisplayName: "Jane Doe", Email: "jane@example.com"}
+	janet := jira.User{AccountID: "acc-janet", Name: "janet", DisplayName: "Janet Roe", Email: "janet@example.com"}
+	jon := jira.User{AccountID: "acc-jon", Name: "jon", DisplayName: "Jon Doe", Email: "jon@example.com"}
+
+	server := jiratest.NewServer(jiratest.WithUsers(jane, janet, jon))
+	t.Cleanup(server.Close)
+
+	client := server.Client()
+
+	cases := []struct {
+		name     string
+		author   string
+		expected string
+		err      string
+	}{
+		{name: "unique match", author: "@jon", expected: "acc-jon"},
+		{name: "exact match among partial ones", author: "@jane", expected: "acc-jane"},
+		{name: "ambiguous", author: "@doe", err: "@doe matches 2 users, use one of:\n  @acc-jane\tJane Doe\n  @acc-jon\tJon Doe"},
+		{name: "unknown", author: "@nobody", err: "no user found for @nobody"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &Selector{Author: tc.author, installation: jira.InstallationTypeCloud}
+			err := s.Resolve(client)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, s.author.AccountID)
+		})
+	}
+}
+
+func TestSelectAuthor(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithUsers(jira.User{AccountID: "acc-jon", Name: "jon", DisplayName: "Jon Doe"}))
+	t.Cleanup(server.Close)
+
+	attachments := []jira.Attachment{
+		{ID: "1", Author: jira.User{AccountID: "acc-jon", DisplayName: "Jonathan D."}},
+		// Same display name, different user.
+		{ID: "2", Author: jira.User{AccountID: "acc-other", DisplayName: "Jon Doe"}},
+		// Display name contains the handle.
+		{ID: "3", Author: jira.User{AccountID: "acc-jonas", DisplayName: "@jon"}},
+	}
+
+	s := &Selector{Author: "@jon", installation: jira.InstallationTypeCloud}
+	out, _, err := s.Select(server.Client(), attachments)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ids(out))
+}
+
+func TestResolveIsCached(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithUsers(jira.User{AccountID: "acc-jon", DisplayName: "Jon Doe"}))
+	t.Cleanup(server.Close)
+
+	s := &Selector{Mine: true, Author: "@jon", installation: jira.InstallationTypeCloud}
+	for range 2 {
+		_, _, err := s.Select(server.Client(), nil)
+		require.NoError(t, err)
+	}
+	// The current user and the handle are looked up once.
+	assert.Len(t, server.Requests(), 2)
+
+	// Values without @ are never resolved.
+	before := len(server.Requests())
+	_, _, err := (&Selector{Author: "Jon", installation: jira.InstallationTypeCloud}).Select(server.Client(), nil)
+	require.NoError(t, err)
+	assert.Len(t, server.Requests(), before)
+}
+
+func TestSelectIssues(t *testing.T) {
+	t.Parallel()
+
+	issues := []cmdcommon.IssueAttachments{
+		{Issue: "TEST-1", Attachments: []jira.Attachment{
+			{ID: "1", Filename: "a.png", Created: "2024-01-01T10:00:00.000+0000"},
+			{ID: "2", Filename: "b.txt", Created: "2024-01-02T10:00:00.000+0000"},
+		}},
+		{Issue: "TEST-2", Attachments: []jira.Attachment{
+			{ID: "3", Filename: "c.txt", Created: "2024-01-03T10:00:00.000+0000"},
+		}},
+	}
+
+	got, report, err := (&Selector{}).SelectIssues(nil, issues)
+	require.NoError(t, err)
+	assert.Equal(t, issues, got)
+	assert.Equal(t, 3, report.Selected())
+
+	got, report, err = (&Selector{Name: "*.txt", installation: jira.InstallationTypeCloud}).SelectIssues(nil, issues)
+	require.NoError(t, err)
+	assert.Equal(t, []cmdcommon.IssueAttachments{
+		{Issue: "TEST-1", Attachments: []jira.Attachment{issues[0].Attachments[1]}},
+		{Issue: "TEST-2", Attachments: []jira.Attachment{issues[1].Attachments[0]}},
+	}, got)
+	assert.Equal(t, 2, report.Selected())
+
+	// Latest counts the attachments of all issues together.
+	got, _, err = (&Selector{Latest: 1, installation: jira.InstallationTypeCloud}).SelectIssues(nil, issues)
+	require.NoError(t, err)
+	assert.Equal(t, []cmdcommon.IssueAttachments{
+		{Issue: "TEST-1"},
+		{Issue: "TEST-2", Attachments: []jira.Attachment{issues[1].Attachments[0]}},
+	}, got)
+}
@@ -0,0 +1,38 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestStatsOutputFormats(t *testing.T) {
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "design.pdf", []byte("%PDF-1.4"))
+	server.AddAttachment("TEST-1", "screenshot.png", []byte("png"))
+
+	env := cmdtest.Env{Client: server.Client(), Config: map[string]any{"server": server.URL}}
+
+	for _, format := range []string{"table", "csv", "json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			res := cmdtest.Run(t, env, NewCmdAttachmentStats(), "TEST-1", "-o", format)
+			require.NoError(t, res.Err)
+			cmdtest.AssertOutput(t, format, res.Stdout)
+		})
+	}
+
+	res := cmdtest.Run(t, env, NewCmdAttachmentStats(), "TEST-1", "-o", "yaml")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, "count: 2\n")
+	assert.Contains(t, res.Stdout, "categories:\n  - name: images\n    count: 1\n")
+
+	res = cmdtest.Run(t, env, NewCmdAttachmentStats(), "TEST-1", "--json")
+	require.NoError(t, res.Err)
+	assert.Contains(t, res.Stdout, `"count": 2`)
+}
@@ -0,0 +1,328 @@
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	helpText = `Stats shows a summary of attachments on an issue.`
+	examples = `$ jira issue attachment stats ISSUE-1
+
+# Print stats as JSON, YAML or CSV
+$ jira issue attachment stats ISSUE-1 -o json
+$ jira issue attachment stats ISSUE-1 -o yaml
+$ jira issue attachment stats ISSUE-1 -o csv
+
+# Print stats as JSON with non-ASCII characters escaped
+$ jira issue attachment stats ISSUE-1 --ascii`
+
+	categoryImages    = "images"
+	categoryDocuments = "documents"
+	categoryArchives  = "archives"
+	categoryOther     = "other"
+
+	mb           = 1024 * 1024
+	histogramBar = 30
+)
+
+var (
+	categories = []string{categoryImages, categoryDocuments, categoryArchives, categoryOther}
+
+	buckets = []struct {
+		label string
+		upper int64 // Exclusive, 0 means unbounded.
+	}{
+		{label: "<1MB", upper: mb},
+		{label: "1-10MB", upper: 10 * mb},
+		{label: "10-100MB", upper: 100 * mb},
+		{label: ">100MB", upper: 0},
+	}
+)
+
+// NewCmdAttachmentStats is an attachment stats command.
+func NewCmdAttachmentStats() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "stats ISSUE-KEY",
+		Short:   "Show a summary of attachments on an issue",
+		Long:    helpText,
+		Example: examples,
+		Annotations: map[string]string{
+			"help:args": "ISSUE-KEY\tIssue key, eg: ISSUE-1",
+		},
+		Run: stats,
+	}
+
+	cmdutil.RegisterOutputFlag(&cmd,
+		[]string{cmdutil.OutputTable, cmdutil.OutputCSV, cmdutil.OutputJSON, cmdutil.OutputYAML},
+		cmdutil.OutputAlias{Flag: "json", Format: cmdutil.OutputJSON, Usage: "Print stats as JSON"},
+	)
+	cmd.Flags().Bool("ascii", false, `Escape non-ASCII characters in the JSON output as \u sequences, implies --output json`)
+	cmdcommon.SetTimezoneFlag(&cmd)
+
+	return &cmd
+}
+
+func stats(cmd *cobra.Command, args []string) {
+	params := parseArgsAndFlags(args, cmd.Flags())
+	output, err := resolveOutput(cmd, params.ascii)
+	cmdutil.ExitIfError(err)
+	loc, err := cmdcommon.DisplayLocation(cmd)
+	cmdutil.ExitIfError(err)
+	version, err := cmdcommon.GetAPIVersion(cmd)
+	cmdutil.ExitIfError(err)
+	client := api.DefaultClient(params.debug)
+
+	if params.issueKey == "" {
+		cmdutil.Failed("ISSUE-KEY is required")
+	}
+
+	issue, err := cmdcommon.GetAttachmentIssue(client, version, params.issueKey, cmdcommon.AttachmentIssueFields)
+	cmdutil.ExitIfRequestError(err, params.debug)
+
+	s := aggregate(issue.Fields.Attachments)
+
+	switch {
+	case params.ascii:
+		cmdutil.ExitIfError(renderJSON(cmdutil.NewASCIIWriter(os.Stdout), s))
+	case output.Structured():
+		cmdutil.ExitIfError(output.Encode(os.Stdout, s))
+	case output.Format == cmdutil.OutputCSV:
+		cmdutil.ExitIfError(renderCSV(os.Stdout, s))
+	default:
+		render(os.Stdout, s, loc)
+	}
+}
+
+// resolveOutput resolves the output format, --ascii implies json.
+func resolveOutput(cmd *cobra.Command, ascii bool) (cmdutil.Renderer, error) {
+	output, err := cmdutil.ResolveRenderer(cmd)
+	if err != nil {
+		return output, err
+	}
+	return output.Imply(ascii, "ascii", cmdutil.OutputJSON)
+}
+
+type statsParams struct {
+	issueKey string
+	ascii    bool
+	debug    bool
+}
+
+func parseArgsAndFlags(args []string, flags query.FlagParser) *statsParams {
+	var issueKey string
+
+	if len(args) >= 1 {
+		issueKey = cmdutil.GetJiraIssueKey(viper.GetString("project.key"), args[0])
+	}
+
+	debug, err := flags.GetBool("debug")
+	cmdutil.ExitIfError(err)
+
+	ascii, err := flags.GetBool("ascii")
+	cmdutil.ExitIfError(err)
+
+	return &statsParams{
+		issueKey: issueKey,
+		ascii:    ascii,
+		debug:    debug,
+	}
+}
+
+// Group is an aggregate of a group of attachments.
+type Group struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Size  int64  `json:"size"`
+}
+
+// Stats is a summary of attachments.
+type Stats struct {
+	Count       int    `json:"count"`
+	TotalSize   int64  `json:"totalSize"`
+	LargestFile string `json:"largestFile,omitempty"`
+	LargestSize int64  `json:"largestSize"`
+	Oldest      string `json:"oldest,omitempty"`
+	Newest      string `json:"newest,omitempty"`
+	// oldest and newest are Oldest and Newest, which are RFC 3339 in UTC, as times.
+	oldest, newest time.Time
+	Categories     []Group `json:"categories"`
+	Distribution   []Group `json:"distribution"`
+}
+
+func aggregate(attachments []jira.Attachment) *Stats {
+	s := Stats{
+		Categories:   make([]Group, len(categories)),
+		Distribution: make([]Group, len(buckets)),
+	}
+	for i, c := range categories {
+		s.Categories[i].Name = c
+	}
+	for i, b := range buckets {
+		s.Distribution[i].Name = b.label
+	}
+
+	for _, a := range attachments {
+		s.Count++
+		s.TotalSize += a.Size
+
+		if s.LargestFile == "" || a.Size > s.LargestSize {
+			s.LargestFile, s.LargestSize = a.Filename, a.Size
+		}
+
+		if created, ok := a.CreatedTime(); ok {
+			if s.oldest.IsZero() || created.Before(s.oldest) {
+				s.oldest, s.Oldest = created, a.CreatedUTC()
+			}
+			if s.newest.IsZero() || created.After(s.newest) {
+				s.newest, s.Newest = created, a.CreatedUTC()
+			}
+		}
+
+		c := categoryIndex(a.MimeType)
+		s.Categories[c].Count++
+		s.Categories[c].Size += a.Size
+
+		b := bucketIndex(a.Size)
+		s.Distribution[b].Count++
+		s.Distribution[b].Size += a.Size
+	}
+
+	return &s
+}
+
+func categoryIndex(mimeType string) int {
+	return indexOf(categories, category(mimeType))
+}
+
+func category(mimeType string) string {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return categoryImages
+	case strings.HasPrefix(mimeType, "text/"),
+		mimeType == "application/pdf",
+		mimeType == "application/rtf",
+		mimeType == "application/msword",
+		strings.HasPrefix(mimeType, "application/vnd.ms-"),
+		strings.HasPrefix(mimeType, "application/vnd.openxmlformats-officedocument."),
+		strings.HasPrefix(mimeType, "application/vnd.oasis.opendocument."):
+		return categoryDocuments
+	}
+
+	switch mimeType {
+	case "application/zip", "application/x-zip-compressed", "application/gzip", "application/x-gzip",
+		"application/x-tar", "application/x-7z-compressed", "application/x-rar-compressed",
+		"application/vnd.rar", "application/x-bzip2", "application/x-xz":
+		return categoryArchives
+	}
+	return categoryOther
+}
+
+func bucketIndex(size int64) int {
+	for i, b := range buckets {
+		if b.upper == 0 || size < b.upper {
+			return i
+		}
+	}
+	return len(buckets) - 1
+}
+
+func indexOf(items []string, item string) int {
+	for i, v := range items {
+		if v == item {
+			return i
+		}
+	}
+	return -1
+}
+
+// render renders the stats as a table with the dates in loc.
+func render(w io.Writer, s *Stats, loc *time.Location) {
+	if s.Count == 0 {
+		fmt.Fprintln(w, "No attachments")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+
+	fmt.Fprintf(tw, "Count:\t%d\n", s.Count)
+	fmt.Fprintf(tw, "Total size:\t%s\n", cmdutil.FormatSize(s.TotalSize))
+	fmt.Fprintf(tw, "Largest:\t%s (%s)\n", s.LargestFile, cmdutil.FormatSize(s.LargestSize))
+	if s.Oldest != "" {
+		fmt.Fprintf(tw, "Oldest:\t%s\n", s.oldest.In(loc).Format(time.DateOnly))
+		fmt.Fprintf(tw, "Newest:\t%s\n", s.newest.In(loc).Format(time.DateOnly))
+	}
+
+	fmt.Fprintf(tw, "\nTYPE\tCOUNT\tSIZE\n")
+	for _, c := range s.Categories {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", c.Name, c.Count, cmdutil.FormatSize(c.Size))
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+	renderHistogram(w, s.Distribution, histogramBar)
+}
+
+// renderHistogram renders a bar for each group scaled to the widest group.
+func renderHistogram(w io.Writer, groups []Group, width int) {
+	var most int
+	for _, g := range groups {
+		most = max(most, g.Count)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	for _, g := range groups {
+		var bar int
+		if most > 0 {
+			bar = g.Count * width / most
+		}
+		if g.Count > 0 && bar == 0 {
+			bar = 1
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", g.Name, strings.Repeat("#", bar), g.Count)
+	}
+	_ = tw.Flush()
+}
+
+func renderJSON(w io.Writer, s *Stats) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// renderCSV writes a row for each category and size bucket, with the kind of group first.
+func renderCSV(w io.Writer, s *Stats) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"GROUP", "NAME", "COUNT", "SIZE"})
+	for _, part := range []struct {
+		kind   string
+		groups []Group
+	}{
+		{kind: "type", groups: s.Categories},
+		{kind: "size", groups: s.Distribution},
+	} {
+		for _, g := range part.groups {
+			_ = cw.Write([]string{part.kind, g.Name, strconv.Itoa(g.Count), strconv.FormatInt(g.Size, 10)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
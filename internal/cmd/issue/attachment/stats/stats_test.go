@@ -0,0 +1,159 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func TestBucketIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		size     int64
+		expected int
+	}{
+		{name: "empty file", size: 0, expected: 0},
+		{name: "just under 1 MB", size: mb - 1, expected: 0},
+		{name: "exactly 1 MB", size: mb, expected: 1},
+		{name: "just under 10 MB", size: 10*mb - 1, expected: 1},
+		{name: "exactly 10 MB", size: 10 * mb, expected: 2},
+		{name: "exactly 100 MB", size: 100 * mb, expected: 3},
+		{name: "huge", size: 5000 * mb, expected: 3},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, bucketIndex(tt.size))
+		})
+	}
+}
+
+func TestCategory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mimeType string
+		expected string
+	}{
+		{mimeType: "image/png", expected: categoryImages},
+		{mimeType: "IMAGE/JPEG", expected: categoryImages},
+		{mimeType: "application/pdf", expected: categoryDocuments},
+		{mimeType: "text/plain; charset=utf-8", expected: categoryDocuments},
+		{mimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", expected: categoryDocuments},
+		{mimeType: "application/zip", expected: categoryArchives},
+		{mimeType: "application/x-tar", expected: categoryArchives},
+		{mimeType: "application/octet-stream", expected: categoryOther},
+		{mimeType: "", expected: categoryOther},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, category(tt.mimeType), tt.mimeType)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty input", func(t *testing.T) {
+		t.Parallel()
+
+		s := aggregate(nil)
+		assert.Equal(t, 0, s.Count)
+		assert.Equal(t, int64(0), s.TotalSize)
+		assert.Empty(t, s.LargestFile)
+		assert.Empty(t, s.Oldest)
+		assert.Len(t, s.Categories, 4)
+		assert.Len(t, s.Distribution, 4)
+	})
+
+	t.Run("attachments", func(t *testing.T) {
+		t.Parallel()
+
+		s := aggregate([]jira.Attachment{
+			{Filename: "a.png", Size: 500, MimeType: "image/png", Created: "2024-02-01T10:00:00.000+0000"},
+			{Filename: "b.pdf", Size: 2 * mb, MimeType: "application/pdf", Created: "2024-01-01T10:00:00.000+0100"},
+			{Filename: "c.zip", Size: 200 * mb, MimeType: "application/zip", Created: "2024-03-01T10:00:00.000+0000"},
+			{Filename: "d.png", Size: mb, MimeType: "image/png", Created: "invalid"},
+		})
+
+		assert.Equal(t, 4, s.Count)
+		assert.Equal(t, int64(500+3*mb+200*mb), s.TotalSize)
+		assert.Equal(t, "c.zip", s.LargestFile)
+		assert.Equal(t, "2024-01-01T09:00:00Z", s.Oldest)
+		assert.Equal(t, "2024-03-01T10:00:00Z", s.Newest)
+		assert.Equal(t, Group{Name: categoryImages, Count: 2, Size: 500 + mb}, s.Categories[0])
+		assert.Equal(t, Group{Name: categoryDocuments, Count: 1, Size: 2 * mb}, s.Categories[1])
+		assert.Equal(t, Group{Name: categoryArchives, Count: 1, Size: 200 * mb}, s.Categories[2])
+		assert.Equal(t, Group{Name: categoryOther}, s.Categories[3])
+		assert.Equal(t, []int{1, 2, 0, 1}, []int{
+			s.Distribution[0].Count, s.Distribution[1].Count, s.Distribution[2].Count, s.Distribution[3].Count,
+		})
+	})
+}
+
+func TestRenderHistogram(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	renderHistogram(&b, []Group{
+		{Name: "<1MB", Count: 10},
+		{Name: "1-10MB", Count: 5},
+		{Name: "10-100MB", Count: 0},
+		{Name: ">100MB", Count: 1},
+	}, 10)
+
+	expected := "<1MB     ########## 10\n" +
+		"1-10MB   #####      5\n" +
+		"10-100MB            0\n" +
+		">100MB   #          1\n"
+
+	assert.Equal(t, expected, b.String())
+}
+
+func TestRenderJSONASCII(t *testing.T) {
+	t.Parallel()
+
+	s := aggregate([]jira.Attachment{
+		{Filename: "報告書.pdf", Size: 1234567, MimeType: "application/pdf", Created: "2024-01-01T10:00:00.000+0000"},
+	})
+
+	var buf bytes.Buffer
+	assert.NoError(t, renderJSON(cmdutil.NewASCIIWriter(&buf), s))
+
+	out := buf.String()
+	assert.Contains(t, out, `"largestFile": "\u5831\u544a\u66f8.pdf"`)
+	assert.Contains(t, out, `"totalSize": 1234567`)
+	for _, r := range out {
+		assert.Less(t, r, rune(0x80))
+	}
+
+	var got Stats
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "報告書.pdf", got.LargestFile)
+}
+
+func TestRenderDates(t *testing.T) {
+	t.Parallel()
+
+	// Created just after midnight in Auckland, it is still the day before in UTC.
+	s := aggregate([]jira.Attachment{{Filename: "nz.log", Size: 10, Created: "2024-03-01T00:30:00.000+1300"}})
+	assert.Equal(t, "2024-02-29T11:30:00Z", s.Oldest)
+
+	var utc, nz bytes.Buffer
+	render(&utc, s, time.UTC)
+	render(&nz, s, time.FixedZone("NZDT", 13*60*60))
+	assert.Contains(t, utc.String(), "Oldest:\t\t2024-02-29\n")
+	assert.Contains(t, nz.String(), "Oldest:\t\t2024-03-01\n")
+}
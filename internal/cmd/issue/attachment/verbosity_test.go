@@ -0,0 +1,87 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/base64"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdtest"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestVerbosityLevels(t *testing.T) {
+	const (
+		login = "me@company.com"
+		token = "s3cr3t-token"
+	)
+
+	var (
+		apiVersion = "Using Jira REST api v3 (installation type)"
+		request    = regexp.MustCompile(`GET http://\S+/secure/attachment/\d+/notes.txt -> 429 Too Many Requests`)
+		selected   = "Selected 1 of 1 attachment(s)"
+		timing     = regexp.MustCompile(`-> 200 OK \(\d+(\.\d+)?m?s\)`)
+		retry      = "Retrying download after 429 Too Many Requests"
+		dump       = "REQUEST DETAILS"
+	)
+
+	cases := []struct {
+		name  string
+		flags []string
+		level int
+	}{
+		{name: "quiet", level: 0},
+		{name: "-v", flags: []string{"-v"}, level: jira.LogInfo},
+		{name: "-vv", flags: []string{"-vv"}, level: jira.LogDetail},
+		{name: "-vvv", flags: []string{"-vvv"}, level: jira.LogDump},
+		{name: "-vvvv is capped", flags: []string{"-vvvv"}, level: jira.LogDump},
+		{name: "--debug", flags: []string{"--debug"}, level: jira.LogDump},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithCredentials(login, token))
+			defer server.Close()
+			server.AddAttachment("TEST-1", "notes.txt", []byte("notes"))
+			// The download is the second request, it is rate limited once.
+			server.SetFaults(jiratest.Faults{RateLimitEvery: 2, RetryAfter: time.Millisecond})
+
+			var log bytes.Buffer
+			defer cmdutil.SetLogOutput(&log)()
+
+			env := cmdtest.Env{
+				Client: server.Client(jira.WithLogger(cmdutil.Log()), jira.WithRetries(1)),
+				Config: map[string]any{"installation": jira.InstallationTypeCloud, "auth.check_token_expiry": false},
+			}
+			args := append([]string{"download", "TEST-1", "notes.txt", "--output", t.TempDir()}, tc.flags...)
+			res := cmdtest.Run(t, env, NewCmdAttachment(), args...)
+			require.NoError(t, res.Err)
+
+			out := log.String()
+			if tc.level == 0 {
+				assert.Empty(t, out)
+			}
+			assert.Equal(t, tc.level >= jira.LogInfo, bytes.Contains(log.Bytes(), []byte(apiVersion)), "api version")
+			assert.Equal(t, tc.level >= jira.LogInfo, request.MatchString(out), "request line")
+			assert.Equal(t, tc.level >= jira.LogInfo, bytes.Contains(log.Bytes(), []byte(selected)), "selection")
+			assert.Equal(t, tc.level >= jira.LogDetail, timing.MatchString(out), "timing")
+			assert.Equal(t, tc.level >= jira.LogDetail, bytes.Contains(log.Bytes(), []byte(retry)), "retry")
+			assert.Equal(t, tc.level >= jira.LogDump, bytes.Contains(log.Bytes(), []byte(dump)), "dump")
+			if tc.level >= jira.LogDump {
+				assert.Contains(t, out, "Authorization: [REDACTED]")
+			}
+
+			basic := base64.StdEncoding.EncodeToString([]byte(login + ":" + token))
+			for _, s := range []string{out, res.Stdout, res.Stderr} {
+				assert.NotContains(t, s, token)
+				assert.NotContains(t, s, basic)
+			}
+		})
+	}
+}
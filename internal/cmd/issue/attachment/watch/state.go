@@ -0,0 +1,128 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/atomicfile"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// state is the set of attachments seen on an issue in the previous poll.
+type state struct {
+	Issue       string            `json:"issue"`
+	Checked     time.Time         `json:"checked"`
+	Attachments []jira.Attachment `json:"attachments"`
+}
+
+// changes are the attachments added and removed since the previous poll.
+type changes struct {
+	Added   []jira.Attachment
+	Removed []jira.Attachment
+}
+
+// Empty reports if there are no changes.
+func (c changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// diff compares attachments by ID. Added attachments are returned in the order
+// of current, removed ones in the order they were seen previously.
+func diff(prev, current []jira.Attachment) changes {
+	seen := make(map[string]struct{}, len(prev))
+	for _, a := range prev {
+		seen[a.ID] = struct{}{}
+	}
+	now := make(map[string]struct{}, len(current))
+	for _, a := range current {
+		now[a.ID] = struct{}{}
+	}
+
+	var c changes
+	for _, a := range current {
+		if _, ok := seen[a.ID]; !ok {
+			c.Added = append(c.Added, a)
+		}
+	}
+	for _, a := range prev {
+		if _, ok := now[a.ID]; !ok {
+			c.Removed = append(c.Removed, a)
+		}
+	}
+	return c
+}
+
+// defaultStateDir returns the directory state files are kept in.
+func defaultStateDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jira-cli", "attachment-watch"), nil
+}
+
+// statePath returns the state file of the issue in dir.
+func statePath(dir, key string) string {
+	return filepath.Join(dir, strings.ToUpper(key)+".json")
+}
+
+// loadState reads the state file, or its backup if the file is torn. A nil state is
+// returned if neither exist.
+func loadState(path string) (*state, error) {
+	var s state
+	_, err := atomicfile.ReadFile(path, func(b []byte) error {
+		s = state{}
+		return json.Unmarshal(b, &s)
+	}, func(error) {})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// saveState replaces the state file atomically, see package atomicfile,
+// so that an interrupted write doesn't leave a corrupt state behind.
+func saveState(path string, s *state) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	attachments := append([]jira.Attachment(nil), s.Attachments...)
+	sort.SliceStable(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+	b, err := json.MarshalIndent(state{Issue: s.Issue, Checked: s.Checked, Attachments: attachments}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, b, 0o600)
+}
+
+const maxBackoff = 15 * time.Minute
+
+// backoff returns the time to wait after the given number of consecutive
+// failures. The interval doubles with every failure up to maxBackoff, but
+// never drops below the regular interval.
+func backoff(interval time.Duration, failures int) time.Duration {
+	if failures <= 0 {
+		return interval
+	}
+	if interval >= maxBackoff {
+		return interval
+	}
+
+	wait := interval
+	for range failures {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
@@ -0,0 +1,241 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/hooks"
+	"github.com/ankitpokhrel/jira-cli/internal/query"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	helpText = `Watch polls an issue and reports attachments added or removed since the last check.
+
+The attachments seen are kept in a state file under the user cache directory, so that
+changes made while the command wasn't running are reported on the next start. The first
+run for an issue only records the current attachments.`
+	examples = `$ jira issue attachment watch ISSUE-1
+
+# Poll every 30 seconds and run a script for every change
+$ jira issue attachment watch ISSUE-1 --interval 30s --exec ./notify.sh
+
+# Check once, exits with status 1 if anything changed since the previous check
+$ jira issue attachment watch ISSUE-1 --once`
+
+	eventAdded   = "added"
+	eventRemoved = "removed"
+
+	// envEvent is the variable with the type of change passed to the exec hook.
+	envEvent = "JIRA_WATCH_EVENT"
+)
+
+// NewCmdAttachmentWatch is an attachment watch command.
+func NewCmdAttachmentWatch() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "watch ISSUE-KEY",
+		Short:   "Report attachments added to or removed from an issue",
+		Long:    helpText,
+		Example: examples,
+		Annotations: map[string]string{
+			"help:args": "ISSUE-KEY\tIssue key, eg: ISSUE-1",
+		},
+		Run: watch,
+	}
+
+	cmd.Flags().String("interval", "60s", "Time between polls, eg: 30s, 5m")
+	cmd.Flags().String("exec", "", "Command to run for every added or removed attachment")
+	cmd.Flags().Bool("once", false, "Check once and exit with status 1 if there are changes")
+
+	return &cmd
+}
+
+func watch(cmd *cobra.Command, args []string) {
+	params := parseArgsAndFlags(args, cmd.Flags())
+	version, err := cmdcommon.GetAPIVersion(cmd)
+	cmdutil.ExitIfError(err)
+	client := api.DefaultClient(params.debug)
+
+	if params.issueKey == "" {
+		cmdutil.Failed("ISSUE-KEY is required")
+	}
+
+	dir, err := defaultStateDir()
+	cmdutil.ExitIfError(err)
+
+	w := &watcher{
+		key:   params.issueKey,
+		state: statePath(dir, params.issueKey),
+		fetch: func(key string) ([]jira.Attachment, error) {
+			issue, err := cmdcommon.GetAttachmentIssue(client, version, key, cmdcommon.AttachmentIssueFields)
+			if err != nil {
+				return nil, err
+			}
+			return issue.Fields.Attachments, nil
+		},
+		hook: hooks.Hook{
+			Name:       "exec",
+			Command:    params.exec,
+			BestEffort: true,
+			Stdout:     os.Stderr,
+			Stderr:     os.Stderr,
+		},
+		out: os.Stdout,
+		now: time.Now,
+	}
+
+	if params.once {
+		c, err := w.poll(context.Background())
+		cmdutil.ExitIfRequestError(err, params.debug)
+		if !c.Empty() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w.run(ctx, params.interval, func(err error, failures int, wait time.Duration) {
+		cmdutil.Warn("Failed to check attachments (%d in a row), retrying in %s: %s", failures, wait, cmdutil.Diagnose(err))
+	})
+}
+
+// watcher reports changes to the attachments of an issue.
+type watcher struct {
+	key   string
+	state string
+	fetch func(key string) ([]jira.Attachment, error)
+	hook  hooks.Hook
+	out   io.Writer
+	now   func() time.Time
+}
+
+// poll fetches the attachments, reports the changes since the
+// previous poll and saves the attachments seen to the state file.
+func (w *watcher) poll(ctx context.Context) (changes, error) {
+	prev, err := loadState(w.state)
+	if err != nil {
+		return changes{}, fmt.Errorf("unable to read state file %s: %w", w.state, err)
+	}
+
+	current, err := w.fetch(w.key)
+	if err != nil {
+		return changes{}, err
+	}
+
+	var c changes
+	if prev != nil {
+		c = diff(prev.Attachments, current)
+	} else {
+		fmt.Fprintf(w.out, "Watching %d attachment(s) on %s\n", len(current), w.key)
+	}
+
+	for _, a := range c.Added {
+		w.report(ctx, eventAdded, a)
+	}
+	for _, a := range c.Removed {
+		w.report(ctx, eventRemoved, a)
+	}
+
+	if err := saveState(w.state, &state{Issue: w.key, Checked: w.now(), Attachments: current}); err != nil {
+		return c, fmt.Errorf("unable to save state file %s: %w", w.state, err)
+	}
+	return c, nil
+}
+
+func (w *watcher) report(ctx context.Context, event string, a jira.Attachment) {
+	line := fmt.Sprintf("%s %s %s %q (ID: %s)", w.now().Format(time.RFC3339), w.key, event, a.Filename, a.ID)
+	if a.Author.DisplayName != "" {
+		line += " by " + cmdutil.SanitizeTerminalText(a.Author.DisplayName)
+	}
+	fmt.Fprintln(w.out, line)
+
+	_ = w.hook.Run(ctx, hooks.Env{
+		envEvent:                event,
+		hooks.EnvIssueKey:       w.key,
+		hooks.EnvAttachmentID:   a.ID,
+		hooks.EnvAttachmentName: a.Filename,
+		hooks.EnvAttachmentURL:  a.Content,
+		hooks.EnvAttachmentSize: strconv.FormatInt(a.Size, 10),
+		hooks.EnvAttachmentType: a.MimeType,
+		hooks.EnvAuthor:         a.Author.DisplayName,
+	})
+}
+
+// run polls until the context is canceled. Consecutive failures back off
+// the poll interval and are reported to the onError func.
+func (w *watcher) run(ctx context.Context, interval time.Duration, onError func(err error, failures int, wait time.Duration)) {
+	var failures int
+	for {
+		wait := interval
+		if _, err := w.poll(ctx); err != nil {
+			failures++
+			wait = backoff(interval, failures)
+			onError(err, failures, wait)
+		} else {
+			failures = 0
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+type watchParams struct {
+	issueKey string
+	interval time.Duration
+	exec     string
+	once     bool
+	debug    bool
+}
+
+func parseArgsAndFlags(args []string, flags query.FlagParser) *watchParams {
+	var issueKey string
+
+	if len(args) >= 1 {
+		issueKey = cmdutil.GetJiraIssueKey(viper.GetString("project.key"), args[0])
+	}
+
+	debug, err := flags.GetBool("debug")
+	cmdutil.ExitIfError(err)
+
+	intervalFlag, err := flags.GetString("interval")
+	cmdutil.ExitIfError(err)
+
+	interval, err := time.ParseDuration(intervalFlag)
+	if err != nil || interval <= 0 {
+		cmdutil.Failed("Invalid --interval duration %q", intervalFlag)
+	}
+
+	exec, err := flags.GetString("exec")
+	cmdutil.ExitIfError(err)
+
+	once, err := flags.GetBool("once")
+	cmdutil.ExitIfError(err)
+
+	return &watchParams{
+		issueKey: issueKey,
+		interval: interval,
+		exec:     exec,
+		once:     once,
+		debug:    debug,
+	}
+}
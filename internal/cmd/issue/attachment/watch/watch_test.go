@@ -0,0 +1,216 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/internal/hooks"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func ids(attachments []jira.Attachment) []string {
+	out := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		out = append(out, a.ID)
+	}
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	prev := []jira.Attachment{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	current := []jira.Attachment{{ID: "4"}, {ID: "2"}, {ID: "5"}}
+
+	c := diff(prev, current)
+	assert.Equal(t, []string{"4", "5"}, ids(c.Added))
+	assert.Equal(t, []string{"1", "3"}, ids(c.Removed))
+	assert.False(t, c.Empty())
+
+	assert.True(t, diff(prev, prev).Empty())
+	assert.True(t, diff(nil, nil).Empty())
+	assert.Equal(t, []string{"1", "2", "3"}, ids(diff(nil, prev).Added))
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := statePath(filepath.Join(t.TempDir(), "watch"), "test-1")
+	assert.Equal(t, "TEST-1.json", filepath.Base(path))
+
+	s, err := loadState(path)
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	checked := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, saveState(path, &state{
+		Issue:       "TEST-1",
+		Checked:     checked,
+		Attachments: []jira.Attachment{{ID: "2", Filename: "b.txt"}, {ID: "1", Filename: "a.txt"}},
+	}))
+
+	s, err = loadState(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "TEST-1", s.Issue)
+	assert.True(t, checked.Equal(s.Checked))
+	assert.Equal(t, []string{"1", "2"}, ids(s.Attachments))
+	assert.Equal(t, "a.txt", s.Attachments[0].Filename)
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = loadState(path)
+	assert.Error(t, err)
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		interval time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{interval: time.Minute, failures: 0, want: time.Minute},
+		{interval: time.Minute, failures: 1, want: 2 * time.Minute},
+		{interval: time.Minute, failures: 2, want: 4 * time.Minute},
+		{interval: time.Minute, failures: 3, want: 8 * time.Minute},
+		{interval: time.Minute, failures: 4, want: maxBackoff},
+		{interval: time.Minute, failures: 100, want: maxBackoff},
+		{interval: 10 * time.Second, failures: 2, want: 40 * time.Second},
+		{interval: time.Hour, failures: 3, want: time.Hour},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, backoff(tc.interval, tc.failures), "%s after %d failures", tc.interval, tc.failures)
+	}
+}
+
+func newTestWatcher(t *testing.T, fetch func(string) ([]jira.Attachment, error)) (*watcher, *bytes.Buffer) {
+	t.Helper()
+
+	var out bytes.Buffer
+	return &watcher{
+		key:   "TEST-1",
+		state: statePath(t.TempDir(), "TEST-1"),
+		fetch: fetch,
+		out:   &out,
+		now:   func() time.Time { return time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC) },
+	}, &out
+}
+
+func TestWatcherPoll(t *testing.T) {
+	t.Parallel()
+
+	current := []jira.Attachment{{ID: "1", Filename: "a.txt"}, {ID: "2", Filename: "b.txt"}}
+	w, out := newTestWatcher(t, func(string) ([]jira.Attachment, error) { return current, nil })
+
+	// The first poll records a baseline.
+	c, err := w.poll(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, c.Empty())
+	assert.Equal(t, "Watching 2 attachment(s) on TEST-1\n", out.String())
+
+	out.Reset()
+	c, err = w.poll(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, c.Empty())
+	assert.Empty(t, out.String())
+
+	// The state survives a new watcher, eg: after a restart.
+	w2, out2 := newTestWatcher(t, func(string) ([]jira.Attachment, error) {
+		return []jira.Attachment{{ID: "2", Filename: "b.txt"}, {ID: "3", Filename: "c.txt", Author: jira.User{DisplayName: "Jane"}}}, nil
+	})
+	w2.state = w.state
+
+	c, err = w2.poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3"}, ids(c.Added))
+	assert.Equal(t, []string{"1"}, ids(c.Removed))
+	assert.Equal(t, "2024-03-01T10:00:00Z TEST-1 added \"c.txt\" (ID: 3) by Jane\n"+
+		"2024-03-01T10:00:00Z TEST-1 removed \"a.txt\" (ID: 1)\n", out2.String())
+}
+
+func TestWatcherPollErrorKeepsState(t *testing.T) {
+	t.Parallel()
+
+	fail := false
+	w, _ := newTestWatcher(t, func(string) ([]jira.Attachment, error) {
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return []jira.Attachment{{ID: "1"}}, nil
+	})
+
+	_, err := w.poll(context.Background())
+	assert.NoError(t, err)
+
+	fail = true
+	_, err = w.poll(context.Background())
+	assert.Error(t, err)
+
+	s, err := loadState(w.state)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ids(s.Attachments))
+}
+
+func TestWatcherExecHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are tested with sh")
+	}
+	t.Parallel()
+
+	var hookOut bytes.Buffer
+	polls := 0
+	w, _ := newTestWatcher(t, func(string) ([]jira.Attachment, error) {
+		polls++
+		if polls == 1 {
+			return nil, nil
+		}
+		return []jira.Attachment{{ID: "7", Filename: "log.txt", Size: 42, MimeType: "text/plain", Content: "https://jira.example.com/attachment/7"}}, nil
+	})
+	w.hook = hooks.Hook{
+		Name:    "exec",
+		Command: `echo "$JIRA_WATCH_EVENT $JIRA_ISSUE_KEY $JIRA_ATTACHMENT_ID $JIRA_ATTACHMENT_NAME $JIRA_ATTACHMENT_SIZE $JIRA_ATTACHMENT_MIMETYPE $JIRA_ATTACHMENT_URL"`,
+		Stdout:  &hookOut,
+		Stderr:  &hookOut,
+	}
+
+	for range 2 {
+		_, err := w.poll(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "[exec] added TEST-1 7 log.txt 42 text/plain https://jira.example.com/attachment/7", strings.TrimSpace(hookOut.String()))
+}
+
+func TestWatcherRunBacksOff(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var waits []time.Duration
+	w, _ := newTestWatcher(t, func(string) ([]jira.Attachment, error) {
+		return nil, errors.New("server down")
+	})
+
+	w.run(ctx, time.Millisecond, func(_ error, failures int, wait time.Duration) {
+		waits = append(waits, wait)
+		if failures == 3 {
+			cancel()
+		}
+	})
+	assert.Equal(t, []time.Duration{2 * time.Millisecond, 4 * time.Millisecond, 8 * time.Millisecond}, waits)
+}
@@ -3,12 +3,15 @@ package list
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdcommon"
 	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
 	"github.com/ankitpokhrel/jira-cli/internal/query"
 	"github.com/ankitpokhrel/jira-cli/internal/view"
@@ -191,14 +194,67 @@ func loadList(cmd *cobra.Command, args []string) {
 				}
 				return []string{}
 			}(),
-			TableStyle: cmdutil.GetTUIStyleConfig(),
-			Timezone:   viper.GetString("timezone"),
+			TableStyle:      cmdutil.GetTUIStyleConfig(),
+			Timezone:        viper.GetString("timezone"),
+			AttachmentBadge: viper.GetBool("tui.attachments.badge"),
+			ASCIIGlyphs:     viper.GetBool("tui.attachments.ascii"),
+		},
+		Downloader: attachmentDownloader{
+			client: api.DefaultClient(debug),
+			dir:    attachmentDownloadDir(),
 		},
 	}
 
 	cmdutil.ExitIfError(v.Render())
 }
 
+// attachmentDownloader downloads all attachments of an issue from the interactive list.
+type attachmentDownloader struct {
+	client *jira.Client
+	dir    string
+}
+
+// DownloadAll downloads all attachments of the issue to a subdirectory named after the issue key,
+// created only accessible by the owner like the directories of `jira issue attachment download`.
+func (d attachmentDownloader) DownloadAll(key string) (string, int, error) {
+	iss, err := cmdcommon.GetAttachmentIssue(d.client, api.InstallationAPIVersion(), key, cmdcommon.AttachmentIssueFields)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(iss.Fields.Attachments) == 0 {
+		return "", 0, nil
+	}
+
+	dir := filepath.Join(d.dir, key)
+	if err := os.MkdirAll(dir, jira.DefaultAttachmentDirMode); err != nil {
+		return "", 0, err
+	}
+
+	var n int
+	for _, a := range iss.Fields.Attachments {
+		// Attachments of archived issues or restricted ones can't be downloaded.
+		if !a.Available() {
+			continue
+		}
+		destPath := filepath.Join(dir, cmdutil.SanitizeFilename(a.Filename, "attachment-"+a.ID))
+		if _, err := os.Stat(destPath); err == nil {
+			return dir, n, fmt.Errorf("file %q already exists", destPath)
+		}
+		if _, err := d.client.DownloadAttachmentWithResult(a.Content, destPath, jira.ExpectContent(a.MimeType, a.Size)); err != nil {
+			return dir, n, err
+		}
+		n++
+	}
+	return dir, n, nil
+}
+
+func attachmentDownloadDir() string {
+	if dir := viper.GetString("attachment.download_dir"); dir != "" {
+		return dir
+	}
+	return "."
+}
+
 func outputRawJSON(issues []*jira.Issue) {
 	data, err := json.MarshalIndent(issues, "", "  ")
 	if err != nil {
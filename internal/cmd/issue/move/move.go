@@ -18,7 +18,10 @@ import (
 const (
 	helpText = `Move transitions an issue from one state to another.`
 	examples = `$ jira issue move ISSUE-1 "In Progress"
-$ jira issue move ISSUE-1 Done`
+$ jira issue move ISSUE-1 Done
+
+# Attach a checklist before the transition so that validators requiring an attachment pass
+$ jira issue move ISSUE-1 "Ready for Release" --attach checklist.pdf --comment "Checklist done"`
 
 	optionCancel = "Cancel"
 )
@@ -43,6 +46,7 @@ STATE		State you want to transition the issue to`,
 	cmd.Flags().String("comment", "", "Add comment to the issue")
 	cmd.Flags().StringP("assignee", "a", "", "Assign issue to a user")
 	cmd.Flags().StringP("resolution", "R", "", "Set resolution")
+	cmd.Flags().StringArray("attach", []string{}, "Upload a file to the issue before the transition, can be repeated")
 	cmd.Flags().Bool("web", false, "Open issue in web browser after successful transition")
 
 	return &cmd
@@ -75,45 +79,7 @@ func move(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	err = func() error {
-		s := cmdutil.Info(fmt.Sprintf("Transitioning issue to %q...", tr.Name))
-		defer s.Stop()
-
-		trFieldsReq := jira.TransitionRequestFields{}
-		trUpdateReq := jira.TransitionRequestUpdate{}
-
-		if mc.params.assignee != "" {
-			trFieldsReq.Assignee = &struct {
-				Name string `json:"name"`
-			}{Name: mc.params.assignee}
-		}
-		if mc.params.resolution != "" {
-			trFieldsReq.Resolution = &struct {
-				Name string `json:"name"`
-			}{Name: mc.params.resolution}
-		}
-		if mc.params.comment != "" {
-			trUpdateReq.Comment = []struct {
-				Add struct {
-					Body string `json:"body"`
-				} `json:"add"`
-			}{
-				{Add: struct {
-					Body string `json:"body"`
-				}{Body: mc.params.comment}},
-			}
-		}
-
-		_, err := client.Transition(mc.params.key, &jira.TransitionRequest{
-			Fields: &trFieldsReq,
-			Update: &trUpdateReq,
-			Transition: &jira.TransitionRequestData{
-				ID:   tr.ID.String(),
-				Name: tr.Name,
-			},
-		})
-		return err
-	}()
+	err = mc.attachAndTransition(tr)
 	cmdutil.ExitIfError(err)
 
 	server := viper.GetString("server")
@@ -133,6 +99,7 @@ type moveParams struct {
 	comment    string
 	assignee   string
 	resolution string
+	attach     []string
 	debug      bool
 }
 
@@ -156,6 +123,9 @@ func parseArgsAndFlags(flags query.FlagParser, args []string, project string) *m
 	resolution, err := flags.GetString("resolution")
 	cmdutil.ExitIfError(err)
 
+	attach, err := flags.GetStringArray("attach")
+	cmdutil.ExitIfError(err)
+
 	debug, err := flags.GetBool("debug")
 	cmdutil.ExitIfError(err)
 
@@ -165,6 +135,7 @@ func parseArgsAndFlags(flags query.FlagParser, args []string, project string) *m
 		comment:    comment,
 		assignee:   assignee,
 		resolution: resolution,
+		attach:     attach,
 		debug:      debug,
 	}
 }
@@ -175,6 +146,109 @@ type moveCmd struct {
 	params      *moveParams
 }
 
+// attach uploads the files given with --attach one by one. The transition is performed
+// after the uploads, so that workflow validators requiring an attachment pass, and is
+// aborted on the first failed upload. Files uploaded before the failure remain on the issue.
+func (mc *moveCmd) attach() ([]jira.Attachment, error) {
+	for _, file := range mc.params.attach {
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("unable to attach %q: %w", file, err)
+		}
+	}
+
+	var uploaded []jira.Attachment
+	for _, file := range mc.params.attach {
+		attachments, err := func() ([]jira.Attachment, error) {
+			s := cmdutil.Info(fmt.Sprintf("Uploading %s", file))
+			defer s.Stop()
+
+			return api.ProxyUploadAttachment(mc.client, mc.params.key, file)
+		}()
+		if err != nil {
+			msg := fmt.Sprintf("unable to upload %q, the issue was not transitioned", file)
+			if len(uploaded) > 0 {
+				msg += fmt.Sprintf(" and the %d file(s) uploaded before remain on the issue", len(uploaded))
+			}
+			return uploaded, fmt.Errorf("%s: %w", msg, err)
+		}
+		uploaded = append(uploaded, attachments...)
+	}
+	return uploaded, nil
+}
+
+// attachAndTransition uploads the files given with --attach and then performs the transition.
+func (mc *moveCmd) attachAndTransition(tr *jira.Transition) error {
+	uploaded, err := mc.attach()
+	if err != nil {
+		return err
+	}
+
+	err = func() error {
+		s := cmdutil.Info(fmt.Sprintf("Transitioning issue to %q...", tr.Name))
+		defer s.Stop()
+
+		return mc.transition(tr, uploaded)
+	}()
+	if err != nil && len(uploaded) > 0 {
+		return fmt.Errorf(
+			"uploaded %d file(s) to issue %s, but the transition to %q failed and the attachments remain on the issue: %w",
+			len(uploaded), mc.params.key, tr.Name, err,
+		)
+	}
+	return err
+}
+
+// transition performs the transition. The names of the uploaded attachments are
+// mentioned in the comment, if any, as stored by the server.
+func (mc *moveCmd) transition(tr *jira.Transition, uploaded []jira.Attachment) error {
+	trFieldsReq := jira.TransitionRequestFields{}
+	trUpdateReq := jira.TransitionRequestUpdate{}
+
+	if mc.params.assignee != "" {
+		trFieldsReq.Assignee = &struct {
+			Name string `json:"name"`
+		}{Name: mc.params.assignee}
+	}
+	if mc.params.resolution != "" {
+		trFieldsReq.Resolution = &struct {
+			Name string `json:"name"`
+		}{Name: mc.params.resolution}
+	}
+	if mc.params.comment != "" {
+		trUpdateReq.Comment = []struct {
+			Add struct {
+				Body string `json:"body"`
+			} `json:"add"`
+		}{
+			{Add: struct {
+				Body string `json:"body"`
+			}{Body: commentWithAttachments(mc.params.comment, uploaded)}},
+		}
+	}
+
+	_, err := mc.client.Transition(mc.params.key, &jira.TransitionRequest{
+		Fields: &trFieldsReq,
+		Update: &trUpdateReq,
+		Transition: &jira.TransitionRequestData{
+			ID:   tr.ID.String(),
+			Name: tr.Name,
+		},
+	})
+	return err
+}
+
+// commentWithAttachments appends the names of the attachments to the comment.
+func commentWithAttachments(comment string, attachments []jira.Attachment) string {
+	if len(attachments) == 0 {
+		return comment
+	}
+	names := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		names = append(names, a.Filename)
+	}
+	return fmt.Sprintf("%s\n\nAttached: %s", comment, strings.Join(names, ", "))
+}
+
 func (mc *moveCmd) setIssueKey(project string) error {
 	if mc.params.key != "" {
 		return nil
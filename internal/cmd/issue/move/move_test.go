@@ -0,0 +1,163 @@
+package move
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func writeFiles(t *testing.T, files map[string]string) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make([]string, 0, len(files))
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// calls returns the method and the last path segment of the requests received by the server.
+func calls(server *jiratest.Server) []string {
+	out := make([]string, 0)
+	for _, r := range server.Requests() {
+		out = append(out, r.Method+" "+r.Path[strings.LastIndex(r.Path, "/")+1:])
+	}
+	return out
+}
+
+func transitionComment(t *testing.T, body string) string {
+	t.Helper()
+
+	var req jira.TransitionRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+	require.NotNil(t, req.Update)
+	require.Len(t, req.Update.Comment, 1)
+	return req.Update.Comment[0].Add.Body
+}
+
+func newMoveCmd(server *jiratest.Server, params *moveParams) *moveCmd {
+	params.key = "TEST-1"
+	return &moveCmd{client: server.Client(), params: params}
+}
+
+var readyForRelease = &jira.Transition{ID: "1", Name: "Ready for Release", IsAvailable: true}
+
+func TestAttachAndTransition(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithTransitions("Ready for Release"))
+	defer server.Close()
+
+	files := writeFiles(t, map[string]string{"checklist.pdf": "done"})
+	mc := newMoveCmd(server, &moveParams{comment: "Checklist done", attach: files})
+
+	require.NoError(t, mc.attachAndTransition(readyForRelease))
+
+	// The file is uploaded before the transition so that validators requiring an attachment pass.
+	assert.Equal(t, []string{"POST attachments", "POST transitions"}, calls(server))
+	assert.Len(t, server.Attachments("TEST-1"), 1)
+
+	transitions := server.Transitions("TEST-1")
+	require.Len(t, transitions, 1)
+	assert.Equal(t, "Checklist done\n\nAttached: checklist.pdf", transitionComment(t, transitions[0]))
+}
+
+func TestAttachAndTransitionUsesServerNames(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithRenameOnCollision())
+	defer server.Close()
+
+	server.AddAttachment("TEST-1", "checklist.pdf", []byte("old"))
+	files := writeFiles(t, map[string]string{"checklist.pdf": "new"})
+	mc := newMoveCmd(server, &moveParams{comment: "Done", attach: files})
+
+	require.NoError(t, mc.attachAndTransition(readyForRelease))
+	assert.Equal(t, "Done\n\nAttached: checklist (1).pdf", transitionComment(t, server.Transitions("TEST-1")[0]))
+}
+
+func TestAttachAndTransitionAbortsOnUploadFailure(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithUploadLimit(5))
+	defer server.Close()
+
+	dir := t.TempDir()
+	small, large := filepath.Join(dir, "small.txt"), filepath.Join(dir, "large.txt")
+	require.NoError(t, os.WriteFile(small, []byte("ok"), 0o600))
+	require.NoError(t, os.WriteFile(large, []byte("too large"), 0o600))
+
+	mc := newMoveCmd(server, &moveParams{attach: []string{small, large}})
+
+	err := mc.attachAndTransition(readyForRelease)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unable to upload "`+large+`", the issue was not transitioned`)
+	assert.Contains(t, err.Error(), "the 1 file(s) uploaded before remain on the issue")
+
+	assert.Equal(t, []string{"POST attachments", "POST attachments"}, calls(server))
+	assert.Empty(t, server.Transitions("TEST-1"))
+}
+
+func TestAttachAndTransitionMissingFile(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	files := writeFiles(t, map[string]string{"a.txt": "a"})
+	mc := newMoveCmd(server, &moveParams{attach: append(files, filepath.Join(t.TempDir(), "missing.txt"))})
+
+	err := mc.attachAndTransition(readyForRelease)
+	assert.ErrorContains(t, err, "missing.txt")
+
+	// Nothing is uploaded if a file doesn't exist.
+	assert.Empty(t, server.Requests())
+}
+
+func TestAttachAndTransitionFailedTransition(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	server.SetFaults(jiratest.Faults{FailTransitions: true})
+	files := writeFiles(t, map[string]string{"checklist.pdf": "done"})
+	mc := newMoveCmd(server, &moveParams{attach: files})
+
+	err := mc.attachAndTransition(readyForRelease)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `uploaded 1 file(s) to issue TEST-1, but the transition to "Ready for Release" failed`)
+	assert.Contains(t, err.Error(), "the attachments remain on the issue")
+
+	var unexpected *jira.ErrUnexpectedResponse
+	require.ErrorAs(t, err, &unexpected)
+	assert.Equal(t, http.StatusBadRequest, unexpected.StatusCode)
+
+	assert.Equal(t, []string{"POST attachments", "POST transitions"}, calls(server))
+	assert.Len(t, server.Attachments("TEST-1"), 1)
+}
+
+func TestTransitionWithoutAttachments(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	mc := newMoveCmd(server, &moveParams{comment: "Moving on"})
+
+	require.NoError(t, mc.attachAndTransition(readyForRelease))
+	assert.Equal(t, []string{"POST transitions"}, calls(server))
+	assert.Equal(t, "Moving on", transitionComment(t, server.Transitions("TEST-1")[0]))
+}
@@ -2,6 +2,7 @@ package view
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -20,6 +21,9 @@ const (
 # Show 5 recent comments when viewing the issue
 $ jira issue view ISSUE-1 --comments 5
 
+# List the attachments by filename
+$ jira issue view ISSUE-1 --attachment-order name
+
 # Get the raw JSON data
 $ jira issue view ISSUE-1 --raw`
 
@@ -28,8 +32,11 @@ $ jira issue view ISSUE-1 --raw`
 	flagComments = "comments"
 	flagPlain    = "plain"
 
-	configProject = "project.key"
-	configServer  = "server"
+	flagAttachmentOrder = "attachment-order"
+
+	configProject         = "project.key"
+	configServer          = "server"
+	configAttachmentOrder = "view.attachment_order"
 
 	messageFetchingData = "Fetching issue details..."
 )
@@ -52,6 +59,10 @@ func NewCmdView() *cobra.Command {
 	cmd.Flags().Uint(flagComments, 1, "Show N comments")
 	cmd.Flags().Bool(flagPlain, false, "Display output in plain mode")
 	cmd.Flags().Bool(flagRaw, false, "Print raw Jira API response")
+	cmd.Flags().String(flagAttachmentOrder, "", fmt.Sprintf(
+		"Order of the attachments: %s (default %q)",
+		strings.Join(tuiView.AttachmentOrders, ", "), tuiView.AttachmentOrderCreatedDesc,
+	))
 
 	return &cmd
 }
@@ -89,6 +100,9 @@ func viewPretty(cmd *cobra.Command, args []string) {
 	debug, err := cmd.Flags().GetBool(flagDebug)
 	cmdutil.ExitIfError(err)
 
+	order, err := attachmentOrder(cmd)
+	cmdutil.ExitIfError(err)
+
 	var comments uint
 	if cmd.Flags().Changed(flagComments) {
 		comments, err = cmd.Flags().GetUint(flagComments)
@@ -114,8 +128,28 @@ func viewPretty(cmd *cobra.Command, args []string) {
 	v := tuiView.Issue{
 		Server:  viper.GetString(configServer),
 		Data:    iss,
-		Display: tuiView.DisplayFormat{Plain: plain},
+		Display: tuiView.DisplayFormat{Plain: plain, AttachmentOrder: order},
 		Options: tuiView.IssueOption{NumComments: comments},
 	}
 	cmdutil.ExitIfError(v.Render())
 }
+
+// attachmentOrder returns the --attachment-order flag, or the view.attachment_order config.
+func attachmentOrder(cmd *cobra.Command) (string, error) {
+	order, err := cmd.Flags().GetString(flagAttachmentOrder)
+	if err != nil {
+		return "", err
+	}
+	if order == "" {
+		order = viper.GetString(configAttachmentOrder)
+	}
+	if order == "" {
+		return tuiView.AttachmentOrderCreatedDesc, nil
+	}
+	if !tuiView.IsValidAttachmentOrder(order) {
+		return "", cmdutil.Errorf(
+			"Invalid attachment order %q, use one of: %s", order, strings.Join(tuiView.AttachmentOrders, ", "),
+		)
+	}
+	return order, nil
+}
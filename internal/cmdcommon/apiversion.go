@@ -0,0 +1,54 @@
+package cmdcommon
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+const apiVersionFlag = "api-version"
+
+// SetAPIVersionFlag registers the persistent --api-version flag of a command group.
+func SetAPIVersionFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(
+		apiVersionFlag, "",
+		fmt.Sprintf("Jira REST api version for this invocation, one of: %s. Overrides the routing based on the installation type",
+			strings.Join(api.APIVersions, ", ")),
+	)
+}
+
+// GetAPIVersion returns the api version the requests of the command are routed to, the one
+// given with --api-version if set, else the one of the configured installation type. The
+// version is logged with -v.
+func GetAPIVersion(cmd *cobra.Command) (string, error) {
+	var override string
+	if f := cmd.Flag(apiVersionFlag); f != nil {
+		override = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(f.Value.String())), "v")
+	}
+
+	version, source := resolveAPIVersion(override, api.InstallationAPIVersion())
+	if version == "" {
+		return "", cmdutil.Errorf(
+			"Invalid --api-version %q, use one of: %s", override, strings.Join(api.APIVersions, ", "),
+		)
+	}
+	cmdutil.Log().Infof("Using Jira REST api v%s (%s)", version, source)
+	return version, nil
+}
+
+// resolveAPIVersion picks the override over the version of the installation. The
+// version is empty if the override is not a valid version.
+func resolveAPIVersion(override, installation string) (version, source string) {
+	if override == "" {
+		return installation, "installation type"
+	}
+	if !slices.Contains(api.APIVersions, override) {
+		return "", ""
+	}
+	return override, "--api-version"
+}
@@ -0,0 +1,53 @@
+package cmdcommon
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		override     string
+		installation string
+		want, source string
+	}{
+		{name: "no override", installation: "3", want: "3", source: "installation type"},
+		{name: "no override on local", installation: "2", want: "2", source: "installation type"},
+		{name: "override", override: "2", installation: "3", want: "2", source: "--api-version"},
+		{name: "invalid override", override: "1", installation: "3"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			version, source := resolveAPIVersion(tc.override, tc.installation)
+			assert.Equal(t, tc.want, version)
+			assert.Equal(t, tc.source, source)
+		})
+	}
+}
+
+func TestGetAPIVersionFromFlag(t *testing.T) {
+	t.Parallel()
+
+	parent := &cobra.Command{Use: "attachment"}
+	SetAPIVersionFlag(parent)
+	child := &cobra.Command{Use: "list"}
+	parent.AddCommand(child)
+
+	assert.NoError(t, parent.PersistentFlags().Set("api-version", "v2"))
+	version, err := GetAPIVersion(child)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", version)
+
+	assert.NoError(t, parent.PersistentFlags().Set("api-version", "5"))
+	_, err = GetAPIVersion(child)
+	assert.EqualError(t, err, `Invalid --api-version "5", use one of: 2, 3`)
+}
@@ -0,0 +1,63 @@
+package cmdcommon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// AttachmentIssueFields are the issue fields fetched by attachment commands.
+// Only attachments are rendered, so comments and description are not requested.
+var AttachmentIssueFields = []string{"attachment"}
+
+// IsSameUser checks if two users are the same using the account id on cloud and the
+// user name on server. It falls back to comparing display names if neither id is set,
+// in which case fallback is true.
+func IsSameUser(a jira.User, b *jira.User, installation string) (match bool, fallback bool) {
+	if b == nil {
+		return false, false
+	}
+	if installation == jira.InstallationTypeLocal {
+		if a.Name != "" && b.Name != "" {
+			return a.Name == b.Name, false
+		}
+	} else if a.AccountID != "" && b.AccountID != "" {
+		return a.AccountID == b.AccountID, false
+	}
+	if a.AccountID != "" && b.AccountID != "" {
+		return a.AccountID == b.AccountID, false
+	}
+	if a.Name != "" && b.Name != "" {
+		return a.Name == b.Name, false
+	}
+	return a.DisplayName != "" && a.DisplayName == b.DisplayName, true
+}
+
+// ParseAge parses an age like 30d or 2w. Units supported by time.ParseDuration are accepted as well.
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	if unit, ok := units[s[len(s)-1]]; ok {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * unit, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
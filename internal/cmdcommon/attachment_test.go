@@ -0,0 +1,123 @@
+package cmdcommon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestIsSameUser(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		author       jira.User
+		me           *jira.User
+		installation string
+		wantMatch    bool
+		wantFallback bool
+	}{
+		{
+			name:         "cloud account id match",
+			author:       jira.User{AccountID: "a-1", Name: "other", DisplayName: "Someone"},
+			me:           &jira.User{AccountID: "a-1", DisplayName: "Me"},
+			installation: jira.InstallationTypeCloud,
+			wantMatch:    true,
+		},
+		{
+			name:         "cloud account id mismatch with same display name",
+			author:       jira.User{AccountID: "a-2", DisplayName: "Me"},
+			me:           &jira.User{AccountID: "a-1", DisplayName: "Me"},
+			installation: jira.InstallationTypeCloud,
+			wantMatch:    false,
+		},
+		{
+			name:         "server name match",
+			author:       jira.User{Name: "jdoe", DisplayName: "John"},
+			me:           &jira.User{Name: "jdoe", DisplayName: "John Doe"},
+			installation: jira.InstallationTypeLocal,
+			wantMatch:    true,
+		},
+		{
+			name:         "server name mismatch",
+			author:       jira.User{Name: "jane", DisplayName: "John"},
+			me:           &jira.User{Name: "jdoe", DisplayName: "John"},
+			installation: jira.InstallationTypeLocal,
+			wantMatch:    false,
+		},
+		{
+			name:         "display name fallback",
+			author:       jira.User{DisplayName: "John"},
+			me:           &jira.User{DisplayName: "John"},
+			installation: jira.InstallationTypeCloud,
+			wantMatch:    true,
+			wantFallback: true,
+		},
+		{
+			name:   "no user",
+			author: jira.User{DisplayName: "John"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			match, fallback := IsSameUser(tc.author, tc.me, tc.installation)
+			assert.Equal(t, tc.wantMatch, match)
+			assert.Equal(t, tc.wantFallback, fallback)
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "xd", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseAge(tc.in)
+		if tc.wantErr {
+			assert.Error(t, err, tc.in)
+			continue
+		}
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+	}
+}
+
+func TestAttachmentIssueFields(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	a := server.AddAttachment("TEST-1", "a.txt", []byte("a"))
+
+	iss, err := server.Client().GetIssueFields("TEST-1", AttachmentIssueFields)
+	assert.NoError(t, err)
+
+	// Attachment commands must not re-expand the payload to comments or description.
+	reqs := server.Requests()
+	assert.Len(t, reqs, 1)
+	assert.Equal(t, "attachment", reqs[0].Query.Get("fields"))
+	assert.Equal(t, []jira.Attachment{a}, iss.Fields.Attachments)
+}
@@ -0,0 +1,16 @@
+package cmdcommon
+
+import (
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/audit"
+)
+
+// AuditLogger returns the logger of the audit.log file, nil if no audit log is configured.
+func AuditLogger() *audit.Logger {
+	path := viper.GetString("audit.log")
+	if path == "" {
+		return nil
+	}
+	return audit.New(path)
+}
@@ -0,0 +1,123 @@
+package cmdcommon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/tui"
+)
+
+const (
+	// ActionViewList is a view full list action of a truncated confirmation prompt.
+	ActionViewList = "View full list"
+
+	// ConfirmListLimit is the number of items listed in a confirmation prompt. Longer lists
+	// are truncated so that the question isn't scrolled off the screen.
+	ConfirmListLimit = 10
+)
+
+// Asker asks survey questions, it is survey.Ask outside of tests.
+type Asker func(qs []*survey.Question, response any, opts ...survey.AskOpt) error
+
+// ConfirmItem is an item listed in a confirmation prompt.
+type ConfirmItem struct {
+	Label string
+	// Size in bytes, negative if unknown.
+	Size int64
+}
+
+// ConfirmMessage builds the message of a confirmation prompt: the question followed by the
+// number of items and their total size, and at most limit items. It reports if the list was
+// truncated, in which case the last line tells how many items are not shown.
+func ConfirmMessage(question string, items []ConfirmItem, limit int) (string, bool) {
+	var total int64
+	known := true
+	for _, it := range items {
+		if it.Size < 0 {
+			known = false
+			continue
+		}
+		total += it.Size
+	}
+
+	var b strings.Builder
+	b.WriteString(question)
+	if len(items) > 1 {
+		if known {
+			fmt.Fprintf(&b, " (%d items, %s in total)", len(items), cmdutil.FormatSize(total))
+		} else {
+			fmt.Fprintf(&b, " (%d items)", len(items))
+		}
+	}
+	b.WriteString("\n")
+
+	truncated := limit > 0 && len(items) > limit
+	shown := items
+	if truncated {
+		shown = items[:limit]
+	}
+	writeConfirmItems(&b, shown)
+	if truncated {
+		fmt.Fprintf(&b, "  … and %d more (select %q to see them)\n", len(items)-limit, ActionViewList)
+	}
+	return b.String(), truncated
+}
+
+func writeConfirmItems(b *strings.Builder, items []ConfirmItem) {
+	for _, it := range items {
+		b.WriteString("  - ")
+		b.WriteString(cmdutil.SanitizeTerminalText(it.Label))
+		if it.Size >= 0 {
+			fmt.Fprintf(b, " (%s)", cmdutil.FormatSize(it.Size))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Confirm asks to submit or cancel an action on the items. If the list is truncated, the
+// prompt has an extra option to show the full list with view, after which it asks again.
+func Confirm(ask Asker, view func(string) error, question string, items []ConfirmItem) (bool, error) {
+	msg, truncated := ConfirmMessage(question, items, ConfirmListLimit)
+
+	options := []string{ActionSubmit, ActionCancel}
+	if truncated {
+		options = append(options, ActionViewList)
+	}
+
+	for {
+		answer := struct{ Action string }{}
+		err := ask([]*survey.Question{
+			{
+				Name:   "action",
+				Prompt: &survey.Select{Message: msg, Options: options},
+			},
+		}, &answer)
+		if err != nil {
+			return false, err
+		}
+
+		if answer.Action != ActionViewList {
+			return answer.Action == ActionSubmit, nil
+		}
+
+		var list strings.Builder
+		writeConfirmItems(&list, items)
+		if err := view(list.String()); err != nil {
+			return false, err
+		}
+	}
+}
+
+// ViewList pages the list through the configured pager, or prints it above the prompt
+// if there is no pager.
+func ViewList(list string) error {
+	if err := tui.PagerOut(list); err != nil {
+		_, err = fmt.Fprint(os.Stderr, list)
+		return err
+	}
+	return nil
+}
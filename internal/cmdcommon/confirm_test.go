@@ -0,0 +1,117 @@
+package cmdcommon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func confirmItems(n int) []ConfirmItem {
+	items := make([]ConfirmItem, 0, n)
+	for i := range n {
+		items = append(items, ConfirmItem{Label: fmt.Sprintf("file-%02d.txt", i+1), Size: 512 * 1024})
+	}
+	return items
+}
+
+func TestConfirmMessage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		items     []ConfirmItem
+		expected  string
+		truncated bool
+	}{
+		{
+			name:     "no items",
+			expected: "Upload?\n",
+		},
+		{
+			name:     "single item",
+			items:    []ConfirmItem{{Label: "a.txt", Size: 10}},
+			expected: "Upload?\n  - a.txt (10 B)\n",
+		},
+		{
+			name:  "within limit",
+			items: confirmItems(3),
+			expected: "Upload? (3 items, 1.50 MB in total)\n" +
+				"  - file-01.txt (512.00 KB)\n  - file-02.txt (512.00 KB)\n  - file-03.txt (512.00 KB)\n",
+		},
+		{
+			name:      "truncated",
+			items:     confirmItems(60),
+			truncated: true,
+			expected: "Upload? (60 items, 30.00 MB in total)\n" +
+				"  - file-01.txt (512.00 KB)\n  - file-02.txt (512.00 KB)\n  - file-03.txt (512.00 KB)\n" +
+				"  - file-04.txt (512.00 KB)\n  - file-05.txt (512.00 KB)\n  - file-06.txt (512.00 KB)\n" +
+				"  - file-07.txt (512.00 KB)\n  - file-08.txt (512.00 KB)\n  - file-09.txt (512.00 KB)\n" +
+				"  - file-10.txt (512.00 KB)\n" +
+				"  … and 50 more (select \"View full list\" to see them)\n",
+		},
+		{
+			name:     "unknown size",
+			items:    []ConfirmItem{{Label: "a.txt", Size: 10}, {Label: "b.txt", Size: -1}},
+			expected: "Upload? (2 items)\n  - a.txt (10 B)\n  - b.txt\n",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg, truncated := ConfirmMessage("Upload?", tc.items, ConfirmListLimit)
+			assert.Equal(t, tc.expected, msg)
+			assert.Equal(t, tc.truncated, truncated)
+		})
+	}
+}
+
+// scriptedAsker answers prompts with the given actions in order and records the options offered.
+type scriptedAsker struct {
+	answers []string
+	options [][]string
+}
+
+func (s *scriptedAsker) ask(qs []*survey.Question, response any, _ ...survey.AskOpt) error {
+	s.options = append(s.options, qs[0].Prompt.(*survey.Select).Options)
+	response.(*struct{ Action string }).Action = s.answers[0]
+	s.answers = s.answers[1:]
+	return nil
+}
+
+func TestConfirm(t *testing.T) {
+	t.Parallel()
+
+	var viewed []string
+	view := func(list string) error {
+		viewed = append(viewed, list)
+		return nil
+	}
+
+	asker := &scriptedAsker{answers: []string{ActionViewList, ActionSubmit}}
+	ok, err := Confirm(asker.ask, view, "Upload?", confirmItems(12))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// The full list is shown and the question asked again.
+	require.Len(t, viewed, 1)
+	assert.Equal(t, 12, strings.Count(viewed[0], "\n"))
+	assert.Contains(t, viewed[0], "file-12.txt")
+	assert.Equal(t, [][]string{
+		{ActionSubmit, ActionCancel, ActionViewList},
+		{ActionSubmit, ActionCancel, ActionViewList},
+	}, asker.options)
+
+	asker = &scriptedAsker{answers: []string{ActionCancel}}
+	ok, err = Confirm(asker.ask, view, "Upload?", confirmItems(2))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, [][]string{{ActionSubmit, ActionCancel}}, asker.options)
+}
@@ -0,0 +1,50 @@
+package cmdcommon
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/where"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// AttachmentIssueLimits returns the limits of the issue fetches of attachment commands:
+// attachment.issue_max_size, 20MB by default, and attachment.issue_timeout, 10s by default.
+func AttachmentIssueLimits() (jira.IssueLimits, error) {
+	limits := jira.IssueLimits{MaxBytes: jira.DefaultIssueMaxBytes, Timeout: jira.DefaultIssueTimeout}
+
+	if s := viper.GetString("attachment.issue_max_size"); s != "" {
+		n, err := where.ParseSize(s)
+		if err != nil || n <= 0 {
+			return limits, cmdutil.Errorf("Invalid attachment.issue_max_size %q, expected a size like 20MB", s)
+		}
+		limits.MaxBytes = n
+	}
+	if s := viper.GetString("attachment.issue_timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return limits, cmdutil.Errorf("Invalid attachment.issue_timeout duration %q", s)
+		}
+		limits.Timeout = d
+	}
+	return limits, nil
+}
+
+// GetAttachmentIssue fetches the fields of an issue for an attachment command using the
+// given api version, within AttachmentIssueLimits.
+func GetAttachmentIssue(client *jira.Client, version, key string, fields []string) (*jira.Issue, error) {
+	return GetAttachmentIssueContext(context.Background(), client, version, key, fields)
+}
+
+// GetAttachmentIssueContext is GetAttachmentIssue with a context.
+func GetAttachmentIssueContext(ctx context.Context, client *jira.Client, version, key string, fields []string) (*jira.Issue, error) {
+	limits, err := AttachmentIssueLimits()
+	if err != nil {
+		return nil, err
+	}
+	return api.ProxyGetIssueFieldsLimitedVersion(ctx, client, version, key, fields, limits)
+}
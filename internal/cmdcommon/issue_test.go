@@ -0,0 +1,60 @@
+package cmdcommon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func TestAttachmentIssueLimits(t *testing.T) {
+	cases := []struct {
+		name             string
+		maxSize, timeout string
+		want             jira.IssueLimits
+		wantErr          string
+	}{
+		{
+			name: "defaults",
+			want: jira.IssueLimits{MaxBytes: 20 << 20, Timeout: 10 * time.Second},
+		},
+		{
+			name:    "configured",
+			maxSize: "50MB",
+			timeout: "1m",
+			want:    jira.IssueLimits{MaxBytes: 50 << 20, Timeout: time.Minute},
+		},
+		{
+			name:    "invalid size",
+			maxSize: "large",
+			wantErr: `Invalid attachment.issue_max_size "large", expected a size like 20MB`,
+		},
+		{
+			name:    "invalid timeout",
+			timeout: "-5s",
+			wantErr: `Invalid attachment.issue_timeout duration "-5s"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			viper.Set("attachment.issue_max_size", tc.maxSize)
+			viper.Set("attachment.issue_timeout", tc.timeout)
+			t.Cleanup(func() {
+				viper.Set("attachment.issue_max_size", "")
+				viper.Set("attachment.issue_timeout", "")
+			})
+
+			limits, err := AttachmentIssueLimits()
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, limits)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package cmdcommon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const waitForWritableFlag = "wait-for-writable"
+
+// SetWaitForWritableFlag adds the --wait-for-writable flag of the commands that add or
+// remove attachments.
+func SetWaitForWritableFlag(cmd *cobra.Command) {
+	cmd.Flags().String(waitForWritableFlag, "",
+		"If Jira is in maintenance/read-only mode, wait up to the given duration for it to accept changes, eg: 30m")
+}
+
+// WaitForWritable waits up to the duration of --wait-for-writable for the instance to leave
+// maintenance or read-only mode before the command changes anything. It returns at once if
+// the flag isn't set or the instance already accepts changes. Ctrl+C stops the wait,
+// nothing is changed then.
+func WaitForWritable(cmd *cobra.Command, client *jira.Client) error {
+	flag, err := cmd.Flags().GetString(waitForWritableFlag)
+	if err != nil || flag == "" {
+		return err
+	}
+	timeout, err := time.ParseDuration(flag)
+	if err != nil || timeout <= 0 {
+		return cmdutil.Errorf("Invalid --wait-for-writable duration %q, eg: 30m", flag)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if ok, _, err := client.Writable(ctx); err == nil && ok {
+		return nil
+	}
+
+	cmdutil.Warn("Jira is in maintenance/read-only mode, waiting up to %s for it to accept changes", timeout)
+	err = client.WaitForWritable(ctx, timeout)
+	switch {
+	case err == nil:
+		cmdutil.Warn("Jira accepts changes again, resuming")
+		return nil
+	case errors.Is(err, context.Canceled):
+		return cmdutil.Errorf("Interrupted while waiting for Jira to leave maintenance, nothing was changed")
+	}
+	return cmdutil.Errorf("%s", cmdutil.Diagnose(err))
+}
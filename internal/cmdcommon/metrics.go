@@ -0,0 +1,49 @@
+package cmdcommon
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/metrics"
+	"github.com/ankitpokhrel/jira-cli/internal/notify"
+)
+
+const metricsFlag = "metrics-textfile"
+
+// Reasons of failures that aren't errors of a request.
+const (
+	// FailureNotAttempted is a file left out once the run stopped.
+	FailureNotAttempted = "not_attempted"
+	// FailureRejected is a file rejected by a hook.
+	FailureRejected = "rejected"
+)
+
+// SetMetricsFlag adds the persistent --metrics-textfile flag of the attachment command group.
+func SetMetricsFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(metricsFlag, "",
+		"Write the metrics of the run to the given file in the Prometheus text format, eg: for the node-exporter textfile collector")
+}
+
+// listenMetrics writes the metrics of the run to the file given with --metrics-textfile
+// once it completes. Commands run outside of the attachment group don't have the flag.
+func listenMetrics(cmd *cobra.Command, n *notify.Notifier) error {
+	if cmd.Flags().Lookup(metricsFlag) == nil {
+		return nil
+	}
+	path, err := cmd.Flags().GetString(metricsFlag)
+	if err != nil || path == "" {
+		return err
+	}
+	n.Listen(func(s notify.Summary) {
+		if err := metrics.WriteFile(path, metrics.FromSummary(s)); err != nil {
+			cmdutil.Warn("Unable to write metrics to %s: %s", path, err)
+		}
+	})
+	return nil
+}
+
+// FailureReason returns the reason of a failed request reported in the metrics, the kind
+// of its diagnosis, eg: timeout.
+func FailureReason(err error) string {
+	return cmdutil.Diagnose(err).Kind.String()
+}
@@ -0,0 +1,49 @@
+package cmdcommon
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/internal/notify"
+)
+
+const notifyFlag = "notify"
+
+// SetNotifyFlag adds the --notify flag of the commands that transfer attachments.
+func SetNotifyFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(notifyFlag, false,
+		"Show a desktop notification when the run completes, if it took longer than notifications.threshold (default 30s)")
+}
+
+// NewNotifier returns the notifier of a run starting now. It is enabled by --notify or
+// notifications.enabled on the commands with the --notify flag, and notifies runs longer
+// than notifications.threshold. The run is passed to the metrics of --metrics-textfile
+// in any case.
+func NewNotifier(cmd *cobra.Command) (*notify.Notifier, error) {
+	var (
+		enabled bool
+		err     error
+	)
+	supported := cmd.Flags().Lookup(notifyFlag) != nil
+	if supported {
+		if enabled, err = cmd.Flags().GetBool(notifyFlag); err != nil {
+			return nil, err
+		}
+	}
+
+	threshold := notify.DefaultThreshold
+	if t := viper.GetString("notifications.threshold"); t != "" {
+		threshold, err = time.ParseDuration(t)
+		if err != nil || threshold < 0 {
+			return nil, cmdutil.Errorf("Invalid notifications.threshold duration %q", t)
+		}
+	}
+	n := notify.New(supported && (enabled || viper.GetBool("notifications.enabled")), threshold)
+	if err := listenMetrics(cmd, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
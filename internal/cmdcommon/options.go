@@ -0,0 +1,49 @@
+package cmdcommon
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// ClientProvider returns the client the requests of a command are sent with.
+type ClientProvider func() (*jira.Client, error)
+
+// Options configure commands embedded in another cobra application. The zero value
+// keeps the behavior of the CLI: the client is built from the config and the output
+// is written to stdout.
+type Options struct {
+	// ClientProvider returns a preconfigured client. If nil, the client is built from
+	// the config, eg: "server", "login" and the selected profile.
+	ClientProvider ClientProvider
+	// Out receives the output of the command. If nil, it is written to stdout.
+	Out io.Writer
+}
+
+// ResolveOptions returns the options passed to a command constructor, the zero value if none.
+func ResolveOptions(opts []Options) Options {
+	if len(opts) == 0 {
+		return Options{}
+	}
+	return opts[0]
+}
+
+// Apply sets the output of the command and, through the parent lookup of cobra, of its
+// subcommands.
+func (o Options) Apply(cmd *cobra.Command) {
+	if o.Out != nil {
+		cmd.SetOut(o.Out)
+	}
+}
+
+// Client returns the client of a command, from the provider if one is set. The config
+// isn't consulted in that case.
+func (o Options) Client(debug bool) (*jira.Client, error) {
+	if o.ClientProvider == nil {
+		return api.DefaultClient(debug), nil
+	}
+	return o.ClientProvider()
+}
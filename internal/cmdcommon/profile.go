@@ -0,0 +1,78 @@
+package cmdcommon
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+const profileFlag = "profile"
+
+// SetProfileFlag registers the persistent --profile flag of a command group. The profile
+// is selected before any subcommand of the group runs, see ApplyProfile.
+func SetProfileFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(
+		profileFlag, "",
+		"Named profile of the config to use for this invocation, eg: a second Jira instance",
+	)
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		if err := ApplyProfile(c); err != nil {
+			return err
+		}
+		return runParentPreRun(cmd, c, args)
+	}
+}
+
+// ApplyProfile selects the profile given with --profile, if any, so that the server,
+// credentials and installation type of the profile are used to build the client. The
+// --project flag keeps precedence over the project of the profile.
+func ApplyProfile(cmd *cobra.Command) error {
+	f := cmd.Flag(profileFlag)
+	if f == nil || f.Value.String() == "" {
+		return nil
+	}
+	name := strings.TrimSpace(f.Value.String())
+
+	if err := api.UseProfile(name); err != nil {
+		var pErr *api.ErrUnknownProfile
+		if errors.As(err, &pErr) && len(pErr.Available) > 0 {
+			return cmdutil.Errorf("Unknown profile %q, available profiles: %s", name, strings.Join(pErr.Available, ", "))
+		}
+		return cmdutil.Errorf("Unknown profile %q, no profiles are defined under \"profiles\" in the config", name)
+	}
+
+	if p := cmd.Flag("project"); p != nil && p.Changed {
+		viper.Set("project.key", p.Value.String())
+	}
+	cmdutil.Log().Infof("Using profile %q (%s)", name, viper.GetString("server"))
+	return nil
+}
+
+// OnInstance returns the suffix naming the Jira instance in prompts, eg: " on customer-jira",
+// if a profile is selected.
+func OnInstance() string {
+	if p := api.ActiveProfile(); p != "" {
+		return " on " + p
+	}
+	return ""
+}
+
+// runParentPreRun runs the persistent pre-run of the closest ancestor of group that has
+// one, eg: the token check of the root command, which cobra skips as group has its own.
+func runParentPreRun(group, cmd *cobra.Command, args []string) error {
+	for p := group.Parent(); p != nil; p = p.Parent() {
+		switch {
+		case p.PersistentPreRunE != nil:
+			return p.PersistentPreRunE(cmd, args)
+		case p.PersistentPreRun != nil:
+			p.PersistentPreRun(cmd, args)
+			return nil
+		}
+	}
+	return nil
+}
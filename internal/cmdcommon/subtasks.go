@@ -0,0 +1,143 @@
+package cmdcommon
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	// subtaskFetchConcurrency is the number of subtasks fetched at the same time.
+	subtaskFetchConcurrency = 4
+	// embeddedSubtaskLimit is the number of subtasks from which the list embedded in the
+	// subtasks field of an issue may be truncated. The subtasks of a parent listing as many
+	// are searched for instead.
+	embeddedSubtaskLimit = 100
+	// subtaskSearchPageSize is the number of subtasks requested per page when searching.
+	subtaskSearchPageSize = 100
+)
+
+// AttachmentParentFields are the issue fields fetched by attachment commands
+// for an issue whose subtasks are included.
+var AttachmentParentFields = []string{"attachment", "subtasks"}
+
+// IssueAttachment is an attachment along with the key of its issue.
+type IssueAttachment struct {
+	Issue string
+	// Index is the 1-based position --index selects the attachment by, 0 if not numbered.
+	Index int
+	jira.Attachment
+}
+
+// IssueAttachments are the attachments of an issue.
+type IssueAttachments struct {
+	Issue       string
+	Attachments []jira.Attachment
+}
+
+// FetchAttachmentsWithSubtasks fetches the attachments of an issue and of its subtasks
+// using the given api version. It fails if the issue can't be fetched, subtasks that
+// can't be fetched are skipped with a warning. If the subtasks listed with the issue may
+// be truncated, all of them are paged with a `parent = KEY` search instead.
+func FetchAttachmentsWithSubtasks(client *jira.Client, version, key string, debug bool) ([]IssueAttachments, error) {
+	return fetchAttachmentsWithSubtasks(client, version, key, embeddedSubtaskLimit, debug)
+}
+
+func fetchAttachmentsWithSubtasks(client *jira.Client, version, key string, limit int, debug bool) ([]IssueAttachments, error) {
+	parent, err := GetAttachmentIssue(client, version, key, AttachmentParentFields)
+	if err != nil {
+		return nil, cmdutil.RequestError(err, debug)
+	}
+	warn := func(format string, a ...any) {
+		cmdutil.Warn(format, a...)
+	}
+
+	if len(parent.Fields.Subtasks) >= limit {
+		issues, err := searchSubtaskAttachments(client, version, parent)
+		if err == nil {
+			return issues, nil
+		}
+		warn("Unable to search the subtasks of %s, only the %d listed with the issue are included: %s",
+			parent.Key, len(parent.Fields.Subtasks), cmdutil.Diagnose(err))
+	}
+
+	return CollectSubtaskAttachments(parent, func(key string) (*jira.Issue, error) {
+		return GetAttachmentIssue(client, version, key, AttachmentIssueFields)
+	}, warn), nil
+}
+
+// searchSubtaskAttachments returns the attachments of the parent followed by the ones of all
+// of its subtasks, paged with a `parent = KEY` search ordered by key. The search returns the
+// attachments along with the subtasks, they aren't fetched one by one.
+func searchSubtaskAttachments(client *jira.Client, version string, parent *jira.Issue) ([]IssueAttachments, error) {
+	out := []IssueAttachments{{Issue: parent.Key, Attachments: parent.Fields.Attachments}}
+
+	jql := fmt.Sprintf("parent = %s ORDER BY key ASC", parent.Key)
+	err := api.ProxySearchIssueAttachmentsVersion(client, version, jql, subtaskSearchPageSize, func(issues []*jira.Issue) error {
+		for _, iss := range issues {
+			out = append(out, IssueAttachments{Issue: iss.Key, Attachments: iss.Fields.Attachments})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CollectSubtaskAttachments returns the attachments of the parent followed by the ones of
+// its subtasks, in the order the subtasks are listed. Subtasks are fetched concurrently,
+// the ones that fail are reported to warn and left out.
+func CollectSubtaskAttachments(
+	parent *jira.Issue, fetch func(key string) (*jira.Issue, error), warn func(format string, a ...any),
+) []IssueAttachments {
+	subtasks := parent.Fields.Subtasks
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, subtaskFetchConcurrency)
+		fetched = make([]*jira.Issue, len(subtasks))
+		errs    = make([]error, len(subtasks))
+	)
+	for i, st := range subtasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			fetched[i], errs[i] = fetch(st.Key)
+		}()
+	}
+	wg.Wait()
+
+	out := make([]IssueAttachments, 0, len(subtasks)+1)
+	out = append(out, IssueAttachments{Issue: parent.Key, Attachments: parent.Fields.Attachments})
+	for i, st := range subtasks {
+		if errs[i] != nil {
+			warn("Skipping attachments of subtask %s: %s", st.Key, cmdutil.Diagnose(errs[i]))
+			continue
+		}
+		out = append(out, IssueAttachments{Issue: st.Key, Attachments: fetched[i].Fields.Attachments})
+	}
+	return out
+}
+
+// MergeByCreated merges the attachments of the issues into a single list sorted by creation date.
+func MergeByCreated(issues []IssueAttachments) []IssueAttachment {
+	var out []IssueAttachment
+	for _, iss := range issues {
+		for _, a := range iss.Attachments {
+			out = append(out, IssueAttachment{Issue: iss.Issue, Attachment: a})
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b IssueAttachment) int {
+		return jira.CompareAttachmentsByCreated(a.Attachment, b.Attachment)
+	})
+	return out
+}
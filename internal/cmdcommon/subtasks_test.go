@@ -0,0 +1,111 @@
+package cmdcommon
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestCollectSubtaskAttachments(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"), jiratest.WithClock(clock))
+	defer server.Close()
+
+	for _, key := range []string{"TEST-2", "TEST-3", "TEST-4"} {
+		server.AddSubtask("TEST-1", key)
+	}
+	server.Forbid("TEST-3")
+
+	// Uploaded in this order, so the merged list interleaves the issues.
+	server.AddAttachment("TEST-4", "first.txt", []byte("1"))
+	server.AddAttachment("TEST-1", "second.txt", []byte("2"))
+	server.AddAttachment("TEST-3", "hidden.txt", []byte("x"))
+	server.AddAttachment("TEST-2", "third.txt", []byte("3"))
+	server.AddAttachment("TEST-1", "fourth.txt", []byte("4"))
+
+	client := server.Client()
+	parent, err := client.GetIssueFields("TEST-1", AttachmentParentFields)
+	require.NoError(t, err)
+
+	var warnings []string
+	issues := CollectSubtaskAttachments(parent, func(key string) (*jira.Issue, error) {
+		return client.GetIssueFields(key, AttachmentIssueFields)
+	}, func(format string, a ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, a...))
+	})
+
+	keys := make([]string, 0, len(issues))
+	for _, iss := range issues {
+		keys = append(keys, iss.Issue)
+	}
+	assert.Equal(t, []string{"TEST-1", "TEST-2", "TEST-4"}, keys)
+
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "Skipping attachments of subtask TEST-3")
+	assert.Contains(t, warnings[0], "403")
+
+	var merged []string
+	for _, a := range MergeByCreated(issues) {
+		merged = append(merged, a.Issue+"/"+a.Filename)
+	}
+	assert.Equal(t, []string{"TEST-4/first.txt", "TEST-1/second.txt", "TEST-2/third.txt", "TEST-1/fourth.txt"}, merged)
+
+	// Only the slim attachment fields are requested, never the full issue.
+	reqs := server.Requests()
+	assert.Len(t, reqs, 4)
+	for _, r := range reqs {
+		if r.Path == "/rest/api/3/issue/TEST-1" || r.Path == "/rest/api/2/issue/TEST-1" {
+			assert.Equal(t, "attachment,subtasks", r.Query.Get("fields"))
+		} else {
+			assert.Equal(t, "attachment", r.Query.Get("fields"), r.Path)
+		}
+	}
+}
+
+func TestFetchAttachmentsWithTruncatedSubtasks(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	for _, key := range []string{"TEST-2", "TEST-3", "TEST-4", "TEST-5"} {
+		server.AddSubtask("TEST-1", key)
+		server.AddAttachment(key, key+".txt", []byte(key))
+	}
+	server.AddAttachment("TEST-1", "parent.txt", []byte("parent"))
+	server.SetFaults(jiratest.Faults{SubtaskLimit: 2})
+
+	for _, version := range []string{"", api.APIVersion2} {
+		issues, err := fetchAttachmentsWithSubtasks(server.Client(), version, "TEST-1", 2, false)
+		require.NoError(t, err, version)
+
+		var files []string
+		for _, iss := range issues {
+			for _, a := range iss.Attachments {
+				files = append(files, iss.Issue+"/"+a.Filename)
+			}
+		}
+		assert.Equal(t, []string{
+			"TEST-1/parent.txt", "TEST-2/TEST-2.txt", "TEST-3/TEST-3.txt", "TEST-4/TEST-4.txt", "TEST-5/TEST-5.txt",
+		}, files, version)
+	}
+
+	// The subtasks come with the search, they aren't fetched one by one.
+	for _, r := range server.Requests() {
+		assert.NotContains(t, []string{"/rest/api/3/issue/TEST-2", "/rest/api/2/issue/TEST-2"}, r.Path)
+	}
+}
@@ -0,0 +1,50 @@
+package cmdcommon
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+const tzFlag = "tz"
+
+// SetTimezoneFlag adds the --tz flag of the commands that show attachment dates.
+func SetTimezoneFlag(cmd *cobra.Command) {
+	cmd.Flags().String(tzFlag, "", "Timezone to show dates in, eg: UTC or Europe/Berlin (default display.timezone or local)")
+}
+
+// DisplayLocation returns the location dates are shown in by tables and plain text:
+// --tz, else the display.timezone or timezone config, else the local timezone. Machine
+// readable outputs are always in UTC.
+func DisplayLocation(cmd *cobra.Command) (*time.Location, error) {
+	tz, err := cmd.Flags().GetString(tzFlag)
+	if err != nil {
+		return nil, err
+	}
+	if tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, cmdutil.Errorf("Invalid --tz %q, use eg: UTC or Europe/Berlin", tz)
+		}
+		return loc, nil
+	}
+	return resolveLocation(viper.GetString("display.timezone"), viper.GetString("timezone"))
+}
+
+// resolveLocation loads the first configured timezone, or returns the local one.
+func resolveLocation(timezones ...string) (*time.Location, error) {
+	for _, tz := range timezones {
+		if tz == "" {
+			continue
+		}
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, cmdutil.Errorf("Invalid timezone %q in the config, use eg: UTC or Europe/Berlin", tz)
+		}
+		return loc, nil
+	}
+	return time.Local, nil
+}
@@ -0,0 +1,36 @@
+package cmdcommon
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
+)
+
+// TempMaxAge returns how old leftover temporary files must be to be cleaned up,
+// attachment.temp_max_age or 24h by default.
+func TempMaxAge() (time.Duration, error) {
+	t := viper.GetString("attachment.temp_max_age")
+	if t == "" {
+		return tmpfile.DefaultMaxAge, nil
+	}
+	age, err := time.ParseDuration(t)
+	if err != nil || age <= 0 {
+		return 0, cmdutil.Errorf("Invalid attachment.temp_max_age duration %q", t)
+	}
+	return age, nil
+}
+
+// CleanTempFiles removes the temporary files left in dir by earlier runs that crashed
+// or were killed, with a warning for each. Failing to clean up doesn't stop the run.
+func CleanTempFiles(dir string, maxAge time.Duration) {
+	_, err := tmpfile.Clean(dir, tmpfile.Options{
+		MaxAge: maxAge,
+		Notify: func(msg string) { cmdutil.Warn(msg) },
+	})
+	if err != nil {
+		cmdutil.Warn("Unable to clean up temporary files in %s: %s", dir, err)
+	}
+}
@@ -0,0 +1,134 @@
+package cmdcommon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// DefaultTokenExpiryWarning is how long before the token expiry attachment commands start to warn.
+const DefaultTokenExpiryWarning = 7 * 24 * time.Hour
+
+// CheckTokenExpiry authenticates with the configured token once before an attachment
+// command starts its work. It fails if the token is rejected and warns if the server
+// hinted that the token expires within the window set by auth.token_expiry_warning.
+//
+// The check is enabled by default on Jira cloud and can be toggled with auth.check_token_expiry.
+// Any other failure is ignored, the command will report it on its first request.
+func CheckTokenExpiry(client *jira.Client) error {
+	installation := viper.GetString("installation")
+	if !tokenCheckEnabled(installation, viper.IsSet("auth.check_token_expiry"), viper.GetBool("auth.check_token_expiry")) {
+		return nil
+	}
+
+	window, err := tokenExpiryWindow(viper.GetString("auth.token_expiry_warning"))
+	if err != nil {
+		cmdutil.Warn("Ignoring auth.token_expiry_warning: %s", err)
+	}
+
+	status, err := api.ProxyCheckToken(client)
+	if err != nil {
+		if jira.IsAuthFailure(err) {
+			return cmdutil.Errorf("The server rejected the configured credentials, refresh your API token and try again")
+		}
+		cmdutil.Log().Detailf("Skipping token expiry check: %s", err)
+		return nil
+	}
+
+	if msg := tokenExpiryWarning(status, time.Now(), window); msg != "" {
+		cmdutil.Warn("%s", msg)
+	}
+	return nil
+}
+
+// tokenCheckEnabled reports if the token check should run. It defaults
+// to on for cloud installations if the config doesn't set it.
+func tokenCheckEnabled(installation string, configured, enabled bool) bool {
+	if configured {
+		return enabled
+	}
+	return installation != jira.InstallationTypeLocal
+}
+
+func tokenExpiryWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return DefaultTokenExpiryWarning, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return DefaultTokenExpiryWarning, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func tokenExpiryWarning(status *jira.TokenStatus, now time.Time, window time.Duration) string {
+	if !status.ExpiresWithin(now, window) {
+		return ""
+	}
+
+	left := status.ExpiresAt.Sub(now)
+	if left <= 0 {
+		return fmt.Sprintf("Your API token expired on %s, requests may start failing at any time",
+			status.ExpiresAt.Local().Format(time.RFC1123))
+	}
+	return fmt.Sprintf("Your API token expires in %s (%s), long running uploads and downloads may fail midway",
+		left.Round(time.Minute), status.ExpiresAt.Local().Format(time.RFC1123))
+}
+
+// AuthGuard tracks the results of a batch of requests and tells
+// the batch to stop once the server rejects the credentials, or
+// rejects a change because the API token is read-only or the
+// instance is in maintenance.
+type AuthGuard struct {
+	succeeded   bool
+	rejected    bool
+	readOnly    bool
+	maintenance bool
+}
+
+// Observe records the result of a request. It returns true if the
+// batch must stop: the error is an authentication failure, or the
+// change was rejected by a read-only token or during maintenance.
+func (g *AuthGuard) Observe(err error) bool {
+	if err == nil {
+		g.succeeded = true
+		return false
+	}
+	switch {
+	case jira.IsAuthFailure(err):
+		g.rejected = true
+	case errors.Is(err, jira.ErrReadOnlyToken):
+		g.rejected, g.readOnly = true, true
+	case errors.Is(err, jira.ErrMaintenance):
+		g.rejected, g.maintenance = true, true
+	}
+	return g.rejected
+}
+
+// Stopped reports if the batch was stopped because the server rejected the
+// credentials, the API token is read-only or the instance is in maintenance
+// or read-only mode. Reason tells which.
+func (g *AuthGuard) Stopped() bool {
+	return g.rejected
+}
+
+// Reason explains why the batch was stopped. A token that worked earlier in
+// the batch and is then rejected has most likely expired or been revoked.
+func (g *AuthGuard) Reason() string {
+	if g.maintenance {
+		return "Jira is in maintenance/read-only mode, try again later"
+	}
+	if g.readOnly {
+		return "the API token is read-only, use a token with write access"
+	}
+	if g.succeeded {
+		return "the server started rejecting the credentials midway, the API token has most likely expired or been revoked"
+	}
+	return "the server rejected the credentials"
+}
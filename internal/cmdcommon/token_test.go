@@ -0,0 +1,91 @@
+package cmdcommon
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestTokenCheckEnabled(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, tokenCheckEnabled(jira.InstallationTypeCloud, false, false))
+	assert.True(t, tokenCheckEnabled("", false, false))
+	assert.False(t, tokenCheckEnabled(jira.InstallationTypeLocal, false, false))
+	assert.True(t, tokenCheckEnabled(jira.InstallationTypeLocal, true, true))
+	assert.False(t, tokenCheckEnabled(jira.InstallationTypeCloud, true, false))
+}
+
+func TestTokenExpiryWindow(t *testing.T) {
+	t.Parallel()
+
+	d, err := tokenExpiryWindow("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultTokenExpiryWarning, d)
+
+	d, err = tokenExpiryWindow("48h")
+	assert.NoError(t, err)
+	assert.Equal(t, 48*time.Hour, d)
+
+	d, err = tokenExpiryWindow("two days")
+	assert.Error(t, err)
+	assert.Equal(t, DefaultTokenExpiryWarning, d)
+}
+
+func TestTokenExpiryWarning(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Empty(t, tokenExpiryWarning(&jira.TokenStatus{}, now, time.Hour))
+	assert.Empty(t, tokenExpiryWarning(&jira.TokenStatus{ExpiresAt: now.Add(48 * time.Hour)}, now, 24*time.Hour))
+	assert.Contains(t, tokenExpiryWarning(&jira.TokenStatus{ExpiresAt: now.Add(90 * time.Minute)}, now, 24*time.Hour), "expires in 1h30m0s")
+	assert.Contains(t, tokenExpiryWarning(&jira.TokenStatus{ExpiresAt: now.Add(-time.Hour)}, now, 24*time.Hour), "expired on")
+}
+
+func TestTokenExpiryFromServer(t *testing.T) {
+	t.Parallel()
+
+	expiry := time.Now().Add(2 * time.Hour)
+
+	server := jiratest.NewServer(jiratest.WithTokenExpiry(expiry))
+	defer server.Close()
+
+	status, err := server.Client().CheckToken()
+	assert.NoError(t, err)
+	assert.Contains(t, tokenExpiryWarning(status, time.Now(), DefaultTokenExpiryWarning), "Your API token expires in")
+}
+
+func TestAuthGuard(t *testing.T) {
+	t.Parallel()
+
+	unauthorized := &jira.ErrUnexpectedResponse{StatusCode: 401}
+
+	var g AuthGuard
+	assert.False(t, g.Observe(errors.New("connection reset")))
+	assert.False(t, g.Observe(&jira.ErrUnexpectedResponse{StatusCode: 413}))
+	assert.True(t, g.Observe(unauthorized))
+	assert.True(t, g.Stopped())
+	assert.Equal(t, "the server rejected the credentials", g.Reason())
+
+	var mid AuthGuard
+	assert.False(t, mid.Observe(nil))
+	assert.True(t, mid.Observe(&jira.ErrAuthenticationRequired{}))
+	assert.Contains(t, mid.Reason(), "expired")
+
+	var ro AuthGuard
+	assert.False(t, ro.Observe(&jira.ErrUnexpectedResponse{StatusCode: 403}))
+	assert.True(t, ro.Observe(&url.Error{Op: "Post", Err: &jira.ReadOnlyTokenError{Rejected: true}}))
+	assert.Contains(t, ro.Reason(), "read-only")
+
+	var mnt AuthGuard
+	assert.False(t, mnt.Observe(nil))
+	assert.True(t, mnt.Observe(&url.Error{Op: "Post", Err: &jira.MaintenanceError{}}))
+	assert.Equal(t, "Jira is in maintenance/read-only mode, try again later", mnt.Reason())
+}
@@ -0,0 +1,46 @@
+package cmdcommon
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const verboseFlag = "verbose"
+
+// SetVerbosityFlag registers the persistent -v flag of a command group, repeated up to three
+// times to log more, see VerbosityLevel. The level is applied before the persistent pre-run
+// already set on the group, eg: the one of SetProfileFlag, so it must be called after it.
+func SetVerbosityFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().CountP(
+		verboseFlag, "v",
+		"Log what the command does to stderr: -v for each operation, -vv adds timings and retries, -vvv dumps the requests",
+	)
+
+	next := cmd.PersistentPreRunE
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		ApplyVerbosity(c)
+		if next != nil {
+			return next(c, args)
+		}
+		return runParentPreRun(cmd, c, args)
+	}
+}
+
+// ApplyVerbosity sets the level of the command logger and makes the client log to it.
+func ApplyVerbosity(cmd *cobra.Command) {
+	cmdutil.Log().SetLevel(VerbosityLevel(cmd))
+	api.SetLogger(cmdutil.Log())
+}
+
+// VerbosityLevel returns the number of times -v is given, capped at jira.LogDump. --debug
+// is the same as -vvv.
+func VerbosityLevel(cmd *cobra.Command) int {
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		return jira.LogDump
+	}
+	level, _ := cmd.Flags().GetCount(verboseFlag)
+	return min(level, jira.LogDump)
+}
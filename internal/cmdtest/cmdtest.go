@@ -0,0 +1,127 @@
+// Package cmdtest runs cobra commands end to end in tests.
+//
+// A command is executed with its arguments against a client for a fake server, eg:
+// one from jiratest, and its output and returned error are captured:
+//
+//	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+//	defer server.Close()
+//
+//	env := cmdtest.Env{Client: server.Client()}
+//	res := cmdtest.Run(t, env, list.NewCmdAttachmentList(), "TEST-1")
+//	assert.NoError(t, res.Err)
+//	assert.Contains(t, res.Stdout, "FILENAME")
+//
+// Commands print through os.Stdout and os.Stderr, which are swapped while a command
+// runs. Tests using Run must therefore not call t.Parallel.
+package cmdtest
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ankitpokhrel/jira-cli/api"
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// mu serializes commands as they share the process output and the viper config.
+var mu sync.Mutex
+
+// Env is what a command runs against.
+type Env struct {
+	// Client is returned by api.DefaultClient while the command runs. If nil, the
+	// client is built from the config like in a real run, eg: "server" and "api_token".
+	Client *jira.Client
+	// Config holds viper config values, eg: "installation". They are reset once the command returns.
+	Config map[string]any
+}
+
+// Result is the outcome of a command executed by Run.
+type Result struct {
+	Stdout string
+	Stderr string
+	// Err is the error returned by the command. It is what the root
+	// command would print before exiting with a non-zero status.
+	Err error
+}
+
+// Run executes cmd with the args as a subcommand of a minimal root that defines the
+// persistent flags of the real one.
+func Run(t *testing.T, env Env, cmd *cobra.Command, args ...string) Result {
+	t.Helper()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	restoreClient := api.SetClient(env.Client)
+	defer restoreClient()
+
+	for key, val := range env.Config {
+		prev := viper.Get(key)
+		viper.Set(key, val)
+		defer viper.Set(key, prev)
+	}
+	defer api.ClearProfile()
+	defer cmdutil.Log().SetLevel(0)
+
+	root := &cobra.Command{Use: "jira", SilenceErrors: true, SilenceUsage: true}
+	root.PersistentFlags().StringP("config", "c", "", "Config file")
+	root.PersistentFlags().StringP("project", "p", "", "Jira project to look into")
+	root.PersistentFlags().Bool("debug", false, "Turn on debug output")
+	root.PersistentFlags().Bool("dry-run", false, "Print the changes instead of sending them to the server")
+	_ = viper.BindPFlag("dry_run", root.PersistentFlags().Lookup("dry-run"))
+	root.AddCommand(cmd)
+	root.SetArgs(append([]string{cmd.Name()}, args...))
+
+	var res Result
+	res.Stdout, res.Stderr = capture(t, func() {
+		res.Err = root.Execute()
+	})
+	return res
+}
+
+// capture swaps os.Stdout and os.Stderr with pipes while fn runs and returns what was written.
+func capture(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	var (
+		wg       sync.WaitGroup
+		out, err bytes.Buffer
+	)
+	pipe := func(dst *bytes.Buffer) *os.File {
+		r, w, pErr := os.Pipe()
+		if pErr != nil {
+			t.Fatalf("cmdtest: %s", pErr)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = io.Copy(dst, r)
+			_ = r.Close()
+		}()
+		return w
+	}
+
+	outW, errW := pipe(&out), pipe(&err)
+	os.Stdout, os.Stderr = outW, errW
+
+	func() {
+		defer func() {
+			_ = outW.Close()
+			_ = errW.Close()
+		}()
+		fn()
+	}()
+	wg.Wait()
+
+	return out.String(), err.String()
+}
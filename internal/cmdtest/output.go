@@ -0,0 +1,38 @@
+package cmdtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// AssertOutput asserts that the output of a command in the given --output format is not
+// empty and can be parsed, ie: that json and yaml output are a document, and that csv
+// output has a header and at least one record of the same number of fields.
+func AssertOutput(t *testing.T, format, out string) bool {
+	t.Helper()
+
+	if !assert.NotEmpty(t, strings.TrimSpace(out), "%s output is empty", format) {
+		return false
+	}
+
+	switch format {
+	case "json":
+		var v any
+		return assert.NoError(t, json.Unmarshal([]byte(out), &v), "invalid json output") &&
+			assert.NotEmpty(t, v, "empty json document")
+	case "yaml":
+		var v any
+		return assert.NoError(t, yaml.Unmarshal([]byte(out), &v), "invalid yaml output") &&
+			assert.NotEmpty(t, v, "empty yaml document")
+	case "csv":
+		records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+		return assert.NoError(t, err, "invalid csv output") &&
+			assert.GreaterOrEqual(t, len(records), 2, "csv output without records")
+	}
+	return true
+}
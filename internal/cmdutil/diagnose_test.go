@@ -0,0 +1,284 @@
+package cmdutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func urlError(err error) error {
+	return &url.Error{Op: "Get", URL: "https://jira.example.com/rest/api/3/issue/TEST-1", Err: err}
+}
+
+func TestDiagnose(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		err         error
+		wantKind    ErrorKind
+		wantMessage string
+		wantSuggest string
+	}{
+		{
+			name: "socks proxy unreachable",
+			err: urlError(&jira.ProxyError{
+				Proxy: "localhost:1080", Addr: "jira.example.com:443", Unreachable: true,
+				Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			}),
+			wantKind:    ErrorKindProxy,
+			wantMessage: "SOCKS5 proxy localhost:1080 is unreachable: dial tcp: connection refused",
+			wantSuggest: "ssh -D",
+		},
+		{
+			name:        "socks proxy auth",
+			err:         urlError(&jira.ProxyError{Proxy: "localhost:1080", Auth: true, Err: errors.New("username/password authentication failed")}),
+			wantKind:    ErrorKindProxy,
+			wantMessage: "SOCKS5 proxy localhost:1080 rejected the credentials: username/password authentication failed",
+			wantSuggest: "username and password",
+		},
+		{
+			name:        "context deadline",
+			err:         fmt.Errorf("download: %w", urlError(context.DeadlineExceeded)),
+			wantKind:    ErrorKindTimeout,
+			wantMessage: "the request to Jira timed out",
+			wantSuggest: "VPN",
+		},
+		{
+			name:        "net timeout",
+			err:         urlError(&net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}),
+			wantKind:    ErrorKindTimeout,
+			wantMessage: "the request to Jira timed out",
+		},
+		{
+			name:        "dns timeout",
+			err:         urlError(&net.OpError{Op: "dial", Err: &net.DNSError{Name: "jira.example.com", IsTimeout: true}}),
+			wantKind:    ErrorKindTimeout,
+			wantMessage: "the request to Jira timed out",
+		},
+		{
+			name:        "connect phase timeout",
+			err:         fmt.Errorf("download: %w", &jira.ErrTimeout{Phase: jira.TimeoutPhaseConnect, Host: "jira.example.com:443", Err: context.DeadlineExceeded}),
+			wantKind:    ErrorKindTimeout,
+			wantMessage: "connection to host jira.example.com:443 timed out",
+			wantSuggest: "network.dial_timeout",
+		},
+		{
+			name:        "response phase timeout",
+			err:         &jira.ErrTimeout{Phase: jira.TimeoutPhaseResponse, Host: "jira.example.com", Err: timeoutError{}},
+			wantKind:    ErrorKindTimeout,
+			wantMessage: "server jira.example.com did not respond in time",
+			wantSuggest: "network.response_header_timeout",
+		},
+		{
+			name:        "transfer phase timeout",
+			err:         &jira.ErrTimeout{Phase: jira.TimeoutPhaseTransfer, Host: "jira.example.com", Err: timeoutError{}},
+			wantKind:    ErrorKindTimeout,
+			wantMessage: "transfer from jira.example.com stalled",
+			wantSuggest: "try again",
+		},
+		{
+			name:        "dns not found",
+			err:         urlError(&net.OpError{Op: "dial", Err: &net.DNSError{Name: "jira.example.com", Err: "no such host", IsNotFound: true}}),
+			wantKind:    ErrorKindDNS,
+			wantMessage: `could not resolve host "jira.example.com"`,
+			wantSuggest: "server URL",
+		},
+		{
+			name:        "unknown authority",
+			err:         urlError(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}),
+			wantKind:    ErrorKindTLS,
+			wantMessage: "the server certificate could not be verified: it is signed by an unknown authority",
+			wantSuggest: "mtls.ca_cert",
+		},
+		{
+			name:        "hostname mismatch",
+			err:         urlError(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "jira.example.com"}),
+			wantKind:    ErrorKindTLS,
+			wantMessage: "the server certificate could not be verified: it is not valid for jira.example.com",
+		},
+		{
+			name:        "expired certificate",
+			err:         urlError(x509.CertificateInvalidError{Reason: x509.Expired}),
+			wantKind:    ErrorKindTLS,
+			wantMessage: "the server certificate could not be verified: it has expired or is not yet valid",
+		},
+		{
+			name: "connection refused",
+			err: urlError(&net.OpError{
+				Op:  "dial",
+				Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED},
+			}),
+			wantKind:    ErrorKindConnectionRefused,
+			wantMessage: "the server refused the connection",
+			wantSuggest: "port",
+		},
+		{
+			name: "forbidden attachment error",
+			err: &jira.AttachmentError{
+				Op:         jira.AttachmentOpDelete,
+				Status:     "403 Forbidden",
+				StatusCode: 403,
+				Attempts:   1,
+				Err: &jira.ErrUnexpectedResponse{
+					Body:       jira.Errors{ErrorMessages: []string{"You do not have permission to delete attachments."}},
+					Status:     "403 Forbidden",
+					StatusCode: 403,
+				},
+			},
+			wantKind:    ErrorKindHTTP,
+			wantMessage: "the server rejected the request with 403 Forbidden: You do not have permission to delete attachments.",
+			wantSuggest: "permission",
+		},
+		{
+			name:        "locked attachment error",
+			err:         &jira.AttachmentError{Op: jira.AttachmentOpUpload, Status: "423 Locked", StatusCode: 423, Attempts: 3, Retryable: true},
+			wantKind:    ErrorKindHTTP,
+			wantMessage: "the server rejected the request with 423 Locked",
+			wantSuggest: "retry later",
+		},
+		{
+			name: "wrapped unexpected response",
+			err: fmt.Errorf("failed to download attachment: 401 Unauthorized%w", &jira.ErrUnexpectedResponse{
+				Body:       jira.Errors{Errors: map[string]string{"b": "second", "a": "first"}},
+				Status:     "401 Unauthorized",
+				StatusCode: 401,
+			}),
+			wantKind:    ErrorKindHTTP,
+			wantMessage: "the server rejected the request with 401 Unauthorized: a: first; b: second",
+			wantSuggest: "API token",
+		},
+		{
+			name:        "server error",
+			err:         &jira.ErrUnexpectedResponse{Status: "502 Bad Gateway", StatusCode: 502},
+			wantKind:    ErrorKindHTTP,
+			wantMessage: "the server rejected the request with 502 Bad Gateway",
+			wantSuggest: "try again later",
+		},
+		{
+			name:        "read-only token",
+			err:         urlError(&jira.ReadOnlyTokenError{Method: "POST", Reason: "Unauthorized; scope does not match", Rejected: true}),
+			wantKind:    ErrorKindReadOnlyToken,
+			wantMessage: "the API token is read-only (Unauthorized; scope does not match)",
+			wantSuggest: "write access",
+		},
+		{
+			name:        "read-only token not attempted",
+			err:         urlError(&jira.ReadOnlyTokenError{Method: "DELETE"}),
+			wantKind:    ErrorKindReadOnlyToken,
+			wantMessage: "not attempted, the API token is read-only",
+		},
+		{
+			name:        "maintenance",
+			err:         urlError(&jira.MaintenanceError{Method: "POST", Reason: "Jira is in read-only mode while it is being upgraded."}),
+			wantKind:    ErrorKindMaintenance,
+			wantMessage: "Jira is in maintenance/read-only mode, try again later (Jira is in read-only mode while it is being upgraded.)",
+			wantSuggest: "--wait-for-writable",
+		},
+		{
+			name:        "still in maintenance",
+			err:         &jira.MaintenanceError{Method: "GET", Waited: 30 * time.Minute},
+			wantKind:    ErrorKindMaintenance,
+			wantMessage: "Jira is still in maintenance/read-only mode after waiting 30m0s, try again later",
+		},
+		{
+			name:        "issue too large",
+			err:         fmt.Errorf("list: %w", &jira.ResponseTooLargeError{Key: "TEST-1", Limit: 20 << 20}),
+			wantKind:    ErrorKindResponseTooLarge,
+			wantMessage: "issue TEST-1 is larger than the 20MB the CLI reads",
+			wantSuggest: "attachment.issue_max_size",
+		},
+		{
+			name:        "issue timeout",
+			err:         &jira.IssueTimeoutError{Key: "TEST-1", Timeout: 10 * time.Second, Err: urlError(context.DeadlineExceeded)},
+			wantKind:    ErrorKindTimeout,
+			wantMessage: "issue TEST-1 was not received within 10s",
+			wantSuggest: "attachment.issue_timeout",
+		},
+		{
+			name:        "upload too large for a proxy",
+			err:         &jira.UploadTooLargeError{Size: 2 << 20, Limit: 10 << 20, Proxy: true},
+			wantKind:    ErrorKindUploadTooLarge,
+			wantMessage: "the server or a proxy in front of it rejected the upload as too large (2097152 bytes); the Jira attachment limit reported by the instance is 10MB",
+			wantSuggest: "request body size",
+		},
+		{
+			name: "upload too large for jira",
+			err: &jira.UploadTooLargeError{Size: 2 << 20, Err: &jira.ErrUnexpectedResponse{
+				Body: jira.Errors{ErrorMessages: []string{"The file is too large"}}, Status: "413 Request Entity Too Large", StatusCode: 413,
+			}},
+			wantKind:    ErrorKindUploadTooLarge,
+			wantMessage: "Jira rejected the upload as too large (2097152 bytes): The file is too large",
+			wantSuggest: "attachment size limit configured in Jira",
+		},
+		{
+			name:        "upload interrupted",
+			err:         &jira.UploadInterruptedError{Size: 2 << 20, Err: urlError(syscall.ECONNRESET)},
+			wantKind:    ErrorKindUploadInterrupted,
+			wantMessage: "the connection was closed while uploading 2097152 bytes",
+			wantSuggest: "request body size limit",
+		},
+		{
+			name:        "unknown",
+			err:         errors.New("something else"),
+			wantKind:    ErrorKindUnknown,
+			wantMessage: "something else",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := Diagnose(tc.err)
+			assert.Equal(t, tc.wantKind, d.Kind)
+			assert.Equal(t, tc.wantMessage, d.Message)
+			assert.Contains(t, d.Suggestion, tc.wantSuggest)
+		})
+	}
+}
+
+func TestFormatDiagnosis(t *testing.T) {
+	t.Parallel()
+
+	err := urlError(context.DeadlineExceeded)
+	d := Diagnose(err)
+
+	assert.Equal(t, "Error: "+d.Message+"\n"+d.Suggestion, formatDiagnosis(d, err, false))
+	assert.Equal(
+		t,
+		"Error: "+d.Message+"\n"+d.Suggestion+"\n\nRaw error: "+err.Error(),
+		formatDiagnosis(d, err, true),
+	)
+	assert.Equal(t, d.Message+". "+d.Suggestion, d.String())
+}
+
+func TestErrorKindString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "timeout", Diagnose(urlError(context.DeadlineExceeded)).Kind.String())
+	assert.Equal(t, "connection_refused", ErrorKindConnectionRefused.String())
+	assert.Equal(t, "proxy", ErrorKindProxy.String())
+	assert.Equal(t, "unknown", ErrorKindUnknown.String())
+	assert.Equal(t, "unknown", ErrorKind(100).String())
+}
@@ -0,0 +1,90 @@
+package cmdutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ExitDenied is the exit status of a command that refused to write an attachment denied
+// by the configured policy, so that scripts can tell it apart from other failures.
+const ExitDenied = 3
+
+// Error is a failure with a message meant for the user. HandleExit prints it like Failed.
+type Error struct {
+	msg string
+	// code is the exit status, 1 if unset.
+	code int
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Errorf returns an Error, it is the RunE counterpart of Failed.
+func Errorf(format string, args ...any) error {
+	return &Error{msg: fmt.Sprintf(format, args...)}
+}
+
+// ErrorfWithCode is Errorf with the exit status of the command, eg: ExitDenied.
+func ErrorfWithCode(code int, format string, args ...any) error {
+	return &Error{msg: fmt.Sprintf(format, args...), code: code}
+}
+
+// ExitCode returns the exit status of a command that failed with err.
+func ExitCode(err error) int {
+	var userErr *Error
+	if errors.As(err, &userErr) && userErr.code != 0 {
+		return userErr.code
+	}
+	return 1
+}
+
+// requestError is a failed request to the server, see RequestError.
+type requestError struct {
+	err   error
+	debug bool
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// RequestError marks err as a failed request so that HandleExit prints the
+// diagnosis like ExitIfRequestError. It returns nil if err is nil.
+func RequestError(err error, debug bool) error {
+	if err == nil {
+		return nil
+	}
+	return &requestError{err: err, debug: debug}
+}
+
+// ErrorMessage formats err the way HandleExit prints it.
+func ErrorMessage(err error) string {
+	var (
+		userErr *Error
+		reqErr  *requestError
+	)
+	switch {
+	case errors.As(err, &userErr):
+		return fmt.Sprintf("\u001B[0;31m✗\u001B[0m %s", userErr.msg)
+	case errors.As(err, &reqErr):
+		return requestErrorMessage(reqErr.err, reqErr.debug)
+	default:
+		return errorMessage(err)
+	}
+}
+
+// HandleExit prints the error returned by a command and exits. Commands using RunE
+// return their errors up to the root, so this is the only place they exit.
+func HandleExit(err error) {
+	if err == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", ErrorMessage(err))
+	os.Exit(ExitCode(err))
+}
@@ -0,0 +1,40 @@
+package cmdutil
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "\u001B[0;31m✗\u001B[0m ISSUE-KEY is required", ErrorMessage(Errorf("%s is required", "ISSUE-KEY")))
+
+	wrapped := fmt.Errorf("upload: %w", Errorf("file too large"))
+	assert.Contains(t, ErrorMessage(wrapped), "file too large")
+
+	reqErr := RequestError(urlError(timeoutError{}), false)
+	assert.Equal(t, requestErrorMessage(urlError(timeoutError{}), false), ErrorMessage(reqErr))
+	assert.ErrorIs(t, RequestError(errPlain, false), errPlain)
+	assert.NoError(t, RequestError(nil, false))
+
+	assert.Equal(t, errorMessage(errPlain), ErrorMessage(errPlain))
+}
+
+var errPlain = errors.New("something went wrong")
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	denied := ErrorfWithCode(ExitDenied, "%d attachment(s) denied", 2)
+	assert.Equal(t, ExitDenied, ExitCode(denied))
+	assert.Equal(t, ExitDenied, ExitCode(fmt.Errorf("download: %w", denied)))
+	assert.Equal(t, "\u001B[0;31m✗\u001B[0m 2 attachment(s) denied", ErrorMessage(denied))
+
+	assert.Equal(t, 1, ExitCode(Errorf("failed")))
+	assert.Equal(t, 1, ExitCode(RequestError(errPlain, false)))
+	assert.Equal(t, 1, ExitCode(errPlain))
+}
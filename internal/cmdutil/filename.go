@@ -0,0 +1,45 @@
+package cmdutil
+
+import (
+	"path"
+	"strings"
+)
+
+// windowsReservedNames are the device names Windows doesn't allow as a filename, with or
+// without an extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename makes a filename from the server, eg: of an attachment, safe to create
+// in a directory on any OS. Directory components are stripped, with both / and \ as
+// separators, so that the file can't be written outside of the directory, eg: with
+// ../../.bashrc or an absolute path. Control characters, including null bytes, and the
+// characters Windows reserves (<>:"|?*) are replaced with _, trailing dots and spaces are
+// removed and Windows device names, eg: CON or nul.txt, are prefixed with _. The fallback
+// is returned if nothing is left of the name.
+func SanitizeFilename(name, fallback string) string {
+	base := path.Base(path.Clean("/" + strings.ReplaceAll(name, `\`, "/")))
+
+	var b strings.Builder
+	for _, r := range base {
+		switch {
+		case isControl(r), strings.ContainsRune(`<>:"|?*`, r):
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	safe := strings.TrimRight(b.String(), ". ")
+
+	if safe == "" || safe == "/" {
+		return fallback
+	}
+	stem, _, _ := strings.Cut(safe, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		safe = "_" + safe
+	}
+	return safe
+}
@@ -0,0 +1,47 @@
+package cmdutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain name", input: "report.pdf", want: "report.pdf"},
+		{name: "unicode and spaces", input: "Übersicht Q1 📎.txt", want: "Übersicht Q1 📎.txt"},
+		{name: "hidden file", input: ".env", want: ".env"},
+		{name: "parent prefix", input: "../../.bashrc", want: ".bashrc"},
+		{name: "parent in the middle", input: "logs/../../../etc/passwd", want: "passwd"},
+		{name: "windows parent prefix", input: `..\..\startup.bat`, want: "startup.bat"},
+		{name: "absolute path", input: "/etc/cron.d/job", want: "job"},
+		{name: "windows absolute path", input: `C:\Windows\System32\drivers\etc\hosts`, want: "hosts"},
+		{name: "null byte", input: "invoice.pdf\x00.exe", want: "invoice.pdf_.exe"},
+		{name: "control characters", input: "a\x1b[31m\nb.txt", want: "a_[31m_b.txt"},
+		{name: "windows reserved characters", input: `what? "why": <a|b>*.txt`, want: "what_ _why__ _a_b__.txt"},
+		{name: "trailing dots and spaces", input: "notes.txt. . ", want: "notes.txt"},
+		{name: "device name", input: "con", want: "_con"},
+		{name: "device name with extension", input: "NUL.tar.gz", want: "_NUL.tar.gz"},
+		{name: "device name prefix is fine", input: "console.log", want: "console.log"},
+		{name: "parent only", input: "..", want: "attachment-10001"},
+		{name: "separators only", input: `/\/`, want: "attachment-10001"},
+		{name: "dots only", input: "...", want: "attachment-10001"},
+		{name: "empty", input: "", want: "attachment-10001"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, SanitizeFilename(tc.input, "attachment-10001"))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package cmdutil
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IssueKeyFile is the file that holds the key of the issue being worked on in a directory,
+// so that commands can be run without the ISSUE-KEY argument, eg: from a shell prompt.
+const IssueKeyFile = ".jira-issue"
+
+// FindIssueKey looks for an IssueKeyFile in dir and its parents and returns the first
+// non-empty line of the closest one. It returns an empty key if there is no such file.
+func FindIssueKey(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		key, err := readIssueKeyFile(filepath.Join(dir, IssueKeyFile))
+		if err == nil {
+			return key, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func readIssueKeyFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			return line, nil
+		}
+	}
+	return "", s.Err()
+}
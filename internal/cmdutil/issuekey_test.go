@@ -0,0 +1,35 @@
+package cmdutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindIssueKey(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0o700))
+
+	key, err := FindIssueKey(nested)
+	require.NoError(t, err)
+	assert.Empty(t, key)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, IssueKeyFile), []byte("\n  TEST-1 \nTEST-2\n"), 0o600))
+
+	key, err = FindIssueKey(nested)
+	require.NoError(t, err)
+	assert.Equal(t, "TEST-1", key)
+
+	// The closest file wins.
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a", IssueKeyFile), []byte("TEST-3"), 0o600))
+
+	key, err = FindIssueKey(nested)
+	require.NoError(t, err)
+	assert.Equal(t, "TEST-3", key)
+}
@@ -0,0 +1,89 @@
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// Logger writes the verbose output of a command, ie: the messages of the levels up to the
+// one set with -v, -vv or -vvv. Messages are redacted with jira.Redact. It implements
+// jira.Logger so that the client logs to the same output.
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level int
+}
+
+var logger = NewLogger(nil, 0)
+
+// NewLogger returns a logger writing the messages up to level to w, stderr if nil.
+func NewLogger(w io.Writer, level int) *Logger {
+	return &Logger{w: w, level: level}
+}
+
+// Log returns the logger of the command.
+func Log() *Logger {
+	return logger
+}
+
+// SetLogOutput makes the logger of the command write to w, eg: a buffer in tests. The
+// returned func restores the previous output.
+func SetLogOutput(w io.Writer) (restore func()) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+
+	prev := logger.w
+	logger.w = w
+	return func() {
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		logger.w = prev
+	}
+}
+
+// SetLevel sets the highest level logged, 0 turns the logger off.
+func (l *Logger) SetLevel(level int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.level = level
+}
+
+// Enabled reports if messages of the level are logged.
+func (l *Logger) Enabled(level int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return level <= l.level
+}
+
+// Logf logs a message at the level, if enabled.
+func (l *Logger) Logf(level int, format string, a ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level > l.level {
+		return
+	}
+	w := l.w
+	if w == nil {
+		w = os.Stderr
+	}
+	msg := jira.Redact(fmt.Sprintf(format, a...))
+	fmt.Fprintf(w, "%s\n", strings.TrimRight(msg, "\n"))
+}
+
+// Infof logs a message at jira.LogInfo, eg: the api version a command uses.
+func (l *Logger) Infof(format string, a ...any) {
+	l.Logf(jira.LogInfo, format, a...)
+}
+
+// Detailf logs a message at jira.LogDetail, eg: how long an operation took.
+func (l *Logger) Detailf(format string, a ...any) {
+	l.Logf(jira.LogDetail, format, a...)
+}
@@ -0,0 +1,40 @@
+package cmdutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := NewLogger(&buf, jira.LogDetail)
+
+	l.Infof("info %d", 1)
+	l.Detailf("detail")
+	l.Logf(jira.LogDump, "dump")
+
+	assert.Equal(t, "info 1\ndetail\n", buf.String())
+	assert.True(t, l.Enabled(jira.LogDetail))
+	assert.False(t, l.Enabled(jira.LogDump))
+
+	buf.Reset()
+	l.SetLevel(0)
+	l.Infof("info")
+	assert.Empty(t, buf.String())
+}
+
+func TestLoggerRedacts(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := NewLogger(&buf, jira.LogDump)
+	l.Logf(jira.LogDump, "Authorization: Bearer secret-pat\n")
+
+	assert.Equal(t, "Authorization: [REDACTED]\n", buf.String())
+}
@@ -0,0 +1,174 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of commands rendering collections.
+const (
+	OutputTable = "table"
+	OutputPlain = "plain"
+	OutputCSV   = "csv"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+const (
+	outputFlag = "output"
+
+	// outputAliasAnnotation marks a legacy boolean flag as an alias of an output format.
+	outputAliasAnnotation = "cmdutil_output_alias"
+	// outputFormatsAnnotation lists the output formats a command supports.
+	outputFormatsAnnotation = "cmdutil_output_formats"
+)
+
+// OutputAlias is a legacy boolean flag selecting an output format, eg: --csv for csv.
+type OutputAlias struct {
+	Flag   string
+	Format string
+	Usage  string
+}
+
+// RegisterOutputFlag registers the --output (-o) flag with the formats supported by the
+// command, the first one being the default. The legacy boolean flags are registered as
+// deprecated aliases of their format.
+func RegisterOutputFlag(cmd *cobra.Command, formats []string, aliases ...OutputAlias) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[outputFormatsAnnotation] = strings.Join(formats, ",")
+
+	cmd.Flags().StringP(outputFlag, "o", formats[0], fmt.Sprintf("Output format: %s", strings.Join(formats, ", ")))
+
+	for _, a := range aliases {
+		cmd.Flags().Bool(a.Flag, false, a.Usage)
+		f := cmd.Flags().Lookup(a.Flag)
+		f.Annotations = map[string][]string{outputAliasAnnotation: {a.Format}}
+		_ = cmd.Flags().MarkDeprecated(a.Flag, fmt.Sprintf("use --output %s instead", a.Format))
+	}
+}
+
+// Renderer is the output format of a command, resolved from --output and its aliases.
+type Renderer struct {
+	Format string
+	// explicit is set if the format was given with --output or an alias.
+	explicit bool
+}
+
+// ResolveRenderer resolves the output format of a command registered with RegisterOutputFlag.
+// An explicit --output wins over the default, and legacy aliases map onto their format. Aliases
+// or an --output selecting different formats are a conflict.
+func ResolveRenderer(cmd *cobra.Command) (Renderer, error) {
+	formats := strings.Split(cmd.Annotations[outputFormatsAnnotation], ",")
+
+	output, err := cmd.Flags().GetString(outputFlag)
+	if err != nil {
+		return Renderer{}, err
+	}
+	output = strings.ToLower(strings.TrimSpace(output))
+	if !slices.Contains(formats, output) {
+		return Renderer{}, Errorf("Unsupported output format %q, use one of: %s", output, strings.Join(formats, ", "))
+	}
+
+	var (
+		format string
+		source string
+	)
+	if cmd.Flags().Changed(outputFlag) {
+		format, source = output, fmt.Sprintf("--output %s", output)
+	}
+
+	var aliasErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		alias, ok := f.Annotations[outputAliasAnnotation]
+		if !ok || !f.Changed || f.Value.String() != "true" || aliasErr != nil {
+			return
+		}
+		if format != "" && format != alias[0] {
+			aliasErr = Errorf("--%s conflicts with %s", f.Name, source)
+			return
+		}
+		format, source = alias[0], "--"+f.Name
+	})
+	if aliasErr != nil {
+		return Renderer{}, aliasErr
+	}
+
+	if format == "" {
+		return Renderer{Format: output}, nil
+	}
+	return Renderer{Format: format, explicit: true}, nil
+}
+
+// Imply returns the renderer of a flag implying a format, eg: --excel implying csv, if the
+// flag is set. The flag conflicts with another format given explicitly.
+func (r Renderer) Imply(set bool, flag, format string) (Renderer, error) {
+	if !set {
+		return r, nil
+	}
+	if r.explicit && r.Format != format {
+		return r, Errorf("--%s can't be used with --output %s", flag, r.Format)
+	}
+	return Renderer{Format: format, explicit: true}, nil
+}
+
+// Structured reports if the output is a structured document, ie: json or yaml.
+func (r Renderer) Structured() bool {
+	return r.Format == OutputJSON || r.Format == OutputYAML
+}
+
+// Encode writes v as indented JSON, or as YAML with the same schema as the JSON output.
+func (r Renderer) Encode(w io.Writer, v any) error {
+	if r.Format == OutputYAML {
+		return EncodeYAML(w, v)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// EncodeYAML writes v as YAML. The value is encoded as JSON first so that the field names,
+// omitted fields and order are the same as in the JSON output.
+func EncodeYAML(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is valid YAML, decoding it into a node keeps the order of the keys.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	blockStyle(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// blockStyle drops the JSON flow style and quotes of the nodes, the encoder quotes the
+// strings that need it.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
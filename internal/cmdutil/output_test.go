@@ -0,0 +1,171 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func newOutputCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+
+	cmd := &cobra.Command{Use: "test"}
+	RegisterOutputFlag(cmd, []string{OutputTable, OutputPlain, OutputCSV, OutputJSON, OutputYAML},
+		OutputAlias{Flag: "plain", Format: OutputPlain},
+		OutputAlias{Flag: "csv", Format: OutputCSV},
+		OutputAlias{Flag: "json", Format: OutputJSON},
+	)
+	cmd.Flags().SetOutput(&bytes.Buffer{})
+	require.NoError(t, cmd.ParseFlags(args))
+	return cmd
+}
+
+func TestResolveRenderer(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "default", want: OutputTable},
+		{name: "output", args: []string{"--output", "yaml"}, want: OutputYAML},
+		{name: "shorthand", args: []string{"-o", "csv"}, want: OutputCSV},
+		{name: "case insensitive", args: []string{"-o", "JSON"}, want: OutputJSON},
+		{name: "alias", args: []string{"--csv"}, want: OutputCSV},
+		{name: "alias and same output", args: []string{"--json", "-o", "json"}, want: OutputJSON},
+		{name: "alias set to false", args: []string{"--csv=false", "-o", "plain"}, want: OutputPlain},
+		{name: "explicit default and alias", args: []string{"-o", "table", "--plain"}, wantErr: "--plain conflicts with --output table"},
+		{name: "alias and other output", args: []string{"--csv", "-o", "json"}, wantErr: "--csv conflicts with --output json"},
+		{name: "two aliases", args: []string{"--csv", "--json"}, wantErr: "--json conflicts with --csv"},
+		{name: "unsupported", args: []string{"-o", "xml"}, wantErr: `Unsupported output format "xml", use one of: table, plain, csv, json, yaml`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := ResolveRenderer(newOutputCmd(t, tc.args...))
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, r.Format)
+		})
+	}
+}
+
+func TestRegisterOutputFlagDeprecatesAliases(t *testing.T) {
+	t.Parallel()
+
+	cmd := newOutputCmd(t)
+	f := cmd.Flags().Lookup("csv")
+	assert.Equal(t, "use --output csv instead", f.Deprecated)
+	assert.True(t, f.Hidden)
+	assert.Equal(t, "o", cmd.Flags().Lookup("output").Shorthand)
+}
+
+func TestRendererStructured(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Renderer{Format: OutputJSON}.Structured())
+	assert.True(t, Renderer{Format: OutputYAML}.Structured())
+	assert.False(t, Renderer{Format: OutputCSV}.Structured())
+}
+
+type yamlFixture struct {
+	Name    string            `json:"name"`
+	Size    int64             `json:"size"`
+	Omitted string            `json:"omitted,omitempty"`
+	Flag    string            `json:"flag"`
+	Items   []yamlFixtureItem `json:"items"`
+	Empty   []string          `json:"empty"`
+}
+
+type yamlFixtureItem struct {
+	ID string `json:"id"`
+}
+
+func TestEncodeYAML(t *testing.T) {
+	t.Parallel()
+
+	v := yamlFixture{
+		Name:  "résumé: final.pdf",
+		Size:  1024,
+		Flag:  "true",
+		Items: []yamlFixtureItem{{ID: "10001"}, {ID: "10002"}},
+		Empty: []string{},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Renderer{Format: OutputYAML}.Encode(&buf, v))
+
+	expected := `name: 'résumé: final.pdf'
+size: 1024
+flag: "true"
+items:
+  - id: "10001"
+  - id: "10002"
+empty: []
+`
+	assert.Equal(t, expected, buf.String())
+
+	// The YAML document has the same schema as the JSON one.
+	var fromYAML, fromJSON map[string]any
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &fromYAML))
+
+	var js bytes.Buffer
+	require.NoError(t, Renderer{Format: OutputJSON}.Encode(&js, v))
+	require.NoError(t, json.Unmarshal(js.Bytes(), &fromJSON))
+
+	assert.Equal(t, len(fromJSON), len(fromYAML))
+	for k := range fromJSON {
+		assert.Contains(t, fromYAML, k)
+	}
+	assert.NotContains(t, fromYAML, "omitted")
+}
+
+func TestRendererImply(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		args    []string
+		set     bool
+		want    string
+		wantErr string
+	}{
+		{name: "not set", args: []string{"-o", "plain"}, want: OutputPlain},
+		{name: "default format", set: true, want: OutputCSV},
+		{name: "same format", args: []string{"-o", "csv"}, set: true, want: OutputCSV},
+		{name: "same format by alias", args: []string{"--csv"}, set: true, want: OutputCSV},
+		{name: "explicit default", args: []string{"-o", "table"}, set: true, wantErr: "--excel can't be used with --output table"},
+		{name: "other format", args: []string{"--json"}, set: true, wantErr: "--excel can't be used with --output json"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := ResolveRenderer(newOutputCmd(t, tc.args...))
+			require.NoError(t, err)
+
+			r, err = r.Imply(tc.set, "excel", OutputCSV)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, r.Format)
+		})
+	}
+}
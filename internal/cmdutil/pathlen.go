@@ -0,0 +1,145 @@
+package cmdutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxFilenameLength is the length limit of a filename, in bytes, on the common
+	// filesystems, eg: ext4, APFS and NTFS.
+	MaxFilenameLength = 255
+
+	// windowsMaxPath is the classic MAX_PATH limit of Windows, paths of that length or
+	// longer must be prefixed with \\?\ to be opened.
+	windowsMaxPath = 260
+
+	// shortHashLength is the number of hex digits of the hash appended to a shortened filename.
+	shortHashLength = 8
+
+	// maxExtLength is the longest suffix kept as the extension of a shortened filename, a
+	// longer one is more likely to be part of the name than an extension.
+	maxExtLength = 16
+)
+
+// maxPathLength returns the length limit of a path on the OS, in bytes. On Windows, it
+// is the limit of the paths prefixed by LongPath.
+func maxPathLength(goos string) int {
+	switch goos {
+	case "windows":
+		return 32767
+	case "darwin":
+		return 1024
+	default:
+		return 4096
+	}
+}
+
+// ShortenFilename shortens name to at most max bytes. The extension is kept and the
+// stem is cut, on a rune boundary, to make room for a "-" and a short hash of the full
+// name, so that names that only differ after the cut still get distinct names. The same
+// name always gives the same result. A name that fits is returned as is.
+func ShortenFilename(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	if len(ext) > maxExtLength || ext == name {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(name, ext)
+
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:shortHashLength] + ext
+
+	keep := max - len(suffix)
+	if keep < 1 {
+		keep = 1
+	}
+	for keep > 0 && !utf8.RuneStart(stem[keep]) {
+		keep--
+	}
+	return stem[:keep] + suffix
+}
+
+// FitFilename returns name, shortened with ShortenFilename if needed, so that neither it
+// nor its path in dir go over the limits of the filesystem, with reserve bytes to spare
+// for the suffixes added to the name, eg: of a temporary file. If dir itself is too long,
+// the name is shortened as much as possible and creating the file fails as it would have.
+func FitFilename(dir, name string, reserve int) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return fitFilename(dir, name, reserve, maxPathLength(runtime.GOOS))
+}
+
+func fitFilename(dir, name string, reserve, maxPath int) string {
+	max := MaxFilenameLength - reserve
+	if room := maxPath - len(dir) - 1 - reserve; room < max {
+		max = room
+	}
+	return ShortenFilename(name, max)
+}
+
+// LongPath returns the path with the \\?\ prefix on Windows if it is too long for the
+// classic MAX_PATH limit, so that it can be created and opened. It returns the path as
+// is on other OS.
+func LongPath(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return longPath(path)
+}
+
+// longPath prefixes an absolute Windows path, eg: C:\dir\file or \\server\share\file,
+// with \\?\ if it is MAX_PATH long or longer. The prefix turns off the normalization of
+// the path by Windows, so forward slashes are replaced by backslashes and . and .. are
+// resolved beforehand. Relative and already prefixed paths are returned as is.
+func longPath(path string) string {
+	if len(path) < windowsMaxPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	p := strings.ReplaceAll(path, "/", `\`)
+
+	var prefix string
+	switch {
+	case strings.HasPrefix(p, `\\`):
+		prefix, p = `\\?\UNC\`, p[2:]
+	case len(p) >= 3 && p[1] == ':' && p[2] == '\\' && isDriveLetter(p[0]):
+		prefix = `\\?\`
+	default:
+		return path
+	}
+	return prefix + cleanWindowsPath(p)
+}
+
+// cleanWindowsPath removes the empty and . elements of a backslash separated path and
+// resolves its .. elements, without going above its first element, the drive or server.
+func cleanWindowsPath(p string) string {
+	parts := strings.Split(p, `\`)
+	out := parts[:1]
+	for _, part := range parts[1:] {
+		switch part {
+		case "", ".":
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, part)
+		}
+	}
+	return strings.Join(out, `\`)
+}
+
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,144 @@
+package cmdutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortenFilename(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("a", 300)
+
+	cases := []struct {
+		name  string
+		input string
+		max   int
+		want  string
+	}{
+		{name: "fits", input: "report.pdf", max: 255, want: "report.pdf"},
+		{name: "exactly the limit", input: strings.Repeat("a", 251) + ".pdf", max: 255, want: strings.Repeat("a", 251) + ".pdf"},
+		{
+			name:  "one byte over the limit",
+			input: strings.Repeat("a", 252) + ".pdf",
+			max:   255,
+			want:  strings.Repeat("a", 242) + "-" + shortHash(strings.Repeat("a", 252)+".pdf") + ".pdf",
+		},
+		{name: "keeps the extension", input: long + ".tar", max: 20, want: "aaaaaaa-" + shortHash(long+".tar") + ".tar"},
+		{name: "no extension", input: long, max: 20, want: "aaaaaaaaaaa-" + shortHash(long)},
+		{name: "long extension is part of the name", input: "a." + long, max: 20, want: "a.aaaaaaaaa-" + shortHash("a."+long)},
+		{name: "dot file", input: "." + long, max: 20, want: ".aaaaaaaaaa-" + shortHash("."+long)},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ShortenFilename(tc.input, tc.max)
+			assert.Equal(t, tc.want, got)
+			assert.LessOrEqual(t, len(got), tc.max)
+			assert.Equal(t, got, ShortenFilename(tc.input, tc.max), "output must be stable")
+		})
+	}
+}
+
+func TestShortenFilenameUnique(t *testing.T) {
+	t.Parallel()
+
+	prefix := strings.Repeat("x", 300)
+	a := ShortenFilename(prefix+"-a.log", 64)
+	b := ShortenFilename(prefix+"-b.log", 64)
+
+	assert.NotEqual(t, a, b)
+	assert.True(t, strings.HasSuffix(a, ".log"))
+	assert.True(t, strings.HasSuffix(b, ".log"))
+}
+
+func TestShortenFilenameRuneBoundary(t *testing.T) {
+	t.Parallel()
+
+	name := strings.Repeat("ü", 200) + ".txt"
+	for max := 20; max < 30; max++ {
+		got := ShortenFilename(name, max)
+
+		assert.True(t, utf8.ValidString(got), got)
+		assert.LessOrEqual(t, len(got), max)
+		assert.True(t, strings.HasSuffix(got, ".txt"))
+	}
+}
+
+func TestFitFilename(t *testing.T) {
+	t.Parallel()
+
+	name := strings.Repeat("a", 200) + ".pdf"
+
+	cases := []struct {
+		name    string
+		dir     string
+		reserve int
+		maxPath int
+		wantLen int
+	}{
+		{name: "fits", dir: "/tmp", reserve: 0, maxPath: 4096, wantLen: 204},
+		{name: "reserve shortens the name", dir: "/tmp", reserve: 60, maxPath: 4096, wantLen: 195},
+		{name: "path at the limit", dir: strings.Repeat("d", 300), reserve: 0, maxPath: 505, wantLen: 204},
+		{name: "path over the limit", dir: strings.Repeat("d", 300), reserve: 0, maxPath: 504, wantLen: 203},
+		{name: "path and reserve", dir: strings.Repeat("d", 300), reserve: 10, maxPath: 504, wantLen: 193},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := fitFilename(tc.dir, name, tc.reserve, tc.maxPath)
+			assert.Len(t, got, tc.wantLen)
+			assert.True(t, strings.HasSuffix(got, ".pdf"))
+		})
+	}
+}
+
+func TestLongPath(t *testing.T) {
+	t.Parallel()
+
+	deep := strings.Repeat(`segment\`, 40) + "file.txt"
+
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "short path", input: `C:\Users\me\file.txt`, want: `C:\Users\me\file.txt`},
+		{name: "one byte under the limit", input: `C:\` + strings.Repeat("a", 256), want: `C:\` + strings.Repeat("a", 256)},
+		{name: "at the limit", input: `C:\` + strings.Repeat("a", 257), want: `\\?\C:\` + strings.Repeat("a", 257)},
+		{name: "drive path", input: `C:\` + deep, want: `\\?\C:\` + deep},
+		{name: "forward slashes", input: "D:/" + strings.ReplaceAll(deep, `\`, "/"), want: `\\?\D:\` + deep},
+		{name: "dot elements", input: `C:\tmp\.\x\..\` + deep, want: `\\?\C:\tmp\` + deep},
+		{name: "unc path", input: `\\server\share\` + deep, want: `\\?\UNC\server\share\` + deep},
+		{name: "already prefixed", input: `\\?\C:\` + deep, want: `\\?\C:\` + deep},
+		{name: "relative path", input: deep, want: deep},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, longPath(tc.input))
+		})
+	}
+}
+
+func shortHash(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])[:8]
+}
@@ -0,0 +1,120 @@
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const (
+	// progressRefreshRate is how often the bar is redrawn at most, like the spinner.
+	progressRefreshRate = 100 * time.Millisecond
+	progressBarWidth    = 30
+)
+
+// ProgressBarEnabled reports if transfers show a progress bar rather than a spinner: stdout
+// is a terminal and --plain isn't set.
+func ProgressBarEnabled(plain bool) bool {
+	return !plain && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Progress shows the progress of an upload or a download on stderr, as a bar with the
+// percentage and the transfer rate, or as the spinner of Info if the bar is disabled.
+type Progress struct {
+	spinner *spinner.Spinner
+
+	mu    sync.Mutex
+	w     io.Writer
+	label string
+	now   func() time.Time
+	start time.Time
+	drawn time.Time
+}
+
+// NewProgress starts showing the progress of a transfer, as a bar if bar is set, see
+// ProgressBarEnabled. Pass its Update method to the transfer and Stop it once done.
+func NewProgress(label string, bar bool) *Progress {
+	if !bar {
+		return &Progress{spinner: Info(label)}
+	}
+	return newProgressBar(color.Error, label, time.Now)
+}
+
+func newProgressBar(w io.Writer, label string, now func() time.Time) *Progress {
+	p := &Progress{w: w, label: label, now: now, start: now()}
+	_, _ = fmt.Fprintf(w, "\r\033[K%s", label)
+	return p
+}
+
+// Update redraws the bar with the bytes transferred so far and the total, -1 if unknown.
+// It is a jira.ProgressFunc. The bar is redrawn at most every 100ms and once complete.
+func (p *Progress) Update(done, total int64) {
+	if p.spinner != nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if now.Sub(p.drawn) < progressRefreshRate && done != total {
+		return
+	}
+	p.drawn = now
+	_, _ = fmt.Fprintf(p.w, "\r\033[K%s", renderProgress(p.label, done, total, now.Sub(p.start)))
+}
+
+// Stop clears the bar, or stops the spinner.
+func (p *Progress) Stop() {
+	if p.spinner != nil {
+		p.spinner.Stop()
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, _ = fmt.Fprint(p.w, "\r\033[K")
+}
+
+// renderProgress returns the line of a transfer, eg:
+//
+//	Downloading backup.tar [=========>                    ]  33%  100.00 MB / 300.00 MB  12.50 MB/s
+//
+// Without a total, only the bytes transferred and the rate are shown.
+func renderProgress(label string, done, total int64, elapsed time.Duration) string {
+	var b strings.Builder
+	b.WriteString(label)
+
+	if total > 0 {
+		ratio := min(float64(done)/float64(total), 1)
+		filled := int(ratio * progressBarWidth)
+		bar := strings.Repeat("=", filled)
+		if filled < progressBarWidth {
+			bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+		}
+		fmt.Fprintf(&b, " [%s] %3d%%  %s / %s", bar, int(ratio*100), FormatSize(done), FormatSize(total))
+	} else {
+		fmt.Fprintf(&b, "  %s", FormatSize(done))
+	}
+
+	if elapsed >= time.Second {
+		fmt.Fprintf(&b, "  %s/s", FormatSize(int64(float64(done)/elapsed.Seconds())))
+	}
+	return b.String()
+}
+
+// FormatSize formats a size in bytes for humans, in binary units with two decimals, eg: 1.50 MB.
+// It is jira.FormatSize, so that sizes read the same in messages of the client and the commands.
+func FormatSize(bytes int64) string {
+	return jira.FormatSize(bytes)
+}
@@ -0,0 +1,125 @@
+package cmdutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		bytes    int64
+		expected string
+	}{
+		{
+			name:     "bytes",
+			bytes:    500,
+			expected: "500 B",
+		},
+		{
+			name:     "kilobytes",
+			bytes:    2048,
+			expected: "2.00 KB",
+		},
+		{
+			name:     "megabytes",
+			bytes:    5242880,
+			expected: "5.00 MB",
+		},
+		{
+			name:     "gigabytes",
+			bytes:    2147483648,
+			expected: "2.00 GB",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			actual := FormatSize(tc.bytes)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestRenderProgress(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		done    int64
+		total   int64
+		elapsed time.Duration
+		want    string
+	}{
+		{
+			name:  "start",
+			total: 300 << 20,
+			want:  "Downloading backup.tar [>                             ]   0%  0 B / 300.00 MB",
+		},
+		{
+			name:    "a third",
+			done:    100 << 20,
+			total:   300 << 20,
+			elapsed: 8 * time.Second,
+			want:    "Downloading backup.tar [==========>                   ]  33%  100.00 MB / 300.00 MB  12.50 MB/s",
+		},
+		{
+			name:    "complete",
+			done:    300 << 20,
+			total:   300 << 20,
+			elapsed: 20 * time.Second,
+			want:    "Downloading backup.tar [==============================] 100%  300.00 MB / 300.00 MB  15.00 MB/s",
+		},
+		{
+			name:    "unknown total",
+			done:    5 << 10,
+			total:   -1,
+			elapsed: 2 * time.Second,
+			want:    "Downloading backup.tar  5.00 KB  2.50 KB/s",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, renderProgress("Downloading backup.tar", tc.done, tc.total, tc.elapsed))
+		})
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf bytes.Buffer
+		now = time.Unix(0, 0)
+	)
+	p := newProgressBar(&buf, "Uploading a.bin", func() time.Time { return now })
+
+	// Updates are drawn at most every 100ms, and once complete.
+	now = now.Add(time.Second)
+	p.Update(1024, 4096)
+	now = now.Add(10 * time.Millisecond)
+	p.Update(2048, 4096)
+	now = now.Add(10 * time.Millisecond)
+	p.Update(4096, 4096)
+	p.Stop()
+
+	lines := strings.Split(buf.String(), "\r\033[K")
+	assert.Equal(t, []string{
+		"",
+		"Uploading a.bin",
+		"Uploading a.bin [=======>                      ]  25%  1.00 KB / 4.00 KB  1.00 KB/s",
+		"Uploading a.bin [==============================] 100%  4.00 KB / 4.00 KB  3.92 KB/s",
+		"",
+	}, lines)
+}
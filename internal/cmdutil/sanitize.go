@@ -0,0 +1,84 @@
+package cmdutil
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTerminalTextLength is the number of characters after which
+// SanitizeTerminalText truncates a value.
+const MaxTerminalTextLength = 255
+
+// SanitizeTerminalText makes a value from the server, eg: an attachment filename, safe to print
+// to a terminal. C0 and C1 control characters, including the ESC and CSI introducers of ANSI
+// escape sequences, are escaped as \xNN, bidirectional formatting characters that could
+// reorder the rendered text are replaced with a visible <U+NNNN> marker, invalid UTF-8 bytes
+// are escaped and values longer than MaxTerminalTextLength characters are truncated.
+//
+// Machine readable outputs like CSV and JSON should use the original value instead.
+func SanitizeTerminalText(s string) string {
+	if isTerminalSafe(s) {
+		return s
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+	for i := 0; i < len(s); {
+		if n >= MaxTerminalTextLength {
+			b.WriteString("…")
+			break
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		case isControl(r):
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case isBidiControl(r):
+			fmt.Fprintf(&b, "<U+%04X>", r)
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+		n++
+	}
+	return b.String()
+}
+
+func isTerminalSafe(s string) bool {
+	if utf8.RuneCountInString(s) > MaxTerminalTextLength {
+		return false
+	}
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return false
+			}
+		}
+		if isControl(r) || isBidiControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || (r >= 0x7f && r <= 0x9f)
+}
+
+// isBidiControl reports if r is an explicit bidirectional formatting character.
+func isBidiControl(r rune) bool {
+	switch {
+	case r == 0x061c, r == 0x200e, r == 0x200f:
+		return true
+	case r >= 0x202a && r <= 0x202e:
+		return true
+	case r >= 0x2066 && r <= 0x2069:
+		return true
+	}
+	return false
+}
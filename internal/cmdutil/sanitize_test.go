@@ -0,0 +1,93 @@
+package cmdutil
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeTerminalText(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "plain ascii",
+			input: "report.pdf",
+			want:  "report.pdf",
+		},
+		{
+			name:  "unicode text passes through",
+			input: "Übersicht 日本語 تقرير 📎.txt",
+			want:  "Übersicht 日本語 تقرير 📎.txt",
+		},
+		{
+			name:  "ansi color sequence",
+			input: "\x1b[31mred\x1b[0m.txt",
+			want:  `\x1b[31mred\x1b[0m.txt`,
+		},
+		{
+			name:  "terminal title sequence",
+			input: "a\x1b]0;pwned\x07.txt",
+			want:  `a\x1b]0;pwned\x07.txt`,
+		},
+		{
+			name:  "c1 csi introducer",
+			input: "a\u009b2Jb",
+			want:  `a\x9b2Jb`,
+		},
+		{
+			name:  "rtl override spoof",
+			input: "invoice\u202efdp.exe",
+			want:  "invoice<U+202E>fdp.exe",
+		},
+		{
+			name:  "bidi isolates",
+			input: "a\u2066b\u2069",
+			want:  "a<U+2066>b<U+2069>",
+		},
+		{
+			name:  "nul byte",
+			input: "file\x00.txt",
+			want:  `file\x00.txt`,
+		},
+		{
+			name:  "newline and tab",
+			input: "a\nb\tc",
+			want:  `a\x0ab\x09c`,
+		},
+		{
+			name:  "invalid utf-8",
+			input: "a\xffb",
+			want:  `a\xffb`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, SanitizeTerminalText(tc.input))
+		})
+	}
+}
+
+func TestSanitizeTerminalTextTruncates(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("é", MaxTerminalTextLength+10)
+	got := SanitizeTerminalText(long)
+
+	assert.Equal(t, MaxTerminalTextLength+1, utf8.RuneCountInString(got))
+	assert.True(t, strings.HasSuffix(got, "é…"))
+
+	exact := strings.Repeat("a", MaxTerminalTextLength)
+	assert.Equal(t, exact, SanitizeTerminalText(exact))
+}
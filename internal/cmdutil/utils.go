@@ -1,11 +1,13 @@
 package cmdutil
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -25,9 +27,22 @@ func ExitIfError(err error) {
 		return
 	}
 
-	var msg string
+	fmt.Fprintf(os.Stderr, "%s\n", errorMessage(err))
+	os.Exit(1)
+}
+
+func errorMessage(err error) string {
+	var (
+		msg   string
+		attEr *jira.AttachmentError
+		dryEr *jira.DryRunError
+	)
 
-	if e, ok := err.(*jira.ErrUnexpectedResponse); ok {
+	if errors.As(err, &attEr) {
+		msg = attachmentErrorMessage(attEr)
+	} else if errors.As(err, &dryEr) {
+		msg = fmt.Sprintf("%s Would send %s %s, stopped as the command doesn't support dry-run", DryRunPrefix, dryEr.Method, dryEr.URL)
+	} else if e, ok := err.(*jira.ErrUnexpectedResponse); ok {
 		dm := fmt.Sprintf(
 			"\njira: Received unexpected response '%s'.\nPlease check the parameters you supplied and try again.",
 			e.Status,
@@ -48,9 +63,19 @@ func ExitIfError(err error) {
 			msg = fmt.Sprintf("Error: %s", err.Error())
 		}
 	}
+	return msg
+}
 
-	fmt.Fprintf(os.Stderr, "%s\n", msg)
-	os.Exit(1)
+func attachmentErrorMessage(e *jira.AttachmentError) string {
+	if !e.Retryable {
+		return fmt.Sprintf("Error: %s", e.Error())
+	}
+	return fmt.Sprintf(
+		"\njira: Failed to %s attachment, received '%s' after %d attempt(s).\n"+
+			"The issue appears to be temporarily locked, eg: by a reindex or a bulk operation.\n"+
+			"Please retry later.",
+		e.Op, e.Status, e.Attempts,
+	)
 }
 
 // Info displays spinner.
@@ -74,6 +99,11 @@ func Success(msg string, args ...interface{}) {
 	_, _ = fmt.Fprintf(os.Stdout, fmt.Sprintf("\n\u001B[0;32m✓\u001B[0m %s\n", msg), args...)
 }
 
+// SuccessStderr prints success message in stderr, eg: when stdout carries the output of the command.
+func SuccessStderr(msg string, args ...interface{}) {
+	_, _ = fmt.Fprintf(os.Stderr, fmt.Sprintf("\u001B[0;32m✓\u001B[0m %s\n", msg), args...)
+}
+
 // Warn prints warning message in stderr.
 func Warn(msg string, args ...interface{}) {
 	_, _ = fmt.Fprintf(os.Stderr, fmt.Sprintf("\u001B[0;33m%s\u001B[0m\n", msg), args...)
@@ -84,6 +114,15 @@ func Fail(msg string, args ...interface{}) {
 	_, _ = fmt.Fprintf(os.Stderr, fmt.Sprintf("\u001B[0;31m✗\u001B[0m %s\n", msg), args...)
 }
 
+// DryRunPrefix prefixes the actions skipped in dry-run mode.
+const DryRunPrefix = "[dry-run]"
+
+// DryRun prints an action skipped in dry-run mode in stderr. The line is not colored
+// so that the output can be searched for the prefix.
+func DryRun(msg string, args ...interface{}) {
+	_, _ = fmt.Fprintf(os.Stderr, DryRunPrefix+" "+msg+"\n", args...)
+}
+
 // Failed prints failure message in stderr and exits.
 func Failed(msg string, args ...interface{}) {
 	Fail(msg, args...)
@@ -106,6 +145,48 @@ func GenerateServerBrowseURL(server, key string) string {
 	return fmt.Sprintf("%s/browse/%s", server, key)
 }
 
+// IssueURLFunc returns the URL to display for the given issue key.
+type IssueURLFunc func(key string) string
+
+// IssueURLResolver returns a func that resolves the URL to display for an issue.
+//
+// The setting is the value of `output.short_urls` config. It can either be a boolean,
+// in which case the issue key is displayed instead of the browse URL, or a text/template
+// such as `https://go/jira/{{.Key}}`. The short flag enables short URLs regardless of
+// the config. The template is validated right away so that the error is reported
+// before doing any network operations.
+func IssueURLResolver(server string, short bool, setting string) (IssueURLFunc, error) {
+	setting = strings.TrimSpace(setting)
+
+	enabled, err := strconv.ParseBool(setting)
+	isTemplate := err != nil && setting != ""
+
+	if !isTemplate && !enabled && !short {
+		return func(key string) string {
+			return GenerateServerBrowseURL(server, key)
+		}, nil
+	}
+	if !isTemplate {
+		return func(key string) string { return key }, nil
+	}
+
+	tmpl, err := template.New("short_url").Option("missingkey=error").Parse(setting)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output.short_urls template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, struct{ Key string }{Key: "KEY-1"}); err != nil {
+		return nil, fmt.Errorf("invalid output.short_urls template: %w", err)
+	}
+
+	return func(key string) string {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, struct{ Key string }{Key: key}); err != nil {
+			return key
+		}
+		return b.String()
+	}, nil
+}
+
 // FormatDateTimeHuman formats date time in human readable format.
 func FormatDateTimeHuman(dt, format string) string {
 	t, err := time.Parse(format, dt)
@@ -216,3 +297,15 @@ func GetTUIStyleConfig() tui.TableStyle {
 		SelectionTextIsBold: bold,
 	}
 }
+
+// AuthorName returns the display name of the user, or "-" if the server didn't send
+// the author, eg: for attachments of archived issues.
+func AuthorName(u jira.User) string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name != "" {
+		return u.Name
+	}
+	return "-"
+}
@@ -264,3 +264,101 @@ func TestGetSubtaskHandle(t *testing.T) {
 		})
 	}
 }
+
+func TestAttachmentErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	locked := &jira.AttachmentError{
+		Op:         jira.AttachmentOpUpload,
+		Status:     "423 Locked",
+		StatusCode: 423,
+		Attempts:   3,
+		Retryable:  true,
+	}
+	msg := attachmentErrorMessage(locked)
+	assert.Contains(t, msg, "Failed to upload attachment, received '423 Locked' after 3 attempt(s)")
+	assert.Contains(t, msg, "temporarily locked")
+	assert.Contains(t, msg, "retry later")
+
+	forbidden := &jira.AttachmentError{
+		Op:         jira.AttachmentOpDelete,
+		Status:     "403 Forbidden",
+		StatusCode: 403,
+		Attempts:   1,
+	}
+	msg = attachmentErrorMessage(forbidden)
+	assert.Equal(t, "Error: failed to delete attachment: 403 Forbidden", msg)
+	assert.NotContains(t, msg, "temporarily locked")
+}
+
+func TestIssueURLResolver(t *testing.T) {
+	cases := []struct {
+		name     string
+		short    bool
+		setting  string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "default is the browse url",
+			expected: "https://example.com/browse/TEST-1",
+		},
+		{
+			name:     "disabled in config",
+			setting:  "false",
+			expected: "https://example.com/browse/TEST-1",
+		},
+		{
+			name:     "bare key via flag",
+			short:    true,
+			expected: "TEST-1",
+		},
+		{
+			name:     "bare key via config",
+			setting:  "true",
+			expected: "TEST-1",
+		},
+		{
+			name:     "flag overrides disabled config",
+			short:    true,
+			setting:  "false",
+			expected: "TEST-1",
+		},
+		{
+			name:     "valid template",
+			setting:  "https://go/jira/{{.Key}}",
+			expected: "https://go/jira/TEST-1",
+		},
+		{
+			name:    "invalid template syntax",
+			setting: "https://go/jira/{{.Key",
+			wantErr: true,
+		},
+		{
+			name:    "invalid template field",
+			setting: "https://go/jira/{{.Issue}}",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn, err := IssueURLResolver("https://example.com", tc.short, tc.setting)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, fn)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, fn("TEST-1"))
+		})
+	}
+}
+
+func TestAuthorName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Jane Doe", AuthorName(jira.User{Name: "jane", DisplayName: "Jane Doe"}))
+	assert.Equal(t, "jane", AuthorName(jira.User{Name: "jane"}))
+	assert.Equal(t, "-", AuthorName(jira.User{}))
+}
@@ -0,0 +1,90 @@
+package cmdutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// UTF8BOM is the byte order mark Excel needs to read a CSV file as UTF-8.
+var UTF8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// excelWriter writes CSV for Excel, see NewExcelWriter.
+type excelWriter struct {
+	w       io.Writer
+	started bool
+	lastCR  bool
+}
+
+// NewExcelWriter returns a writer that prepends a UTF-8 BOM to the output and
+// converts LF line endings to CRLF, so that Excel opens non-ASCII text correctly
+// instead of assuming the system codepage. Existing CRLF endings are kept as is.
+func NewExcelWriter(w io.Writer) io.Writer {
+	return &excelWriter{w: w}
+}
+
+// Write implements io.Writer.
+func (e *excelWriter) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+	if !e.started {
+		buf.Write(UTF8BOM)
+		e.started = true
+	}
+	for _, b := range p {
+		if b == '\n' && !e.lastCR {
+			buf.WriteByte('\r')
+		}
+		buf.WriteByte(b)
+		e.lastCR = b == '\r'
+	}
+	if _, err := e.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// asciiWriter escapes non-ASCII characters, see NewASCIIWriter.
+type asciiWriter struct {
+	w       io.Writer
+	pending []byte
+}
+
+// NewASCIIWriter returns a writer that escapes non-ASCII characters in JSON output as \u
+// sequences, using surrogate pairs outside the basic multilingual plane. JSON only allows
+// non-ASCII characters in strings, so the output stays valid JSON with the same values.
+// A character split across writes is held back until it is complete.
+func NewASCIIWriter(w io.Writer) io.Writer {
+	return &asciiWriter{w: w}
+}
+
+// Write implements io.Writer.
+func (a *asciiWriter) Write(p []byte) (int, error) {
+	in := append(a.pending, p...)
+	a.pending = nil
+
+	var buf bytes.Buffer
+	for len(in) > 0 {
+		if in[0] < utf8.RuneSelf {
+			buf.WriteByte(in[0])
+			in = in[1:]
+			continue
+		}
+		if !utf8.FullRune(in) {
+			a.pending = append([]byte(nil), in...)
+			break
+		}
+		r, size := utf8.DecodeRune(in)
+		in = in[size:]
+		if r > 0xFFFF {
+			r -= 0x10000
+			fmt.Fprintf(&buf, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+			continue
+		}
+		fmt.Fprintf(&buf, `\u%04x`, r)
+	}
+	if _, err := a.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,57 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcelWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := NewExcelWriter(&buf)
+
+	_, err := io.WriteString(w, "ID,FILENAME\n10000,報告書.pdf\r")
+	require.NoError(t, err)
+	// The CR of a CRLF ending split across writes is not doubled.
+	_, err = io.WriteString(w, "\n10001,notes.txt\n")
+	require.NoError(t, err)
+
+	assert.Equal(t, UTF8BOM, buf.Bytes()[:3])
+	assert.Equal(t, "ID,FILENAME\r\n10000,報告書.pdf\r\n10001,notes.txt\r\n", buf.String()[3:])
+}
+
+func TestASCIIWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(NewASCIIWriter(&buf))
+	require.NoError(t, enc.Encode(map[string]string{"file": "報告書.pdf", "emoji": "😀", "plain": "a.txt"}))
+
+	assert.Equal(t, `{"emoji":"\ud83d\ude00","file":"\u5831\u544a\u66f8.pdf","plain":"a.txt"}`+"\n", buf.String())
+
+	var decoded map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "報告書.pdf", decoded["file"])
+	assert.Equal(t, "😀", decoded["emoji"])
+}
+
+func TestASCIIWriterSplitRune(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := NewASCIIWriter(&buf)
+
+	b := []byte(`"報"`)
+	for i := range b {
+		n, err := w.Write(b[i : i+1])
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+	assert.Equal(t, `"\u5831"`, buf.String())
+}
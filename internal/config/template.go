@@ -0,0 +1,225 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/atomicfile"
+)
+
+// TemplatesKey is the config key of the attachment upload templates.
+const TemplatesKey = "attachment.templates"
+
+// ErrTemplateNotFound denotes that no template of the name is defined in the config.
+var ErrTemplateNotFound = errors.New("template not found")
+
+var templateName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// AttachmentTemplate is a named set of files uploaded together by attachment add --template,
+// eg: the reports attached to the QA ticket of every sprint. The paths and the comment may
+// use the {{.IssueKey}} variable, the paths env vars as well.
+type AttachmentTemplate struct {
+	Files   []string `mapstructure:"files" yaml:"files"`
+	Comment string   `mapstructure:"comment" yaml:"comment,omitempty"`
+}
+
+// TemplateVars are the variables available to the paths and the comment of a template.
+type TemplateVars struct {
+	IssueKey string
+}
+
+// Validate checks that the template has files and that its paths and comment are valid
+// templates.
+func (t AttachmentTemplate) Validate() error {
+	if len(t.Files) == 0 {
+		return errors.New("at least one file is required")
+	}
+	for _, f := range append([]string{t.Comment}, t.Files...) {
+		if _, err := parseTemplate(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expand returns the paths and the comment of the template for the variables. Env vars
+// of the paths, eg: $CI_PROJECT_DIR or ${CI_PROJECT_DIR}, are expanded with getenv.
+func (t AttachmentTemplate) Expand(vars TemplateVars, getenv func(string) string) ([]string, string, error) {
+	files := make([]string, 0, len(t.Files))
+	for _, f := range t.Files {
+		path, err := execTemplate(f, vars)
+		if err != nil {
+			return nil, "", err
+		}
+		files = append(files, os.Expand(path, getenv))
+	}
+	comment, err := execTemplate(t.Comment, vars)
+	if err != nil {
+		return nil, "", err
+	}
+	return files, comment, nil
+}
+
+func parseTemplate(s string) (*template.Template, error) {
+	return template.New("").Option("missingkey=error").Parse(s)
+}
+
+func execTemplate(s string, vars TemplateVars) (string, error) {
+	tmpl, err := parseTemplate(s)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ValidTemplateName checks that the name can be used as a config key, ie: lowercase
+// letters, digits, dashes and underscores.
+func ValidTemplateName(name string) error {
+	if !templateName.MatchString(name) {
+		return fmt.Errorf("invalid template name %q, use lowercase letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// AttachmentTemplates reads the templates defined in the config file.
+func AttachmentTemplates(path string) (map[string]AttachmentTemplate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Attachment struct {
+			Templates map[string]AttachmentTemplate `yaml:"templates"`
+		} `yaml:"attachment"`
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, err
+	}
+	return cfg.Attachment.Templates, nil
+}
+
+// TemplateNames returns the names of the templates, sorted.
+func TemplateNames(templates map[string]AttachmentTemplate) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SaveAttachmentTemplate adds the template to the config file, or replaces the one of the
+// same name. The rest of the file is kept as is.
+func SaveAttachmentTemplate(path, name string, t AttachmentTemplate) error {
+	var value yaml.Node
+	if err := value.Encode(t); err != nil {
+		return err
+	}
+	return editConfig(path, func(root *yaml.Node) error {
+		templates := mappingAt(root, strings.Split(TemplatesKey, ".")...)
+		if _, i := lookup(templates, name); i >= 0 {
+			templates.Content[i+1] = &value
+			return nil
+		}
+		templates.Content = append(templates.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, &value)
+		return nil
+	})
+}
+
+// RemoveAttachmentTemplate removes the template from the config file. It fails with
+// ErrTemplateNotFound if there is no template of the name.
+func RemoveAttachmentTemplate(path, name string) error {
+	return editConfig(path, func(root *yaml.Node) error {
+		attachment, _ := lookup(root, "attachment")
+		templates, _ := lookup(attachment, "templates")
+		_, i := lookup(templates, name)
+		if i < 0 {
+			return ErrTemplateNotFound
+		}
+		templates.Content = append(templates.Content[:i], templates.Content[i+2:]...)
+		return nil
+	})
+}
+
+// editConfig applies fn to the root mapping of the yaml config file and writes it back. The
+// config is replaced atomically, so that a crash while writing never leaves it truncated.
+func editConfig(path string, fn func(root *yaml.Node) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("unable to edit %s: the config is not a mapping", path)
+	}
+	if err := fn(root); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(4)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, out.Bytes(), info.Mode().Perm())
+}
+
+// lookup returns the value of the key of a mapping node and the index of the key, -1 if the
+// key is not set or the node isn't a mapping.
+func lookup(node *yaml.Node, key string) (*yaml.Node, int) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1], i
+		}
+	}
+	return nil, -1
+}
+
+// mappingAt returns the mapping at the path of keys, created if missing. A value of the path
+// that isn't a mapping is replaced.
+func mappingAt(node *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		next, i := lookup(node, key)
+		switch {
+		case i < 0:
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, next)
+		case next.Kind != yaml.MappingNode:
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content[i+1] = next
+		}
+		node = next
+	}
+	return node
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentTemplateExpand(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"CI_PROJECT_DIR": "/builds/qa"}
+	tmpl := AttachmentTemplate{
+		Files:   []string{"reports/test-report.html", "$CI_PROJECT_DIR/{{.IssueKey}}.log", "${CI_PROJECT_DIR}/$UNSET/a.zip"},
+		Comment: "QA artifacts for {{.IssueKey}} in $CI_PROJECT_DIR",
+	}
+
+	files, comment, err := tmpl.Expand(TemplateVars{IssueKey: "TEST-1"}, func(k string) string { return env[k] })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reports/test-report.html", "/builds/qa/TEST-1.log", "/builds/qa//a.zip"}, files)
+	assert.Equal(t, "QA artifacts for TEST-1 in $CI_PROJECT_DIR", comment, "env vars are only expanded in paths")
+
+	_, _, err = AttachmentTemplate{Files: []string{"{{.Sprint}}.zip"}}.Expand(TemplateVars{IssueKey: "TEST-1"}, os.Getenv)
+	assert.Error(t, err)
+}
+
+func TestAttachmentTemplateValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, AttachmentTemplate{Files: []string{"a.txt"}, Comment: "for {{.IssueKey}}"}.Validate())
+	assert.EqualError(t, AttachmentTemplate{}.Validate(), "at least one file is required")
+	assert.Error(t, AttachmentTemplate{Files: []string{"{{.IssueKey"}}.Validate())
+	assert.Error(t, AttachmentTemplate{Files: []string{"a.txt"}, Comment: "{{end}}"}.Validate())
+}
+
+func TestValidTemplateName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"qa-report", "qa_report2", "7"} {
+		assert.NoError(t, ValidTemplateName(name), name)
+	}
+	for _, name := range []string{"", "QA", "qa.report", "-qa", "qa report"} {
+		assert.Error(t, ValidTemplateName(name), name)
+	}
+}
+
+func TestSaveAndRemoveAttachmentTemplate(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".config.yml")
+	config := "server: https://jira.example.com\n# keep me\nattachment:\n    issue_timeout: 30s\n"
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+
+	qa := AttachmentTemplate{Files: []string{"reports/test-report.html", "reports/coverage.xml"}, Comment: "QA artifacts for {{.IssueKey}}"}
+	require.NoError(t, SaveAttachmentTemplate(path, "qa-report", qa))
+	require.NoError(t, SaveAttachmentTemplate(path, "logs", AttachmentTemplate{Files: []string{"app.log"}}))
+
+	templates, err := AttachmentTemplates(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]AttachmentTemplate{"qa-report": qa, "logs": {Files: []string{"app.log"}}}, templates)
+	assert.Equal(t, []string{"logs", "qa-report"}, TemplateNames(templates))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "server: https://jira.example.com")
+	assert.Contains(t, string(b), "# keep me")
+	assert.Contains(t, string(b), "issue_timeout: 30s")
+
+	// Saving again replaces the template.
+	require.NoError(t, SaveAttachmentTemplate(path, "logs", AttachmentTemplate{Files: []string{"other.log"}}))
+	templates, err = AttachmentTemplates(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other.log"}, templates["logs"].Files)
+
+	require.NoError(t, RemoveAttachmentTemplate(path, "qa-report"))
+	assert.ErrorIs(t, RemoveAttachmentTemplate(path, "qa-report"), ErrTemplateNotFound)
+
+	templates, err = AttachmentTemplates(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs"}, TemplateNames(templates))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "the mode of the config is kept")
+}
+
+func TestSaveAttachmentTemplateEmptyConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".config.yml")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	require.NoError(t, SaveAttachmentTemplate(path, "logs", AttachmentTemplate{Files: []string{"app.log"}}))
+	templates, err := AttachmentTemplates(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]AttachmentTemplate{"logs": {Files: []string{"app.log"}}}, templates)
+
+	assert.ErrorIs(t, RemoveAttachmentTemplate(filepath.Join(t.TempDir(), ".config.yml"), "logs"), os.ErrNotExist)
+}
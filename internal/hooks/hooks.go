@@ -0,0 +1,206 @@
+// Package hooks runs user configured shell commands around attachment transfers.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the time a hook is allowed to run if no timeout is configured.
+	DefaultTimeout = 5 * time.Minute
+
+	// waitDelay is the time to wait for the output of a killed hook, eg: if
+	// it started a child process that keeps the output pipes open.
+	waitDelay = time.Second
+)
+
+// Environment variables passed to attachment hooks.
+const (
+	EnvPhase          = "JIRA_HOOK_PHASE"
+	EnvIssueKey       = "JIRA_ISSUE_KEY"
+	EnvAttachmentFile = "JIRA_ATTACHMENT_FILE"
+	EnvAttachmentName = "JIRA_ATTACHMENT_NAME"
+	EnvAttachmentID   = "JIRA_ATTACHMENT_ID"
+	EnvAttachmentURL  = "JIRA_ATTACHMENT_URL"
+	EnvAttachmentSize = "JIRA_ATTACHMENT_SIZE"
+	EnvAttachmentType = "JIRA_ATTACHMENT_MIMETYPE"
+	EnvAuthor         = "JIRA_ATTACHMENT_AUTHOR"
+)
+
+// Env is a set of variables added to the environment of a hook.
+type Env map[string]string
+
+// Hook is a shell command.
+type Hook struct {
+	// Name identifies the hook in its output and errors, eg: pre-upload.
+	Name string
+	// Command is run with sh -c, or cmd /C on Windows.
+	Command string
+	// Timeout is the time after which the hook is killed, defaults to DefaultTimeout.
+	Timeout time.Duration
+	// BestEffort hooks only print a warning when they fail.
+	BestEffort bool
+	// Stdout and Stderr receive the output of the hook, prefixed with its name.
+	// Output is discarded if they are nil.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Error is returned when a hook fails.
+type Error struct {
+	Hook     string
+	ExitCode int
+	TimedOut bool
+	Timeout  time.Duration
+	Err      error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.TimedOut:
+		return fmt.Sprintf("%s hook timed out after %s", e.Hook, e.Timeout)
+	case e.ExitCode > 0:
+		return fmt.Sprintf("%s hook exited with status %d", e.Hook, e.ExitCode)
+	default:
+		return fmt.Sprintf("%s hook failed: %s", e.Hook, e.Err)
+	}
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Enabled reports if a command is configured.
+func (h Hook) Enabled() bool {
+	return strings.TrimSpace(h.Command) != ""
+}
+
+// Run runs the hook with env added to the environment of the current process.
+// It returns an *Error if the hook exits with a non-zero status or times out,
+// unless the hook is best-effort in which case a warning is printed instead.
+func (h Hook) Run(ctx context.Context, env Env) error {
+	if !h.Enabled() {
+		return nil
+	}
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout, stderr := newPrefixWriter(h.Stdout, h.Name), newPrefixWriter(h.Stderr, h.Name)
+
+	cmd := shellCommand(ctx, h.Command)
+	cmd.Env = append(os.Environ(), env.list()...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+	stdout.flush()
+	stderr.flush()
+
+	if err == nil {
+		return nil
+	}
+
+	herr := &Error{Hook: h.Name, ExitCode: -1, Timeout: timeout, Err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		herr.ExitCode = exitErr.ExitCode()
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		herr.TimedOut = true
+	}
+
+	if h.BestEffort {
+		if h.Stderr != nil {
+			fmt.Fprintf(h.Stderr, "Warning: %s\n", herr)
+		}
+		return nil
+	}
+	return herr
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+func (e Env) list() []string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, k+"="+e[k])
+	}
+	return out
+}
+
+// prefixWriter prefixes every line written to it.
+type prefixWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, name string) *prefixWriter {
+	if w == nil {
+		w = io.Discard
+	}
+	return &prefixWriter{w: w, prefix: []byte("[" + name + "] ")}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// flush writes a trailing line without a newline.
+func (p *prefixWriter) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) > 0 {
+		_ = p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	_, err := p.w.Write(append(append([]byte{}, p.prefix...), line...))
+	return err
+}
@@ -0,0 +1,139 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use sh")
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	skipOnWindows(t)
+
+	cases := []struct {
+		name       string
+		command    string
+		bestEffort bool
+		wantErr    string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "success",
+			command:    `echo "scanning $JIRA_FILE"; printf 'clean'`,
+			wantStdout: "[pre-upload] scanning report.pdf\n[pre-upload] clean\n",
+		},
+		{
+			name:       "non-zero exit",
+			command:    `echo "infected" >&2; exit 3`,
+			wantErr:    "pre-upload hook exited with status 3",
+			wantCode:   3,
+			wantStderr: "[pre-upload] infected\n",
+		},
+		{
+			name:       "best effort",
+			command:    "exit 1",
+			bestEffort: true,
+			wantStderr: "Warning: pre-upload hook exited with status 1\n",
+		},
+		{
+			name: "disabled",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			h := Hook{
+				Name:       "pre-upload",
+				Command:    tc.command,
+				BestEffort: tc.bestEffort,
+				Stdout:     &stdout,
+				Stderr:     &stderr,
+			}
+
+			err := h.Run(context.Background(), Env{"JIRA_FILE": "report.pdf"})
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+
+				var herr *Error
+				assert.True(t, errors.As(err, &herr))
+				assert.Equal(t, tc.wantCode, herr.ExitCode)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantStdout, stdout.String())
+			assert.Equal(t, tc.wantStderr, stderr.String())
+		})
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	t.Parallel()
+	skipOnWindows(t)
+
+	var stdout bytes.Buffer
+	h := Hook{
+		Name:    "post-upload",
+		Command: `echo "$JIRA_ISSUE_KEY $JIRA_ATTACHMENT_ID"; test -n "$PATH"`,
+		Stdout:  &stdout,
+	}
+
+	err := h.Run(context.Background(), Env{"JIRA_ISSUE_KEY": "TEST-1", "JIRA_ATTACHMENT_ID": "10001"})
+	assert.NoError(t, err)
+	assert.Equal(t, "[post-upload] TEST-1 10001\n", stdout.String())
+}
+
+func TestRunTimeout(t *testing.T) {
+	t.Parallel()
+	skipOnWindows(t)
+
+	h := Hook{Name: "pre-upload", Command: "sleep 10", Timeout: 100 * time.Millisecond}
+
+	start := time.Now()
+	err := h.Run(context.Background(), nil)
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.EqualError(t, err, "pre-upload hook timed out after 100ms")
+
+	var herr *Error
+	assert.True(t, errors.As(err, &herr))
+	assert.True(t, herr.TimedOut)
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := newPrefixWriter(&out, "hook")
+
+	_, _ = w.Write([]byte("first li"))
+	_, _ = w.Write([]byte("ne\nsecond\nthi"))
+	w.flush()
+
+	assert.Equal(t, "[hook] first line\n[hook] second\n[hook] thi\n", out.String())
+}
+
+func TestEnvList(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"A=1", "B=2"}, Env{"B": "2", "A": "1"}.list())
+}
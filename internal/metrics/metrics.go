@@ -0,0 +1,210 @@
+// Package metrics writes the outcome of attachment runs in the Prometheus text format,
+// eg: for the textfile collector of node-exporter.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/internal/notify"
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
+)
+
+// Metric types of the text format.
+const (
+	TypeCounter = "counter"
+	TypeGauge   = "gauge"
+)
+
+// Names of the metrics of a run.
+const (
+	NameProcessed = "jira_cli_attachments_processed_total"
+	NameBytes     = "jira_cli_attachment_bytes_transferred_total"
+	NameFailures  = "jira_cli_attachment_failures_total"
+	NameDuration  = "jira_cli_attachment_run_duration_seconds"
+)
+
+// ReasonUnknown is the reason of the failures a summary doesn't explain.
+const ReasonUnknown = "unknown"
+
+// fileMode lets the collector, which usually runs as another user, read the file.
+const fileMode os.FileMode = 0o644
+
+// Label is a label of a sample.
+type Label struct {
+	Name, Value string
+}
+
+// Sample is a value of a metric with its labels.
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Family is a metric with its help text, type and samples.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// FromSummary returns the metrics of a run. Counters count the run only, like those of
+// a process that was just started.
+func FromSummary(s notify.Summary) []Family {
+	processed := Family{
+		Name: NameProcessed,
+		Help: "Attachments transferred or deleted by the last run, by operation.",
+		Type: TypeCounter,
+	}
+	if s.Op != "" {
+		processed.Samples = []Sample{{Labels: []Label{{"op", string(s.Op)}}, Value: float64(s.Done)}}
+	}
+
+	transferred := Family{
+		Name: NameBytes,
+		Help: "Bytes of attachments transferred by the last run, by direction.",
+		Type: TypeCounter,
+	}
+	if dir := direction(s.Op); dir != "" {
+		transferred.Samples = []Sample{{Labels: []Label{{"direction", dir}}, Value: float64(s.Bytes)}}
+	}
+
+	failures := Family{
+		Name: NameFailures,
+		Help: "Attachments the last run failed to process or didn't attempt, by reason.",
+		Type: TypeCounter,
+	}
+	for _, r := range failureReasons(s) {
+		failures.Samples = append(failures.Samples, Sample{Labels: []Label{{"reason", r.name}}, Value: float64(r.count)})
+	}
+
+	duration := Family{
+		Name:    NameDuration,
+		Help:    "Duration of the last run in seconds.",
+		Type:    TypeGauge,
+		Samples: []Sample{{Value: s.Elapsed.Seconds()}},
+	}
+
+	return []Family{processed, transferred, failures, duration}
+}
+
+func direction(op notify.Op) string {
+	switch op {
+	case notify.OpDownload:
+		return "in"
+	case notify.OpUpload:
+		return "out"
+	}
+	return ""
+}
+
+type reason struct {
+	name  string
+	count int
+}
+
+// failureReasons returns the failures of the summary by reason, sorted by name. The
+// failures without a reason are counted as unknown.
+func failureReasons(s notify.Summary) []reason {
+	counts := make(map[string]int, len(s.Failures)+1)
+	explained := 0
+	for name, n := range s.Failures {
+		if n <= 0 {
+			continue
+		}
+		if name == "" {
+			name = ReasonUnknown
+		}
+		counts[name] += n
+		explained += n
+	}
+	if s.Failed > explained {
+		counts[ReasonUnknown] += s.Failed - explained
+	}
+
+	reasons := make([]reason, 0, len(counts))
+	for name, n := range counts {
+		reasons = append(reasons, reason{name: name, count: n})
+	}
+	sort.Slice(reasons, func(i, j int) bool { return reasons[i].name < reasons[j].name })
+	return reasons
+}
+
+// Write writes the families in the Prometheus text format.
+func Write(w io.Writer, families []Family) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		if f.Help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		}
+		if f.Type != "" {
+			fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Type)
+		}
+		for _, s := range f.Samples {
+			bw.WriteString(f.Name)
+			if len(s.Labels) > 0 {
+				bw.WriteByte('{')
+				for i, l := range s.Labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, `%s="%s"`, l.Name, escapeLabelValue(l.Value))
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(formatValue(s.Value))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteFile writes the families to path atomically: they are written to a temporary file
+// in the same directory which is then renamed, so that a collector never reads a partial
+// file. The temporary file is named so that the textfile collector, which only reads
+// *.prom files, ignores it.
+func WriteFile(path string, families []Family) (err error) {
+	f, err := tmpfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	if err := Write(f, families); err != nil {
+		return err
+	}
+	if err := f.Chmod(fileMode); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Clean(path))
+}
+
+// escapeHelp escapes a help text, backslashes and line feeds.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeLabelValue escapes a label value, backslashes, double quotes and line feeds.
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/internal/notify"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	families := []Family{
+		{
+			Name: "test_total",
+			Help: "A help text with a \\ and a\nline feed.",
+			Type: TypeCounter,
+			Samples: []Sample{
+				{Labels: []Label{{"reason", `a "quoted" \ value` + "\n"}}, Value: 3},
+				{Labels: []Label{{"a", "1"}, {"b", "2"}}, Value: 0.5},
+			},
+		},
+		{
+			Name:    "test_seconds",
+			Type:    TypeGauge,
+			Samples: []Sample{{Value: 1e-3}},
+		},
+		{
+			Name: "test_empty",
+			Help: "No samples.",
+			Type: TypeCounter,
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, families))
+	assert.Equal(t, `# HELP test_total A help text with a \\ and a\nline feed.
+# TYPE test_total counter
+test_total{reason="a \"quoted\" \\ value\n"} 3
+test_total{a="1",b="2"} 0.5
+# TYPE test_seconds gauge
+test_seconds 0.001
+# HELP test_empty No samples.
+# TYPE test_empty counter
+`, buf.String())
+}
+
+func TestFromSummary(t *testing.T) {
+	t.Parallel()
+
+	t.Run("download", func(t *testing.T) {
+		t.Parallel()
+
+		s := notify.Summary{
+			Op:       notify.OpDownload,
+			Total:    10,
+			Done:     4,
+			Failed:   5,
+			Bytes:    2048,
+			Elapsed:  1500 * time.Millisecond,
+			Failures: map[string]int{"timeout": 1, "not_attempted": 2},
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, FromSummary(s)))
+		assert.Equal(t, `# HELP jira_cli_attachments_processed_total Attachments transferred or deleted by the last run, by operation.
+# TYPE jira_cli_attachments_processed_total counter
+jira_cli_attachments_processed_total{op="download"} 4
+# HELP jira_cli_attachment_bytes_transferred_total Bytes of attachments transferred by the last run, by direction.
+# TYPE jira_cli_attachment_bytes_transferred_total counter
+jira_cli_attachment_bytes_transferred_total{direction="in"} 2048
+# HELP jira_cli_attachment_failures_total Attachments the last run failed to process or didn't attempt, by reason.
+# TYPE jira_cli_attachment_failures_total counter
+jira_cli_attachment_failures_total{reason="not_attempted"} 2
+jira_cli_attachment_failures_total{reason="timeout"} 1
+jira_cli_attachment_failures_total{reason="unknown"} 2
+# HELP jira_cli_attachment_run_duration_seconds Duration of the last run in seconds.
+# TYPE jira_cli_attachment_run_duration_seconds gauge
+jira_cli_attachment_run_duration_seconds 1.5
+`, buf.String())
+	})
+
+	t.Run("upload is outgoing", func(t *testing.T) {
+		t.Parallel()
+
+		families := FromSummary(notify.Summary{Op: notify.OpUpload, Total: 1, Done: 1, Bytes: 5})
+		require.Len(t, families[1].Samples, 1)
+		assert.Equal(t, []Label{{"direction", "out"}}, families[1].Samples[0].Labels)
+		assert.Empty(t, families[2].Samples, "no failures")
+	})
+
+	t.Run("delete transfers nothing", func(t *testing.T) {
+		t.Parallel()
+
+		families := FromSummary(notify.Summary{Op: notify.OpDelete, Total: 2, Done: 2})
+		assert.Equal(t, []Sample{{Labels: []Label{{"op", "delete"}}, Value: 2}}, families[0].Samples)
+		assert.Empty(t, families[1].Samples)
+	})
+}
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jira.prom")
+	require.NoError(t, os.WriteFile(path, []byte("stale"), 0o600))
+
+	require.NoError(t, WriteFile(path, FromSummary(notify.Summary{Op: notify.OpDownload, Total: 1, Done: 1})))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `jira_cli_attachments_processed_total{op="download"} 1`)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, fileMode, info.Mode().Perm(), "the collector can read the file")
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left")
+}
+
+func TestWriteFileMissingDir(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "missing")
+	err := WriteFile(filepath.Join(dir, "jira.prom"), nil)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
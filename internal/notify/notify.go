@@ -0,0 +1,213 @@
+// Package notify sends a desktop notification when a long attachment run completes.
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/internal/cmdutil"
+)
+
+// DefaultThreshold is how long a run must take before its completion is notified.
+const DefaultThreshold = 30 * time.Second
+
+// appName is the application the notifications are sent as.
+const appName = "jira"
+
+// Op is a transfer operation.
+type Op string
+
+// Operations summarized in notifications.
+const (
+	OpUpload   Op = "upload"
+	OpDownload Op = "download"
+	OpDelete   Op = "delete"
+)
+
+// title is the operation capitalized, eg: Upload.
+func (o Op) title() string {
+	if o == "" {
+		return ""
+	}
+	return strings.ToUpper(string(o[:1])) + string(o[1:])
+}
+
+// past is the operation in the past tense, eg: Uploaded.
+func (o Op) past() string {
+	return strings.TrimSuffix(o.title(), "e") + "ed"
+}
+
+// Summary is the outcome of a run.
+type Summary struct {
+	Op Op
+	// Target is what the files were transferred to or from, eg: an issue key.
+	Target string
+	// Total is the number of files in the run.
+	Total int
+	// Done is the number of files transferred.
+	Done int
+	// Failed is the number of files that failed or weren't attempted.
+	Failed int
+	// Failures breaks Failed down by reason, eg: timeout or not_attempted. It may be
+	// incomplete, the failures without a reason are unknown.
+	Failures map[string]int
+	// Bytes is the size of the files transferred, not shown if 0.
+	Bytes int64
+	// Elapsed is how long the run took.
+	Elapsed time.Duration
+}
+
+// Message composes the title and body of the notification of a run.
+func Message(s Summary) (string, string) {
+	title := s.Op.title()
+	switch {
+	case s.Failed == 0:
+		title += " finished"
+	case s.Done == 0:
+		title += " failed"
+	default:
+		title += " finished with failures"
+	}
+
+	var b strings.Builder
+	b.WriteString(s.Op.past())
+	b.WriteString(" ")
+	if s.Failed > 0 {
+		fmt.Fprintf(&b, "%d of ", s.Done)
+	}
+	b.WriteString(plural(s.Total, "file"))
+	if s.Bytes > 0 {
+		fmt.Fprintf(&b, " (%s)", cmdutil.FormatSize(s.Bytes))
+	}
+	if s.Target != "" {
+		if s.Op == OpDownload || s.Op == OpDelete {
+			b.WriteString(" from ")
+		} else {
+			b.WriteString(" to ")
+		}
+		b.WriteString(s.Target)
+	}
+	if s.Elapsed > 0 {
+		fmt.Fprintf(&b, " in %s", s.Elapsed.Round(time.Second))
+	}
+	if s.Failed > 0 {
+		fmt.Fprintf(&b, ", %d failed", s.Failed)
+	}
+	return title, b.String()
+}
+
+// Command returns the command that shows a notification on the given OS, false if
+// notifications aren't supported on it.
+func Command(goos, title, body string) (string, []string, bool) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		return "osascript", []string{"-e", script}, true
+	case "windows":
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", toastScript(title, body)}, true
+	case "linux", "freebsd", "netbsd", "openbsd", "dragonfly":
+		return "notify-send", []string{"--app-name", appName, title, body}, true
+	}
+	return "", nil, false
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ", "\r", " ")
+	return `"` + r.Replace(s) + `"`
+}
+
+// toastScript is a PowerShell script that shows a toast notification.
+func toastScript(title, body string) string {
+	xml := fmt.Sprintf(`<toast><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>`,
+		xmlEscape(title), xmlEscape(body))
+
+	return strings.Join([]string{
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] > $null",
+		"$xml = New-Object Windows.Data.Xml.Dom.XmlDocument",
+		"$xml.LoadXml(" + powerShellString(xml) + ")",
+		"$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(" + powerShellString(appName) + ").Show($toast)",
+	}, "; ")
+}
+
+// powerShellString quotes s as a PowerShell single-quoted string literal.
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
+
+// Runner runs a command, it is exec outside of tests.
+type Runner func(name string, args ...string) error
+
+func execRunner(name string, args ...string) error {
+	return exec.Command(name, args...).Run()
+}
+
+// Notifier notifies the completion of a run that took longer than its threshold.
+type Notifier struct {
+	enabled   bool
+	threshold time.Duration
+	goos      string
+	run       Runner
+	now       func() time.Time
+	start     time.Time
+	listeners []Listener
+}
+
+// Listener receives the summary of every run, whether it is notified or not, eg: to
+// export metrics.
+type Listener func(Summary)
+
+// New returns a notifier for a run starting now. It does nothing unless enabled.
+// A threshold of 0 notifies every run.
+func New(enabled bool, threshold time.Duration) *Notifier {
+	return NewWith(enabled, threshold, runtime.GOOS, execRunner, time.Now)
+}
+
+// NewWith is New with the OS, the command runner and the clock given.
+func NewWith(enabled bool, threshold time.Duration, goos string, run Runner, now func() time.Time) *Notifier {
+	return &Notifier{enabled: enabled, threshold: threshold, goos: goos, run: run, now: now, start: now()}
+}
+
+// Listen registers a listener of the outcome of the run.
+func (n *Notifier) Listen(l Listener) {
+	n.listeners = append(n.listeners, l)
+}
+
+// Done passes the outcome of the run to the listeners, and notifies it if it took at
+// least the threshold. The elapsed time of the summary is set by the notifier. Failures
+// to notify are ignored, a missing notify-send must not fail a transfer that succeeded.
+func (n *Notifier) Done(s Summary) {
+	if n == nil {
+		return
+	}
+	s.Elapsed = n.now().Sub(n.start)
+	for _, l := range n.listeners {
+		l(s)
+	}
+	if !n.enabled || s.Elapsed < n.threshold {
+		return
+	}
+
+	title, body := Message(s)
+	name, args, ok := Command(n.goos, title, body)
+	if !ok {
+		return
+	}
+	_ = n.run(name, args...)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
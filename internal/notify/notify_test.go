@@ -0,0 +1,232 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		summary   Summary
+		wantTitle string
+		wantBody  string
+	}{
+		{
+			name:      "upload succeeded",
+			summary:   Summary{Op: OpUpload, Target: "TEST-1", Total: 3, Done: 3, Bytes: 5 << 20, Elapsed: 130 * time.Second},
+			wantTitle: "Upload finished",
+			wantBody:  "Uploaded 3 files (5.00 MB) to TEST-1 in 2m10s",
+		},
+		{
+			name:      "single file",
+			summary:   Summary{Op: OpDownload, Target: "TEST-1", Total: 1, Done: 1, Bytes: 512, Elapsed: 45 * time.Second},
+			wantTitle: "Download finished",
+			wantBody:  "Downloaded 1 file (512 B) from TEST-1 in 45s",
+		},
+		{
+			name:      "partial failure",
+			summary:   Summary{Op: OpDownload, Target: "TEST-1", Total: 5, Done: 3, Failed: 2, Bytes: 2048, Elapsed: time.Minute},
+			wantTitle: "Download finished with failures",
+			wantBody:  "Downloaded 3 of 5 files (2.00 KB) from TEST-1 in 1m0s, 2 failed",
+		},
+		{
+			name:      "all failed",
+			summary:   Summary{Op: OpUpload, Target: "TEST-1", Total: 2, Failed: 2, Elapsed: 31 * time.Second},
+			wantTitle: "Upload failed",
+			wantBody:  "Uploaded 0 of 2 files to TEST-1 in 31s, 2 failed",
+		},
+		{
+			name:      "elapsed is rounded to the second",
+			summary:   Summary{Op: OpUpload, Target: "2 issue(s)", Total: 4, Done: 4, Elapsed: 40*time.Second + 600*time.Millisecond},
+			wantTitle: "Upload finished",
+			wantBody:  "Uploaded 4 files to 2 issue(s) in 41s",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			title, body := Message(tc.summary)
+			assert.Equal(t, tc.wantTitle, title)
+			assert.Equal(t, tc.wantBody, body)
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	t.Run("linux", func(t *testing.T) {
+		t.Parallel()
+
+		name, args, ok := Command("linux", "Upload finished", `Uploaded 1 file to "TEST-1"`)
+		require.True(t, ok)
+		assert.Equal(t, "notify-send", name)
+		assert.Equal(t, []string{"--app-name", "jira", "Upload finished", `Uploaded 1 file to "TEST-1"`}, args)
+	})
+
+	t.Run("darwin", func(t *testing.T) {
+		t.Parallel()
+
+		name, args, ok := Command("darwin", "Upload finished", `Uploaded "a\b.txt"`)
+		require.True(t, ok)
+		assert.Equal(t, "osascript", name)
+		assert.Equal(t, []string{"-e", `display notification "Uploaded \"a\\b.txt\"" with title "Upload finished"`}, args)
+	})
+
+	t.Run("windows", func(t *testing.T) {
+		t.Parallel()
+
+		name, args, ok := Command("windows", "Upload finished", "Uploaded <1> file to Bob's issue & more")
+		require.True(t, ok)
+		assert.Equal(t, "powershell", name)
+		require.Len(t, args, 4)
+		assert.Equal(t, []string{"-NoProfile", "-NonInteractive", "-Command"}, args[:3])
+
+		script := args[3]
+		assert.Contains(t, script, "<text>Upload finished</text>")
+		assert.Contains(t, script, "<text>Uploaded &lt;1&gt; file to Bob&apos;s issue &amp; more</text>")
+		assert.Contains(t, script, "CreateToastNotifier('jira')")
+		// Single quotes are doubled in the PowerShell string literal.
+		assert.NotContains(t, strings.ReplaceAll(script, "''", ""), "Bob's")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, ok := Command("plan9", "Upload finished", "Uploaded 1 file")
+		assert.False(t, ok)
+	})
+}
+
+// fakeClock is a clock advanced by the test.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// recorder records the commands run.
+type recorder struct {
+	calls [][]string
+	err   error
+}
+
+func (r *recorder) run(name string, args ...string) error {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return r.err
+}
+
+func TestNotifierThreshold(t *testing.T) {
+	t.Parallel()
+
+	summary := Summary{Op: OpUpload, Target: "TEST-1", Total: 1, Done: 1}
+
+	t.Run("shorter runs aren't notified", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		rec := &recorder{}
+		n := NewWith(true, DefaultThreshold, "linux", rec.run, clock.Now)
+
+		clock.Advance(29 * time.Second)
+		n.Done(summary)
+		assert.Empty(t, rec.calls)
+	})
+
+	t.Run("runs reaching the threshold are notified", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		rec := &recorder{}
+		n := NewWith(true, DefaultThreshold, "linux", rec.run, clock.Now)
+
+		clock.Advance(DefaultThreshold)
+		n.Done(summary)
+		require.Len(t, rec.calls, 1)
+		assert.Equal(t, []string{"notify-send", "--app-name", "jira", "Upload finished", "Uploaded 1 file to TEST-1 in 30s"}, rec.calls[0])
+	})
+
+	t.Run("zero threshold notifies every run", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		rec := &recorder{}
+		n := NewWith(true, 0, "darwin", rec.run, clock.Now)
+
+		n.Done(summary)
+		require.Len(t, rec.calls, 1)
+		assert.Equal(t, "osascript", rec.calls[0][0])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		rec := &recorder{}
+		n := NewWith(false, 0, "linux", rec.run, clock.Now)
+
+		clock.Advance(time.Hour)
+		n.Done(summary)
+		assert.Empty(t, rec.calls)
+	})
+
+	t.Run("unsupported os", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		rec := &recorder{}
+		n := NewWith(true, 0, "plan9", rec.run, clock.Now)
+
+		n.Done(summary)
+		assert.Empty(t, rec.calls)
+	})
+}
+
+func TestNotifierIgnoresFailures(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := &recorder{err: errors.New(`exec: "notify-send": executable file not found in $PATH`)}
+	n := NewWith(true, 0, "linux", rec.run, clock.Now)
+
+	assert.NotPanics(t, func() { n.Done(Summary{Op: OpDownload, Total: 1, Done: 1}) })
+	assert.Len(t, rec.calls, 1)
+
+	var nilNotifier *Notifier
+	assert.NotPanics(t, func() { nilNotifier.Done(Summary{Op: OpDownload, Total: 1, Done: 1}) })
+}
+
+func TestNotifierListeners(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := &recorder{}
+	// Listeners are called for every run, even when notifications are disabled or the
+	// run is shorter than the threshold.
+	n := NewWith(false, DefaultThreshold, "linux", rec.run, clock.Now)
+
+	var got []Summary
+	n.Listen(func(s Summary) { got = append(got, s) })
+
+	clock.Advance(2 * time.Second)
+	n.Done(Summary{Op: OpDelete, Target: "TEST-1", Total: 2, Done: 2})
+
+	require.Len(t, got, 1)
+	assert.Equal(t, OpDelete, got[0].Op)
+	assert.Equal(t, 2*time.Second, got[0].Elapsed)
+	assert.Empty(t, rec.calls)
+
+	title, body := Message(got[0])
+	assert.Equal(t, "Delete finished", title)
+	assert.Equal(t, "Deleted 2 files from TEST-1 in 2s", body)
+}
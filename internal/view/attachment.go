@@ -0,0 +1,211 @@
+package view
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/tui"
+)
+
+// Orders of the attachments section of the issue view.
+const (
+	// AttachmentOrderAPI keeps the order returned by the API, ie: the upload order.
+	AttachmentOrderAPI = "api"
+	// AttachmentOrderCreatedDesc lists the newest attachments first, as the Jira web UI does.
+	AttachmentOrderCreatedDesc = "created-desc"
+	// AttachmentOrderCreatedAsc lists the oldest attachments first.
+	AttachmentOrderCreatedAsc = "created-asc"
+	// AttachmentOrderName lists the attachments by filename.
+	AttachmentOrderName = "name"
+)
+
+// AttachmentOrders are the valid orders of the attachments section.
+var AttachmentOrders = []string{
+	AttachmentOrderAPI,
+	AttachmentOrderCreatedDesc,
+	AttachmentOrderCreatedAsc,
+	AttachmentOrderName,
+}
+
+// IsValidAttachmentOrder checks if the order is one of AttachmentOrders.
+func IsValidAttachmentOrder(order string) bool {
+	return slices.Contains(AttachmentOrders, order)
+}
+
+// sortAttachments returns a copy of the attachments in the given order,
+// defaulting to created-desc to match the Jira web UI.
+func sortAttachments(attachments []jira.Attachment, order string) []jira.Attachment {
+	out := slices.Clone(attachments)
+
+	switch order {
+	case AttachmentOrderAPI:
+	case AttachmentOrderCreatedAsc:
+		slices.SortStableFunc(out, jira.CompareAttachmentsByCreated)
+	case AttachmentOrderName:
+		slices.SortStableFunc(out, func(a, b jira.Attachment) int {
+			if c := strings.Compare(strings.ToLower(a.Filename), strings.ToLower(b.Filename)); c != 0 {
+				return c
+			}
+			return jira.CompareAttachmentIDs(a, b)
+		})
+	default:
+		slices.SortStableFunc(out, func(a, b jira.Attachment) int {
+			return jira.CompareAttachmentsByCreated(b, a)
+		})
+	}
+	return out
+}
+
+// attachmentSummary aggregates the attachments of an issue for the footer of the
+// attachments section.
+type attachmentSummary struct {
+	count int
+	size  int64
+	// unknown is the number of attachments without a size, excluded from size.
+	unknown int
+	newest  time.Time
+	// dated is set if at least one creation date could be parsed.
+	dated bool
+}
+
+func summarizeAttachments(attachments []jira.Attachment) attachmentSummary {
+	s := attachmentSummary{count: len(attachments)}
+	for _, a := range attachments {
+		if a.Size <= 0 {
+			s.unknown++
+		} else {
+			s.size += a.Size
+		}
+		if t, ok := a.CreatedTime(); ok && (!s.dated || t.After(s.newest)) {
+			s.newest, s.dated = t, true
+		}
+	}
+	return s
+}
+
+// fields returns the count, total size and age of the newest attachment. The size is
+// marked with an asterisk if some sizes are unknown, and the age is empty if no date
+// could be parsed.
+func (s attachmentSummary) fields(now time.Time) (count, size, age string) {
+	count = fmt.Sprintf("%d attachments", s.count)
+	if s.count == 1 {
+		count = "1 attachment"
+	}
+	size = formatAttachmentSize(s.size)
+	if s.unknown > 0 {
+		size += "*"
+	}
+	if s.dated {
+		age = formatAge(now.Sub(s.newest))
+	}
+	return count, size, age
+}
+
+// String renders the summary as, eg: 5 attachments · 231.40 MB · newest 2d ago.
+func (s attachmentSummary) String(now time.Time) string {
+	count, size, age := s.fields(now)
+
+	parts := []string{count, size}
+	if age != "" {
+		parts = append(parts, "newest "+age)
+	}
+	out := strings.Join(parts, " · ")
+	if s.unknown > 0 {
+		out += fmt.Sprintf(" (*%d of unknown size not counted)", s.unknown)
+	}
+	return out
+}
+
+// Trailer renders the summary as a tab separated line for scripts, the age being - if unknown.
+func (s attachmentSummary) Trailer(now time.Time) string {
+	_, size, age := s.fields(now)
+	if age == "" {
+		age = "-"
+	}
+	return strings.Join([]string{"attachments", fmt.Sprint(s.count), size, age}, "\t")
+}
+
+// formatAge formats a duration as a short relative age, eg: 2d ago.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	}
+}
+
+// AttachmentDownloader downloads all attachments of an issue.
+type AttachmentDownloader interface {
+	DownloadAll(key string) (dir string, count int, err error)
+}
+
+// attachmentBadge returns a compact indicator of the number of attachments. A nil slice
+// means the attachment field was not fetched, the count is unknown and shown as "?" rather
+// than as no attachments. Nothing is displayed for an issue without attachments.
+func attachmentBadge(attachments []jira.Attachment, ascii bool) string {
+	count := "?"
+	switch {
+	case attachments == nil:
+	case len(attachments) == 0:
+		return ""
+	default:
+		count = fmt.Sprint(len(attachments))
+	}
+	if ascii {
+		return "[" + count + "]"
+	}
+	return "📎 " + count
+}
+
+// supportsGlyphs checks if the terminal is likely to render unicode glyphs.
+func supportsGlyphs(getenv func(string) string) bool {
+	if tui.IsDumbTerminal() {
+		return false
+	}
+	for _, k := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		v := getenv(k)
+		if v == "" {
+			continue
+		}
+		v = strings.ToLower(v)
+		return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+	}
+	return false
+}
+
+func useASCIIGlyphs(force bool) bool {
+	return force || !supportsGlyphs(os.Getenv)
+}
+
+func downloadAttachments(d AttachmentDownloader) tui.DownloadFunc {
+	return func(r, _ int, data any) func() string {
+		key := issueKeyFromTuiData(r, data)
+
+		return func() string {
+			return downloadAttachmentsMessage(d, key)
+		}
+	}
+}
+
+func downloadAttachmentsMessage(d AttachmentDownloader, key string) string {
+	if key == "" {
+		return "No issue selected"
+	}
+	dir, n, err := d.DownloadAll(key)
+	if err != nil {
+		return fmt.Sprintf("Failed to download attachments of %s: %s", key, err)
+	}
+	if n == 0 {
+		return fmt.Sprintf("No attachments found for %s", key)
+	}
+	return fmt.Sprintf("Downloaded %d attachment(s) of %s to %s", n, key, dir)
+}
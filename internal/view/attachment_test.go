@@ -1,11 +1,15 @@
 package view
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/tui"
 )
 
 func TestFormatAttachmentSize(t *testing.T) {
@@ -183,4 +187,243 @@ func TestIssueWithoutAttachments(t *testing.T) {
 	// Ensure attachments section is not in the full output
 	fullOutput := issue.String()
 	assert.NotContains(t, fullOutput, "Attachments")
+	assert.NotContains(t, fullOutput, "attachments\t")
+}
+
+func TestAttachmentBadge(t *testing.T) {
+	t.Parallel()
+
+	three := []jira.Attachment{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	tests := []struct {
+		name        string
+		attachments []jira.Attachment
+		ascii       bool
+		expected    string
+	}{
+		{name: "not fetched", attachments: nil, expected: "📎 ?"},
+		{name: "not fetched ascii", attachments: nil, ascii: true, expected: "[?]"},
+		{name: "zero attachments", attachments: []jira.Attachment{}, expected: ""},
+		{name: "glyph", attachments: three, expected: "📎 3"},
+		{name: "ascii", attachments: three, ascii: true, expected: "[3]"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, attachmentBadge(tc.attachments, tc.ascii))
+		})
+	}
+}
+
+func TestIssueDataWithAttachmentBadge(t *testing.T) {
+	issues := getIssues()
+	issues[0].Fields.Attachments = []jira.Attachment{{ID: "1"}, {ID: "2"}}
+
+	list := IssueList{
+		Project: "TEST",
+		Server:  "https://test.local",
+		Data:    issues,
+		Display: DisplayFormat{
+			Columns:         []string{"key", "status"},
+			AttachmentBadge: true,
+			ASCIIGlyphs:     true,
+		},
+	}
+	expected := tui.TableData{
+		[]string{"KEY", "STATUS", "ATTACHMENTS"},
+		[]string{"TEST-1", "Done", "[2]"},
+		[]string{"TEST-2", "Open", "[?]"},
+	}
+	assert.Equal(t, expected, list.data())
+
+	// An issue fetched without attachments has no badge.
+	issues[1].Fields.Attachments = []jira.Attachment{}
+	assert.Equal(t, []string{"TEST-2", "Open", ""}, list.data()[2])
+
+	// The badge is never displayed in plain mode.
+	list.Display.Plain = true
+	assert.Equal(t, []string{"KEY", "STATUS"}, list.data()[0])
+}
+
+type fakeAttachmentDownloader struct {
+	keys  []string
+	count int
+	err   error
+}
+
+func (f *fakeAttachmentDownloader) DownloadAll(key string) (string, int, error) {
+	f.keys = append(f.keys, key)
+	return "/tmp/" + key, f.count, f.err
+}
+
+func TestDownloadAttachmentsDispatch(t *testing.T) {
+	data := tui.TableData{
+		[]string{"TYPE", "KEY"},
+		[]string{"Bug", "TEST-1"},
+		[]string{"Story", "TEST-2"},
+	}
+
+	d := &fakeAttachmentDownloader{count: 3}
+	run := downloadAttachments(d)(2, 0, data)
+	assert.Empty(t, d.keys, "download must not start before the returned func is run")
+	assert.Equal(t, "Downloaded 3 attachment(s) of TEST-2 to /tmp/TEST-2", run())
+	assert.Equal(t, []string{"TEST-2"}, d.keys)
+
+	d = &fakeAttachmentDownloader{}
+	assert.Equal(t, "No attachments found for TEST-1", downloadAttachments(d)(1, 0, data)())
+
+	d = &fakeAttachmentDownloader{err: errors.New("forbidden")}
+	assert.Equal(t, "Failed to download attachments of TEST-1: forbidden", downloadAttachments(d)(1, 0, data)())
+}
+
+func TestSupportsGlyphs(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+
+	t.Setenv("TERM", "xterm-256color")
+
+	assert.True(t, supportsGlyphs(env(map[string]string{"LANG": "en_US.UTF-8"})))
+	assert.True(t, supportsGlyphs(env(map[string]string{"LC_ALL": "C.utf8", "LANG": "C"})))
+	assert.False(t, supportsGlyphs(env(map[string]string{"LC_ALL": "C", "LANG": "en_US.UTF-8"})))
+	assert.False(t, supportsGlyphs(env(nil)))
+}
+
+func TestSortAttachments(t *testing.T) {
+	t.Parallel()
+
+	// In upload order, as returned by the API, with a created date that can't be parsed.
+	attachments := []jira.Attachment{
+		{ID: "10001", Filename: "b-notes.txt", Created: "2020-12-03T10:00:00.000+0100"},
+		{ID: "10002", Filename: "a-report.pdf", Created: "2020-12-01T10:00:00.000+0100"},
+		{ID: "10003", Filename: "C-diagram.png", Created: "2020-12-02T10:00:00.000+0100"},
+		{ID: "10004", Filename: "d-broken.log", Created: "unknown"},
+	}
+
+	cases := []struct {
+		order    string
+		expected []string
+	}{
+		{order: AttachmentOrderAPI, expected: []string{"10001", "10002", "10003", "10004"}},
+		{order: AttachmentOrderCreatedAsc, expected: []string{"10002", "10003", "10001", "10004"}},
+		{order: AttachmentOrderCreatedDesc, expected: []string{"10004", "10001", "10003", "10002"}},
+		{order: "", expected: []string{"10004", "10001", "10003", "10002"}},
+		{order: AttachmentOrderName, expected: []string{"10002", "10001", "10003", "10004"}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.order, func(t *testing.T) {
+			t.Parallel()
+
+			var ids []string
+			for _, a := range sortAttachments(attachments, tc.order) {
+				ids = append(ids, a.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+
+	// The issue data is left untouched.
+	assert.Equal(t, "10001", attachments[0].ID)
+}
+
+func TestIssueAttachmentsOrder(t *testing.T) {
+	t.Parallel()
+
+	data := &jira.Issue{
+		Key: "TEST-1",
+		Fields: jira.IssueFields{
+			Attachments: []jira.Attachment{
+				{ID: "10001", Filename: "old.txt", Created: "2020-12-01T10:00:00.000+0100"},
+				{ID: "10002", Filename: "new.txt", Created: "2020-12-02T10:00:00.000+0100"},
+			},
+		},
+	}
+
+	cases := []struct {
+		order         string
+		first, second string
+	}{
+		{order: AttachmentOrderAPI, first: "old.txt", second: "new.txt"},
+		{order: AttachmentOrderCreatedDesc, first: "new.txt", second: "old.txt"},
+	}
+
+	for _, tc := range cases {
+		out := Issue{Data: data, Display: DisplayFormat{AttachmentOrder: tc.order}}.attachments()
+		assert.Less(t, strings.Index(out, tc.first), strings.Index(out, tc.second), tc.order)
+	}
+}
+
+func TestIssueAttachmentsSummary(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 12, 4, 12, 0, 0, 0, time.UTC)
+	data := &jira.Issue{
+		Key: "TEST-1",
+		Fields: jira.IssueFields{
+			Attachments: []jira.Attachment{
+				{ID: "10001", Filename: "spec.pdf", Size: 2097152, Created: "2020-12-01T10:00:00.000+0000"},
+				{ID: "10002", Filename: "log.txt", Size: 1048576, Created: "2020-12-02T10:00:00.000+0000"},
+				{ID: "10003", Filename: "shot.png", Size: 1048576, Created: "2020-11-20T10:00:00.000+0000"},
+			},
+		},
+	}
+
+	issue := Issue{Data: data, now: func() time.Time { return now }}
+	assert.Contains(t, issue.attachments(), "3 attachments · 4.00 MB · newest 2d ago")
+
+	issue.Display.Plain = true
+	out := issue.attachments()
+	assert.Contains(t, out, "\n  attachments\t3\t4.00 MB\t2d ago\n")
+	assert.NotContains(t, out, "·")
+}
+
+func TestIssueAttachmentsSummaryUnknownSize(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 12, 2, 13, 0, 0, 0, time.UTC)
+	data := &jira.Issue{
+		Key: "TEST-1",
+		Fields: jira.IssueFields{
+			Attachments: []jira.Attachment{
+				{ID: "10001", Filename: "spec.pdf", Size: 1536, Created: "2020-12-02T10:00:00.000+0000"},
+				{ID: "10002", Filename: "lost.bin", Created: "not a date"},
+			},
+		},
+	}
+
+	issue := Issue{Data: data, now: func() time.Time { return now }}
+	assert.Contains(t, issue.attachments(), "2 attachments · 1.50 KB* · newest 3h ago (*1 of unknown size not counted)")
+
+	issue.Display.Plain = true
+	assert.Contains(t, issue.attachments(), "attachments\t2\t1.50 KB*\t3h ago")
+}
+
+func TestAttachmentSummaryWithoutDates(t *testing.T) {
+	t.Parallel()
+
+	s := summarizeAttachments([]jira.Attachment{{ID: "1", Size: 500, Created: "yesterday"}})
+	now := time.Now()
+
+	assert.Equal(t, "1 attachment · 500 B", s.String(now))
+	assert.Equal(t, "attachments\t1\t500 B\t-", s.Trailer(now))
+}
+
+func TestFormatAge(t *testing.T) {
+	t.Parallel()
+
+	cases := map[time.Duration]string{
+		-time.Hour:       "just now",
+		30 * time.Second: "just now",
+		5 * time.Minute:  "5m ago",
+		3 * time.Hour:    "3h ago",
+		49 * time.Hour:   "2d ago",
+	}
+	for d, want := range cases {
+		assert.Equal(t, want, formatAge(d), d.String())
+	}
 }
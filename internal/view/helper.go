@@ -56,6 +56,7 @@ const (
 * [yellow]ENTER[default] to open the selected issue in the browser
 * [yellow]c[default] to copy issue URL to the system clipboard
 * [yellow]CTRL + k[default] to copy issue key to the system clipboard
+* [yellow]a[default] to download all attachments of the selected issue
 * [yellow]q / ESC / CTRL + c[default] to quit the app
 * [yellow]?[default] to view this help page`
 )
@@ -112,6 +113,19 @@ func formatDateTime(dt, format, tz string) string {
 	return t.In(loc).Format("2006-01-02 15:04:05")
 }
 
+// displayLocation loads the timezone dates are shown in, the local one if it is not set
+// or invalid.
+func displayLocation(tz string) *time.Location {
+	if tz == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
 func prepareTitle(text string) string {
 	text = strings.TrimSpace(text)
 	return tview.Escape(text)
@@ -6,6 +6,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/fatih/color"
@@ -56,6 +57,16 @@ type Issue struct {
 	Data    *jira.Issue
 	Display DisplayFormat
 	Options IssueOption
+
+	// now returns the current time, defaults to time.Now.
+	now func() time.Time
+}
+
+func (i Issue) clock() time.Time {
+	if i.now != nil {
+		return i.now()
+	}
+	return time.Now()
 }
 
 // Render renders the view.
@@ -414,20 +425,30 @@ func (i Issue) attachments() string {
 		fmt.Sprintf("\n %s\n\n", coloredOut("ATTACHMENTS", color.FgWhite, color.Bold)),
 	)
 
-	for _, a := range i.Data.Fields.Attachments {
+	for _, a := range sortAttachments(i.Data.Fields.Attachments, i.Display.AttachmentOrder) {
 		size := formatAttachmentSize(a.Size)
-		date := cmdutil.FormatDateTimeHuman(a.Created, jira.RFC3339)
+		if !a.Available() {
+			size += ", unavailable"
+		}
+		date := a.FormatCreated(displayLocation(i.Display.Timezone), "Mon, 02 Jan 06")
 		attachments.WriteString(
 			fmt.Sprintf(
 				"  📎 %s (%s) - Added by %s on %s\n",
-				coloredOut(a.Filename, color.FgCyan),
+				coloredOut(cmdutil.SanitizeTerminalText(a.Filename), color.FgCyan),
 				size,
-				a.Author.DisplayName,
+				cmdutil.SanitizeTerminalText(cmdutil.AuthorName(a.Author)),
 				date,
 			),
 		)
 	}
 
+	summary := summarizeAttachments(i.Data.Fields.Attachments)
+	if i.Display.Plain {
+		attachments.WriteString(fmt.Sprintf("\n  %s\n", summary.Trailer(i.clock())))
+	} else {
+		attachments.WriteString(fmt.Sprintf("\n  %s\n", gray(summary.String(i.clock()))))
+	}
+
 	return attachments.String()
 }
 
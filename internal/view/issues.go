@@ -25,6 +25,14 @@ type DisplayFormat struct {
 	Comments     uint
 	TableStyle   tui.TableStyle
 	Timezone     string
+
+	// AttachmentBadge displays the attachment count column in the interactive mode.
+	AttachmentBadge bool
+	// ASCIIGlyphs forces ASCII output for the glyphs used in the interactive mode.
+	ASCIIGlyphs bool
+	// AttachmentOrder is the order of the attachments section of the issue view,
+	// one of AttachmentOrders. Defaults to created-desc.
+	AttachmentOrder string
 }
 
 // IssueList is a list view for issues.
@@ -35,6 +43,9 @@ type IssueList struct {
 	Display    DisplayFormat
 	Refresh    tui.RefreshFunc
 	FooterText string
+
+	// Downloader is used to download attachments of the selected issue from the TUI.
+	Downloader AttachmentDownloader
 }
 
 // Render renders the view.
@@ -65,7 +76,7 @@ func (l *IssueList) Render() error {
 		l.FooterText = fmt.Sprintf("Showing %d results for project %q", len(data)-1, l.Project)
 	}
 
-	view := tui.NewTable(
+	opts := []tui.TableOption{
 		tui.WithTableStyle(l.Display.TableStyle),
 		tui.WithTableFooterText(l.FooterText),
 		tui.WithTableHelpText(tableHelpText),
@@ -130,9 +141,12 @@ func (l *IssueList) Render() error {
 		}),
 		tui.WithRefreshFunc(l.Refresh),
 		tui.WithFixedColumns(l.Display.FixedColumns),
-	)
+	}
+	if l.Downloader != nil {
+		opts = append(opts, tui.WithDownloadFunc(downloadAttachments(l.Downloader)))
+	}
 
-	return view.Paint(data)
+	return tui.NewTable(opts...).Paint(data)
 }
 
 // renderPlain renders the issue in plain view.
@@ -157,6 +171,17 @@ func (*IssueList) validColumnsMap() map[string]struct{} {
 }
 
 func (l *IssueList) header() []string {
+	headers := l.columns()
+
+	// Attachment badge is only displayed in the interactive mode.
+	if l.Display.AttachmentBadge && !l.Display.Plain && !l.Display.CSV {
+		headers = append(headers, fieldAttachments)
+	}
+
+	return headers
+}
+
+func (l *IssueList) columns() []string {
 	if len(l.Display.Columns) == 0 {
 		validColumns := ValidIssueColumns()
 		if l.Display.NoTruncate || !l.Display.Plain {
@@ -231,6 +256,8 @@ func (l *IssueList) assignColumns(columns []string, issue *jira.Issue) []string
 			bucket = append(bucket, formatDateTime(issue.Fields.Updated, jira.RFC3339, l.Display.Timezone))
 		case fieldLabels:
 			bucket = append(bucket, strings.Join(issue.Fields.Labels, ","))
+		case fieldAttachments:
+			bucket = append(bucket, attachmentBadge(issue.Fields.Attachments, useASCIIGlyphs(l.Display.ASCIIGlyphs)))
 		}
 	}
 
@@ -0,0 +1,243 @@
+package where
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+const day = 24 * time.Hour
+
+var operators = map[string]struct{}{
+	"=": {}, "!=": {}, "<": {}, "<=": {}, ">": {}, ">=": {}, "~": {}, "!~": {},
+}
+
+var (
+	orderOps  = []string{"=", "!=", "<", "<=", ">", ">="}
+	stringOps = []string{"=", "!=", "~", "!~"}
+)
+
+type node interface {
+	eval(a *jira.Attachment) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(a *jira.Attachment) bool { return n.left.eval(a) && n.right.eval(a) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(a *jira.Attachment) bool { return n.left.eval(a) || n.right.eval(a) }
+
+type notNode struct{ n node }
+
+func (n notNode) eval(a *jira.Attachment) bool { return !n.n.eval(a) }
+
+type predicate func(a *jira.Attachment) bool
+
+func (p predicate) eval(a *jira.Attachment) bool { return p(a) }
+
+type field struct {
+	ops     []string
+	compile func(op, val string) (node, error)
+}
+
+func (f field) supports(op string) bool {
+	for _, o := range f.ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+var fields = map[string]field{
+	"size":     {ops: orderOps, compile: compileSize},
+	"created":  {ops: orderOps, compile: compileCreated},
+	"author":   {ops: stringOps, compile: compileAuthor},
+	"filename": {ops: stringOps, compile: compileString(func(a *jira.Attachment) string { return a.Filename }, false)},
+	"mimetype": {ops: stringOps, compile: compileString(func(a *jira.Attachment) string { return a.MimeType }, true)},
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for n := range fields {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func compileSize(op, val string) (node, error) {
+	want, err := ParseSize(val)
+	if err != nil {
+		return nil, err
+	}
+	return predicate(func(a *jira.Attachment) bool {
+		return compare(op, a.Size, want)
+	}), nil
+}
+
+func compare(op string, got, want int64) bool {
+	switch op {
+	case "=":
+		return got == want
+	case "!=":
+		return got != want
+	case "<":
+		return got < want
+	case "<=":
+		return got <= want
+	case ">":
+		return got > want
+	default:
+		return got >= want
+	}
+}
+
+func compileCreated(op, val string) (node, error) {
+	start, end, err := parseDate(val)
+	if err != nil {
+		return nil, err
+	}
+
+	// A date without time covers the whole day, so created = 2024-06-01
+	// matches anything created that day and created > 2024-06-01 starts
+	// the day after. For timestamps start and end are the same instant.
+	return predicate(func(a *jira.Attachment) bool {
+		t, ok := a.CreatedTime()
+		if !ok {
+			return false
+		}
+		switch op {
+		case "=":
+			return !t.Before(start) && !t.After(end)
+		case "!=":
+			return t.Before(start) || t.After(end)
+		case "<":
+			return t.Before(start)
+		case "<=":
+			return !t.After(end)
+		case ">":
+			return t.After(end)
+		default:
+			return !t.Before(start)
+		}
+	}), nil
+}
+
+// compileAuthor matches the display name, user name, account id or email of the author.
+// Negated operators match if none of them matches.
+func compileAuthor(op, val string) (node, error) {
+	positive := strings.TrimPrefix(op, "!")
+	match, err := stringMatcher(positive, val, true)
+	if err != nil {
+		return nil, err
+	}
+	negate := strings.HasPrefix(op, "!")
+
+	return predicate(func(a *jira.Attachment) bool {
+		u := a.Author
+		for _, s := range []string{u.DisplayName, u.Name, u.AccountID, u.Email} {
+			if s != "" && match(s) {
+				return !negate
+			}
+		}
+		return negate
+	}), nil
+}
+
+func compileString(get func(a *jira.Attachment) string, fold bool) func(op, val string) (node, error) {
+	return func(op, val string) (node, error) {
+		match, err := stringMatcher(op, val, fold)
+		if err != nil {
+			return nil, err
+		}
+		return predicate(func(a *jira.Attachment) bool {
+			return match(get(a))
+		}), nil
+	}
+}
+
+// stringMatcher compares strings for equality or, with ~, against a glob pattern.
+func stringMatcher(op, val string, fold bool) (func(string) bool, error) {
+	norm := func(s string) string { return s }
+	if fold {
+		norm = strings.ToLower
+	}
+	want := norm(val)
+
+	switch op {
+	case "=":
+		return func(s string) bool { return norm(s) == want }, nil
+	case "!=":
+		return func(s string) bool { return norm(s) != want }, nil
+	}
+
+	if _, err := path.Match(want, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %s", quote(val))
+	}
+	negate := op == "!~"
+	return func(s string) bool {
+		ok, _ := path.Match(want, norm(s))
+		return ok != negate
+	}, nil
+}
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// ParseSize parses a size with an optional unit, eg: 512, 10KB, 1.5GB. Units are
+// case-insensitive and, like the sizes displayed by the CLI, powers of 1024.
+func ParseSize(s string) (int64, error) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i == -1 {
+		i = len(s)
+	}
+
+	num, unit := s[:i], strings.ToLower(s[i:])
+	mul, ok := sizeUnits[unit]
+	if !ok || num == "" {
+		return 0, fmt.Errorf("invalid size %s, expected a number with an optional unit like 10MB", quote(s))
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %s", quote(s))
+	}
+	return int64(n * float64(mul)), nil
+}
+
+// parseDate parses a date or a timestamp. Dates are in the local time zone and
+// cover the whole day.
+func parseDate(s string) (start, end time.Time, err error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, t.Add(day - time.Nanosecond), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, t, nil
+		}
+	}
+	return start, end, fmt.Errorf("invalid date %s, expected YYYY-MM-DD or an RFC3339 timestamp", quote(s))
+}
@@ -0,0 +1,130 @@
+package where
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokLParen
+	tokRParen
+	tokOp
+	tokWord
+	tokString
+	tokAnd
+	tokOr
+	tokNot
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of expression"
+	case tokLParen:
+		return `"("`
+	case tokRParen:
+		return `")"`
+	case tokOp:
+		return "operator"
+	case tokString:
+		return "string"
+	case tokAnd, tokOr, tokNot:
+		return "keyword"
+	default:
+		return "word"
+	}
+}
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int // Byte offset in the expression.
+}
+
+var keywords = map[string]tokenKind{
+	"and": tokAnd,
+	"or":  tokOr,
+	"not": tokNot,
+}
+
+// lex splits the expression into tokens. The last token is always tokEOF.
+func lex(src string) ([]token, error) {
+	var (
+		toks []token
+		i    int
+	)
+
+	for i < len(src) {
+		c := rune(src[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, val: "(", pos: i})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, val: ")", pos: i})
+			i++
+		case strings.ContainsRune("<>=!~", c):
+			op := string(c)
+			if i+1 < len(src) && (src[i+1] == '=' || (c == '!' && src[i+1] == '~')) {
+				op = src[i : i+2]
+			}
+			if _, ok := operators[op]; !ok {
+				return nil, &Error{Expr: src, Pos: i, Msg: "unknown operator " + quote(op)}
+			}
+			toks = append(toks, token{kind: tokOp, val: op, pos: i})
+			i += len(op)
+		case c == '"' || c == '\'':
+			s, n, ok := lexString(src[i:])
+			if !ok {
+				return nil, &Error{Expr: src, Pos: i, Msg: "unterminated string"}
+			}
+			toks = append(toks, token{kind: tokString, val: s, pos: i})
+			i += n
+		default:
+			start := i
+			for i < len(src) && isWordByte(src[i]) {
+				i++
+			}
+			w := src[start:i]
+			kind, ok := keywords[strings.ToLower(w)]
+			if !ok {
+				kind = tokWord
+			}
+			toks = append(toks, token{kind: kind, val: w, pos: start})
+		}
+	}
+
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexString reads a quoted string. A backslash escapes the next character.
+func lexString(src string) (string, int, bool) {
+	var (
+		b     strings.Builder
+		quote = src[0]
+	)
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if i+1 < len(src) {
+				i++
+				b.WriteByte(src[i])
+			}
+		case quote:
+			return b.String(), i + 1, true
+		default:
+			b.WriteByte(src[i])
+		}
+	}
+	return "", 0, false
+}
+
+func isWordByte(c byte) bool {
+	return !unicode.IsSpace(rune(c)) && !strings.ContainsRune(`()<>=!~"'`, rune(c))
+}
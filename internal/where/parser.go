@@ -0,0 +1,134 @@
+package where
+
+import (
+	"fmt"
+	"strings"
+)
+
+type parser struct {
+	src  string
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{Expr: p.src, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseOr parses: and ("or" and)*.
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary ("and" unary)*.
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses: "not" unary | "(" or ")" | comparison.
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+
+	switch t.kind {
+	case tokNot:
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokLParen:
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, `expected ")", got %s`, describe(closing))
+		}
+		return n, nil
+	case tokWord:
+		return p.parseComparison()
+	default:
+		return nil, p.errorf(t, "expected a field, got %s", describe(t))
+	}
+}
+
+// parseComparison parses: field operator value.
+func (p *parser) parseComparison() (node, error) {
+	ft := p.next()
+	f, ok := fields[strings.ToLower(ft.val)]
+	if !ok {
+		return nil, p.errorf(ft, "unknown field %s, expected one of %s", quote(ft.val), strings.Join(fieldNames(), ", "))
+	}
+
+	ot := p.next()
+	if ot.kind != tokOp {
+		return nil, p.errorf(ot, "expected an operator after %s, got %s", ft.val, describe(ot))
+	}
+	if !f.supports(ot.val) {
+		return nil, p.errorf(ot, "operator %s is not supported for %s", quote(ot.val), ft.val)
+	}
+
+	vt := p.next()
+	if vt.kind != tokWord && vt.kind != tokString {
+		return nil, p.errorf(vt, "expected a value after %s, got %s", quote(ot.val), describe(vt))
+	}
+
+	n, err := f.compile(ot.val, vt.val)
+	if err != nil {
+		return nil, p.errorf(vt, "%s", err)
+	}
+	return n, nil
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return t.kind.String()
+	case tokString:
+		return fmt.Sprintf("string %q", t.val)
+	default:
+		return quote(t.val)
+	}
+}
+
+func quote(s string) string {
+	return `"` + s + `"`
+}
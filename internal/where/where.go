@@ -0,0 +1,78 @@
+// Package where implements a small filter language over attachments, eg:
+//
+//	size > 50MB and created < 2024-06-01 and not filename ~ "*.pdf"
+//
+// Comparisons on size, created, author, filename and mimetype can be
+// combined with and, or, not and parentheses. And binds tighter than or.
+package where
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+// Error is a syntax or type error in an expression.
+type Error struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+// Error returns the message followed by the expression and a caret pointing at the offending token.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s\n  %s\n  %s^", e.Msg, e.Expr, strings.Repeat(" ", e.Pos))
+}
+
+// Expr is a compiled expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses the expression. An empty expression matches everything.
+func Compile(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return &Expr{src: src}, nil
+	}
+
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{src: src, toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, `expected "and", "or" or end of expression, got %s`, describe(t))
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Match reports if the attachment matches the expression.
+func (e *Expr) Match(a *jira.Attachment) bool {
+	if e == nil || e.root == nil {
+		return true
+	}
+	return e.root.eval(a)
+}
+
+// Filter returns the attachments matching the expression.
+func (e *Expr) Filter(attachments []jira.Attachment) []jira.Attachment {
+	var out []jira.Attachment
+	for i := range attachments {
+		if e.Match(&attachments[i]) {
+			out = append(out, attachments[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,275 @@
+package where
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+var (
+	report = jira.Attachment{
+		ID:       "1",
+		Filename: "report.pdf",
+		MimeType: "application/pdf",
+		Size:     60 * 1024 * 1024,
+		Created:  "2024-05-10T10:00:00.000+0000",
+		Author:   jira.User{AccountID: "a-1", DisplayName: "Jane Contractor"},
+	}
+	dump = jira.Attachment{
+		ID:       "2",
+		Filename: "dump.tar.gz",
+		MimeType: "application/gzip",
+		Size:     80 * 1024 * 1024,
+		Created:  "2024-03-01T10:00:00.000+0000",
+		Author:   jira.User{AccountID: "a-1", DisplayName: "Jane Contractor"},
+	}
+	screenshot = jira.Attachment{
+		ID:       "3",
+		Filename: "Screenshot.PNG",
+		MimeType: "Image/PNG",
+		Size:     512 * 1024,
+		Created:  "2024-07-15T10:00:00.000+0000",
+		Author:   jira.User{Name: "bob", DisplayName: "Bob Employee"},
+	}
+	all = []jira.Attachment{report, dump, screenshot}
+)
+
+func ids(attachments []jira.Attachment) []string {
+	out := []string{}
+	for _, a := range attachments {
+		out = append(out, a.ID)
+	}
+	return out
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{name: "empty matches everything", expr: "  ", want: []string{"1", "2", "3"}},
+		{name: "size greater", expr: "size > 50MB", want: []string{"1", "2"}},
+		{name: "size less or equal", expr: "size <= 512KB", want: []string{"3"}},
+		{name: "size equal in bytes", expr: "size = 524288", want: []string{"3"}},
+		{name: "size not equal", expr: "size != 512k", want: []string{"1", "2"}},
+		{name: "created before date", expr: "created < 2024-06-01", want: []string{"1", "2"}},
+		{name: "created on date", expr: "created = 2024-05-10", want: []string{"1"}},
+		{name: "created after date excludes the day", expr: "created > 2024-05-10", want: []string{"3"}},
+		{name: "created on or after date includes the day", expr: "created >= 2024-05-10", want: []string{"1", "3"}},
+		{name: "created before timestamp", expr: "created < 2024-05-10T12:00:00Z", want: []string{"1", "2"}},
+		{name: "filename glob", expr: `filename ~ "*.pdf"`, want: []string{"1"}},
+		{name: "filename glob is case sensitive", expr: `filename ~ "*.png"`, want: []string{}},
+		{name: "filename not glob", expr: `filename !~ '*.pdf'`, want: []string{"2", "3"}},
+		{name: "filename equal bare word", expr: "filename = dump.tar.gz", want: []string{"2"}},
+		{name: "mimetype is case insensitive", expr: "mimetype ~ image/*", want: []string{"3"}},
+		{name: "author display name", expr: `author = "jane contractor"`, want: []string{"1", "2"}},
+		{name: "author user name", expr: "author = bob", want: []string{"3"}},
+		{name: "author account id glob", expr: "author ~ a-*", want: []string{"1", "2"}},
+		{name: "author not equal", expr: "author != bob", want: []string{"1", "2"}},
+		{
+			name: "combined",
+			expr: `size > 50MB and created < 2024-06-01 and not filename ~ "*.pdf"`,
+			want: []string{"2"},
+		},
+		{name: "and binds tighter than or", expr: "size < 1MB or size > 70MB and created < 2024-04-01", want: []string{"2", "3"}},
+		{name: "parentheses", expr: "(size < 1MB or size > 70MB) and created < 2024-04-01", want: []string{"2"}},
+		{name: "not binds tighter than and", expr: "not size < 1MB and author ~ *Contractor", want: []string{"1", "2"}},
+		{name: "double not", expr: "not not size < 1MB", want: []string{"3"}},
+		{name: "keywords are case insensitive", expr: "SIZE < 1MB OR Filename = report.pdf", want: []string{"1", "3"}},
+		{name: "operators without spaces", expr: "size>=80MB", want: []string{"2"}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			e, err := Compile(tc.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, ids(e.Filter(all)))
+		})
+	}
+}
+
+func TestMatchCreatedWithoutMilliseconds(t *testing.T) {
+	t.Parallel()
+
+	// Jira Server returns the creation date without milliseconds.
+	a := &jira.Attachment{Created: "2024-05-10T10:00:00+0000"}
+
+	for expr, want := range map[string]bool{
+		"created < 2024-06-01":           true,
+		"created = 2024-05-10":           true,
+		"created > 2024-05-10":           false,
+		"created < 2024-05-10T12:00:00Z": true,
+		"created > 2024-05-10T09:00:00Z": true,
+	} {
+		e, err := Compile(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, want, e.Match(a), expr)
+	}
+}
+
+func TestMatchInvalidCreated(t *testing.T) {
+	t.Parallel()
+
+	e, err := Compile("created < 2030-01-01")
+	assert.NoError(t, err)
+	assert.False(t, e.Match(&jira.Attachment{Created: "yesterday"}))
+}
+
+func TestCompileErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{
+			name: "missing value",
+			expr: "size > and created < 2024-01-01",
+			want: "expected a value after \">\", got \"and\"\n  size > and created < 2024-01-01\n         ^",
+		},
+		{
+			name: "unknown field",
+			expr: "owner = bob",
+			want: "unknown field \"owner\", expected one of author, created, filename, mimetype, size\n  owner = bob\n  ^",
+		},
+		{
+			name: "unsupported operator",
+			expr: "filename > a",
+			want: "operator \">\" is not supported for filename\n  filename > a\n           ^",
+		},
+		{
+			name: "unknown operator",
+			expr: "size =< 1MB",
+			want: "expected a value after \"=\", got \"<\"\n  size =< 1MB\n        ^",
+		},
+		{
+			name: "bad unit",
+			expr: "size > 10XB",
+			want: "invalid size \"10XB\", expected a number with an optional unit like 10MB\n  size > 10XB\n         ^",
+		},
+		{
+			name: "bad date",
+			expr: "created < 2024-13-01",
+			want: "invalid date \"2024-13-01\", expected YYYY-MM-DD or an RFC3339 timestamp\n  created < 2024-13-01\n            ^",
+		},
+		{
+			name: "unclosed parenthesis",
+			expr: "(size > 1MB",
+			want: "expected \")\", got end of expression\n  (size > 1MB\n             ^",
+		},
+		{
+			name: "unexpected closing parenthesis",
+			expr: "size > 1MB)",
+			want: "expected \"and\", \"or\" or end of expression, got \")\"\n  size > 1MB)\n            ^",
+		},
+		{
+			name: "missing operand",
+			expr: "size > 1MB and",
+			want: "expected a field, got end of expression\n  size > 1MB and\n                ^",
+		},
+		{
+			name: "missing operator",
+			expr: "size 1MB",
+			want: "expected an operator after size, got \"1MB\"\n  size 1MB\n       ^",
+		},
+		{
+			name: "missing boolean operator",
+			expr: "size > 1MB size < 2MB",
+			want: "expected \"and\", \"or\" or end of expression, got \"size\"\n  size > 1MB size < 2MB\n             ^",
+		},
+		{
+			name: "unterminated string",
+			expr: `filename ~ "*.pdf`,
+			want: "unterminated string\n  filename ~ \"*.pdf\n             ^",
+		},
+		{
+			name: "bad pattern",
+			expr: `filename ~ "[a"`,
+			want: "invalid pattern \"[a\"\n  filename ~ \"[a\"\n             ^",
+		},
+		{
+			name: "lone bang",
+			expr: "size ! 1",
+			want: "unknown operator \"!\"\n  size ! 1\n       ^",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Compile(tc.expr)
+			assert.EqualError(t, err, tc.want)
+
+			var werr *Error
+			assert.ErrorAs(t, err, &werr)
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in   string
+		want int64
+		err  bool
+	}{
+		{in: "0", want: 0},
+		{in: "512", want: 512},
+		{in: "512B", want: 512},
+		{in: "10KB", want: 10 * 1024},
+		{in: "10kib", want: 10 * 1024},
+		{in: "10k", want: 10 * 1024},
+		{in: "50MB", want: 50 * 1024 * 1024},
+		{in: "1.5GB", want: 1536 * 1024 * 1024},
+		{in: "2tb", want: 2 << 40},
+		{in: "MB", err: true},
+		{in: "10XB", err: true},
+		{in: "1.2.3MB", err: true},
+		{in: "", err: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseSize(tc.in)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	t.Parallel()
+
+	start, end, err := parseDate("2024-06-01")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local), start)
+	assert.Equal(t, time.Date(2024, 6, 1, 23, 59, 59, 999999999, time.Local), end)
+
+	start, end, err = parseDate("2024-06-01T10:00:00+02:00")
+	assert.NoError(t, err)
+	assert.True(t, start.Equal(end))
+	assert.Equal(t, "2024-06-01T08:00:00Z", start.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,117 @@
+// Package atomicfile writes files that must survive a crash of the process writing them,
+// eg: the progress file of a resumable download.
+//
+// A file is written to a temporary file in its directory, synced to disk and renamed over
+// the previous version, which is kept as a backup next to it. A crash at any point leaves
+// either the previous or the new content in place, never a truncated file. A file that is
+// corrupt anyway, eg: by a disk that lied about syncing, is read from its backup instead.
+package atomicfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BackupSuffix is appended to the name of a file to name its backup.
+const BackupSuffix = ".bak"
+
+// BackupPath returns the path of the backup of the file at path.
+func BackupPath(path string) string {
+	return path + BackupSuffix
+}
+
+// WriteFile writes data to the file at path with the given permission. The content the
+// file had, if any, is kept as its backup, replacing the previous backup.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// A crash between the renames leaves only the backup, which ReadFile falls back to.
+	if err := os.Rename(path, BackupPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes the renames of the directory to disk. It is best effort, some platforms,
+// eg: windows, can't sync a directory.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// CorruptError is returned by ReadFile if neither the file nor its backup are valid.
+type CorruptError struct {
+	Path string
+	Err  error
+}
+
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("%s is corrupt: %s", e.Path, e.Err)
+}
+
+func (e *CorruptError) Unwrap() error {
+	return e.Err
+}
+
+// ReadFile reads the file at path and checks its content with valid. If the file is
+// missing or invalid, its backup is read instead and fallback is called with the reason
+// the file was not used. If the backup can't be used either, the error about the file is
+// returned, a *CorruptError if it is invalid or os.ErrNotExist if neither exist.
+func ReadFile(path string, valid func([]byte) error, fallback func(reason error)) ([]byte, error) {
+	data, err := readValid(path, valid)
+	if err == nil {
+		return data, nil
+	}
+
+	backup, bErr := readValid(BackupPath(path), valid)
+	switch {
+	case bErr == nil:
+		fallback(err)
+		return backup, nil
+	case errors.Is(err, os.ErrNotExist) && !errors.Is(bErr, os.ErrNotExist):
+		return nil, bErr
+	}
+	return nil, err
+}
+
+func readValid(path string, valid func([]byte) error) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := valid(data); err != nil {
+		return nil, &CorruptError{Path: path, Err: err}
+	}
+	return data, nil
+}
@@ -0,0 +1,152 @@
+package atomicfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nonEmpty is a validator rejecting empty content, like a torn write would leave.
+func nonEmpty(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("empty")
+	}
+	return nil
+}
+
+func noFallback(t *testing.T) func(error) {
+	return func(reason error) { t.Errorf("unexpected fallback: %s", reason) }
+}
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	require.NoError(t, WriteFile(path, []byte("v1"), 0o600))
+	_, err := os.Stat(BackupPath(path))
+	assert.True(t, os.IsNotExist(err), "there is no backup of a new file")
+
+	require.NoError(t, WriteFile(path, []byte("v2"), 0o600))
+	require.NoError(t, WriteFile(path, []byte("v3"), 0o600))
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "v3", string(got))
+	backup, err := os.ReadFile(BackupPath(path))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(backup), "only the previous version is kept")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary file is left")
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestWriteFileMissingDir(t *testing.T) {
+	t.Parallel()
+
+	err := WriteFile(filepath.Join(t.TempDir(), "missing", "state.json"), []byte("v1"), 0o600)
+	assert.Error(t, err)
+}
+
+func TestReadFile(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		primary  *string
+		backup   *string
+		want     string
+		fallback bool
+		err      error
+	}{
+		{name: "primary", primary: ptr("v2"), backup: ptr("v1"), want: "v2"},
+		{name: "torn primary", primary: ptr(""), backup: ptr("v1"), want: "v1", fallback: true},
+		{name: "missing primary", backup: ptr("v1"), want: "v1", fallback: true},
+		{name: "both corrupt", primary: ptr(""), backup: ptr(""), err: &CorruptError{}},
+		{name: "corrupt without backup", primary: ptr(""), err: &CorruptError{}},
+		{name: "only a corrupt backup", backup: ptr(""), err: &CorruptError{}},
+		{name: "missing", err: os.ErrNotExist},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "state.json")
+			if tc.primary != nil {
+				require.NoError(t, os.WriteFile(path, []byte(*tc.primary), 0o600))
+			}
+			if tc.backup != nil {
+				require.NoError(t, os.WriteFile(BackupPath(path), []byte(*tc.backup), 0o600))
+			}
+
+			var reasons []error
+			got, err := ReadFile(path, nonEmpty, func(reason error) { reasons = append(reasons, reason) })
+
+			switch want := tc.err.(type) {
+			case nil:
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, string(got))
+			case *CorruptError:
+				var cErr *CorruptError
+				require.ErrorAs(t, err, &cErr)
+				assert.EqualError(t, cErr.Err, "empty")
+			default:
+				assert.ErrorIs(t, err, want)
+			}
+			if tc.fallback {
+				assert.Len(t, reasons, 1)
+			} else {
+				assert.Empty(t, reasons)
+			}
+		})
+	}
+}
+
+func TestReadFileAfterTornWrite(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, WriteFile(path, []byte(`{"done": 1}`), 0o600))
+	require.NoError(t, WriteFile(path, []byte(`{"done": 2}`), 0o600))
+
+	// A crash tears the file mid-content.
+	require.NoError(t, os.Truncate(path, 5))
+
+	valid := func(b []byte) error {
+		if string(b) != `{"done": 1}` && string(b) != `{"done": 2}` {
+			return errors.New("truncated")
+		}
+		return nil
+	}
+	var reason error
+	got, err := ReadFile(path, valid, func(r error) { reason = r })
+	require.NoError(t, err)
+	assert.Equal(t, `{"done": 1}`, string(got))
+	assert.EqualError(t, reason, path+" is corrupt: truncated")
+
+	// The next write replaces the torn file and keeps it as the backup.
+	require.NoError(t, WriteFile(path, []byte(`{"done": 3}`), 0o600))
+	got, err = ReadFile(path, func([]byte) error { return nil }, noFallback(t))
+	require.NoError(t, err)
+	assert.Equal(t, `{"done": 3}`, string(got))
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -41,6 +41,12 @@ func Browse(url string) error {
 	return cmd.Run()
 }
 
+// OpenFile opens the file at path with the default application of its type, using
+// xdg-open on Linux, open on macOS and the file protocol handler on Windows.
+func OpenFile(path string) error {
+	return browser.OpenFile(path)
+}
+
 func getBrowserFromENV() string {
 	br := os.Getenv("JIRA_BROWSER")
 	if br == "" {
@@ -0,0 +1,174 @@
+// Package dirlock implements an advisory lock on a directory so that
+// concurrent jira-cli invocations don't write to it at the same time.
+package dirlock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the lock file created in the locked directory.
+const FileName = ".jira-cli.lock"
+
+const pollInterval = 100 * time.Millisecond
+
+// errBusy is returned by the platform specific lock implementation if the lock is held.
+var errBusy = errors.New("dirlock: lock is held")
+
+// Holder describes the process holding the lock.
+type Holder struct {
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+// ErrLocked is returned if the directory is locked by another process.
+type ErrLocked struct {
+	Holder Holder
+}
+
+func (e *ErrLocked) Error() string {
+	if e.Holder.PID == 0 {
+		return "another jira-cli operation is in progress"
+	}
+	return fmt.Sprintf(
+		"another jira-cli operation is in progress (pid %d, started %s)",
+		e.Holder.PID, e.Holder.Started.Format(time.RFC3339),
+	)
+}
+
+// Options configures lock acquisition.
+type Options struct {
+	// Wait is how long to wait for the lock to be released. The
+	// lock acquisition fails immediately if it is zero.
+	Wait time.Duration
+	// Notify is called with a notice when a stale lock is broken.
+	Notify func(msg string)
+
+	alive func(pid int) bool
+	now   func() time.Time
+}
+
+// Lock is an acquired directory lock.
+type Lock struct {
+	file *os.File
+	path string
+}
+
+// Acquire locks the directory. The lock is advisory, it only
+// guards against other processes that use this package.
+func Acquire(dir string, opts Options) (*Lock, error) {
+	if opts.alive == nil {
+		opts.alive = ProcessAlive
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+
+	path := filepath.Join(dir, FileName)
+	deadline := opts.now().Add(opts.Wait)
+
+	for {
+		l, holder, err := tryAcquire(path, opts)
+		if err == nil {
+			return l, nil
+		}
+		if !errors.Is(err, errBusy) {
+			return nil, err
+		}
+		if !opts.now().Before(deadline) {
+			return nil, &ErrLocked{Holder: holder}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func tryAcquire(path string, opts Options) (*Lock, Holder, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, Holder{}, err
+	}
+
+	if err := lockFile(f); err != nil {
+		holder := readHolder(f)
+		_ = f.Close()
+
+		if errors.Is(err, errBusy) && holder.PID != 0 && !opts.alive(holder.PID) {
+			// The holder is gone but the lock wasn't released, eg: the lock file was
+			// inherited by a child process. Break the lock by replacing the file.
+			if opts.Notify != nil {
+				opts.Notify(fmt.Sprintf("Removing stale lock held by pid %d since %s", holder.PID, holder.Started.Format(time.RFC3339)))
+			}
+			if err := os.Remove(path); err != nil {
+				return nil, holder, err
+			}
+			return tryAcquire(path, Options{alive: func(int) bool { return true }, now: opts.now})
+		}
+		return nil, holder, err
+	}
+
+	// The file may have been removed by the previous holder after we opened it.
+	if !samePath(f, path) {
+		_ = unlockFile(f)
+		_ = f.Close()
+		return tryAcquire(path, opts)
+	}
+
+	if err := writeHolder(f, Holder{PID: os.Getpid(), Started: opts.now()}); err != nil {
+		_ = unlockFile(f)
+		_ = f.Close()
+		return nil, Holder{}, err
+	}
+	return &Lock{file: f, path: path}, Holder{}, nil
+}
+
+// Release releases the lock. It is safe to call it more than once.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	f := l.file
+	l.file = nil
+
+	return release(f, l.path)
+}
+
+func samePath(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi, pi)
+}
+
+func readHolder(f *os.File) Holder {
+	var h Holder
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return h
+	}
+	_ = json.Unmarshal(b, &h)
+	return h
+}
+
+func writeHolder(f *os.File, h Holder) error {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(b, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
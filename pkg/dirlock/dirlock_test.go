@@ -0,0 +1,110 @@
+package dirlock
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	l, err := Acquire(dir, Options{})
+	assert.NoError(t, err)
+
+	b, err := os.ReadFile(filepath.Join(dir, FileName))
+	assert.NoError(t, err)
+
+	var h Holder
+	assert.NoError(t, json.Unmarshal(b, &h))
+	assert.Equal(t, os.Getpid(), h.PID)
+
+	assert.NoError(t, l.Release())
+	assert.NoError(t, l.Release())
+
+	l, err = Acquire(dir, Options{})
+	assert.NoError(t, err)
+	assert.NoError(t, l.Release())
+}
+
+func TestAcquireHeld(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	first, err := Acquire(dir, Options{})
+	assert.NoError(t, err)
+	defer func() { _ = first.Release() }()
+
+	start := time.Now()
+	second, err := Acquire(dir, Options{})
+	assert.Nil(t, second)
+	assert.Less(t, time.Since(start), time.Second)
+
+	var lockErr *ErrLocked
+	assert.ErrorAs(t, err, &lockErr)
+	assert.Equal(t, os.Getpid(), lockErr.Holder.PID)
+	assert.Contains(t, err.Error(), "another jira-cli operation is in progress (pid")
+}
+
+func TestAcquireWaitTimeout(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	first, err := Acquire(dir, Options{})
+	assert.NoError(t, err)
+	defer func() { _ = first.Release() }()
+
+	start := time.Now()
+	_, err = Acquire(dir, Options{Wait: 300 * time.Millisecond})
+	assert.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	first, err := Acquire(dir, Options{})
+	assert.NoError(t, err)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = first.Release()
+	}()
+
+	second, err := Acquire(dir, Options{Wait: 5 * time.Second})
+	assert.NoError(t, err)
+	assert.NoError(t, second.Release())
+}
+
+func TestAcquireBreaksStaleLock(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// The first lock is held, but its holder claims to be a dead process.
+	first, err := Acquire(dir, Options{})
+	assert.NoError(t, err)
+	defer func() { _ = first.Release() }()
+
+	assert.NoError(t, writeHolder(first.file, Holder{PID: 999999, Started: time.Now()}))
+
+	var notices []string
+	second, err := Acquire(dir, Options{
+		Notify: func(msg string) { notices = append(notices, msg) },
+		alive:  func(pid int) bool { return pid != 999999 },
+	})
+	assert.NoError(t, err)
+	assert.Len(t, notices, 1)
+	assert.Contains(t, notices[0], "pid 999999")
+	assert.NoError(t, second.Release())
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package dirlock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func lockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errBusy
+	}
+	return err
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// release removes the lock file before unlocking it so that a process
+// waiting for the lock never acquires a file that is about to be removed.
+func release(f *os.File, path string) error {
+	rmErr := os.Remove(path)
+	if err := unlockFile(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return rmErr
+}
+
+// ProcessAlive reports if a process with the pid is running.
+func ProcessAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package dirlock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlock(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+
+	a, err := os.Create(path)
+	assert.NoError(t, err)
+	defer func() { _ = a.Close() }()
+
+	b, err := os.Open(path)
+	assert.NoError(t, err)
+	defer func() { _ = b.Close() }()
+
+	assert.NoError(t, lockFile(a))
+	assert.ErrorIs(t, lockFile(b), errBusy)
+	assert.NoError(t, unlockFile(a))
+	assert.NoError(t, lockFile(b))
+	assert.NoError(t, unlockFile(b))
+
+	assert.True(t, ProcessAlive(os.Getpid()))
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package dirlock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockFileEx(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+
+	a, err := os.Create(path)
+	assert.NoError(t, err)
+	defer func() { _ = a.Close() }()
+
+	b, err := os.OpenFile(path, os.O_RDWR, 0)
+	assert.NoError(t, err)
+	defer func() { _ = b.Close() }()
+
+	assert.NoError(t, lockFile(a))
+	assert.ErrorIs(t, lockFile(b), errBusy)
+
+	// The holder must stay readable while the lock is held.
+	_, err = os.ReadFile(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, unlockFile(a))
+	assert.NoError(t, lockFile(b))
+	assert.NoError(t, unlockFile(b))
+
+	assert.True(t, ProcessAlive(os.Getpid()))
+}
@@ -0,0 +1,277 @@
+// Package eol converts line endings of text content.
+package eol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Mode is a line ending conversion mode.
+type Mode string
+
+const (
+	// ModeNone leaves line endings untouched.
+	ModeNone Mode = ""
+	// ModeNative converts line endings to the ones of the current platform.
+	ModeNative Mode = "native"
+	// ModeLF converts line endings to LF.
+	ModeLF Mode = "lf"
+	// ModeCRLF converts line endings to CRLF.
+	ModeCRLF Mode = "crlf"
+)
+
+const sniffLen = 512
+
+// ParseMode parses and validates a line ending mode.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(s)); m {
+	case ModeNone, ModeNative, ModeLF, ModeCRLF:
+		return m, nil
+	}
+	return ModeNone, fmt.Errorf("invalid line ending mode %q, must be one of native, lf or crlf", s)
+}
+
+// Resolve resolves the native mode to the line ending of the given platform.
+func (m Mode) Resolve(goos string) Mode {
+	if m != ModeNative {
+		return m
+	}
+	if goos == "windows" {
+		return ModeCRLF
+	}
+	return ModeLF
+}
+
+// String returns the line ending in upper case, eg: CRLF.
+func (m Mode) String() string {
+	return strings.ToUpper(string(m.Resolve(runtime.GOOS)))
+}
+
+// IsText reports if the content is text based on the mime type or, if
+// the mime type is not conclusive, by sniffing the head of the content.
+func IsText(mimeType string, head []byte) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	for _, b := range head {
+		if b == 0 {
+			return false
+		}
+	}
+	return strings.HasPrefix(http.DetectContentType(head), "text/")
+}
+
+// converter converts line endings of content split into chunks. A CR at the end of a chunk
+// is held back until the next chunk tells whether it is part of a CRLF pair.
+type converter struct {
+	mode      Mode
+	pendingCR bool
+	prevCR    bool
+}
+
+// convert appends the converted chunk to out.
+func (c *converter) convert(out, in []byte) []byte {
+	for _, b := range in {
+		if c.mode == ModeCRLF {
+			if b == '\n' && !c.prevCR {
+				out = append(out, '\r')
+			}
+			out = append(out, b)
+			c.prevCR = b == '\r'
+			continue
+		}
+
+		switch {
+		case b == '\r':
+			if c.pendingCR {
+				out = append(out, '\r')
+			}
+			c.pendingCR = true
+		case b == '\n':
+			c.pendingCR = false
+			out = append(out, '\n')
+		default:
+			if c.pendingCR {
+				out = append(out, '\r')
+				c.pendingCR = false
+			}
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// flush appends a CR held back at the end of the content to out.
+func (c *converter) flush(out []byte) []byte {
+	if c.pendingCR {
+		c.pendingCR = false
+		return append(out, '\r')
+	}
+	return out
+}
+
+type reader struct {
+	converter
+	r   io.Reader
+	buf []byte
+	out []byte
+	err error
+}
+
+// NewReader returns a reader that converts line endings of r as per the mode.
+// A CRLF pair split across reads of the underlying reader is handled correctly.
+func NewReader(r io.Reader, mode Mode) io.Reader {
+	mode = mode.Resolve(runtime.GOOS)
+	if mode == ModeNone {
+		return r
+	}
+	return &reader{converter: converter{mode: mode}, r: r, buf: make([]byte, 32*1024)}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		n, err := r.r.Read(r.buf)
+		r.out = r.convert(r.out, r.buf[:n])
+		if err != nil {
+			r.out = r.flush(r.out)
+			r.err = err
+		}
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// Writer converts line endings of the text content written to it as per the mode, while
+// it streams to the underlying writer. The head of the content is held back until it tells
+// whether the content is text, binary content is written as is. Close must be called once
+// all content is written to write what is held back.
+type Writer struct {
+	converter
+	w         io.Writer
+	mimeType  string
+	head      []byte
+	decided   bool
+	text      bool
+	buf       []byte
+	closed    bool
+	converted bool
+}
+
+// NewWriter returns a writer that converts line endings of text content written to w as
+// per the mode. A CRLF pair split across writes is handled correctly. The mime type of the
+// content is used to tell if it is text, see IsText.
+func NewWriter(w io.Writer, mode Mode, mimeType string) *Writer {
+	mode = mode.Resolve(runtime.GOOS)
+	return &Writer{converter: converter{mode: mode}, w: w, mimeType: mimeType, decided: mode == ModeNone}
+}
+
+// Write writes p, converted if the content is text.
+func (w *Writer) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.head = append(w.head, p...)
+		if len(w.head) < sniffLen {
+			return len(p), nil
+		}
+		head := w.head
+		w.head = nil
+		if err := w.decide(head); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if err := w.write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes the content held back. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if !w.decided {
+		head := w.head
+		w.head = nil
+		if err := w.decide(head); err != nil {
+			return err
+		}
+	}
+	if !w.text {
+		return nil
+	}
+	if out := w.flush(w.buf[:0]); len(out) > 0 {
+		_, err := w.w.Write(out)
+		return err
+	}
+	return nil
+}
+
+// Converted reports if the content was text whose line endings were converted. It is only
+// known once the head of the content is written.
+func (w *Writer) Converted() bool {
+	return w.converted
+}
+
+func (w *Writer) decide(head []byte) error {
+	w.decided = true
+	w.text = w.mode != ModeNone && IsText(w.mimeType, head[:min(len(head), sniffLen)])
+	w.converted = w.text
+	return w.write(head)
+}
+
+func (w *Writer) write(p []byte) error {
+	if !w.text {
+		_, err := w.w.Write(p)
+		return err
+	}
+	w.buf = w.convert(w.buf[:0], p)
+	_, err := w.w.Write(w.buf)
+	return err
+}
+
+// ConvertFile converts line endings of the file at src and writes it to dst. Binary
+// files are left alone and nothing is written to dst, in which case false is returned.
+func ConvertFile(dst, src string, mode Mode, mimeType string) (bool, error) {
+	if mode == ModeNone {
+		return false, nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = in.Close() }()
+
+	br := bufio.NewReaderSize(in, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if !IsText(mimeType, head) {
+		return false, nil
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(out, NewReader(br, mode)); err != nil {
+		_ = out.Close()
+		return false, err
+	}
+	return true, out.Close()
+}
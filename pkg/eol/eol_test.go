@@ -0,0 +1,263 @@
+package eol
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// splitReader returns the content in reads of the given sizes.
+type splitReader struct {
+	data  []byte
+	sizes []int
+}
+
+func (r *splitReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := len(r.data)
+	if len(r.sizes) > 0 {
+		n = min(r.sizes[0], n)
+		r.sizes = r.sizes[1:]
+	}
+	n = copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "native", "lf", "crlf", "CRLF"} {
+		_, err := ParseMode(s)
+		assert.NoError(t, err, s)
+	}
+
+	_, err := ParseMode("cr")
+	assert.Error(t, err)
+
+	assert.Equal(t, ModeCRLF, ModeNative.Resolve("windows"))
+	assert.Equal(t, ModeLF, ModeNative.Resolve("linux"))
+	assert.Equal(t, ModeCRLF, ModeCRLF.Resolve("linux"))
+}
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		mode     Mode
+		input    string
+		sizes    []int
+		expected string
+	}{
+		{
+			name:     "lf to crlf",
+			mode:     ModeCRLF,
+			input:    "one\ntwo\nthree",
+			expected: "one\r\ntwo\r\nthree",
+		},
+		{
+			name:     "crlf to lf",
+			mode:     ModeLF,
+			input:    "one\r\ntwo\r\n",
+			expected: "one\ntwo\n",
+		},
+		{
+			name:     "mixed to lf",
+			mode:     ModeLF,
+			input:    "one\r\ntwo\nthree\rfour\r\r\n",
+			expected: "one\ntwo\nthree\rfour\r\n",
+		},
+		{
+			name:     "mixed to crlf",
+			mode:     ModeCRLF,
+			input:    "one\r\ntwo\nthree\rfour",
+			expected: "one\r\ntwo\r\nthree\rfour",
+		},
+		{
+			name:     "crlf split across reads to lf",
+			mode:     ModeLF,
+			input:    "one\r\ntwo\r\n",
+			sizes:    []int{4, 1, 3, 1},
+			expected: "one\ntwo\n",
+		},
+		{
+			name:     "crlf split across reads to crlf",
+			mode:     ModeCRLF,
+			input:    "one\r\ntwo\n",
+			sizes:    []int{4, 5},
+			expected: "one\r\ntwo\r\n",
+		},
+		{
+			name:     "trailing cr is kept",
+			mode:     ModeLF,
+			input:    "one\r",
+			sizes:    []int{3, 1},
+			expected: "one\r",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := &splitReader{data: []byte(tc.input), sizes: tc.sizes}
+			out, err := io.ReadAll(NewReader(src, tc.mode))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(out))
+
+			out, err = io.ReadAll(NewReader(iotest.OneByteReader(bytes.NewBufferString(tc.input)), tc.mode))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(out))
+		})
+	}
+}
+
+func TestIsText(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsText("text/plain", nil))
+	assert.True(t, IsText("application/octet-stream", []byte("plain log line\n")))
+	assert.False(t, IsText("application/octet-stream", []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0}))
+	assert.False(t, IsText("", []byte("text\x00with nul\n")))
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("a line of the log\n", 64)
+
+	cases := []struct {
+		name      string
+		mode      Mode
+		mimeType  string
+		input     string
+		sizes     []int
+		expected  string
+		converted bool
+	}{
+		{
+			name:      "lf to crlf",
+			mode:      ModeCRLF,
+			mimeType:  "text/plain",
+			input:     "one\ntwo\nthree",
+			expected:  "one\r\ntwo\r\nthree",
+			converted: true,
+		},
+		{
+			name:      "crlf to lf",
+			mode:      ModeLF,
+			mimeType:  "text/plain",
+			input:     "one\r\ntwo\r\n",
+			expected:  "one\ntwo\n",
+			converted: true,
+		},
+		{
+			name:      "mixed to lf",
+			mode:      ModeLF,
+			input:     "one\r\ntwo\nthree\rfour\r\r\n",
+			expected:  "one\ntwo\nthree\rfour\r\n",
+			converted: true,
+		},
+		{
+			name:      "crlf split across writes after the head to lf",
+			mode:      ModeLF,
+			input:     long + "one\r\ntwo\r",
+			sizes:     []int{len(long) + 4, 1, 3, 1},
+			expected:  strings.ReplaceAll(long, "\r\n", "\n") + "one\ntwo\r",
+			converted: true,
+		},
+		{
+			name:      "content longer than the head to crlf",
+			mode:      ModeCRLF,
+			input:     long,
+			sizes:     []int{100, 1000},
+			expected:  strings.ReplaceAll(long, "\n", "\r\n"),
+			converted: true,
+		},
+		{
+			name:     "binary is left alone",
+			mode:     ModeCRLF,
+			mimeType: "application/octet-stream",
+			input:    "\x00\x01\n\xff\r\n\x00" + long,
+			sizes:    []int{3, 700},
+			expected: "\x00\x01\n\xff\r\n\x00" + long,
+		},
+		{
+			name:     "no conversion without a mode",
+			mode:     ModeNone,
+			mimeType: "text/plain",
+			input:    "one\r\ntwo\n",
+			expected: "one\r\ntwo\n",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, sizes := range [][]int{tc.sizes, {1}} {
+				var out bytes.Buffer
+				w := NewWriter(&out, tc.mode, tc.mimeType)
+
+				data := []byte(tc.input)
+				for i := 0; len(data) > 0; i++ {
+					n := len(data)
+					if len(sizes) > 0 {
+						n = min(sizes[min(i, len(sizes)-1)], n)
+					}
+					written, err := w.Write(data[:n])
+					assert.NoError(t, err)
+					assert.Equal(t, n, written)
+					data = data[n:]
+				}
+				assert.NoError(t, w.Close())
+
+				assert.Equal(t, tc.expected, out.String())
+				assert.Equal(t, tc.converted, w.Converted())
+			}
+		})
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	text := filepath.Join(dir, "app.log")
+	assert.NoError(t, os.WriteFile(text, []byte("one\ntwo\n"), 0o644))
+
+	dst := filepath.Join(dir, "app.crlf.log")
+	converted, err := ConvertFile(dst, text, ModeCRLF, "text/plain")
+	assert.NoError(t, err)
+	assert.True(t, converted)
+
+	b, err := os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, "one\r\ntwo\r\n", string(b))
+
+	binary := []byte{0x00, 0x01, '\n', 0xff, '\r', '\n', 0x00}
+	bin := filepath.Join(dir, "blob.bin")
+	assert.NoError(t, os.WriteFile(bin, binary, 0o644))
+
+	converted, err = ConvertFile(filepath.Join(dir, "blob.crlf.bin"), bin, ModeCRLF, "application/octet-stream")
+	assert.NoError(t, err)
+	assert.False(t, converted)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3, "nothing is written for a binary file")
+}
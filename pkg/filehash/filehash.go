@@ -0,0 +1,116 @@
+// Package filehash hashes files in parallel and verifies them against expected sums.
+package filehash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// MaxDefaultWorkers caps the default number of workers. Hashing is usually
+// bound by the disk well before all cores of a large machine are busy.
+const MaxDefaultWorkers = 8
+
+// File is a file to hash.
+type File struct {
+	Path string
+	// Want is the expected hex encoded sum. Verification is skipped if it is empty.
+	Want string
+}
+
+// Result is the outcome of hashing a file.
+type Result struct {
+	File
+	Sum  string
+	Size int64
+	Err  error
+}
+
+// OK reports if the file was read and matches the expected sum, if any.
+func (r Result) OK() bool {
+	return r.Err == nil && (r.Want == "" || r.Want == r.Sum)
+}
+
+// Options configures Files.
+type Options struct {
+	// Workers is the number of files hashed concurrently, defaults to DefaultWorkers.
+	Workers int
+	// New creates the hash, defaults to SHA-256.
+	New func() hash.Hash
+
+	// open is used in tests to control how long each file takes.
+	open func(path string) (io.ReadCloser, error)
+}
+
+// DefaultWorkers returns GOMAXPROCS capped at MaxDefaultWorkers.
+func DefaultWorkers() int {
+	return min(runtime.GOMAXPROCS(0), MaxDefaultWorkers)
+}
+
+// Files hashes the files using a pool of workers. The results are in the
+// same order as the files regardless of the order in which they complete.
+// A file that can't be read is reported in its result and doesn't stop
+// the others. Files are streamed through the hash, so memory use doesn't
+// depend on their size.
+func Files(files []File, opts Options) []Result {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers()
+	}
+	if opts.New == nil {
+		opts.New = sha256.New
+	}
+	if opts.open == nil {
+		opts.open = func(path string) (io.ReadCloser, error) { return os.Open(path) }
+	}
+
+	var (
+		results = make([]Result, len(files))
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+
+	for w := 0; w < min(opts.Workers, len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Each worker writes to its own slots of results, so no locking is required.
+			for i := range jobs {
+				results[i] = hashFile(files[i], opts)
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func hashFile(f File, opts Options) Result {
+	res := Result{File: f}
+
+	r, err := opts.open(f.Path)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer func() { _ = r.Close() }()
+
+	h := opts.New()
+	if res.Size, err = io.Copy(h, r); err != nil {
+		res.Err = fmt.Errorf("%s: %w", f.Path, err)
+		return res
+	}
+	res.Sum = hex.EncodeToString(h.Sum(nil))
+
+	return res
+}
@@ -0,0 +1,166 @@
+package filehash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sum(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+type slowReader struct {
+	io.Reader
+	delay time.Duration
+	err   error
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.delay)
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.Reader.Read(p)
+}
+
+func (r *slowReader) Close() error { return nil }
+
+func TestFilesOrderedResults(t *testing.T) {
+	t.Parallel()
+
+	contents := map[string]string{
+		"a.txt": "first",
+		"b.txt": "second",
+		"c.txt": "corrupted",
+		"d.txt": "fourth",
+		"e.txt": "unreadable",
+		"f.txt": "sixth",
+	}
+	files := []File{
+		{Path: "a.txt", Want: sum("first")},
+		{Path: "b.txt", Want: sum("second")},
+		{Path: "c.txt", Want: sum("original")},
+		{Path: "d.txt"},
+		{Path: "e.txt", Want: sum("unreadable")},
+		{Path: "f.txt", Want: sum("sixth")},
+		{Path: "missing.txt"},
+	}
+
+	opts := Options{
+		Workers: 4,
+		open: func(path string) (io.ReadCloser, error) {
+			c, ok := contents[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			// Earlier files take longer, so they complete last.
+			delay := time.Duration(len(contents)-strings.IndexByte("abcdef", path[0])) * 5 * time.Millisecond
+			r := &slowReader{Reader: strings.NewReader(c), delay: delay}
+			if path == "e.txt" {
+				r.err = errors.New("input/output error")
+			}
+			return r, nil
+		},
+	}
+
+	results := Files(files, opts)
+
+	assert.Len(t, results, len(files))
+	for i, r := range results {
+		assert.Equal(t, files[i].Path, r.Path)
+	}
+
+	assert.True(t, results[0].OK())
+	assert.Equal(t, int64(len("first")), results[0].Size)
+	assert.True(t, results[1].OK())
+
+	// A corrupted file is reported without affecting the others.
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, sum("corrupted"), results[2].Sum)
+	assert.False(t, results[2].OK())
+
+	// Files without an expected sum are only hashed.
+	assert.True(t, results[3].OK())
+	assert.Equal(t, sum("fourth"), results[3].Sum)
+
+	assert.EqualError(t, results[4].Err, "e.txt: input/output error")
+	assert.False(t, results[4].OK())
+
+	assert.True(t, results[5].OK())
+
+	assert.ErrorIs(t, results[6].Err, os.ErrNotExist)
+	assert.False(t, results[6].OK())
+}
+
+func TestFilesFromDisk(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	var files []File
+	for i := 0; i < 20; i++ {
+		content := strings.Repeat(fmt.Sprintf("line %d\n", i), i*100)
+		path := filepath.Join(dir, fmt.Sprintf("%02d.txt", i))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		files = append(files, File{Path: path, Want: sum(content)})
+	}
+
+	results := Files(files, Options{})
+	for i, r := range results {
+		assert.Equal(t, files[i].Path, r.Path)
+		assert.True(t, r.OK(), r.Path)
+	}
+}
+
+func TestFilesEmpty(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, Files(nil, Options{}))
+}
+
+func TestDefaultWorkers(t *testing.T) {
+	t.Parallel()
+
+	w := DefaultWorkers()
+	assert.GreaterOrEqual(t, w, 1)
+	assert.LessOrEqual(t, w, MaxDefaultWorkers)
+}
+
+func BenchmarkFiles(b *testing.B) {
+	const (
+		count = 16
+		size  = 4 << 20
+	)
+
+	dir := b.TempDir()
+	data := []byte(strings.Repeat("x", size))
+
+	files := make([]File, count)
+	for i := range files {
+		path := filepath.Join(dir, fmt.Sprintf("%02d.bin", i))
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			b.Fatal(err)
+		}
+		files[i] = File{Path: path}
+	}
+
+	for _, workers := range []int{1, 2, 4, MaxDefaultWorkers} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(count * size)
+			for i := 0; i < b.N; i++ {
+				Files(files, Options{Workers: workers})
+			}
+		})
+	}
+}
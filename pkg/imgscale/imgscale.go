@@ -0,0 +1,167 @@
+// Package imgscale downscales PNG and JPEG images.
+package imgscale
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// FormatPNG is the format name of PNG images.
+	FormatPNG = "png"
+	// FormatJPEG is the format name of JPEG images.
+	FormatJPEG = "jpeg"
+
+	// DefaultJPEGQuality is the quality of re-encoded JPEG images if none is given.
+	DefaultJPEGQuality = 85
+)
+
+// Options are the options of a resize.
+type Options struct {
+	// MaxDimension is the maximum width and height of the image in pixels.
+	MaxDimension int
+	// JPEGQuality is the quality of re-encoded JPEG images, from 1 to 100.
+	JPEGQuality int
+}
+
+// Result describes a resize. Width, Height and Size are the ones of the original image,
+// NewWidth, NewHeight and NewSize the ones of the resized image if it was Resized.
+type Result struct {
+	Format    string
+	Width     int
+	Height    int
+	Size      int64
+	NewWidth  int
+	NewHeight int
+	NewSize   int64
+	Resized   bool
+}
+
+// Fit scales the dimensions down to fit within limit pixels, preserving the aspect ratio.
+// Dimensions that already fit, or a limit of 0, are returned as is.
+func Fit(width, height, limit int) (int, int) {
+	if limit <= 0 || (width <= limit && height <= limit) {
+		return width, height
+	}
+
+	if width >= height {
+		return limit, scaled(height, limit, width)
+	}
+	return scaled(width, limit, height), limit
+}
+
+// scaled returns n * num / den rounded to the nearest pixel, at least 1.
+func scaled(n, num, den int) int {
+	v := (2*n*num + den) / (2 * den)
+	return max(v, 1)
+}
+
+// ResizeFile writes a downscaled copy of the PNG or JPEG image at src to dst if it is larger
+// than the maximum dimension in either direction. The EXIF orientation of JPEG images is applied
+// before resizing, as it is lost with the other metadata when the image is re-encoded.
+//
+// Other files and images that already fit are left alone: Resized is false and dst is not
+// written. The source file is never modified.
+func ResizeFile(dst, src string, opts Options) (*Result, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	res := Result{Size: info.Size()}
+
+	// Only the header is read to tell whether the file is an image that needs resizing.
+	cfg, format, err := image.DecodeConfig(bufio.NewReader(f))
+	if err != nil || (format != FormatPNG && format != FormatJPEG) {
+		// Not an image we can resize.
+		return &res, nil
+	}
+	res.Format, res.Width, res.Height = format, cfg.Width, cfg.Height
+
+	if w, h := Fit(cfg.Width, cfg.Height, opts.MaxDimension); w == cfg.Width && h == cfg.Height {
+		return &res, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode image: %w", err)
+	}
+	if format == FormatJPEG {
+		img = Orient(img, Orientation(data))
+	}
+
+	resized := Resize(img, opts.MaxDimension)
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return nil, err
+	}
+	cw := &countingWriter{w: out}
+	if err := encode(cw, resized, format, opts.JPEGQuality); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+
+	b := resized.Bounds()
+	res.NewWidth, res.NewHeight, res.NewSize, res.Resized = b.Dx(), b.Dy(), cw.n, true
+	return &res, nil
+}
+
+// Resize downscales the image to fit within limit pixels, preserving the aspect ratio.
+func Resize(img image.Image, limit int) image.Image {
+	b := img.Bounds()
+	w, h := Fit(b.Dx(), b.Dy(), limit)
+	if w == b.Dx() && h == b.Dy() {
+		return img
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func encode(w io.Writer, img image.Image, format string, quality int) error {
+	if format == FormatPNG {
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		return enc.Encode(w, img)
+	}
+	if quality <= 0 {
+		quality = DefaultJPEGQuality
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: min(quality, 100)})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,247 @@
+package imgscale
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFit(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		width, height int
+		limit         int
+		wantW, wantH  int
+	}{
+		{name: "no limit", width: 4000, height: 3000, limit: 0, wantW: 4000, wantH: 3000},
+		{name: "already fits", width: 800, height: 600, limit: 1600, wantW: 800, wantH: 600},
+		{name: "exactly at limit", width: 1600, height: 900, limit: 1600, wantW: 1600, wantH: 900},
+		{name: "landscape", width: 4000, height: 3000, limit: 1600, wantW: 1600, wantH: 1200},
+		{name: "portrait", width: 3000, height: 4000, limit: 1600, wantW: 1200, wantH: 1600},
+		{name: "square", width: 2048, height: 2048, limit: 1000, wantW: 1000, wantH: 1000},
+		{name: "rounds to nearest", width: 3024, height: 1964, limit: 1600, wantW: 1600, wantH: 1039},
+		{name: "keeps one pixel", width: 10000, height: 2, limit: 100, wantW: 100, wantH: 1},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, h := Fit(tc.width, tc.height, tc.limit)
+			assert.Equal(t, tc.wantW, w)
+			assert.Equal(t, tc.wantH, h)
+		})
+	}
+}
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func writePNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF segment with the orientation tag right after the SOI marker.
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifTypeShort)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(seg)+2))
+	app1 = append(app1, seg...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func decodeConfig(t *testing.T, path string) (image.Config, string) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	cfg, format, err := image.DecodeConfig(f)
+	assert.NoError(t, err)
+	return cfg, format
+}
+
+func TestResizeFilePNG(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "shot.png")
+	dst := filepath.Join(dir, "out.png")
+	writePNG(t, src, testImage(300, 200))
+	orig, err := os.ReadFile(src)
+	assert.NoError(t, err)
+
+	res, err := ResizeFile(dst, src, Options{MaxDimension: 120})
+	assert.NoError(t, err)
+	assert.True(t, res.Resized)
+	assert.Equal(t, FormatPNG, res.Format)
+	assert.Equal(t, 300, res.Width)
+	assert.Equal(t, 200, res.Height)
+	assert.Equal(t, 120, res.NewWidth)
+	assert.Equal(t, 80, res.NewHeight)
+	assert.Equal(t, int64(len(orig)), res.Size)
+
+	info, err := os.Stat(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), res.NewSize)
+
+	cfg, format := decodeConfig(t, dst)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, 120, cfg.Width)
+	assert.Equal(t, 80, cfg.Height)
+
+	// The source file is never modified.
+	after, err := os.ReadFile(src)
+	assert.NoError(t, err)
+	assert.Equal(t, orig, after)
+}
+
+func TestResizeFileJPEGKeepsAspectRatio(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "photo.jpg")
+	dst := filepath.Join(dir, "out.jpg")
+	assert.NoError(t, os.WriteFile(src, encodeJPEG(t, testImage(200, 500)), 0o600))
+
+	res, err := ResizeFile(dst, src, Options{MaxDimension: 100, JPEGQuality: 70})
+	assert.NoError(t, err)
+	assert.True(t, res.Resized)
+	assert.Equal(t, FormatJPEG, res.Format)
+
+	cfg, format := decodeConfig(t, dst)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 40, cfg.Width)
+	assert.Equal(t, 100, cfg.Height)
+}
+
+func TestResizeFilePassThrough(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.png")
+	writePNG(t, small, testImage(64, 48))
+	text := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, os.WriteFile(text, []byte("not an image"), 0o600))
+
+	for _, src := range []string{small, text} {
+		dst := filepath.Join(dir, "out")
+		res, err := ResizeFile(dst, src, Options{MaxDimension: 100})
+		assert.NoError(t, err)
+		assert.False(t, res.Resized)
+
+		_, err = os.Stat(dst)
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	res, err := ResizeFile(filepath.Join(dir, "out"), small, Options{MaxDimension: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, 64, res.Width)
+	assert.Equal(t, 48, res.Height)
+}
+
+func TestResizeFileAppliesOrientation(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "phone.jpg")
+	dst := filepath.Join(dir, "out.jpg")
+
+	// Stored landscape, displayed portrait.
+	data := withOrientation(encodeJPEG(t, testImage(400, 200)), 6)
+	assert.NoError(t, os.WriteFile(src, data, 0o600))
+	assert.Equal(t, 6, Orientation(data))
+
+	res, err := ResizeFile(dst, src, Options{MaxDimension: 100})
+	assert.NoError(t, err)
+	assert.True(t, res.Resized)
+	assert.Equal(t, 50, res.NewWidth)
+	assert.Equal(t, 100, res.NewHeight)
+
+	cfg, _ := decodeConfig(t, dst)
+	assert.Equal(t, 50, cfg.Width)
+	assert.Equal(t, 100, cfg.Height)
+}
+
+func TestOrientation(t *testing.T) {
+	t.Parallel()
+
+	plain := encodeJPEG(t, testImage(8, 8))
+	assert.Equal(t, OrientationNormal, Orientation(plain))
+	assert.Equal(t, OrientationNormal, Orientation([]byte("not a jpeg")))
+	assert.Equal(t, 3, Orientation(withOrientation(plain, 3)))
+	assert.Equal(t, OrientationNormal, Orientation(withOrientation(plain, 9)))
+}
+
+func TestOrient(t *testing.T) {
+	t.Parallel()
+
+	// A 3x2 image with a marked top left pixel.
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	mark := color.NRGBA{R: 255, A: 255}
+	img.Set(0, 0, mark)
+
+	cases := []struct {
+		orientation  int
+		w, h         int
+		markX, markY int
+	}{
+		{orientation: 1, w: 3, h: 2, markX: 0, markY: 0},
+		{orientation: 2, w: 3, h: 2, markX: 2, markY: 0},
+		{orientation: 3, w: 3, h: 2, markX: 2, markY: 1},
+		{orientation: 4, w: 3, h: 2, markX: 0, markY: 1},
+		{orientation: 5, w: 2, h: 3, markX: 0, markY: 0},
+		{orientation: 6, w: 2, h: 3, markX: 1, markY: 0},
+		{orientation: 7, w: 2, h: 3, markX: 1, markY: 2},
+		{orientation: 8, w: 2, h: 3, markX: 0, markY: 2},
+	}
+
+	for _, tc := range cases {
+		out := Orient(img, tc.orientation)
+		assert.Equal(t, tc.w, out.Bounds().Dx(), "orientation %d", tc.orientation)
+		assert.Equal(t, tc.h, out.Bounds().Dy(), "orientation %d", tc.orientation)
+		assert.Equal(t, mark, color.NRGBAModel.Convert(out.At(tc.markX, tc.markY)), "orientation %d", tc.orientation)
+	}
+}
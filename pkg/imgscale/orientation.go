@@ -0,0 +1,131 @@
+package imgscale
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+const (
+	// OrientationNormal is the EXIF orientation of an image stored upright.
+	OrientationNormal = 1
+
+	exifOrientationTag = 0x0112
+	exifTypeShort      = 3
+)
+
+// Orientation reads the EXIF orientation of a JPEG image, from 1 to 8. Images without EXIF
+// data, or with a value that can't be read, are reported as OrientationNormal.
+func Orientation(jpegData []byte) int {
+	exif := exifSegment(jpegData)
+	if len(exif) < 8 {
+		return OrientationNormal
+	}
+
+	var order binary.ByteOrder
+	switch string(exif[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return OrientationNormal
+	}
+	if order.Uint16(exif[2:4]) != 42 {
+		return OrientationNormal
+	}
+
+	ifd := int(order.Uint32(exif[4:8]))
+	if ifd < 8 || ifd+2 > len(exif) {
+		return OrientationNormal
+	}
+	entries := int(order.Uint16(exif[ifd : ifd+2]))
+	for i := range entries {
+		e := ifd + 2 + i*12
+		if e+12 > len(exif) {
+			break
+		}
+		if order.Uint16(exif[e:e+2]) != exifOrientationTag || order.Uint16(exif[e+2:e+4]) != exifTypeShort {
+			continue
+		}
+		if o := int(order.Uint16(exif[e+8 : e+10])); o >= 1 && o <= 8 {
+			return o
+		}
+		break
+	}
+	return OrientationNormal
+}
+
+// exifSegment returns the TIFF data of the EXIF APP1 segment of a JPEG image.
+func exifSegment(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		// Start of scan, the metadata segments are all before it.
+		if marker == 0xDA {
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + size
+		if size < 2 || end > len(data) {
+			return nil
+		}
+		seg := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return seg[6:]
+		}
+		i = end
+	}
+	return nil
+}
+
+// Orient applies the EXIF orientation to the image, so that it is upright.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= OrientationNormal || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally.
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180°.
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored vertically.
+				dx, dy = x, h-1-y
+			case 5: // Transposed.
+				dx, dy = y, x
+			case 6: // Rotated 90° clockwise to be upright.
+				dx, dy = h-1-y, x
+			case 7: // Transversed.
+				dx, dy = h-1-y, w-1-x
+			case 8: // Rotated 90° counterclockwise to be upright.
+				dx, dy = y, w-1-x
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
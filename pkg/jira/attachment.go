@@ -1,25 +1,22 @@
 package jira
 
 import (
+	"bufio"
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // applyAuth applies authentication to the HTTP request.
 func (c *Client) applyAuth(req *http.Request) {
-	// Set default auth type to `basic`.
-	if c.authType == nil {
-		basic := AuthTypeBasic
-		c.authType = &basic
-	}
-
 	// Apply authentication
 	switch c.authType.String() {
 	case string(AuthTypeMTLS):
@@ -33,95 +30,404 @@ func (c *Client) applyAuth(req *http.Request) {
 	}
 }
 
+// DownloadResult holds the outcome of an attachment download.
+type DownloadResult struct {
+	// Bytes is the number of bytes written to the destination.
+	Bytes int64
+	// Total is the size advertised by the server. It is only
+	// meaningful if TotalKnown is true.
+	Total int64
+	// TotalKnown is false if the server didn't advertise the content
+	// length, eg: when the response is chunked or a proxy stripped it.
+	TotalKnown bool
+	// ETag and LastModified are the validators sent by the server. They
+	// can be passed to a later download to skip unchanged attachments.
+	ETag         string
+	LastModified string
+	// NotModified is true if the server responded with 304 to a
+	// conditional request. Nothing is written to the destination.
+	NotModified bool
+}
+
+type downloadOptions struct {
+	ctx             context.Context
+	ifNoneMatch     string
+	ifModifiedSince string
+	mimeType        string
+	size            int64
+	budget          *ByteBudget
+	ranges          int
+	mode            os.FileMode
+	stallTimeout    time.Duration
+	stallRetries    int
+	contentCheck    func(head []byte) error
+	progress        ProgressFunc
+	// counter adds up the progress of the ranges of a download.
+	counter *progressCounter
+}
+
+// DownloadOption is a functional option for attachment downloads.
+type DownloadOption func(*downloadOptions)
+
+// IfNoneMatch makes the download conditional on the ETag of the attachment.
+func IfNoneMatch(etag string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.ifNoneMatch = etag
+	}
+}
+
+// IfModifiedSince makes the download conditional on the modification date of the attachment.
+// The value is usually the LastModified of a prior download result.
+func IfModifiedSince(lastModified string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.ifModifiedSince = lastModified
+	}
+}
+
+// ExpectContent passes the mime type and size of the attachment from its metadata.
+// It is used to detect a login page served in place of a non-HTML attachment.
+func ExpectContent(mimeType string, size int64) DownloadOption {
+	return func(o *downloadOptions) {
+		o.mimeType = mimeType
+		o.size = size
+	}
+}
+
+// ContentSniffLen is the number of bytes passed to the check of WithContentCheck, the
+// head of the content, like http.DetectContentType considers.
+const ContentSniffLen = 512
+
+// WithContentCheck passes the first ContentSniffLen bytes of the content, or all of it
+// if it is shorter, to check before any byte is written. If check returns an error, the
+// download is aborted with it and no file is created. The attachment is downloaded as a
+// single stream, WithParallelRanges is ignored, so that its head is checked first.
+func WithContentCheck(check func(head []byte) error) DownloadOption {
+	return func(o *downloadOptions) {
+		o.contentCheck = check
+	}
+}
+
 // DownloadAttachment downloads an attachment from the given URL to the specified file path.
 func (c *Client) DownloadAttachment(url, destPath string) error {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return err
+	return c.DownloadAttachmentContext(context.Background(), url, destPath)
+}
+
+// DownloadAttachmentContext is DownloadAttachment with a context. If the context is cancelled,
+// the transfer is aborted and the partially written file is removed.
+func (c *Client) DownloadAttachmentContext(ctx context.Context, url, destPath string) error {
+	_, err := c.DownloadAttachmentWithResultContext(ctx, url, destPath)
+	return err
+}
+
+// DownloadAttachmentWithResult downloads an attachment from the given URL to the
+// specified file path and reports how many bytes were transferred.
+//
+// The attachment is written to a temporary file in the directory of the path, see package
+// tmpfile, and renamed to the path once it was received in full. A failed download
+// leaves the path as it was, the temporary file is removed.
+//
+// The downloaded size is verified against the Content-Length header only if the
+// server advertised one. Verification is skipped for chunked responses.
+//
+// If a conditional option is given and the server responds with 304, the result
+// has NotModified set and the destination is left untouched. Servers that ignore
+// conditional headers simply send the attachment again.
+//
+// Conditional downloads are always sent as a single stream, WithParallelRanges is
+// ignored for them. Bytes of a ranged attempt that falls back to a single stream
+// count against the byte budget.
+//
+// A transfer that stalls is retried, see WithStallTimeout.
+func (c *Client) DownloadAttachmentWithResult(url, destPath string, opts ...DownloadOption) (*DownloadResult, error) {
+	return c.DownloadAttachmentWithResultContext(context.Background(), url, destPath, opts...)
+}
+
+// DownloadAttachmentWithResultContext is DownloadAttachmentWithResult with a context. If the
+// context is cancelled, the transfer is aborted and the partially written file is removed.
+func (c *Client) DownloadAttachmentWithResultContext(
+	ctx context.Context, url, destPath string, opts ...DownloadOption,
+) (*DownloadResult, error) {
+	if url == "" {
+		return nil, ErrAttachmentUnavailable
 	}
 
-	c.applyAuth(req)
+	o := newDownloadOptions(ctx, opts)
+	return atomicDownload(destPath, func(path string) (*DownloadResult, error) {
+		if o.ranges > 1 && o.ifNoneMatch == "" && o.ifModifiedSince == "" && o.contentCheck == nil {
+			result, err := c.downloadRanges(url, path, o)
+			if !errors.Is(err, errRangesUnsupported) {
+				return result, err
+			}
+			c.logf(LogDetail, "Range requests are not supported for %s, downloading as a single stream", url)
+		}
+
+		return c.downloadStream(url, o, func(body io.Reader, total int64) (int64, error) {
+			return c.writeAttachment(path, body, total, o.mode)
+		}, func() {
+			_ = os.Remove(path)
+		})
+	})
+}
+
+// DownloadAttachmentTo streams an attachment from the given URL to w, eg: into an
+// archive, and reports how many bytes were transferred. It behaves like
+// DownloadAttachmentWithResult except that WithParallelRanges and WithFileMode are
+// ignored. If the size received doesn't match the Content-Length, an error is
+// returned but the bytes were already written to w.
+func (c *Client) DownloadAttachmentTo(url string, w io.Writer, opts ...DownloadOption) (*DownloadResult, error) {
+	return c.DownloadAttachmentToContext(context.Background(), url, w, opts...)
+}
+
+// DownloadAttachmentToContext is DownloadAttachmentTo with a context.
+func (c *Client) DownloadAttachmentToContext(
+	ctx context.Context, url string, w io.Writer, opts ...DownloadOption,
+) (*DownloadResult, error) {
+	if url == "" {
+		return nil, ErrAttachmentUnavailable
+	}
+
+	return c.downloadStream(url, newDownloadOptions(ctx, opts), func(body io.Reader, _ int64) (int64, error) {
+		return io.Copy(w, body)
+	}, nil)
+}
+
+func newDownloadOptions(ctx context.Context, opts []DownloadOption) downloadOptions {
+	o := downloadOptions{ctx: ctx, stallTimeout: DefaultStallTimeout, stallRetries: DefaultStallRetries}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// downloadStream downloads an attachment as a single stream and passes the body to
+// write. discard, if set, is called if the size written doesn't match the content length.
+func (c *Client) downloadStream(
+	url string, o downloadOptions, write func(body io.Reader, total int64) (int64, error), discard func(),
+) (*DownloadResult, error) {
+	res, attempts, err := c.withAttachmentRetryContext(o.ctx, AttachmentOpDownload, func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	httpClient := &http.Client{Transport: c.transport}
-	res, err := httpClient.Do(req)
+		c.applyAuth(req)
+		if o.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", o.ifNoneMatch)
+		}
+		if o.ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", o.ifModifiedSince)
+		}
+
+		return c.doAttachment(req.WithContext(o.ctx))
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = res.Body.Close() }()
 
+	if res.StatusCode == http.StatusNotModified {
+		return &DownloadResult{
+			ETag:         firstNonEmpty(res.Header.Get("ETag"), o.ifNoneMatch),
+			LastModified: firstNonEmpty(res.Header.Get("Last-Modified"), o.ifModifiedSince),
+			NotModified:  true,
+		}, nil
+	}
 	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download attachment: %s", res.Status)
+		if ClassifyAttachmentStatus(res.StatusCode) == StatusTransient || attempts > 1 {
+			return nil, newAttachmentError(AttachmentOpDownload, res, attempts)
+		}
+		return nil, fmt.Errorf("failed to download attachment: %s%w", res.Status, formatUnexpectedResponse(res))
+	}
+
+	total, known := contentLength(res.ContentLength)
+	result := DownloadResult{
+		Total:        total,
+		TotalKnown:   known,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+
+	var raw io.Reader = res.Body
+	if o.stallTimeout > 0 {
+		rr := c.newResumingReader(url, res, 0, 0, known && acceptsRanges(res), o)
+		defer func() { _ = rr.Close() }()
+		raw = rr
 	}
 
-	// Create the destination file
-	out, err := os.Create(destPath)
+	body, err := guardLoginPage(raw, res, o)
 	if err != nil {
-		return err
+		return &result, err
+	}
+	if o.contentCheck != nil {
+		br := bufio.NewReaderSize(body, ContentSniffLen)
+		head, err := br.Peek(ContentSniffLen)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return &result, wrapTimeout(err, hostOf(url), TimeoutPhaseTransfer)
+		}
+		if err := o.contentCheck(head); err != nil {
+			return &result, err
+		}
+		body = br
+	}
+	if o.budget != nil {
+		if o.budget.Exceeded() {
+			return &result, &ErrSizeCapExceeded{Limit: o.budget.Limit(), Used: o.budget.Used()}
+		}
+		body = &budgetReader{r: body, budget: o.budget}
+	}
+	if o.progress != nil {
+		body = &ProgressReader{r: body, c: newProgressCounter(total, o.progress)}
 	}
-	defer func() { _ = out.Close() }()
 
-	// Copy the response body to the file
-	_, err = io.Copy(out, res.Body)
-	return err
+	result.Bytes, err = write(body, total)
+	if err != nil {
+		if result.TotalKnown && errors.Is(err, io.ErrUnexpectedEOF) {
+			return &result, fmt.Errorf(
+				"failed to download attachment: connection closed after %d of %d bytes: %w", result.Bytes, result.Total, err,
+			)
+		}
+		return &result, wrapTimeout(err, hostOf(url), TimeoutPhaseTransfer)
+	}
+
+	if !result.TotalKnown {
+		c.logf(LogDetail, "Content length of %s is unknown, skipping size verification", url)
+		return &result, nil
+	}
+	if result.Bytes != result.Total {
+		if discard != nil {
+			discard()
+		}
+		return &result, fmt.Errorf(
+			"failed to download attachment: received %d bytes, expected %d", result.Bytes, result.Total,
+		)
+	}
+	return &result, nil
+}
+
+// guardLoginPage checks if a small HTML page was received in place of an attachment
+// that isn't HTML according to its metadata. The returned reader must be used to
+// read the body as part of it may have been consumed.
+func guardLoginPage(body io.Reader, res *http.Response, o downloadOptions) (io.Reader, error) {
+	if o.mimeType == "" || isHTMLType(o.mimeType) || !isHTMLType(res.Header.Get("Content-Type")) {
+		return body, nil
+	}
+
+	br := bufio.NewReaderSize(body, loginPageMaxSize)
+	head, err := br.Peek(loginPageMaxSize)
+	if errors.Is(err, io.EOF) && int64(len(head)) != o.size {
+		return nil, &ErrAuthenticationRequired{Title: pageTitle(head)}
+	}
+	return br, nil
+}
+
+// contentLength decides if the content length reported by the server can be trusted.
+//
+// A value of -1 means the length is unknown, eg: for chunked responses. A value of 0
+// is ambiguous as some proxies report it when they strip the header, so we treat it
+// as unknown as well.
+func contentLength(n int64) (int64, bool) {
+	if n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
 
 // UploadAttachment uploads a file as an attachment to the specified issue using v3 API.
-func (c *Client) UploadAttachment(key, filePath string) ([]Attachment, error) {
-	return c.uploadAttachment(key, filePath, apiVersion3)
+func (c *Client) UploadAttachment(key, filePath string, opts ...UploadOption) ([]Attachment, error) {
+	return c.uploadAttachment(key, filePath, filepath.Base(filePath), apiVersion3, opts)
 }
 
 // UploadAttachmentV2 uploads a file as an attachment to the specified issue using v2 API.
-func (c *Client) UploadAttachmentV2(key, filePath string) ([]Attachment, error) {
-	return c.uploadAttachment(key, filePath, apiVersion2)
+func (c *Client) UploadAttachmentV2(key, filePath string, opts ...UploadOption) ([]Attachment, error) {
+	return c.uploadAttachment(key, filePath, filepath.Base(filePath), apiVersion2, opts)
 }
 
-func (c *Client) uploadAttachment(key, filePath, ver string) ([]Attachment, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = file.Close() }()
+// UploadAttachmentAs uploads a file as an attachment with the given name using v3 API.
+func (c *Client) UploadAttachmentAs(key, filePath, name string, opts ...UploadOption) ([]Attachment, error) {
+	return c.uploadAttachment(key, filePath, name, apiVersion3, opts)
+}
 
-	// Create a buffer to hold the multipart form data
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// UploadAttachmentAsV2 uploads a file as an attachment with the given name using v2 API.
+func (c *Client) UploadAttachmentAsV2(key, filePath, name string, opts ...UploadOption) ([]Attachment, error) {
+	return c.uploadAttachment(key, filePath, name, apiVersion2, opts)
+}
 
-	// Create a form file field
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
-	if err != nil {
-		return nil, err
+// UploadAttachmentFromReader uploads the content read from r as an attachment with the given
+// name using v3 API. Content that can't be seeked is held in memory to be able to retry.
+func (c *Client) UploadAttachmentFromReader(key, name string, r io.Reader, opts ...UploadOption) ([]Attachment, error) {
+	return c.uploadAttachmentFromReader(key, name, r, apiVersion3, opts)
+}
+
+// UploadAttachmentFromReaderV2 is UploadAttachmentFromReader using v2 API.
+func (c *Client) UploadAttachmentFromReaderV2(key, name string, r io.Reader, opts ...UploadOption) ([]Attachment, error) {
+	return c.uploadAttachmentFromReader(key, name, r, apiVersion2, opts)
+}
+
+func (c *Client) uploadAttachmentFromReader(key, name string, r io.Reader, ver string, opts []UploadOption) ([]Attachment, error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		rs = bytes.NewReader(b)
 	}
+	return c.uploadContent(key, rs, name, ver, opts)
+}
 
-	// Copy the file content to the form field
-	_, err = io.Copy(part, file)
+func (c *Client) uploadAttachment(key, filePath, name, ver string, opts []UploadOption) ([]Attachment, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = file.Close() }()
+
+	return c.uploadContent(key, file, name, ver, opts)
+}
 
-	// Close the writer to finalize the multipart message
-	err = writer.Close()
+func (c *Client) uploadContent(key string, content io.ReadSeeker, name, ver string, opts []UploadOption) ([]Attachment, error) {
+	// The form is streamed from the content rather than buffered, large files included.
+	upload, err := newFileUpload(content, "file", name)
 	if err != nil {
 		return nil, err
 	}
+	upload.progress = newUploadOptions(opts).progress
 
 	path := fmt.Sprintf("/issue/%s/attachments", key)
 
 	// Set custom headers for multipart upload
 	headers := Header{
-		"Content-Type":      writer.FormDataContentType(),
+		"Content-Type":      upload.contentType,
 		"X-Atlassian-Token": "no-check", // Required to bypass CSRF protection
 	}
 
-	var res *http.Response
-
-	switch ver {
-	case apiVersion2:
-		res, err = c.postWithHeaders(context.Background(), c.server+baseURLv2+path, body.Bytes(), headers)
-	default:
-		res, err = c.postWithHeaders(context.Background(), c.server+baseURLv3+path, body.Bytes(), headers)
+	endpoint := c.server + baseURLv3 + path
+	if ver == apiVersion2 {
+		endpoint = c.server + baseURLv2 + path
 	}
 
+	res, attempts, err := c.withAttachmentRetry(AttachmentOpUpload, func() (*http.Response, error) {
+		body, err := upload.body()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = body.Close() }()
+
+		return c.postReader(context.Background(), endpoint, body, headers)
+	})
 	if err != nil {
+		if isConnectionDropped(err) {
+			return nil, &UploadInterruptedError{Size: upload.size, Err: err}
+		}
 		return nil, err
 	}
 	if res == nil {
@@ -130,24 +436,56 @@ func (c *Client) uploadAttachment(key, filePath, ver string) ([]Attachment, erro
 	defer func() { _ = res.Body.Close() }()
 
 	if res.StatusCode != http.StatusOK {
+		if ClassifyAttachmentStatus(res.StatusCode) == StatusTransient || attempts > 1 {
+			return nil, newAttachmentError(AttachmentOpUpload, res, attempts)
+		}
+		if res.StatusCode == http.StatusRequestEntityTooLarge {
+			return nil, c.uploadTooLarge(res, upload.size)
+		}
 		return nil, formatUnexpectedResponse(res)
 	}
 
 	var attachments []Attachment
-	err = json.NewDecoder(res.Body).Decode(&attachments)
+	err = decodeAttachmentResponse(res, &attachments)
 	if err != nil {
 		return nil, err
 	}
+	if len(attachments) == 0 {
+		return nil, ErrNoAttachmentCreated
+	}
 
 	return attachments, nil
 }
 
 // postWithHeaders is a helper method to send POST requests with custom body and headers.
 func (c *Client) postWithHeaders(ctx context.Context, endpoint string, body []byte, headers Header) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	return c.postReader(ctx, endpoint, bytes.NewReader(body), headers)
+}
+
+// postReader sends a POST request with a body read from r. The Content-Length is set if r
+// tells its size, like a bytes.Reader, otherwise the body is sent chunked. With debug enabled, or LogDump,
+// multipart bodies are dumped with the part headers and the first bytes of each part only.
+func (c *Client) postReader(ctx context.Context, endpoint string, r io.Reader, headers Header) (res *http.Response, err error) {
+	size := int64(-1)
+	if sr, ok := r.(interface{ Size() int64 }); ok {
+		size = sr.Size()
+	}
+
+	var sniffer *multipartSniffer
+	if c.debug || c.logs(LogDump) {
+		if mt, params, err := mime.ParseMediaType(headers["Content-Type"]); err == nil && strings.HasPrefix(mt, "multipart/") {
+			sniffer = newMultipartSniffer(r, params["boundary"], debugPartPreview)
+			r = sniffer
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, r)
 	if err != nil {
 		return nil, err
 	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
 
 	for k, v := range headers {
 		req.Header.Set(k, v)
@@ -155,8 +493,15 @@ func (c *Client) postWithHeaders(ctx context.Context, endpoint string, body []by
 
 	c.applyAuth(req)
 
-	httpClient := &http.Client{Transport: c.transport}
-	return httpClient.Do(req.WithContext(ctx))
+	switch {
+	case sniffer != nil && c.logs(LogDump):
+		// The transport of the logger dumps the headers, only the parts are left.
+		defer func() { logDump(c.logger, "Request Body", []byte(sniffer.Dump())) }()
+	case sniffer != nil:
+		defer func() { dumpMultipart(c.debugWriter(), req, res, sniffer) }()
+	}
+
+	return c.doAttachment(req.WithContext(ctx))
 }
 
 // DeleteAttachment deletes an attachment using v3 API.
@@ -172,19 +517,15 @@ func (c *Client) DeleteAttachmentV2(attachmentID string) error {
 func (c *Client) deleteAttachment(attachmentID, ver string) error {
 	path := fmt.Sprintf("/attachment/%s", attachmentID)
 
-	var (
-		res *http.Response
-		err error
-	)
-
-	switch ver {
-	case apiVersion2:
-		res, err = c.DeleteV2(context.Background(), path, nil)
-	default:
-		// v3 doesn't have Delete method, need to add it to client
-		res, err = c.delete(context.Background(), c.server+baseURLv3+path, nil)
-	}
-
+	res, attempts, err := c.withAttachmentRetry(AttachmentOpDelete, func() (*http.Response, error) {
+		switch ver {
+		case apiVersion2:
+			return c.DeleteV2(context.Background(), path, nil)
+		default:
+			// v3 doesn't have Delete method, need to add it to client
+			return c.delete(context.Background(), c.server+baseURLv3+path, nil)
+		}
+	})
 	if err != nil {
 		return err
 	}
@@ -194,8 +535,18 @@ func (c *Client) deleteAttachment(attachmentID, ver string) error {
 	defer func() { _ = res.Body.Close() }()
 
 	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		if ClassifyAttachmentStatus(res.StatusCode) == StatusTransient || attempts > 1 {
+			return newAttachmentError(AttachmentOpDelete, res, attempts)
+		}
 		return formatUnexpectedResponse(res)
 	}
+	if res.StatusCode == http.StatusOK {
+		body, err := io.ReadAll(io.LimitReader(res.Body, loginPageMaxSize))
+		if err != nil {
+			return err
+		}
+		return checkLoginPage(res.Header, body)
+	}
 
 	return nil
 }
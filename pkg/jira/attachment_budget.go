@@ -0,0 +1,79 @@
+package jira
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// ErrSizeCapExceeded is returned when a download would take the bytes
+// written by the downloads sharing a ByteBudget over its limit.
+type ErrSizeCapExceeded struct {
+	Limit int64
+	// Used is the number of bytes received when the cap was hit.
+	Used int64
+}
+
+func (e *ErrSizeCapExceeded) Error() string {
+	return fmt.Sprintf("download size cap of %s exceeded", FormatSize(e.Limit))
+}
+
+// ByteBudget caps the total number of bytes written by a set of downloads.
+// It is safe to share between downloads running concurrently.
+type ByteBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// NewByteBudget returns a budget that allows limit bytes to be downloaded.
+func NewByteBudget(limit int64) *ByteBudget {
+	return &ByteBudget{limit: limit}
+}
+
+// Limit returns the number of bytes allowed by the budget.
+func (b *ByteBudget) Limit() int64 {
+	return b.limit
+}
+
+// Used returns the number of bytes received so far, including the
+// bytes of downloads that failed or were aborted by the cap.
+func (b *ByteBudget) Used() int64 {
+	return b.used.Load()
+}
+
+// Exceeded reports if a download already went over the limit.
+func (b *ByteBudget) Exceeded() bool {
+	return b.used.Load() > b.limit
+}
+
+// consume accounts for n bytes and fails if the limit is exceeded.
+func (b *ByteBudget) consume(n int64) error {
+	if used := b.used.Add(n); used > b.limit {
+		return &ErrSizeCapExceeded{Limit: b.limit, Used: used}
+	}
+	return nil
+}
+
+// WithByteBudget counts the bytes received against the budget. The download
+// is aborted and the partial file is removed once the budget is exceeded.
+func WithByteBudget(b *ByteBudget) DownloadOption {
+	return func(o *downloadOptions) {
+		o.budget = b
+	}
+}
+
+// budgetReader fails the read that takes the budget over its limit.
+type budgetReader struct {
+	r      io.Reader
+	budget *ByteBudget
+}
+
+func (r *budgetReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if bErr := r.budget.consume(int64(n)); bErr != nil {
+			return n, bErr
+		}
+	}
+	return n, err
+}
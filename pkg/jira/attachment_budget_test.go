@@ -0,0 +1,91 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByteBudgetConcurrent(t *testing.T) {
+	t.Parallel()
+
+	b := NewByteBudget(1000)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures int
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				if err := b.consume(10); err != nil {
+					mu.Lock()
+					failures++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(2000), b.Used())
+	assert.Equal(t, 100, failures)
+	assert.True(t, b.Exceeded())
+}
+
+func TestDownloadAttachmentByteBudget(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := strings.Repeat("x", 100)
+		if r.URL.Path == "/unknown" {
+			// Flushing before writing the body makes the response chunked, without a content length.
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	dir := t.TempDir()
+	budget := NewByteBudget(150)
+
+	_, err := client.DownloadAttachmentWithResult(server.URL+"/known", filepath.Join(dir, "a"), WithByteBudget(budget))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), budget.Used())
+
+	// The size of the second attachment is unknown, it goes over the cap midway.
+	dest := filepath.Join(dir, "b")
+	_, err = client.DownloadAttachmentWithResult(server.URL+"/unknown", dest, WithByteBudget(budget))
+
+	var capErr *ErrSizeCapExceeded
+	assert.True(t, errors.As(err, &capErr), "got %v", err)
+	assert.Equal(t, int64(150), capErr.Limit)
+	assert.Greater(t, capErr.Used, int64(150))
+
+	_, err = os.Stat(dest)
+	assert.True(t, os.IsNotExist(err), "partial file must be removed")
+
+	// Nothing is written once the budget is exhausted.
+	dest = filepath.Join(dir, "c")
+	_, err = client.DownloadAttachmentWithResult(server.URL+"/known", dest, WithByteBudget(budget))
+	assert.True(t, errors.As(err, &capErr), "got %v", err)
+	_, err = os.Stat(dest)
+	assert.True(t, os.IsNotExist(err))
+}
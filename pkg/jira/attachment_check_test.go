@@ -0,0 +1,74 @@
+package jira
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadAttachmentContentCheck(t *testing.T) {
+	t.Parallel()
+
+	elf := append([]byte("\x7fELF"), bytes.Repeat([]byte{0}, 4096)...)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tool":
+			_, _ = w.Write(elf)
+		default:
+			_, _ = w.Write([]byte("short"))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	errDenied := errors.New("denied")
+
+	t.Run("denied before anything is written", func(t *testing.T) {
+		t.Parallel()
+
+		var got []byte
+		dest := filepath.Join(t.TempDir(), "report.pdf")
+		_, err := client.DownloadAttachmentWithResult(server.URL+"/tool", dest, WithParallelRanges(4), WithContentCheck(func(head []byte) error {
+			got = append([]byte(nil), head...)
+			return errDenied
+		}))
+		assert.ErrorIs(t, err, errDenied)
+		assert.Equal(t, elf[:ContentSniffLen], got)
+		_, err = os.Stat(dest)
+		assert.ErrorIs(t, err, os.ErrNotExist, "no file is created")
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		t.Parallel()
+
+		dest := filepath.Join(t.TempDir(), "tool")
+		res, err := client.DownloadAttachmentWithResult(server.URL+"/tool", dest, WithContentCheck(func([]byte) error { return nil }))
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(elf)), res.Bytes)
+		b, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, elf, b, "the checked head is written too")
+	})
+
+	t.Run("content shorter than the head", func(t *testing.T) {
+		t.Parallel()
+
+		var got []byte
+		var buf bytes.Buffer
+		_, err := client.DownloadAttachmentTo(server.URL+"/short", &buf, WithContentCheck(func(head []byte) error {
+			got = append([]byte(nil), head...)
+			return nil
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "short", string(got))
+		assert.Equal(t, "short", buf.String())
+	})
+}
@@ -0,0 +1,429 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultChunkSize is the default size of a chunk in chunked uploads.
+	DefaultChunkSize int64 = 8 << 20
+
+	mediaUploadPath = "/rest/media/1.0/upload"
+)
+
+var (
+	// ErrMediaAPIUnavailable denotes that the media upload endpoints are not available on the server.
+	ErrMediaAPIUnavailable = fmt.Errorf("jira: media upload API is not available")
+	// ErrChunkedUploadUnsupported denotes that chunked uploads are not supported by the installation.
+	ErrChunkedUploadUnsupported = fmt.Errorf("jira: chunked uploads are only supported on Jira cloud")
+)
+
+// ChunkedUploadOptions holds options for chunked uploads.
+type ChunkedUploadOptions struct {
+	// ChunkSize is the size of each chunk in bytes. Defaults to DefaultChunkSize.
+	ChunkSize int64
+	// Sessions, if set, keeps the progress of the upload so that a later call for the same
+	// file and issue only uploads the missing chunks. A saved session is discarded if the
+	// file changed or the server no longer knows it.
+	Sessions UploadSessionStore
+	// Notify is called with a message when a saved session is resumed or discarded.
+	Notify func(msg string)
+}
+
+func (o ChunkedUploadOptions) notify(format string, args ...any) {
+	if o.Notify != nil {
+		o.Notify(fmt.Sprintf(format, args...))
+	}
+}
+
+type chunkRange struct {
+	Index int
+	Start int64
+	End   int64 // Exclusive.
+}
+
+func (r chunkRange) size() int64 {
+	return r.End - r.Start
+}
+
+// chunkRanges splits a file of the given size into chunks. The last
+// chunk holds the remaining bytes and may be smaller than the rest.
+func chunkRanges(size, chunkSize int64) []chunkRange {
+	if size <= 0 || chunkSize <= 0 {
+		return nil
+	}
+
+	ranges := make([]chunkRange, 0, (size+chunkSize-1)/chunkSize)
+	for i, start := 0, int64(0); start < size; i, start = i+1, start+chunkSize {
+		end := min(start+chunkSize, size)
+		ranges = append(ranges, chunkRange{Index: i, Start: start, End: end})
+	}
+	return ranges
+}
+
+type uploadState int
+
+const (
+	uploadStateNew uploadState = iota
+	uploadStateCreated
+	uploadStateUploading
+	uploadStateFinalized
+	uploadStateAttached
+)
+
+func (s uploadState) String() string {
+	switch s {
+	case uploadStateNew:
+		return "new"
+	case uploadStateCreated:
+		return "created"
+	case uploadStateUploading:
+		return "uploading"
+	case uploadStateFinalized:
+		return "finalized"
+	case uploadStateAttached:
+		return "attached"
+	}
+	return "unknown"
+}
+
+// uploadSession tracks the progress of a chunked upload.
+type uploadSession struct {
+	ID      string
+	MediaID string
+	state   uploadState
+}
+
+// transition moves the session to the next state. Only forward transitions
+// are allowed; uploading may be repeated for every chunk.
+func (s *uploadSession) transition(to uploadState) error {
+	valid := to == s.state+1 || (to == uploadStateUploading && s.state == uploadStateUploading)
+	if !valid {
+		return fmt.Errorf("jira: invalid upload session transition from %s to %s", s.state, to)
+	}
+	s.state = to
+	return nil
+}
+
+// UploadAttachmentChunked uploads a file in chunks using the media API of Jira cloud
+// and attaches the resulting media to the issue. It returns ErrMediaAPIUnavailable
+// if the server doesn't support the media endpoints.
+func (c *Client) UploadAttachmentChunked(key, filePath string, opts ChunkedUploadOptions) ([]Attachment, error) {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	up := chunkedUpload{
+		key:    key,
+		name:   filepath.Base(filePath),
+		file:   file,
+		size:   info.Size(),
+		ranges: chunkRanges(info.Size(), chunkSize),
+	}
+	if opts.Sessions == nil {
+		return c.runChunkedUpload(&up)
+	}
+
+	if filePath, err = filepath.Abs(filePath); err != nil {
+		return nil, err
+	}
+	fp, err := fingerprint(file, info)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := opts.Sessions.Load(key, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if saved != nil && (!saved.Fingerprint.Equal(fp) || saved.ChunkSize != chunkSize) {
+		opts.notify("Discarding the interrupted upload of %s, the file or the chunk size changed since", up.name)
+		saved = nil
+	}
+	resumed := saved != nil
+	if resumed {
+		opts.notify("Resuming the upload of %s, %d of %d chunk(s) left", up.name, len(missingChunks(up.ranges, saved.Completed)), len(up.ranges))
+	} else {
+		saved = &SavedUpload{Issue: key, Path: filePath, ChunkSize: chunkSize, Fingerprint: fp}
+	}
+
+	up.saved, up.store = saved, opts.Sessions
+	attachments, err := c.runChunkedUpload(&up)
+	if resumed && errors.Is(err, ErrUploadSessionExpired) {
+		opts.notify("The upload session of %s has expired on the server, starting over", up.name)
+		up.saved = &SavedUpload{Issue: key, Path: filePath, ChunkSize: chunkSize, Fingerprint: fp}
+		attachments, err = c.runChunkedUpload(&up)
+	}
+	if err != nil {
+		if errors.Is(err, ErrUploadSessionExpired) {
+			_ = opts.Sessions.Delete(key, filePath)
+		}
+		return nil, err
+	}
+	return attachments, opts.Sessions.Delete(key, filePath)
+}
+
+// chunkedUpload is a file being uploaded in chunks.
+type chunkedUpload struct {
+	key    string
+	name   string
+	file   io.ReaderAt
+	size   int64
+	ranges []chunkRange
+
+	// saved is the progress kept in store, both are nil if the upload isn't resumable.
+	saved *SavedUpload
+	store UploadSessionStore
+}
+
+// save persists the progress of a resumable upload.
+func (u *chunkedUpload) save() error {
+	if u.store == nil {
+		return nil
+	}
+	u.saved.Updated = time.Now()
+	return u.store.Save(u.saved)
+}
+
+func (c *Client) runChunkedUpload(up *chunkedUpload) ([]Attachment, error) {
+	var sess uploadSession
+
+	ranges := up.ranges
+	if up.saved != nil && up.saved.SessionID != "" {
+		sess.ID = up.saved.SessionID
+		if err := sess.transition(uploadStateCreated); err != nil {
+			return nil, err
+		}
+		ranges = missingChunks(ranges, up.saved.Completed)
+	} else {
+		if err := c.createUploadSession(&sess); err != nil {
+			return nil, err
+		}
+		if up.saved != nil {
+			up.saved.SessionID, up.saved.Completed = sess.ID, nil
+		}
+		if err := up.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, r := range ranges {
+		if err := sess.transition(uploadStateUploading); err != nil {
+			return nil, err
+		}
+		if err := c.uploadChunk(&sess, up.file, r, up.size); err != nil {
+			return nil, err
+		}
+		if up.saved != nil {
+			up.saved.Completed.Set(r.Index)
+		}
+		if err := up.save(); err != nil {
+			return nil, err
+		}
+	}
+	if sess.state == uploadStateCreated {
+		// Empty files and fully uploaded resumed sessions don't have any chunks to upload.
+		if err := sess.transition(uploadStateUploading); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.finalizeUpload(&sess, up.name, up.size, len(up.ranges)); err != nil {
+		return nil, err
+	}
+
+	return c.attachMedia(&sess, up.key)
+}
+
+func (c *Client) mediaRequest(method, path string, body []byte, headers Header) (*http.Response, error) {
+	return c.request(context.Background(), method, c.server+mediaUploadPath+path, body, headers)
+}
+
+func (c *Client) createUploadSession(sess *uploadSession) error {
+	res, err := c.mediaRequest(http.MethodPost, "", nil, Header{
+		"Accept":            "application/json",
+		"X-Atlassian-Token": "no-check",
+	})
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return ErrMediaAPIUnavailable
+	default:
+		return formatUnexpectedResponse(res)
+	}
+
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := decodeAttachmentResponse(res, &out); err != nil {
+		return err
+	}
+	if out.ID == "" {
+		return fmt.Errorf("jira: media API returned an empty upload session")
+	}
+
+	sess.ID = out.ID
+	return sess.transition(uploadStateCreated)
+}
+
+// uploadChunk uploads a single chunk. The chunk is retried with a backoff
+// if the request fails or the server responds with a non-permanent status.
+func (c *Client) uploadChunk(sess *uploadSession, file io.ReaderAt, r chunkRange, total int64) error {
+	buf := make([]byte, r.size())
+	if _, err := file.ReadAt(buf, r.Start); err != nil && err != io.EOF {
+		return err
+	}
+
+	path := fmt.Sprintf("/%s/chunk/%d", sess.ID, r.Index)
+	headers := Header{
+		"Content-Type":      "application/octet-stream",
+		"Content-Range":     fmt.Sprintf("bytes %d-%d/%d", r.Start, r.End-1, total),
+		"X-Atlassian-Token": "no-check",
+	}
+
+	var (
+		res *http.Response
+		err error
+	)
+
+	wait := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		res, err = c.mediaRequest(http.MethodPut, path, buf, headers)
+		if err == nil && res != nil {
+			if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusCreated || res.StatusCode == http.StatusNoContent {
+				_ = res.Body.Close()
+				return nil
+			}
+			if isSessionGone(res.StatusCode) {
+				defer func() { _ = res.Body.Close() }()
+				return fmt.Errorf("%w: %w", ErrUploadSessionExpired, formatUnexpectedResponse(res))
+			}
+			if ClassifyAttachmentStatus(res.StatusCode) == StatusPermanent || attempt >= attachmentMaxAttempts {
+				defer func() { _ = res.Body.Close() }()
+				return formatUnexpectedResponse(res)
+			}
+			_ = res.Body.Close()
+		}
+		if err != nil && attempt >= attachmentMaxAttempts {
+			return fmt.Errorf("jira: failed to upload chunk %d: %w", r.Index, err)
+		}
+
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// isSessionGone reports if the media API responded that it doesn't know the upload session.
+func isSessionGone(code int) bool {
+	return code == http.StatusNotFound || code == http.StatusGone
+}
+
+func (c *Client) finalizeUpload(sess *uploadSession, name string, size int64, chunks int) error {
+	body, err := json.Marshal(struct {
+		Name   string `json:"name"`
+		Size   int64  `json:"size"`
+		Chunks int    `json:"chunks"`
+	}{Name: name, Size: size, Chunks: chunks})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.mediaRequest(http.MethodPost, fmt.Sprintf("/%s/finalize", sess.ID), body, Header{
+		"Accept":            "application/json",
+		"Content-Type":      "application/json",
+		"X-Atlassian-Token": "no-check",
+	})
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if isSessionGone(res.StatusCode) {
+		return fmt.Errorf("%w: %w", ErrUploadSessionExpired, formatUnexpectedResponse(res))
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return formatUnexpectedResponse(res)
+	}
+
+	var out struct {
+		MediaID string `json:"mediaId"`
+	}
+	if err := decodeAttachmentResponse(res, &out); err != nil {
+		return err
+	}
+	if out.MediaID == "" {
+		return fmt.Errorf("jira: media API didn't return a media id for upload session %s", sess.ID)
+	}
+
+	sess.MediaID = out.MediaID
+	return sess.transition(uploadStateFinalized)
+}
+
+func (c *Client) attachMedia(sess *uploadSession, key string) ([]Attachment, error) {
+	body, err := json.Marshal(struct {
+		MediaID string `json:"mediaId"`
+	}{MediaID: sess.MediaID})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Post(context.Background(), fmt.Sprintf("/issue/%s/attachments/media", key), body, Header{
+		"Accept":            "application/json",
+		"Content-Type":      "application/json",
+		"X-Atlassian-Token": "no-check",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, formatUnexpectedResponse(res)
+	}
+
+	var attachments []Attachment
+	if err := decodeAttachmentResponse(res, &attachments); err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return nil, ErrNoAttachmentCreated
+	}
+	if err := sess.transition(uploadStateAttached); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
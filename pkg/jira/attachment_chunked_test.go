@@ -0,0 +1,283 @@
+package jira
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkRanges(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		size      int64
+		chunkSize int64
+		expected  []chunkRange
+	}{
+		{
+			name:      "empty file",
+			size:      0,
+			chunkSize: 4,
+			expected:  nil,
+		},
+		{
+			name:      "smaller than a chunk",
+			size:      3,
+			chunkSize: 4,
+			expected:  []chunkRange{{Index: 0, Start: 0, End: 3}},
+		},
+		{
+			name:      "exact multiple",
+			size:      8,
+			chunkSize: 4,
+			expected:  []chunkRange{{Index: 0, Start: 0, End: 4}, {Index: 1, Start: 4, End: 8}},
+		},
+		{
+			name:      "last partial chunk",
+			size:      10,
+			chunkSize: 4,
+			expected: []chunkRange{
+				{Index: 0, Start: 0, End: 4},
+				{Index: 1, Start: 4, End: 8},
+				{Index: 2, Start: 8, End: 10},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, chunkRanges(tc.size, tc.chunkSize))
+		})
+	}
+}
+
+func TestUploadSessionTransition(t *testing.T) {
+	t.Parallel()
+
+	var sess uploadSession
+
+	assert.Error(t, sess.transition(uploadStateUploading))
+	assert.NoError(t, sess.transition(uploadStateCreated))
+	assert.Error(t, sess.transition(uploadStateFinalized))
+	assert.NoError(t, sess.transition(uploadStateUploading))
+	assert.NoError(t, sess.transition(uploadStateUploading))
+	assert.NoError(t, sess.transition(uploadStateFinalized))
+	assert.Error(t, sess.transition(uploadStateUploading))
+	assert.NoError(t, sess.transition(uploadStateAttached))
+	assert.Error(t, sess.transition(uploadStateAttached))
+}
+
+// mediaServer is a scripted mock of the media upload endpoints that
+// reassembles the uploaded chunks.
+type mediaServer struct {
+	mu       sync.Mutex
+	chunks   map[int][]byte
+	failures map[int]int // Number of 503 responses to send for a chunk.
+	attempts map[int]int
+	finalize struct {
+		name string
+		size int64
+	}
+	unavailable bool
+	attached    bool
+
+	sessions int             // Number of upload sessions created.
+	expired  map[string]bool // Sessions the server no longer knows.
+	abortAt  int             // Chunk to reject with 400 once, like a run killed midway; -1 for none.
+	puts     []int           // Chunks stored, in order.
+}
+
+func newMediaServer() *mediaServer {
+	return &mediaServer{
+		chunks:   make(map[int][]byte),
+		failures: make(map[int]int),
+		attempts: make(map[int]int),
+		expired:  make(map[string]bool),
+		abortAt:  -1,
+	}
+}
+
+func (m *mediaServer) assembled() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	idx := make([]int, 0, len(m.chunks))
+	for i := range m.chunks {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+
+	var buf bytes.Buffer
+	for _, i := range idx {
+		buf.Write(m.chunks[i])
+	}
+	return buf.Bytes()
+}
+
+func (m *mediaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Session paths are /{id}/chunk/{n} and /{id}/finalize.
+	var id, action string
+	if rest, ok := strings.CutPrefix(r.URL.Path, mediaUploadPath+"/"); ok {
+		id, action, _ = strings.Cut(rest, "/")
+	}
+	if id != "" && m.expired[id] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case r.URL.Path == mediaUploadPath && r.Method == http.MethodPost:
+		if m.unavailable {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		m.sessions++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"id": "session-%d"}`, m.sessions)
+	case strings.HasPrefix(action, "chunk/") && r.Method == http.MethodPut:
+		i, _ := strconv.Atoi(strings.TrimPrefix(action, "chunk/"))
+		m.attempts[i]++
+		if i == m.abortAt {
+			m.abortAt = -1
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if m.failures[i] > 0 {
+			m.failures[i]--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		m.chunks[i] = b
+		m.puts = append(m.puts, i)
+		w.WriteHeader(http.StatusNoContent)
+	case action == "finalize" && r.Method == http.MethodPost:
+		b, _ := io.ReadAll(r.Body)
+		_, _ = fmt.Sscanf(string(b), `{"name":%q,"size":%d`, &m.finalize.name, &m.finalize.size)
+		_, _ = w.Write([]byte(`{"mediaId": "media-1"}`))
+	case r.URL.Path == "/rest/api/3/issue/TEST-1/attachments/media" && r.Method == http.MethodPost:
+		b, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(b), `"media-1"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.attached = true
+		_, _ = w.Write([]byte(`[{"id": "10001", "filename": "upload.bin"}]`))
+	case r.URL.Path == "/rest/api/3/issue/TEST-1/attachments" && r.Method == http.MethodPost:
+		_, _ = w.Write([]byte(`[{"id": "10002", "filename": "upload.bin"}]`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeRandomFile(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return path, data
+}
+
+func TestUploadAttachmentChunked(t *testing.T) {
+	t.Parallel()
+
+	media := newMediaServer()
+	server := httptest.NewServer(media)
+	defer server.Close()
+
+	path, data := writeRandomFile(t, 10*1024+17)
+
+	client := newRetryTestClient(server.URL)
+	attachments, err := client.UploadAttachmentChunked("TEST-1", path, ChunkedUploadOptions{ChunkSize: 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, []Attachment{{ID: "10001", Filename: "upload.bin"}}, attachments)
+
+	assert.Len(t, media.chunks, 11)
+	assert.Len(t, media.chunks[10], 17)
+	assert.True(t, bytes.Equal(data, media.assembled()))
+	assert.Equal(t, "upload.bin", media.finalize.name)
+	assert.Equal(t, int64(len(data)), media.finalize.size)
+	assert.True(t, media.attached)
+}
+
+func TestUploadAttachmentChunkedRetriesChunk(t *testing.T) {
+	t.Parallel()
+
+	media := newMediaServer()
+	media.failures[1] = 2
+
+	server := httptest.NewServer(media)
+	defer server.Close()
+
+	path, data := writeRandomFile(t, 3000)
+
+	client := newRetryTestClient(server.URL)
+	_, err := client.UploadAttachmentChunked("TEST-1", path, ChunkedUploadOptions{ChunkSize: 1024})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, media.attempts[0])
+	assert.Equal(t, 3, media.attempts[1])
+	assert.Equal(t, 1, media.attempts[2])
+	assert.True(t, bytes.Equal(data, media.assembled()))
+}
+
+func TestUploadAttachmentChunkedGivesUpOnChunk(t *testing.T) {
+	t.Parallel()
+
+	media := newMediaServer()
+	media.failures[0] = attachmentMaxAttempts
+
+	server := httptest.NewServer(media)
+	defer server.Close()
+
+	path, _ := writeRandomFile(t, 100)
+
+	client := newRetryTestClient(server.URL)
+	_, err := client.UploadAttachmentChunked("TEST-1", path, ChunkedUploadOptions{ChunkSize: 1024})
+	assert.Error(t, err)
+
+	assert.Equal(t, attachmentMaxAttempts, media.attempts[0])
+	assert.False(t, media.attached)
+}
+
+func TestUploadAttachmentChunkedMediaAPIUnavailable(t *testing.T) {
+	t.Parallel()
+
+	media := newMediaServer()
+	media.unavailable = true
+
+	server := httptest.NewServer(media)
+	defer server.Close()
+
+	path, _ := writeRandomFile(t, 100)
+
+	client := newRetryTestClient(server.URL)
+	_, err := client.UploadAttachmentChunked("TEST-1", path, ChunkedUploadOptions{})
+	assert.ErrorIs(t, err, ErrMediaAPIUnavailable)
+	assert.Empty(t, media.chunks)
+}
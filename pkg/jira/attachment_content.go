@@ -0,0 +1,42 @@
+package jira
+
+import (
+	"net/url"
+	"strings"
+)
+
+// secureAttachmentPath is the path of the attachment content of Jira server and data center.
+const secureAttachmentPath = "/secure/attachment/"
+
+// AttachmentContentURL returns the URL to download an attachment from using v3 version of
+// the api, its content link as is. It is empty for an unavailable attachment.
+func (c *Client) AttachmentContentURL(a Attachment) string {
+	return a.Content
+}
+
+// AttachmentContentURLV2 returns the URL to download an attachment from on Jira server and
+// data center. Their content links point to /secure/attachment under the base URL configured
+// on the server, which differs from the one the CLI is configured with behind a reverse proxy,
+// eg: an internal host name. The path is then moved under the configured server so that the
+// request is sent with the credentials and transport of the server. It is empty for an
+// unavailable attachment.
+func (c *Client) AttachmentContentURLV2(a Attachment) string {
+	u, err := url.Parse(a.Content)
+	if err != nil || !u.IsAbs() {
+		return a.Content
+	}
+	i := strings.Index(u.EscapedPath(), secureAttachmentPath)
+	if i < 0 {
+		return a.Content
+	}
+	server, err := url.Parse(c.server)
+	if err != nil || strings.EqualFold(u.Host, server.Host) {
+		return a.Content
+	}
+
+	rebased := c.server + u.EscapedPath()[i:]
+	if u.RawQuery != "" {
+		rebased += "?" + u.RawQuery
+	}
+	return rebased
+}
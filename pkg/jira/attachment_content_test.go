@@ -0,0 +1,51 @@
+package jira
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentContentURLV2(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{Server: "https://jira.example.com/jira/"})
+
+	cases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "same host",
+			content: "https://jira.example.com/jira/secure/attachment/10001/a.txt",
+			want:    "https://jira.example.com/jira/secure/attachment/10001/a.txt",
+		},
+		{
+			name:    "internal base url",
+			content: "http://jira-app01:8080/secure/attachment/10001/report%20q3.pdf",
+			want:    "https://jira.example.com/jira/secure/attachment/10001/report%20q3.pdf",
+		},
+		{
+			name:    "internal base url with a context path and query",
+			content: "http://jira-app01:8080/jira/secure/attachment/10001/a.txt?download=true",
+			want:    "https://jira.example.com/jira/secure/attachment/10001/a.txt?download=true",
+		},
+		{
+			name:    "other path",
+			content: "https://media.example.com/file/10001",
+			want:    "https://media.example.com/file/10001",
+		},
+		{name: "unavailable", content: "", want: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := Attachment{ID: "10001", Content: tc.content}
+			assert.Equal(t, tc.want, client.AttachmentContentURLV2(a))
+			assert.Equal(t, tc.content, client.AttachmentContentURL(a))
+		})
+	}
+}
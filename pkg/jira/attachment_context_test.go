@@ -0,0 +1,127 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowServer sends the first bytes of a large attachment and then stalls until the
+// request is cancelled. started is closed once the first bytes are flushed.
+func slowServer(started chan<- struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", "1073741824")
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 64<<10))
+		w.(http.Flusher).Flush()
+		close(started)
+
+		<-r.Context().Done()
+	}))
+}
+
+func TestDownloadAttachmentContextCancel(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	server := slowServer(started)
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(time.Minute))
+	dest := filepath.Join(t.TempDir(), "dump.bin")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-started
+		// Let the client write the first bytes before cancelling.
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- client.DownloadAttachmentContext(ctx, server.URL+"/dump.bin", dest) }()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("download did not return after the context was cancelled")
+	}
+	_, err := os.Stat(dest)
+	assert.ErrorIs(t, err, os.ErrNotExist, "the partial file is removed")
+}
+
+func TestDownloadAttachmentToContextCancel(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	server := slowServer(started)
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	var buf bytes.Buffer
+	_, err := client.DownloadAttachmentToContext(ctx, server.URL+"/dump.bin", &buf)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDownloadAttachmentContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	dest := filepath.Join(t.TempDir(), "a.txt")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.DownloadAttachmentWithResultContext(ctx, server.URL+"/a.txt", dest, WithParallelRanges(4))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, atomic.LoadInt32(&calls))
+	_, err = os.Stat(dest)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDownloadAttachmentContextCancelsBackoff(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusLocked)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	client.retryBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.DownloadAttachmentWithResultContext(ctx, server.URL+"/a.txt", filepath.Join(t.TempDir(), "a.txt"))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
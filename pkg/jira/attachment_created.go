@@ -0,0 +1,80 @@
+package jira
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// UnparsedDateMarker follows a creation date that is passed through as returned by the
+// server because it couldn't be parsed.
+const UnparsedDateMarker = "[UNPARSED]"
+
+// createdLayouts are the layouts of the creation dates returned by Jira. Jira cloud returns
+// them with milliseconds, older servers without. The offset is the one of the server or of
+// the user profile, it differs between installations.
+var createdLayouts = []string{RFC3339MilliLayout, RFC3339, time.RFC3339}
+
+// ParseCreated parses a creation date returned by Jira.
+func ParseCreated(s string) (time.Time, bool) {
+	for _, layout := range createdLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// UnmarshalJSON decodes the attachment and parses its creation date once into CreatedAt.
+// The string returned by the server is kept in Created.
+func (a *Attachment) UnmarshalJSON(data []byte) error {
+	type attachment Attachment
+
+	var out attachment
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	*a = Attachment(out)
+	a.CreatedAt, _ = ParseCreated(a.Created)
+	return nil
+}
+
+// CreatedTime returns the creation date of the attachment, parsed at decode time or from
+// Created for attachments that weren't decoded.
+func (a Attachment) CreatedTime() (time.Time, bool) {
+	if !a.CreatedAt.IsZero() {
+		return a.CreatedAt, true
+	}
+	return ParseCreated(a.Created)
+}
+
+// CreatedUTC formats the creation date as RFC 3339 in UTC, with the milliseconds if any,
+// for machine readable outputs. A date that can't be parsed is returned as is followed by
+// UnparsedDateMarker.
+func (a Attachment) CreatedUTC() string {
+	return a.FormatCreated(time.UTC, time.RFC3339Nano)
+}
+
+// FormatCreated formats the creation date in the location with the layout. A date that
+// can't be parsed is returned as is followed by UnparsedDateMarker.
+func (a Attachment) FormatCreated(loc *time.Location, layout string) string {
+	if a.Created == "" && a.CreatedAt.IsZero() {
+		return ""
+	}
+	t, ok := a.CreatedTime()
+	if !ok {
+		return a.Created + " " + UnparsedDateMarker
+	}
+	return t.In(loc).Format(layout)
+}
+
+// sameCreated reports if two creation dates are the same instant, or the same string if
+// either can't be parsed. It accepts the dates as returned by the server and as formatted
+// by CreatedUTC.
+func sameCreated(x, y string) bool {
+	if x == y {
+		return true
+	}
+	tx, okX := ParseCreated(x)
+	ty, okY := ParseCreated(y)
+	return okX && okY && tx.Equal(ty)
+}
@@ -0,0 +1,105 @@
+package jira
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createdAt parses a creation date as the client does when decoding.
+func createdAt(s string) time.Time {
+	t, _ := ParseCreated(s)
+	return t
+}
+
+func TestAttachmentDecodeParsesCreated(t *testing.T) {
+	t.Parallel()
+
+	var got []Attachment
+	err := json.Unmarshal([]byte(`[
+		{"id":"10001","filename":"cloud.pdf","created":"2024-03-01T10:00:00.123+0100"},
+		{"id":"10002","filename":"server.pdf","created":"2024-03-01T10:00:00+0530"},
+		{"id":"10003","filename":"rfc.pdf","created":"2024-03-01T10:00:00Z"},
+		{"id":"10004","filename":"odd.pdf","created":"01/03/2024 10:00"},
+		{"id":"10005","filename":"none.pdf"}
+	]`), &got)
+	require.NoError(t, err)
+	require.Len(t, got, 5)
+
+	// The raw string is kept as returned.
+	assert.Equal(t, "2024-03-01T10:00:00.123+0100", got[0].Created)
+	assert.True(t, got[0].CreatedAt.Equal(time.Date(2024, 3, 1, 9, 0, 0, 123e6, time.UTC)))
+	assert.True(t, got[1].CreatedAt.Equal(time.Date(2024, 3, 1, 4, 30, 0, 0, time.UTC)))
+	assert.True(t, got[2].CreatedAt.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)))
+
+	assert.Equal(t, "01/03/2024 10:00", got[3].Created)
+	assert.True(t, got[3].CreatedAt.IsZero())
+	_, ok := got[3].CreatedTime()
+	assert.False(t, ok)
+
+	assert.True(t, got[4].CreatedAt.IsZero())
+}
+
+func TestAttachmentCreatedUTC(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		created string
+		want    string
+	}{
+		{name: "positive offset", created: "2024-03-01T10:00:00.000+0100", want: "2024-03-01T09:00:00Z"},
+		{name: "milliseconds", created: "2024-03-01T10:00:00.459+0100", want: "2024-03-01T09:00:00.459Z"},
+		{name: "crosses the date boundary", created: "2024-03-01T09:30:00.000+1300", want: "2024-02-29T20:30:00Z"},
+		{name: "negative offset", created: "2024-02-29T20:30:00.000-1000", want: "2024-03-01T06:30:00Z"},
+		{name: "without milliseconds", created: "2024-03-01T10:00:00+0000", want: "2024-03-01T10:00:00Z"},
+		{name: "unparseable", created: "yesterday", want: "yesterday [UNPARSED]"},
+		{name: "empty", created: "", want: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := Attachment{Created: tc.created}
+			assert.Equal(t, tc.want, a.CreatedUTC())
+
+			// Decoded attachments render the same.
+			var decoded Attachment
+			b, err := json.Marshal(map[string]string{"created": tc.created})
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(b, &decoded))
+			assert.Equal(t, tc.want, decoded.CreatedUTC())
+		})
+	}
+}
+
+func TestAttachmentFormatCreated(t *testing.T) {
+	t.Parallel()
+
+	auckland := time.FixedZone("NZDT", 13*60*60)
+	berlin := time.FixedZone("CET", 60*60)
+
+	// Created just after midnight in Auckland, it is still the day before in Berlin and UTC.
+	a := Attachment{Created: "2024-03-01T00:30:00.000+1300"}
+	assert.Equal(t, "2024-03-01", a.FormatCreated(auckland, time.DateOnly))
+	assert.Equal(t, "2024-02-29", a.FormatCreated(berlin, time.DateOnly))
+	assert.Equal(t, "2024-02-29 11:30", a.FormatCreated(time.UTC, "2006-01-02 15:04"))
+
+	odd := Attachment{Created: "2024-13-45"}
+	assert.Equal(t, "2024-13-45 [UNPARSED]", odd.FormatCreated(berlin, time.DateOnly))
+}
+
+func TestSameCreated(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, sameCreated("2024-03-01T10:00:00.459+0100", "2024-03-01T10:00:00.459+0100"))
+	assert.True(t, sameCreated("2024-03-01T10:00:00.459+0100", "2024-03-01T09:00:00.459Z"))
+	assert.True(t, sameCreated("2024-03-01T00:30:00.000+1300", "2024-02-29T11:30:00Z"))
+	assert.False(t, sameCreated("2024-03-01T10:00:00.459+0100", "2024-03-01T09:00:00Z"))
+	assert.True(t, sameCreated("yesterday", "yesterday"))
+	assert.False(t, sameCreated("yesterday", "2024-03-01T09:00:00Z"))
+}
@@ -0,0 +1,47 @@
+package jira
+
+import "fmt"
+
+// DeleteAttachmentOptions are the options of a delete.
+type DeleteAttachmentOptions struct {
+	// IfCreated is a precondition on the created timestamp of the attachment, as returned
+	// by the server or in UTC as listed. The attachment is only deleted if it is the same
+	// instant, to the millisecond.
+	IfCreated string
+}
+
+// ErrPreconditionFailed is returned if a delete precondition doesn't hold, eg: because the
+// attachment was replaced since it was listed.
+type ErrPreconditionFailed struct {
+	ID       string
+	Expected string
+	Actual   string
+}
+
+// Error implements error.
+func (e *ErrPreconditionFailed) Error() string {
+	return fmt.Sprintf("precondition failed: attachment %s was created %s, expected %s", e.ID, e.Actual, e.Expected)
+}
+
+// DeleteAttachmentWithOptions deletes an attachment using v3 API once its preconditions hold.
+func (c *Client) DeleteAttachmentWithOptions(attachmentID string, opts DeleteAttachmentOptions) error {
+	return c.deleteAttachmentWithOptions(attachmentID, apiVersion3, opts)
+}
+
+// DeleteAttachmentWithOptionsV2 deletes an attachment using v2 API once its preconditions hold.
+func (c *Client) DeleteAttachmentWithOptionsV2(attachmentID string, opts DeleteAttachmentOptions) error {
+	return c.deleteAttachmentWithOptions(attachmentID, apiVersion2, opts)
+}
+
+func (c *Client) deleteAttachmentWithOptions(attachmentID, ver string, opts DeleteAttachmentOptions) error {
+	if opts.IfCreated != "" {
+		a, err := c.getAttachment(attachmentID, ver)
+		if err != nil {
+			return err
+		}
+		if !sameCreated(a.Created, opts.IfCreated) {
+			return &ErrPreconditionFailed{ID: attachmentID, Expected: opts.IfCreated, Actual: a.Created}
+		}
+	}
+	return c.deleteAttachment(attachmentID, ver)
+}
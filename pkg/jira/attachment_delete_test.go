@@ -0,0 +1,84 @@
+package jira
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCreated = "2024-01-31T10:00:00.000+0000"
+
+// deleteServer serves the metadata of attachment 10001 and records the methods of the requests.
+type deleteServer struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (s *deleteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.methods = append(s.methods, r.Method)
+	s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"10001","filename":"report.pdf","created":"` + testCreated + `","size":10}`))
+	case http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestDeleteAttachmentWithOptions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		opts        DeleteAttachmentOptions
+		wantErr     bool
+		wantMethods []string
+	}{
+		{
+			name:        "no precondition",
+			wantMethods: []string{http.MethodDelete},
+		},
+		{
+			name:        "matching precondition",
+			opts:        DeleteAttachmentOptions{IfCreated: testCreated},
+			wantMethods: []string{http.MethodGet, http.MethodDelete},
+		},
+		{
+			name:        "mismatched precondition",
+			opts:        DeleteAttachmentOptions{IfCreated: "2024-01-31T10:00:00.000+0100"},
+			wantErr:     true,
+			wantMethods: []string{http.MethodGet},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &deleteServer{}
+			server := httptest.NewServer(s)
+			defer server.Close()
+
+			client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+			err := client.DeleteAttachmentWithOptions("10001", tc.opts)
+			if tc.wantErr {
+				var pf *ErrPreconditionFailed
+				assert.ErrorAs(t, err, &pf)
+				assert.Equal(t, testCreated, pf.Actual)
+				assert.Equal(t, tc.opts.IfCreated, pf.Expected)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantMethods, s.methods)
+		})
+	}
+}
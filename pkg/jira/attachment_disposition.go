@@ -0,0 +1,80 @@
+package jira
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"unicode/utf8"
+)
+
+// createFilePart creates the part of a file upload. Unlike multipart.Writer.CreateFormFile,
+// the filename is escaped so that names with quotes, backslashes or control characters
+// don't produce a malformed Content-Disposition.
+func createFilePart(w *multipart.Writer, field, filename string) (io.Writer, error) {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fileDisposition(field, filename))
+	h.Set("Content-Type", "application/octet-stream")
+	return w.CreatePart(h)
+}
+
+// fileDisposition returns the Content-Disposition of a form-data file part.
+//
+// The filename parameter is written the way browsers do per RFC 7578: quotes and control
+// characters are percent-encoded, as servers differ on whether they honor backslash
+// escapes in a quoted string. Backslashes are percent-encoded too for the same reason.
+// If that changes the name, the exact name is also given as an RFC 5987 filename*
+// parameter, which takes precedence for servers supporting it, Jira included.
+func fileDisposition(field, filename string) string {
+	d := fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotedParam(field), escapeQuotedParam(filename))
+	if needsExtendedFilename(filename) {
+		d += "; filename*=UTF-8''" + encodeExtValue(filename)
+	}
+	return d
+}
+
+// escapeQuotedParam percent-encodes the characters that can't be written as is in a
+// quoted-string parameter.
+func escapeQuotedParam(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' || c == '\\' || c < 0x20 || c == 0x7f {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// needsExtendedFilename reports if the filename parameter can't carry the name exactly.
+func needsExtendedFilename(s string) bool {
+	return !utf8.ValidString(s) || escapeQuotedParam(s) != s || strings.IndexFunc(s, func(r rune) bool {
+		return r >= utf8.RuneSelf
+	}) >= 0
+}
+
+// encodeExtValue encodes s as the value of an RFC 5987 ext-value, without its charset.
+func encodeExtValue(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// isAttrChar reports if c is an attr-char of RFC 5987, which doesn't need encoding.
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
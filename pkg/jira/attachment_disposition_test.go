@@ -0,0 +1,109 @@
+package jira
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dispositionNames = []string{
+	"plain.txt",
+	`report "final".pdf`,
+	`back\slash.txt`,
+	`a;b; filename="evil".txt`,
+	"  padded name.txt  ",
+	"100% done.txt",
+	"résumé 日本.txt",
+	"tab\there.txt",
+}
+
+func TestFileDisposition(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `form-data; name="file"; filename="plain.txt"`, fileDisposition("file", "plain.txt"))
+	assert.Equal(t, `form-data; name="file"; filename="a;b.txt"`, fileDisposition("file", "a;b.txt"))
+	assert.Equal(t,
+		`form-data; name="file"; filename="report %22final%22.pdf"; filename*=UTF-8''report%20%22final%22.pdf`,
+		fileDisposition("file", `report "final".pdf`),
+	)
+	assert.Equal(t,
+		`form-data; name="file"; filename="back%5Cslash.txt"; filename*=UTF-8''back%5Cslash.txt`,
+		fileDisposition("file", `back\slash.txt`),
+	)
+	assert.Equal(t,
+		`form-data; name="file"; filename="é.txt"; filename*=UTF-8''%C3%A9.txt`,
+		fileDisposition("file", "é.txt"),
+	)
+}
+
+func TestCreateFilePartRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range dispositionNames {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var body bytes.Buffer
+			w := multipart.NewWriter(&body)
+			part, err := createFilePart(w, "file", name)
+			require.NoError(t, err)
+			_, err = part.Write([]byte("content"))
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			r := multipart.NewReader(&body, w.Boundary())
+			p, err := r.NextPart()
+			require.NoError(t, err)
+			assert.Equal(t, "file", p.FormName())
+			assert.Equal(t, name, p.FileName())
+			assert.Equal(t, "application/octet-stream", p.Header.Get("Content-Type"))
+
+			content, err := io.ReadAll(p)
+			require.NoError(t, err)
+			assert.Equal(t, "content", string(content))
+
+			_, err = r.NextPart()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestUploadAttachmentAsEscapesFilename(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range dispositionNames {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var received string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseMultipartForm(10<<20))
+				_, header, err := r.FormFile("file")
+				require.NoError(t, err)
+				received = header.Filename
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`[{"id": "10001", "filename": "x"}]`))
+			}))
+			t.Cleanup(server.Close)
+
+			client := NewClient(Config{Server: server.URL, Login: "test", APIToken: "token"}, WithTimeout(3*time.Second))
+
+			file := filepath.Join(t.TempDir(), "local.txt")
+			require.NoError(t, os.WriteFile(file, []byte("content"), 0o600))
+
+			_, err := client.UploadAttachmentAs("TEST-1", file, name)
+			require.NoError(t, err)
+			assert.Equal(t, name, received)
+		})
+	}
+}
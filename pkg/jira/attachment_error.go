@@ -0,0 +1,321 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// AttachmentOpUpload is an attachment upload operation.
+	AttachmentOpUpload = "upload"
+	// AttachmentOpDownload is an attachment download operation.
+	AttachmentOpDownload = "download"
+	// AttachmentOpDelete is an attachment delete operation.
+	AttachmentOpDelete = "delete"
+
+	attachmentMaxAttempts  = 3
+	attachmentRetryBackoff = 500 * time.Millisecond
+)
+
+// ErrNoAttachmentCreated denotes that the server accepted the upload but didn't create any attachment.
+var ErrNoAttachmentCreated = fmt.Errorf(
+	"jira: server accepted the request but created no attachment, the file may have been rejected by a filter",
+)
+
+// ErrAttachmentUnavailable denotes that the attachment has no content URL, eg: because
+// the issue is archived or access to the attachment is restricted.
+var ErrAttachmentUnavailable = fmt.Errorf(
+	"jira: attachment is unavailable, the issue may be archived or the attachment restricted",
+)
+
+// StatusClass classifies a response status received from an attachment endpoint.
+type StatusClass int
+
+const (
+	// StatusPermanent denotes a failure that won't go away by retrying.
+	StatusPermanent StatusClass = iota
+	// StatusTransient denotes a failure where the server rejected the request
+	// without processing it, eg: because the issue is temporarily locked.
+	StatusTransient
+	// StatusAmbiguous denotes a failure where the request may or may not have
+	// been processed by the server.
+	StatusAmbiguous
+)
+
+// ClassifyAttachmentStatus classifies the status code received from
+// upload, download and delete attachment endpoints.
+func ClassifyAttachmentStatus(code int) StatusClass {
+	switch code {
+	case http.StatusConflict, http.StatusLocked:
+		// Jira Data Center returns 409 during reindex and 423
+		// when the issue is locked by a bulk operation.
+		return StatusTransient
+	case http.StatusRequestTimeout, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return StatusAmbiguous
+	default:
+		return StatusPermanent
+	}
+}
+
+// AttachmentError is returned when an attachment operation keeps failing
+// with a response that is not permanent.
+type AttachmentError struct {
+	Op         string
+	Status     string
+	StatusCode int
+	Attempts   int
+	Retryable  bool
+	Err        error
+}
+
+func (e *AttachmentError) Error() string {
+	msg := fmt.Sprintf("failed to %s attachment: %s", e.Op, e.Status)
+	if e.Attempts > 1 {
+		msg = fmt.Sprintf("%s (after %d attempts)", msg, e.Attempts)
+	}
+	if e.Err != nil {
+		if body := e.Err.Error(); body != "" {
+			msg = fmt.Sprintf("%s%s", msg, body)
+		}
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *AttachmentError) Unwrap() error {
+	return e.Err
+}
+
+func newAttachmentError(op string, res *http.Response, attempts int) *AttachmentError {
+	return &AttachmentError{
+		Op:         op,
+		Status:     res.Status,
+		StatusCode: res.StatusCode,
+		Attempts:   attempts,
+		Retryable:  ClassifyAttachmentStatus(res.StatusCode) == StatusTransient,
+		Err:        formatUnexpectedResponse(res),
+	}
+}
+
+// withAttachmentRetry sends the request of op built by fn and retries it with an
+// exponential backoff as long as the server responds with a transient status. Responses
+// retryable with WithRetries are retried as many times as configured. The last response
+// is returned as is once the attempts are exhausted.
+func (c *Client) withAttachmentRetry(op string, fn func() (*http.Response, error)) (*http.Response, int, error) {
+	return c.withAttachmentRetryContext(context.Background(), op, fn)
+}
+
+// withAttachmentRetryContext is withAttachmentRetry giving up the backoff once ctx is done.
+func (c *Client) withAttachmentRetryContext(ctx context.Context, op string, fn func() (*http.Response, error)) (*http.Response, int, error) {
+	var (
+		res       *http.Response
+		err       error
+		transient int
+		retries   int
+	)
+
+	wait := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		res, err = fn()
+		if err != nil || res == nil {
+			return res, attempt, err
+		}
+
+		var delay time.Duration
+		switch {
+		case ClassifyAttachmentStatus(res.StatusCode) == StatusTransient:
+			if transient++; transient >= attachmentMaxAttempts {
+				c.logf(LogDetail, "Giving up %s after %d attempts: %s", op, attempt, res.Status)
+				return res, attempt, nil
+			}
+			delay, wait = wait, wait*2
+		case retries < c.retries && retryableStatus(op, res.StatusCode):
+			d, ok := c.retryDelay(res, retries+1)
+			if !ok {
+				c.logf(LogDetail, "Not retrying %s: %s asks to wait %s, more than %s", op, res.Status, d, attachmentMaxRetryAfter)
+				return res, attempt, nil
+			}
+			retries++
+			delay = d
+		default:
+			return res, attempt, nil
+		}
+
+		c.logf(LogDetail, "Retrying %s after %s, waiting %s (attempt %d)", op, res.Status, delay, attempt+1)
+
+		_ = res.Body.Close()
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, attempt, ctx.Err()
+		}
+	}
+}
+
+// UploadTooLargeError is returned when an upload is rejected with 413 Request Entity Too
+// Large, by Jira or by a reverse proxy in front of it, eg: nginx rejects bodies over its
+// client_max_body_size with an HTML page.
+type UploadTooLargeError struct {
+	// Size is the size of the request body in bytes.
+	Size int64
+	// Limit is the attachment size limit reported by the instance, 0 if the attachment
+	// settings weren't fetched, see GetAttachmentMeta.
+	Limit int64
+	// Proxy is set if the response isn't an error of Jira, eg: an HTML page.
+	Proxy bool
+	Err   *ErrUnexpectedResponse
+}
+
+func (e *UploadTooLargeError) Error() string {
+	who := "Jira"
+	if e.Proxy {
+		who = "the server or a proxy in front of it"
+	}
+	return fmt.Sprintf("jira: %s rejected the upload as too large (%d bytes)", who, e.Size)
+}
+
+// Unwrap returns the underlying error.
+func (e *UploadTooLargeError) Unwrap() error {
+	return e.Err
+}
+
+// uploadTooLarge returns the error of an upload of size bytes rejected with 413. The limit
+// is the one of the attachment settings cached by the client, if they were fetched.
+func (c *Client) uploadTooLarge(res *http.Response, size int64) *UploadTooLargeError {
+	resErr := formatUnexpectedResponse(res)
+	e := &UploadTooLargeError{
+		Size:  size,
+		Proxy: len(resErr.Body.ErrorMessages) == 0 && len(resErr.Body.Errors) == 0,
+		Err:   resErr,
+	}
+	c.mu.Lock()
+	if c.attachmentMetaCache != nil {
+		e.Limit = c.attachmentMetaCache.UploadLimit
+	}
+	c.mu.Unlock()
+	return e
+}
+
+// UploadInterruptedError is returned when the connection is reset or closed while the
+// body of an upload is sent. Reverse proxies do so, instead of responding, when the body
+// goes over their size limit.
+type UploadInterruptedError struct {
+	// Size is the size of the request body in bytes.
+	Size int64
+	Err  error
+}
+
+func (e *UploadInterruptedError) Error() string {
+	return fmt.Sprintf("jira: the connection was closed while uploading %d bytes: %s", e.Size, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *UploadInterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// isConnectionDropped reports if the peer reset or closed the connection before responding.
+func isConnectionDropped(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// ReconcileAttachments compares the filenames sent in an upload request with the attachments
+// returned by the server and reports the filenames for which no attachment was created.
+func ReconcileAttachments(sent []string, created []Attachment) []string {
+	remaining := make(map[string]int, len(created))
+	for _, a := range created {
+		remaining[a.Filename]++
+	}
+
+	var missing []string
+	for _, name := range sent {
+		if remaining[name] > 0 {
+			remaining[name]--
+			continue
+		}
+		missing = append(missing, name)
+	}
+	return missing
+}
+
+// loginPageMaxSize is the largest HTML body treated as a login page when
+// downloading an attachment that isn't expected to be HTML.
+const loginPageMaxSize = 256 * 1024
+
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// ErrAuthenticationRequired is returned when an attachment endpoint responds with
+// an HTML page instead of the expected response. This happens behind some SSO
+// setups where an expired session redirects to a login page with status 200.
+type ErrAuthenticationRequired struct {
+	// Title is the title of the page received, if any.
+	Title string
+}
+
+func (e *ErrAuthenticationRequired) Error() string {
+	msg := "jira: received an HTML page instead of the expected response, your session or API token may have expired"
+	if e.Title != "" {
+		msg = fmt.Sprintf("%s (page title: %q)", msg, e.Title)
+	}
+	return msg + ".\nPlease refresh your token or re-authenticate and try again."
+}
+
+// checkLoginPage returns ErrAuthenticationRequired if a successful response
+// that was expected to be JSON or empty is an HTML page.
+func checkLoginPage(header http.Header, body []byte) error {
+	if !isHTML(header, body) {
+		return nil
+	}
+	return &ErrAuthenticationRequired{Title: pageTitle(body)}
+}
+
+func isHTML(header http.Header, body []byte) bool {
+	return isHTMLType(header.Get("Content-Type")) || bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
+}
+
+func isHTMLType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "text/html" || mt == "application/xhtml+xml")
+}
+
+// pageTitle extracts the title of an HTML page. Long titles are truncated.
+func pageTitle(body []byte) string {
+	const maxLen = 80
+
+	m := titlePattern.FindSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	title := strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+	if r := []rune(title); len(r) > maxLen {
+		title = string(r[:maxLen-1]) + "…"
+	}
+	return title
+}
+
+// decodeAttachmentResponse decodes a successful JSON response of an attachment endpoint.
+func decodeAttachmentResponse(res *http.Response, v any) error {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if err := checkLoginPage(res.Header, body); err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
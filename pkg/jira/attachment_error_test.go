@@ -0,0 +1,357 @@
+package jira
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyAttachmentStatus(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		code     int
+		expected StatusClass
+	}{
+		{code: http.StatusOK, expected: StatusPermanent},
+		{code: http.StatusBadRequest, expected: StatusPermanent},
+		{code: http.StatusForbidden, expected: StatusPermanent},
+		{code: http.StatusNotFound, expected: StatusPermanent},
+		{code: http.StatusConflict, expected: StatusTransient},
+		{code: http.StatusLocked, expected: StatusTransient},
+		{code: http.StatusRequestTimeout, expected: StatusAmbiguous},
+		{code: http.StatusInternalServerError, expected: StatusAmbiguous},
+		{code: http.StatusBadGateway, expected: StatusAmbiguous},
+		{code: http.StatusServiceUnavailable, expected: StatusAmbiguous},
+		{code: http.StatusGatewayTimeout, expected: StatusAmbiguous},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, ClassifyAttachmentStatus(tc.code), http.StatusText(tc.code))
+	}
+}
+
+func newRetryTestClient(server string) *Client {
+	client := NewClient(Config{
+		Server:   server,
+		Login:    "test",
+		APIToken: "token",
+	}, WithTimeout(3*time.Second))
+	client.retryBackoff = time.Millisecond
+
+	return client
+}
+
+func TestUploadAttachmentRetriesLockedIssue(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusLocked)
+			return
+		}
+
+		// The body must be sent in full on every attempt.
+		file, _, err := r.FormFile("file")
+		assert.NoError(t, err)
+		_ = file.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`[{"id": "10001", "filename": "test.txt"}]`))
+	}))
+	defer server.Close()
+
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	assert.NoError(t, os.WriteFile(testFile, []byte("test content"), 0o644))
+
+	attachments, err := newRetryTestClient(server.URL).UploadAttachment("TEST-1", testFile)
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 1)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestDeleteAttachmentPersistentConflict(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"errorMessages":["Reindex in progress"]}`))
+	}))
+	defer server.Close()
+
+	err := newRetryTestClient(server.URL).DeleteAttachment("10001")
+	assert.Error(t, err)
+
+	var attErr *AttachmentError
+	assert.True(t, errors.As(err, &attErr))
+	assert.True(t, attErr.Retryable)
+	assert.Equal(t, AttachmentOpDelete, attErr.Op)
+	assert.Equal(t, http.StatusConflict, attErr.StatusCode)
+	assert.Equal(t, attachmentMaxAttempts, attErr.Attempts)
+	assert.Contains(t, err.Error(), "Reindex in progress")
+	assert.Equal(t, int32(attachmentMaxAttempts), atomic.LoadInt32(&calls))
+}
+
+func TestDownloadAttachmentNotRetriedOnPermanentFailure(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := newRetryTestClient(server.URL).DownloadAttachment(server.URL+"/attachment/10001", filepath.Join(t.TempDir(), "out"))
+	assert.Error(t, err)
+
+	var attErr *AttachmentError
+	assert.False(t, errors.As(err, &attErr))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestUploadAttachmentEmptyResponse(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	testFile := filepath.Join(t.TempDir(), "virus.exe")
+	assert.NoError(t, os.WriteFile(testFile, []byte("MZ"), 0o644))
+
+	attachments, err := newRetryTestClient(server.URL).UploadAttachment("TEST-1", testFile)
+	assert.ErrorIs(t, err, ErrNoAttachmentCreated)
+	assert.Nil(t, attachments)
+	assert.Contains(t, err.Error(), "created no attachment")
+}
+
+func TestReconcileAttachments(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		sent     []string
+		created  []Attachment
+		expected []string
+	}{
+		{
+			name:    "all created",
+			sent:    []string{"a.txt", "b.png"},
+			created: []Attachment{{Filename: "b.png"}, {Filename: "a.txt"}},
+		},
+		{
+			name:     "nothing created",
+			sent:     []string{"a.txt", "b.png"},
+			expected: []string{"a.txt", "b.png"},
+		},
+		{
+			name:     "one missing",
+			sent:     []string{"a.txt", "b.png", "c.zip"},
+			created:  []Attachment{{Filename: "a.txt"}, {Filename: "c.zip"}},
+			expected: []string{"b.png"},
+		},
+		{
+			name:     "duplicate names are counted",
+			sent:     []string{"a.txt", "a.txt"},
+			created:  []Attachment{{Filename: "a.txt"}},
+			expected: []string{"a.txt"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, ReconcileAttachments(tc.sent, tc.created))
+		})
+	}
+}
+
+func TestCheckLoginPage(t *testing.T) {
+	t.Parallel()
+
+	page, err := os.ReadFile("./testdata/login-page.html")
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantTitle   string
+		wantErr     bool
+	}{
+		{
+			name:        "login page",
+			contentType: "text/html; charset=utf-8",
+			body:        page,
+			wantTitle:   "Sign in – Example Corp SSO",
+			wantErr:     true,
+		},
+		{
+			name:        "login page served as json",
+			contentType: "application/json",
+			body:        page,
+			wantTitle:   "Sign in – Example Corp SSO",
+			wantErr:     true,
+		},
+		{
+			name:        "html without title",
+			contentType: "text/html",
+			body:        []byte("<html><body>Session expired</body></html>"),
+			wantErr:     true,
+		},
+		{
+			name:        "json",
+			contentType: "application/json;charset=UTF-8",
+			body:        []byte(`[{"id": "10001", "filename": "a.html"}]`),
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			header := http.Header{}
+			header.Set("Content-Type", tc.contentType)
+
+			err := checkLoginPage(header, tc.body)
+			if !tc.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			var authErr *ErrAuthenticationRequired
+			assert.ErrorAs(t, err, &authErr)
+			assert.Equal(t, tc.wantTitle, authErr.Title)
+		})
+	}
+}
+
+func TestErrAuthenticationRequired(t *testing.T) {
+	t.Parallel()
+
+	err := &ErrAuthenticationRequired{Title: "Sign in"}
+	assert.Equal(
+		t,
+		"jira: received an HTML page instead of the expected response, your session or API token may have expired "+
+			"(page title: \"Sign in\").\nPlease refresh your token or re-authenticate and try again.",
+		err.Error(),
+	)
+
+	err = &ErrAuthenticationRequired{}
+	assert.Equal(
+		t,
+		"jira: received an HTML page instead of the expected response, your session or API token may have expired.\n"+
+			"Please refresh your token or re-authenticate and try again.",
+		err.Error(),
+	)
+}
+
+func TestUploadAttachmentLoginPage(t *testing.T) {
+	t.Parallel()
+
+	page, err := os.ReadFile("./testdata/login-page.html")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(200)
+		_, _ = w.Write(page)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "a.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("content"), 0o600))
+
+	_, err = newRetryTestClient(server.URL).UploadAttachment("TEST-1", file)
+
+	var authErr *ErrAuthenticationRequired
+	assert.ErrorAs(t, err, &authErr)
+	assert.Equal(t, "Sign in – Example Corp SSO", authErr.Title)
+}
+
+func TestDownloadAttachmentLoginPage(t *testing.T) {
+	t.Parallel()
+
+	page, err := os.ReadFile("./testdata/login-page.html")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html;charset=UTF-8")
+		w.WriteHeader(200)
+		_, _ = w.Write(page)
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server.URL)
+
+	cases := []struct {
+		name     string
+		opts     []DownloadOption
+		wantAuth bool
+	}{
+		{
+			name:     "pdf expected",
+			opts:     []DownloadOption{ExpectContent("application/pdf", 482133)},
+			wantAuth: true,
+		},
+		{
+			name: "html attachment",
+			opts: []DownloadOption{ExpectContent("text/html", int64(len(page)))},
+		},
+		{
+			name: "html served for an attachment of the same size",
+			opts: []DownloadOption{ExpectContent("application/octet-stream", int64(len(page)))},
+		},
+		{
+			name: "no metadata",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "out")
+			_, err := client.DownloadAttachmentWithResult(server.URL+"/attachment/content/10001", dest, tc.opts...)
+
+			if !tc.wantAuth {
+				assert.NoError(t, err)
+				content, err := os.ReadFile(dest)
+				assert.NoError(t, err)
+				assert.Equal(t, page, content)
+				return
+			}
+
+			var authErr *ErrAuthenticationRequired
+			assert.ErrorAs(t, err, &authErr)
+
+			_, statErr := os.Stat(dest)
+			assert.True(t, os.IsNotExist(statErr))
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
+)
+
+// DefaultAttachmentFileMode is the permission of downloaded attachments, only readable by the owner.
+const DefaultAttachmentFileMode os.FileMode = 0o600
+
+// DefaultAttachmentDirMode is the permission of the directories created for downloaded
+// attachments, only accessible by the owner.
+const DefaultAttachmentDirMode os.FileMode = 0o700
+
+// WithFileMode sets the permission of the downloaded file, DefaultAttachmentFileMode if not set.
+// On Windows only the owner write bit is honoured, as a read-only attribute.
+func WithFileMode(mode os.FileMode) DownloadOption {
+	return func(o *downloadOptions) {
+		o.mode = mode
+	}
+}
+
+// createAttachmentFile creates or truncates the file at path with the given permission.
+// The mode is set on the open file before anything is written to it, so that neither the
+// umask nor the mode of a file being overwritten leave the content exposed, not even while
+// the download is in progress.
+func createAttachmentFile(path string, mode os.FileMode) (*os.File, error) {
+	if mode == 0 {
+		mode = DefaultAttachmentFileMode
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return nil, err
+	}
+	if err := out.Chmod(mode); err != nil {
+		_ = out.Close()
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return out, nil
+}
+
+// atomicDownload calls download with the path of a temporary file next to destPath and
+// renames it to destPath if the download succeeds, so that destPath never holds a partial
+// attachment, not even while it downloads. The temporary file is removed otherwise, and
+// if the server reported that the attachment was not modified.
+func atomicDownload(destPath string, download func(path string) (*DownloadResult, error)) (*DownloadResult, error) {
+	tmp, err := tmpfile.Create(destPath)
+	if err != nil {
+		return nil, err
+	}
+	_ = tmp.Close()
+
+	result, err := download(tmp.Name())
+	if err == nil && !result.NotModified {
+		err = os.Rename(tmp.Name(), destPath)
+	}
+	if err != nil || result.NotModified {
+		_ = os.Remove(tmp.Name())
+	}
+
+	var diskFull *ErrDiskFull
+	if errors.As(err, &diskFull) {
+		diskFull.Path = destPath
+	}
+	return result, err
+}
+
+// ErrDiskFull is returned when the destination filesystem runs out of space during a download.
+type ErrDiskFull struct {
+	Path string
+	// Needed is the size of the attachment, 0 if the server didn't advertise it.
+	Needed int64
+	// Written is the number of bytes written before the filesystem filled up.
+	Written int64
+	// Free is the space available on the filesystem, -1 if it couldn't be determined.
+	Free int64
+}
+
+func (e *ErrDiskFull) Error() string {
+	needed := FormatSize(e.Needed)
+	if e.Needed <= 0 {
+		needed = "more than " + FormatSize(e.Written)
+	}
+	free := "unknown"
+	if e.Free >= 0 {
+		free = FormatSize(e.Free)
+	}
+	return fmt.Sprintf("destination filesystem is full (needed %s, free %s)", needed, free)
+}
+
+// Unwrap allows the error to be matched against syscall.ENOSPC.
+func (e *ErrDiskFull) Unwrap() error {
+	return syscall.ENOSPC
+}
+
+// writeAttachment writes body to the file at path and returns the number of bytes written.
+//
+// If the size is known, the space is reserved up front so that an attachment that can't
+// fit fails before anything is written. The file is removed on every error so that a
+// failed download doesn't leave a partial file behind on a nearly full filesystem.
+func (c *Client) writeAttachment(path string, body io.Reader, size int64, mode os.FileMode) (n int64, err error) {
+	out, err := createAttachmentFile(path, mode)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		// Some filesystems only report a full disk when the file is closed.
+		if cErr := out.Close(); err == nil {
+			err = cErr
+		}
+		if err == nil {
+			return
+		}
+		_ = os.Remove(path)
+		if isNoSpace(err) {
+			free, fErr := freeDiskSpace(filepath.Dir(path))
+			if fErr != nil {
+				free = -1
+			}
+			err = &ErrDiskFull{Path: path, Needed: size, Written: n, Free: free}
+		}
+	}()
+
+	if size > 0 {
+		if err := preallocate(out, size); err != nil {
+			return 0, err
+		}
+	}
+
+	var w io.Writer = out
+	if c.attachmentWriter != nil {
+		w = c.attachmentWriter(out)
+	}
+	return io.Copy(w, body)
+}
+
+// isNoSpace checks if the error was caused by a full filesystem.
+func isNoSpace(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || isNoSpaceOS(err)
+}
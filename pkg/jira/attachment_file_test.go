@@ -0,0 +1,279 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/tmpfile"
+)
+
+// limitedWriter simulates a filesystem with a fixed amount of free space.
+type limitedWriter struct {
+	w     io.Writer
+	limit int64
+	err   error
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.limit {
+		n, _ := l.w.Write(p[:l.limit])
+		l.limit = 0
+		return n, l.err
+	}
+	l.limit -= int64(len(p))
+	return l.w.Write(p)
+}
+
+func TestDownloadAttachmentWriteErrors(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("x", 4096)
+
+	cases := []struct {
+		name          string
+		contentLength bool
+		err           error
+		wantDiskFull  bool
+		wantMessage   string
+	}{
+		{
+			name:          "filesystem full with known size",
+			contentLength: true,
+			err:           syscall.ENOSPC,
+			wantDiskFull:  true,
+			wantMessage:   "destination filesystem is full (needed 4.00 KB, free ",
+		},
+		{
+			name:         "filesystem full with unknown size",
+			err:          syscall.ENOSPC,
+			wantDiskFull: true,
+			wantMessage:  "destination filesystem is full (needed more than 1.00 KB, free ",
+		},
+		{
+			name:          "other write error",
+			contentLength: true,
+			err:           syscall.EIO,
+			wantMessage:   "input/output error",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.contentLength {
+					w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+				}
+				w.WriteHeader(200)
+				_, _ = w.Write([]byte(content))
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+			client.attachmentWriter = func(w io.Writer) io.Writer {
+				return &limitedWriter{w: w, limit: 1024, err: tc.err}
+			}
+
+			destPath := filepath.Join(t.TempDir(), "downloaded.bin")
+
+			_, err := client.DownloadAttachmentWithResult(server.URL+"/attachments/test.bin", destPath)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantMessage)
+
+			var diskFull *ErrDiskFull
+			assert.Equal(t, tc.wantDiskFull, errors.As(err, &diskFull))
+			assert.Equal(t, tc.wantDiskFull, errors.Is(err, syscall.ENOSPC))
+			if tc.wantDiskFull {
+				assert.Equal(t, int64(1024), diskFull.Written)
+			}
+
+			// The partial file is always removed.
+			_, statErr := os.Stat(destPath)
+			assert.True(t, os.IsNotExist(statErr))
+		})
+	}
+}
+
+func TestDownloadAttachmentTruncatedRemovesFile(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "4096")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte("short body"))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	destPath := filepath.Join(t.TempDir(), "downloaded.bin")
+
+	_, err := client.DownloadAttachmentWithResult(server.URL+"/attachments/test.bin", destPath)
+	assert.Error(t, err)
+
+	_, statErr := os.Stat(destPath)
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestErrDiskFull(t *testing.T) {
+	t.Parallel()
+
+	err := &ErrDiskFull{Path: "a.bin", Needed: 5 * 1024 * 1024, Free: 1024}
+	assert.Equal(t, "destination filesystem is full (needed 5.00 MB, free 1.00 KB)", err.Error())
+
+	err = &ErrDiskFull{Path: "a.bin", Written: 512, Free: -1}
+	assert.Equal(t, "destination filesystem is full (needed more than 512 B, free unknown)", err.Error())
+}
+
+func TestDownloadAttachmentFileMode(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are best effort on windows")
+	}
+
+	content := strings.Repeat("secret ", 512)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "secret.txt", time.Time{}, strings.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	cases := []struct {
+		name     string
+		opts     []DownloadOption
+		existing bool
+		want     os.FileMode
+	}{
+		{name: "default", want: DefaultAttachmentFileMode},
+		{name: "custom mode", opts: []DownloadOption{WithFileMode(0o640)}, want: 0o640},
+		{name: "mode wider than umask", opts: []DownloadOption{WithFileMode(0o666)}, want: 0o666},
+		{name: "overwritten file", existing: true, want: DefaultAttachmentFileMode},
+		{name: "parallel ranges", opts: []DownloadOption{WithParallelRanges(4), WithFileMode(0o604)}, want: 0o604},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			destPath := filepath.Join(t.TempDir(), "secret.txt")
+			if tc.existing {
+				assert.NoError(t, os.WriteFile(destPath, []byte("old"), 0o644))
+				assert.NoError(t, os.Chmod(destPath, 0o644))
+			}
+
+			_, err := client.DownloadAttachmentWithResult(server.URL+"/secret.txt", destPath, tc.opts...)
+			assert.NoError(t, err)
+
+			info, err := os.Stat(destPath)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, info.Mode().Perm())
+
+			got, err := os.ReadFile(destPath)
+			assert.NoError(t, err)
+			assert.Equal(t, content, string(got))
+		})
+	}
+}
+
+func TestDownloadAttachmentConnectionClosedEarly(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		// The network drops after part of the body.
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 4096\r\n\r\n" + strings.Repeat("x", 1000))
+		_ = buf.Flush()
+		_ = conn.Close()
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	for _, existing := range []bool{false, true} {
+		dir := t.TempDir()
+		destPath := filepath.Join(dir, "report.pdf")
+		if existing {
+			assert.NoError(t, os.WriteFile(destPath, []byte("previous download"), 0o600))
+		}
+
+		_, err := client.DownloadAttachmentWithResult(server.URL+"/report.pdf", destPath)
+		assert.EqualError(t, err, "failed to download attachment: connection closed after 1000 of 4096 bytes: unexpected EOF")
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+		if existing {
+			got, err := os.ReadFile(destPath)
+			assert.NoError(t, err)
+			assert.Equal(t, "previous download", string(got), "a failed download leaves the file as it was")
+		} else {
+			_, statErr := os.Stat(destPath)
+			assert.True(t, os.IsNotExist(statErr))
+		}
+
+		want := 0
+		if existing {
+			want = 1
+		}
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, want, "the temporary file is removed")
+	}
+}
+
+func TestDownloadAttachmentWritesTemporaryFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	destPath := filepath.Join(dir, "report.pdf")
+
+	var during []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "8")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte("half"))
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			during = append(during, e.Name())
+		}
+		_, _ = w.Write([]byte("done"))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	_, err := client.DownloadAttachmentWithResult(server.URL+"/report.pdf", destPath)
+	assert.NoError(t, err)
+
+	if assert.Len(t, during, 1) {
+		_, ok := tmpfile.Parse(during[0])
+		assert.True(t, ok, "the download is written to a temporary file, got %s", during[0])
+	}
+	got, err := os.ReadFile(destPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "halfdone", string(got))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,52 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// GetAttachment fetches the metadata of an attachment, eg: its filename, author, size,
+// mime type, created date and content URL, using v3 version of the GET /attachment/{id}
+// endpoint, without fetching its issue.
+func (c *Client) GetAttachment(attachmentID string) (*Attachment, error) {
+	return c.getAttachment(attachmentID, apiVersion3)
+}
+
+// GetAttachmentV2 fetches the metadata of an attachment using v2 version of the
+// GET /attachment/{id} endpoint.
+func (c *Client) GetAttachmentV2(attachmentID string) (*Attachment, error) {
+	return c.getAttachment(attachmentID, apiVersion2)
+}
+
+func (c *Client) getAttachment(attachmentID, ver string) (*Attachment, error) {
+	path := fmt.Sprintf("/attachment/%s", attachmentID)
+
+	var (
+		res *http.Response
+		err error
+	)
+	switch ver {
+	case apiVersion2:
+		res, err = c.GetV2(context.Background(), path, nil)
+	default:
+		res, err = c.Get(context.Background(), path, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, formatUnexpectedResponse(res)
+	}
+
+	var out Attachment
+	if err := decodeAttachmentResponse(res, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
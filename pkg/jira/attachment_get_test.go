@@ -0,0 +1,98 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAttachmentJSON = `{
+	"self": "https://jira.example.com/rest/api/3/attachment/10001",
+	"id": "10001",
+	"filename": "report.pdf",
+	"author": {"accountId": "5b10a2844c20165700ede21g", "displayName": "Jane Doe"},
+	"created": "` + testCreated + `",
+	"size": 10,
+	"mimeType": "application/pdf",
+	"content": "https://jira.example.com/rest/api/3/attachment/content/10001"
+}`
+
+func TestGetAttachment(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		get  func(*Client) (*Attachment, error)
+		path string
+	}{
+		{name: "v3", get: func(c *Client) (*Attachment, error) { return c.GetAttachment("10001") }, path: "/rest/api/3/attachment/10001"},
+		{name: "v2", get: func(c *Client) (*Attachment, error) { return c.GetAttachmentV2("10001") }, path: "/rest/api/2/attachment/10001"},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				assert.Equal(t, tc.path, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(testAttachmentJSON))
+			}))
+			defer server.Close()
+
+			a, err := tc.get(NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second)))
+			require.NoError(t, err)
+			assert.Equal(t, "10001", a.ID)
+			assert.Equal(t, "report.pdf", a.Filename)
+			assert.Equal(t, "Jane Doe", a.Author.DisplayName)
+			assert.Equal(t, int64(10), a.Size)
+			assert.Equal(t, "application/pdf", a.MimeType)
+			assert.Equal(t, testCreated, a.Created)
+			assert.Equal(t, time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC), a.CreatedAt.UTC())
+			assert.Equal(t, "https://jira.example.com/rest/api/3/attachment/content/10001", a.Content)
+			assert.True(t, a.Available())
+		})
+	}
+}
+
+func TestGetAttachmentNotFound(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errorMessages":["The attachment with id '10001' does not exist"],"errors":{}}`))
+	}))
+	defer server.Close()
+
+	a, err := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second)).GetAttachment("10001")
+	assert.Nil(t, a)
+
+	var resErr *ErrUnexpectedResponse
+	require.True(t, errors.As(err, &resErr), err)
+	assert.Equal(t, http.StatusNotFound, resErr.StatusCode)
+	assert.Contains(t, resErr.Error(), "does not exist")
+}
+
+func TestGetAttachmentMalformedJSON(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "10001", "filename": `))
+	}))
+	defer server.Close()
+
+	a, err := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second)).GetAttachmentV2("10001")
+	assert.Nil(t, a)
+
+	var syntaxErr *json.SyntaxError
+	assert.True(t, errors.As(err, &syntaxErr), err)
+}
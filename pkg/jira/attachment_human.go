@@ -0,0 +1,63 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ArchiveEntry is a file inside an attached archive.
+type ArchiveEntry struct {
+	Index     int    `json:"index"`
+	Path      string `json:"path"`
+	Label     string `json:"label"`
+	Size      string `json:"size"`
+	MediaType string `json:"mediaType"`
+}
+
+// Name returns the name of the entry as shown by Jira, or its path if there is no label.
+func (e ArchiveEntry) Name() string {
+	if e.Label != "" {
+		return e.Label
+	}
+	return e.Path
+}
+
+// AttachmentArchive is the human readable metadata of an attached archive. Jira lists only
+// the first entries, TotalEntryCount is the number of files in the whole archive.
+type AttachmentArchive struct {
+	ID              int            `json:"id"`
+	Name            string         `json:"name"`
+	Entries         []ArchiveEntry `json:"entries"`
+	TotalEntryCount int            `json:"totalEntryCount"`
+	MediaType       string         `json:"mediaType"`
+}
+
+// GetAttachmentHumanMetadata fetches the contents of an attached archive using GET
+// /attachment/{id}/expand/human endpoint. The endpoint is only available on Jira server
+// and data center. ErrNoResult is returned if it is missing or the attachment is not an
+// archive that Jira can expand.
+func (c *Client) GetAttachmentHumanMetadata(id string) (*AttachmentArchive, error) {
+	res, err := c.GetV2(context.Background(), fmt.Sprintf("/attachment/%s/expand/human", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNoResult
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, formatUnexpectedResponse(res)
+	}
+
+	var out AttachmentArchive
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
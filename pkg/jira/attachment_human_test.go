@@ -0,0 +1,61 @@
+package jira
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAttachmentHumanMetadata(t *testing.T) {
+	var statusCode int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/attachment/10001/expand/human", r.URL.Path)
+
+		if statusCode != http.StatusOK {
+			w.WriteHeader(statusCode)
+			return
+		}
+		resp, err := os.ReadFile("./testdata/attachment-human.json")
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	statusCode = http.StatusOK
+	actual, err := client.GetAttachmentHumanMetadata("10001")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 10001, actual.ID)
+	assert.Equal(t, "images.zip", actual.Name)
+	assert.Equal(t, "application/zip", actual.MediaType)
+	assert.Equal(t, 39, actual.TotalEntryCount)
+	assert.Len(t, actual.Entries, 3)
+	assert.Equal(t, ArchiveEntry{
+		Index:     1,
+		Path:      "Allegro from Duet in C Major.mp3",
+		Label:     "Allegro from Duet in C Major.mp3",
+		Size:      "1.36 MB",
+		MediaType: "audio/mpeg",
+	}, actual.Entries[1])
+	assert.Equal(t, "docs/l...path/thanks.txt", actual.Entries[2].Name())
+	assert.Equal(t, "docs/long/path/thanks.txt", ArchiveEntry{Path: "docs/long/path/thanks.txt"}.Name())
+
+	statusCode = http.StatusNotFound
+	_, err = client.GetAttachmentHumanMetadata("10001")
+	assert.ErrorIs(t, err, ErrNoResult)
+
+	statusCode = http.StatusInternalServerError
+	_, err = client.GetAttachmentHumanMetadata("10001")
+	var unexpected *ErrUnexpectedResponse
+	assert.ErrorAs(t, err, &unexpected)
+}
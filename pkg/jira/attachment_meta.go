@@ -0,0 +1,66 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// AttachmentMeta holds the attachment settings of the instance.
+type AttachmentMeta struct {
+	Enabled bool `json:"enabled"`
+	// UploadLimit is the maximum size of an attachment in bytes.
+	UploadLimit int64 `json:"uploadLimit"`
+}
+
+// GetAttachmentMeta fetches the attachment settings using v3 version of the
+// GET /attachment/meta endpoint. The settings are cached by the client so repeated
+// calls don't hit the server.
+func (c *Client) GetAttachmentMeta() (*AttachmentMeta, error) {
+	return c.getAttachmentMeta(apiVersion3)
+}
+
+// GetAttachmentMetaV2 fetches the attachment settings using v2 version of the
+// GET /attachment/meta endpoint. The settings are cached by the client so repeated
+// calls don't hit the server.
+func (c *Client) GetAttachmentMetaV2() (*AttachmentMeta, error) {
+	return c.getAttachmentMeta(apiVersion2)
+}
+
+func (c *Client) getAttachmentMeta(ver string) (*AttachmentMeta, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.attachmentMetaCache != nil {
+		return c.attachmentMetaCache, nil
+	}
+
+	var (
+		res *http.Response
+		err error
+	)
+	switch ver {
+	case apiVersion2:
+		res, err = c.GetV2(context.Background(), "/attachment/meta", nil)
+	default:
+		res, err = c.Get(context.Background(), "/attachment/meta", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, formatUnexpectedResponse(res)
+	}
+
+	var out AttachmentMeta
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	c.attachmentMetaCache = &out
+	return &out, nil
+}
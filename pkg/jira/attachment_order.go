@@ -0,0 +1,37 @@
+package jira
+
+import (
+	"cmp"
+	"strconv"
+)
+
+// CompareAttachmentIDs orders attachments by id. Ids are assigned in upload order, so they
+// are compared as numbers; ids that are not numbers are compared as strings after those that are.
+func CompareAttachmentIDs(a, b Attachment) int {
+	x, errX := strconv.ParseInt(a.ID, 10, 64)
+	y, errY := strconv.ParseInt(b.ID, 10, 64)
+	switch {
+	case errX == nil && errY == nil:
+		return cmp.Compare(x, y)
+	case errX == nil:
+		return -1
+	case errY == nil:
+		return 1
+	default:
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
+
+// CompareAttachmentsByCreated orders attachments by creation date, oldest first. Attachments
+// created at the same time, or with a date that can't be parsed, are ordered by id instead,
+// so that the order is the same on every run.
+func CompareAttachmentsByCreated(a, b Attachment) int {
+	x, okX := a.CreatedTime()
+	y, okY := b.CreatedTime()
+	if okX && okY {
+		if c := x.Compare(y); c != 0 {
+			return c
+		}
+	}
+	return CompareAttachmentIDs(a, b)
+}
@@ -0,0 +1,66 @@
+package jira
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentCreatedTime(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		created string
+		ok      bool
+	}{
+		{name: "cloud", created: "2024-01-02T10:00:00.123+0100", ok: true},
+		{name: "without milliseconds", created: "2024-01-02T10:00:00+0100", ok: true},
+		{name: "rfc3339", created: "2024-01-02T10:00:00+01:00", ok: true},
+		{name: "empty", created: "", ok: false},
+		{name: "garbage", created: "yesterday", ok: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			created, ok := Attachment{Created: tc.created}.CreatedTime()
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, 9, created.UTC().Hour())
+			}
+		})
+	}
+}
+
+func TestCompareAttachmentIDs(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, -1, CompareAttachmentIDs(Attachment{ID: "9"}, Attachment{ID: "10"}))
+	assert.Equal(t, 1, CompareAttachmentIDs(Attachment{ID: "10"}, Attachment{ID: "9"}))
+	assert.Equal(t, 0, CompareAttachmentIDs(Attachment{ID: "10"}, Attachment{ID: "10"}))
+	assert.Equal(t, -1, CompareAttachmentIDs(Attachment{ID: "10"}, Attachment{ID: "abc"}))
+	assert.Equal(t, -1, CompareAttachmentIDs(Attachment{ID: "abc"}, Attachment{ID: "abd"}))
+}
+
+func TestCompareAttachmentsByCreated(t *testing.T) {
+	t.Parallel()
+
+	older := Attachment{ID: "20", Created: "2024-01-01T10:00:00.000+0000"}
+	newer := Attachment{ID: "10", Created: "2024-01-01T12:00:00.000+0100"}
+	same := Attachment{ID: "5", Created: "2024-01-01T11:00:00.000+0000"}
+	broken := Attachment{ID: "1", Created: "not a date"}
+
+	assert.Equal(t, -1, CompareAttachmentsByCreated(older, newer))
+	assert.Equal(t, 1, CompareAttachmentsByCreated(newer, older))
+
+	// Same instant in different zones, ordered by id.
+	assert.Equal(t, 1, CompareAttachmentsByCreated(newer, same))
+
+	// Unparseable dates fall back to the id order.
+	assert.Equal(t, -1, CompareAttachmentsByCreated(broken, older))
+	assert.Equal(t, 1, CompareAttachmentsByCreated(older, broken))
+}
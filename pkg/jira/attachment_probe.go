@@ -0,0 +1,90 @@
+package jira
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultProbeTimeout bounds a probe of an attachment content URL.
+const DefaultProbeTimeout = 5 * time.Second
+
+// LinkStatus is the outcome of a probe of an attachment content URL.
+type LinkStatus string
+
+const (
+	// LinkOK is content the server serves.
+	LinkOK LinkStatus = "ok"
+	// LinkMissing is content the server doesn't have, eg: not migrated.
+	LinkMissing LinkStatus = "missing"
+	// LinkForbidden is content the credentials aren't allowed to download.
+	LinkForbidden LinkStatus = "forbidden"
+	// LinkError is content whose state couldn't be determined, eg: the probe timed out.
+	LinkError LinkStatus = "error"
+)
+
+// ClassifyLinkStatus classifies the status code received when probing a content URL.
+func ClassifyLinkStatus(code int) LinkStatus {
+	switch {
+	case code >= 200 && code < 300:
+		return LinkOK
+	case code == http.StatusNotFound, code == http.StatusGone:
+		return LinkMissing
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return LinkForbidden
+	}
+	return LinkError
+}
+
+// LinkProbe is the result of a probe of an attachment content URL.
+type LinkProbe struct {
+	Status LinkStatus
+	// StatusCode is the status of the last response, 0 if there was none.
+	StatusCode int
+	// Err is the error of the last request, if any.
+	Err error
+}
+
+// ProbeAttachment checks that the content at url can be downloaded without downloading
+// it. It sends a HEAD request, and a GET of the first byte if the server rejects HEAD, eg:
+// with 405 or signed URLs only valid for GET. Each request is bounded by timeout,
+// independently of the timeout of the client.
+func (c *Client) ProbeAttachment(ctx context.Context, url string, timeout time.Duration) LinkProbe {
+	p := c.probe(ctx, http.MethodHead, url, timeout)
+	if p.Err == nil && headRejected(p.StatusCode) {
+		p = c.probe(ctx, http.MethodGet, url, timeout)
+	}
+	return p
+}
+
+func (c *Client) probe(ctx context.Context, method, url string, timeout time.Duration) LinkProbe {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return LinkProbe{Status: LinkError, Err: err}
+	}
+	c.applyAuth(req)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	res, err := c.doAttachment(req)
+	if err != nil {
+		return LinkProbe{Status: LinkError, Err: err}
+	}
+	// The body isn't read, a server ignoring the range is cut off when it is closed.
+	_ = res.Body.Close()
+	return LinkProbe{Status: ClassifyLinkStatus(res.StatusCode), StatusCode: res.StatusCode}
+}
+
+// headRejected reports if the status of a HEAD request may be a rejection of the method
+// rather than the state of the content.
+func headRejected(code int) bool {
+	switch code {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package jira
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyLinkStatus(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, LinkOK, ClassifyLinkStatus(http.StatusOK))
+	assert.Equal(t, LinkOK, ClassifyLinkStatus(http.StatusPartialContent))
+	assert.Equal(t, LinkMissing, ClassifyLinkStatus(http.StatusNotFound))
+	assert.Equal(t, LinkMissing, ClassifyLinkStatus(http.StatusGone))
+	assert.Equal(t, LinkForbidden, ClassifyLinkStatus(http.StatusUnauthorized))
+	assert.Equal(t, LinkForbidden, ClassifyLinkStatus(http.StatusForbidden))
+	assert.Equal(t, LinkError, ClassifyLinkStatus(http.StatusInternalServerError))
+	assert.Equal(t, LinkError, ClassifyLinkStatus(http.StatusMethodNotAllowed))
+}
+
+// probeServer serves content whose status depends on the path and the method, and
+// records the requests it receives.
+type probeServer struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *probeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Range"))
+	s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/ok":
+		w.WriteHeader(http.StatusOK)
+	case "/missing":
+		w.WriteHeader(http.StatusNotFound)
+	case "/forbidden":
+		w.WriteHeader(http.StatusForbidden)
+	case "/no-head":
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Range", "bytes 0-0/1024")
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte("x"))
+	case "/signed":
+		// Signed URLs of object stores are only valid for the method they were signed for.
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(make([]byte, 1<<20))
+	case "/hang":
+		<-r.Context().Done()
+	}
+}
+
+func (s *probeServer) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func TestProbeAttachment(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		path     string
+		want     LinkStatus
+		requests []string
+	}{
+		{path: "/ok", want: LinkOK, requests: []string{"HEAD /ok "}},
+		{path: "/missing", want: LinkMissing, requests: []string{"HEAD /missing "}},
+		{path: "/forbidden", want: LinkForbidden, requests: []string{"HEAD /forbidden ", "GET /forbidden bytes=0-0"}},
+		{path: "/no-head", want: LinkOK, requests: []string{"HEAD /no-head ", "GET /no-head bytes=0-0"}},
+		{path: "/signed", want: LinkOK, requests: []string{"HEAD /signed ", "GET /signed bytes=0-0"}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+
+			ps := &probeServer{}
+			server := httptest.NewServer(ps)
+			t.Cleanup(server.Close)
+
+			p := newRetryTestClient(server.URL).ProbeAttachment(context.Background(), server.URL+tc.path, time.Second)
+			assert.NoError(t, p.Err)
+			assert.Equal(t, tc.want, p.Status)
+			assert.Equal(t, tc.requests, ps.recorded())
+		})
+	}
+}
+
+func TestProbeAttachmentTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(&probeServer{})
+	t.Cleanup(server.Close)
+
+	// The client timeout is longer, the probe is bounded by its own.
+	client := newRetryTestClient(server.URL)
+	start := time.Now()
+	p := client.ProbeAttachment(context.Background(), server.URL+"/hang", 100*time.Millisecond)
+
+	assert.Equal(t, LinkError, p.Status)
+	assert.Error(t, p.Err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
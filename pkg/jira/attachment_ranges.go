@@ -0,0 +1,305 @@
+package jira
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // Only used to verify a checksum sent by the server.
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// errRangesUnsupported is returned when the server doesn't honour range requests.
+// The download then falls back to a single stream.
+var errRangesUnsupported = errors.New("jira: range requests are not supported")
+
+// WithParallelRanges downloads the attachment as n byte ranges fetched concurrently.
+// It only applies if the server supports range requests and the size of the attachment
+// is known, the attachment is downloaded as a single stream otherwise.
+func WithParallelRanges(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.ranges = n
+	}
+}
+
+// rangeProbe is what a 1 byte range request tells about an attachment.
+type rangeProbe struct {
+	size         int64
+	etag         string
+	lastModified string
+	digest       string
+}
+
+// splitRanges splits size bytes into at most n ranges. The last range
+// holds the remaining bytes and may be smaller than the rest.
+func splitRanges(size int64, n int) []chunkRange {
+	if n <= 0 {
+		return nil
+	}
+	return chunkRanges(size, (size+int64(n)-1)/int64(n))
+}
+
+// downloadRanges downloads the attachment as concurrent byte ranges written at their
+// offsets in a preallocated file. It returns errRangesUnsupported before anything is
+// written if the server doesn't honour ranges, and also if it stops honouring them
+// midway, in which case the partial file is removed.
+func (c *Client) downloadRanges(url, destPath string, o downloadOptions) (*DownloadResult, error) {
+	probe, err := c.probeRanges(o.ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	result := DownloadResult{
+		Total:        probe.size,
+		TotalKnown:   true,
+		ETag:         probe.etag,
+		LastModified: probe.lastModified,
+	}
+	if o.budget != nil && o.budget.Exceeded() {
+		return &result, &ErrSizeCapExceeded{Limit: o.budget.Limit(), Used: o.budget.Used()}
+	}
+
+	result.Bytes, err = c.writeRanges(url, destPath, probe, splitRanges(probe.size, o.ranges), o)
+	if err != nil {
+		return &result, wrapTimeout(err, hostOf(url), TimeoutPhaseTransfer)
+	}
+	if result.Bytes != result.Total {
+		_ = os.Remove(destPath)
+		return &result, fmt.Errorf(
+			"failed to download attachment: received %d bytes, expected %d", result.Bytes, result.Total,
+		)
+	}
+	if err := verifyDigest(destPath, probe.digest); err != nil {
+		_ = os.Remove(destPath)
+		return &result, err
+	}
+	return &result, nil
+}
+
+// probeRanges requests the first byte of the attachment. Only a 206 response with
+// a Content-Range header that includes the full size shows that ranges are supported.
+func (c *Client) probeRanges(ctx context.Context, url string) (*rangeProbe, error) {
+	res, err := c.getRange(ctx, url, "bytes=0-0", "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusPartialContent {
+		return nil, errRangesUnsupported
+	}
+	start, _, size, ok := parseContentRange(res.Header.Get("Content-Range"))
+	if !ok || start != 0 || size <= 0 {
+		return nil, errRangesUnsupported
+	}
+	return &rangeProbe{
+		size:         size,
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+		digest:       res.Header.Get("Digest"),
+	}, nil
+}
+
+// writeRanges fetches the ranges concurrently and writes each one at its offset.
+// The file is removed on every error.
+func (c *Client) writeRanges(
+	url, path string, probe *rangeProbe, ranges []chunkRange, o downloadOptions,
+) (n int64, err error) {
+	out, err := createAttachmentFile(path, o.mode)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cErr := out.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	if err := preallocate(out, probe.size); err != nil {
+		return 0, err
+	}
+	if err := out.Truncate(probe.size); err != nil {
+		return 0, err
+	}
+
+	// If-Range makes the server send the whole attachment with 200 if it changed
+	// since the probe, so that ranges of two versions are never mixed.
+	validator := firstNonEmpty(probe.etag, probe.lastModified)
+	if o.progress != nil {
+		o.counter = newProgressCounter(probe.size, o.progress)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		written = make([]int64, len(ranges))
+		errs    = make([]error, len(ranges))
+	)
+	for i, r := range ranges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			written[i], errs[i] = c.fetchRange(url, validator, r, io.NewOffsetWriter(out, r.Start), o)
+		}()
+	}
+	wg.Wait()
+
+	for i := range ranges {
+		n += written[i]
+	}
+	// A server that stops honouring ranges takes precedence, the download is retried as a stream.
+	for _, e := range errs {
+		if errors.Is(e, errRangesUnsupported) {
+			return n, e
+		}
+	}
+	return n, errors.Join(errs...)
+}
+
+// fetchRange downloads a single range and verifies that the server sent exactly that range.
+// A range that stalls is resumed from where it stalled.
+func (c *Client) fetchRange(url, validator string, r chunkRange, w io.Writer, o downloadOptions) (int64, error) {
+	res, err := c.getRange(o.ctx, url, fmt.Sprintf("bytes=%d-%d", r.Start, r.End-1), validator)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	switch {
+	case res.StatusCode == http.StatusOK:
+		// The server ignored the range or the attachment changed since the probe.
+		return 0, errRangesUnsupported
+	case res.StatusCode != http.StatusPartialContent:
+		if ClassifyAttachmentStatus(res.StatusCode) == StatusTransient {
+			return 0, newAttachmentError(AttachmentOpDownload, res, attachmentMaxAttempts)
+		}
+		return 0, fmt.Errorf("failed to download attachment: %s%w", res.Status, formatUnexpectedResponse(res))
+	}
+
+	start, end, _, ok := parseContentRange(res.Header.Get("Content-Range"))
+	if !ok || start != r.Start || end != r.End-1 {
+		return 0, errRangesUnsupported
+	}
+
+	var raw io.Reader = res.Body
+	if o.stallTimeout > 0 {
+		rr := c.newResumingReader(url, res, r.Start, r.End, true, o)
+		rr.validator = validator
+		defer func() { _ = rr.Close() }()
+		raw = rr
+	}
+
+	var body io.Reader = io.LimitReader(raw, r.size())
+	if o.budget != nil {
+		body = &budgetReader{r: body, budget: o.budget}
+	}
+	if o.counter != nil {
+		body = &ProgressReader{r: body, c: o.counter}
+	}
+	n, err := io.Copy(w, body)
+	if err != nil {
+		return n, err
+	}
+	if n != r.size() {
+		return n, fmt.Errorf("failed to download attachment: received %d bytes of range %d-%d", n, r.Start, r.End-1)
+	}
+	return n, nil
+}
+
+func (c *Client) getRange(ctx context.Context, url, byteRange, validator string) (*http.Response, error) {
+	res, _, err := c.withAttachmentRetryContext(ctx, AttachmentOpDownload, func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		c.applyAuth(req)
+		if byteRange != "" {
+			req.Header.Set("Range", byteRange)
+		}
+		if validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+
+		return c.doAttachment(req.WithContext(ctx))
+	})
+	return res, err
+}
+
+// parseContentRange parses a Content-Range header, eg: "bytes 0-99/1000".
+// The end is inclusive. The size is -1 if the server sent "*".
+func parseContentRange(h string) (start, end, size int64, ok bool) {
+	spec, found := strings.CutPrefix(h, "bytes ")
+	if !found {
+		return 0, 0, 0, false
+	}
+	rng, total, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	first, last, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+
+	var err error
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+		return 0, 0, 0, false
+	}
+	size = -1
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, 0, false
+		}
+	}
+	return start, end, size, true
+}
+
+// verifyDigest checks the file against a Digest header, eg: "SHA-256=<base64>".
+// Only SHA-256 and MD5 are checked, other algorithms and a missing header are ignored.
+func verifyDigest(path, header string) error {
+	for _, d := range strings.Split(header, ",") {
+		algo, want, found := strings.Cut(strings.TrimSpace(d), "=")
+		if !found {
+			continue
+		}
+
+		var h hash.Hash
+		switch strings.ToLower(algo) {
+		case "sha-256":
+			h = sha256.New()
+		case "md5":
+			h = md5.New() //nolint:gosec
+		default:
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(h, f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+
+		if got := base64.StdEncoding.EncodeToString(h.Sum(nil)); got != want {
+			return fmt.Errorf("failed to download attachment: %s checksum mismatch", algo)
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package jira
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRanges(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		size     int64
+		n        int
+		expected []chunkRange
+	}{
+		{
+			name:     "no ranges",
+			size:     10,
+			n:        0,
+			expected: nil,
+		},
+		{
+			name: "uneven last range",
+			size: 10,
+			n:    3,
+			expected: []chunkRange{
+				{Index: 0, Start: 0, End: 4},
+				{Index: 1, Start: 4, End: 8},
+				{Index: 2, Start: 8, End: 10},
+			},
+		},
+		{
+			name: "exact split",
+			size: 9,
+			n:    3,
+			expected: []chunkRange{
+				{Index: 0, Start: 0, End: 3},
+				{Index: 1, Start: 3, End: 6},
+				{Index: 2, Start: 6, End: 9},
+			},
+		},
+		{
+			name:     "more ranges than bytes",
+			size:     2,
+			n:        4,
+			expected: []chunkRange{{Index: 0, Start: 0, End: 1}, {Index: 1, Start: 1, End: 2}},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, splitRanges(tc.size, tc.n))
+		})
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		header           string
+		start, end, size int64
+		ok               bool
+	}{
+		{header: "bytes 0-0/1000", start: 0, end: 0, size: 1000, ok: true},
+		{header: "bytes 100-199/1000", start: 100, end: 199, size: 1000, ok: true},
+		{header: "bytes 0-0/*", start: 0, end: 0, size: -1, ok: true},
+		{header: "bytes */1000"},
+		{header: "bytes 10-5/1000"},
+		{header: "items 0-0/10"},
+		{header: ""},
+	}
+
+	for _, tc := range cases {
+		start, end, size, ok := parseContentRange(tc.header)
+		assert.Equal(t, tc.ok, ok, tc.header)
+		if tc.ok {
+			assert.Equal(t, []int64{tc.start, tc.end, tc.size}, []int64{start, end, size}, tc.header)
+		}
+	}
+}
+
+// rangeServer serves content with range support from http.ServeContent. The
+// ignoreRanges hook can make it answer a ranged request with the whole content.
+type rangeServer struct {
+	content      []byte
+	digest       string
+	ignoreRanges func(rangeHeader string) bool
+
+	mu     sync.Mutex
+	ranges []string
+	plain  atomic.Int32
+}
+
+func newRangeServer(t *testing.T, size int) *rangeServer {
+	t.Helper()
+
+	content := make([]byte, size)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(content)
+	return &rangeServer{content: content, digest: "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])}
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rng := r.Header.Get("Range")
+	if rng == "" {
+		s.plain.Add(1)
+	} else {
+		s.mu.Lock()
+		s.ranges = append(s.ranges, rng)
+		s.mu.Unlock()
+	}
+	if s.ignoreRanges != nil && s.ignoreRanges(rng) {
+		r.Header.Del("Range")
+	}
+
+	w.Header().Set("ETag", `"v1"`)
+	w.Header().Set("Digest", s.digest)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.content))
+}
+
+func downloadWithRanges(t *testing.T, s *rangeServer, n int) (string, *DownloadResult, error) {
+	t.Helper()
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	dest := filepath.Join(t.TempDir(), "big.bin")
+	client := NewClient(Config{Server: server.URL}, WithTimeout(5*time.Second))
+	res, err := client.DownloadAttachmentWithResult(server.URL+"/big.bin", dest, WithParallelRanges(n))
+	return dest, res, err
+}
+
+func TestDownloadAttachmentParallelRanges(t *testing.T) {
+	t.Parallel()
+
+	s := newRangeServer(t, 1<<20+3)
+	dest, res, err := downloadWithRanges(t, s, 4)
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, s.content, got)
+	assert.Equal(t, int64(len(s.content)), res.Bytes)
+	assert.Equal(t, `"v1"`, res.ETag)
+
+	assert.ElementsMatch(t, []string{
+		"bytes=0-0",
+		"bytes=0-262144",
+		"bytes=262145-524289",
+		"bytes=524290-786434",
+		"bytes=786435-1048578",
+	}, s.ranges)
+	assert.Zero(t, s.plain.Load())
+}
+
+func TestDownloadAttachmentParallelRangesFallback(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		ignore func(rng string) bool
+	}{
+		{
+			// Accept-Ranges is advertised, but ranged requests get the whole content with 200.
+			name:   "ranges ignored",
+			ignore: func(string) bool { return true },
+		},
+		{
+			name:   "ranges ignored after the probe",
+			ignore: func(rng string) bool { return rng != "bytes=0-0" },
+		},
+		{
+			name:   "one range ignored",
+			ignore: func(rng string) bool { return rng == "bytes=5000-9999" },
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newRangeServer(t, 20000)
+			s.ignoreRanges = tc.ignore
+
+			dest, res, err := downloadWithRanges(t, s, 4)
+			require.NoError(t, err)
+
+			got, err := os.ReadFile(dest)
+			require.NoError(t, err)
+			assert.Equal(t, s.content, got, "content must not be corrupted")
+			assert.Equal(t, int64(len(s.content)), res.Bytes)
+			assert.Equal(t, int32(1), s.plain.Load(), "must fall back to a single stream")
+		})
+	}
+}
+
+func TestDownloadAttachmentParallelRangesUnknownSize(t *testing.T) {
+	t.Parallel()
+
+	var plain atomic.Int32
+	content := []byte("streamed content")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			w.Header().Set("Content-Range", "bytes 0-0/*")
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(content[:1])
+			return
+		}
+		plain.Add(1)
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.txt")
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	_, err := client.DownloadAttachmentWithResult(server.URL+"/a.txt", dest, WithParallelRanges(4))
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	assert.Equal(t, int32(1), plain.Load())
+}
+
+func TestDownloadAttachmentParallelRangesDigestMismatch(t *testing.T) {
+	t.Parallel()
+
+	s := newRangeServer(t, 10000)
+	s.digest = "SHA-256=" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	dest, _, err := downloadWithRanges(t, s, 3)
+	assert.ErrorContains(t, err, "SHA-256 checksum mismatch")
+
+	_, statErr := os.Stat(dest)
+	assert.True(t, os.IsNotExist(statErr), "corrupt file must be removed")
+}
@@ -0,0 +1,167 @@
+package jira
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/atomicfile"
+)
+
+// fingerprintHeadSize is the number of leading bytes of a file hashed into its fingerprint.
+const fingerprintHeadSize = 1 << 20
+
+// ErrUploadSessionExpired denotes that the server no longer knows a chunked upload session,
+// eg: because it was abandoned for too long.
+var ErrUploadSessionExpired = fmt.Errorf("jira: upload session has expired")
+
+// FileFingerprint identifies the content of a file well enough to tell if an interrupted
+// upload of it can be resumed: its size, modification time and a hash of its first MB.
+type FileFingerprint struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Head    string    `json:"head"`
+}
+
+// Equal reports if both fingerprints are of the same content.
+func (f FileFingerprint) Equal(o FileFingerprint) bool {
+	return f.Size == o.Size && f.ModTime.Equal(o.ModTime) && f.Head == o.Head
+}
+
+// fingerprint computes the fingerprint of an open file.
+func fingerprint(file io.ReaderAt, info os.FileInfo) (FileFingerprint, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(file, 0, min(info.Size(), fingerprintHeadSize))); err != nil {
+		return FileFingerprint{}, err
+	}
+	return FileFingerprint{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Head:    hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// ChunkBitmap has bit i set once chunk i of an upload is stored on the server.
+type ChunkBitmap []byte
+
+// Has reports if chunk i is set.
+func (b ChunkBitmap) Has(i int) bool {
+	return i >= 0 && i/8 < len(b) && b[i/8]&(1<<(i%8)) != 0
+}
+
+// Set marks chunk i, growing the bitmap as needed.
+func (b *ChunkBitmap) Set(i int) {
+	for len(*b) <= i/8 {
+		*b = append(*b, 0)
+	}
+	(*b)[i/8] |= 1 << (i % 8)
+}
+
+// missingChunks returns the ranges that are not set in the bitmap.
+func missingChunks(ranges []chunkRange, done ChunkBitmap) []chunkRange {
+	out := make([]chunkRange, 0, len(ranges))
+	for _, r := range ranges {
+		if !done.Has(r.Index) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// SavedUpload is the progress of a chunked upload kept in an UploadSessionStore.
+type SavedUpload struct {
+	Issue       string          `json:"issue"`
+	Path        string          `json:"path"`
+	SessionID   string          `json:"sessionId"`
+	ChunkSize   int64           `json:"chunkSize"`
+	Fingerprint FileFingerprint `json:"fingerprint"`
+	Completed   ChunkBitmap     `json:"completed"`
+	Updated     time.Time       `json:"updated"`
+}
+
+// UploadSessionStore persists chunked upload sessions so that an interrupted
+// upload can be resumed by a later invocation.
+type UploadSessionStore interface {
+	// Load returns the saved upload of the file to the issue, or nil if there is none.
+	Load(issue, path string) (*SavedUpload, error)
+	Save(u *SavedUpload) error
+	Delete(issue, path string) error
+}
+
+// FileSessionStore is an UploadSessionStore that keeps every upload in a JSON file in Dir.
+type FileSessionStore struct {
+	Dir string
+}
+
+func (s FileSessionStore) file(issue, path string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(issue) + "\x00" + path))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// Load implements UploadSessionStore. A session file torn by a crash is read from its
+// backup instead.
+func (s FileSessionStore) Load(issue, path string) (*SavedUpload, error) {
+	var u SavedUpload
+	_, err := atomicfile.ReadFile(s.file(issue, path), func(b []byte) error {
+		u = SavedUpload{}
+		return json.Unmarshal(b, &u)
+	}, func(error) {})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Guard against hash collisions.
+	if !strings.EqualFold(u.Issue, issue) || u.Path != path {
+		return nil, nil
+	}
+	return &u, nil
+}
+
+// Save implements UploadSessionStore. The file is replaced atomically, see package
+// atomicfile, so that an interrupted write doesn't leave a corrupt session behind.
+func (s FileSessionStore) Save(u *SavedUpload) error {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.file(u.Issue, u.Path), b, 0o600)
+}
+
+// Delete implements UploadSessionStore. The backup of the session file is deleted too.
+func (s FileSessionStore) Delete(issue, path string) error {
+	file := s.file(issue, path)
+	for _, f := range []string{file, atomicfile.BackupPath(file)} {
+		if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear deletes all saved uploads and returns how many there were.
+func (s FileSessionStore) Clear() (int, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		for _, f := range []string{f, atomicfile.BackupPath(f)} {
+			if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return 0, err
+			}
+		}
+	}
+	return len(files), nil
+}
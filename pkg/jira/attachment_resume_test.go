@@ -0,0 +1,256 @@
+package jira
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fingerprintFile(t *testing.T, path string) FileFingerprint {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+
+	fp, err := fingerprint(f, info)
+	require.NoError(t, err)
+	return fp
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o600))
+
+	mtime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+
+	fp := fingerprintFile(t, path)
+	assert.Equal(t, int64(5), fp.Size)
+	assert.True(t, fp.ModTime.Equal(mtime))
+	assert.True(t, fp.Equal(fingerprintFile(t, path)))
+
+	// Same size and modification time, different content.
+	require.NoError(t, os.WriteFile(path, []byte("other"), 0o600))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	assert.False(t, fp.Equal(fingerprintFile(t, path)))
+
+	// Same content, touched.
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o600))
+	assert.False(t, fp.Equal(fingerprintFile(t, path)))
+}
+
+func TestChunkBitmap(t *testing.T) {
+	t.Parallel()
+
+	var b ChunkBitmap
+	assert.False(t, b.Has(0))
+	assert.False(t, b.Has(-1))
+
+	b.Set(0)
+	b.Set(9)
+	assert.True(t, b.Has(0))
+	assert.True(t, b.Has(9))
+	assert.False(t, b.Has(8))
+	assert.False(t, b.Has(100))
+	assert.Len(t, b, 2)
+}
+
+func TestMissingChunks(t *testing.T) {
+	t.Parallel()
+
+	ranges := chunkRanges(10, 2)
+
+	var done ChunkBitmap
+	assert.Equal(t, ranges, missingChunks(ranges, done))
+
+	done.Set(0)
+	done.Set(1)
+	done.Set(3)
+	assert.Equal(t, []chunkRange{{Index: 2, Start: 4, End: 6}, {Index: 4, Start: 8, End: 10}}, missingChunks(ranges, done))
+
+	done.Set(2)
+	done.Set(4)
+	assert.Empty(t, missingChunks(ranges, done))
+}
+
+func TestFileSessionStore(t *testing.T) {
+	t.Parallel()
+
+	store := FileSessionStore{Dir: filepath.Join(t.TempDir(), "sessions")}
+
+	u, err := store.Load("TEST-1", "/tmp/a.bin")
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+
+	saved := &SavedUpload{
+		Issue:       "TEST-1",
+		Path:        "/tmp/a.bin",
+		SessionID:   "session-1",
+		ChunkSize:   1024,
+		Fingerprint: FileFingerprint{Size: 5000, ModTime: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Head: "abc"},
+	}
+	saved.Completed.Set(1)
+	require.NoError(t, store.Save(saved))
+	// Saving again keeps the previous session file as a backup.
+	require.NoError(t, store.Save(saved))
+	require.NoError(t, store.Save(&SavedUpload{Issue: "TEST-1", Path: "/tmp/b.bin", SessionID: "session-2"}))
+	require.NoError(t, store.Save(&SavedUpload{Issue: "TEST-1", Path: "/tmp/b.bin", SessionID: "session-2"}))
+
+	u, err = store.Load("test-1", "/tmp/a.bin")
+	require.NoError(t, err)
+	require.NotNil(t, u)
+	assert.Equal(t, "session-1", u.SessionID)
+	assert.True(t, u.Fingerprint.Equal(saved.Fingerprint))
+	assert.True(t, u.Completed.Has(1))
+	assert.False(t, u.Completed.Has(0))
+
+	// Sessions are per issue.
+	u, err = store.Load("TEST-2", "/tmp/a.bin")
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+
+	require.NoError(t, store.Delete("TEST-1", "/tmp/a.bin"))
+	require.NoError(t, store.Delete("TEST-1", "/tmp/a.bin"))
+	u, err = store.Load("TEST-1", "/tmp/a.bin")
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+
+	n, err := store.Clear()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	u, err = store.Load("TEST-1", "/tmp/b.bin")
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+
+	// The backups are deleted along with the sessions.
+	entries, err := os.ReadDir(store.Dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestUploadAttachmentChunkedResumes(t *testing.T) {
+	t.Parallel()
+
+	media := newMediaServer()
+	// The first run dies on the third of five chunks.
+	media.abortAt = 2
+
+	server := httptest.NewServer(media)
+	defer server.Close()
+
+	path, data := writeRandomFile(t, 5*1024)
+	store := FileSessionStore{Dir: t.TempDir()}
+
+	var notices []string
+	opts := ChunkedUploadOptions{
+		ChunkSize: 1024,
+		Sessions:  store,
+		Notify:    func(msg string) { notices = append(notices, msg) },
+	}
+
+	client := newRetryTestClient(server.URL)
+	_, err := client.UploadAttachmentChunked("TEST-1", path, opts)
+	require.Error(t, err)
+	assert.Equal(t, []int{0, 1}, media.puts)
+	assert.False(t, media.attached)
+
+	media.puts = nil
+	attachments, err := client.UploadAttachmentChunked("TEST-1", path, opts)
+	require.NoError(t, err)
+	assert.Len(t, attachments, 1)
+
+	// Only the missing chunks are uploaded, to the same session.
+	assert.Equal(t, []int{2, 3, 4}, media.puts)
+	assert.Equal(t, 1, media.sessions)
+	assert.True(t, bytes.Equal(data, media.assembled()))
+	assert.True(t, media.attached)
+	assert.Equal(t, []string{"Resuming the upload of upload.bin, 3 of 5 chunk(s) left"}, notices)
+
+	// The session is gone once the upload is done.
+	abs, err := filepath.Abs(path)
+	require.NoError(t, err)
+	u, err := store.Load("TEST-1", abs)
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+}
+
+func TestUploadAttachmentChunkedDiscardsChangedFile(t *testing.T) {
+	t.Parallel()
+
+	media := newMediaServer()
+	media.abortAt = 2
+
+	server := httptest.NewServer(media)
+	defer server.Close()
+
+	path, _ := writeRandomFile(t, 5*1024)
+	var notices []string
+	opts := ChunkedUploadOptions{
+		ChunkSize: 1024,
+		Sessions:  FileSessionStore{Dir: t.TempDir()},
+		Notify:    func(msg string) { notices = append(notices, msg) },
+	}
+
+	client := newRetryTestClient(server.URL)
+	_, err := client.UploadAttachmentChunked("TEST-1", path, opts)
+	require.Error(t, err)
+
+	data := bytes.Repeat([]byte("x"), 5*1024)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	media.puts = nil
+	_, err = client.UploadAttachmentChunked("TEST-1", path, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, media.puts)
+	assert.Equal(t, 2, media.sessions)
+	assert.True(t, bytes.Equal(data, media.assembled()))
+	assert.Equal(t, []string{"Discarding the interrupted upload of upload.bin, the file or the chunk size changed since"}, notices)
+}
+
+func TestUploadAttachmentChunkedExpiredSession(t *testing.T) {
+	t.Parallel()
+
+	media := newMediaServer()
+	media.abortAt = 2
+
+	server := httptest.NewServer(media)
+	defer server.Close()
+
+	path, data := writeRandomFile(t, 5*1024)
+	var notices []string
+	opts := ChunkedUploadOptions{
+		ChunkSize: 1024,
+		Sessions:  FileSessionStore{Dir: t.TempDir()},
+		Notify:    func(msg string) { notices = append(notices, msg) },
+	}
+
+	client := newRetryTestClient(server.URL)
+	_, err := client.UploadAttachmentChunked("TEST-1", path, opts)
+	require.Error(t, err)
+
+	media.expired["session-1"] = true
+	media.puts = nil
+	_, err = client.UploadAttachmentChunked("TEST-1", path, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, media.puts)
+	assert.Equal(t, 2, media.sessions)
+	assert.True(t, bytes.Equal(data, media.assembled()))
+	assert.Equal(t, []string{
+		"Resuming the upload of upload.bin, 3 of 5 chunk(s) left",
+		"The upload session of upload.bin has expired on the server, starting over",
+	}, notices)
+}
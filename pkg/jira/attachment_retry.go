@@ -0,0 +1,86 @@
+package jira
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// attachmentMaxBackoff caps the backoff between two retries of WithRetries.
+	attachmentMaxBackoff = 30 * time.Second
+
+	// attachmentMaxRetryAfter is the longest Retry-After honored by WithRetries. The
+	// response is returned as is if the server asks to wait longer.
+	attachmentMaxRetryAfter = 5 * time.Minute
+)
+
+// WithRetries is a functional opt to retry attachment requests rejected with 429 Too Many
+// Requests, or failed with a 5xx for downloads and deletions, up to n more times. The wait
+// between two attempts is the Retry-After of the response if any, else an exponential
+// backoff with jitter. Uploads aren't retried on a 5xx as the server may have created the
+// attachment before failing. They aren't retried by default.
+func WithRetries(n int) ClientFunc {
+	return func(c *Client) {
+		c.retries = max(n, 0)
+	}
+}
+
+// retryableStatus reports if the response status of an attachment operation is retried
+// with WithRetries.
+func retryableStatus(op string, code int) bool {
+	if code == http.StatusTooManyRequests {
+		return true
+	}
+	return op != AttachmentOpUpload && code >= 500 && code <= 599
+}
+
+// retryDelay returns how long to wait before the nth retry of a response. It is false if
+// the server asks to wait longer than attachmentMaxRetryAfter.
+func (c *Client) retryDelay(res *http.Response, n int) (time.Duration, bool) {
+	if d, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+		return d, d <= attachmentMaxRetryAfter
+	}
+	return jitter(backoff(c.retryBackoff, n)), true
+}
+
+// backoff returns base doubled for every retry after the first one, capped at
+// attachmentMaxBackoff.
+func backoff(base time.Duration, n int) time.Duration {
+	d := base
+	for i := 1; i < n && d < attachmentMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, attachmentMaxBackoff)
+}
+
+// jitter returns a random duration between half of d and d, so that clients rate limited
+// at the same time don't retry at the same time.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2+1) //nolint:gosec // The jitter doesn't need a secure source.
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a number of seconds or
+// an HTTP date, into the duration to wait from now.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	at, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	return max(at.Sub(now), 0), true
+}
@@ -0,0 +1,231 @@
+package jira
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingServer responds to the first failures requests with status and the Retry-After
+// header if set, and with ok to the next ones. It records when each request was received.
+type failingServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	calls []time.Time
+}
+
+func newFailingServer(t *testing.T, failures, status int, retryAfter string, ok http.HandlerFunc) *failingServer {
+	t.Helper()
+
+	s := &failingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.calls = append(s.calls, time.Now())
+		n := len(s.calls)
+		s.mu.Unlock()
+
+		if n <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		ok(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *failingServer) requests() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.calls...)
+}
+
+func newRetriesTestClient(server string, retries int) *Client {
+	client := newRetryTestClient(server)
+	client.retries = retries
+	return client
+}
+
+func uploadOK(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`[{"id": "10001", "filename": "test.txt"}]`))
+}
+
+func TestDownloadAttachmentRetries(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		server := newFailingServer(t, 2, status, "", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("content"))
+		})
+
+		dest := filepath.Join(t.TempDir(), "file.txt")
+		err := newRetriesTestClient(server.URL, 2).DownloadAttachment(server.URL+"/file.txt", dest)
+		require.NoError(t, err, status)
+
+		got, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(got))
+		assert.Len(t, server.requests(), 3, status)
+	}
+}
+
+func TestDownloadAttachmentRetriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	server := newFailingServer(t, 5, http.StatusBadGateway, "", func(w http.ResponseWriter, _ *http.Request) {})
+
+	err := newRetriesTestClient(server.URL, 2).DownloadAttachment(server.URL+"/file.txt", filepath.Join(t.TempDir(), "file.txt"))
+
+	var attErr *AttachmentError
+	require.True(t, errors.As(err, &attErr), err)
+	assert.Equal(t, http.StatusBadGateway, attErr.StatusCode)
+	assert.Equal(t, 3, attErr.Attempts)
+	assert.Len(t, server.requests(), 3)
+}
+
+func TestAttachmentRequestsNotRetriedByDefault(t *testing.T) {
+	t.Parallel()
+
+	server := newFailingServer(t, 1, http.StatusTooManyRequests, "0", func(w http.ResponseWriter, _ *http.Request) {})
+
+	err := newRetryTestClient(server.URL).DownloadAttachment(server.URL+"/file.txt", filepath.Join(t.TempDir(), "file.txt"))
+	assert.Error(t, err)
+	assert.Len(t, server.requests(), 1)
+}
+
+func TestUploadAttachmentRetriesRateLimit(t *testing.T) {
+	t.Parallel()
+
+	server := newFailingServer(t, 2, http.StatusTooManyRequests, "", func(w http.ResponseWriter, r *http.Request) {
+		// The body must be sent in full on every attempt.
+		file, _, err := r.FormFile("file")
+		assert.NoError(t, err)
+		_ = file.Close()
+
+		uploadOK(w, r)
+	})
+
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("test content"), 0o600))
+
+	attachments, err := newRetriesTestClient(server.URL, 3).UploadAttachment("TEST-1", testFile)
+	require.NoError(t, err)
+	assert.Len(t, attachments, 1)
+	assert.Len(t, server.requests(), 3)
+}
+
+func TestUploadAttachmentNotRetriedOnServerError(t *testing.T) {
+	t.Parallel()
+
+	server := newFailingServer(t, 1, http.StatusServiceUnavailable, "", uploadOK)
+
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("test content"), 0o600))
+
+	_, err := newRetriesTestClient(server.URL, 3).UploadAttachment("TEST-1", testFile)
+	assert.Error(t, err)
+	assert.Len(t, server.requests(), 1, "the server may have created the attachment")
+}
+
+func TestDeleteAttachmentRetries(t *testing.T) {
+	t.Parallel()
+
+	server := newFailingServer(t, 1, http.StatusBadGateway, "", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	require.NoError(t, newRetriesTestClient(server.URL, 1).DeleteAttachment("10001"))
+	assert.Len(t, server.requests(), 2)
+}
+
+func TestAttachmentRetryHonorsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	server := newFailingServer(t, 1, http.StatusTooManyRequests, "1", uploadOK)
+
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("test content"), 0o600))
+
+	// The backoff alone would retry after a millisecond.
+	_, err := newRetriesTestClient(server.URL, 1).UploadAttachment("TEST-1", testFile)
+	require.NoError(t, err)
+
+	calls := server.requests()
+	require.Len(t, calls, 2)
+	assert.GreaterOrEqual(t, calls[1].Sub(calls[0]), time.Second)
+}
+
+func TestAttachmentRetryGivesUpOnLongRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	server := newFailingServer(t, 1, http.StatusTooManyRequests, "3600", uploadOK)
+
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("test content"), 0o600))
+
+	_, err := newRetriesTestClient(server.URL, 3).UploadAttachment("TEST-1", testFile)
+	assert.Error(t, err)
+	assert.Len(t, server.requests(), 1)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "", ok: false},
+		{value: "0", want: 0, ok: true},
+		{value: " 120 ", want: 2 * time.Minute, ok: true},
+		{value: "-1", ok: false},
+		{value: "Fri, 01 Mar 2024 10:00:30 GMT", want: 30 * time.Second, ok: true},
+		{value: "Fri, 01 Mar 2024 09:59:00 GMT", want: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+
+	for _, tc := range cases {
+		got, ok := parseRetryAfter(tc.value, now)
+		assert.Equal(t, tc.ok, ok, tc.value)
+		assert.Equal(t, tc.want, got, tc.value)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 500*time.Millisecond, backoff(500*time.Millisecond, 1))
+	assert.Equal(t, time.Second, backoff(500*time.Millisecond, 2))
+	assert.Equal(t, 4*time.Second, backoff(500*time.Millisecond, 4))
+	assert.Equal(t, attachmentMaxBackoff, backoff(500*time.Millisecond, 100))
+
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, NewClient(Config{}).retries)
+	assert.Equal(t, 3, NewClient(Config{}, WithRetries(3)).retries)
+	assert.Equal(t, 0, NewClient(Config{}, WithRetries(-1)).retries)
+}
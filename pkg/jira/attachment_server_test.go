@@ -0,0 +1,148 @@
+package jira_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+	"github.com/ankitpokhrel/jira-cli/pkg/jira/jiratest"
+)
+
+func TestDownloadAttachment(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithCredentials("test", "token"))
+	defer server.Close()
+
+	a := server.AddAttachment("TEST-1", "test.txt", []byte("This is test attachment content"))
+
+	destPath := filepath.Join(t.TempDir(), "downloaded.txt")
+	require.NoError(t, server.Client().DownloadAttachment(a.Content, destPath))
+
+	content, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, "This is test attachment content", string(content))
+
+	// The credentials are checked.
+	client := jira.NewClient(jira.Config{Server: server.URL, Login: "test", APIToken: "wrong"})
+	err = client.DownloadAttachment(a.Content, filepath.Join(t.TempDir(), "denied.txt"))
+	assert.Error(t, err)
+}
+
+func TestDownloadAttachmentError(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	a := server.AddAttachment("TEST-1", "test.txt", []byte("content"))
+	server.RemoveAttachment(a.ID)
+
+	destPath := filepath.Join(t.TempDir(), "downloaded.txt")
+	err := server.Client().DownloadAttachment(a.Content, destPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to download attachment")
+
+	_, statErr := os.Stat(destPath)
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestUploadAttachment(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithCredentials("test", "token"), jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("test content"), 0o644))
+
+	attachments, err := server.Client().UploadAttachment("TEST-1", testFile)
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "test.txt", attachments[0].Filename)
+	assert.Equal(t, "test", attachments[0].Author.DisplayName)
+	assert.Equal(t, int64(12), attachments[0].Size)
+
+	content, ok := server.Content(attachments[0].ID)
+	assert.True(t, ok)
+	assert.Equal(t, "test content", string(content))
+
+	requests := server.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, http.MethodPost, requests[0].Method)
+	assert.Equal(t, "/rest/api/3/issue/TEST-1/attachments", requests[0].Path)
+}
+
+func TestUploadAttachmentV2(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithCredentials("test", "token"), jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("test content"), 0o644))
+
+	attachments, err := server.Client().UploadAttachmentV2("TEST-1", testFile)
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, attachments[0].ID, server.Attachments("TEST-1")[0].ID)
+	assert.Equal(t, "/rest/api/2/issue/TEST-1/attachments", server.Requests()[0].Path)
+}
+
+func TestUploadAttachmentFileNotFound(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer(jiratest.WithIssues("TEST-1"))
+	defer server.Close()
+
+	_, err := server.Client().UploadAttachment("TEST-1", "/nonexistent/file.txt")
+	assert.Error(t, err)
+	assert.True(t, os.IsNotExist(err))
+	assert.Empty(t, server.Requests(), "nothing is sent to the server")
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	a := server.AddAttachment("TEST-1", "test.txt", []byte("content"))
+
+	require.NoError(t, server.Client().DeleteAttachment(a.ID))
+	assert.Empty(t, server.Attachments("TEST-1"))
+
+	requests := server.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, http.MethodDelete, requests[0].Method)
+	assert.Equal(t, "/rest/api/3/attachment/"+a.ID, requests[0].Path)
+}
+
+func TestDeleteAttachmentV2(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	a := server.AddAttachment("TEST-1", "test.txt", []byte("content"))
+
+	require.NoError(t, server.Client().DeleteAttachmentV2(a.ID))
+	assert.Empty(t, server.Attachments("TEST-1"))
+	assert.Equal(t, "/rest/api/2/attachment/"+a.ID, server.Requests()[0].Path)
+}
+
+func TestDeleteAttachmentError(t *testing.T) {
+	t.Parallel()
+
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	err := server.Client().DeleteAttachment("10001")
+	assert.Error(t, err)
+	assert.IsType(t, &jira.ErrUnexpectedResponse{}, err)
+}
@@ -0,0 +1,209 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of the stall detection of downloads.
+const (
+	DefaultStallTimeout = 30 * time.Second
+	DefaultStallRetries = 3
+)
+
+// errTransferStalled is returned by a stallReader once no bytes were received for its timeout.
+var errTransferStalled = errors.New("jira: no bytes received within the stall timeout")
+
+// WithStallTimeout aborts a download attempt if no bytes are received for the timeout, and
+// then retries it up to the given number of times. An attempt is resumed from where it
+// stalled if the server supports range requests, it is restarted from scratch otherwise.
+// A timeout of 0 or less disables the stall detection. Downloads use DefaultStallTimeout
+// and DefaultStallRetries if not set.
+func WithStallTimeout(timeout time.Duration, retries int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.stallTimeout = timeout
+		o.stallRetries = retries
+	}
+}
+
+// stallReader is a watchdog over a reader. The timer runs while a Read is waiting for
+// bytes, and calls abort if it fires. Abort must unblock the pending Read, eg: by closing
+// the body of a response, which then returns errTransferStalled.
+type stallReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	stalled atomic.Bool
+}
+
+func newStallReader(r io.Reader, timeout time.Duration, abort func()) *stallReader {
+	s := &stallReader{r: r, timeout: timeout}
+	s.timer = time.AfterFunc(timeout, func() {
+		s.stalled.Store(true)
+		abort()
+	})
+	s.timer.Stop()
+	return s
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	if s.stalled.Load() {
+		return 0, errTransferStalled
+	}
+
+	s.timer.Reset(s.timeout)
+	n, err := s.r.Read(p)
+	s.timer.Stop()
+
+	if s.stalled.Load() {
+		return n, errTransferStalled
+	}
+	return n, err
+}
+
+// Stop stops the watchdog.
+func (s *stallReader) Stop() {
+	s.timer.Stop()
+}
+
+// resumingReader reads the body of an attachment download. If the transfer stalls, the
+// attachment is requested again from the current offset if ranges are supported, and
+// from scratch otherwise, skipping the bytes that were already read.
+type resumingReader struct {
+	c   *Client
+	ctx context.Context
+	url string
+	// start is the offset of the first byte of the body in the attachment, end the
+	// offset right after the last one, or 0 if the body runs to the end.
+	start, end int64
+	// validator is the ETag or Last-Modified of the first response, the attachment
+	// must still have it when the download is resumed or restarted.
+	validator string
+	ranged    bool
+
+	timeout time.Duration
+	retries int
+
+	read int64
+	body io.ReadCloser
+	r    *stallReader
+}
+
+func (c *Client) newResumingReader(url string, res *http.Response, start, end int64, ranged bool, o downloadOptions) *resumingReader {
+	r := &resumingReader{
+		c:         c,
+		ctx:       o.ctx,
+		url:       url,
+		start:     start,
+		end:       end,
+		validator: firstNonEmpty(res.Header.Get("ETag"), res.Header.Get("Last-Modified")),
+		ranged:    ranged,
+		timeout:   o.stallTimeout,
+		retries:   o.stallRetries,
+	}
+	r.watch(res.Body)
+	return r
+}
+
+// acceptsRanges reports if a response to a plain request shows that a download can be
+// resumed with a range request.
+func acceptsRanges(res *http.Response) bool {
+	return res.Header.Get("Accept-Ranges") == "bytes" &&
+		firstNonEmpty(res.Header.Get("ETag"), res.Header.Get("Last-Modified")) != ""
+}
+
+func (r *resumingReader) watch(body io.ReadCloser) {
+	r.body = body
+	r.r = newStallReader(body, r.timeout, func() { _ = body.Close() })
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if !errors.Is(err, errTransferStalled) {
+		return n, err
+	}
+
+	for {
+		if r.retries <= 0 {
+			return n, &ErrTimeout{Phase: TimeoutPhaseTransfer, Host: hostOf(r.url), Err: err}
+		}
+		r.retries--
+
+		r.c.logf(LogDetail, "Transfer of %s stalled after %d bytes, retrying (%d retries left)", r.url, r.read, r.retries)
+		err = r.reopen()
+		if !errors.Is(err, errTransferStalled) {
+			break
+		}
+	}
+	if err != nil {
+		return n, err
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return r.Read(p)
+}
+
+// reopen requests the rest of the attachment and replaces the body being read.
+func (r *resumingReader) reopen() error {
+	r.r.Stop()
+	_ = r.body.Close()
+
+	pos := r.start + r.read
+	var byteRange string
+	if r.ranged && pos > 0 {
+		byteRange = fmt.Sprintf("bytes=%d-", pos)
+		if r.end > 0 {
+			byteRange += fmt.Sprint(r.end - 1)
+		}
+	}
+
+	res, err := r.c.getRange(r.ctx, r.url, byteRange, r.validator)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusPartialContent && byteRange != "":
+		start, _, _, ok := parseContentRange(res.Header.Get("Content-Range"))
+		if !ok || start != pos {
+			_ = res.Body.Close()
+			return fmt.Errorf("failed to resume attachment download: server sent an unexpected range")
+		}
+		r.watch(res.Body)
+		return nil
+	case res.StatusCode == http.StatusOK:
+		if r.start > 0 {
+			// A single range of a parallel download can't be restarted from scratch.
+			_ = res.Body.Close()
+			return errRangesUnsupported
+		}
+		if r.validator != "" && firstNonEmpty(res.Header.Get("ETag"), res.Header.Get("Last-Modified")) != r.validator {
+			_ = res.Body.Close()
+			return fmt.Errorf("failed to resume attachment download: the attachment changed on the server")
+		}
+		r.watch(res.Body)
+		if _, err := io.CopyN(io.Discard, r.r, r.read); err != nil {
+			return err
+		}
+		return nil
+	default:
+		defer func() { _ = res.Body.Close() }()
+		if ClassifyAttachmentStatus(res.StatusCode) == StatusTransient {
+			return newAttachmentError(AttachmentOpDownload, res, attachmentMaxAttempts)
+		}
+		return fmt.Errorf("failed to resume attachment download: %s%w", res.Status, formatUnexpectedResponse(res))
+	}
+}
+
+// Close stops the watchdog and closes the body being read.
+func (r *resumingReader) Close() error {
+	r.r.Stop()
+	return r.body.Close()
+}
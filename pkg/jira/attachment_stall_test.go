@@ -0,0 +1,237 @@
+package jira
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStallReader(t *testing.T) {
+	t.Parallel()
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("ab"))
+		// A pause shorter than the timeout is not a stall.
+		time.Sleep(20 * time.Millisecond)
+		_, _ = pw.Write([]byte("cd"))
+		// Then the reader pauses for good.
+	}()
+
+	r := newStallReader(pr, 200*time.Millisecond, func() { _ = pr.CloseWithError(errors.New("aborted")) })
+	defer r.Stop()
+
+	start := time.Now()
+	got, err := io.ReadAll(r)
+	assert.ErrorIs(t, err, errTransferStalled)
+	assert.Equal(t, "abcd", string(got))
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestStallReaderSlowConsumer(t *testing.T) {
+	t.Parallel()
+
+	// Time spent outside of Read, eg: writing to a slow disk, is not a stall.
+	r := newStallReader(strings.NewReader("abc"), 20*time.Millisecond, func() {})
+	defer r.Stop()
+
+	buf := make([]byte, 1)
+	var got []byte
+	for {
+		n, err := r.Read(buf)
+		got = append(got, buf[:n]...)
+		if err != nil {
+			assert.ErrorIs(t, err, io.EOF)
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Equal(t, "abc", string(got))
+}
+
+// stallingServer serves content, stalling after half of it on the first request. Range
+// requests are honoured if ranges is set.
+func stallingServer(t *testing.T, content []byte, ranges bool, stalls int) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		requests []string
+		release  = make(chan struct{})
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Header.Get("Range"))
+		attempt := len(requests)
+		mu.Unlock()
+
+		w.Header().Set("ETag", `"v1"`)
+		if ranges {
+			w.Header().Set("Accept-Ranges", "bytes")
+		}
+
+		body := content
+		if rng := r.Header.Get("Range"); ranges && rng != "" {
+			var start int
+			_, _ = fmt.Sscanf(rng, "bytes=%d-", &start)
+			body = content[start:]
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+			w.WriteHeader(http.StatusOK)
+		}
+
+		if attempt > stalls {
+			_, _ = w.Write(body)
+			return
+		}
+		_, _ = w.Write(body[:len(body)/2])
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestDownloadAttachmentResumesStalledTransfer(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	server, requests := stallingServer(t, content, true, 1)
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	dest := filepath.Join(t.TempDir(), "a.bin")
+
+	res, err := client.DownloadAttachmentWithResult(
+		server.URL+"/attachment/content/1", dest, WithStallTimeout(100*time.Millisecond, 2),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), res.Bytes)
+	assert.True(t, res.TotalKnown)
+
+	got, err := os.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// The retry resumes from where the first attempt stalled.
+	assert.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", len(content)/2)}, requests())
+}
+
+func TestDownloadAttachmentRestartsStalledTransfer(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("abcdefghij"), 1000)
+	server, requests := stallingServer(t, content, false, 2)
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	dest := filepath.Join(t.TempDir(), "a.bin")
+
+	res, err := client.DownloadAttachmentWithResult(
+		server.URL+"/attachment/content/1", dest, WithStallTimeout(100*time.Millisecond, 2),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), res.Bytes)
+
+	got, err := os.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Without range support, each retry downloads the attachment from scratch.
+	assert.Equal(t, []string{"", "", ""}, requests())
+}
+
+func TestDownloadAttachmentStallRetriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("x"), 4096)
+	server, requests := stallingServer(t, content, true, 10)
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	dest := filepath.Join(t.TempDir(), "a.bin")
+
+	_, err := client.DownloadAttachmentWithResult(
+		server.URL+"/attachment/content/1", dest, WithStallTimeout(50*time.Millisecond, 1),
+	)
+
+	var tErr *ErrTimeout
+	assert.True(t, errors.As(err, &tErr), "got %v", err)
+	assert.Equal(t, TimeoutPhaseTransfer, tErr.Phase)
+	assert.Contains(t, err.Error(), "stalled")
+	assert.Len(t, requests(), 2)
+
+	_, err = os.Stat(dest)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadRangesResumesStalledRange(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var (
+		mu      sync.Mutex
+		stalled bool
+	)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var start, end int
+		_, _ = fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+		body := content[start : end+1]
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		w.WriteHeader(http.StatusPartialContent)
+
+		mu.Lock()
+		stall := start == 0 && end > 0 && !stalled
+		stalled = stalled || stall
+		mu.Unlock()
+		if !stall {
+			_, _ = w.Write(body)
+			return
+		}
+		_, _ = w.Write(body[:10])
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+	dest := filepath.Join(t.TempDir(), "a.bin")
+
+	res, err := client.DownloadAttachmentWithResult(
+		server.URL+"/attachment/content/1", dest, WithParallelRanges(4), WithStallTimeout(100*time.Millisecond, 1),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), res.Bytes)
+
+	got, err := os.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, content, got)
+}
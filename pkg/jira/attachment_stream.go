@@ -0,0 +1,103 @@
+package jira
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+)
+
+// fileUpload streams a file as the only part of a multipart form, so that the file is never
+// held in memory whatever its size. Each body starts over from the beginning of the file, so
+// that a request can be retried. Any seekable content is streamed the same way.
+type fileUpload struct {
+	file        io.ReadSeeker
+	field, name string
+	boundary    string
+	contentType string
+	// size is the length of the whole form.
+	size int64
+	// fileSize is the size of the file alone.
+	fileSize int64
+	// progress, if set, receives the bytes of the file sent by each body.
+	progress ProgressFunc
+}
+
+func newFileUpload(file io.ReadSeeker, field, name string) (*fileUpload, error) {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// The form is the file content framed by the part header and the closing boundary, so
+	// its length is the one of an empty form plus the size of the file.
+	var frame bytes.Buffer
+	w := multipart.NewWriter(&frame)
+	if _, err := createFilePart(w, field, name); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return &fileUpload{
+		file:        file,
+		field:       field,
+		name:        name,
+		boundary:    w.Boundary(),
+		contentType: w.FormDataContentType(),
+		size:        int64(frame.Len()) + size,
+		fileSize:    size,
+	}, nil
+}
+
+// body returns a reader of the form. The form is written by a goroutine as it is read.
+func (u *fileUpload) body() (*uploadBody, error) {
+	if _, err := u.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		w := multipart.NewWriter(pw)
+		err := w.SetBoundary(u.boundary)
+		if err == nil {
+			var part io.Writer
+			if part, err = createFilePart(w, u.field, u.name); err == nil {
+				var file io.Reader = u.file
+				if u.progress != nil {
+					file = NewProgressReader(file, u.fileSize, u.progress)
+				}
+				_, err = io.Copy(part, file)
+			}
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	return &uploadBody{PipeReader: pr, size: u.size, done: done}, nil
+}
+
+// uploadBody is the read end of a streamed form.
+type uploadBody struct {
+	*io.PipeReader
+	size int64
+	done chan struct{}
+}
+
+// Size returns the length of the form, sent as the Content-Length of the request.
+func (b *uploadBody) Size() int64 {
+	return b.size
+}
+
+// Close stops the writing goroutine and waits for it to return, so that the file isn't
+// read anymore once the body is closed.
+func (b *uploadBody) Close() error {
+	err := b.PipeReader.Close()
+	<-b.done
+	return err
+}
@@ -0,0 +1,182 @@
+package jira
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeArchive writes size random bytes to a file and returns its path and checksum.
+func writeArchive(t *testing.T, size int64) (string, [sha256.Size]byte) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), io.LimitReader(rand.New(rand.NewSource(1)), size)) //nolint:gosec
+	require.NoError(t, err)
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return path, sum
+}
+
+// streamingServer reads the uploaded part as a stream, without buffering it, and records its
+// checksum and the length of the request.
+func streamingServer(t *testing.T, sum *[sha256.Size]byte, contentLength *int64, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		atomic.StoreInt64(contentLength, r.ContentLength)
+
+		mr, err := r.MultipartReader()
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		part, err := mr.NextPart()
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, "file", part.FormName())
+		assert.Equal(t, "archive.tar.gz", part.FileName())
+
+		h := sha256.New()
+		_, err = io.Copy(h, part)
+		assert.NoError(t, err)
+		copy(sum[:], h.Sum(nil))
+
+		_, err = mr.NextPart()
+		assert.ErrorIs(t, err, io.EOF)
+
+		// The first attempt is retried, the body must be streamed again from the start.
+		if n == 1 {
+			w.WriteHeader(http.StatusLocked)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id": "10001", "filename": "archive.tar.gz"}]`))
+	}))
+}
+
+func TestUploadAttachmentStreamsLargeFile(t *testing.T) {
+	const size = 32 << 20
+
+	path, want := writeArchive(t, size)
+
+	var (
+		got           [sha256.Size]byte
+		contentLength int64
+		calls         int32
+	)
+	server := streamingServer(t, &got, &contentLength, &calls)
+	defer server.Close()
+
+	client := newRetryTestClient(server.URL)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	attachments, err := client.UploadAttachment("TEST-1", path)
+	require.NoError(t, err)
+
+	runtime.ReadMemStats(&after)
+
+	require.Len(t, attachments, 1)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, want, got, "the server receives the whole file")
+	assert.Greater(t, atomic.LoadInt64(&contentLength), int64(size), "the exact length of the form is sent")
+
+	// Both attempts together allocate a fraction of the file, buffering it would allocate
+	// more than its size.
+	allocated := after.TotalAlloc - before.TotalAlloc
+	assert.Less(t, allocated, uint64(size/4), "allocated %d bytes to upload %d", allocated, size)
+}
+
+func TestFileUploadSize(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello world"), 0o600))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	upload, err := newFileUpload(f, "file", `naïve "notes".txt`)
+	require.NoError(t, err)
+
+	for range 2 {
+		body, err := upload.body()
+		require.NoError(t, err)
+		form, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+
+		assert.EqualValues(t, len(form), upload.size)
+		assert.Contains(t, string(form), "hello world")
+		assert.Contains(t, string(form), "--"+upload.boundary+"--")
+	}
+}
+
+func TestFileUploadCloseStopsWriter(t *testing.T) {
+	t.Parallel()
+
+	path, _ := writeArchive(t, 1<<20)
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	upload, err := newFileUpload(f, "file", "archive.tar.gz")
+	require.NoError(t, err)
+
+	body, err := upload.body()
+	require.NoError(t, err)
+	_, err = io.ReadFull(body, make([]byte, 512))
+	require.NoError(t, err)
+
+	// Closing before the end returns once the writer is done with the file.
+	require.NoError(t, body.Close())
+	_, err = io.ReadAll(body)
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+func TestUploadAttachmentFromReaderRetries(t *testing.T) {
+	t.Parallel()
+
+	path, want := writeArchive(t, 256<<10)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var (
+		got           [sha256.Size]byte
+		contentLength int64
+		calls         int32
+	)
+	server := streamingServer(t, &got, &contentLength, &calls)
+	defer server.Close()
+
+	client := newRetryTestClient(server.URL)
+
+	// A reader that can't be seeked is buffered to be sent again on retry.
+	attachments, err := client.UploadAttachmentFromReader("TEST-1", "archive.tar.gz", io.MultiReader(bytes.NewReader(content)))
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, want, got)
+	assert.Greater(t, atomic.LoadInt64(&contentLength), int64(len(content)))
+}
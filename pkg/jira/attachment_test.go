@@ -1,7 +1,9 @@
 package jira
 
 import (
-	"io"
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -11,99 +13,55 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestDownloadAttachment(t *testing.T) {
+func TestDecodePartialAttachments(t *testing.T) {
 	t.Parallel()
 
-	testContent := "This is test attachment content"
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/attachments/test.txt", r.URL.Path)
-		assert.Equal(t, "Basic dGVzdDp0b2tlbg==", r.Header.Get("Authorization"))
-
-		w.Header().Set("Content-Type", "text/plain")
-		w.WriteHeader(200)
-		_, _ = w.Write([]byte(testContent))
-	}))
-	defer server.Close()
-
-	client := NewClient(Config{
-		Server:   server.URL,
-		Login:    "test",
-		APIToken: "token",
-	}, WithTimeout(3*time.Second))
-
-	// Create temp file
-	tmpDir := t.TempDir()
-	destPath := filepath.Join(tmpDir, "downloaded.txt")
-
-	err := client.DownloadAttachment(server.URL+"/attachments/test.txt", destPath)
-	assert.NoError(t, err)
-
-	// Verify file was created and has correct content
-	content, err := os.ReadFile(destPath)
-	assert.NoError(t, err)
-	assert.Equal(t, testContent, string(content))
+	// Archived issues on Data Center may return attachments without content URL or author.
+	payload := `{"key": "TEST-1", "fields": {"attachment": [
+		{"id": "1", "filename": "full.txt", "author": {"displayName": "Jane"}, "size": 10, "content": "https://example.com/1"},
+		{"id": "2", "filename": "archived.txt", "size": 20},
+		{"id": "3", "filename": "restricted.txt", "author": null, "content": ""}
+	]}}`
+
+	var iss Issue
+	assert.NoError(t, json.Unmarshal([]byte(payload), &iss))
+
+	attachments := iss.Fields.Attachments
+	assert.Len(t, attachments, 3)
+	assert.True(t, attachments[0].Available())
+	assert.Equal(t, "Jane", attachments[0].Author.DisplayName)
+	for _, a := range attachments[1:] {
+		assert.False(t, a.Available(), a.Filename)
+		assert.Equal(t, User{}, a.Author, a.Filename)
+	}
 }
 
-func TestDownloadAttachmentError(t *testing.T) {
+func TestDownloadAttachmentUnavailable(t *testing.T) {
 	t.Parallel()
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(404)
-	}))
-	defer server.Close()
+	client := NewClient(Config{Server: "https://example.com"}, WithTimeout(time.Second))
+	destPath := filepath.Join(t.TempDir(), "archived.txt")
 
-	client := NewClient(Config{
-		Server:   server.URL,
-		Login:    "test",
-		APIToken: "token",
-	}, WithTimeout(3*time.Second))
-
-	tmpDir := t.TempDir()
-	destPath := filepath.Join(tmpDir, "downloaded.txt")
+	_, err := client.DownloadAttachmentWithResult("", destPath)
+	assert.ErrorIs(t, err, ErrAttachmentUnavailable)
 
-	err := client.DownloadAttachment(server.URL+"/attachments/test.txt", destPath)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to download attachment")
+	_, statErr := os.Stat(destPath)
+	assert.True(t, os.IsNotExist(statErr))
 }
 
-func TestUploadAttachment(t *testing.T) {
+func TestDownloadAttachmentWithResult(t *testing.T) {
 	t.Parallel()
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/rest/api/3/issue/TEST-1/attachments", r.URL.Path)
-		assert.Equal(t, "POST", r.Method)
-		assert.Contains(t, r.Header.Get("Content-Type"), "multipart/form-data")
-		assert.Equal(t, "no-check", r.Header.Get("X-Atlassian-Token"))
-
-		// Parse multipart form
-		err := r.ParseMultipartForm(10 << 20)
-		assert.NoError(t, err)
-
-		file, header, err := r.FormFile("file")
-		assert.NoError(t, err)
-		assert.Equal(t, "test.txt", header.Filename)
-
-		content, err := io.ReadAll(file)
-		assert.NoError(t, err)
-		assert.Equal(t, "test content", string(content))
+	testContent := strings.Repeat("chunk of attachment content\n", 512)
 
-		w.Header().Set("Content-Type", "application/json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(testContent)))
 		w.WriteHeader(200)
-		_, _ = w.Write([]byte(`[{
-			"id": "10001",
-			"filename": "test.txt",
-			"author": {
-				"displayName": "Test User",
-				"accountId": "123"
-			},
-			"created": "2020-12-03T14:05:20.974+0100",
-			"size": 12,
-			"mimeType": "text/plain",
-			"content": "http://example.com/attachment/10001"
-		}]`))
+		_, _ = w.Write([]byte(testContent))
 	}))
 	defer server.Close()
 
@@ -113,90 +71,89 @@ func TestUploadAttachment(t *testing.T) {
 		APIToken: "token",
 	}, WithTimeout(3*time.Second))
 
-	// Create temp file
-	tmpDir := t.TempDir()
-	testFile := filepath.Join(tmpDir, "test.txt")
-	err := os.WriteFile(testFile, []byte("test content"), 0o644)
-	assert.NoError(t, err)
+	destPath := filepath.Join(t.TempDir(), "downloaded.txt")
 
-	attachments, err := client.UploadAttachment("TEST-1", testFile)
+	res, err := client.DownloadAttachmentWithResult(server.URL+"/attachments/test.txt", destPath)
 	assert.NoError(t, err)
-	assert.Len(t, attachments, 1)
-	assert.Equal(t, "10001", attachments[0].ID)
-	assert.Equal(t, "test.txt", attachments[0].Filename)
-	assert.Equal(t, "Test User", attachments[0].Author.DisplayName)
-	assert.Equal(t, int64(12), attachments[0].Size)
+	assert.True(t, res.TotalKnown)
+	assert.Equal(t, int64(len(testContent)), res.Total)
+	assert.Equal(t, int64(len(testContent)), res.Bytes)
 }
 
-func TestUploadAttachmentV2(t *testing.T) {
+func TestDownloadAttachmentTo(t *testing.T) {
 	t.Parallel()
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/rest/api/2/issue/TEST-1/attachments", r.URL.Path)
-		assert.Equal(t, "POST", r.Method)
+	testContent := strings.Repeat("chunk of attachment content\n", 512)
 
-		w.Header().Set("Content-Type", "application/json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/attachments/short.txt" {
+			// Advertise more than is sent, the connection is closed early.
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(testContent)+10))
+		}
 		w.WriteHeader(200)
-		_, _ = w.Write([]byte(`[{
-			"id": "10001",
-			"filename": "test.txt",
-			"author": {
-				"displayName": "Test User",
-				"name": "testuser"
-			},
-			"created": "2020-12-03T14:05:20.974+0100",
-			"size": 12,
-			"mimeType": "text/plain",
-			"content": "http://example.com/attachment/10001"
-		}]`))
+		_, _ = w.Write([]byte(testContent))
 	}))
 	defer server.Close()
 
-	client := NewClient(Config{
-		Server:   server.URL,
-		Login:    "test",
-		APIToken: "token",
-	}, WithTimeout(3*time.Second))
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
 
-	// Create temp file
-	tmpDir := t.TempDir()
-	testFile := filepath.Join(tmpDir, "test.txt")
-	err := os.WriteFile(testFile, []byte("test content"), 0o644)
-	assert.NoError(t, err)
+	var buf bytes.Buffer
+	res, err := client.DownloadAttachmentTo(server.URL+"/attachments/test.txt", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, buf.String())
+	assert.Equal(t, int64(len(testContent)), res.Bytes)
 
-	attachments, err := client.UploadAttachmentV2("TEST-1", testFile)
-	assert.NoError(t, err)
-	assert.Len(t, attachments, 1)
-	assert.Equal(t, "10001", attachments[0].ID)
+	buf.Reset()
+	_, err = client.DownloadAttachmentTo(server.URL+"/attachments/short.txt", &buf)
+	assert.Error(t, err)
+
+	_, err = client.DownloadAttachmentTo("", &buf)
+	assert.ErrorIs(t, err, ErrAttachmentUnavailable)
 }
 
-func TestUploadAttachmentFileNotFound(t *testing.T) {
+func TestDownloadAttachmentBinary(t *testing.T) {
 	t.Parallel()
 
+	content := make([]byte, 0, 512)
+	for i := 0; i < 512; i++ {
+		content = append(content, byte(i))
+	}
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		t.Fatal("Should not reach server")
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(content)
 	}))
 	defer server.Close()
 
-	client := NewClient(Config{
-		Server:   server.URL,
-		Login:    "test",
-		APIToken: "token",
-	}, WithTimeout(3*time.Second))
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
 
-	_, err := client.UploadAttachment("TEST-1", "/nonexistent/file.txt")
-	assert.Error(t, err)
-	assert.True(t, os.IsNotExist(err))
+	// Both the file and the stream get the bytes as sent, line endings included.
+	var buf bytes.Buffer
+	_, err := client.DownloadAttachmentTo(server.URL+"/attachments/app.bin", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, content, buf.Bytes())
+
+	destPath := filepath.Join(t.TempDir(), "app.bin")
+	require.NoError(t, client.DownloadAttachment(server.URL+"/attachments/app.bin", destPath))
+	got, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
 }
 
-func TestDeleteAttachment(t *testing.T) {
+func TestDownloadAttachmentChunked(t *testing.T) {
 	t.Parallel()
 
+	chunk := strings.Repeat("x", 1024)
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/rest/api/3/attachment/10001", r.URL.Path)
-		assert.Equal(t, "DELETE", r.Method)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(200)
 
-		w.WriteHeader(204)
+		// Flushing before the handler returns forces chunked transfer encoding.
+		for i := 0; i < 10; i++ {
+			_, _ = w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
 	}))
 	defer server.Close()
 
@@ -206,48 +163,113 @@ func TestDeleteAttachment(t *testing.T) {
 		APIToken: "token",
 	}, WithTimeout(3*time.Second))
 
-	err := client.DeleteAttachment("10001")
-	assert.NoError(t, err)
-}
-
-func TestDeleteAttachmentV2(t *testing.T) {
-	t.Parallel()
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/rest/api/2/attachment/10001", r.URL.Path)
-		assert.Equal(t, "DELETE", r.Method)
-
-		w.WriteHeader(204)
-	}))
-	defer server.Close()
+	destPath := filepath.Join(t.TempDir(), "downloaded.bin")
 
-	client := NewClient(Config{
-		Server:   server.URL,
-		Login:    "test",
-		APIToken: "token",
-	}, WithTimeout(3*time.Second))
+	res, err := client.DownloadAttachmentWithResult(server.URL+"/attachments/test.bin", destPath)
+	assert.NoError(t, err)
+	assert.False(t, res.TotalKnown)
+	assert.Equal(t, int64(10*len(chunk)), res.Bytes)
 
-	err := client.DeleteAttachmentV2("10001")
+	info, err := os.Stat(destPath)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(10*len(chunk)), info.Size())
 }
 
-func TestDeleteAttachmentError(t *testing.T) {
+func TestContentLength(t *testing.T) {
 	t.Parallel()
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(404)
-		_, _ = w.Write([]byte(`{"errorMessages":["Attachment not found"]}`))
-	}))
-	defer server.Close()
+	cases := []struct {
+		name          string
+		input         int64
+		expectedTotal int64
+		expectedKnown bool
+	}{
+		{name: "unknown", input: -1, expectedTotal: 0, expectedKnown: false},
+		{name: "ambiguous zero", input: 0, expectedTotal: 0, expectedKnown: false},
+		{name: "positive", input: 1024, expectedTotal: 1024, expectedKnown: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			total, known := contentLength(tc.input)
+			assert.Equal(t, tc.expectedTotal, total)
+			assert.Equal(t, tc.expectedKnown, known)
+		})
+	}
+}
 
-	client := NewClient(Config{
-		Server:   server.URL,
-		Login:    "test",
-		APIToken: "token",
-	}, WithTimeout(3*time.Second))
+func TestDownloadAttachmentConditional(t *testing.T) {
+	t.Parallel()
 
-	err := client.DeleteAttachment("10001")
-	assert.Error(t, err)
-	assert.IsType(t, &ErrUnexpectedResponse{}, err)
+	const (
+		etag    = `"abc123"`
+		content = "attachment content"
+	)
+
+	cases := []struct {
+		name            string
+		honorConditions bool
+		expectModified  bool
+	}{
+		{name: "server honoring If-None-Match", honorConditions: true, expectModified: false},
+		{name: "server ignoring If-None-Match", honorConditions: false, expectModified: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", etag)
+				w.Header().Set("Last-Modified", "Wed, 01 Jan 2025 00:00:00 GMT")
+				if tc.honorConditions && r.Header.Get("If-None-Match") == etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.WriteHeader(200)
+				_, _ = w.Write([]byte(content))
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{
+				Server:   server.URL,
+				Login:    "test",
+				APIToken: "token",
+			}, WithTimeout(3*time.Second))
+
+			destPath := filepath.Join(t.TempDir(), "downloaded.txt")
+
+			first, err := client.DownloadAttachmentWithResult(server.URL+"/attachments/test.txt", destPath)
+			assert.NoError(t, err)
+			assert.False(t, first.NotModified)
+			assert.Equal(t, etag, first.ETag)
+			assert.Equal(t, "Wed, 01 Jan 2025 00:00:00 GMT", first.LastModified)
+
+			assert.NoError(t, os.Remove(destPath))
+
+			second, err := client.DownloadAttachmentWithResult(
+				server.URL+"/attachments/test.txt", destPath,
+				IfNoneMatch(first.ETag), IfModifiedSince(first.LastModified),
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, !tc.expectModified, second.NotModified)
+			assert.Equal(t, etag, second.ETag)
+
+			if tc.expectModified {
+				assert.Equal(t, int64(len(content)), second.Bytes)
+				b, err := os.ReadFile(destPath)
+				assert.NoError(t, err)
+				assert.Equal(t, content, string(b))
+			} else {
+				assert.Equal(t, int64(0), second.Bytes)
+				_, err := os.Stat(destPath)
+				assert.True(t, os.IsNotExist(err))
+			}
+		})
+	}
 }
@@ -0,0 +1,124 @@
+package jira
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nginx413 = `<html>
+<head><title>413 Request Entity Too Large</title></head>
+<body>
+<center><h1>413 Request Entity Too Large</h1></center>
+<hr><center>nginx</center>
+</body>
+</html>
+`
+
+// tooLargeServer serves the attachment settings and rejects uploads with 413 and body. It
+// counts the requests for the settings.
+func tooLargeServer(t *testing.T, contentType, body string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var metaRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/3/attachment/meta" {
+			atomic.AddInt32(&metaRequests, 1)
+			_, _ = w.Write([]byte(`{"enabled":true,"uploadLimit":10485760}`))
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &metaRequests
+}
+
+func writeUploadFile(t *testing.T, size int) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "dump.bin")
+	require.NoError(t, os.WriteFile(file, make([]byte, size), 0o600))
+	return file
+}
+
+func TestUploadAttachmentRejectedByProxy(t *testing.T) {
+	t.Parallel()
+
+	server, metaRequests := tooLargeServer(t, "text/html", nginx413)
+	file := writeUploadFile(t, 2048)
+	client := newRetryTestClient(server.URL)
+
+	_, err := client.UploadAttachment("TEST-1", file)
+
+	var tooLarge *UploadTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	assert.True(t, tooLarge.Proxy, "an HTML page isn't an error of Jira")
+	assert.Greater(t, tooLarge.Size, int64(2048), "the size of the whole form")
+	assert.Zero(t, tooLarge.Limit, "the settings aren't fetched by the upload")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, tooLarge.Err.StatusCode)
+	assert.Zero(t, atomic.LoadInt32(metaRequests))
+
+	_, err = client.GetAttachmentMeta()
+	require.NoError(t, err)
+	_, err = client.GetAttachmentMeta()
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(metaRequests), "the settings are cached")
+
+	_, err = client.UploadAttachment("TEST-1", file)
+	require.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, int64(10<<20), tooLarge.Limit, "the cached settings are used")
+}
+
+func TestUploadAttachmentRejectedByJira(t *testing.T) {
+	t.Parallel()
+
+	server, _ := tooLargeServer(t, "application/json", `{"errorMessages":["The file is too large"]}`)
+	file := writeUploadFile(t, 2048)
+
+	_, err := newRetryTestClient(server.URL).UploadAttachment("TEST-1", file)
+
+	var tooLarge *UploadTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	assert.False(t, tooLarge.Proxy)
+	assert.Equal(t, []string{"The file is too large"}, tooLarge.Err.Body.ErrorMessages)
+}
+
+func TestUploadAttachmentConnectionDropped(t *testing.T) {
+	t.Parallel()
+
+	// Like a proxy over its body size limit, the server reads the headers and closes the
+	// connection without responding.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = http.ReadRequest(bufio.NewReader(conn))
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				_ = tcp.SetLinger(0)
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	file := writeUploadFile(t, 8<<20)
+	_, err = newRetryTestClient("http://"+ln.Addr().String()).UploadAttachment("TEST-1", file)
+
+	var dropped *UploadInterruptedError
+	require.ErrorAs(t, err, &dropped)
+	assert.Greater(t, dropped.Size, int64(8<<20))
+}
@@ -0,0 +1,109 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// changelogPageSize is the number of changelog entries requested per page.
+const changelogPageSize = 100
+
+// ChangelogItem is a change of a single field in a changelog entry. For fields holding a list,
+// like attachments, adding a value only sets To and removing one only sets From.
+type ChangelogItem struct {
+	Field      string `json:"field"`
+	FieldType  string `json:"fieldtype"`
+	FieldID    string `json:"fieldId,omitempty"`
+	From       string `json:"from"`
+	FromString string `json:"fromString"`
+	To         string `json:"to"`
+	ToString   string `json:"toString"`
+}
+
+// Changelog is an entry in the history of an issue.
+type Changelog struct {
+	ID      string          `json:"id"`
+	Author  User            `json:"author"`
+	Created string          `json:"created"`
+	Items   []ChangelogItem `json:"items"`
+}
+
+// changelogPage is a page of the GET /issue/{key}/changelog endpoint.
+type changelogPage struct {
+	StartAt    int         `json:"startAt"`
+	MaxResults int         `json:"maxResults"`
+	Total      int         `json:"total"`
+	IsLast     bool        `json:"isLast"`
+	Values     []Changelog `json:"values"`
+}
+
+// GetIssueChangelog fetches the full history of an issue, oldest first, using
+// v3 version of the paginated GET /issue/{key}/changelog endpoint.
+func (c *Client) GetIssueChangelog(key string) ([]Changelog, error) {
+	var out []Changelog
+
+	for startAt := 0; ; {
+		path := fmt.Sprintf("/issue/%s/changelog?startAt=%d&maxResults=%d", url.PathEscape(key), startAt, changelogPageSize)
+
+		var page changelogPage
+		if err := c.getJSON(path, apiVersion3, &page); err != nil {
+			return nil, err
+		}
+		out = append(out, page.Values...)
+
+		startAt += len(page.Values)
+		if page.IsLast || len(page.Values) == 0 || (page.Total > 0 && startAt >= page.Total) {
+			return out, nil
+		}
+	}
+}
+
+// GetIssueChangelogV2 fetches the full history of an issue, oldest first, using v2 version
+// of the GET /issue/{key} endpoint with the changelog expanded, as Jira server doesn't
+// have the changelog endpoint. Jira server returns all entries in one go.
+func (c *Client) GetIssueChangelogV2(key string) ([]Changelog, error) {
+	path := fmt.Sprintf("/issue/%s?%s", url.PathEscape(key), url.Values{
+		"fields": {"attachment"},
+		"expand": {"changelog"},
+	}.Encode())
+
+	var out struct {
+		Changelog struct {
+			Histories []Changelog `json:"histories"`
+		} `json:"changelog"`
+	}
+	if err := c.getJSON(path, apiVersion2, &out); err != nil {
+		return nil, err
+	}
+	return out.Changelog.Histories, nil
+}
+
+func (c *Client) getJSON(path, ver string, out any) error {
+	var (
+		res *http.Response
+		err error
+	)
+
+	switch ver {
+	case apiVersion2:
+		res, err = c.GetV2(context.Background(), path, nil)
+	default:
+		res, err = c.Get(context.Background(), path, nil)
+	}
+
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return formatUnexpectedResponse(res)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
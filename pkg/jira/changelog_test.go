@@ -0,0 +1,99 @@
+package jira
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIssueChangelog(t *testing.T) {
+	t.Parallel()
+
+	var pages []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/3/issue/TEST-1/changelog", r.URL.Path)
+		assert.Equal(t, "100", r.URL.Query().Get("maxResults"))
+
+		startAt := r.URL.Query().Get("startAt")
+		pages = append(pages, startAt)
+
+		resp, err := os.ReadFile("./testdata/changelog-" + map[string]string{"0": "0", "2": "1"}[startAt] + ".json")
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	actual, err := client.GetIssueChangelog("TEST-1")
+	require.NoError(t, err)
+
+	// The second page starts after the entries received, not after maxResults.
+	assert.Equal(t, []string{"0", "2"}, pages)
+
+	require.Len(t, actual, 4)
+	assert.Equal(t, []string{"100", "101", "102", "103"}, []string{actual[0].ID, actual[1].ID, actual[2].ID, actual[3].ID})
+	assert.Equal(t, "acc-jon", actual[1].Author.AccountID)
+	assert.Equal(t, "2024-02-01T10:00:00.000+0000", actual[1].Created)
+	assert.Equal(t, ChangelogItem{
+		Field:     "Attachment",
+		FieldType: "jira",
+		FieldID:   "attachment",
+		To:        "10002",
+		ToString:  "report.pdf",
+	}, actual[1].Items[1])
+	assert.Equal(t, ChangelogItem{
+		Field:      "Attachment",
+		FieldType:  "jira",
+		FieldID:    "attachment",
+		From:       "10003",
+		FromString: "draft.docx",
+	}, actual[3].Items[0])
+}
+
+func TestGetIssueChangelogV2(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/issue/TEST-1", r.URL.Path)
+		assert.Equal(t, "changelog", r.URL.Query().Get("expand"))
+		assert.Equal(t, "attachment", r.URL.Query().Get("fields"))
+
+		resp, err := os.ReadFile("./testdata/issue-changelog.json")
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	actual, err := client.GetIssueChangelogV2("TEST-1")
+	require.NoError(t, err)
+	require.Len(t, actual, 2)
+	assert.Equal(t, "jane", actual[1].Author.Name)
+	assert.Equal(t, "10001", actual[1].Items[0].From)
+}
+
+func TestGetIssueChangelogError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	_, err := client.GetIssueChangelog("TEST-404")
+	assert.IsType(t, &ErrUnexpectedResponse{}, err)
+}
@@ -7,12 +7,15 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -124,6 +127,35 @@ type Client struct {
 	token     string
 	timeout   time.Duration
 	debug     bool
+	proxy     func(*http.Request) (*url.URL, error)
+
+	mediaTLS *tls.Config
+	// attachment is the transport of attachment requests, see attachmentTransport.
+	attachment         http.RoundTripper
+	attachmentTimeouts AttachmentTimeouts
+	// dial replaces the dialer of attachment requests in tests.
+	dial dialFunc
+
+	retryBackoff time.Duration
+	// retries is the number of retries of rate limited and failed attachment requests,
+	// see WithRetries.
+	retries int
+
+	// dryRun blocks mutating requests, see WithDryRun.
+	dryRun bool
+
+	// attachmentWriter wraps the file an attachment is downloaded to. It is used
+	// in tests to simulate a filesystem that runs out of space.
+	attachmentWriter func(io.Writer) io.Writer
+
+	// debugOut receives debug dumps of streamed requests, stdout if nil.
+	debugOut io.Writer
+	// logger receives the verbose output of the client, see WithLogger.
+	logger Logger
+
+	mu                  sync.Mutex
+	myselfCache         *User
+	attachmentMetaCache *AttachmentMeta
 }
 
 // ClientFunc decorates option for client.
@@ -137,14 +169,26 @@ func NewClient(c Config, opts ...ClientFunc) *Client {
 		token:    c.APIToken,
 		authType: c.AuthType,
 		debug:    c.Debug,
+
+		retryBackoff: attachmentRetryBackoff,
+	}
+
+	// Set default auth type to `basic`. It is set once here, the client is shared by
+	// concurrent requests that read it.
+	if client.authType == nil {
+		basic := AuthTypeBasic
+		client.authType = &basic
 	}
 
 	for _, opt := range opts {
 		opt(&client)
 	}
+	if client.proxy == nil {
+		client.proxy = http.ProxyFromEnvironment
+	}
 
 	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+		Proxy: client.proxy,
 		TLSClientConfig: &tls.Config{
 			MinVersion:         tls.VersionTLS12,
 			InsecureSkipVerify: client.insecure,
@@ -177,6 +221,26 @@ func NewClient(c Config, opts ...ClientFunc) *Client {
 
 	client.transport = transport
 
+	attachment := newAttachmentTransport(transport, client.attachmentTimeouts, client.dial)
+	client.attachment = attachment
+	if client.mediaTLS != nil {
+		media := attachment.Clone()
+		media.TLSClientConfig = client.mediaTLS.Clone()
+		if media.TLSClientConfig.MinVersion == 0 {
+			media.TLSClientConfig.MinVersion = tls.VersionTLS12
+		}
+		client.attachment = newHostTransport(client.server, attachment, media)
+	}
+
+	if client.logger != nil {
+		client.transport = logTransport{next: client.transport, logger: client.logger}
+		client.attachment = logTransport{next: client.attachment, logger: client.logger}
+	}
+	if client.dryRun {
+		client.transport = dryRunTransport{next: client.transport}
+		client.attachment = dryRunTransport{next: client.attachment}
+	}
+
 	return &client
 }
 
@@ -244,6 +308,16 @@ func (c *Client) DeleteV2(ctx context.Context, path string, headers Header) (*ht
 	return c.request(ctx, http.MethodDelete, c.server+baseURLv2+path, nil, headers)
 }
 
+// Request sends a request with the given method to v3 version of the jira api.
+func (c *Client) Request(ctx context.Context, method, path string, body []byte, headers Header) (*http.Response, error) {
+	return c.request(ctx, method, c.server+baseURLv3+path, body, headers)
+}
+
+// RequestV2 sends a request with the given method to v2 version of the jira api.
+func (c *Client) RequestV2(ctx context.Context, method, path string, body []byte, headers Header) (*http.Response, error) {
+	return c.request(ctx, method, c.server+baseURLv2+path, body, headers)
+}
+
 func (c *Client) request(ctx context.Context, method, endpoint string, body []byte, headers Header) (*http.Response, error) {
 	var (
 		req *http.Request
@@ -257,7 +331,8 @@ func (c *Client) request(ctx context.Context, method, endpoint string, body []by
 	}
 
 	defer func() {
-		if c.debug {
+		// Requests are dumped by the transport of the logger if it logs them.
+		if c.debug && !c.logs(LogDump) {
 			dump(req, res)
 		}
 	}()
@@ -266,12 +341,6 @@ func (c *Client) request(ctx context.Context, method, endpoint string, body []by
 		req.Header.Set(k, v)
 	}
 
-	// Set default auth type to `basic`.
-	if c.authType == nil {
-		basic := AuthTypeBasic
-		c.authType = &basic
-	}
-
 	// When need to compare using `String()` here, it is used to handle cases where the
 	// authentication type might be empty, ensuring it defaults to the appropriate value.
 	switch c.authType.String() {
@@ -285,7 +354,7 @@ func (c *Client) request(ctx context.Context, method, endpoint string, body []by
 		req.SetBasicAuth(c.login, c.token)
 	}
 
-	httpClient := &http.Client{Transport: c.transport}
+	httpClient := &http.Client{Transport: c.maintenanceGuard(c.readOnlyGuard(c.transport))}
 
 	return httpClient.Do(req.WithContext(ctx))
 }
@@ -301,11 +370,15 @@ func dump(req *http.Request, res *http.Response) {
 }
 
 func prettyPrintDump(heading string, data []byte) {
+	printDump(os.Stdout, heading, data)
+}
+
+func printDump(w io.Writer, heading string, data []byte) {
 	const separatorWidth = 60
 
-	fmt.Printf("\n\n%s", strings.ToUpper(heading))
-	fmt.Printf("\n%s\n\n", strings.Repeat("-", separatorWidth))
-	fmt.Print(string(data))
+	fmt.Fprintf(w, "\n\n%s", strings.ToUpper(heading))
+	fmt.Fprintf(w, "\n%s\n\n", strings.Repeat("-", separatorWidth))
+	fmt.Fprint(w, string(data))
 }
 
 func formatUnexpectedResponse(res *http.Response) *ErrUnexpectedResponse {
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package jira
+
+import "errors"
+
+func freeDiskSpace(string) (int64, error) {
+	return 0, errors.New("free disk space is not supported on this platform")
+}
+
+func isNoSpaceOS(error) bool {
+	return false
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin
+
+package jira
+
+import "golang.org/x/sys/unix"
+
+// freeDiskSpace returns the space available to unprivileged users on the filesystem of dir.
+func freeDiskSpace(dir string) (int64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+func isNoSpaceOS(error) bool {
+	return false
+}
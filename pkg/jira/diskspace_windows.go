@@ -0,0 +1,27 @@
+//go:build windows
+
+package jira
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// freeDiskSpace returns the space available to the current user on the volume of dir.
+func freeDiskSpace(dir string) (int64, error) {
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var avail, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &avail, &total, &free); err != nil {
+		return 0, err
+	}
+	return int64(avail), nil
+}
+
+func isNoSpaceOS(err error) bool {
+	return errors.Is(err, windows.ERROR_DISK_FULL) || errors.Is(err, windows.ERROR_HANDLE_DISK_FULL)
+}
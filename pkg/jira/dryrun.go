@@ -0,0 +1,59 @@
+package jira
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ErrDryRun denotes that a request was not sent because it would change data on
+// the server and the client is in dry-run mode, see WithDryRun.
+var ErrDryRun = fmt.Errorf("jira: request not sent in dry-run mode")
+
+// DryRunError is a request blocked in dry-run mode. It matches ErrDryRun with errors.Is.
+type DryRunError struct {
+	Method string
+	URL    string
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("jira: %s %s not sent in dry-run mode", e.Method, e.URL)
+}
+
+// Is makes errors.Is(err, ErrDryRun) report true.
+func (e *DryRunError) Is(target error) bool {
+	return target == ErrDryRun
+}
+
+// WithDryRun is a functional opt to block every request that may change data on the
+// server, ie: anything but GET, HEAD and OPTIONS. Blocked requests fail with a
+// DryRunError before they reach the network. The guard sits in the transport, so
+// it covers every request of the client, including uploads and attachment content.
+func WithDryRun(dryRun bool) ClientFunc {
+	return func(c *Client) {
+		c.dryRun = dryRun
+	}
+}
+
+// dryRunTransport rejects mutating requests, see WithDryRun.
+type dryRunTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.next.RoundTrip(req)
+	}
+	// A RoundTripper must close the body, even on errors. This also
+	// unblocks the writer of a streamed multipart body.
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, &DryRunError{Method: req.Method, URL: req.URL.Redacted()}
+}
+
+// DryRun reports if the client is in dry-run mode.
+func (c *Client) DryRun() bool {
+	return c.dryRun
+}
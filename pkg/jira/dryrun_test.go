@@ -0,0 +1,57 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		methods []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithDryRun(true))
+	assert.True(t, client.DryRun())
+
+	res, err := client.Get(context.Background(), "/myself", nil)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+
+	_, err = client.Post(context.Background(), "/issue", []byte(`{}`), nil)
+	assert.ErrorIs(t, err, ErrDryRun)
+
+	var dryErr *DryRunError
+	require.True(t, errors.As(err, &dryErr))
+	assert.Equal(t, http.MethodPost, dryErr.Method)
+	assert.Equal(t, server.URL+"/rest/api/3/issue", dryErr.URL)
+
+	_, err = client.DeleteV2(context.Background(), "/attachment/1", nil)
+	assert.ErrorIs(t, err, ErrDryRun)
+
+	// The streamed multipart upload must not block on the rejected body.
+	file := filepath.Join(t.TempDir(), "a.txt")
+	require.NoError(t, os.WriteFile(file, []byte("hello"), 0o600))
+	_, err = client.UploadAttachment("TEST-1", file)
+	assert.ErrorIs(t, err, ErrDryRun)
+
+	assert.Equal(t, []string{http.MethodGet}, methods)
+}
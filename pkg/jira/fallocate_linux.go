@@ -0,0 +1,20 @@
+//go:build linux
+
+package jira
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// preallocate reserves size bytes for the file. Filesystems that don't
+// support fallocate are ignored, the space is then allocated on write.
+func preallocate(f *os.File, size int64) error {
+	err := unix.Fallocate(int(f.Fd()), 0, 0, size)
+	if errors.Is(err, unix.ENOSPC) || errors.Is(err, unix.EFBIG) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package jira
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreallocate(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "prealloc.bin"))
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	assert.NoError(t, preallocate(f, 8192))
+
+	// Filesystems without fallocate support leave the file empty.
+	info, err := f.Stat()
+	assert.NoError(t, err)
+	assert.Contains(t, []int64{0, 8192}, info.Size())
+}
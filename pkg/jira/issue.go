@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/ankitpokhrel/jira-cli/pkg/jira/filter/issue"
@@ -28,7 +29,7 @@ const (
 
 // GetIssue fetches issue details using GET /issue/{key} endpoint.
 func (c *Client) GetIssue(key string, opts ...filter.Filter) (*Issue, error) {
-	iss, err := c.getIssue(key, apiVersion3)
+	iss, err := c.getIssue(context.Background(), key, apiVersion3)
 	if err != nil {
 		return nil, err
 	}
@@ -49,11 +50,47 @@ func (c *Client) GetIssue(key string, opts ...filter.Filter) (*Issue, error) {
 
 // GetIssueV2 fetches issue details using v2 version of Jira GET /issue/{key} endpoint.
 func (c *Client) GetIssueV2(key string, _ ...filter.Filter) (*Issue, error) {
-	return c.getIssue(key, apiVersion2)
+	return c.getIssue(context.Background(), key, apiVersion2)
 }
 
-func (c *Client) getIssue(key, ver string) (*Issue, error) {
-	rawOut, err := c.getIssueRaw(key, ver)
+// GetIssueFields fetches only the given fields of an issue using v3 version of the
+// GET /issue/{key} endpoint. Fields that are not requested are left empty.
+func (c *Client) GetIssueFields(key string, fields []string) (*Issue, error) {
+	return c.GetIssueFieldsContext(context.Background(), key, fields)
+}
+
+// GetIssueFieldsContext is GetIssueFields with a context, eg: to cap how long the request may take.
+func (c *Client) GetIssueFieldsContext(ctx context.Context, key string, fields []string) (*Issue, error) {
+	iss, err := c.getIssue(ctx, key, apiVersion3, fields...)
+	if err != nil {
+		return nil, err
+	}
+
+	iss.Fields.Description = ifaceToADF(iss.Fields.Description)
+	for i, cmt := range iss.Fields.Comment.Comments {
+		iss.Fields.Comment.Comments[i].Body = ifaceToADF(cmt.Body)
+	}
+	return iss, nil
+}
+
+// GetIssueFieldsV2 fetches only the given fields of an issue using v2 version of the
+// GET /issue/{key} endpoint. Fields that are not requested are left empty.
+func (c *Client) GetIssueFieldsV2(key string, fields []string) (*Issue, error) {
+	return c.GetIssueFieldsV2Context(context.Background(), key, fields)
+}
+
+// GetIssueFieldsV2Context is GetIssueFieldsV2 with a context.
+func (c *Client) GetIssueFieldsV2Context(ctx context.Context, key string, fields []string) (*Issue, error) {
+	return c.getIssue(ctx, key, apiVersion2, fields...)
+}
+
+func (c *Client) getIssue(ctx context.Context, key, ver string, fields ...string) (*Issue, error) {
+	return c.getIssueMax(ctx, key, ver, 0, fields...)
+}
+
+// getIssueMax fetches an issue reading at most maxBytes of the response, unlimited if zero.
+func (c *Client) getIssueMax(ctx context.Context, key, ver string, maxBytes int64, fields ...string) (*Issue, error) {
+	rawOut, err := c.getIssueRawMax(ctx, key, ver, maxBytes, fields...)
 	if err != nil {
 		return nil, err
 	}
@@ -68,16 +105,23 @@ func (c *Client) getIssue(key, ver string) (*Issue, error) {
 
 // GetIssueRaw fetches issue details same as GetIssue but returns the raw API response body string.
 func (c *Client) GetIssueRaw(key string) (string, error) {
-	return c.getIssueRaw(key, apiVersion3)
+	return c.getIssueRaw(context.Background(), key, apiVersion3)
 }
 
 // GetIssueV2Raw fetches issue details same as GetIssueV2 but returns the raw API response body string.
 func (c *Client) GetIssueV2Raw(key string) (string, error) {
-	return c.getIssueRaw(key, apiVersion2)
+	return c.getIssueRaw(context.Background(), key, apiVersion2)
 }
 
-func (c *Client) getIssueRaw(key, ver string) (string, error) {
+func (c *Client) getIssueRaw(ctx context.Context, key, ver string, fields ...string) (string, error) {
+	return c.getIssueRawMax(ctx, key, ver, 0, fields...)
+}
+
+func (c *Client) getIssueRawMax(ctx context.Context, key, ver string, maxBytes int64, fields ...string) (string, error) {
 	path := fmt.Sprintf("/issue/%s", key)
+	if len(fields) > 0 {
+		path += "?" + url.Values{"fields": {strings.Join(fields, ",")}}.Encode()
+	}
 
 	var (
 		res *http.Response
@@ -86,9 +130,9 @@ func (c *Client) getIssueRaw(key, ver string) (string, error) {
 
 	switch ver {
 	case apiVersion2:
-		res, err = c.GetV2(context.Background(), path, nil)
+		res, err = c.GetV2(ctx, path, nil)
 	default:
-		res, err = c.Get(context.Background(), path, nil)
+		res, err = c.Get(ctx, path, nil)
 	}
 
 	if err != nil {
@@ -103,11 +147,20 @@ func (c *Client) getIssueRaw(key, ver string) (string, error) {
 		return "", formatUnexpectedResponse(res)
 	}
 
+	var body io.Reader = res.Body
+	if maxBytes > 0 {
+		// One byte past the limit tells a response of exactly the limit from a larger one.
+		body = io.LimitReader(res.Body, maxBytes+1)
+	}
+
 	var b strings.Builder
-	_, err = io.Copy(&b, res.Body)
+	n, err := io.Copy(&b, body)
 	if err != nil {
 		return "", err
 	}
+	if maxBytes > 0 && n > maxBytes {
+		return "", &ResponseTooLargeError{Key: key, Limit: maxBytes, Fields: fields}
+	}
 	return b.String(), nil
 }
 
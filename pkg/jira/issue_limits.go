@@ -0,0 +1,88 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultIssueMaxBytes is the default cap of the response of a limited issue fetch.
+	DefaultIssueMaxBytes = 20 << 20
+	// DefaultIssueTimeout is the default deadline of a limited issue fetch.
+	DefaultIssueTimeout = 10 * time.Second
+)
+
+// IssueLimits guards a fetch of an issue against pathological payloads, eg: issues with
+// megabytes of comments, that take long to receive and a lot of memory to decode.
+type IssueLimits struct {
+	// MaxBytes is the largest response body read, unlimited if zero.
+	MaxBytes int64
+	// Timeout is the deadline of the call, independent of the timeout of the client.
+	// There is none if zero.
+	Timeout time.Duration
+}
+
+// ResponseTooLargeError is returned when an issue is larger than IssueLimits.MaxBytes.
+// The body is not read past the limit.
+type ResponseTooLargeError struct {
+	Key    string
+	Limit  int64
+	Fields []string
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	msg := fmt.Sprintf("jira: response for issue %s is larger than %s", e.Key, FormatSize(e.Limit))
+	if len(e.Fields) == 0 {
+		return msg + ", retry with only the needed fields, eg: fields=attachment"
+	}
+	return msg + " with fields " + strings.Join(e.Fields, ",")
+}
+
+// IssueTimeoutError is returned when an issue isn't received within IssueLimits.Timeout.
+type IssueTimeoutError struct {
+	Key     string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *IssueTimeoutError) Error() string {
+	return fmt.Sprintf("jira: issue %s was not received within %s", e.Key, e.Timeout)
+}
+
+func (e *IssueTimeoutError) Unwrap() error { return e.Err }
+
+// GetIssueFieldsLimited is GetIssueFieldsContext enforcing the limits.
+func (c *Client) GetIssueFieldsLimited(ctx context.Context, key string, fields []string, limits IssueLimits) (*Issue, error) {
+	iss, err := c.getIssueLimited(ctx, key, apiVersion3, fields, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	iss.Fields.Description = ifaceToADF(iss.Fields.Description)
+	for i, cmt := range iss.Fields.Comment.Comments {
+		iss.Fields.Comment.Comments[i].Body = ifaceToADF(cmt.Body)
+	}
+	return iss, nil
+}
+
+// GetIssueFieldsV2Limited is GetIssueFieldsV2Context enforcing the limits.
+func (c *Client) GetIssueFieldsV2Limited(ctx context.Context, key string, fields []string, limits IssueLimits) (*Issue, error) {
+	return c.getIssueLimited(ctx, key, apiVersion2, fields, limits)
+}
+
+func (c *Client) getIssueLimited(ctx context.Context, key, ver string, fields []string, limits IssueLimits) (*Issue, error) {
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+
+	iss, err := c.getIssueMax(ctx, key, ver, limits.MaxBytes, fields...)
+	if err != nil && limits.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, &IssueTimeoutError{Key: key, Timeout: limits.Timeout, Err: err}
+	}
+	return iss, err
+}
@@ -0,0 +1,150 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const slimIssue = `{"key": "TEST-1", "fields": {"attachment": [{"id": "10001", "filename": "a.txt", "size": 5}]}}`
+
+// largeIssue returns an issue whose response is exactly size bytes, padded with a comment.
+func largeIssue(size int) string {
+	const frame = `{"key": "TEST-1", "fields": {"attachment": [], "comment": {"comments": [{"body": ""}]}}}`
+	return strings.Replace(frame, `"body": ""`, `"body": "`+strings.Repeat("x", size-len(frame))+`"`, 1)
+}
+
+// issueServer streams the full issue in small chunks unless only the attachment field is
+// requested, like Jira does for issues with huge comments. It counts the bytes sent.
+func issueServer(t *testing.T, full string, sent *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := full
+		if r.URL.Query().Get("fields") == "attachment" {
+			body = slimIssue
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		f, _ := w.(http.Flusher)
+		for len(body) > 0 {
+			n := min(len(body), 64<<10)
+			if _, err := io.WriteString(w, body[:n]); err != nil {
+				return
+			}
+			atomic.AddInt64(sent, int64(n))
+			if f != nil {
+				f.Flush()
+			}
+			body = body[n:]
+		}
+	}))
+}
+
+func TestGetIssueFieldsLimitedMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	const limit = 1 << 20
+
+	t.Run("oversized", func(t *testing.T) {
+		t.Parallel()
+
+		var sent int64
+		server := issueServer(t, largeIssue(8*limit), &sent)
+		defer server.Close()
+		client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+		for _, get := range []func(context.Context, string, []string, IssueLimits) (*Issue, error){
+			client.GetIssueFieldsLimited, client.GetIssueFieldsV2Limited,
+		} {
+			_, err := get(context.Background(), "TEST-1", nil, IssueLimits{MaxBytes: limit})
+
+			var tooLarge *ResponseTooLargeError
+			require.ErrorAs(t, err, &tooLarge)
+			assert.Equal(t, "TEST-1", tooLarge.Key)
+			assert.EqualValues(t, limit, tooLarge.Limit)
+			assert.EqualError(t, err, "jira: response for issue TEST-1 is larger than 1.00 MB, retry with only the needed fields, eg: fields=attachment")
+		}
+
+		// With the fields requested, the error names them instead.
+		err := (&ResponseTooLargeError{Key: "TEST-1", Limit: limit, Fields: []string{"attachment", "parent"}}).Error()
+		assert.Equal(t, "jira: response for issue TEST-1 is larger than 1.00 MB with fields attachment,parent", err)
+	})
+
+	t.Run("just under the cap", func(t *testing.T) {
+		t.Parallel()
+
+		var sent int64
+		server := issueServer(t, largeIssue(limit), &sent)
+		defer server.Close()
+		client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+		iss, err := client.GetIssueFieldsLimited(context.Background(), "TEST-1", nil, IssueLimits{MaxBytes: limit})
+		require.NoError(t, err)
+		assert.Equal(t, "TEST-1", iss.Key)
+		assert.EqualValues(t, limit, atomic.LoadInt64(&sent))
+	})
+
+	t.Run("slim fetch stays under the cap", func(t *testing.T) {
+		t.Parallel()
+
+		var sent int64
+		server := issueServer(t, largeIssue(64*limit), &sent)
+		defer server.Close()
+		client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+		iss, err := client.GetIssueFieldsLimited(context.Background(), "TEST-1", []string{"attachment"},
+			IssueLimits{MaxBytes: DefaultIssueMaxBytes})
+		require.NoError(t, err)
+		require.Len(t, iss.Fields.Attachments, 1)
+		assert.Equal(t, "a.txt", iss.Fields.Attachments[0].Filename)
+		assert.EqualValues(t, len(slimIssue), atomic.LoadInt64(&sent))
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		t.Parallel()
+
+		var sent int64
+		server := issueServer(t, largeIssue(2*limit), &sent)
+		defer server.Close()
+		client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+		_, err := client.GetIssueFieldsLimited(context.Background(), "TEST-1", nil, IssueLimits{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestGetIssueFieldsLimitedTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// The client timeout is longer than the deadline of the call.
+	client := NewClient(Config{Server: server.URL}, WithTimeout(time.Minute))
+
+	start := time.Now()
+	_, err := client.GetIssueFieldsV2Limited(context.Background(), "TEST-1", []string{"attachment"},
+		IssueLimits{Timeout: 100 * time.Millisecond})
+
+	var timeoutErr *IssueTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.EqualError(t, err, fmt.Sprintf("jira: issue TEST-1 was not received within %s", 100*time.Millisecond))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
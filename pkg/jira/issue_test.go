@@ -103,10 +103,11 @@ func TestGetIssue(t *testing.T) {
 						DisplayName: "Person A",
 						AccountID:   "123",
 					},
-					Created:  "2020-12-01T10:00:00.000+0100",
-					Size:     1048576,
-					MimeType: "application/pdf",
-					Content:  "https://example.com/attachment/10001",
+					Created:   "2020-12-01T10:00:00.000+0100",
+					CreatedAt: createdAt("2020-12-01T10:00:00.000+0100"),
+					Size:      1048576,
+					MimeType:  "application/pdf",
+					Content:   "https://example.com/attachment/10001",
 				},
 				{
 					ID:       "10002",
@@ -115,10 +116,11 @@ func TestGetIssue(t *testing.T) {
 						DisplayName: "Person B",
 						AccountID:   "456",
 					},
-					Created:  "2020-12-02T15:30:00.000+0100",
-					Size:     524288,
-					MimeType: "image/png",
-					Content:  "https://example.com/attachment/10002",
+					Created:   "2020-12-02T15:30:00.000+0100",
+					CreatedAt: createdAt("2020-12-02T15:30:00.000+0100"),
+					Size:      524288,
+					MimeType:  "image/png",
+					Content:   "https://example.com/attachment/10002",
 				},
 			},
 		},
@@ -177,6 +179,54 @@ func TestGetIssueWithoutDescription(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestGetIssueFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/3/issue/TEST-1", r.URL.Path)
+		assert.Equal(t, "attachment,summary", r.URL.Query().Get("fields"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{
+			"key": "TEST-1",
+			"fields": {
+				"summary": "Bug summary",
+				"attachment": [{"id": "10001", "filename": "a.txt", "size": 12}]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	actual, err := client.GetIssueFields("TEST-1", []string{"attachment", "summary"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "TEST-1", actual.Key)
+	assert.Equal(t, "Bug summary", actual.Fields.Summary)
+	assert.Equal(t, []Attachment{{ID: "10001", Filename: "a.txt", Size: 12}}, actual.Fields.Attachments)
+	assert.Nil(t, actual.Fields.Description)
+	assert.Empty(t, actual.Fields.Comment.Comments)
+}
+
+func TestGetIssueFieldsV2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/issue/TEST-1", r.URL.Path)
+		assert.Equal(t, "status", r.URL.Query().Get("fields"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"key": "TEST-1", "fields": {"status": {"name": "Done"}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	actual, err := client.GetIssueFieldsV2("TEST-1", []string{"status"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Done", actual.Fields.Status.Name)
+	assert.Empty(t, actual.Fields.Attachments)
+}
+
 func TestGetIssueV2(t *testing.T) {
 	var unexpectedStatusCode bool
 
@@ -299,7 +349,33 @@ func TestGetIssueRaw(t *testing.T) {
     "watches": {
       "watchCount": 1,
       "isWatching": true
-    }
+    },
+    "attachment": [
+      {
+        "id": "10001",
+        "filename": "test-document.pdf",
+        "author": {
+          "displayName": "Person A",
+          "accountId": "123"
+        },
+        "created": "2020-12-01T10:00:00.000+0100",
+        "size": 1048576,
+        "mimeType": "application/pdf",
+        "content": "https://example.com/attachment/10001"
+      },
+      {
+        "id": "10002",
+        "filename": "screenshot.png",
+        "author": {
+          "displayName": "Person B",
+          "accountId": "456"
+        },
+        "created": "2020-12-02T15:30:00.000+0100",
+        "size": 524288,
+        "mimeType": "image/png",
+        "content": "https://example.com/attachment/10002"
+      }
+    ]
   }
 }
 `,
@@ -0,0 +1,385 @@
+package jiratest
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/jira"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestUploadDownloadDelete(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithCredentials("jane@example.com", "secret"), WithIssues("TEST-1"))
+	defer srv.Close()
+
+	client := srv.Client()
+
+	out, err := client.UploadAttachment("TEST-1", writeFile(t, "notes.txt", "hello, world"))
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	assert.Equal(t, "notes.txt", out[0].Filename)
+	assert.Equal(t, int64(12), out[0].Size)
+	assert.Equal(t, "text/plain", out[0].MimeType)
+	assert.Equal(t, "jane@example.com", out[0].Author.Name)
+
+	issue, err := client.GetIssueFields("TEST-1", []string{"attachment"})
+	require.NoError(t, err)
+	require.Len(t, issue.Fields.Attachments, 1)
+	assert.Equal(t, out[0].ID, issue.Fields.Attachments[0].ID)
+
+	dest := filepath.Join(t.TempDir(), "notes.txt")
+	res, err := client.DownloadAttachmentWithResult(out[0].Content, dest)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), res.Bytes)
+
+	b, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "hello, world", string(b))
+
+	require.NoError(t, client.DeleteAttachment(out[0].ID))
+	assert.Empty(t, srv.Attachments("TEST-1"))
+	_, ok := srv.Content(out[0].ID)
+	assert.False(t, ok)
+
+	err = client.DeleteAttachment(out[0].ID)
+	assert.Error(t, err)
+}
+
+func TestUnknownIssue(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	defer srv.Close()
+
+	_, err := srv.Client().UploadAttachment("TEST-404", writeFile(t, "a.txt", "a"))
+	assert.Error(t, err)
+
+	_, err = srv.Client().GetIssueFields("TEST-404", []string{"attachment"})
+	assert.Error(t, err)
+}
+
+func TestAuthentication(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithCredentials("jane@example.com", "secret"), WithIssues("TEST-1"))
+	defer srv.Close()
+
+	srv.AddAttachment("TEST-1", "a.txt", []byte("a"))
+
+	bad := jira.NewClient(jira.Config{Server: srv.URL, Login: "jane@example.com", APIToken: "wrong"})
+	_, err := bad.GetIssueFields("TEST-1", []string{"attachment"})
+	assert.Error(t, err)
+
+	dest := filepath.Join(t.TempDir(), "a.txt")
+	_, err = bad.DownloadAttachmentWithResult(srv.Attachments("TEST-1")[0].Content, dest)
+	assert.Error(t, err)
+
+	bearer := NewServer(WithCredentials("", "pat"), WithIssues("TEST-1"))
+	defer bearer.Close()
+
+	_, err = bearer.Client().GetIssueFields("TEST-1", []string{"attachment"})
+	assert.NoError(t, err)
+}
+
+func TestUploadLimit(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithUploadLimit(4), WithIssues("TEST-1"))
+	defer srv.Close()
+
+	_, err := srv.Client().UploadAttachment("TEST-1", writeFile(t, "big.txt", "too large"))
+
+	var ue *jira.ErrUnexpectedResponse
+	require.True(t, errors.As(err, &ue), "got %v", err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ue.StatusCode)
+	assert.Empty(t, srv.Attachments("TEST-1"))
+
+	res, err := http.Get(srv.URL + "/rest/api/3/attachment/meta")
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestUploadRequiresXSRFHeader(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"))
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/rest/api/3/issue/TEST-1/attachments", "multipart/form-data; boundary=x", http.NoBody)
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestFaultRateLimit(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"))
+	defer srv.Close()
+
+	srv.SetFaults(Faults{RateLimitEvery: 2})
+	client := srv.Client()
+
+	_, err := client.GetIssueFields("TEST-1", []string{"attachment"})
+	assert.NoError(t, err)
+
+	_, err = client.GetIssueFields("TEST-1", []string{"attachment"})
+	var ue *jira.ErrUnexpectedResponse
+	require.True(t, errors.As(err, &ue), "got %v", err)
+	assert.Equal(t, http.StatusTooManyRequests, ue.StatusCode)
+
+	_, err = client.GetIssueFields("TEST-1", []string{"attachment"})
+	assert.NoError(t, err)
+
+	res, err := http.Get(srv.URL + "/rest/api/3/attachment/meta")
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "1", res.Header.Get("Retry-After"))
+}
+
+func TestFaultTruncateDownloads(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"))
+	defer srv.Close()
+
+	a := srv.AddAttachment("TEST-1", "data.bin", []byte("0123456789"))
+	srv.SetFaults(Faults{TruncateDownloads: true})
+
+	dest := filepath.Join(t.TempDir(), "data.bin")
+	_, err := srv.Client().DownloadAttachmentWithResult(a.Content, dest)
+	assert.Error(t, err)
+
+	_, err = os.Stat(dest)
+	assert.True(t, os.IsNotExist(err), "partial download must be removed")
+
+	srv.SetFaults(Faults{})
+	_, err = srv.Client().DownloadAttachmentWithResult(a.Content, dest)
+	assert.NoError(t, err)
+}
+
+func TestFaultLoginPage(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"))
+	defer srv.Close()
+
+	a := srv.AddAttachment("TEST-1", "report.pdf", []byte("%PDF-1.4"))
+	srv.SetFaults(Faults{LoginPage: true})
+	client := srv.Client()
+
+	var authErr *jira.ErrAuthenticationRequired
+
+	_, err := client.DownloadAttachmentWithResult(
+		a.Content, filepath.Join(t.TempDir(), "report.pdf"), jira.ExpectContent(a.MimeType, a.Size),
+	)
+	require.True(t, errors.As(err, &authErr), "got %v", err)
+	assert.Equal(t, "Sign in - Example Corp SSO", authErr.Title)
+
+	_, err = client.UploadAttachment("TEST-1", writeFile(t, "a.txt", "a"))
+	assert.True(t, errors.As(err, &authErr), "got %v", err)
+
+	err = client.DeleteAttachment(a.ID)
+	assert.True(t, errors.As(err, &authErr), "got %v", err)
+	assert.Len(t, srv.Attachments("TEST-1"), 1)
+}
+
+func TestRequestsAreRecorded(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"))
+	defer srv.Close()
+
+	_, err := srv.Client().GetIssueFields("TEST-1", []string{"attachment", "summary"})
+	require.NoError(t, err)
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, http.MethodGet, reqs[0].Method)
+	assert.Equal(t, "/rest/api/3/issue/TEST-1", reqs[0].Path)
+	assert.Equal(t, "attachment,summary", reqs[0].Query.Get("fields"))
+}
+
+func TestFaultUnauthorizedAfter(t *testing.T) {
+	t.Parallel()
+
+	expiry := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	srv := NewServer(WithIssues("TEST-1"), WithTokenExpiry(expiry))
+	defer srv.Close()
+
+	srv.SetFaults(Faults{UnauthorizedAfter: 1})
+	client := srv.Client()
+
+	status, err := client.CheckToken()
+	require.NoError(t, err)
+	assert.True(t, expiry.Equal(status.ExpiresAt))
+
+	_, err = client.GetIssueFields("TEST-1", []string{"attachment"})
+	assert.True(t, jira.IsAuthFailure(err), "got %v", err)
+}
+
+func TestFaultOmitContentLength(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"))
+	defer srv.Close()
+
+	a := srv.AddAttachment("TEST-1", "data.bin", []byte("0123456789"))
+	srv.SetFaults(Faults{OmitContentLength: true})
+
+	res, err := srv.Client().DownloadAttachmentWithResult(a.Content, filepath.Join(t.TempDir(), "data.bin"))
+	require.NoError(t, err)
+	assert.False(t, res.TotalKnown)
+	assert.Equal(t, int64(10), res.Bytes)
+}
+
+func TestTransitions(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"), WithTransitions("In Progress", "Done"))
+	defer srv.Close()
+
+	client := srv.Client()
+
+	transitions, err := client.TransitionsV2("TEST-1")
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	assert.Equal(t, "Done", transitions[1].Name)
+
+	_, err = client.Transition("TEST-1", &jira.TransitionRequest{Transition: &jira.TransitionRequestData{ID: "2"}})
+	require.NoError(t, err)
+	assert.Len(t, srv.Transitions("TEST-1"), 1)
+
+	srv.SetFaults(Faults{FailTransitions: true})
+	code, err := client.Transition("TEST-1", &jira.TransitionRequest{Transition: &jira.TransitionRequestData{ID: "2"}})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Len(t, srv.Transitions("TEST-1"), 1)
+}
+
+func TestChangelog(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"))
+	defer srv.Close()
+
+	var entries []jira.Changelog
+	for i := range 150 {
+		entries = append(entries, jira.Changelog{ID: strconv.Itoa(i), Created: "2024-01-01T10:00:00.000+0000"})
+	}
+	srv.AddChangelog("TEST-1", entries...)
+
+	client := srv.Client()
+
+	got, err := client.GetIssueChangelog("TEST-1")
+	require.NoError(t, err)
+	assert.Len(t, got, 150)
+	assert.Equal(t, "149", got[149].ID)
+
+	var pages []string
+	for _, r := range srv.Requests() {
+		pages = append(pages, r.Query.Get("startAt"))
+	}
+	assert.Equal(t, []string{"0", "100"}, pages)
+
+	got, err = client.GetIssueChangelogV2("TEST-1")
+	require.NoError(t, err)
+	assert.Len(t, got, 150)
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1", "TEST-2", "TEST-10", "OTHER-1"))
+	defer srv.Close()
+
+	srv.AddAttachment("TEST-10", "a.txt", []byte("a"))
+	srv.AddIssue("TEST-3", "hidden")
+	srv.Forbid("TEST-3")
+
+	client := srv.Client()
+
+	page, err := client.SearchIssueAttachments("project = TEST", "", 2)
+	require.NoError(t, err)
+	assert.False(t, page.IsLast)
+	assert.Equal(t, "2", page.NextPageToken)
+	require.Len(t, page.Issues, 2)
+	assert.Equal(t, "TEST-1", page.Issues[0].Key)
+	assert.Equal(t, "TEST-2", page.Issues[1].Key)
+
+	page, err = client.SearchIssueAttachments("project = TEST", page.NextPageToken, 2)
+	require.NoError(t, err)
+	assert.True(t, page.IsLast)
+	require.Len(t, page.Issues, 1)
+	assert.Equal(t, "TEST-10", page.Issues[0].Key)
+	require.Len(t, page.Issues[0].Fields.Attachments, 1)
+	assert.Equal(t, "a.txt", page.Issues[0].Fields.Attachments[0].Filename)
+
+	page, err = client.SearchIssueAttachmentsV2(`project = "TEST"`, 1, 10)
+	require.NoError(t, err)
+	assert.Len(t, page.Issues, 2)
+
+	_, err = client.SearchIssueAttachments("status = Done", "", 10)
+	assert.Error(t, err)
+
+	page, err = client.SearchIssueKeys("project = TEST ORDER BY key ASC", "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Issues, 3)
+	assert.Equal(t, "TEST-10", page.Issues[2].Key)
+	assert.Empty(t, page.Issues[2].Fields.Attachments, "only keys are requested")
+}
+
+func TestExpandArchive(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(WithIssues("TEST-1"))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := range 35 {
+		f, err := zw.Create(fmt.Sprintf("dir/file-%02d.txt", i))
+		require.NoError(t, err)
+		_, _ = f.Write([]byte("hello"))
+	}
+	require.NoError(t, zw.Close())
+
+	archive := srv.AddAttachment("TEST-1", "bundle.zip", buf.Bytes())
+	text := srv.AddAttachment("TEST-1", "notes.txt", []byte("hello"))
+
+	client := srv.Client()
+
+	out, err := client.GetAttachmentHumanMetadata(archive.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "bundle.zip", out.Name)
+	assert.Equal(t, 35, out.TotalEntryCount)
+	require.Len(t, out.Entries, 30)
+	assert.Equal(t, "dir/file-01.txt", out.Entries[1].Name())
+	assert.Equal(t, "5 B", out.Entries[1].Size)
+
+	_, err = client.GetAttachmentHumanMetadata(text.ID)
+	assert.ErrorIs(t, err, jira.ErrNoResult)
+}
@@ -0,0 +1,123 @@
+package jira
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Verbosity levels of a Logger, each one includes the ones below.
+const (
+	// LogInfo logs a line per operation, eg: the requests sent and their status.
+	LogInfo = 1
+	// LogDetail adds timings and the retry and backoff decisions.
+	LogDetail = 2
+	// LogDump adds a dump of every request and response, with credentials redacted.
+	LogDump = 3
+)
+
+// logDumpMaxBody is the largest request body included in a dump.
+const logDumpMaxBody = 64 << 10
+
+// Logger receives the verbose output of a client, see WithLogger.
+type Logger interface {
+	// Enabled reports if messages of the level are logged.
+	Enabled(level int) bool
+	// Logf logs a message at the level, if enabled.
+	Logf(level int, format string, a ...any)
+}
+
+// WithLogger is a functional opt to log the requests of the client, the decisions it takes,
+// eg: to retry a request, and dumps of the requests and responses depending on the levels
+// enabled by l. Credentials are redacted from the dumps.
+func WithLogger(l Logger) ClientFunc {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// logs reports if the client logs messages of the level.
+func (c *Client) logs(level int) bool {
+	return c.logger != nil && c.logger.Enabled(level)
+}
+
+// logf logs a message at the level. Without a logger, the message is printed to stderr in
+// debug mode.
+func (c *Client) logf(level int, format string, a ...any) {
+	switch {
+	case c.logger != nil:
+		c.logger.Logf(level, format, a...)
+	case c.debug:
+		fmt.Fprintf(os.Stderr, format+"\n", a...)
+	}
+}
+
+var (
+	redactHeader   = regexp.MustCompile(`(?im)^((?:proxy-)?authorization|(?:set-)?cookie):[^\r\n]*`)
+	redactUserinfo = regexp.MustCompile(`(://)[^/@\s]+@`)
+)
+
+// Redact masks the credentials of a text, ie: the values of the authorization and cookie
+// headers of a dump and the user info of URLs.
+func Redact(s string) string {
+	s = redactHeader.ReplaceAllString(s, "$1: [REDACTED]")
+	return redactUserinfo.ReplaceAllString(s, "${1}[REDACTED]@")
+}
+
+// logTransport logs the requests sent through it, see WithLogger.
+type logTransport struct {
+	next   http.RoundTripper
+	logger Logger
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.logger.Enabled(LogDump) {
+		logDump(t.logger, "Request Details", dumpRequest(req))
+	}
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	took := time.Since(start).Round(time.Millisecond)
+
+	line := fmt.Sprintf("%s %s", req.Method, req.URL.Redacted())
+	switch {
+	case err != nil:
+		line += " failed: " + err.Error()
+	default:
+		line += " -> " + res.Status
+	}
+	if t.logger.Enabled(LogDetail) {
+		line += fmt.Sprintf(" (%s)", took)
+	}
+	t.logger.Logf(LogInfo, "%s", line)
+
+	if err == nil && t.logger.Enabled(LogDump) {
+		b, _ := httputil.DumpResponse(res, false)
+		logDump(t.logger, "Response Details", b)
+	}
+	return res, err
+}
+
+// logDump logs a dump at LogDump with the credentials redacted.
+func logDump(l Logger, heading string, data []byte) {
+	var buf bytes.Buffer
+	printDump(&buf, heading, data)
+	l.Logf(LogDump, "%s", Redact(buf.String()))
+}
+
+// dumpRequest dumps the request with its body if the body can be read again and is small,
+// eg: the json of a search. Streamed bodies, eg: of uploads, are left out.
+func dumpRequest(req *http.Request) []byte {
+	body := req.Body == nil || req.Body == http.NoBody ||
+		(req.GetBody != nil && req.ContentLength > 0 && req.ContentLength <= logDumpMaxBody)
+	b, err := httputil.DumpRequestOut(req, body)
+	if err != nil {
+		b, _ = httputil.DumpRequestOut(req, false)
+	}
+	return b
+}
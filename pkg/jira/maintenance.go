@@ -0,0 +1,200 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrMaintenance denotes that a request that would change attachments was rejected because
+// the instance is in maintenance or read-only mode, eg: during a Data Center upgrade.
+var ErrMaintenance = errors.New("jira: the instance is in maintenance/read-only mode")
+
+// MaintenanceError is a mutating attachment request rejected because the instance is in
+// maintenance or read-only mode. It matches ErrMaintenance with errors.Is.
+type MaintenanceError struct {
+	Method string
+	URL    string
+	// Reason is the message of the maintenance banner, if any.
+	Reason string
+	// Waited is set if the instance was still in maintenance once WaitForWritable gave up.
+	Waited time.Duration
+}
+
+func (e *MaintenanceError) Error() string {
+	msg := fmt.Sprintf("jira: %s %s rejected, Jira is in maintenance/read-only mode", e.Method, e.URL)
+	if e.Waited > 0 {
+		msg = fmt.Sprintf("jira: still in maintenance/read-only mode after waiting %s", e.Waited)
+	}
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Reason)
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrMaintenance) report true.
+func (e *MaintenanceError) Is(target error) bool {
+	return target == ErrMaintenance
+}
+
+const (
+	// statePath is the status endpoint of Jira, it reports the state of the node,
+	// eg: {"state":"RUNNING"}, without authentication.
+	statePath = "/status"
+
+	// stateRunning is the state of a node that serves every request.
+	stateRunning = "RUNNING"
+
+	// maintenanceMaxBackoff caps the wait between two polls of WaitForWritable.
+	maintenanceMaxBackoff = 30 * time.Second
+)
+
+// maintenancePattern matches the messages of the maintenance banner, eg: "Jira is in
+// read-only mode while it is being upgraded" or "This instance is undergoing maintenance".
+var maintenancePattern = regexp.MustCompile(`(?i)\bmaintenance\b|\bread[- ]only\s+mode\b`)
+
+// isMaintenanceResponse reports if a response is the 503 an instance in maintenance or
+// read-only mode responds to changes with, and returns the message of its banner. Either
+// a maintenance header or a banner in the body is required, a 503 of an overloaded
+// server or of a proxy is not mistaken for maintenance.
+func isMaintenanceResponse(status int, header http.Header, body []byte) (string, bool) {
+	if status != http.StatusServiceUnavailable {
+		return "", false
+	}
+
+	msg := maintenanceMessage(header, body)
+	for name := range header {
+		if strings.Contains(strings.ToLower(name), "maintenance") {
+			return msg, true
+		}
+	}
+	var state struct {
+		State string `json:"state"`
+	}
+	if json.Unmarshal(body, &state) == nil && strings.EqualFold(state.State, "MAINTENANCE") {
+		return msg, true
+	}
+	return msg, msg != "" && maintenancePattern.MatchString(msg)
+}
+
+// maintenanceMessage extracts the message of a maintenance banner, in the shape of the
+// usual JSON errors or of {"state": "MAINTENANCE", "message": "..."}. A page that isn't
+// JSON, eg: of a proxy, is only kept if it is short.
+func maintenanceMessage(header http.Header, body []byte) string {
+	if json.Valid(body) {
+		return forbiddenMessage(body)
+	}
+	if isHTML(header, body) {
+		return pageTitle(body)
+	}
+	if msg := strings.TrimSpace(string(body)); len(msg) <= 200 {
+		return msg
+	}
+	return ""
+}
+
+// maintenanceTransport turns the 503 of a mutating attachment request caused by maintenance
+// into a MaintenanceError. It is an error rather than a response, so the request is not
+// retried. Requests that only read, like listing and downloading attachments, are passed
+// through as they may still work.
+type maintenanceTransport struct {
+	next http.RoundTripper
+}
+
+// maintenanceGuard wraps a transport of the client with maintenanceTransport.
+func (c *Client) maintenanceGuard(next http.RoundTripper) http.RoundTripper {
+	return maintenanceTransport{next: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t maintenanceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusServiceUnavailable || !isMutatingAttachmentRequest(req) {
+		return res, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, scopeBodyLimit))
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return res, nil
+	}
+	reason, ok := isMaintenanceResponse(res.StatusCode, res.Header, body)
+	if !ok {
+		return res, nil
+	}
+	return nil, &MaintenanceError{Method: req.Method, URL: req.URL.Redacted(), Reason: reason}
+}
+
+// Writable reports if the instance accepts changes, as per the state of its status
+// endpoint. The message of the maintenance banner is returned if it doesn't.
+func (c *Client) Writable(ctx context.Context) (bool, string, error) {
+	res, err := c.request(ctx, http.MethodGet, c.server+statePath, nil, Header{"Accept": "application/json"})
+	if err != nil {
+		return false, "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, scopeBodyLimit))
+	if err != nil {
+		return false, "", err
+	}
+	if reason, ok := isMaintenanceResponse(res.StatusCode, res.Header, body); ok {
+		return false, reason, nil
+	}
+
+	var out struct {
+		State string `json:"state"`
+	}
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		// Jira cloud, or a proxy, doesn't expose the status endpoint, there is no
+		// maintenance mode to wait for.
+		return true, "", nil
+	case json.Unmarshal(body, &out) == nil && out.State != "":
+		return strings.EqualFold(out.State, stateRunning), out.State, nil
+	case res.StatusCode == http.StatusOK:
+		return true, "", nil
+	}
+	return false, res.Status, nil
+}
+
+// WaitForWritable polls the status endpoint of the instance until it accepts changes, with
+// an exponential backoff, or returns a MaintenanceError once the timeout passes. It gives
+// up early if ctx is done. A request that fails is treated as the instance not being
+// writable yet, as a node restarting after an upgrade refuses connections for a while.
+func (c *Client) WaitForWritable(ctx context.Context, timeout time.Duration) error {
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	wait := c.retryBackoff
+	reason := ""
+	for {
+		ok, msg, err := c.Writable(pollCtx)
+		if ok {
+			return nil
+		}
+		if err == nil {
+			reason = msg
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-pollCtx.Done():
+			t.Stop()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return &MaintenanceError{Method: http.MethodGet, URL: c.server + statePath, Reason: reason, Waited: timeout}
+		}
+		wait = min(wait*2, maintenanceMaxBackoff)
+	}
+}
@@ -0,0 +1,211 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsMaintenanceResponse(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       string
+		wantReason string
+		want       bool
+	}{
+		{
+			name:       "read-only banner",
+			status:     http.StatusServiceUnavailable,
+			body:       `{"errorMessages":["Jira is in read-only mode while it is being upgraded."]}`,
+			wantReason: "Jira is in read-only mode while it is being upgraded.",
+			want:       true,
+		},
+		{
+			name:       "maintenance message",
+			status:     http.StatusServiceUnavailable,
+			body:       `{"message":"This instance is undergoing maintenance"}`,
+			wantReason: "This instance is undergoing maintenance",
+			want:       true,
+		},
+		{
+			name:   "status endpoint",
+			status: http.StatusServiceUnavailable,
+			body:   `{"state":"MAINTENANCE"}`,
+			want:   true,
+		},
+		{
+			name:   "maintenance header",
+			status: http.StatusServiceUnavailable,
+			header: http.Header{"X-Jira-Maintenance": {"read-only"}},
+			body:   "<html><head><title>Service Unavailable</title></head></html>",
+			// The title of the page is kept as the reason.
+			wantReason: "Service Unavailable",
+			want:       true,
+		},
+		{
+			name:       "overloaded server",
+			status:     http.StatusServiceUnavailable,
+			body:       `{"errorMessages":["Service temporarily unavailable"]}`,
+			wantReason: "Service temporarily unavailable",
+		},
+		{
+			name:       "proxy page",
+			status:     http.StatusServiceUnavailable,
+			body:       "<html><head><title>503 Service Temporarily Unavailable</title></head></html>",
+			wantReason: "503 Service Temporarily Unavailable",
+		},
+		{
+			name:   "not a 503",
+			status: http.StatusInternalServerError,
+			body:   `{"errorMessages":["Jira is in read-only mode"]}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			reason, ok := isMaintenanceResponse(tc.status, tc.header, []byte(tc.body))
+			assert.Equal(t, tc.want, ok)
+			assert.Equal(t, tc.wantReason, reason)
+		})
+	}
+}
+
+// maintenanceServer is a server in read-only mode for the first polls of its status
+// endpoint: it rejects changes with the maintenance banner until then, and serves reads
+// all along. It returns the number of requests it received by method, the polls are
+// counted as "GET /status".
+func maintenanceServer(t *testing.T, polls int) (*httptest.Server, func(key string) int) {
+	t.Helper()
+
+	var (
+		mu     sync.Mutex
+		counts = make(map[string]int)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		key := r.Method
+		if r.URL.Path == "/status" {
+			key += " /status"
+		}
+		counts[key]++
+		maintenance := counts["GET /status"] <= polls
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/status" && maintenance:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"state":"MAINTENANCE"}`))
+		case r.URL.Path == "/status":
+			_, _ = w.Write([]byte(`{"state":"RUNNING"}`))
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"id":"10001","filename":"report.pdf"}`))
+		case maintenance:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"errorMessages":["Jira is in read-only mode while it is being upgraded."]}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func(key string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[key]
+	}
+}
+
+func TestMaintenanceIsNotRetried(t *testing.T) {
+	t.Parallel()
+
+	server, count := maintenanceServer(t, 1)
+	client := NewClient(Config{Server: server.URL, Login: "user", APIToken: "token"}, WithTimeout(3*time.Second))
+	client.retryBackoff = time.Millisecond
+
+	err := client.DeleteAttachment("10001")
+	var mntErr *MaintenanceError
+	require.ErrorAs(t, err, &mntErr)
+	assert.True(t, errors.Is(err, ErrMaintenance))
+	assert.Equal(t, "Jira is in read-only mode while it is being upgraded.", mntErr.Reason)
+	assert.Equal(t, 1, count(http.MethodDelete), "the change is not retried")
+
+	// Reading still works.
+	a, err := client.GetAttachment("10001")
+	require.NoError(t, err)
+	assert.Equal(t, "report.pdf", a.Filename)
+}
+
+func TestWaitForWritable(t *testing.T) {
+	t.Parallel()
+
+	server, count := maintenanceServer(t, 3)
+	client := NewClient(Config{Server: server.URL, Login: "user", APIToken: "token"}, WithTimeout(3*time.Second))
+	client.retryBackoff = 10 * time.Millisecond
+
+	ok, reason, err := client.Writable(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, reason)
+	assert.Error(t, client.DeleteAttachment("10001"))
+
+	start := time.Now()
+	require.NoError(t, client.WaitForWritable(context.Background(), 5*time.Second))
+	// Polled twice more, 10ms and then 20ms apart.
+	assert.Equal(t, 4, count("GET /status"))
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	require.NoError(t, client.DeleteAttachment("10001"))
+	assert.Equal(t, 2, count(http.MethodDelete))
+}
+
+func TestWaitForWritableGivesUp(t *testing.T) {
+	t.Parallel()
+
+	server, count := maintenanceServer(t, 1000)
+	client := NewClient(Config{Server: server.URL, Login: "user", APIToken: "token"}, WithTimeout(3*time.Second))
+	client.retryBackoff = 10 * time.Millisecond
+
+	start := time.Now()
+	err := client.WaitForWritable(context.Background(), 100*time.Millisecond)
+	var mntErr *MaintenanceError
+	require.ErrorAs(t, err, &mntErr)
+	assert.Equal(t, 100*time.Millisecond, mntErr.Waited)
+	assert.EqualError(t, err, "jira: still in maintenance/read-only mode after waiting 100ms")
+	assert.Less(t, time.Since(start), time.Second)
+	// The backoff doubles: polled at 0, 10, 30 and 70ms, fewer times on a slow machine.
+	assert.LessOrEqual(t, count("GET /status"), 4)
+	assert.GreaterOrEqual(t, count("GET /status"), 2)
+
+	// A cancelled context stops the wait before the deadline.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	err = client.WaitForWritable(ctx, time.Hour)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWritableWithoutStatusEndpoint(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	// Jira cloud doesn't expose the status endpoint, there is nothing to wait for.
+	client := NewClient(Config{Server: server.URL, Login: "user", APIToken: "token"}, WithTimeout(3*time.Second))
+	require.NoError(t, client.WaitForWritable(context.Background(), time.Second))
+}
@@ -33,3 +33,55 @@ func (c *Client) Me() (*Me, error) {
 
 	return &me, err
 }
+
+// Myself fetches the current user from v3 /myself endpoint. The user
+// is cached by the client so repeated calls don't hit the server.
+func (c *Client) Myself() (*User, error) {
+	return c.myself(apiVersion3)
+}
+
+// MyselfV2 fetches the current user from v2 /myself endpoint. The user
+// is cached by the client so repeated calls don't hit the server.
+func (c *Client) MyselfV2() (*User, error) {
+	return c.myself(apiVersion2)
+}
+
+func (c *Client) myself(ver string) (*User, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.myselfCache != nil {
+		return c.myselfCache, nil
+	}
+
+	var (
+		res *http.Response
+		err error
+	)
+
+	switch ver {
+	case apiVersion2:
+		res, err = c.GetV2(context.Background(), "/myself", nil)
+	default:
+		res, err = c.Get(context.Background(), "/myself", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, formatUnexpectedResponse(res)
+	}
+
+	var u User
+	if err := json.NewDecoder(res.Body).Decode(&u); err != nil {
+		return nil, err
+	}
+
+	c.myselfCache = &u
+	return c.myselfCache, nil
+}
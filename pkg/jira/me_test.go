@@ -45,3 +45,42 @@ func TestMe(t *testing.T) {
 	_, err = client.Me()
 	assert.Error(t, &ErrUnexpectedResponse{}, err)
 }
+
+func TestMyself(t *testing.T) {
+	var calls int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/3/myself", r.URL.Path)
+		calls++
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"accountId": "a-123", "displayName": "Person A", "emailAddress": "user@test.com"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	first, err := client.Myself()
+	assert.NoError(t, err)
+	assert.Equal(t, &User{AccountID: "a-123", DisplayName: "Person A", Email: "user@test.com"}, first)
+
+	second, err := client.Myself()
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMyselfV2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/myself", r.URL.Path)
+
+		w.WriteHeader(400)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	_, err := client.MyselfV2()
+	assert.Error(t, err)
+}
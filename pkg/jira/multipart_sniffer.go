@@ -0,0 +1,205 @@
+package jira
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// debugPartPreview is the number of bytes of each part body shown in debug dumps.
+	debugPartPreview = 256
+	// maxPartHeaderSize caps the headers captured for a part.
+	maxPartHeaderSize = 4 << 10
+
+	crlf = '\r'<<8 | '\n'
+)
+
+type snifferState int
+
+const (
+	sniffPreamble snifferState = iota
+	sniffDelimiter
+	sniffHeaders
+	sniffBody
+	sniffEpilogue
+)
+
+// sniffedPart is what the sniffer captured of a multipart part.
+type sniffedPart struct {
+	header  []byte
+	preview []byte
+	size    int64
+	done    bool
+}
+
+// multipartSniffer passes a multipart body through unchanged while capturing the part
+// headers and the first bytes of each part for debug output. Memory use is bounded by
+// the caps no matter how large the body is, so a streaming upload can be dumped
+// without buffering it.
+//
+// Parts are delimited by "\r\n--" followed by the boundary. The body is assumed to start
+// with the first delimiter, as written by multipart.Writer, without a preamble.
+type multipartSniffer struct {
+	r        io.Reader
+	delim    []byte
+	cap      int
+	state    snifferState
+	matched  int
+	trailer  []byte
+	last4    uint32
+	parts    []*sniffedPart
+	complete bool
+}
+
+func newMultipartSniffer(r io.Reader, boundary string, partCap int) *multipartSniffer {
+	return &multipartSniffer{
+		r:     r,
+		delim: []byte("\r\n--" + boundary),
+		cap:   partCap,
+		// The first delimiter isn't preceded by a line break.
+		matched: 2,
+	}
+}
+
+func (s *multipartSniffer) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	for _, b := range p[:n] {
+		s.feed(b)
+	}
+	return n, err
+}
+
+// feed advances the state machine by one byte.
+func (s *multipartSniffer) feed(b byte) {
+	switch s.state {
+	case sniffPreamble, sniffBody:
+		s.body(b)
+	case sniffDelimiter:
+		// Two bytes follow a delimiter: "--" for the final one, else a line break.
+		s.trailer = append(s.trailer, b)
+		if len(s.trailer) < 2 {
+			return
+		}
+		if string(s.trailer) == "--" {
+			s.state, s.complete = sniffEpilogue, true
+			return
+		}
+		s.parts = append(s.parts, &sniffedPart{})
+		// The line break ending the delimiter counts towards the blank line of a part without headers.
+		s.last4, s.state = crlf, sniffHeaders
+	case sniffHeaders:
+		part := s.parts[len(s.parts)-1]
+		if len(part.header) < maxPartHeaderSize {
+			part.header = append(part.header, b)
+		}
+		if s.last4 = s.last4<<8 | uint32(b); s.last4 == crlf<<16|crlf {
+			s.state = sniffBody
+		}
+	case sniffEpilogue:
+	}
+}
+
+func (s *multipartSniffer) body(b byte) {
+	var part *sniffedPart
+	if s.state == sniffBody {
+		part = s.parts[len(s.parts)-1]
+		part.size++
+		if len(part.preview) < s.cap {
+			part.preview = append(part.preview, b)
+		}
+	}
+
+	// The first byte of the delimiter doesn't appear in the rest of it,
+	// so a failed match can only restart at the current byte.
+	switch {
+	case b == s.delim[s.matched]:
+		s.matched++
+	case b == s.delim[0]:
+		s.matched = 1
+	default:
+		s.matched = 0
+	}
+	if s.matched < len(s.delim) {
+		return
+	}
+
+	if part != nil {
+		part.size -= int64(len(s.delim))
+		if int64(len(part.preview)) > part.size {
+			part.preview = part.preview[:part.size]
+		}
+		part.done = true
+	}
+	s.matched, s.trailer, s.state = 0, s.trailer[:0], sniffDelimiter
+}
+
+// Dump renders the captured headers and previews. Text parts are shown as is and binary
+// parts as a hex dump, followed by a marker with the number of bytes left out.
+func (s *multipartSniffer) Dump() string {
+	var b strings.Builder
+
+	boundary := string(s.delim[2:])
+	for _, part := range s.parts {
+		b.WriteString(boundary + "\r\n")
+		b.Write(part.header)
+
+		if isPrintable(part.preview) {
+			b.Write(part.preview)
+			b.WriteString("\n")
+		} else {
+			b.WriteString(hex.Dump(part.preview))
+		}
+
+		if left := part.size - int64(len(part.preview)); left > 0 {
+			fmt.Fprintf(&b, "... [%d more bytes not shown, part size %d bytes]\n", left, part.size)
+		}
+		if !part.done {
+			b.WriteString("... [incomplete part]\n")
+		}
+	}
+	if s.complete {
+		b.WriteString(boundary + "--\n")
+	}
+	return b.String()
+}
+
+// dumpMultipart prints a debug dump of a multipart request and its response. The request
+// body is rendered from what the sniffer captured while the body was sent.
+func dumpMultipart(w io.Writer, req *http.Request, res *http.Response, sniffer *multipartSniffer) {
+	reqDump, _ := httputil.DumpRequest(req, false)
+	printDump(w, "Request Details", append(reqDump, sniffer.Dump()...))
+
+	if res != nil {
+		respDump, _ := httputil.DumpResponse(res, false)
+		printDump(w, "Response Details", respDump)
+	}
+}
+
+func (c *Client) debugWriter() io.Writer {
+	if c.debugOut != nil {
+		return c.debugOut
+	}
+	return os.Stdout
+}
+
+// isPrintable reports if the bytes look like text. A rune cut by the preview cap is ignored.
+func isPrintable(b []byte) bool {
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 {
+			return len(b) < utf8.UTFMax && !utf8.FullRune(b)
+		}
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+		b = b[size:]
+	}
+	return true
+}
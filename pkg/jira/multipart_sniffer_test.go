@@ -0,0 +1,227 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildMultipart(t *testing.T, parts ...func(*multipart.Writer) error) ([]byte, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		require.NoError(t, p(w))
+	}
+	require.NoError(t, w.Close())
+	return body.Bytes(), w.Boundary()
+}
+
+func filePart(name string, content []byte) func(*multipart.Writer) error {
+	return func(w *multipart.Writer) error {
+		part, err := w.CreateFormFile("file", name)
+		if err != nil {
+			return err
+		}
+		_, err = part.Write(content)
+		return err
+	}
+}
+
+func TestMultipartSniffer(t *testing.T) {
+	t.Parallel()
+
+	binary := make([]byte, 1000)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+
+	body, boundary := buildMultipart(t,
+		func(w *multipart.Writer) error { return w.WriteField("comment", "hello") },
+		filePart("notes.txt", []byte(strings.Repeat("line of text\n", 10))),
+		filePart("data.bin", binary),
+	)
+
+	for name, wrap := range map[string]func(io.Reader) io.Reader{
+		"whole reads":    func(r io.Reader) io.Reader { return r },
+		"one byte reads": iotest.OneByteReader,
+	} {
+		wrap := wrap
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newMultipartSniffer(wrap(bytes.NewReader(body)), boundary, 16)
+			out, err := io.ReadAll(s)
+			require.NoError(t, err)
+			assert.Equal(t, body, out, "the body must pass through unchanged")
+
+			require.Len(t, s.parts, 3)
+			assert.Equal(t, int64(5), s.parts[0].size)
+			assert.Equal(t, int64(130), s.parts[1].size)
+			assert.Equal(t, int64(1000), s.parts[2].size)
+
+			dump := s.Dump()
+			assert.Contains(t, dump, "--"+boundary+"\r\n"+`Content-Disposition: form-data; name="comment"`)
+			assert.Contains(t, dump, "\r\n\r\nhello\n")
+			assert.NotContains(t, dump, "hello\n... [")
+			assert.Contains(t, dump, `filename="notes.txt"`)
+			assert.Contains(t, dump, "line of text\nlin\n... [114 more bytes not shown, part size 130 bytes]\n")
+			assert.Contains(t, dump, "00000000  00 01 02 03 04 05 06 07  08 09 0a 0b 0c 0d 0e 0f  |................|\n")
+			assert.Contains(t, dump, "... [984 more bytes not shown, part size 1000 bytes]\n")
+			assert.True(t, strings.HasSuffix(dump, "--"+boundary+"--\n"))
+		})
+	}
+}
+
+func TestMultipartSnifferNearBoundary(t *testing.T) {
+	t.Parallel()
+
+	// Content resembling the start of a delimiter must not end the part.
+	content := []byte("a\r\n-b\r\n--c\r\n\r\n--")
+	body, boundary := buildMultipart(t, filePart("tricky.txt", content))
+
+	s := newMultipartSniffer(iotest.OneByteReader(bytes.NewReader(body)), boundary, 64)
+	_, err := io.ReadAll(s)
+	require.NoError(t, err)
+
+	require.Len(t, s.parts, 1)
+	assert.True(t, s.parts[0].done)
+	assert.Equal(t, int64(len(content)), s.parts[0].size)
+	assert.Equal(t, content, s.parts[0].preview)
+	assert.True(t, s.complete)
+}
+
+func TestMultipartSnifferIncomplete(t *testing.T) {
+	t.Parallel()
+
+	body, boundary := buildMultipart(t, filePart("a.txt", []byte(strings.Repeat("a", 100))))
+
+	s := newMultipartSniffer(bytes.NewReader(body[:len(body)/2]), boundary, 8)
+	_, err := io.ReadAll(s)
+	require.NoError(t, err)
+
+	dump := s.Dump()
+	assert.Contains(t, dump, "... [incomplete part]")
+	assert.NotContains(t, dump, "--"+boundary+"--")
+}
+
+func TestIsPrintable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isPrintable([]byte("plain text\r\n\twith tabs")))
+	assert.True(t, isPrintable([]byte("Übersicht")))
+	assert.True(t, isPrintable([]byte("cut \xc3")))
+	assert.False(t, isPrintable([]byte{0x89, 'P', 'N', 'G'}))
+	assert.False(t, isPrintable([]byte("nul\x00")))
+}
+
+type patternReader struct {
+	left int64
+}
+
+func (r *patternReader) Read(p []byte) (int, error) {
+	if r.left <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.left {
+		p = p[:r.left]
+	}
+	for i := range p {
+		p[i] = byte(i % 251)
+	}
+	r.left -= int64(len(p))
+	return len(p), nil
+}
+
+func TestPostReaderDebugDumpStreams(t *testing.T) {
+	const size = 50 << 20
+
+	var received int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var dump bytes.Buffer
+	client := NewClient(Config{Server: server.URL, Debug: true}, WithTimeout(10*time.Second))
+	client.debugOut = &dump
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		part, err := mw.CreateFormFile("file", "big.bin")
+		if err == nil {
+			_, err = io.Copy(part, &patternReader{left: size})
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	res, err := client.postReader(context.Background(), server.URL+"/upload", pr, Header{
+		"Content-Type":      mw.FormDataContentType(),
+		"X-Atlassian-Token": "no-check",
+	})
+	require.NoError(t, err)
+	_ = res.Body.Close()
+
+	runtime.ReadMemStats(&after)
+
+	assert.Greater(t, received, int64(size))
+	// Buffering the body, for the request or for the dump, would allocate at least its size.
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(size/4))
+
+	out := dump.String()
+	assert.Contains(t, out, "REQUEST DETAILS")
+	assert.Contains(t, out, "X-Atlassian-Token: no-check")
+	assert.Contains(t, out, `Content-Disposition: form-data; name="file"; filename="big.bin"`)
+	assert.Contains(t, out, "00000000  00 01 02 03")
+	assert.Contains(t, out, "more bytes not shown, part size 52428800 bytes]")
+	assert.Contains(t, out, "RESPONSE DETAILS")
+	assert.Less(t, dump.Len(), 8<<10)
+}
+
+func TestUploadAttachmentDebugDump(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, header, err := r.FormFile("file")
+		assert.NoError(t, err)
+		_, _ = w.Write([]byte(`[{"id": "1", "filename": "` + header.Filename + `"}]`))
+	}))
+	defer server.Close()
+
+	var dump bytes.Buffer
+	client := NewClient(Config{Server: server.URL, Debug: true}, WithTimeout(3*time.Second))
+	client.debugOut = &dump
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(path, []byte("quarterly numbers"), 0o600))
+
+	_, err := client.UploadAttachment("TEST-1", path)
+	require.NoError(t, err)
+
+	assert.Contains(t, dump.String(), `filename="report.txt"`)
+	assert.Contains(t, dump.String(), "quarterly numbers\n")
+}
@@ -0,0 +1,84 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Attachment related permission keys of GET /mypermissions.
+const (
+	PermissionBrowseProjects       = "BROWSE_PROJECTS"
+	PermissionCreateAttachments    = "CREATE_ATTACHMENTS"
+	PermissionDeleteOwnAttachments = "DELETE_OWN_ATTACHMENTS"
+	PermissionDeleteAllAttachments = "DELETE_ALL_ATTACHMENTS"
+)
+
+// Permission is a permission of the current user.
+type Permission struct {
+	Key            string `json:"key"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	HavePermission bool   `json:"havePermission"`
+}
+
+// MyPermissionsOptions are the options of a GET /mypermissions request.
+type MyPermissionsOptions struct {
+	// Permissions are the keys of the permissions to check.
+	Permissions []string
+	// IssueKey checks the permissions in the context of the issue, if set.
+	IssueKey string
+}
+
+// MyPermissions fetches the permissions of the current user using v3 version of the
+// GET /mypermissions endpoint. The result is keyed by permission key.
+func (c *Client) MyPermissions(opts MyPermissionsOptions) (map[string]Permission, error) {
+	return c.myPermissions(opts, apiVersion3)
+}
+
+// MyPermissionsV2 fetches the permissions of the current user using v2 version of the
+// GET /mypermissions endpoint. The result is keyed by permission key.
+func (c *Client) MyPermissionsV2(opts MyPermissionsOptions) (map[string]Permission, error) {
+	return c.myPermissions(opts, apiVersion2)
+}
+
+func (c *Client) myPermissions(opts MyPermissionsOptions, ver string) (map[string]Permission, error) {
+	q := url.Values{}
+	q.Set("permissions", strings.Join(opts.Permissions, ","))
+	if opts.IssueKey != "" {
+		q.Set("issueKey", opts.IssueKey)
+	}
+	path := "/mypermissions?" + q.Encode()
+
+	var (
+		res *http.Response
+		err error
+	)
+	switch ver {
+	case apiVersion2:
+		res, err = c.GetV2(context.Background(), path, nil)
+	default:
+		res, err = c.Get(context.Background(), path, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, formatUnexpectedResponse(res)
+	}
+
+	var out struct {
+		Permissions map[string]Permission `json:"permissions"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out.Permissions, nil
+}
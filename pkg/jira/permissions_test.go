@@ -0,0 +1,39 @@
+package jira
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMyPermissions(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/mypermissions", r.URL.Path)
+		assert.Equal(t, "CREATE_ATTACHMENTS,DELETE_OWN_ATTACHMENTS", r.URL.Query().Get("permissions"))
+		assert.Equal(t, "TEST-1", r.URL.Query().Get("issueKey"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"permissions":{
+			"CREATE_ATTACHMENTS":{"id":"19","key":"CREATE_ATTACHMENTS","name":"Create attachments","type":"PROJECT","havePermission":true},
+			"DELETE_OWN_ATTACHMENTS":{"id":"39","key":"DELETE_OWN_ATTACHMENTS","name":"Delete own attachments","type":"PROJECT","havePermission":false}
+		}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	perms, err := client.MyPermissionsV2(MyPermissionsOptions{
+		Permissions: []string{PermissionCreateAttachments, PermissionDeleteOwnAttachments},
+		IssueKey:    "TEST-1",
+	})
+	require.NoError(t, err)
+	assert.True(t, perms[PermissionCreateAttachments].HavePermission)
+	assert.Equal(t, "Create attachments", perms[PermissionCreateAttachments].Name)
+	assert.False(t, perms[PermissionDeleteOwnAttachments].HavePermission)
+}
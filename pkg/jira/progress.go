@@ -0,0 +1,109 @@
+package jira
+
+import (
+	"io"
+	"sync"
+)
+
+// ProgressFunc receives the number of bytes of a transfer done so far and its total size,
+// -1 if the size isn't known. It is called after every read or write of the transfer, the
+// calls of a transfer split in concurrent parts are serialized.
+type ProgressFunc func(done, total int64)
+
+// progressCounter adds up the bytes of a transfer, possibly from several goroutines, and
+// reports the sum.
+type progressCounter struct {
+	mu    sync.Mutex
+	done  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func newProgressCounter(total int64, fn ProgressFunc) *progressCounter {
+	if total <= 0 {
+		total = -1
+	}
+	return &progressCounter{total: total, fn: fn}
+}
+
+func (c *progressCounter) add(n int) {
+	if n <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.done += int64(n)
+	c.fn(c.done, c.total)
+}
+
+// ProgressReader reports the bytes read from the underlying reader.
+type ProgressReader struct {
+	r io.Reader
+	c *progressCounter
+}
+
+// NewProgressReader returns a reader that calls fn with the cumulative number of bytes
+// read from r. A total of 0 or less is reported as -1, unknown.
+func NewProgressReader(r io.Reader, total int64, fn ProgressFunc) *ProgressReader {
+	return &ProgressReader{r: r, c: newProgressCounter(total, fn)}
+}
+
+// Read implements io.Reader.
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.c.add(n)
+	return n, err
+}
+
+// ProgressWriter reports the bytes written to the underlying writer.
+type ProgressWriter struct {
+	w io.Writer
+	c *progressCounter
+}
+
+// NewProgressWriter returns a writer that calls fn with the cumulative number of bytes
+// written to w. A total of 0 or less is reported as -1, unknown.
+func NewProgressWriter(w io.Writer, total int64, fn ProgressFunc) *ProgressWriter {
+	return &ProgressWriter{w: w, c: newProgressCounter(total, fn)}
+}
+
+// Write implements io.Writer.
+func (w *ProgressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.c.add(n)
+	return n, err
+}
+
+// WithProgress reports the bytes received by a download to fn, the total is the
+// Content-Length of the response. A download that is retried from the start, eg: when
+// the server stops honouring ranges, reports from 0 again.
+func WithProgress(fn ProgressFunc) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+	}
+}
+
+type uploadOptions struct {
+	progress ProgressFunc
+}
+
+// UploadOption is a functional option for attachment uploads.
+type UploadOption func(*uploadOptions)
+
+// WithUploadProgress reports the bytes of the file sent by an upload to fn, the total is
+// the size of the file. An upload that is retried reports from 0 again.
+func WithUploadProgress(fn ProgressFunc) UploadOption {
+	return func(o *uploadOptions) {
+		o.progress = fn
+	}
+}
+
+func newUploadOptions(opts []UploadOption) uploadOptions {
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,159 @@
+package jira
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressRecorder records the calls of a ProgressFunc.
+type progressRecorder struct {
+	mu     sync.Mutex
+	done   []int64
+	totals []int64
+}
+
+func (r *progressRecorder) update(done, total int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.done = append(r.done, done)
+	r.totals = append(r.totals, total)
+}
+
+// assertCumulative checks that the bytes reported only grow, up to want, with the given total.
+func (r *progressRecorder) assertCumulative(t *testing.T, want, total int64) {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	require.NotEmpty(t, r.done)
+	for i := 1; i < len(r.done); i++ {
+		assert.Greater(t, r.done[i], r.done[i-1], "call %d", i)
+	}
+	assert.Equal(t, want, r.done[len(r.done)-1])
+	for _, got := range r.totals {
+		assert.Equal(t, total, got)
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	t.Parallel()
+
+	var rec progressRecorder
+	r := NewProgressReader(iotest.OneByteReader(strings.NewReader("hello")), 5, rec.update)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, rec.done)
+	rec.assertCumulative(t, 5, 5)
+
+	// An empty read isn't reported and an unknown total is -1.
+	var unknown progressRecorder
+	_, err = io.ReadAll(NewProgressReader(strings.NewReader(""), 0, unknown.update))
+	require.NoError(t, err)
+	assert.Empty(t, unknown.done)
+
+	_, err = io.ReadAll(NewProgressReader(strings.NewReader("abc"), 0, unknown.update))
+	require.NoError(t, err)
+	unknown.assertCumulative(t, 3, -1)
+}
+
+func TestProgressWriter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		rec progressRecorder
+		buf bytes.Buffer
+	)
+	w := NewProgressWriter(&buf, 10, rec.update)
+	for _, s := range []string{"0123", "456", "789"} {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "0123456789", buf.String())
+	assert.Equal(t, []int64{4, 7, 10}, rec.done)
+	rec.assertCumulative(t, 10, 10)
+}
+
+func TestDownloadAttachmentProgress(t *testing.T) {
+	t.Parallel()
+
+	s := newRangeServer(t, 1<<20+3)
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	client := NewClient(Config{Server: server.URL}, WithTimeout(5*time.Second))
+	size := int64(len(s.content))
+
+	t.Run("stream", func(t *testing.T) {
+		t.Parallel()
+
+		var rec progressRecorder
+		dest := filepath.Join(t.TempDir(), "big.bin")
+		res, err := client.DownloadAttachmentWithResult(server.URL+"/big.bin", dest, WithProgress(rec.update))
+		require.NoError(t, err)
+		assert.Equal(t, size, res.Bytes)
+		rec.assertCumulative(t, size, size)
+	})
+
+	t.Run("ranges", func(t *testing.T) {
+		t.Parallel()
+
+		// The ranges are received concurrently, their bytes add up.
+		var rec progressRecorder
+		dest := filepath.Join(t.TempDir(), "big.bin")
+		_, err := client.DownloadAttachmentWithResult(server.URL+"/big.bin", dest, WithParallelRanges(4), WithProgress(rec.update))
+		require.NoError(t, err)
+		rec.assertCumulative(t, size, size)
+	})
+
+	t.Run("writer", func(t *testing.T) {
+		t.Parallel()
+
+		var rec progressRecorder
+		_, err := client.DownloadAttachmentTo(server.URL+"/big.bin", io.Discard, WithProgress(rec.update))
+		require.NoError(t, err)
+		rec.assertCumulative(t, size, size)
+	})
+}
+
+func TestUploadAttachmentProgress(t *testing.T) {
+	t.Parallel()
+
+	var received int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received, _ = io.Copy(io.Discard, f)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id":"10001","filename":"big.bin"}]`))
+	}))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	content := bytes.Repeat([]byte("x"), 3<<20+5)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	var rec progressRecorder
+	client := NewClient(Config{Server: server.URL}, WithTimeout(5*time.Second))
+	_, err := client.UploadAttachment("TEST-1", path, WithUploadProgress(rec.update))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), received)
+	// Only the bytes of the file are reported, not the ones of the form around it.
+	rec.assertCumulative(t, int64(len(content)), int64(len(content)))
+}
@@ -0,0 +1,183 @@
+package jira
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrReadOnlyToken denotes that a request that would change attachments was rejected, or
+// not sent, because the API token doesn't have the scope to change data.
+var ErrReadOnlyToken = errors.New("jira: the API token is read-only")
+
+// ReadOnlyTokenError is a mutating attachment request rejected for lack of token scope, or
+// not sent because an earlier one in the process was. It matches ErrReadOnlyToken with
+// errors.Is.
+type ReadOnlyTokenError struct {
+	Method string
+	URL    string
+	// Reason is the message of the response the credentials were classified on.
+	Reason string
+	// Rejected is set if this request was sent and rejected, rather than not sent.
+	Rejected bool
+}
+
+func (e *ReadOnlyTokenError) Error() string {
+	outcome := "not sent"
+	if e.Rejected {
+		outcome = "rejected"
+	}
+	msg := fmt.Sprintf("jira: %s %s %s, the API token is read-only", e.Method, e.URL, outcome)
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Reason)
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrReadOnlyToken) report true.
+func (e *ReadOnlyTokenError) Is(target error) bool {
+	return target == ErrReadOnlyToken
+}
+
+// scopeBodyLimit caps the body of a 403 response read to classify it.
+const scopeBodyLimit = 64 << 10
+
+// scopePattern matches the messages of token scope rejections, eg: "The token does not
+// have the required scope" or "Unauthorized; scope does not match". Project permission
+// rejections ("You do not have permission to ...") don't mention scopes or tokens.
+var scopePattern = regexp.MustCompile(`(?i)\bscopes?\b|\bread[- ]only\s+(?:api\s+|access\s+)?token\b|\btoken\s+is\s+read[- ]only\b`)
+
+// readOnlyTokens caches, for the lifetime of the process, the credentials that the server
+// rejected for lack of scope. It is keyed by readOnlyKey and holds the reason.
+var readOnlyTokens = struct {
+	sync.Mutex
+	reasons map[string]string
+}{reasons: make(map[string]string)}
+
+// readOnlyKey identifies the credentials of a client without keeping the token around.
+func readOnlyKey(server, login, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return server + "\x00" + login + "\x00" + hex.EncodeToString(sum[:8])
+}
+
+func readOnlyReason(key string) (string, bool) {
+	readOnlyTokens.Lock()
+	defer readOnlyTokens.Unlock()
+
+	reason, ok := readOnlyTokens.reasons[key]
+	return reason, ok
+}
+
+func markReadOnly(key, reason string) {
+	readOnlyTokens.Lock()
+	defer readOnlyTokens.Unlock()
+
+	readOnlyTokens.reasons[key] = reason
+}
+
+// ReadOnly reports if the server rejected a mutating attachment request of the client for
+// lack of token scope earlier in the process, and the message it responded with.
+func (c *Client) ReadOnly() (string, bool) {
+	return readOnlyReason(readOnlyKey(c.server, c.login, c.token))
+}
+
+// isScopeRejection reports if a 403 response was caused by the scope of the token rather
+// than by the permissions of the user, and returns the message of the response.
+func isScopeRejection(status int, header http.Header, body []byte) (string, bool) {
+	if status != http.StatusForbidden {
+		return "", false
+	}
+
+	msg := forbiddenMessage(body)
+	if strings.Contains(header.Get("WWW-Authenticate"), "insufficient_scope") {
+		if msg == "" {
+			msg = "insufficient scope"
+		}
+		return msg, true
+	}
+	return msg, msg != "" && scopePattern.MatchString(msg)
+}
+
+// forbiddenMessage extracts the message of a JSON error response, either in the usual
+// errorMessages shape or the {"code": 403, "message": "..."} shape of the API gateway.
+func forbiddenMessage(body []byte) string {
+	var out struct {
+		Errors
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+
+	msgs := append([]string(nil), out.ErrorMessages...)
+	for _, v := range out.Errors.Errors {
+		msgs = append(msgs, v)
+	}
+	if out.Message != "" {
+		msgs = append(msgs, out.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// isMutatingAttachmentRequest reports if the request adds or removes attachments.
+func isMutatingAttachmentRequest(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return strings.Contains(req.URL.Path, "/attachment") || strings.HasPrefix(req.URL.Path, "/rest/media/")
+}
+
+// readOnlyTransport turns a 403 of a mutating attachment request caused by the scope of
+// the token into a ReadOnlyTokenError, and fails the next ones without sending them.
+// Genuine permission rejections are returned as is. Requests that only read, like
+// listing and downloading attachments, are never blocked.
+type readOnlyTransport struct {
+	next http.RoundTripper
+	key  string
+}
+
+// readOnlyGuard wraps a transport of the client with readOnlyTransport.
+func (c *Client) readOnlyGuard(next http.RoundTripper) http.RoundTripper {
+	return readOnlyTransport{next: next, key: readOnlyKey(c.server, c.login, c.token)}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isMutatingAttachmentRequest(req) {
+		return t.next.RoundTrip(req)
+	}
+	if reason, ok := readOnlyReason(t.key); ok {
+		// A RoundTripper must close the body, even on errors.
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, &ReadOnlyTokenError{Method: req.Method, URL: req.URL.Redacted(), Reason: reason}
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusForbidden {
+		return res, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, scopeBodyLimit))
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return res, nil
+	}
+	reason, ok := isScopeRejection(res.StatusCode, res.Header, body)
+	if !ok {
+		return res, nil
+	}
+	markReadOnly(t.key, reason)
+	return nil, &ReadOnlyTokenError{Method: req.Method, URL: req.URL.Redacted(), Reason: reason, Rejected: true}
+}
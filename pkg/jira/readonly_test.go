@@ -0,0 +1,187 @@
+package jira
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsScopeRejection(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       string
+		wantReason string
+		want       bool
+	}{
+		{
+			name:       "gateway scope mismatch",
+			status:     http.StatusForbidden,
+			body:       `{"code":403,"message":"Unauthorized; scope does not match"}`,
+			wantReason: "Unauthorized; scope does not match",
+			want:       true,
+		},
+		{
+			name:       "missing required scope",
+			status:     http.StatusForbidden,
+			body:       `{"errorMessages":["The token does not have the required scopes: write:jira-work"],"errors":{}}`,
+			wantReason: "The token does not have the required scopes: write:jira-work",
+			want:       true,
+		},
+		{
+			name:       "read-only personal access token",
+			status:     http.StatusForbidden,
+			body:       `{"errorMessages":["This operation is not allowed with a read-only access token."]}`,
+			wantReason: "This operation is not allowed with a read-only access token.",
+			want:       true,
+		},
+		{
+			name:       "insufficient scope header",
+			status:     http.StatusForbidden,
+			header:     http.Header{"Www-Authenticate": {`Bearer error="insufficient_scope"`}},
+			wantReason: "insufficient scope",
+			want:       true,
+		},
+		{
+			name:       "project permission",
+			status:     http.StatusForbidden,
+			body:       `{"errorMessages":["You do not have permission to create attachments for this issue."],"errors":{}}`,
+			wantReason: "You do not have permission to create attachments for this issue.",
+		},
+		{
+			name:       "delete permission",
+			status:     http.StatusForbidden,
+			body:       `{"errorMessages":["You do not have permission to delete attachments for this issue."]}`,
+			wantReason: "You do not have permission to delete attachments for this issue.",
+		},
+		{
+			name:       "plain text",
+			status:     http.StatusForbidden,
+			body:       "Forbidden",
+			wantReason: "Forbidden",
+		},
+		{
+			name:   "not a 403",
+			status: http.StatusUnauthorized,
+			body:   `{"code":401,"message":"Unauthorized; scope does not match"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			header := tc.header
+			if header == nil {
+				header = http.Header{}
+			}
+			reason, ok := isScopeRejection(tc.status, header, []byte(tc.body))
+			assert.Equal(t, tc.want, ok)
+			assert.Equal(t, tc.wantReason, reason)
+		})
+	}
+}
+
+// forbiddenServer rejects attachment deletes with 403 and the given body, and counts the
+// requests it receives by method.
+func forbiddenServer(t *testing.T, body string) (*httptest.Server, func(method string) int) {
+	t.Helper()
+
+	var (
+		mu     sync.Mutex
+		counts = make(map[string]int)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts[r.Method]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"10001","filename":"report.pdf"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func(method string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[method]
+	}
+}
+
+func TestReadOnlyTokenIsCached(t *testing.T) {
+	t.Parallel()
+
+	server, count := forbiddenServer(t, `{"code":403,"message":"Unauthorized; scope does not match"}`)
+	client := NewClient(Config{Server: server.URL, Login: "ro", APIToken: "read-only"}, WithTimeout(3*time.Second))
+
+	_, ok := client.ReadOnly()
+	assert.False(t, ok)
+
+	err := client.DeleteAttachment("10001")
+	var roErr *ReadOnlyTokenError
+	require.ErrorAs(t, err, &roErr)
+	assert.True(t, roErr.Rejected)
+	assert.Equal(t, "Unauthorized; scope does not match", roErr.Reason)
+	assert.Equal(t, 1, count(http.MethodDelete))
+
+	// The next change fails without reaching the server.
+	err = client.DeleteAttachment("10002")
+	require.ErrorAs(t, err, &roErr)
+	assert.False(t, roErr.Rejected)
+	assert.True(t, errors.Is(err, ErrReadOnlyToken))
+	assert.Equal(t, 1, count(http.MethodDelete))
+
+	// The cache is shared by every client of the same credentials in the process.
+	other := NewClient(Config{Server: server.URL, Login: "ro", APIToken: "read-only"}, WithTimeout(3*time.Second))
+	reason, ok := other.ReadOnly()
+	assert.True(t, ok)
+	assert.Equal(t, "Unauthorized; scope does not match", reason)
+	assert.ErrorIs(t, other.DeleteAttachment("10003"), ErrReadOnlyToken)
+	assert.Equal(t, 1, count(http.MethodDelete))
+
+	// Reading still works.
+	a, err := client.GetAttachment("10001")
+	require.NoError(t, err)
+	assert.Equal(t, "report.pdf", a.Filename)
+
+	// Other credentials on the same server are not affected.
+	writer := NewClient(Config{Server: server.URL, Login: "ro", APIToken: "read-write"}, WithTimeout(3*time.Second))
+	_, ok = writer.ReadOnly()
+	assert.False(t, ok)
+}
+
+func TestProjectPermissionIsNotReadOnly(t *testing.T) {
+	t.Parallel()
+
+	server, count := forbiddenServer(t, `{"errorMessages":["You do not have permission to delete attachments for this issue."],"errors":{}}`)
+	client := NewClient(Config{Server: server.URL, Login: "user", APIToken: "token"}, WithTimeout(3*time.Second))
+
+	for range 2 {
+		err := client.DeleteAttachment("10001")
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrReadOnlyToken))
+
+		var unexpected *ErrUnexpectedResponse
+		require.ErrorAs(t, err, &unexpected)
+		assert.Equal(t, http.StatusForbidden, unexpected.StatusCode)
+		assert.Contains(t, unexpected.Error(), "You do not have permission to delete attachments")
+	}
+	assert.Equal(t, 2, count(http.MethodDelete))
+
+	_, ok := client.ReadOnly()
+	assert.False(t, ok)
+}
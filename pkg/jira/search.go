@@ -27,6 +27,54 @@ func (c *Client) SearchV2(jql string, from, limit uint) (*SearchResult, error) {
 	return c.search(path, apiVersion2)
 }
 
+// SearchIssueAttachments fetches a page of the issues matching the jql with only their key and
+// attachments, using v3 version of the Jira GET /search/jql endpoint. Pass the NextPageToken
+// of the previous page to get the next one.
+func (c *Client) SearchIssueAttachments(jql, pageToken string, limit uint) (*SearchResult, error) {
+	return c.searchFields(jql, pageToken, limit, "attachment")
+}
+
+// SearchIssueAttachmentsV2 fetches a page of the issues matching the jql with only their key
+// and attachments, using v2 version of the Jira GET /search endpoint.
+func (c *Client) SearchIssueAttachmentsV2(jql string, from, limit uint) (*SearchResult, error) {
+	return c.searchFieldsV2(jql, from, limit, "attachment")
+}
+
+// SearchIssueKeys fetches a page of the issues matching the jql with only their key, using
+// v3 version of the Jira GET /search/jql endpoint. Pass the NextPageToken of the previous
+// page to get the next one.
+func (c *Client) SearchIssueKeys(jql, pageToken string, limit uint) (*SearchResult, error) {
+	return c.searchFields(jql, pageToken, limit, "key")
+}
+
+// SearchIssueKeysV2 fetches a page of the issues matching the jql with only their key, using
+// v2 version of the Jira GET /search endpoint.
+func (c *Client) SearchIssueKeysV2(jql string, from, limit uint) (*SearchResult, error) {
+	return c.searchFieldsV2(jql, from, limit, "key")
+}
+
+func (c *Client) searchFields(jql, pageToken string, limit uint, fields string) (*SearchResult, error) {
+	q := url.Values{
+		"jql":        {jql},
+		"maxResults": {fmt.Sprint(limit)},
+		"fields":     {fields},
+	}
+	if pageToken != "" {
+		q.Set("nextPageToken", pageToken)
+	}
+	return c.search("/search/jql?"+q.Encode(), apiVersion3)
+}
+
+func (c *Client) searchFieldsV2(jql string, from, limit uint, fields string) (*SearchResult, error) {
+	q := url.Values{
+		"jql":        {jql},
+		"startAt":    {fmt.Sprint(from)},
+		"maxResults": {fmt.Sprint(limit)},
+		"fields":     {fields},
+	}
+	return c.search("/search?"+q.Encode(), apiVersion2)
+}
+
 func (c *Client) search(path, ver string) (*SearchResult, error) {
 	var (
 		res *http.Response
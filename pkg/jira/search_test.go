@@ -140,3 +140,105 @@ func TestSearch(t *testing.T) {
 	_, err = client.SearchV2("project=TEST", 0, 100)
 	assert.Error(t, &ErrUnexpectedResponse{}, err)
 }
+
+func TestSearchIssueAttachments(t *testing.T) {
+	var apiVersion2 bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qs := r.URL.Query()
+
+		if apiVersion2 {
+			assert.Equal(t, "/rest/api/2/search", r.URL.Path)
+			assert.Equal(t, url.Values{
+				"jql":        []string{"project=TEST"},
+				"fields":     []string{"attachment"},
+				"startAt":    []string{"50"},
+				"maxResults": []string{"50"},
+			}, qs)
+		} else {
+			assert.Equal(t, "/rest/api/3/search/jql", r.URL.Path)
+			assert.Equal(t, url.Values{
+				"jql":           []string{"project=TEST"},
+				"fields":        []string{"attachment"},
+				"maxResults":    []string{"50"},
+				"nextPageToken": []string{"CAEaAggC"},
+			}, qs)
+		}
+
+		resp, err := os.ReadFile("./testdata/search-attachments.json")
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	actual, err := client.SearchIssueAttachments("project=TEST", "CAEaAggC", 50)
+	assert.NoError(t, err)
+	assert.False(t, actual.IsLast)
+	assert.Equal(t, "CAEaAggD", actual.NextPageToken)
+	assert.Len(t, actual.Issues, 2)
+	assert.Equal(t, "TEST-1", actual.Issues[0].Key)
+	assert.Equal(t, []Attachment{{
+		ID:        "10001",
+		Filename:  "architecture.pdf",
+		Created:   "2024-01-02T10:00:00.000+0100",
+		CreatedAt: createdAt("2024-01-02T10:00:00.000+0100"),
+		Size:      41943040,
+		MimeType:  "application/pdf",
+		Content:   "https://test.local/rest/api/3/attachment/content/10001",
+	}}, actual.Issues[0].Fields.Attachments)
+
+	apiVersion2 = true
+
+	actual, err = client.SearchIssueAttachmentsV2("project=TEST", 50, 50)
+	assert.NoError(t, err)
+	assert.Len(t, actual.Issues, 2)
+}
+
+func TestSearchIssueKeys(t *testing.T) {
+	var apiVersion2 bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qs := r.URL.Query()
+
+		if apiVersion2 {
+			assert.Equal(t, "/rest/api/2/search", r.URL.Path)
+			assert.Equal(t, url.Values{
+				"jql":        []string{"project=TEST ORDER BY key ASC"},
+				"fields":     []string{"key"},
+				"startAt":    []string{"100"},
+				"maxResults": []string{"100"},
+			}, qs)
+		} else {
+			assert.Equal(t, "/rest/api/3/search/jql", r.URL.Path)
+			assert.Equal(t, url.Values{
+				"jql":        []string{"project=TEST ORDER BY key ASC"},
+				"fields":     []string{"key"},
+				"maxResults": []string{"100"},
+			}, qs)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"isLast": true, "issues": [{"key": "TEST-1"}, {"key": "TEST-2"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	actual, err := client.SearchIssueKeys("project=TEST ORDER BY key ASC", "", 100)
+	assert.NoError(t, err)
+	assert.True(t, actual.IsLast)
+	assert.Len(t, actual.Issues, 2)
+	assert.Equal(t, "TEST-2", actual.Issues[1].Key)
+
+	apiVersion2 = true
+
+	actual, err = client.SearchIssueKeysV2("project=TEST ORDER BY key ASC", 100, 100)
+	assert.NoError(t, err)
+	assert.Len(t, actual.Issues, 2)
+}
@@ -0,0 +1,157 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/proxy"
+)
+
+// defaultSOCKSPort is the port of a SOCKS5 proxy URL without one, eg: socks5://localhost.
+const defaultSOCKSPort = "1080"
+
+// ProxyError is an attachment request that failed at the SOCKS5 proxy it goes through. It
+// names the proxy rather than the Jira host, as the host was never reached.
+type ProxyError struct {
+	// Proxy is the address of the proxy, eg: localhost:1080.
+	Proxy string
+	// Addr is the address the proxy was asked to connect to.
+	Addr string
+	// Unreachable is set if the connection to the proxy itself failed.
+	Unreachable bool
+	// Auth is set if the proxy rejected the credentials of the proxy URL, or requires some.
+	Auth bool
+	Err  error
+}
+
+func (e *ProxyError) Error() string {
+	switch {
+	case e.Unreachable:
+		return fmt.Sprintf("SOCKS5 proxy %s is unreachable: %s", e.Proxy, e.Err)
+	case e.Auth:
+		return fmt.Sprintf("SOCKS5 proxy %s rejected the credentials: %s", e.Proxy, e.Err)
+	}
+	return fmt.Sprintf("SOCKS5 proxy %s failed to connect to %s: %s", e.Proxy, e.Addr, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// isSOCKS reports if the proxy URL is a SOCKS5 proxy. Host names are always resolved by
+// the proxy, socks5h is accepted as a synonym.
+func isSOCKS(u *url.URL) bool {
+	return u != nil && (strings.EqualFold(u.Scheme, "socks5") || strings.EqualFold(u.Scheme, "socks5h"))
+}
+
+// httpProxyOnly returns the proxy func without the SOCKS5 proxies, which are dialed by
+// socksDial instead.
+func httpProxyOnly(fn func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	if fn == nil {
+		return nil
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := fn(req)
+		if err != nil || isSOCKS(u) {
+			return nil, err
+		}
+		return u, nil
+	}
+}
+
+// socksDial returns a dial func that connects through the SOCKS5 proxy the proxy func
+// picks for the address, with the username and password of the proxy URL if any. Addresses
+// without a proxy, or with an HTTP proxy, are dialed with dial as is.
+func socksDial(fn func(*http.Request) (*url.URL, error), dial dialFunc) dialFunc {
+	if fn == nil {
+		return dial
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		u, err := socksProxyFor(fn, addr)
+		if err != nil {
+			return nil, err
+		}
+		if u == nil {
+			return dial(ctx, network, addr)
+		}
+		return dialSOCKS(ctx, u, dial, network, addr)
+	}
+}
+
+// socksProxyFor returns the SOCKS5 proxy of the address, nil if it has none. The dial
+// doesn't know the URL of the request, port 80 is taken for http and any other for https.
+func socksProxyFor(fn func(*http.Request) (*url.URL, error), addr string) (*url.URL, error) {
+	scheme := "https"
+	if _, port, err := net.SplitHostPort(addr); err == nil && port == "80" {
+		scheme = "http"
+	}
+	u, err := fn(&http.Request{URL: &url.URL{Scheme: scheme, Host: addr}})
+	if err != nil || !isSOCKS(u) {
+		return nil, err
+	}
+	return u, nil
+}
+
+func dialSOCKS(ctx context.Context, u *url.URL, dial dialFunc, network, addr string) (net.Conn, error) {
+	proxyAddr := u.Host
+	if u.Port() == "" {
+		proxyAddr = net.JoinHostPort(u.Hostname(), defaultSOCKSPort)
+	}
+
+	var auth *proxy.Auth
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth = &proxy.Auth{User: u.User.Username(), Password: password}
+	}
+
+	// The error of the connection to the proxy tells an unreachable proxy from one that
+	// failed to connect to the address.
+	var reachErr error
+	forward := contextDialer(func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		reachErr = err
+		return conn, err
+	})
+
+	d, err := proxy.SOCKS5("tcp", proxyAddr, auth, forward)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := d.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	if err == nil {
+		return conn, nil
+	}
+
+	pErr := &ProxyError{Proxy: proxyAddr, Addr: addr, Err: err}
+	switch {
+	case reachErr != nil:
+		pErr.Unreachable, pErr.Err = true, reachErr
+	case isSOCKSAuthFailure(err):
+		pErr.Auth, pErr.Err = true, errors.Unwrap(err)
+	}
+	return nil, pErr
+}
+
+// isSOCKSAuthFailure reports if the SOCKS5 handshake failed on authentication. The errors
+// of x/net/proxy aren't exported, they are told by their message.
+func isSOCKSAuthFailure(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "authentication") || strings.Contains(msg, "username/password")
+}
+
+// contextDialer adapts a dialFunc to proxy.Dialer and proxy.ContextDialer.
+type contextDialer dialFunc
+
+func (d contextDialer) Dial(network, addr string) (net.Conn, error) {
+	return d(context.Background(), network, addr)
+}
+
+func (d contextDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return d(ctx, network, addr)
+}
@@ -0,0 +1,195 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default timeouts of the phases of attachment requests.
+const (
+	DefaultDialTimeout           = 5 * time.Second
+	DefaultTLSHandshakeTimeout   = 5 * time.Second
+	DefaultResponseHeaderTimeout = 30 * time.Second
+)
+
+// AttachmentTimeouts are the timeouts of the phases of attachment requests. They are
+// short so that an unreachable host fails fast, while the transfer of the body is not
+// covered by any of them: it is only bounded by the context of the request, so that
+// large files are never cut off. A zero value uses the default of the phase.
+type AttachmentTimeouts struct {
+	// Dial is how long to wait for the TCP connection to the host.
+	Dial time.Duration
+	// TLSHandshake is how long to wait for the TLS handshake once connected.
+	TLSHandshake time.Duration
+	// ResponseHeader is how long to wait for the response headers once the request is sent.
+	ResponseHeader time.Duration
+}
+
+func (t AttachmentTimeouts) withDefaults() AttachmentTimeouts {
+	if t.Dial <= 0 {
+		t.Dial = DefaultDialTimeout
+	}
+	if t.TLSHandshake <= 0 {
+		t.TLSHandshake = DefaultTLSHandshakeTimeout
+	}
+	if t.ResponseHeader <= 0 {
+		t.ResponseHeader = DefaultResponseHeaderTimeout
+	}
+	return t
+}
+
+// WithAttachmentTimeouts is a functional opt to set the timeouts of the phases of attachment requests.
+func WithAttachmentTimeouts(t AttachmentTimeouts) ClientFunc {
+	return func(c *Client) {
+		c.attachmentTimeouts = t
+	}
+}
+
+// TimeoutPhase is the phase of a request that timed out.
+type TimeoutPhase int
+
+const (
+	// TimeoutPhaseUnknown is a timeout that couldn't be attributed to a phase.
+	TimeoutPhaseUnknown TimeoutPhase = iota
+	// TimeoutPhaseConnect is a timeout while connecting to the host, including the TLS handshake.
+	TimeoutPhaseConnect
+	// TimeoutPhaseResponse is a timeout while waiting for the server to respond to the request.
+	TimeoutPhaseResponse
+	// TimeoutPhaseTransfer is a timeout while the body was transferred.
+	TimeoutPhaseTransfer
+)
+
+// ErrTimeout is an attachment request that timed out in the given phase.
+type ErrTimeout struct {
+	Phase TimeoutPhase
+	Host  string
+	Err   error
+}
+
+func (e *ErrTimeout) Error() string {
+	switch e.Phase {
+	case TimeoutPhaseConnect:
+		return fmt.Sprintf("connection to host %s timed out", e.Host)
+	case TimeoutPhaseResponse:
+		return fmt.Sprintf("server %s did not respond in time", e.Host)
+	case TimeoutPhaseTransfer:
+		return fmt.Sprintf("transfer from %s stalled", e.Host)
+	}
+	return fmt.Sprintf("request to %s timed out", e.Host)
+}
+
+// Unwrap returns the underlying error.
+func (e *ErrTimeout) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports that the error is a timeout, like net.Error.
+func (e *ErrTimeout) Timeout() bool {
+	return true
+}
+
+// ClassifyTimeout returns the phase of a request error that is a timeout. The
+// second value is false if the error is not a timeout.
+func ClassifyTimeout(err error) (TimeoutPhase, bool) {
+	if err == nil {
+		return TimeoutPhaseUnknown, false
+	}
+
+	var (
+		tErr  *ErrTimeout
+		opErr *net.OpError
+		nErr  net.Error
+	)
+	switch {
+	case errors.As(err, &tErr):
+		return tErr.Phase, true
+	// The errors of net/http for these phases are not exported.
+	case strings.Contains(err.Error(), "TLS handshake timeout"):
+		return TimeoutPhaseConnect, true
+	case strings.Contains(err.Error(), "timeout awaiting response headers"):
+		return TimeoutPhaseResponse, true
+	case errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout():
+		return TimeoutPhaseConnect, true
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &nErr) && nErr.Timeout():
+		return TimeoutPhaseUnknown, true
+	}
+	return TimeoutPhaseUnknown, false
+}
+
+// wrapTimeout wraps a timeout of a request to host in an ErrTimeout. A timeout that can't
+// be attributed to a phase is attributed to the given fallback phase. Other errors are
+// returned as is.
+func wrapTimeout(err error, host string, fallback TimeoutPhase) error {
+	phase, ok := ClassifyTimeout(err)
+	if !ok {
+		return err
+	}
+	var tErr *ErrTimeout
+	if errors.As(err, &tErr) {
+		return err
+	}
+	if phase == TimeoutPhaseUnknown {
+		phase = fallback
+	}
+	return &ErrTimeout{Phase: phase, Host: host, Err: err}
+}
+
+// doAttachment sends an attachment request with the attachment transport. Timeouts are
+// reported as an ErrTimeout naming the phase that timed out.
+func (c *Client) doAttachment(req *http.Request) (*http.Response, error) {
+	httpClient := &http.Client{Transport: c.attachmentTransport()}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, wrapTimeout(err, req.URL.Host, TimeoutPhaseUnknown)
+	}
+	return res, nil
+}
+
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Host
+	}
+	return rawURL
+}
+
+// dialFunc is the signature of net.Dialer.DialContext.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// dialWithTimeout bounds dial to the timeout. Only the connection is bounded, the
+// context of the request still applies to the whole request.
+func dialWithTimeout(dial dialFunc, timeout time.Duration) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		conn, err := dial(dctx, network, addr)
+		var pErr *ProxyError
+		if err != nil && ctx.Err() == nil && errors.Is(dctx.Err(), context.DeadlineExceeded) && !errors.As(err, &pErr) {
+			return nil, &ErrTimeout{Phase: TimeoutPhaseConnect, Host: addr, Err: err}
+		}
+		return conn, err
+	}
+}
+
+// newAttachmentTransport returns a copy of the transport with the timeouts of the
+// attachment requests. The dial func is the one of a net.Dialer if nil. SOCKS5 proxies
+// of the transport are dialed by socksDial, the dial timeout covers their handshake.
+func newAttachmentTransport(base *http.Transport, timeouts AttachmentTimeouts, dial dialFunc) *http.Transport {
+	timeouts = timeouts.withDefaults()
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	t := base.Clone()
+	t.Proxy = httpProxyOnly(base.Proxy)
+	t.DialContext = dialWithTimeout(socksDial(base.Proxy, dial), timeouts.Dial)
+	t.TLSHandshakeTimeout = timeouts.TLSHandshake
+	t.ResponseHeaderTimeout = timeouts.ResponseHeader
+	return t
+}
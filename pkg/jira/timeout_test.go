@@ -0,0 +1,193 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withDial is a functional opt to replace the dialer of the attachment transport.
+func withDial(dial dialFunc) ClientFunc {
+	return func(c *Client) {
+		c.dial = dial
+	}
+}
+
+func TestClassifyTimeout(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		err       error
+		wantPhase TimeoutPhase
+		wantOK    bool
+	}{
+		{name: "nil", err: nil},
+		{name: "not a timeout", err: errors.New("connection reset by peer")},
+		{
+			name:      "phase error",
+			err:       fmt.Errorf("download: %w", &ErrTimeout{Phase: TimeoutPhaseTransfer}),
+			wantPhase: TimeoutPhaseTransfer,
+			wantOK:    true,
+		},
+		{name: "tls handshake", err: errors.New("net/http: TLS handshake timeout"), wantPhase: TimeoutPhaseConnect, wantOK: true},
+		{
+			name:      "response headers",
+			err:       errors.New("net/http: timeout awaiting response headers"),
+			wantPhase: TimeoutPhaseResponse,
+			wantOK:    true,
+		},
+		{
+			name:      "dial",
+			err:       &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded},
+			wantPhase: TimeoutPhaseConnect,
+			wantOK:    true,
+		},
+		{name: "read", err: &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, wantOK: true},
+		{name: "context deadline", err: context.DeadlineExceeded, wantOK: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			phase, ok := ClassifyTimeout(tc.err)
+			assert.Equal(t, tc.wantPhase, phase)
+			assert.Equal(t, tc.wantOK, ok)
+		})
+	}
+}
+
+func TestAttachmentDialTimeout(t *testing.T) {
+	t.Parallel()
+
+	// A host that never accepts the connection.
+	blackhole := func(ctx context.Context, _, _ string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: ctx.Err()}
+	}
+	client := NewClient(
+		Config{Server: "http://jira.example.com"},
+		WithTimeout(5*time.Second),
+		WithAttachmentTimeouts(AttachmentTimeouts{Dial: 50 * time.Millisecond}),
+		withDial(blackhole),
+	)
+
+	start := time.Now()
+	err := client.DownloadAttachment("http://jira.example.com/attachment/content/1", filepath.Join(t.TempDir(), "a.txt"))
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	var tErr *ErrTimeout
+	assert.True(t, errors.As(err, &tErr), "got %v", err)
+	assert.Equal(t, TimeoutPhaseConnect, tErr.Phase)
+	assert.Contains(t, err.Error(), "connection to host jira.example.com:80 timed out")
+}
+
+func TestAttachmentTLSHandshakeTimeout(t *testing.T) {
+	t.Parallel()
+
+	// A host that accepts the connection but never speaks TLS.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				<-done
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	server := "https://" + ln.Addr().String()
+	client := NewClient(
+		Config{Server: server},
+		WithTimeout(5*time.Second),
+		WithAttachmentTimeouts(AttachmentTimeouts{TLSHandshake: 50 * time.Millisecond}),
+	)
+
+	err = client.DownloadAttachment(server+"/attachment/content/1", filepath.Join(t.TempDir(), "a.txt"))
+
+	var tErr *ErrTimeout
+	assert.True(t, errors.As(err, &tErr), "got %v", err)
+	assert.Equal(t, TimeoutPhaseConnect, tErr.Phase)
+}
+
+func TestAttachmentResponseHeaderTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := NewClient(
+		Config{Server: server.URL},
+		WithTimeout(5*time.Second),
+		WithAttachmentTimeouts(AttachmentTimeouts{ResponseHeader: 50 * time.Millisecond}),
+	)
+
+	err := client.DownloadAttachment(server.URL+"/attachment/content/1", filepath.Join(t.TempDir(), "a.txt"))
+
+	var tErr *ErrTimeout
+	assert.True(t, errors.As(err, &tErr), "got %v", err)
+	assert.Equal(t, TimeoutPhaseResponse, tErr.Phase)
+	assert.Contains(t, err.Error(), "did not respond in time")
+}
+
+func TestAttachmentSlowBodyNotTimedOut(t *testing.T) {
+	t.Parallel()
+
+	const chunks = 6
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprint(chunks))
+		w.WriteHeader(http.StatusOK)
+		for range chunks {
+			_, _ = w.Write([]byte("x"))
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// The body takes longer than the response header timeout.
+	client := NewClient(
+		Config{Server: server.URL},
+		WithTimeout(5*time.Second),
+		WithAttachmentTimeouts(AttachmentTimeouts{
+			Dial:           time.Second,
+			TLSHandshake:   time.Second,
+			ResponseHeader: 100 * time.Millisecond,
+		}),
+	)
+
+	dest := filepath.Join(t.TempDir(), "a.txt")
+	assert.NoError(t, client.DownloadAttachment(server.URL+"/attachment/content/1", dest))
+
+	got, err := os.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", chunks), string(got))
+}
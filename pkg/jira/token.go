@@ -0,0 +1,125 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenExpiryHeaders are the response headers checked for the expiry of the token used
+// to authenticate the request. Atlassian doesn't document a single header for this, so
+// the known variants sent by the cloud edge and by SSO proxies in front of Jira are
+// checked in order.
+var tokenExpiryHeaders = []string{
+	"X-Atlassian-Token-Expiry",
+	"X-Atlassian-Token-Expires-At",
+	"X-Token-Expiry",
+}
+
+// TokenStatus is the result of a token health check.
+type TokenStatus struct {
+	// ExpiresAt is the expiry of the token as reported by the server.
+	// It is zero if the server didn't send an expiry hint.
+	ExpiresAt time.Time
+}
+
+// ExpiresWithin reports if the token is known to expire within d of now.
+func (s *TokenStatus) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !s.ExpiresAt.IsZero() && s.ExpiresAt.Sub(now) <= d
+}
+
+// CheckToken authenticates against the v3 /myself endpoint
+// and returns the token expiry hinted by the response headers.
+func (c *Client) CheckToken() (*TokenStatus, error) {
+	return c.checkToken(apiVersion3)
+}
+
+// CheckTokenV2 authenticates against the v2 /myself endpoint
+// and returns the token expiry hinted by the response headers.
+func (c *Client) CheckTokenV2() (*TokenStatus, error) {
+	return c.checkToken(apiVersion2)
+}
+
+func (c *Client) checkToken(ver string) (*TokenStatus, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	switch ver {
+	case apiVersion2:
+		res, err = c.GetV2(context.Background(), "/myself", nil)
+	default:
+		res, err = c.Get(context.Background(), "/myself", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, formatUnexpectedResponse(res)
+	}
+
+	expiresAt, _ := ParseTokenExpiry(res.Header)
+	return &TokenStatus{ExpiresAt: expiresAt}, nil
+}
+
+// ParseTokenExpiry returns the token expiry from the response headers. The value
+// can be an RFC 3339 or HTTP date, or a unix timestamp in seconds or milliseconds.
+func ParseTokenExpiry(h http.Header) (time.Time, bool) {
+	for _, name := range tokenExpiryHeaders {
+		v := strings.TrimSpace(h.Get(name))
+		if v == "" {
+			continue
+		}
+		if t, ok := parseExpiry(v); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseExpiry(v string) (time.Time, bool) {
+	// Timestamps in milliseconds have more than 10 digits until the year 2286.
+	const maxSecondsDigits = 10
+
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+		if len(v) > maxSecondsDigits {
+			return time.UnixMilli(n), true
+		}
+		return time.Unix(n, 0), true
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// IsAuthFailure reports if the error means that the server rejected the credentials,
+// either with a 401 response or with a login page served in place of the response.
+func IsAuthFailure(err error) bool {
+	var (
+		unexpected *ErrUnexpectedResponse
+		attachment *AttachmentError
+		login      *ErrAuthenticationRequired
+	)
+	switch {
+	case errors.As(err, &login):
+		return true
+	case errors.As(err, &attachment):
+		return attachment.StatusCode == http.StatusUnauthorized
+	case errors.As(err, &unexpected):
+		return unexpected.StatusCode == http.StatusUnauthorized
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTokenExpiry(t *testing.T) {
+	t.Parallel()
+
+	want := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		header http.Header
+		want   time.Time
+		ok     bool
+	}{
+		{
+			name:   "rfc3339",
+			header: http.Header{"X-Atlassian-Token-Expiry": {"2025-06-01T12:00:00Z"}},
+			want:   want,
+			ok:     true,
+		},
+		{
+			name:   "http date",
+			header: http.Header{"X-Atlassian-Token-Expires-At": {"Sun, 01 Jun 2025 12:00:00 GMT"}},
+			want:   want,
+			ok:     true,
+		},
+		{
+			name:   "unix seconds",
+			header: http.Header{"X-Token-Expiry": {fmt.Sprint(want.Unix())}},
+			want:   want,
+			ok:     true,
+		},
+		{
+			name:   "unix milliseconds",
+			header: http.Header{"X-Token-Expiry": {fmt.Sprint(want.UnixMilli())}},
+			want:   want,
+			ok:     true,
+		},
+		{
+			name: "invalid value falls through to the next header",
+			header: http.Header{
+				"X-Atlassian-Token-Expiry": {"soon"},
+				"X-Token-Expiry":           {"2025-06-01T12:00:00Z"},
+			},
+			want: want,
+			ok:   true,
+		},
+		{
+			name:   "no header",
+			header: http.Header{"Content-Type": {"application/json"}},
+		},
+		{
+			name:   "invalid",
+			header: http.Header{"X-Atlassian-Token-Expiry": {"never"}},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := ParseTokenExpiry(tc.header)
+			assert.Equal(t, tc.ok, ok)
+			assert.True(t, tc.want.Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	var unauthorized bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/3/myself", r.URL.Path)
+
+		if unauthorized {
+			w.WriteHeader(401)
+			return
+		}
+		w.Header().Set("X-Atlassian-Token-Expiry", "2025-06-01T12:00:00Z")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"accountId": "a-123"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+	status, err := client.CheckToken()
+	assert.NoError(t, err)
+
+	expiresAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.True(t, expiresAt.Equal(status.ExpiresAt))
+	assert.True(t, status.ExpiresWithin(expiresAt.Add(-time.Hour), 2*time.Hour))
+	assert.False(t, status.ExpiresWithin(expiresAt.Add(-3*time.Hour), 2*time.Hour))
+	assert.False(t, (&TokenStatus{}).ExpiresWithin(expiresAt, time.Hour))
+
+	unauthorized = true
+
+	_, err = client.CheckToken()
+	assert.True(t, IsAuthFailure(err))
+}
+
+func TestIsAuthFailure(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsAuthFailure(&ErrUnexpectedResponse{StatusCode: 401}))
+	assert.True(t, IsAuthFailure(fmt.Errorf("failed: %w", &ErrUnexpectedResponse{StatusCode: 401})))
+	assert.True(t, IsAuthFailure(&AttachmentError{StatusCode: 401}))
+	assert.True(t, IsAuthFailure(&ErrAuthenticationRequired{}))
+	assert.False(t, IsAuthFailure(&ErrUnexpectedResponse{StatusCode: 403}))
+	assert.False(t, IsAuthFailure(errors.New("connection refused")))
+	assert.False(t, IsAuthFailure(nil))
+}
@@ -0,0 +1,61 @@
+package jira
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// hostTransport dispatches requests to the primary server host to the primary
+// transport and requests to any other host, eg: the media host serving attachment
+// content, to the media transport.
+type hostTransport struct {
+	host    string
+	primary http.RoundTripper
+	media   http.RoundTripper
+}
+
+func newHostTransport(server string, primary, media http.RoundTripper) *hostTransport {
+	var host string
+	if u, err := url.Parse(server); err == nil {
+		host = canonicalHost(u)
+	}
+	return &hostTransport{host: host, primary: primary, media: media}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if canonicalHost(req.URL) == t.host {
+		return t.primary.RoundTrip(req)
+	}
+	return t.media.RoundTrip(req)
+}
+
+func canonicalHost(u *url.URL) string {
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+	return host + ":" + port
+}
+
+// WithMediaTLSConfig is a functional opt to use a separate TLS config for attachment
+// downloads served from a host other than the jira server, eg: a media host with a
+// certificate issued by an internal CA. Requests to the jira server are not affected.
+func WithMediaTLSConfig(cfg *tls.Config) ClientFunc {
+	return func(c *Client) {
+		c.mediaTLS = cfg
+	}
+}
+
+// attachmentTransport returns the transport used to upload and download attachment
+// content. It has its own timeouts for each phase, see AttachmentTimeouts.
+func (c *Client) attachmentTransport() http.RoundTripper {
+	return c.maintenanceGuard(c.readOnlyGuard(c.attachment))
+}
@@ -0,0 +1,184 @@
+package jira
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// newTLSServer starts a TLS server with a certificate for 127.0.0.1 issued by the CA.
+func (ca *testCA) newTLSServer(t *testing.T, h http.Handler) *httptest.Server {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(h)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+	server.StartTLS()
+
+	return server
+}
+
+// newMediaTLSClient creates a client for the primary server that trusts primaryCA
+// for the jira server, the same way the system pool would, and mediaCA for others.
+func newMediaTLSClient(server string, primaryCA, mediaCA *testCA) *Client {
+	client := NewClient(Config{
+		Server:   server,
+		Login:    "test",
+		APIToken: "token",
+	}, WithTimeout(3*time.Second), WithMediaTLSConfig(&tls.Config{RootCAs: mediaCA.pool}))
+
+	client.transport.(*http.Transport).TLSClientConfig.RootCAs = primaryCA.pool
+	client.attachment.(*hostTransport).primary.(*http.Transport).TLSClientConfig.RootCAs = primaryCA.pool
+
+	return client
+}
+
+func TestMediaTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	primaryCA, mediaCA := newTestCA(t, "primary"), newTestCA(t, "media")
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	primary := primaryCA.newTLSServer(t, handler)
+	defer primary.Close()
+
+	media := mediaCA.newTLSServer(t, handler)
+	defer media.Close()
+
+	client := newMediaTLSClient(primary.URL, primaryCA, mediaCA)
+	dir := t.TempDir()
+
+	// Attachment content on the media host is verified with the media config.
+	assert.NoError(t, client.DownloadAttachment(media.URL+"/file/1", filepath.Join(dir, "media.txt")))
+
+	// Attachment content on the jira server is verified with the primary config.
+	assert.NoError(t, client.DownloadAttachment(primary.URL+"/attachment/content/1", filepath.Join(dir, "primary.txt")))
+
+	// API calls always use the primary config.
+	res, err := client.Get(t.Context(), "/myself", nil)
+	assert.NoError(t, err)
+	_ = res.Body.Close()
+
+	b, err := os.ReadFile(filepath.Join(dir, "media.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(b))
+}
+
+func TestMediaTLSConfigNotUsedForPrimary(t *testing.T) {
+	t.Parallel()
+
+	primaryCA, mediaCA := newTestCA(t, "primary"), newTestCA(t, "media")
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	// The jira server presents a certificate that only the media config trusts.
+	primary := mediaCA.newTLSServer(t, handler)
+	defer primary.Close()
+
+	client := newMediaTLSClient(primary.URL, primaryCA, mediaCA)
+
+	_, err := client.Get(t.Context(), "/myself", nil) //nolint:bodyclose
+	assert.Error(t, err)
+
+	err = client.DownloadAttachment(primary.URL+"/attachment/content/1", filepath.Join(t.TempDir(), "a.txt"))
+	assert.Error(t, err)
+}
+
+func TestHostTransport(t *testing.T) {
+	t.Parallel()
+
+	var used []string
+	rt := func(name string) http.RoundTripper {
+		return roundTripFunc(func(*http.Request) (*http.Response, error) {
+			used = append(used, name)
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		})
+	}
+
+	tr := newHostTransport("https://jira.example.com", rt("primary"), rt("media"))
+
+	for _, u := range []string{
+		"https://jira.example.com/rest/api/3/myself",
+		"https://JIRA.example.com:443/secure/attachment/1",
+		"https://media.example.com/file/1",
+		"http://jira.example.com/rest/api/3/myself",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		_, _ = tr.RoundTrip(req) //nolint:bodyclose
+	}
+
+	assert.Equal(t, []string{"primary", "primary", "media", "media"}, used)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -2,6 +2,7 @@ package jira
 
 import (
 	"encoding/json"
+	"time"
 )
 
 const (
@@ -68,6 +69,7 @@ type Issue struct {
 // IssueFields holds issue fields.
 type IssueFields struct {
 	Summary     string      `json:"summary"`
+	Project     Project     `json:"project"`
 	Description interface{} `json:"description"` // string in v1/v2, adf.ADF in v3
 	Labels      []string    `json:"labels"`
 	Resolution  struct {
@@ -195,11 +197,21 @@ type User struct {
 
 // Attachment holds attachment metadata.
 type Attachment struct {
+	Self     string `json:"self,omitempty"` // REST URL of the attachment
 	ID       string `json:"id"`
 	Filename string `json:"filename"`
 	Author   User   `json:"author"`
-	Created  string `json:"created"`
+	Created  string `json:"created"` // As returned by the server, see CreatedTime
 	Size     int64  `json:"size"`
 	MimeType string `json:"mimeType"`
 	Content  string `json:"content"` // URL to download the attachment
+
+	// CreatedAt is Created parsed when the attachment is decoded, zero if it can't be parsed.
+	CreatedAt time.Time `json:"-"`
+}
+
+// Available reports if the attachment can be downloaded. Attachments of archived issues
+// or restricted by the server may be returned without a content URL.
+func (a Attachment) Available() bool {
+	return a.Content != ""
 }
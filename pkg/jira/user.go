@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -103,3 +104,62 @@ func (c *Client) userSearch(opt *UserSearchOptions, ver string) ([]*User, error)
 	}
 	return out, nil
 }
+
+// SearchUsers searches for users matching the query, eg: a display name, email or
+// user name, using v3 version of the GET /user/search endpoint.
+func (c *Client) SearchUsers(query string, maxResults int) ([]*User, error) {
+	return c.searchUsers(query, maxResults, apiVersion3)
+}
+
+// SearchUsersV2 searches for users matching the query using v2 version of the GET /user/search
+// endpoint. Jira server only supports the `username` param, which also matches display names
+// and emails, so the query is sent as the user name.
+func (c *Client) SearchUsersV2(query string, maxResults int) ([]*User, error) {
+	return c.searchUsers(query, maxResults, apiVersion2)
+}
+
+func (c *Client) searchUsers(query string, maxResults int, ver string) ([]*User, error) {
+	if query == "" {
+		return nil, ErrInvalidSearchOption
+	}
+
+	q := url.Values{}
+	if ver == apiVersion2 {
+		q.Set("username", query)
+	} else {
+		q.Set("query", query)
+	}
+	if maxResults > 0 {
+		q.Set("maxResults", strconv.Itoa(maxResults))
+	}
+	path := "/user/search?" + q.Encode()
+
+	var (
+		res *http.Response
+		err error
+	)
+	switch ver {
+	case apiVersion2:
+		res, err = c.GetV2(context.Background(), path, nil)
+	default:
+		res, err = c.Get(context.Background(), path, nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrEmptyResponse
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, formatUnexpectedResponse(res)
+	}
+
+	var out []*User
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -129,3 +129,50 @@ func TestUserSearchV2(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected, actual)
 }
+
+func TestSearchUsers(t *testing.T) {
+	cases := []struct {
+		name   string
+		search func(c *Client) ([]*User, error)
+		path   string
+		query  url.Values
+	}{
+		{
+			name:   "v3",
+			search: func(c *Client) ([]*User, error) { return c.SearchUsers("jane doe", 10) },
+			path:   "/rest/api/3/user/search",
+			query:  url.Values{"query": []string{"jane doe"}, "maxResults": []string{"10"}},
+		},
+		{
+			name:   "v2",
+			search: func(c *Client) ([]*User, error) { return c.SearchUsersV2("jane", 0) },
+			path:   "/rest/api/2/user/search",
+			query:  url.Values{"username": []string{"jane"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.path, r.URL.Path)
+				assert.Equal(t, tc.query, r.URL.Query())
+
+				resp, err := os.ReadFile("./testdata/users.json")
+				assert.NoError(t, err)
+				_, _ = w.Write(resp)
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{Server: server.URL}, WithTimeout(3*time.Second))
+
+			users, err := tc.search(client)
+			assert.NoError(t, err)
+			assert.Len(t, users, 2)
+			assert.Equal(t, "janedoe", users[0].Name)
+		})
+	}
+
+	client := NewClient(Config{Server: "http://localhost"}, WithTimeout(3*time.Second))
+	_, err := client.SearchUsers("", 0)
+	assert.ErrorIs(t, err, ErrInvalidSearchOption)
+}
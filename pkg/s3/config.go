@@ -0,0 +1,119 @@
+package s3
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadConfig returns the region, credentials and endpoint configured the standard AWS way:
+//
+//   - the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION (or
+//     AWS_DEFAULT_REGION) and AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL) variables,
+//   - else the profile named by AWS_PROFILE, "default" if unset, of the shared credentials
+//     file, ~/.aws/credentials or AWS_SHARED_CREDENTIALS_FILE, and of the shared config
+//     file, ~/.aws/config or AWS_CONFIG_FILE.
+//
+// Credentials of SSO, of instance metadata and of credential processes aren't supported.
+// New fails if no credentials were found.
+func LoadConfig() (Config, error) {
+	profile := firstEnv("AWS_PROFILE", "AWS_DEFAULT_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	section := "profile " + profile
+	if profile == "default" {
+		section = profile
+	}
+
+	config, err := readSharedFile(firstEnv("AWS_CONFIG_FILE"), "config", section)
+	if err != nil {
+		return Config{}, err
+	}
+	credentials, err := readSharedFile(firstEnv("AWS_SHARED_CREDENTIALS_FILE"), "credentials", profile)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Config{
+		Region:   firstNonEmpty(firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"), config["region"]),
+		Endpoint: firstNonEmpty(firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"), config["endpoint_url"]),
+	}
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		cfg.Credentials = Credentials{
+			AccessKeyID:     id,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		return cfg, nil
+	}
+	// Credentials may also be set in the config file.
+	for _, values := range []map[string]string{credentials, config} {
+		if values["aws_access_key_id"] != "" {
+			cfg.Credentials = Credentials{
+				AccessKeyID:     values["aws_access_key_id"],
+				SecretAccessKey: values["aws_secret_access_key"],
+				SessionToken:    values["aws_session_token"],
+			}
+			break
+		}
+	}
+	return cfg, nil
+}
+
+// readSharedFile returns the keys of a section of a shared AWS file, path or ~/.aws/name
+// if empty. A missing file has no keys.
+func readSharedFile(path, name, section string) (map[string]string, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(home, ".aws", name)
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	values := make(map[string]string)
+	var current string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			current = strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+		case current == section:
+			if k, v, ok := strings.Cut(line, "="); ok {
+				values[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+			}
+		}
+	}
+	return values, scanner.Err()
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package s3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	config := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(credentials, []byte(`
+[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[archive]
+aws_access_key_id=AKIAARCHIVE
+aws_secret_access_key=archive-secret
+aws_session_token=archive-token
+`), 0o600))
+	require.NoError(t, os.WriteFile(config, []byte(`
+# comment
+[default]
+region = eu-west-1
+
+[profile archive]
+region = eu-central-1
+endpoint_url = http://minio.local:9000
+`), 0o600))
+
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION", "AWS_DEFAULT_REGION",
+		"AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_S3", "AWS_PROFILE", "AWS_DEFAULT_PROFILE",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_CONFIG_FILE", config)
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		Credentials: Credentials{AccessKeyID: "AKIADEFAULT", SecretAccessKey: "default-secret"},
+		Region:      "eu-west-1",
+	}, cfg)
+
+	t.Setenv("AWS_PROFILE", "archive")
+	cfg, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		Credentials: Credentials{AccessKeyID: "AKIAARCHIVE", SecretAccessKey: "archive-secret", SessionToken: "archive-token"},
+		Region:      "eu-central-1",
+		Endpoint:    "http://minio.local:9000",
+	}, cfg)
+
+	// The variables take precedence over the files.
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	t.Setenv("AWS_REGION", "us-west-2")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "http://localhost:9000")
+	cfg, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		Credentials: Credentials{AccessKeyID: "AKIAENV", SecretAccessKey: "env-secret"},
+		Region:      "us-west-2",
+		Endpoint:    "http://localhost:9000",
+	}, cfg)
+
+	// Missing files have no keys.
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "missing"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "missing"))
+	cfg, err = LoadConfig()
+	require.NoError(t, err)
+	_, err = New(cfg)
+	assert.EqualError(t, err, "s3: no credentials, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or configure a profile in ~/.aws/credentials")
+}
@@ -0,0 +1,249 @@
+// Package s3 writes objects to Amazon S3 and S3-compatible object storages, eg: MinIO,
+// with requests signed with AWS Signature Version 4.
+//
+// It only implements what streaming downloads into a bucket requires: objects are written
+// as they are received with a multipart upload whose parts are buffered in memory, see
+// Client.NewWriter.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPartSize is the size of the parts of a multipart upload.
+	DefaultPartSize = 8 << 20
+	// MinPartSize is the smallest part S3 accepts, except for the last part of an upload.
+	MinPartSize = 5 << 20
+
+	// DefaultRegion is the region used if none is configured.
+	DefaultRegion = "us-east-1"
+)
+
+// Credentials are the AWS credentials requests are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials.
+	SessionToken string
+}
+
+// Config configures a Client.
+type Config struct {
+	Credentials
+
+	Region string
+	// Endpoint is the URL of an S3-compatible service, eg: http://localhost:9000 for MinIO.
+	// Its buckets are addressed in the path. Amazon S3 is used if it is empty.
+	Endpoint string
+	// PartSize is the size of the parts of multipart uploads, DefaultPartSize if not set.
+	// S3 rejects parts smaller than MinPartSize, except for the last one.
+	PartSize int
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Client writes objects to S3.
+type Client struct {
+	cfg      Config
+	endpoint *url.URL
+	http     *http.Client
+	now      func() time.Time
+}
+
+// New returns a client for the configuration.
+func New(cfg Config) (*Client, error) {
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3: no credentials, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or configure a profile in ~/.aws/credentials")
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	if cfg.PartSize <= 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+
+	c := Client{cfg: cfg, http: cfg.HTTPClient, now: time.Now}
+	if c.http == nil {
+		c.http = http.DefaultClient
+	}
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("s3: invalid endpoint %q, expected a URL like http://localhost:9000", cfg.Endpoint)
+		}
+		c.endpoint = u
+	}
+	return &c, nil
+}
+
+// Object is an object written to S3.
+type Object struct {
+	Bucket string
+	Key    string
+	// ETag is the entity tag S3 assigned to the object, without quotes.
+	ETag string
+	Size int64
+}
+
+// PutObject writes body as the object at key in bucket with a single request.
+func (c *Client) PutObject(ctx context.Context, bucket, key, contentType string, body []byte) (*Object, error) {
+	header := make(http.Header)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	res, err := c.do(ctx, http.MethodPut, bucket, key, nil, header, body)
+	if err != nil {
+		return nil, err
+	}
+	_ = res.Body.Close()
+	return &Object{Bucket: bucket, Key: key, ETag: unquote(res.Header.Get("ETag")), Size: int64(len(body))}, nil
+}
+
+// do sends a signed request for the object and returns the response if its status is
+// successful, an *Error otherwise.
+func (c *Client) do(
+	ctx context.Context, method, bucket, key string, query url.Values, header http.Header, body []byte,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, "", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.URL = c.objectURL(bucket, key, query)
+	req.Host = req.URL.Host
+	req.ContentLength = int64(len(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	sign(req, c.cfg.Credentials, c.cfg.Region, hashHex(body), c.now())
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 300 {
+		defer func() { _ = res.Body.Close() }()
+		return nil, responseError(res, bucket, key)
+	}
+	return res, nil
+}
+
+// objectURL returns the URL of the object. Buckets are addressed in the host name on
+// Amazon S3, unless their name has dots that TLS wildcard certificates don't cover, and
+// in the path on other endpoints.
+func (c *Client) objectURL(bucket, key string, query url.Values) *url.URL {
+	var u url.URL
+	switch {
+	case c.endpoint != nil:
+		u = *c.endpoint
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + bucket + "/" + key
+	case strings.Contains(bucket, "."):
+		u = url.URL{Scheme: "https", Host: "s3." + c.cfg.Region + ".amazonaws.com", Path: "/" + bucket + "/" + key}
+	default:
+		u = url.URL{Scheme: "https", Host: bucket + ".s3." + c.cfg.Region + ".amazonaws.com", Path: "/" + key}
+	}
+	u.RawPath = escape(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// Error is an error response of S3.
+type Error struct {
+	StatusCode int
+	// Code is the S3 error code, eg: NoSuchBucket or AccessDenied.
+	Code    string
+	Message string
+	Bucket  string
+	Key     string
+}
+
+func (e *Error) Error() string {
+	switch e.Code {
+	case "NoSuchBucket":
+		return fmt.Sprintf("s3: bucket %q doesn't exist", e.Bucket)
+	case "AccessDenied":
+		return fmt.Sprintf("s3: access denied to s3://%s/%s, check the permissions of the credentials on the bucket", e.Bucket, e.Key)
+	case "InvalidAccessKeyId", "SignatureDoesNotMatch":
+		return fmt.Sprintf("s3: the credentials were rejected (%s), check the access key and the secret", e.Code)
+	}
+	msg := fmt.Sprintf("s3: %s (%d)", e.Code, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// errorBody is the XML body of S3 error responses.
+type errorBody struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func responseError(res *http.Response, bucket, key string) error {
+	e := Error{StatusCode: res.StatusCode, Bucket: bucket, Key: key}
+	var body errorBody
+	if b, err := io.ReadAll(io.LimitReader(res.Body, 64<<10)); err == nil && xml.Unmarshal(b, &body) == nil {
+		e.Code, e.Message = body.Code, body.Message
+	}
+	if e.Code == "" {
+		e.Code = strings.ReplaceAll(http.StatusText(res.StatusCode), " ", "")
+	}
+	return &e
+}
+
+// Location is a bucket and a prefix of the keys of its objects, written s3://bucket/prefix/.
+type Location struct {
+	Bucket string
+	// Prefix has no leading or trailing slash, it is empty for the root of the bucket.
+	Prefix string
+}
+
+var bucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// ParseURL parses an s3://bucket/prefix/ URL.
+func ParseURL(raw string) (Location, error) {
+	rest, ok := strings.CutPrefix(raw, "s3://")
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if !ok || !bucketPattern.MatchString(bucket) {
+		return Location{}, fmt.Errorf("invalid S3 URL %q, expected s3://bucket/prefix/", raw)
+	}
+	return Location{Bucket: bucket, Prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// Key returns the key of an object under the prefix, the elements joined with slashes.
+func (l Location) Key(elem ...string) string {
+	parts := make([]string, 0, len(elem)+1)
+	for _, e := range append([]string{l.Prefix}, elem...) {
+		if e != "" {
+			parts = append(parts, e)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// URL returns the s3:// URL of the object at key.
+func (l Location) URL(key string) string {
+	return "s3://" + l.Bucket + "/" + key
+}
+
+func (l Location) String() string {
+	if l.Prefix == "" {
+		return "s3://" + l.Bucket + "/"
+	}
+	return "s3://" + l.Bucket + "/" + l.Prefix + "/"
+}
+
+func unquote(etag string) string {
+	return strings.Trim(etag, `"`)
+}
@@ -0,0 +1,353 @@
+// Package s3test provides an in-process fake S3 server implementing the object and
+// multipart upload requests used by package s3, for tests that shouldn't depend on a real
+// object storage.
+//
+// Buckets are addressed in the path, like on MinIO. The server checks the access key of
+// the requests and the hash of their payload, not their signature.
+package s3test
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // Only used to derive ETags and check parts, as S3 does.
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/s3"
+)
+
+const (
+	// AccessKeyID is the access key accepted by the server.
+	AccessKeyID = "AKIAS3TESTEXAMPLE"
+	// SecretAccessKey is the secret of AccessKeyID.
+	SecretAccessKey = "s3test-secret"
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithBuckets creates empty buckets.
+func WithBuckets(names ...string) Option {
+	return func(s *Server) {
+		for _, name := range names {
+			s.buckets[name] = make(map[string]*object)
+		}
+	}
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	// Path is the unescaped path, /bucket/key.
+	Path  string
+	Query string
+}
+
+// Server is a fake S3 server.
+type Server struct {
+	// URL is the endpoint of a server started with NewServer.
+	URL string
+
+	ts *httptest.Server
+
+	mu       sync.Mutex
+	buckets  map[string]map[string]*object
+	denied   map[string]bool
+	failPart int
+	uploads  map[string]*upload
+	nextID   int
+	aborted  int
+	requests []Request
+}
+
+type object struct {
+	content     []byte
+	contentType string
+	etag        string
+}
+
+type upload struct {
+	bucket      string
+	key         string
+	contentType string
+	parts       map[int][]byte
+}
+
+// New returns a server that is not listening yet. Use NewServer to start an in-process server.
+func New(opts ...Option) *Server {
+	s := &Server{
+		buckets: make(map[string]map[string]*object),
+		denied:  make(map[string]bool),
+		uploads: make(map[string]*upload),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewServer starts a server listening on a local port.
+func NewServer(opts ...Option) *Server {
+	s := New(opts...)
+	s.ts = httptest.NewServer(s)
+	s.URL = s.ts.URL
+	return s
+}
+
+// Close shuts down a server started with NewServer.
+func (s *Server) Close() {
+	if s.ts != nil {
+		s.ts.Close()
+	}
+}
+
+// Config returns a client configuration for the server with its credentials.
+func (s *Server) Config() s3.Config {
+	return s3.Config{
+		Credentials: s3.Credentials{AccessKeyID: AccessKeyID, SecretAccessKey: SecretAccessKey},
+		Endpoint:    s.URL,
+	}
+}
+
+// Deny makes the server reject every request to the bucket with AccessDenied.
+func (s *Server) Deny(bucket string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.denied[bucket] = true
+}
+
+// FailPart makes the server fail the upload of the part with the given number, 0 to stop.
+func (s *Server) FailPart(number int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failPart = number
+}
+
+// Object returns the content of an object and its ETag, without quotes.
+func (s *Server) Object(bucket, key string) ([]byte, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, "", false
+	}
+	return append([]byte(nil), o.content...), strings.Trim(o.etag, `"`), true
+}
+
+// ContentType returns the content type of an object.
+func (s *Server) ContentType(bucket, key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if o, ok := s.buckets[bucket][key]; ok {
+		return o.contentType
+	}
+	return ""
+}
+
+// Keys returns the sorted keys of the objects of a bucket.
+func (s *Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for k := range s.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PendingUploads returns the number of multipart uploads neither completed nor aborted.
+func (s *Server) PendingUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.uploads)
+}
+
+// AbortedUploads returns the number of multipart uploads aborted.
+func (s *Server) AbortedUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.aborted
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery})
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+AccessKeyID+"/") {
+		writeError(w, http.StatusForbidden, "InvalidAccessKeyId", "The AWS access key Id you provided does not exist in our records.")
+		return
+	}
+	if sum := sha256.Sum256(body); r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.")
+		return
+	}
+	if s.denied[bucket] {
+		writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied")
+		return
+	}
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	switch {
+	case r.Method == http.MethodPut && !query.Has("uploadId"):
+		o := &object{content: body, contentType: r.Header.Get("Content-Type"), etag: etag(body)}
+		objects[key] = o
+		w.Header().Set("ETag", o.etag)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.nextID++
+		id := fmt.Sprintf("upload-%d", s.nextID)
+		s.uploads[id] = &upload{bucket: bucket, key: key, contentType: r.Header.Get("Content-Type"), parts: make(map[int][]byte)}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadID string `xml:"UploadId"`
+		}{Bucket: bucket, Key: key, UploadID: id})
+	case r.Method == http.MethodPut:
+		s.uploadPart(w, r, s.uploads[uploadID], body)
+	case r.Method == http.MethodPost:
+		s.complete(w, uploadID, objects, body)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		if _, ok := s.uploads[uploadID]; !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.")
+			return
+		}
+		delete(s.uploads, uploadID)
+		s.aborted++
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "A header you provided implies functionality that is not implemented")
+	}
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, u *upload, body []byte) {
+	if u == nil {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.")
+		return
+	}
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number < 1 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "Part number must be an integer between 1 and 10000, inclusive")
+		return
+	}
+	sum := md5.Sum(body) //nolint:gosec
+	if md := r.Header.Get("Content-MD5"); md != "" && md != base64.StdEncoding.EncodeToString(sum[:]) {
+		writeError(w, http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received.")
+		return
+	}
+	if number == s.failPart {
+		writeError(w, http.StatusInternalServerError, "InternalError", "We encountered an internal error. Please try again.")
+		return
+	}
+	u.parts[number] = body
+	w.Header().Set("ETag", etag(body))
+}
+
+func (s *Server) complete(w http.ResponseWriter, uploadID string, objects map[string]*object, body []byte) {
+	u, ok := s.uploads[uploadID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.")
+		return
+	}
+	var req struct {
+		Parts []struct {
+			PartNumber int
+			ETag       string
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &req); err != nil || len(req.Parts) == 0 {
+		writeError(w, http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed.")
+		return
+	}
+
+	var (
+		content []byte
+		sums    []byte
+	)
+	for i, p := range req.Parts {
+		part, ok := u.parts[p.PartNumber]
+		if !ok || p.PartNumber != i+1 || p.ETag != etag(part) {
+			writeError(w, http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found.")
+			return
+		}
+		if i < len(req.Parts)-1 && len(part) < s3.MinPartSize {
+			writeError(w, http.StatusBadRequest, "EntityTooSmall", "Your proposed upload is smaller than the minimum allowed object size.")
+			return
+		}
+		content = append(content, part...)
+		sum := md5.Sum(part) //nolint:gosec
+		sums = append(sums, sum[:]...)
+	}
+	sum := md5.Sum(sums) //nolint:gosec
+	o := &object{content: content, contentType: u.contentType, etag: fmt.Sprintf(`"%x-%d"`, sum, len(req.Parts))}
+	objects[u.key] = o
+	delete(s.uploads, uploadID)
+
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Bucket: u.bucket, Key: u.key, ETag: o.etag})
+}
+
+func etag(b []byte) string {
+	return fmt.Sprintf(`"%x"`, md5.Sum(b)) //nolint:gosec
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	_ = xml.NewEncoder(&buf).Encode(v)
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: message})
+}
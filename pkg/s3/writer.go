@@ -0,0 +1,225 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // S3 checks parts with MD5, it isn't used for security.
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// abortTimeout bounds the request aborting a multipart upload, sent even if the context
+// of the writer is cancelled.
+const abortTimeout = 30 * time.Second
+
+var (
+	// ErrAborted is returned by the writes of a Writer after Abort.
+	ErrAborted = errors.New("s3: upload aborted")
+
+	errCommitted = errors.New("s3: upload already committed")
+)
+
+// Writer writes an object as the content is written to it, see Client.NewWriter.
+type Writer struct {
+	c           *Client
+	ctx         context.Context
+	bucket      string
+	key         string
+	contentType string
+
+	buf      []byte
+	uploadID string
+	parts    []completedPart
+	size     int64
+	// err fails the next writes, set on failure, once committed or aborted.
+	err error
+}
+
+// NewWriter returns a writer of the object at key in bucket. The content is buffered in
+// parts of Config.PartSize, each uploaded as part of a multipart upload as soon as it is
+// full, so that at most one part is held in memory. A content smaller than a part is
+// written with a single request. The object only exists once Commit succeeds, a failed
+// write or Abort discard the parts already uploaded.
+func (c *Client) NewWriter(ctx context.Context, bucket, key, contentType string) *Writer {
+	return &Writer{c: c, ctx: ctx, bucket: bucket, key: key, contentType: contentType}
+}
+
+// Write buffers p and uploads the parts it fills.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	var n int
+	for len(p) > 0 {
+		k := min(len(p), w.c.cfg.PartSize-len(w.buf))
+		w.buf = append(w.buf, p[:k]...)
+		p, n = p[k:], n+k
+		if len(w.buf) == w.c.cfg.PartSize {
+			if err := w.uploadPart(); err != nil {
+				return n, w.fail(err)
+			}
+		}
+	}
+	return n, nil
+}
+
+// Commit uploads what is left of the content and completes the object.
+func (w *Writer) Commit() (*Object, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	if w.uploadID == "" {
+		obj, err := w.c.PutObject(w.ctx, w.bucket, w.key, w.contentType, w.buf)
+		if err != nil {
+			w.err = err
+			return nil, err
+		}
+		w.err = errCommitted
+		return obj, nil
+	}
+
+	if len(w.buf) > 0 {
+		if err := w.uploadPart(); err != nil {
+			return nil, w.fail(err)
+		}
+	}
+	etag, err := w.complete()
+	if err != nil {
+		return nil, w.fail(err)
+	}
+	w.err = errCommitted
+	return &Object{Bucket: w.bucket, Key: w.key, ETag: etag, Size: w.size}, nil
+}
+
+// Abort discards the parts uploaded so far, the object isn't created.
+func (w *Writer) Abort() error {
+	if w.err == nil {
+		w.err = ErrAborted
+	}
+	w.buf = nil
+	if w.uploadID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), abortTimeout)
+	defer cancel()
+
+	uploadID := w.uploadID
+	w.uploadID = ""
+	res, err := w.c.do(ctx, http.MethodDelete, w.bucket, w.key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	return nil
+}
+
+// fail aborts the upload after err and returns err.
+func (w *Writer) fail(err error) error {
+	w.err = err
+	_ = w.Abort()
+	return err
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (w *Writer) uploadPart() error {
+	if w.uploadID == "" {
+		if err := w.create(); err != nil {
+			return err
+		}
+	}
+
+	sum := md5.Sum(w.buf) //nolint:gosec
+	header := make(http.Header)
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+
+	number := len(w.parts) + 1
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {w.uploadID}}
+	res, err := w.c.do(w.ctx, http.MethodPut, w.bucket, w.key, query, header, w.buf)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+
+	w.parts = append(w.parts, completedPart{PartNumber: number, ETag: res.Header.Get("ETag")})
+	w.size += int64(len(w.buf))
+	w.buf = w.buf[:0]
+	return nil
+}
+
+func (w *Writer) create() error {
+	header := make(http.Header)
+	if w.contentType != "" {
+		header.Set("Content-Type", w.contentType)
+	}
+	res, err := w.c.do(w.ctx, http.MethodPost, w.bucket, w.key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	var out struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := decodeXML(res.Body, &out); err != nil {
+		return err
+	}
+	if out.UploadID == "" {
+		return errors.New("s3: no upload id in the response to the creation of the multipart upload")
+	}
+	w.uploadID = out.UploadID
+	return nil
+}
+
+func (w *Writer) complete() (string, error) {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: w.parts})
+	if err != nil {
+		return "", err
+	}
+
+	res, err := w.c.do(w.ctx, http.MethodPost, w.bucket, w.key, url.Values{"uploadId": {w.uploadID}}, nil, body)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	// S3 may report an error in the body of a 200 response once it started to respond.
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	var failure errorBody
+	if xml.Unmarshal(b, &failure) == nil && failure.Code != "" {
+		return "", &Error{StatusCode: res.StatusCode, Code: failure.Code, Message: failure.Message, Bucket: w.bucket, Key: w.key}
+	}
+	var out struct {
+		ETag string `xml:"ETag"`
+	}
+	if err := decodeXML(bytes.NewReader(b), &out); err != nil {
+		return "", err
+	}
+	w.uploadID = ""
+	return unquote(out.ETag), nil
+}
+
+func decodeXML(r io.Reader, v any) error {
+	if err := xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("s3: invalid response: %w", err)
+	}
+	return nil
+}
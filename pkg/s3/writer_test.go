@@ -0,0 +1,183 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/s3"
+	"github.com/ankitpokhrel/jira-cli/pkg/s3/s3test"
+)
+
+func newClient(t *testing.T, server *s3test.Server) *s3.Client {
+	t.Helper()
+
+	cfg := server.Config()
+	cfg.PartSize = s3.MinPartSize
+	c, err := s3.New(cfg)
+	require.NoError(t, err)
+	return c
+}
+
+// writeInChunks writes content in chunks of an odd size, as an HTTP body is read.
+func writeInChunks(w *s3.Writer, content []byte) error {
+	for len(content) > 0 {
+		n := min(len(content), 32*1024+7)
+		if _, err := w.Write(content[:n]); err != nil {
+			return err
+		}
+		content = content[n:]
+	}
+	return nil
+}
+
+func TestWriterSmallObject(t *testing.T) {
+	t.Parallel()
+
+	server := s3test.NewServer(s3test.WithBuckets("archive"))
+	t.Cleanup(server.Close)
+
+	w := newClient(t, server).NewWriter(context.Background(), "archive", "jira/TEST-1/report (1).pdf", "application/pdf")
+	require.NoError(t, writeInChunks(w, []byte("%PDF-1.7")))
+	obj, err := w.Commit()
+	require.NoError(t, err)
+
+	content, etag, ok := server.Object("archive", "jira/TEST-1/report (1).pdf")
+	require.True(t, ok)
+	assert.Equal(t, "%PDF-1.7", string(content))
+	assert.Equal(t, etag, obj.ETag)
+	assert.Equal(t, int64(8), obj.Size)
+	assert.Equal(t, "application/pdf", server.ContentType("archive", "jira/TEST-1/report (1).pdf"))
+	assert.Len(t, server.Requests(), 1, "a content smaller than a part is written with a single request")
+}
+
+func TestWriterMultipart(t *testing.T) {
+	t.Parallel()
+
+	server := s3test.NewServer(s3test.WithBuckets("archive"))
+	t.Cleanup(server.Close)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), (2*s3.MinPartSize+1024)/16)
+	w := newClient(t, server).NewWriter(context.Background(), "archive", "backup.tar", "")
+	require.NoError(t, writeInChunks(w, content))
+	obj, err := w.Commit()
+	require.NoError(t, err)
+
+	got, etag, ok := server.Object("archive", "backup.tar")
+	require.True(t, ok)
+	assert.Equal(t, content, got)
+	assert.Equal(t, etag, obj.ETag)
+	assert.True(t, strings.HasSuffix(obj.ETag, "-3"), obj.ETag)
+	assert.Equal(t, int64(len(content)), obj.Size)
+	assert.Zero(t, server.PendingUploads())
+}
+
+func TestWriterErrors(t *testing.T) {
+	t.Parallel()
+
+	server := s3test.NewServer(s3test.WithBuckets("archive", "locked"))
+	t.Cleanup(server.Close)
+	server.Deny("locked")
+
+	client := newClient(t, server)
+
+	w := client.NewWriter(context.Background(), "missing", "a.txt", "")
+	require.NoError(t, writeInChunks(w, []byte("a")))
+	_, err := w.Commit()
+	assert.EqualError(t, err, `s3: bucket "missing" doesn't exist`)
+	var s3Err *s3.Error
+	require.True(t, errors.As(err, &s3Err))
+	assert.Equal(t, "NoSuchBucket", s3Err.Code)
+
+	w = client.NewWriter(context.Background(), "locked", "a.txt", "")
+	require.NoError(t, writeInChunks(w, []byte("a")))
+	_, err = w.Commit()
+	assert.EqualError(t, err, "s3: access denied to s3://locked/a.txt, check the permissions of the credentials on the bucket")
+
+	cfg := server.Config()
+	cfg.SecretAccessKey, cfg.AccessKeyID = "wrong", "AKIAWRONG"
+	wrong, err := s3.New(cfg)
+	require.NoError(t, err)
+	_, err = wrong.PutObject(context.Background(), "archive", "a.txt", "", []byte("a"))
+	assert.EqualError(t, err, "s3: the credentials were rejected (InvalidAccessKeyId), check the access key and the secret")
+}
+
+func TestWriterAbortsOnFailure(t *testing.T) {
+	t.Parallel()
+
+	server := s3test.NewServer(s3test.WithBuckets("archive"))
+	t.Cleanup(server.Close)
+	server.FailPart(2)
+
+	content := bytes.Repeat([]byte("x"), 3*s3.MinPartSize)
+	w := newClient(t, server).NewWriter(context.Background(), "archive", "big.bin", "")
+	err := writeInChunks(w, content)
+	assert.EqualError(t, err, "s3: InternalError (500): We encountered an internal error. Please try again.")
+
+	_, err = w.Write([]byte("more"))
+	assert.Error(t, err, "writes fail once an upload failed")
+	_, err = w.Commit()
+	assert.Error(t, err)
+
+	_, _, ok := server.Object("archive", "big.bin")
+	assert.False(t, ok)
+	assert.Zero(t, server.PendingUploads())
+	assert.Equal(t, 1, server.AbortedUploads())
+}
+
+func TestWriterAbort(t *testing.T) {
+	t.Parallel()
+
+	server := s3test.NewServer(s3test.WithBuckets("archive"))
+	t.Cleanup(server.Close)
+
+	// The source of the content fails after a part was uploaded, eg: a download cut off.
+	ctx, cancel := context.WithCancel(context.Background())
+	w := newClient(t, server).NewWriter(ctx, "archive", "big.bin", "")
+	require.NoError(t, writeInChunks(w, bytes.Repeat([]byte("x"), s3.MinPartSize+10)))
+	cancel()
+
+	assert.NoError(t, w.Abort(), "the upload is aborted even if the context is cancelled")
+	_, err := w.Commit()
+	assert.ErrorIs(t, err, s3.ErrAborted)
+
+	_, _, ok := server.Object("archive", "big.bin")
+	assert.False(t, ok)
+	assert.Zero(t, server.PendingUploads())
+	assert.Equal(t, 1, server.AbortedUploads())
+}
+
+func TestParseURL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		raw    string
+		want   s3.Location
+		key    string
+		errMsg string
+	}{
+		{raw: "s3://archive/jira/attachments/", want: s3.Location{Bucket: "archive", Prefix: "jira/attachments"}, key: "jira/attachments/TEST-1/a.pdf"},
+		{raw: "s3://archive/jira", want: s3.Location{Bucket: "archive", Prefix: "jira"}, key: "jira/TEST-1/a.pdf"},
+		{raw: "s3://archive", want: s3.Location{Bucket: "archive"}, key: "TEST-1/a.pdf"},
+		{raw: "s3://archive/", want: s3.Location{Bucket: "archive"}, key: "TEST-1/a.pdf"},
+		{raw: "archive/jira", errMsg: `invalid S3 URL "archive/jira", expected s3://bucket/prefix/`},
+		{raw: "s3://Archive/jira", errMsg: `invalid S3 URL "s3://Archive/jira", expected s3://bucket/prefix/`},
+		{raw: "s3:///jira", errMsg: `invalid S3 URL "s3:///jira", expected s3://bucket/prefix/`},
+	}
+
+	for _, tc := range cases {
+		loc, err := s3.ParseURL(tc.raw)
+		if tc.errMsg != "" {
+			assert.EqualError(t, err, tc.errMsg)
+			continue
+		}
+		require.NoError(t, err, tc.raw)
+		assert.Equal(t, tc.want, loc)
+		assert.Equal(t, tc.key, loc.Key("TEST-1", "a.pdf"))
+	}
+}
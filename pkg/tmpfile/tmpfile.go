@@ -0,0 +1,156 @@
+// Package tmpfile names the temporary files jira-cli writes next to their destination, eg:
+// while converting a download in place, so that the leftovers of a crashed or killed run
+// can be recognized and cleaned up by a later one.
+//
+// A temporary file is named Prefix + "<pid>-<name>.<random>" + Suffix in the directory of
+// its destination, eg: .jira-cli-4242-report.pdf.123456.partial, where pid is the process
+// writing it and name the base name of the destination.
+package tmpfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankitpokhrel/jira-cli/pkg/dirlock"
+)
+
+const (
+	// Prefix starts the name of every temporary file.
+	Prefix = ".jira-cli-"
+	// Suffix ends the name of every temporary file.
+	Suffix = ".partial"
+
+	// DefaultMaxAge is how old a temporary file must be to be cleaned up.
+	DefaultMaxAge = 24 * time.Hour
+)
+
+// Create creates a temporary file for the destination path in its directory.
+func Create(path string) (*os.File, error) {
+	return os.CreateTemp(filepath.Dir(path), pattern(os.Getpid(), filepath.Base(path)))
+}
+
+func pattern(pid int, name string) string {
+	return fmt.Sprintf("%s%d-%s.*%s", Prefix, pid, name, Suffix)
+}
+
+// Parse reports if name is the name of a temporary file and returns the pid of the
+// process that created it.
+func Parse(name string) (int, bool) {
+	if !strings.HasPrefix(name, Prefix) || !strings.HasSuffix(name, Suffix) {
+		return 0, false
+	}
+	rest := strings.TrimSuffix(strings.TrimPrefix(name, Prefix), Suffix)
+	pidStr, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
+
+// Leftover is a temporary file left behind by an earlier run.
+type Leftover struct {
+	Path    string
+	PID     int
+	Size    int64
+	ModTime time.Time
+}
+
+// Options configures Clean.
+type Options struct {
+	// MaxAge is how long a temporary file must not have been modified for to be removed,
+	// DefaultMaxAge if zero.
+	MaxAge time.Duration
+	// DryRun lists the leftovers without removing them.
+	DryRun bool
+	// Notify is called with a notice for every file removed, or that would be with DryRun.
+	Notify func(msg string)
+
+	alive func(pid int) bool
+	now   func() time.Time
+}
+
+// Clean removes the temporary files in dir, not in its subdirectories, that are older
+// than the max age and whose process isn't running anymore, and returns them. A file of a
+// running process is kept whatever its age, eg: a long download, and so is a recent file
+// of a process that is gone, as its pid may be reused by a run that is just starting.
+func Clean(dir string, opts Options) ([]Leftover, error) {
+	if opts.MaxAge == 0 {
+		opts.MaxAge = DefaultMaxAge
+	}
+	if opts.alive == nil {
+		opts.alive = dirlock.ProcessAlive
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+
+	leftovers, err := find(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]Leftover, 0, len(leftovers))
+	for _, l := range leftovers {
+		if !opts.DryRun {
+			if err := os.Remove(l.Path); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return removed, err
+			}
+		}
+		removed = append(removed, l)
+		if opts.Notify != nil {
+			verb := "Removed"
+			if opts.DryRun {
+				verb = "Would remove"
+			}
+			opts.Notify(fmt.Sprintf("%s temporary file %s left by pid %d, last modified %s",
+				verb, l.Path, l.PID, l.ModTime.Format(time.RFC3339)))
+		}
+	}
+	return removed, nil
+}
+
+// find lists the temporary files in dir that can be removed.
+func find(dir string, opts Options) ([]Leftover, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := opts.now().Add(-opts.MaxAge)
+	var out []Leftover
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		pid, ok := Parse(e.Name())
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(cutoff) || opts.alive(pid) {
+			continue
+		}
+		out = append(out, Leftover{Path: filepath.Join(dir, e.Name()), PID: pid, Size: info.Size(), ModTime: info.ModTime()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
@@ -0,0 +1,175 @@
+package tmpfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// seed creates a file in dir last modified age ago.
+func seed(t *testing.T, dir, name string, age time.Duration) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("partial"), 0o600))
+	mtime := now.Add(-age)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	return path
+}
+
+func alivePIDs(pids ...int) func(int) bool {
+	return func(pid int) bool {
+		for _, p := range pids {
+			if p == pid {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	f, err := Create(filepath.Join(dir, "report.pdf"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	assert.Equal(t, dir, filepath.Dir(f.Name()))
+	name := filepath.Base(f.Name())
+	assert.True(t, strings.HasPrefix(name, Prefix), name)
+	assert.True(t, strings.HasSuffix(name, Suffix), name)
+	assert.Contains(t, name, "-report.pdf.")
+
+	pid, ok := Parse(name)
+	assert.True(t, ok)
+	assert.Equal(t, os.Getpid(), pid)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		wantPID int
+		wantOK  bool
+	}{
+		{".jira-cli-4242-report.pdf.123456.partial", 4242, true},
+		{".jira-cli-7-a-b-c.txt.1.partial", 7, true},
+		{"report.pdf", 0, false},
+		{".jira-cli.lock", 0, false},
+		{".jira-cli-4242-report.pdf.123456", 0, false},
+		{".jira-cli-abc-report.pdf.1.partial", 0, false},
+		{".jira-cli-0-report.pdf.1.partial", 0, false},
+		{".jira-cli-4242.partial", 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pid, ok := Parse(tc.name)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantPID, pid)
+		})
+	}
+}
+
+func TestCleanAge(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	stale := seed(t, dir, ".jira-cli-100-a.txt.1.partial", 25*time.Hour)
+	fresh := seed(t, dir, ".jira-cli-101-b.txt.2.partial", time.Hour)
+	other := seed(t, dir, "c.txt", 48*time.Hour)
+
+	removed, err := Clean(dir, Options{alive: alivePIDs(), now: func() time.Time { return now }})
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, stale, removed[0].Path)
+	assert.Equal(t, 100, removed[0].PID)
+	assert.EqualValues(t, len("partial"), removed[0].Size)
+
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, fresh)
+	assert.FileExists(t, other)
+
+	// A shorter max age also removes the recent file.
+	removed, err = Clean(dir, Options{MaxAge: 30 * time.Minute, alive: alivePIDs(), now: func() time.Time { return now }})
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.NoFileExists(t, fresh)
+	assert.FileExists(t, other)
+}
+
+func TestCleanKeepsFilesOfLiveProcesses(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	live := seed(t, dir, ".jira-cli-200-a.txt.1.partial", 72*time.Hour)
+	dead := seed(t, dir, ".jira-cli-201-b.txt.2.partial", 72*time.Hour)
+
+	removed, err := Clean(dir, Options{alive: alivePIDs(200), now: func() time.Time { return now }})
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, dead, removed[0].Path)
+	assert.FileExists(t, live)
+	assert.NoFileExists(t, dead)
+}
+
+func TestCleanDryRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := seed(t, dir, ".jira-cli-300-a.txt.1.partial", 30*time.Hour)
+	b := seed(t, dir, ".jira-cli-301-b.txt.2.partial", 40*time.Hour)
+	seed(t, dir, ".jira-cli-302-c.txt.3.partial", time.Minute)
+
+	var notices []string
+	removed, err := Clean(dir, Options{
+		DryRun: true,
+		Notify: func(msg string) { notices = append(notices, msg) },
+		alive:  alivePIDs(),
+		now:    func() time.Time { return now },
+	})
+	require.NoError(t, err)
+	require.Len(t, removed, 2)
+	assert.Equal(t, a, removed[0].Path)
+	assert.Equal(t, b, removed[1].Path)
+	assert.FileExists(t, a)
+	assert.FileExists(t, b)
+
+	require.Len(t, notices, 2)
+	assert.Equal(t, "Would remove temporary file "+a+" left by pid 300, last modified "+
+		now.Add(-30*time.Hour).Local().Format(time.RFC3339), notices[0])
+}
+
+func TestCleanSkipsSubdirectories(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "TEST-1")
+	require.NoError(t, os.Mkdir(sub, 0o700))
+	nested := seed(t, sub, ".jira-cli-400-a.txt.1.partial", 72*time.Hour)
+
+	removed, err := Clean(dir, Options{alive: alivePIDs(), now: func() time.Time { return now }})
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+	assert.FileExists(t, nested)
+}
+
+func TestCleanMissingDir(t *testing.T) {
+	t.Parallel()
+
+	removed, err := Clean(filepath.Join(t.TempDir(), "missing"), Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, removed)
+}
@@ -42,6 +42,11 @@ type CopyFunc func(row, column int, data interface{})
 // CopyKeyFunc is fired when a user press 'CTRL+K' character in the table cell.
 type CopyKeyFunc func(row, column int, data interface{})
 
+// DownloadFunc is fired when a user press 'a' character in the table cell.
+// The returned func is run in the background and the message it
+// returns is displayed in the footer once it completes.
+type DownloadFunc func(row, column int, data interface{}) func() string
+
 // TableData is the data to be displayed in a table.
 type TableData [][]string
 
@@ -102,6 +107,7 @@ type Table struct {
 	refreshFunc  RefreshFunc
 	copyFunc     CopyFunc
 	copyKeyFunc  CopyKeyFunc
+	downloadFunc DownloadFunc
 }
 
 // TableOption is a functional option to wrap table properties.
@@ -214,6 +220,13 @@ func WithCopyKeyFunc(fn CopyKeyFunc) TableOption {
 	}
 }
 
+// WithDownloadFunc sets a func that is triggered when a user press 'a'.
+func WithDownloadFunc(fn DownloadFunc) TableOption {
+	return func(t *Table) {
+		t.downloadFunc = fn
+	}
+}
+
 // WithFixedColumns sets the number of columns that are locked (do not scroll right).
 func WithFixedColumns(cols uint) TableOption {
 	return func(t *Table) {
@@ -299,6 +312,20 @@ func (t *Table) initTable() {
 					}
 					r, c := t.view.GetSelection()
 					t.copyFunc(r, c, t.data)
+				case 'a':
+					if t.downloadFunc == nil {
+						break
+					}
+					r, c := t.view.GetSelection()
+					run := t.downloadFunc(r, c, t.data)
+
+					t.footer.SetText(pad("Downloading attachments in the background...", 1))
+					go func() {
+						msg := run()
+						t.screen.QueueUpdateDraw(func() {
+							t.footer.SetText(pad(msg, 1))
+						})
+					}()
 				case 'v':
 					if t.viewModeFunc == nil {
 						break